@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"maps"
 
 	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
 	"github.com/solo-io/gloo/projects/gateway2/deployer"
@@ -11,16 +12,20 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	apiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	apiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	apiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
@@ -32,6 +37,13 @@ type GatewayConfig struct {
 	AutoProvision  bool
 	Kick           func(ctx context.Context)
 
+	// AutoServiceType enables falling back to NodePort proxy Services in clusters without load balancer support
+	AutoServiceType bool
+	// XdsTLS configures mTLS between the proxies and the control plane
+	XdsTLS deployer.XdsTLSConfig
+	// ImageRegistryOverride replaces the registry host of the proxy image
+	ImageRegistryOverride string
+
 	ControlPlane bootstrap.ControlPlane
 	IstioValues  bootstrap.IstioValues
 }
@@ -53,9 +65,12 @@ func NewBaseGatewayController(ctx context.Context, cfg GatewayConfig) error {
 		controllerBuilder.watchGwClass,
 		controllerBuilder.watchGw,
 		controllerBuilder.watchHttpRoute,
+		controllerBuilder.watchTlsRoute,
+		controllerBuilder.watchTcpRoute,
 		controllerBuilder.watchReferenceGrant,
 		controllerBuilder.watchNamespaces,
 		controllerBuilder.watchRouteOptions,
+		controllerBuilder.watchServices,
 		controllerBuilder.addIndexes,
 	)
 
@@ -78,10 +93,27 @@ type controllerBuilder struct {
 
 func (c *controllerBuilder) addIndexes(ctx context.Context) error {
 	return query.IterateIndices(func(obj client.Object, field string, indexer client.IndexerFunc) error {
+		if served, err := c.isKindServed(obj); err != nil || !served {
+			return err
+		}
 		return c.cfg.Mgr.GetFieldIndexer().IndexField(ctx, obj, field, indexer)
 	})
 }
 
+// isKindServed returns false if the kind of the object is not served by the cluster,
+// e.g. because only the standard channel CRDs of the Gateway API are installed
+func (c *controllerBuilder) isKindServed(obj client.Object) (bool, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.cfg.Mgr.GetScheme())
+	if err != nil {
+		return false, err
+	}
+	_, err = c.cfg.Mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 func (c *controllerBuilder) watchGw(ctx context.Context) error {
 	// setup a deployer
 	log := log.FromContext(ctx)
@@ -92,6 +124,12 @@ func (c *controllerBuilder) watchGw(ctx context.Context) error {
 		Dev:            c.cfg.Dev,
 		Port:           c.cfg.ControlPlane.GetBindPort(),
 		IstioValues:    c.cfg.IstioValues,
+
+		AutoServiceType: c.cfg.AutoServiceType,
+		ClusterReader:   c.cfg.Mgr.GetClient(),
+		XdsTLS:          c.cfg.XdsTLS,
+
+		ImageRegistryOverride: c.cfg.ImageRegistryOverride,
 	})
 	if err != nil {
 		return err
@@ -104,21 +142,32 @@ func (c *controllerBuilder) watchGw(ctx context.Context) error {
 
 	buildr := ctrl.NewControllerManagedBy(c.cfg.Mgr).
 		// Don't use WithEventFilter here as it also filters events for Owned objects.
+		// Annotations of the Gateway configure its proxy (e.g. ServiceAnnotationsAnnotation) without bumping its
+		// generation, so changing them also triggers a reconcile. Recording the deployed generation does not.
 		For(&apiv1.Gateway{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(object client.Object) bool {
 			if gw, ok := object.(*apiv1.Gateway); ok {
 				return gw.Spec.GatewayClassName == c.cfg.GWClass
 			}
 			return false
-		}), predicate.GenerationChangedPredicate{}))
+		}), predicate.Or(predicate.GenerationChangedPredicate{}, gatewayAnnotationChangedPredicate())))
 
 	for _, gvk := range gvks {
+		var clientObj client.Object
 		obj, err := c.cfg.Mgr.GetScheme().New(gvk)
-		if err != nil {
+		switch {
+		case runtime.IsNotRegisteredError(err):
+			// types which are not in the scheme (e.g. ServiceMonitors) are watched as unstructured objects
+			u := &unstructured.Unstructured{}
+			u.SetGroupVersionKind(gvk)
+			clientObj = u
+		case err != nil:
 			return err
-		}
-		clientObj, ok := obj.(client.Object)
-		if !ok {
-			return fmt.Errorf("object %T is not a client.Object", obj)
+		default:
+			var ok bool
+			clientObj, ok = obj.(client.Object)
+			if !ok {
+				return fmt.Errorf("object %T is not a client.Object", obj)
+			}
 		}
 		log.Info("watching gvk as gateway child", "gvk", gvk)
 		// unless its a service, we don't care about the status
@@ -167,6 +216,44 @@ func (c *controllerBuilder) watchHttpRoute(ctx context.Context) error {
 	return nil
 }
 
+func (c *controllerBuilder) watchTlsRoute(ctx context.Context) error {
+	served, err := c.isKindServed(&apiv1alpha2.TLSRoute{})
+	if err != nil {
+		return err
+	}
+	if !served {
+		log.FromContext(ctx).Info("TLSRoute CRD is not installed, TLSRoutes will be ignored")
+		return nil
+	}
+	err = ctrl.NewControllerManagedBy(c.cfg.Mgr).
+		WithEventFilter(predicate.GenerationChangedPredicate{}).
+		For(&apiv1alpha2.TLSRoute{}).
+		Complete(reconcile.Func(c.reconciler.ReconcileTlsRoutes))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *controllerBuilder) watchTcpRoute(ctx context.Context) error {
+	served, err := c.isKindServed(&apiv1alpha2.TCPRoute{})
+	if err != nil {
+		return err
+	}
+	if !served {
+		log.FromContext(ctx).Info("TCPRoute CRD is not installed, TCPRoutes will be ignored")
+		return nil
+	}
+	err = ctrl.NewControllerManagedBy(c.cfg.Mgr).
+		WithEventFilter(predicate.GenerationChangedPredicate{}).
+		For(&apiv1alpha2.TCPRoute{}).
+		Complete(reconcile.Func(c.reconciler.ReconcileTcpRoutes))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (c *controllerBuilder) watchReferenceGrant(ctx context.Context) error {
 	err := ctrl.NewControllerManagedBy(c.cfg.Mgr).
 		WithEventFilter(predicate.GenerationChangedPredicate{}).
@@ -198,6 +285,20 @@ func (c *controllerBuilder) watchRouteOptions(ctx context.Context) error {
 	return nil
 }
 
+// watchServices resyncs when Service annotations change, as backend plugins are configured through them.
+// Changes to the endpoints of Services are picked up by discovery instead.
+func (c *controllerBuilder) watchServices(ctx context.Context) error {
+	err := ctrl.NewControllerManagedBy(c.cfg.Mgr).
+		Named("service-annotations").
+		WithEventFilter(predicate.AnnotationChangedPredicate{}).
+		For(&corev1.Service{}).
+		Complete(reconcile.Func(c.reconciler.ReconcileServices))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 type controllerReconciler struct {
 	cli    client.Client
 	scheme *runtime.Scheme
@@ -210,6 +311,11 @@ func (r *controllerReconciler) ReconcileRouteOptions(ctx context.Context, req ct
 	return ctrl.Result{}, nil
 }
 
+func (r *controllerReconciler) ReconcileServices(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.kick(ctx)
+	return ctrl.Result{}, nil
+}
+
 func (r *controllerReconciler) ReconcileNamespaces(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	// reconcile all gateways with namespace selector
 	r.kick(ctx)
@@ -233,6 +339,18 @@ func (r *controllerReconciler) ReconcileHttpRoutes(ctx context.Context, req ctrl
 	return ctrl.Result{}, nil
 }
 
+func (r *controllerReconciler) ReconcileTlsRoutes(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// find impacted gateways and queue them
+	r.kick(ctx)
+	return ctrl.Result{}, nil
+}
+
+func (r *controllerReconciler) ReconcileTcpRoutes(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// find impacted gateways and queue them
+	r.kick(ctx)
+	return ctrl.Result{}, nil
+}
+
 func (r *controllerReconciler) ReconcileReferenceGrants(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 
 	// reconcile all things?!
@@ -279,3 +397,23 @@ func (r *controllerReconciler) ReconcileGatewayClasses(ctx context.Context, req
 
 	return ctrl.Result{}, nil
 }
+
+// gatewayAnnotationChangedPredicate is like predicate.AnnotationChangedPredicate, but ignores the changes to
+// deployer.LastDeployedGenerationAnnotation, so that recording the deployed generation of a Gateway does not
+// trigger another reconcile of it
+func gatewayAnnotationChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return false
+			}
+			return !maps.Equal(withoutDeployedGeneration(e.ObjectOld.GetAnnotations()), withoutDeployedGeneration(e.ObjectNew.GetAnnotations()))
+		},
+	}
+}
+
+func withoutDeployedGeneration(annotations map[string]string) map[string]string {
+	annotations = maps.Clone(annotations)
+	delete(annotations, deployer.LastDeployedGenerationAnnotation)
+	return annotations
+}
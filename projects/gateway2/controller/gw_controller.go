@@ -24,7 +24,7 @@ type gatewayReconciler struct {
 	autoProvision bool
 
 	scheme   *runtime.Scheme
-	deployer *deployer.Deployer
+	deployer deployer.ProxyDeployer
 	kick     func(ctx context.Context)
 }
 
@@ -62,52 +62,81 @@ func (r *gatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	if err != nil {
 		return ctrl.Result{}, err
 	}
-	// update gw status: find the name of the service we own, and see if it update the status with it
+	// update gw status: find the services we own (one per listener shard), and update the status with their addresses
 	result := ctrl.Result{}
+	var svcs []*metav1.ObjectMeta
 	for _, obj := range objs {
 		if svc, ok := obj.(*corev1.Service); ok {
-			err := updateStatus(ctx, r.cli, &gw, &svc.ObjectMeta)
-			if err != nil {
-				log.Error(err, "failed to update status")
-				result.Requeue = true
-			}
+			svcs = append(svcs, &svc.ObjectMeta)
 		}
 	}
+	if err := updateStatus(ctx, r.cli, &gw, svcs); err != nil {
+		log.Error(err, "failed to update status")
+		result.Requeue = true
+	}
 
-	log.Info("xxxxx deploying objects", "Objects", objs)
+	// nothing to apply if this generation was deployed and none of its objects were deleted or changed since
+	if deployer.IsDeployed(&gw) {
+		applied, err := r.deployer.IsApplied(ctx, objs, r.cli)
+		if err != nil {
+			return result, err
+		}
+		if applied {
+			log.V(1).Info("gateway generation already deployed, skipping", "generation", gw.GetGeneration())
+			r.kick(ctx)
+			return result, nil
+		}
+	}
 
 	err = r.deployer.DeployObjs(ctx, objs, r.cli)
 	if err != nil {
 		return result, err
 	}
+	if err := deployer.RecordDeployedGeneration(ctx, &gw, r.cli); err != nil {
+		log.Error(err, "failed to record deployed generation")
+		result.Requeue = true
+	}
 	r.kick(ctx)
 
 	return result, nil
 }
 
-func updateStatus(ctx context.Context, cli client.Client, gw *api.Gateway, svcmd *metav1.ObjectMeta) error {
-	svcnns := client.ObjectKey{
-		Namespace: svcmd.Namespace,
-		Name:      svcmd.Name,
-	}
-	var svc corev1.Service
-	if err := cli.Get(ctx, svcnns, &svc); err != nil {
-		return client.IgnoreNotFound(err)
-	}
+// updateStatus sets the addresses of the Gateway to the addresses of all the given Services that it owns.
+// The status is left as is until the Services exist.
+func updateStatus(ctx context.Context, cli client.Client, gw *api.Gateway, svcmds []*metav1.ObjectMeta) error {
+	var desiredAddresses []api.GatewayStatusAddress
+	var owned bool
+	for _, svcmd := range svcmds {
+		svcnns := client.ObjectKey{
+			Namespace: svcmd.Namespace,
+			Name:      svcmd.Name,
+		}
+		var svc corev1.Service
+		if err := cli.Get(ctx, svcnns, &svc); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			continue
+		}
 
-	// make sure we own this service
-	controller := metav1.GetControllerOf(&svc)
-	if controller == nil {
-		return nil
-	}
+		// make sure we own this service
+		controller := metav1.GetControllerOf(&svc)
+		if controller == nil {
+			continue
+		}
 
-	if gw.UID != controller.UID {
+		if gw.UID != controller.UID {
+			continue
+		}
+		owned = true
+		desiredAddresses = append(desiredAddresses, getDesiredAddresses(&svc)...)
+	}
+	if !owned {
 		return nil
 	}
 
 	// update gateway addresses in the status
 
-	desiredAddresses := getDesiredAddresses(&svc)
 	actualAddresses := gw.Status.Addresses
 	if slices.Equal(desiredAddresses, actualAddresses) {
 		return nil
@@ -9,6 +9,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	api "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/solo-io/gloo/projects/gateway2/deployer"
 )
 
 var _ = Describe("GwController", func() {
@@ -83,4 +85,90 @@ var _ = Describe("GwController", func() {
 
 	})
 
+	It("should re-create a deleted proxy object", func() {
+		gw := api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "gw-recreate",
+				Namespace: "default",
+			},
+			Spec: api.GatewaySpec{
+				GatewayClassName: api.ObjectName(gatewayClassName),
+				Listeners: []api.Listener{{
+					Protocol: "HTTP",
+					Port:     80,
+					Name:     "listener",
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &gw)).To(Succeed())
+
+		svcKey := client.ObjectKey{Namespace: "default", Name: "gloo-proxy-gw-recreate"}
+		var svc corev1.Service
+		Eventually(func() error {
+			return k8sClient.Get(ctx, svcKey, &svc)
+		}, timeout, interval).Should(Succeed(), "service not created")
+		Eventually(func() map[string]string {
+			var updated api.Gateway
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&gw), &updated); err != nil {
+				return nil
+			}
+			return updated.Annotations
+		}, timeout, interval).Should(HaveKey(deployer.LastDeployedGenerationAnnotation))
+
+		// the generation of the Gateway was deployed, the Service must still be restored
+		uid := svc.UID
+		Expect(k8sClient.Delete(ctx, &svc)).To(Succeed())
+		Eventually(func() bool {
+			if err := k8sClient.Get(ctx, svcKey, &svc); err != nil {
+				return false
+			}
+			return svc.UID != uid
+		}, timeout, interval).Should(BeTrue(), "service not re-created")
+	})
+
+	It("should roll out changes of the gateway annotations", func() {
+		gw := api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "gw-annotations",
+				Namespace: "default",
+				Annotations: map[string]string{
+					deployer.ServiceAnnotationsAnnotation: `{"example.com/team":"blue"}`,
+				},
+			},
+			Spec: api.GatewaySpec{
+				GatewayClassName: api.ObjectName(gatewayClassName),
+				Listeners: []api.Listener{{
+					Protocol: "HTTP",
+					Port:     80,
+					Name:     "listener",
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &gw)).To(Succeed())
+
+		svcKey := client.ObjectKey{Namespace: "default", Name: "gloo-proxy-gw-annotations"}
+		serviceAnnotations := func() map[string]string {
+			var svc corev1.Service
+			if err := k8sClient.Get(ctx, svcKey, &svc); err != nil {
+				return nil
+			}
+			return svc.Annotations
+		}
+		Eventually(serviceAnnotations, timeout, interval).Should(HaveKeyWithValue("example.com/team", "blue"))
+
+		// annotations do not bump the generation of the Gateway
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&gw), &gw); err != nil {
+				return err
+			}
+			generation := gw.Generation
+			gw.Annotations[deployer.ServiceAnnotationsAnnotation] = `{"example.com/team":"green"}`
+			if err := k8sClient.Update(ctx, &gw); err != nil {
+				return err
+			}
+			Expect(gw.Generation).To(Equal(generation))
+			return nil
+		}, timeout, interval).Should(Succeed())
+		Eventually(serviceAnnotations, timeout, interval).Should(HaveKeyWithValue("example.com/team", "green"))
+	})
 })
@@ -0,0 +1,249 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	api "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/solo-io/gloo/projects/gateway2/controller/scheme"
+	"github.com/solo-io/gloo/projects/gateway2/deployer"
+	"github.com/solo-io/gloo/projects/gateway2/deployer/fake"
+)
+
+// these tests drive the gatewayReconciler against a fake client and a fake deployer,
+// so unlike the suite they do not need an api server.
+
+func newTestGateway() *api.Gateway {
+	return &api.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "gw",
+			Namespace:  "default",
+			UID:        "gw-uid",
+			Generation: 1,
+		},
+		Spec: api.GatewaySpec{GatewayClassName: "gloo-gateway"},
+	}
+}
+
+func newTestReconciler(d deployer.ProxyDeployer, kicks *int, objs ...client.Object) *gatewayReconciler {
+	s := scheme.NewScheme()
+	cli := fakeclient.NewClientBuilder().
+		WithScheme(s).
+		WithStatusSubresource(&api.Gateway{}).
+		WithObjects(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}).
+		WithObjects(objs...).
+		Build()
+	return &gatewayReconciler{
+		cli:           cli,
+		scheme:        s,
+		className:     "gloo-gateway",
+		autoProvision: true,
+		deployer:      d,
+		kick:          func(context.Context) { *kicks++ },
+	}
+}
+
+func reconcileRequest(gw *api.Gateway) ctrl.Request {
+	return ctrl.Request{NamespacedName: types.NamespacedName{Namespace: gw.Namespace, Name: gw.Name}}
+}
+
+func TestReconcileDeploysGateway(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	gw := newTestGateway()
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "gloo-proxy-gw", Namespace: "default"}}
+	d := &fake.Deployer{Objs: []client.Object{svc}}
+	var kicks int
+	r := newTestReconciler(d, &kicks, gw)
+
+	_, err := r.Reconcile(ctx, reconcileRequest(gw))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(d.RenderedGateways()).To(HaveLen(1))
+	g.Expect(d.RenderedGateways()[0].GetName()).To(Equal("gw"))
+	g.Expect(d.DeployedObjs()).To(ConsistOf(svc))
+	g.Expect(kicks).To(Equal(1))
+
+	var live api.Gateway
+	g.Expect(r.cli.Get(ctx, client.ObjectKeyFromObject(gw), &live)).To(Succeed())
+	g.Expect(deployer.IsDeployed(&live)).To(BeTrue())
+}
+
+func ownedService(gw *api.Gateway, name, clusterIP string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: gw.Namespace,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "gateway.networking.k8s.io/v1",
+				Kind:       "Gateway",
+				Name:       gw.Name,
+				UID:        gw.UID,
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: corev1.ServiceSpec{ClusterIP: clusterIP},
+	}
+}
+
+func statusAddresses(g *WithT, r *gatewayReconciler, gw *api.Gateway) []string {
+	var live api.Gateway
+	g.Expect(r.cli.Get(context.Background(), client.ObjectKeyFromObject(gw), &live)).To(Succeed())
+	var addresses []string
+	for _, address := range live.Status.Addresses {
+		addresses = append(addresses, address.Value)
+	}
+	return addresses
+}
+
+func TestReconcileUpdatesAddresses(t *testing.T) {
+	g := NewWithT(t)
+
+	gw := newTestGateway()
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "gloo-proxy-gw", Namespace: "default"}}
+	d := &fake.Deployer{Objs: []client.Object{svc}}
+	var kicks int
+	r := newTestReconciler(d, &kicks, gw, ownedService(gw, "gloo-proxy-gw", "10.0.0.1"))
+
+	_, err := r.Reconcile(context.Background(), reconcileRequest(gw))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(statusAddresses(g, r, gw)).To(Equal([]string{"10.0.0.1"}))
+}
+
+func TestReconcileUpdatesAddressesOfAllShards(t *testing.T) {
+	g := NewWithT(t)
+
+	gw := newTestGateway()
+	d := &fake.Deployer{Objs: []client.Object{
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "gloo-proxy-gw-shard-0", Namespace: "default"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "gloo-proxy-gw-shard-1", Namespace: "default"}},
+	}}
+	var kicks int
+	r := newTestReconciler(d, &kicks, gw,
+		ownedService(gw, "gloo-proxy-gw-shard-0", "10.0.0.1"),
+		ownedService(gw, "gloo-proxy-gw-shard-1", "10.0.0.2"),
+	)
+
+	_, err := r.Reconcile(context.Background(), reconcileRequest(gw))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(statusAddresses(g, r, gw)).To(Equal([]string{"10.0.0.1", "10.0.0.2"}))
+}
+
+func TestReconcileDeployerError(t *testing.T) {
+	g := NewWithT(t)
+
+	gw := newTestGateway()
+	d := &fake.Deployer{Err: errors.New("render failed")}
+	var kicks int
+	r := newTestReconciler(d, &kicks, gw)
+
+	_, err := r.Reconcile(context.Background(), reconcileRequest(gw))
+	g.Expect(err).To(MatchError("render failed"))
+	g.Expect(d.DeployedObjs()).To(BeEmpty())
+	g.Expect(kicks).To(BeZero())
+}
+
+func TestReconcileSkipsNamespacesWithoutAutoDeploy(t *testing.T) {
+	g := NewWithT(t)
+
+	gw := newTestGateway()
+	d := &fake.Deployer{}
+	var kicks int
+	r := newTestReconciler(d, &kicks, gw)
+	r.autoProvision = false
+
+	_, err := r.Reconcile(context.Background(), reconcileRequest(gw))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(d.RenderedGateways()).To(BeEmpty())
+	g.Expect(kicks).To(BeZero())
+}
+
+func TestReconcileSkipsDeployedGeneration(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	gw := newTestGateway()
+	gw.Annotations = map[string]string{deployer.LastDeployedGenerationAnnotation: "1"}
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "gloo-proxy-gw", Namespace: "default"}}
+	d := &fake.Deployer{Objs: []client.Object{svc}, Applied: true}
+	var kicks int
+	r := newTestReconciler(d, &kicks, gw)
+	var before api.Gateway
+	g.Expect(r.cli.Get(ctx, client.ObjectKeyFromObject(gw), &before)).To(Succeed())
+
+	_, err := r.Reconcile(ctx, reconcileRequest(gw))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(d.DeployedObjs()).To(BeEmpty())
+	g.Expect(kicks).To(Equal(1))
+
+	var live api.Gateway
+	g.Expect(r.cli.Get(ctx, client.ObjectKeyFromObject(gw), &live)).To(Succeed())
+	g.Expect(live.ResourceVersion).To(Equal(before.ResourceVersion))
+}
+
+func TestReconcileDeploysDeployedGenerationNotApplied(t *testing.T) {
+	g := NewWithT(t)
+
+	gw := newTestGateway()
+	gw.Annotations = map[string]string{deployer.LastDeployedGenerationAnnotation: "1"}
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "gloo-proxy-gw", Namespace: "default"}}
+	d := &fake.Deployer{Objs: []client.Object{svc}}
+	var kicks int
+	r := newTestReconciler(d, &kicks, gw)
+
+	_, err := r.Reconcile(context.Background(), reconcileRequest(gw))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(d.DeployedObjs()).To(ConsistOf(svc))
+}
+
+func TestGatewayAnnotationChangedPredicate(t *testing.T) {
+	g := NewWithT(t)
+
+	p := gatewayAnnotationChangedPredicate()
+	old := newTestGateway()
+	recorded := old.DeepCopy()
+	recorded.Annotations = map[string]string{deployer.LastDeployedGenerationAnnotation: "1"}
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: recorded})).To(BeFalse())
+
+	changed := recorded.DeepCopy()
+	changed.Annotations[deployer.ServiceAnnotationsAnnotation] = `{"foo":"bar"}`
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: recorded, ObjectNew: changed})).To(BeTrue())
+}
+
+func TestReconcileDoesNotLogObjectsAtInfoLevel(t *testing.T) {
+	g := NewWithT(t)
+
+	var lines []string
+	ctx := log.IntoContext(context.Background(), funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{Verbosity: 0}))
+
+	gw := newTestGateway()
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "gloo-proxy-gw", Namespace: "default"},
+		Data:       map[string]string{"envoy.yaml": "api_key: hunter2"},
+	}
+	d := &fake.Deployer{Objs: []client.Object{cm}}
+	var kicks int
+	r := newTestReconciler(d, &kicks, gw)
+
+	_, err := r.Reconcile(ctx, reconcileRequest(gw))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(d.DeployedObjs()).To(ConsistOf(cm))
+	for _, line := range lines {
+		g.Expect(line).NotTo(ContainSubstring("hunter2"))
+	}
+}
@@ -13,6 +13,9 @@ import (
 	"github.com/solo-io/gloo/projects/gateway2/discovery"
 	"github.com/solo-io/gloo/projects/gateway2/extensions"
 	"github.com/solo-io/gloo/projects/gateway2/secrets"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/hedging"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/mirror"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/retrypolicy"
 	"github.com/solo-io/gloo/projects/gateway2/wellknown"
 	"github.com/solo-io/gloo/projects/gateway2/xds"
 	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
@@ -83,7 +86,13 @@ func Start(ctx context.Context, cfg StartConfig) error {
 	glooTranslator := translator.NewDefaultTranslator(
 		cfg.Opts.Settings,
 		cfg.GlooPluginRegistryFactory(ctx))
-	var sanz sanitizer.XdsSanitizers
+	// the hedge policies, retry budgets and shadow request modifications marked by the hedging, retry policy and
+	// mirror plugins cannot be set on the gloo routes and upstreams
+	sanz := sanitizer.XdsSanitizers{
+		hedging.NewSanitizer(),
+		retrypolicy.NewSanitizer(),
+		mirror.NewSanitizer(),
+	}
 	inputChannels := xds.NewXdsInputChannels()
 
 	k8sGwExtensions, err := cfg.ExtensionsFactory(mgr)
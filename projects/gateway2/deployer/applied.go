@@ -0,0 +1,144 @@
+package deployer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// LastAppliedConfigurationAnnotation is set on every deployed object to a hash of the desired state of the object
+// when it was last applied. Objects whose desired state did not change since are not applied again, so that
+// reconciling a Gateway does not patch every object of its proxy.
+// Objects changed by another client since they were last applied are applied again (see isApplied).
+const LastAppliedConfigurationAnnotation = "gateway2.solo.io/last-applied-configuration"
+
+// appliedConfigurationHash returns the hash of the desired state of the object (see DiffObjects), which is
+// recorded under LastAppliedConfigurationAnnotation
+func appliedConfigurationHash(obj client.Object) (string, error) {
+	state, err := desiredState(obj)
+	if err != nil {
+		return "", err
+	}
+	if metadata, ok := state["metadata"].(map[string]any); ok {
+		if annotations, ok := metadata["annotations"].(map[string]any); ok {
+			delete(annotations, LastAppliedConfigurationAnnotation)
+			// the object may only be annotated because its hash was already recorded
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+	}
+	// maps are marshalled with sorted keys, so the hash is stable
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s %s: %w", obj.GetObjectKind().GroupVersionKind(), obj.GetName(), err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// setLastAppliedConfiguration annotates the object with the hash of its desired state and returns the hash
+func setLastAppliedConfiguration(obj client.Object) (string, error) {
+	hash, err := appliedConfigurationHash(obj)
+	if err != nil {
+		return "", err
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[LastAppliedConfigurationAnnotation] = hash
+	obj.SetAnnotations(annotations)
+	return hash, nil
+}
+
+// isApplied returns true if the object exists in the cluster, was last applied with the given hash, and still holds
+// the desired state it was applied with. Objects changed by another client since, e.g. scaling the Deployment, are
+// applied again to revert the change.
+func isApplied(ctx context.Context, scheme *runtime.Scheme, obj client.Object, hash string, cli client.Client) (bool, error) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	var live client.Object
+	if typed, err := scheme.New(gvk); err == nil {
+		live, _ = typed.(client.Object)
+	}
+	if live == nil {
+		// types which are not in the scheme (e.g. ServiceMonitors) are read as unstructured objects
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		live = u
+	}
+	if err := cli.Get(ctx, client.ObjectKeyFromObject(obj), live); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if live.GetAnnotations()[LastAppliedConfigurationAnnotation] != hash {
+		return false, nil
+	}
+
+	desired, err := desiredState(obj)
+	if err != nil {
+		return false, err
+	}
+	liveState, err := desiredState(live)
+	if err != nil {
+		return false, err
+	}
+	return containsState(liveState, desired), nil
+}
+
+// containsState returns true if every field set in the desired state is set to the same value in the live state.
+// The fields only set in the live state, e.g. defaulted by the API server, are ignored.
+func containsState(live, desired any) bool {
+	switch desired := desired.(type) {
+	case map[string]any:
+		liveMap, ok := live.(map[string]any)
+		if !ok {
+			return len(desired) == 0 && live == nil
+		}
+		for key, value := range desired {
+			if isUnset(value) {
+				continue
+			}
+			if !containsState(liveMap[key], value) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		liveList, ok := live.([]any)
+		if !ok || len(liveList) != len(desired) {
+			return len(desired) == 0 && live == nil
+		}
+		for i := range desired {
+			if !containsState(liveList[i], desired[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return equality.Semantic.DeepEqual(live, desired)
+	}
+}
+
+// isUnset returns true for the values which are not applied, e.g. the null creationTimestamp of pod templates
+func isUnset(value any) bool {
+	switch value := value.(type) {
+	case nil:
+		return true
+	case map[string]any:
+		return len(value) == 0
+	case []any:
+		return len(value) == 0
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package deployer_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	api "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/solo-io/gloo/projects/gateway2/controller/scheme"
+	"github.com/solo-io/gloo/projects/gateway2/deployer"
+	"github.com/solo-io/gloo/projects/gateway2/wellknown"
+)
+
+var _ = Describe("Incremental apply", func() {
+	var (
+		d       *deployer.Deployer
+		gw      *api.Gateway
+		cli     client.Client
+		patched []string
+	)
+
+	BeforeEach(func() {
+		var err error
+		d, err = deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+			ControllerName: wellknown.GatewayControllerName,
+			Port:           8080,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		gw = &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+
+		patched = nil
+		// the fake client does not support server-side apply, so applied objects are created or updated instead
+		cli = fake.NewClientBuilder().WithScheme(scheme.NewScheme()).WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if patch.Type() != types.ApplyPatchType {
+					return c.Patch(ctx, obj, patch, opts...)
+				}
+				patched = append(patched, obj.GetObjectKind().GroupVersionKind().Kind+"/"+obj.GetName())
+				toApply := obj.DeepCopyObject().(client.Object)
+				err := c.Create(ctx, toApply)
+				if apierrors.IsAlreadyExists(err) {
+					toApply.SetResourceVersion("")
+					return c.Update(ctx, toApply)
+				}
+				return err
+			},
+		}).Build()
+	})
+
+	deploy := func(mutate func(objs []client.Object)) {
+		objs, err := d.GetObjsToDeploy(context.Background(), gw)
+		Expect(err).NotTo(HaveOccurred())
+		if mutate != nil {
+			mutate(objs)
+		}
+		Expect(d.DeployObjs(context.Background(), objs, cli)).To(Succeed())
+	}
+
+	It("should annotate the applied objects with their applied configuration", func() {
+		deploy(nil)
+		Expect(patched).NotTo(BeEmpty())
+
+		var svc corev1.Service
+		Expect(cli.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "gloo-proxy-foo"}, &svc)).To(Succeed())
+		Expect(svc.Annotations).To(HaveKeyWithValue(deployer.LastAppliedConfigurationAnnotation, Not(BeEmpty())))
+	})
+
+	It("should not re-apply unchanged objects", func() {
+		deploy(nil)
+		Expect(patched).NotTo(BeEmpty())
+
+		patched = nil
+		deploy(nil)
+		Expect(patched).To(BeEmpty())
+	})
+
+	It("should only re-apply changed objects", func() {
+		deploy(nil)
+		Expect(len(patched)).To(BeNumerically(">", 1))
+
+		patched = nil
+		deploy(func(objs []client.Object) {
+			for _, obj := range objs {
+				if svc, ok := obj.(*corev1.Service); ok {
+					svc.Spec.Type = corev1.ServiceTypeNodePort
+				}
+			}
+		})
+		Expect(patched).To(ConsistOf("Service/gloo-proxy-foo"))
+
+		var svc corev1.Service
+		Expect(cli.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "gloo-proxy-foo"}, &svc)).To(Succeed())
+		Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeNodePort))
+	})
+
+	It("should revert the changes made to the applied objects by another client", func() {
+		deploy(nil)
+		key := client.ObjectKey{Namespace: "default", Name: "gloo-proxy-foo"}
+		var dep appsv1.Deployment
+		Expect(cli.Get(context.Background(), key, &dep)).To(Succeed())
+		replicas := *dep.Spec.Replicas
+		dep.Spec.Replicas = ptr.To(replicas + 2)
+		dep.Spec.Template.Spec.Containers[0].Image = "example.com/other:latest"
+		Expect(cli.Update(context.Background(), &dep)).To(Succeed())
+
+		patched = nil
+		deploy(nil)
+		Expect(patched).To(ConsistOf("Deployment/gloo-proxy-foo"))
+
+		Expect(cli.Get(context.Background(), key, &dep)).To(Succeed())
+		Expect(*dep.Spec.Replicas).To(Equal(replicas))
+		Expect(dep.Spec.Template.Spec.Containers[0].Image).NotTo(Equal("example.com/other:latest"))
+	})
+
+	It("should ignore the fields the applied objects do not set", func() {
+		deploy(nil)
+		key := client.ObjectKey{Namespace: "default", Name: "gloo-proxy-foo"}
+		var dep appsv1.Deployment
+		Expect(cli.Get(context.Background(), key, &dep)).To(Succeed())
+		// e.g. set by the deployment controller
+		dep.Annotations["deployment.kubernetes.io/revision"] = "1"
+		Expect(cli.Update(context.Background(), &dep)).To(Succeed())
+
+		patched = nil
+		deploy(nil)
+		Expect(patched).To(BeEmpty())
+	})
+
+	It("should re-apply objects that were deleted", func() {
+		deploy(nil)
+		Expect(cli.Delete(context.Background(), &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gloo-proxy-foo"},
+		})).To(Succeed())
+
+		patched = nil
+		deploy(nil)
+		Expect(patched).To(ConsistOf("Service/gloo-proxy-foo"))
+	})
+})
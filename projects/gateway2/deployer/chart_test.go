@@ -0,0 +1,129 @@
+package deployer_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/solo-io/gloo/projects/gateway2/controller/scheme"
+	"github.com/solo-io/gloo/projects/gateway2/deployer"
+	"github.com/solo-io/gloo/projects/gateway2/wellknown"
+)
+
+// newChart returns a minimal chart rendering the given templates, keyed by file name
+func newChart(templates map[string]string) *chart.Chart {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion: chart.APIVersionV2,
+			Name:       "test",
+			Version:    "0.0.1",
+		},
+		Values: map[string]any{},
+	}
+	for name, data := range templates {
+		c.Templates = append(c.Templates, &chart.File{Name: "templates/" + name, Data: []byte(data)})
+	}
+	return c
+}
+
+// portsConfigMap renders the ports of the Gateway into a ConfigMap named after the Gateway
+const portsConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: proxy-{{ .Values.gateway.name }}
+data:
+  {{- range $p := .Values.gateway.ports }}
+  {{ $p.name }}: "{{ $p.port }}:{{ $p.targetPort }}"
+  {{- end }}
+`
+
+var _ = Describe("Deployer with chart", func() {
+	gw := &api.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "default",
+			UID:       "1235",
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Gateway",
+			APIVersion: "gateway.solo.io/v1beta1",
+		},
+		Spec: api.GatewaySpec{
+			Listeners: []api.Listener{
+				{Name: "http", Port: 80},
+				{Name: "https", Port: 443},
+			},
+		},
+	}
+	newDeployer := func(c *chart.Chart) (*deployer.Deployer, error) {
+		return deployer.NewDeployerWithChart(scheme.NewScheme(), &deployer.Inputs{
+			ControllerName: wellknown.GatewayControllerName,
+			Port:           8080,
+		}, c)
+	}
+
+	It("should render the given chart", func() {
+		d, err := newDeployer(newChart(map[string]string{"configmap.yaml": portsConfigMap}))
+		Expect(err).NotTo(HaveOccurred())
+
+		objs, err := d.GetObjsToDeploy(context.Background(), gw)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objs).To(HaveLen(1))
+		cm, ok := objs[0].(*corev1.ConfigMap)
+		Expect(ok).To(BeTrue())
+		Expect(cm.Name).To(Equal("proxy-foo"))
+		Expect(cm.Namespace).To(Equal("default"))
+		Expect(cm.Labels).To(HaveKeyWithValue(deployer.GatewayNameLabel, "foo"))
+		Expect(cm.OwnerReferences).To(ConsistOf(HaveField("Name", "foo")))
+		Expect(cm.Data).To(Equal(map[string]string{
+			"http":  "80:8080",
+			"https": "443:8443",
+		}))
+	})
+
+	It("should pass the pinned object names to the chart", func() {
+		d, err := newDeployer(newChart(map[string]string{"configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Values.gateway.fullnameOverride }}
+`}))
+		Expect(err).NotTo(HaveOccurred())
+		objs, err := d.GetObjsToDeploy(context.Background(), gw)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objs).To(HaveLen(1))
+		Expect(objs[0].GetName()).To(Equal(deployer.ProxyName(gw)))
+	})
+
+	It("should require a chart", func() {
+		_, err := newDeployer(nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("healthz", func() {
+		It("should be healthy with a valid chart", func() {
+			d, err := newDeployer(newChart(map[string]string{"configmap.yaml": portsConfigMap}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(d.Healthz(context.Background())).To(Succeed())
+		})
+
+		It("should be healthy with the embedded chart", func() {
+			d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(d.Healthz(context.Background())).To(Succeed())
+		})
+
+		It("should not be healthy when the chart fails to render", func() {
+			d, err := newDeployer(newChart(map[string]string{"broken.yaml": "{{ .Values.broken"}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(d.Healthz(context.Background())).To(MatchError(ContainSubstring("deployer is not healthy")))
+		})
+	})
+})
@@ -0,0 +1,52 @@
+package deployer_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/solo-io/gloo/projects/gateway2/controller/scheme"
+	"github.com/solo-io/gloo/projects/gateway2/deployer"
+)
+
+// FuzzConvertYAMLToObjects checks that arbitrary manifests are either converted or rejected with ErrConvert,
+// without panicking. Run with `go test -fuzz=FuzzConvertYAMLToObjects ./projects/gateway2/deployer/`.
+func FuzzConvertYAMLToObjects(f *testing.F) {
+	for _, seed := range []string{
+		widgetYaml,
+		"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: first\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: second\n",
+		"---\n# comment\n---\n",
+		"kind: [Service",
+		// duplicate keys
+		"apiVersion: v1\nkind: ConfigMap\nkind: Secret\nmetadata:\n  name: dup\n",
+		// deeply nested
+		strings.Repeat("[", 10000) + strings.Repeat("]", 10000),
+		strings.Repeat("a:\n ", 1000) + "b",
+		// alias expansion
+		"a: &a [x, x, x, x, x, x, x, x]\nb: &b [*a, *a, *a, *a, *a, *a, *a, *a]\nc: &c [*b, *b, *b, *b, *b, *b, *b, *b]\n",
+		// known kinds with fields of the wrong type
+		"apiVersion: v1\nkind: Service\nmetadata:\n  name: 1\nspec:\n  ports: 80\n",
+		"apiVersion: 1\nkind: [Service]\n",
+		"null",
+		"[]",
+		"42",
+		"{\"apiVersion\": \"v1\", \"kind\": \"ConfigMap\", \"data\": {\"a\": 1}}",
+	} {
+		f.Add([]byte(seed))
+	}
+	s := scheme.NewScheme()
+	f.Fuzz(func(t *testing.T, data []byte) {
+		objs, err := deployer.ConvertYAMLToObjects(s, data)
+		if err != nil {
+			if !errors.Is(err, deployer.ErrConvert) {
+				t.Fatalf("expected an ErrConvert error, got %v", err)
+			}
+			return
+		}
+		for _, obj := range objs {
+			if obj == nil {
+				t.Fatal("expected no nil objects")
+			}
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package deployer_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	api "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/solo-io/gloo/projects/gateway2/controller/scheme"
+	"github.com/solo-io/gloo/projects/gateway2/deployer"
+	"github.com/solo-io/gloo/projects/gateway2/wellknown"
+)
+
+var widgetGV = schema.GroupVersion{Group: "example.solo.io", Version: "v1"}
+
+// Widget is a stand-in for a user CRD rendered by a chart
+type Widget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WidgetSpec `json:"spec,omitempty"`
+}
+
+type WidgetSpec struct {
+	Size int `json:"size,omitempty"`
+}
+
+func (w *Widget) DeepCopyObject() runtime.Object {
+	out := *w
+	w.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+func addWidgetToScheme(s *runtime.Scheme) error {
+	s.AddKnownTypes(widgetGV, &Widget{})
+	return nil
+}
+
+const widgetYaml = `
+apiVersion: example.solo.io/v1
+kind: Widget
+metadata:
+  name: foo
+  namespace: default
+spec:
+  size: 3
+`
+
+var _ = Describe("ConvertYAMLToObjects", func() {
+	It("should return unknown types as unstructured", func() {
+		objs, err := deployer.ConvertYAMLToObjects(scheme.NewScheme(), []byte(widgetYaml))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objs).To(HaveLen(1))
+		Expect(objs[0]).To(BeAssignableToTypeOf(&unstructured.Unstructured{}))
+	})
+
+	It("should reference the offending document in decode errors", func() {
+		manifest := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: second
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: [name: third
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: fourth
+`
+		_, err := deployer.ConvertYAMLToObjects(scheme.NewScheme(), []byte(manifest))
+		Expect(err).To(HaveOccurred())
+		Expect(errors.Is(err, deployer.ErrConvert)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("document 3"))
+		Expect(err.Error()).To(ContainSubstring("name: third"))
+		Expect(err.Error()).NotTo(ContainSubstring("fourth"))
+	})
+
+	It("should reject oversized documents", func() {
+		manifest := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: huge
+data:
+  key: ` + strings.Repeat("a", deployer.MaxDocumentSize) + `
+`
+		_, err := deployer.ConvertYAMLToObjects(scheme.NewScheme(), []byte(manifest))
+		Expect(err).To(HaveOccurred())
+		Expect(errors.Is(err, deployer.ErrConvert)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("document 2"))
+		Expect(err.Error()).To(ContainSubstring("above the maximum"))
+		// the snippet of the document is still truncated
+		Expect(len(err.Error())).To(BeNumerically("<", 1000))
+	})
+
+	It("should skip empty documents", func() {
+		manifest := `
+---
+# Source: gloo-gateway/templates/gateway/empty.yaml
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+`
+		objs, err := deployer.ConvertYAMLToObjects(scheme.NewScheme(), []byte(manifest))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objs).To(HaveLen(1))
+		Expect(objs[0].GetName()).To(Equal("first"))
+	})
+
+	It("should return types registered by scheme extensions as typed objects", func() {
+		base := scheme.NewScheme()
+		extended, err := deployer.ExtendScheme(base, addWidgetToScheme)
+		Expect(err).NotTo(HaveOccurred())
+
+		objs, err := deployer.ConvertYAMLToObjects(extended, []byte(widgetYaml))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objs).To(HaveLen(1))
+		widget, ok := objs[0].(*Widget)
+		Expect(ok).To(BeTrue(), "expected a typed Widget, got %T", objs[0])
+		Expect(widget.GetName()).To(Equal("foo"))
+		Expect(widget.Spec.Size).To(Equal(3))
+
+		// the base scheme must not be modified
+		Expect(base.Recognizes(widgetGV.WithKind("Widget"))).To(BeFalse())
+		// and types of the base scheme must still be known
+		Expect(extended.Recognizes(schema.GroupVersionKind{Version: "v1", Kind: "Service"})).To(BeTrue())
+	})
+
+	It("should still render typed chart objects when the deployer has scheme extensions", func() {
+		d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+			ControllerName:   wellknown.GatewayControllerName,
+			Port:             8080,
+			SchemeExtensions: []func(*runtime.Scheme) error{addWidgetToScheme},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default", UID: "1235"},
+			TypeMeta:   metav1.TypeMeta{Kind: "Gateway", APIVersion: "gateway.solo.io/v1beta1"},
+		}
+		objs, err := d.GetObjsToDeploy(context.Background(), gw)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objs).To(ContainElement(BeAssignableToTypeOf(&corev1.Service{})))
+	})
+})
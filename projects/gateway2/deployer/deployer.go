@@ -1,27 +1,36 @@
 package deployer
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/docker/distribution/reference"
+	"github.com/go-logr/logr"
 	"golang.org/x/exp/slices"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/storage"
 	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	api "sigs.k8s.io/gateway-api/apis/v1"
@@ -34,6 +43,22 @@ import (
 	"github.com/solo-io/gloo/projects/gloo/pkg/defaults"
 )
 
+const (
+	// GatewayNameLabel is set on every deployed object to the name of the Gateway that owns it
+	GatewayNameLabel = "gateway.networking.k8s.io/gateway-name"
+	// ControllerNameLabel is set on every deployed object to the (sanitized) name of the controller that deployed it
+	ControllerNameLabel = "gateway2.solo.io/controller-name"
+	// ManagedByLabel is set on every deployed object that does not already have it set by the chart
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	// GatewayUIDLabel is set to the UID of the Gateway on the objects deployed to another cluster than the one of
+	// the Gateway (see DeployToCluster), in place of the owner reference the garbage collector of that cluster
+	// could not resolve
+	GatewayUIDLabel = "gateway2.solo.io/gateway-uid"
+	// ServiceAnnotationsAnnotation is set on a Gateway to a JSON object of annotations added to the proxy Service,
+	// overriding the values of Inputs.ServiceAnnotations for the same keys
+	ServiceAnnotationsAnnotation = "gateway2.solo.io/service-annotations"
+)
+
 type gatewayPort struct {
 	Port       uint16 `json:"port"`
 	Protocol   string `json:"protocol"`
@@ -41,6 +66,26 @@ type gatewayPort struct {
 	TargetPort uint16 `json:"targetPort"`
 }
 
+// ProxyDeployer is the set of operations used by controllers to render and deploy proxies for a Gateway.
+// It is implemented by *Deployer, and exists so that consumers can inject fakes in tests.
+type ProxyDeployer interface {
+	// GetGvksToWatch returns the list of GVKs that the deployer will deploy, and should therefore be watched
+	GetGvksToWatch(ctx context.Context) ([]schema.GroupVersionKind, error)
+	// GetObjsToDeploy renders the objects required to run a proxy for the given Gateway. If gvks are given,
+	// only the objects of these kinds are returned.
+	GetObjsToDeploy(ctx context.Context, gw *api.Gateway, gvks ...schema.GroupVersionKind) ([]client.Object, error)
+	// DeployObjs applies the given objects using the provided client
+	DeployObjs(ctx context.Context, objs []client.Object, cli client.Client) error
+	// IsApplied returns true if every given object is present in the cluster with the state it would be applied
+	// with, i.e. DeployObjs would not apply any of them
+	IsApplied(ctx context.Context, objs []client.Object, cli client.Client) (bool, error)
+	// Deploy renders and applies the objects required to run a proxy for the given Gateway, unless the
+	// current generation of the Gateway was already deployed and its objects are still applied
+	Deploy(ctx context.Context, gw *api.Gateway, cli client.Client) error
+}
+
+var _ ProxyDeployer = &Deployer{}
+
 // A Deployer is responsible for deploying proxies
 type Deployer struct {
 	chart  *chart.Chart
@@ -55,6 +100,201 @@ type Inputs struct {
 	Dev            bool
 	Port           int
 	IstioValues    bootstrap.IstioValues
+	// CommonLabels are merged on top of the standard label set (see commonLabels) which is applied to
+	// every deployed object. Labels rendered by the chart are never overwritten.
+	CommonLabels map[string]string
+	// AutoServiceType enables falling back from a LoadBalancer to a NodePort Service when the cluster
+	// does not appear to support load balancers (e.g. kind or minikube). Requires ClusterReader to be set.
+	AutoServiceType bool
+	// ClusterReader is used to probe the cluster for load balancer support when AutoServiceType is enabled,
+	// and to resolve the parameters of the GatewayClass of the Gateways (see ReplicasParameter) when set.
+	// It is read on every render, so it is expected to be served by a cache, e.g. the client of the manager.
+	ClusterReader client.Reader
+	// SchemeExtensions register additional types (e.g. user CRDs rendered by the chart) into the scheme
+	// used to convert rendered objects, so they are returned typed rather than unstructured.
+	// The scheme passed to NewDeployer is not modified.
+	SchemeExtensions []func(*runtime.Scheme) error
+	// XdsTLS configures the transport security of the connection from the proxies to the control plane.
+	// Proxies connect over plaintext unless it is enabled.
+	XdsTLS XdsTLSConfig
+	// ImageRegistryOverride replaces the registry host of the proxy image (e.g. with an internal mirror in
+	// air-gapped environments), whether it is the chart default or overridden by the GG_EXPERIMENTAL_DEPLOYER_IMAGE env.
+	ImageRegistryOverride string
+	// TerminationGracePeriodSeconds overrides the termination grace period of the proxy pods, to give long-lived
+	// connections enough time to drain. The Kubernetes default is used when unset.
+	TerminationGracePeriodSeconds *int64
+	// PodSecurityContext is set on the proxy pods
+	PodSecurityContext *corev1.PodSecurityContext
+	// ContainerSecurityContext is set on the proxy container, merged with the chart default (which runs as non-root
+	// with a read-only root filesystem, and only the NET_BIND_SERVICE capability). It does not apply to sidecars.
+	ContainerSecurityContext *corev1.SecurityContext
+	// AutomountServiceAccountToken sets whether the token of the service account is mounted in the proxy pods.
+	// The setting of the service account, which does not automount its token, is used when unset.
+	AutomountServiceAccountToken *bool
+	// RuntimeClassName is the RuntimeClass used to run the proxy pods, e.g. for sandboxed runtimes such as gVisor
+	// or Kata. The default runtime of the cluster is used when unset.
+	RuntimeClassName *string
+	// DNSPolicy is the DNS policy of the proxy pods. The Kubernetes default (ClusterFirst) is used when empty.
+	DNSPolicy corev1.DNSPolicy
+	// DNSConfig tunes the DNS resolution of the proxy pods, e.g. to lower ndots. It is required with the None policy.
+	DNSConfig *corev1.PodDNSConfig
+	// PreStopHook is run in the proxy container before it is terminated, e.g. to sleep or run a drain
+	// command while connections drain. It should complete within the termination grace period.
+	PreStopHook *corev1.LifecycleHandler
+	// SidecarContainers are added to the proxy pods alongside the proxy container, e.g. for logging or metrics
+	SidecarContainers []corev1.Container
+	// Replicas is the number of replicas of the proxy Deployment. It is ignored when Autoscaling is set,
+	// as the replica count is then managed by the HorizontalPodAutoscaler.
+	Replicas *int32
+	// Autoscaling, if set, renders a HorizontalPodAutoscaler for the proxy Deployment
+	Autoscaling *AutoscalingConfig
+	// ServiceMonitor, if set, exposes the proxy metrics on the proxy Service and renders a Prometheus Operator
+	// ServiceMonitor to scrape them. The ServiceMonitor CRD must be installed in the cluster.
+	ServiceMonitor *ServiceMonitorConfig
+	// StatsPort overrides the port on which the proxy serves its metrics when ServiceMonitor is set.
+	// Defaults to the chart default when unset.
+	StatsPort uint16
+	// AdminPort overrides the port of the proxy admin interface, bound to localhost in the proxy pods.
+	// Defaults to the chart default when unset.
+	AdminPort uint16
+	// Logger is used by the deployer instead of the logger of the context when set
+	Logger logr.Logger
+	// SensitiveValueKeys are the keys of the helm values whose values are redacted when the values are logged,
+	// at any depth, on top of container environment variables which are always redacted
+	SensitiveValueKeys []string
+	// MaxRenderedObjects, if positive, makes GetObjsToDeploy fail when the chart renders more objects than this,
+	// to avoid overwhelming the API server with a misconfigured chart or values
+	MaxRenderedObjects int
+	// ServiceAnnotations are added to the proxy Services, e.g. to configure cloud load balancers.
+	// They can be overridden for a Gateway with ServiceAnnotationsAnnotation.
+	ServiceAnnotations map[string]string
+	// ObjectTransformers mutate the objects of the given GVK returned by GetObjsToDeploy, once common labels
+	// and owner references are set, e.g. to add an annotation to the Service only. A transformer returning
+	// an error fails GetObjsToDeploy.
+	ObjectTransformers map[schema.GroupVersionKind]func(client.Object) error
+}
+
+// ServiceMonitorConfig configures the ServiceMonitor scraping the proxy metrics
+type ServiceMonitorConfig struct {
+	// Interval is the scrape interval, rounded down to the second. Defaults to the chart default when unset.
+	Interval time.Duration
+	// Path is the path on which metrics are served. Defaults to the chart default when unset.
+	Path string
+}
+
+// AutoscalingConfig configures the HorizontalPodAutoscaler of the proxy Deployment
+type AutoscalingConfig struct {
+	MinReplicas int32
+	MaxReplicas int32
+	// TargetCPUUtilizationPercentage defaults to the chart default when unset
+	TargetCPUUtilizationPercentage *int32
+	// TargetMemoryUtilizationPercentage is not used as a metric when unset
+	TargetMemoryUtilizationPercentage *int32
+}
+
+// reservedContainerNames are the names of the containers rendered by the chart in the proxy pods
+var reservedContainerNames = []string{"gloo-gateway", "sds", "istio-proxy"}
+
+func validateInputs(inputs *Inputs) error {
+	if inputs.XdsTLS.Enabled && inputs.XdsTLS.CASecretName == "" {
+		return fmt.Errorf("xds tls is enabled but no CA secret name was provided")
+	}
+	if inputs.TerminationGracePeriodSeconds != nil && *inputs.TerminationGracePeriodSeconds < 0 {
+		return fmt.Errorf("termination grace period must not be negative, got %d", *inputs.TerminationGracePeriodSeconds)
+	}
+	if err := validateSecurityContexts(inputs.PodSecurityContext, inputs.ContainerSecurityContext); err != nil {
+		return err
+	}
+	if inputs.RuntimeClassName != nil && *inputs.RuntimeClassName == "" {
+		return fmt.Errorf("runtime class name must not be empty")
+	}
+	if err := validateDNS(inputs.DNSPolicy, inputs.DNSConfig); err != nil {
+		return err
+	}
+	if hook := inputs.PreStopHook; hook != nil && hook.Exec == nil && hook.HTTPGet == nil && hook.TCPSocket == nil {
+		return fmt.Errorf("preStop hook must define an exec, httpGet or tcpSocket handler")
+	}
+	containerNames := slices.Clone(reservedContainerNames)
+	for _, sidecar := range inputs.SidecarContainers {
+		if sidecar.Name == "" {
+			return fmt.Errorf("sidecar containers must have a name")
+		}
+		if slices.Contains(containerNames, sidecar.Name) {
+			return fmt.Errorf("duplicate container name %q in sidecar containers", sidecar.Name)
+		}
+		if sidecar.Image == "" {
+			return fmt.Errorf("sidecar container %q must have an image", sidecar.Name)
+		}
+		containerNames = append(containerNames, sidecar.Name)
+	}
+	if inputs.Replicas != nil && *inputs.Replicas < 0 {
+		return fmt.Errorf("replicas must not be negative, got %d", *inputs.Replicas)
+	}
+	if as := inputs.Autoscaling; as != nil && (as.MinReplicas < 1 || as.MaxReplicas < as.MinReplicas) {
+		return fmt.Errorf("autoscaling requires 1 <= minReplicas <= maxReplicas, got minReplicas=%d maxReplicas=%d", as.MinReplicas, as.MaxReplicas)
+	}
+	if sm := inputs.ServiceMonitor; sm != nil {
+		if sm.Interval != 0 && sm.Interval < time.Second {
+			return fmt.Errorf("service monitor interval must be at least 1s, got %s", sm.Interval)
+		}
+		if sm.Path != "" && !strings.HasPrefix(sm.Path, "/") {
+			return fmt.Errorf("service monitor path must start with '/', got %q", sm.Path)
+		}
+	}
+	if inputs.MaxRenderedObjects < 0 {
+		return fmt.Errorf("max rendered objects must not be negative, got %d", inputs.MaxRenderedObjects)
+	}
+	if inputs.StatsPort != 0 && inputs.StatsPort == inputs.AdminPort {
+		return fmt.Errorf("stats port and admin port must differ, got %d for both", inputs.StatsPort)
+	}
+	return nil
+}
+
+// validateSecurityContexts rejects the security contexts of the proxy pods that the API server would reject,
+// or that could never be admitted
+func validateSecurityContexts(pod *corev1.PodSecurityContext, container *corev1.SecurityContext) error {
+	if pod != nil && ptr.Deref(pod.RunAsNonRoot, false) && pod.RunAsUser != nil && *pod.RunAsUser == 0 {
+		return fmt.Errorf("pod security context must not run as user 0 with runAsNonRoot")
+	}
+	if container == nil {
+		return nil
+	}
+	if ptr.Deref(container.RunAsNonRoot, false) && container.RunAsUser != nil && *container.RunAsUser == 0 {
+		return fmt.Errorf("container security context must not run as user 0 with runAsNonRoot")
+	}
+	if ptr.Deref(container.Privileged, false) && container.AllowPrivilegeEscalation != nil && !*container.AllowPrivilegeEscalation {
+		return fmt.Errorf("container security context must not disallow privilege escalation for a privileged container")
+	}
+	return nil
+}
+
+// validDNSPolicies are the DNS policies of pods supported by Kubernetes
+var validDNSPolicies = []corev1.DNSPolicy{
+	corev1.DNSClusterFirstWithHostNet,
+	corev1.DNSClusterFirst,
+	corev1.DNSDefault,
+	corev1.DNSNone,
+}
+
+// validateDNS rejects unknown DNS policies, and the None policy without nameservers, as the pods would
+// otherwise not be able to resolve any name
+func validateDNS(policy corev1.DNSPolicy, config *corev1.PodDNSConfig) error {
+	if policy != "" && !slices.Contains(validDNSPolicies, policy) {
+		return fmt.Errorf("unknown dns policy %q, must be one of %v", policy, validDNSPolicies)
+	}
+	if policy == corev1.DNSNone && (config == nil || len(config.Nameservers) == 0) {
+		return fmt.Errorf("dns policy %s requires a dns config with at least one nameserver", corev1.DNSNone)
+	}
+	return nil
+}
+
+// XdsTLSConfig configures mTLS between the proxies and the control plane xds server
+type XdsTLSConfig struct {
+	// Enabled makes proxies connect to the xds server over mTLS
+	Enabled bool
+	// CASecretName is the name of the secret holding the client certificate (tls.crt, tls.key) presented by
+	// the proxies, and the CA (ca.crt) used to validate the xds server. It must exist in the namespace of each Gateway.
+	CASecretName string
 }
 
 // NewDeployer creates a new gateway deployer
@@ -68,6 +308,23 @@ func NewDeployer(scheme *runtime.Scheme, inputs *Inputs) (*Deployer, error) {
 		helmChart.Metadata.AppVersion = version.Version
 		helmChart.Metadata.Version = version.Version
 	}
+	return NewDeployerWithChart(scheme, inputs, helmChart)
+}
+
+// NewDeployerWithChart creates a new gateway deployer rendering the given chart instead of the embedded
+// gloo-gateway chart, e.g. for unit tests to render a minimal chart exercising only the values they set.
+// The chart is rendered with the same values as the embedded chart, and is not copied.
+func NewDeployerWithChart(scheme *runtime.Scheme, inputs *Inputs, helmChart *chart.Chart) (*Deployer, error) {
+	if err := validateInputs(inputs); err != nil {
+		return nil, err
+	}
+	if helmChart == nil {
+		return nil, fmt.Errorf("a chart is required")
+	}
+	scheme, err := ExtendScheme(scheme, inputs.SchemeExtensions...)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Deployer{
 		scheme: scheme,
@@ -76,16 +333,32 @@ func NewDeployer(scheme *runtime.Scheme, inputs *Inputs) (*Deployer, error) {
 	}, nil
 }
 
-// GetGvksToWatch returns the list of GVKs that the deployer will watch for
-func (d *Deployer) GetGvksToWatch(ctx context.Context) ([]schema.GroupVersionKind, error) {
-	fakeGw := &api.Gateway{
+// logger returns the configured logger, falling back to the logger of the context
+func (d *Deployer) logger(ctx context.Context) logr.Logger {
+	if !d.inputs.Logger.IsZero() {
+		return d.inputs.Logger
+	}
+	return log.FromContext(ctx)
+}
+
+func (d *Deployer) sensitiveValueKeys() []string {
+	return append(slices.Clone(defaultSensitiveValueKeys), d.inputs.SensitiveValueKeys...)
+}
+
+// defaultGateway returns a Gateway without listeners, which is rendered to find out which objects
+// the chart renders for any Gateway
+func defaultGateway() *api.Gateway {
+	return &api.Gateway{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "default",
 			Namespace: "default",
 		},
 	}
+}
 
-	objs, err := d.renderChartToObjects(ctx, fakeGw)
+// GetGvksToWatch returns the list of GVKs that the deployer will watch for
+func (d *Deployer) GetGvksToWatch(ctx context.Context) ([]schema.GroupVersionKind, error) {
+	objs, err := d.renderChartToObjects(ctx, defaultGateway(), d.localXdsAddress())
 	if err != nil {
 		return nil, err
 	}
@@ -99,6 +372,15 @@ func (d *Deployer) GetGvksToWatch(ctx context.Context) ([]schema.GroupVersionKin
 	return ret, nil
 }
 
+// Healthz renders the chart for a default Gateway, returning an error if the chart can not be rendered,
+// e.g. for the readiness check of a controller embedding the deployer. The rendered objects are not applied.
+func (d *Deployer) Healthz(ctx context.Context) error {
+	if _, err := d.renderChartToObjects(ctx, defaultGateway(), d.localXdsAddress()); err != nil {
+		return fmt.Errorf("deployer is not healthy: %w", err)
+	}
+	return nil
+}
+
 func jsonConvert(in []gatewayPort, out interface{}) error {
 	b, err := json.Marshal(in)
 	if err != nil {
@@ -107,7 +389,26 @@ func jsonConvert(in []gatewayPort, out interface{}) error {
 	return json.Unmarshal(b, out)
 }
 
-func (d *Deployer) renderChartToObjects(ctx context.Context, gw *api.Gateway) ([]client.Object, error) {
+// xdsAddress is the socket address that the proxies connect to on startup, to receive xds updates
+type xdsAddress struct {
+	host string
+	port int
+}
+
+// localXdsAddress returns the address of the control plane Service, reachable from the cluster of the deployer.
+//
+// NOTE: The current implementation in flawed in multiple ways:
+//  1. This assumes that the Control Plane is installed in `gloo-system`
+//  2. The port is the bindAddress of the Go server, but there is not a strong guarantee that that port
+//     will always be what is exposed by the Kubernetes Service.
+func (d *Deployer) localXdsAddress() xdsAddress {
+	return xdsAddress{
+		host: fmt.Sprintf("gloo.%s.svc.%s", defaults.GlooSystem, "cluster.local"),
+		port: d.inputs.Port,
+	}
+}
+
+func (d *Deployer) renderChartToObjects(ctx context.Context, gw *api.Gateway, xds xdsAddress) ([]client.Object, error) {
 
 	// must not be nil for helm to not fail.
 	gwPorts := []gatewayPort{}
@@ -123,6 +424,9 @@ func (d *Deployer) renderChartToObjects(ctx context.Context, gw *api.Gateway) ([
 		port.Protocol = "TCP"
 		gwPorts = append(gwPorts, port)
 	}
+	if err := d.validateProxyPorts(gwPorts); err != nil {
+		return nil, err
+	}
 
 	// convert to json for helm (otherwise go template fails, as the field names are uppercase)
 	var portsAny []any
@@ -131,6 +435,33 @@ func (d *Deployer) renderChartToObjects(ctx context.Context, gw *api.Gateway) ([
 		return nil, err
 	}
 
+	params, err := d.resolveParameters(ctx, gw)
+	if err != nil {
+		return nil, err
+	}
+	// an explicit service type takes precedence over the detection of load balancer support
+	var serviceType corev1.ServiceType
+	if params.serviceType != nil {
+		serviceType = *params.serviceType
+	} else {
+		serviceType, err = d.getServiceType(ctx, gw)
+		if err != nil {
+			return nil, err
+		}
+	}
+	serviceAnnotations, err := d.serviceAnnotations(gw)
+	if err != nil {
+		return nil, err
+	}
+	shards, err := ListenerShards(gw)
+	if err != nil {
+		return nil, err
+	}
+	imageValues, err := d.getDeployerImageValues()
+	if err != nil {
+		return nil, err
+	}
+
 	vals := map[string]any{
 		"controlPlane": map[string]any{
 			"enabled": false,
@@ -139,34 +470,136 @@ func (d *Deployer) renderChartToObjects(ctx context.Context, gw *api.Gateway) ([
 			"enabled":     true,
 			"name":        gw.Name,
 			"gatewayName": gw.Name,
-			"ports":       portsAny,
+			// pin the names of the objects rather than relying on the naming logic of the chart
+			"fullnameOverride": ProxyName(gw),
+			"ports":            portsAny,
 			// Default to Load Balancer
 			"service": map[string]any{
-				"type": "LoadBalancer",
+				"type":        string(serviceType),
+				"annotations": serviceAnnotations,
 			},
 			"istioSDS": map[string]any{
 				"enabled": d.inputs.IstioValues.SDSEnabled,
 			},
 			"xds": map[string]any{
 				// The xds host/port MUST map to the Service definition for the Control Plane
-				// This is the socket address that the Proxy will connect to on startup, to receive xds updates
-				//
-				// NOTE: The current implementation in flawed in multiple ways:
-				//	1 - This assumes that the Control Plane is installed in `gloo-system`
-				//	2 - The port is the bindAddress of the Go server, but there is not a strong guarantee that that port
-				//		will always be what is exposed by the Kubernetes Service.
-				"host": fmt.Sprintf("gloo.%s.svc.%s", defaults.GlooSystem, "cluster.local"),
-				"port": d.inputs.Port,
+				"host": xds.host,
+				"port": xds.port,
+				"tls": map[string]any{
+					"enabled":    d.inputs.XdsTLS.Enabled,
+					"secretName": d.inputs.XdsTLS.CASecretName,
+				},
 			},
-			"image": getDeployerImageValues(),
+			"image": imageValues,
 		},
 	}
 	if d.inputs.Dev {
 		vals["develop"] = true
 	}
-	log := log.FromContext(ctx)
-	log.Info("rendering helm chart", "vals", vals)
-	objs, err := d.Render(ctx, gw.Name, gw.Namespace, vals)
+	gatewayVals := vals["gateway"].(map[string]any)
+	if d.inputs.TerminationGracePeriodSeconds != nil {
+		gatewayVals["terminationGracePeriodSeconds"] = *d.inputs.TerminationGracePeriodSeconds
+	}
+	if d.inputs.PodSecurityContext != nil {
+		podSecurityContext, err := runtime.DefaultUnstructuredConverter.ToUnstructured(d.inputs.PodSecurityContext)
+		if err != nil {
+			return nil, err
+		}
+		gatewayVals["podSecurityContext"] = podSecurityContext
+	}
+	if d.inputs.ContainerSecurityContext != nil {
+		securityContext, err := runtime.DefaultUnstructuredConverter.ToUnstructured(d.inputs.ContainerSecurityContext)
+		if err != nil {
+			return nil, err
+		}
+		gatewayVals["securityContext"] = securityContext
+	}
+	if d.inputs.RuntimeClassName != nil {
+		gatewayVals["runtimeClassName"] = *d.inputs.RuntimeClassName
+	}
+	if d.inputs.DNSPolicy != "" {
+		gatewayVals["dnsPolicy"] = string(d.inputs.DNSPolicy)
+	}
+	if d.inputs.DNSConfig != nil {
+		dnsConfig, err := runtime.DefaultUnstructuredConverter.ToUnstructured(d.inputs.DNSConfig)
+		if err != nil {
+			return nil, err
+		}
+		gatewayVals["dnsConfig"] = dnsConfig
+	}
+	if d.inputs.AutomountServiceAccountToken != nil {
+		gatewayVals["automountServiceAccountToken"] = *d.inputs.AutomountServiceAccountToken
+	}
+	if d.inputs.PreStopHook != nil {
+		preStopHook, err := runtime.DefaultUnstructuredConverter.ToUnstructured(d.inputs.PreStopHook)
+		if err != nil {
+			return nil, err
+		}
+		gatewayVals["preStopHook"] = preStopHook
+	}
+	if len(d.inputs.SidecarContainers) > 0 {
+		sidecars := make([]any, 0, len(d.inputs.SidecarContainers))
+		for i := range d.inputs.SidecarContainers {
+			sidecar, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&d.inputs.SidecarContainers[i])
+			if err != nil {
+				return nil, err
+			}
+			sidecars = append(sidecars, sidecar)
+		}
+		gatewayVals["sidecarContainers"] = sidecars
+	}
+	replicas := d.inputs.Replicas
+	if params.replicas != nil {
+		replicas = params.replicas
+	}
+	if as := d.inputs.Autoscaling; as != nil {
+		autoscaling := map[string]any{
+			"enabled":     true,
+			"minReplicas": as.MinReplicas,
+			"maxReplicas": as.MaxReplicas,
+		}
+		if as.TargetCPUUtilizationPercentage != nil {
+			autoscaling["targetCPUUtilizationPercentage"] = *as.TargetCPUUtilizationPercentage
+		}
+		if as.TargetMemoryUtilizationPercentage != nil {
+			autoscaling["targetMemoryUtilizationPercentage"] = *as.TargetMemoryUtilizationPercentage
+		}
+		gatewayVals["autoscaling"] = autoscaling
+	} else if replicas != nil {
+		gatewayVals["replicaCount"] = *replicas
+	}
+	if params.resources != nil {
+		resources, err := runtime.DefaultUnstructuredConverter.ToUnstructured(params.resources)
+		if err != nil {
+			return nil, err
+		}
+		gatewayVals["resources"] = resources
+	}
+	if sm := d.inputs.ServiceMonitor; sm != nil {
+		serviceMonitor := map[string]any{
+			"enabled": true,
+		}
+		if sm.Interval != 0 {
+			serviceMonitor["interval"] = fmt.Sprintf("%ds", int64(sm.Interval/time.Second))
+		}
+		if sm.Path != "" {
+			serviceMonitor["path"] = sm.Path
+		}
+		gatewayVals["serviceMonitor"] = serviceMonitor
+	}
+	if d.inputs.StatsPort != 0 {
+		gatewayVals["statsPort"] = d.inputs.StatsPort
+	}
+	if d.inputs.AdminPort != 0 {
+		gatewayVals["adminPort"] = d.inputs.AdminPort
+	}
+	var objs []client.Object
+	if shards > 1 {
+		objs, err = d.renderShards(ctx, gw, vals, gwPorts, shards)
+	} else {
+		d.logger(ctx).V(1).Info("rendering helm chart", "vals", redactValues(vals, d.sensitiveValueKeys()))
+		objs, err = d.Render(ctx, ReleaseName(gw), gw.Namespace, vals)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -178,7 +611,61 @@ func (d *Deployer) renderChartToObjects(ctx context.Context, gw *api.Gateway) ([
 	return objs, nil
 }
 
-func (d *Deployer) Render(ctx context.Context, name, ns string, vals map[string]any) ([]client.Object, error) {
+// maxReleaseNameLength is the maximum length of helm release names
+const maxReleaseNameLength = 53
+
+// ReleaseName returns the name of the helm release rendering the proxy of the given Gateway, qualified with its
+// namespace so that Gateways of the same name in different namespaces do not share a release. Names too long for
+// helm are truncated and suffixed with a hash of the full name to remain unique.
+// The rendered objects are named after the Gateway only, see the gateway.name chart value.
+func ReleaseName(gw *api.Gateway) string {
+	name := fmt.Sprintf("%s-%s", gw.Namespace, gw.Name)
+	if len(name) <= maxReleaseNameLength {
+		return name
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))[:8]
+	prefix := strings.TrimRight(name[:maxReleaseNameLength-len(hash)-1], "-.")
+	return prefix + "-" + hash
+}
+
+// ReleaseRevisionAnnotation is set on the objects rendered with WithReleaseRevision to the revision of the release
+const ReleaseRevisionAnnotation = "gateway2.solo.io/release-revision"
+
+type renderOptions struct {
+	revision int
+	labels   map[string]string
+}
+
+// RenderOption configures the metadata of the helm release rendered by Render
+type RenderOption func(*renderOptions)
+
+// WithReleaseRevision records the revision of the logical release the objects belong to with
+// ReleaseRevisionAnnotation on every rendered object. The chart itself is always rendered as the first
+// revision of a new release, as releases are not persisted.
+func WithReleaseRevision(revision int) RenderOption {
+	return func(o *renderOptions) {
+		o.revision = revision
+	}
+}
+
+// WithReleaseLabels sets labels on the helm release, and on every rendered object without overwriting
+// the labels rendered by the chart. Helm rejects the labels it reserves for its own use, e.g. "name" and "owner".
+func WithReleaseLabels(labels map[string]string) RenderOption {
+	return func(o *renderOptions) {
+		o.labels = labels
+	}
+}
+
+// Render renders the chart as a release of the given name and namespace with the given values
+func (d *Deployer) Render(ctx context.Context, name, ns string, vals map[string]any, opts ...RenderOption) ([]client.Object, error) {
+	var options renderOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.revision < 0 {
+		return nil, fmt.Errorf("%w: release revision must not be negative, got %d", ErrRender, options.revision)
+	}
+
 	mem := driver.NewMemory()
 	mem.SetNamespace(ns)
 	cfg := &action.Configuration{
@@ -188,57 +675,236 @@ func (d *Deployer) Render(ctx context.Context, name, ns string, vals map[string]
 	client.Namespace = ns
 	client.ReleaseName = name
 	client.ClientOnly = true
+	client.Labels = options.labels
 	release, err := client.RunWithContext(ctx, d.chart, vals)
 	if err != nil {
-		return nil, fmt.Errorf("failed to render helm chart: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrRender, err)
 	}
 
 	objs, err := ConvertYAMLToObjects(d.scheme, []byte(release.Manifest))
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert yaml to objects: %w", err)
+		return nil, err
+	}
+	for _, obj := range objs {
+		if len(options.labels) > 0 {
+			mergeLabels(obj, options.labels)
+		}
+		if options.revision > 0 {
+			annotations := obj.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[ReleaseRevisionAnnotation] = strconv.Itoa(options.revision)
+			obj.SetAnnotations(annotations)
+		}
 	}
 	return objs, nil
 }
 
-func (d *Deployer) GetObjsToDeploy(ctx context.Context, gw *api.Gateway) ([]client.Object, error) {
-	objs, err := d.renderChartToObjects(ctx, gw)
+// serviceAnnotations returns the annotations of the proxy Service of the given Gateway, the annotations of the
+// Gateway taking precedence over the inputs
+func (d *Deployer) serviceAnnotations(gw *api.Gateway) (map[string]any, error) {
+	// helm only merges maps of any with the chart defaults
+	annotations := map[string]any{}
+	for k, v := range d.inputs.ServiceAnnotations {
+		annotations[k] = v
+	}
+	if value, ok := gw.GetAnnotations()[ServiceAnnotationsAnnotation]; ok {
+		var overrides map[string]string
+		if err := json.Unmarshal([]byte(value), &overrides); err != nil {
+			return nil, fmt.Errorf("invalid value for annotation %s: must be a JSON object of strings: %w", ServiceAnnotationsAnnotation, err)
+		}
+		for k, v := range overrides {
+			annotations[k] = v
+		}
+	}
+	return annotations, nil
+}
+
+// validateProxyPorts checks that the configured stats and admin ports do not collide with the ports the proxy
+// listens on for the Gateway listeners
+func (d *Deployer) validateProxyPorts(gwPorts []gatewayPort) error {
+	for _, p := range gwPorts {
+		if d.inputs.StatsPort != 0 && p.TargetPort == d.inputs.StatsPort {
+			return fmt.Errorf("stats port %d collides with the target port of listener %s", d.inputs.StatsPort, p.Name)
+		}
+		if d.inputs.AdminPort != 0 && p.TargetPort == d.inputs.AdminPort {
+			return fmt.Errorf("admin port %d collides with the target port of listener %s", d.inputs.AdminPort, p.Name)
+		}
+	}
+	return nil
+}
+
+// GetObjsToDeploy renders the objects required to run a proxy for the given Gateway. If gvks are given,
+// only the objects of these kinds are returned, e.g. to apply the Service without redeploying the Deployment.
+func (d *Deployer) GetObjsToDeploy(ctx context.Context, gw *api.Gateway, gvks ...schema.GroupVersionKind) ([]client.Object, error) {
+	return d.getObjsToDeploy(ctx, gw, d.localXdsAddress(), false, gvks...)
+}
+
+// getObjsToDeploy renders the objects of the proxy of the given Gateway connecting to the control plane at the
+// given xds address. The objects of remote proxies, deployed to another cluster than the one of the Gateway,
+// are labeled with the UID of the Gateway rather than owned by it.
+func (d *Deployer) getObjsToDeploy(
+	ctx context.Context,
+	gw *api.Gateway,
+	xds xdsAddress,
+	remote bool,
+	gvks ...schema.GroupVersionKind,
+) ([]client.Object, error) {
+	if err := d.checkProxyNames(ctx, gw); err != nil {
+		return nil, fmt.Errorf("failed to get objects to deploy: %w", err)
+	}
+	objs, err := d.renderChartToObjects(ctx, gw, xds)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get objects to deploy: %w", err)
 	}
+	if limit := d.inputs.MaxRenderedObjects; limit > 0 && len(objs) > limit {
+		return nil, fmt.Errorf("failed to get objects to deploy: rendered %d objects, exceeding the limit of %d", len(objs), limit)
+	}
+	objs = FilterObjectsByGvk(objs, gvks...)
+
+	labels := d.commonLabels(gw)
+	if remote {
+		labels[GatewayUIDLabel] = string(gw.UID)
+	}
 
 	// Set owner ref
 	trueVal := true
 	for _, obj := range objs {
-		fmt.Printf("xxxxx objToDeploy: kind=%v, namespace=%s, name=%s\n", obj.GetObjectKind(),
-			obj.GetNamespace(), obj.GetName())
+		mergeLabels(obj, labels)
+
+		if !remote {
+			obj.SetOwnerReferences([]metav1.OwnerReference{{
+				Kind:       gw.Kind,
+				APIVersion: gw.APIVersion,
+				Controller: &trueVal,
+				UID:        gw.UID,
+				Name:       gw.Name,
+			}})
+		}
 
-		obj.SetOwnerReferences([]metav1.OwnerReference{{
-			Kind:       gw.Kind,
-			APIVersion: gw.APIVersion,
-			Controller: &trueVal,
-			UID:        gw.UID,
-			Name:       gw.Name,
-		}})
+		if transform, ok := d.inputs.ObjectTransformers[obj.GetObjectKind().GroupVersionKind()]; ok {
+			if err := transform(obj); err != nil {
+				return nil, fmt.Errorf("failed to transform %s %s: %w", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), err)
+			}
+		}
 	}
 
 	return objs, nil
 }
 
+// commonLabels returns the set of labels that is applied to every object deployed for the given Gateway
+func (d *Deployer) commonLabels(gw *api.Gateway) map[string]string {
+	labels := map[string]string{
+		GatewayNameLabel:    gw.Name,
+		ControllerNameLabel: sanitizeLabelValue(d.inputs.ControllerName),
+		ManagedByLabel:      sanitizeLabelValue(d.inputs.ControllerName),
+	}
+	for k, v := range d.inputs.CommonLabels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// mergeLabels adds the given labels to the object, without overwriting any label that is already set
+func mergeLabels(obj client.Object, labels map[string]string) {
+	objLabels := obj.GetLabels()
+	if objLabels == nil {
+		objLabels = make(map[string]string, len(labels))
+	}
+	for k, v := range labels {
+		if _, ok := objLabels[k]; !ok {
+			objLabels[k] = v
+		}
+	}
+	obj.SetLabels(objLabels)
+}
+
+// sanitizeLabelValue converts the given string into a valid label value,
+// e.g. the controller name "solo.io/gloo-gateway" becomes "solo.io.gloo-gateway"
+func sanitizeLabelValue(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '.'
+		}
+	}, s)
+	if len(s) > 63 {
+		s = s[:63]
+	}
+	return strings.Trim(s, "-_.")
+}
+
+// DeployObjs applies the given objects to the cluster with server-side apply. Objects that were already applied
+// with the same desired state (see LastAppliedConfigurationAnnotation) are skipped.
 func (d *Deployer) DeployObjs(ctx context.Context, objs []client.Object, cli client.Client) error {
 	for _, obj := range objs {
+		applied, err := d.isObjApplied(ctx, obj, cli)
+		if err != nil {
+			return err
+		}
+		if applied {
+			d.logger(ctx).V(1).Info("object unchanged since last applied, skipping", "kind", obj.GetObjectKind().GroupVersionKind().Kind, "name", obj.GetName())
+			continue
+		}
 		if err := cli.Patch(ctx, obj, client.Apply, client.ForceOwnership, client.FieldOwner(d.inputs.ControllerName)); err != nil {
-			return fmt.Errorf("failed to apply object %s %s: %w", obj.GetObjectKind().GroupVersionKind().String(), obj.GetName(), err)
+			return fmt.Errorf("%w %s %s: %w", ErrApply, obj.GetObjectKind().GroupVersionKind().String(), obj.GetName(), err)
 		}
 	}
 	return nil
 }
 
+// IsApplied returns true if every given object is present in the cluster, was last applied with its current desired
+// state and was not changed by another client since (see isApplied)
+func (d *Deployer) IsApplied(ctx context.Context, objs []client.Object, cli client.Client) (bool, error) {
+	for _, obj := range objs {
+		applied, err := d.isObjApplied(ctx, obj, cli)
+		if err != nil || !applied {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// isObjApplied annotates the object with the hash of its desired state, and returns whether it is already applied
+func (d *Deployer) isObjApplied(ctx context.Context, obj client.Object, cli client.Client) (bool, error) {
+	hash, err := setLastAppliedConfiguration(obj)
+	if err != nil {
+		return false, fmt.Errorf("%w %s %s: %w", ErrApply, obj.GetObjectKind().GroupVersionKind().String(), obj.GetName(), err)
+	}
+	applied, err := isApplied(ctx, d.scheme, obj, hash, cli)
+	if err != nil {
+		return false, fmt.Errorf("%w %s %s: %w", ErrApply, obj.GetObjectKind().GroupVersionKind().String(), obj.GetName(), err)
+	}
+	return applied, nil
+}
+
+// Deploy renders and applies the objects required to run a proxy for the given Gateway, and records the generation
+// of the Gateway (see LastDeployedGenerationAnnotation) once its objects were successfully applied.
+// Nothing is applied if the current generation of the Gateway was already deployed and all of its objects are still
+// applied; otherwise the objects are applied, so that deleted or changed objects are restored and changes
+// that do not bump the generation of the Gateway are rolled out. Unchanged objects are skipped by DeployObjs.
 func (d *Deployer) Deploy(ctx context.Context, gw *api.Gateway, cli client.Client) error {
 	objs, err := d.GetObjsToDeploy(ctx, gw)
 	if err != nil {
 		return err
 	}
-	return d.DeployObjs(ctx, objs, cli)
+	if IsDeployed(gw) {
+		applied, err := d.IsApplied(ctx, objs, cli)
+		if err != nil {
+			return err
+		}
+		if applied {
+			d.logger(ctx).V(1).Info("gateway generation already deployed, skipping", "generation", gw.GetGeneration())
+			return nil
+		}
+	}
+	if err := d.DeployObjs(ctx, objs, cli); err != nil {
+		return err
+	}
+	return RecordDeployedGeneration(ctx, gw, cli)
 }
 
 func loadFs(filesystem fs.FS) (*chart.Chart, error) {
@@ -286,18 +952,69 @@ func loadFs(filesystem fs.FS) (*chart.Chart, error) {
 	return loader.LoadFiles(bufferedFiles)
 }
 
+// FilterObjectsByGvk returns the objects of the given kinds. All objects are returned if no gvks are given.
+func FilterObjectsByGvk(objs []client.Object, gvks ...schema.GroupVersionKind) []client.Object {
+	if len(gvks) == 0 {
+		return objs
+	}
+	var ret []client.Object
+	for _, obj := range objs {
+		if slices.Contains(gvks, obj.GetObjectKind().GroupVersionKind()) {
+			ret = append(ret, obj)
+		}
+	}
+	return ret
+}
+
+// ExtendScheme returns a scheme containing all the types known to the base scheme, as well as the types
+// registered by the given extensions. The base scheme is returned as-is if there are no extensions.
+func ExtendScheme(base *runtime.Scheme, extensions ...func(*runtime.Scheme) error) (*runtime.Scheme, error) {
+	if len(extensions) == 0 {
+		return base, nil
+	}
+	extended := runtime.NewScheme()
+	for gvk, t := range base.AllKnownTypes() {
+		extended.AddKnownTypeWithName(gvk, reflect.New(t).Interface().(runtime.Object))
+	}
+	for _, extension := range extensions {
+		if err := extension(extended); err != nil {
+			return nil, fmt.Errorf("failed to extend scheme: %w", err)
+		}
+	}
+	return extended, nil
+}
+
+// maxDocumentSnippetLength is the maximum length of the offending document included in decode errors
+const maxDocumentSnippetLength = 120
+
+// MaxDocumentSize is the maximum size in bytes of a document of the manifest, above the size of the largest request
+// the Kubernetes API server accepts, so that valid objects are never rejected while decoding is bounded
+const MaxDocumentSize = 3 * 1024 * 1024
+
 func ConvertYAMLToObjects(scheme *runtime.Scheme, yamlData []byte) ([]client.Object, error) {
 	var objs []client.Object
 
 	// Split the YAML manifest into separate documents
-	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(yamlData), 4096)
-	for {
-		var obj unstructured.Unstructured
-		if err := decoder.Decode(&obj); err != nil {
+	reader := yaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(yamlData)))
+	for index := 1; ; index++ {
+		doc, err := reader.Read()
+		if err != nil {
 			if err == io.EOF {
 				break
 			}
-			return nil, err
+			return nil, fmt.Errorf("%w: failed to read document %d: %w", ErrConvert, index, err)
+		}
+		if len(doc) > MaxDocumentSize {
+			return nil, fmt.Errorf("%w: document %d (%q) is %d bytes, above the maximum of %d bytes",
+				ErrConvert, index, documentSnippet(doc), len(doc), MaxDocumentSize)
+		}
+
+		var obj unstructured.Unstructured
+		if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(doc), 4096).Decode(&obj); err != nil {
+			if err == io.EOF {
+				continue
+			}
+			return nil, fmt.Errorf("%w: failed to decode document %d (%q): %w", ErrConvert, index, documentSnippet(doc), err)
 		}
 		// try to translate to real objects, so they are easier to query later
 		gvk := obj.GetObjectKind().GroupVersionKind()
@@ -319,25 +1036,77 @@ func ConvertYAMLToObjects(scheme *runtime.Scheme, yamlData []byte) ([]client.Obj
 	return objs, nil
 }
 
-func getDeployerImageValues() map[string]any {
+// documentSnippet returns the beginning of the given document, to give context in errors
+func documentSnippet(doc []byte) string {
+	snippet := strings.TrimSpace(string(doc))
+	if len(snippet) > maxDocumentSnippetLength {
+		snippet = snippet[:maxDocumentSnippetLength] + "..."
+	}
+	return snippet
+}
+
+// getDeployerImageValues returns an error if the image of the env is not a valid image reference,
+// e.g. registry:5000/repo:tag or repo@sha256:<digest>
+func (d *Deployer) getDeployerImageValues() (map[string]any, error) {
 	image := os.Getenv(constants.GlooGatewayDeployerImage)
 	defaultImageValues := map[string]any{
 		// If tag is not defined, we fall back to the default behavior, which is to use that Chart version
 		"tag": "",
 	}
+	if d.inputs.ImageRegistryOverride != "" {
+		defaultImageValues["repository"] = overrideImageRegistry(d.defaultImageRepository(), d.inputs.ImageRegistryOverride)
+	}
 
 	if image == "" {
 		// If the env is not defined, return the default
-		return defaultImageValues
+		return defaultImageValues, nil
+	}
+
+	ref, err := reference.Parse(image)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image %q of env %s: %w", image, constants.GlooGatewayDeployerImage, err)
 	}
+	named, ok := ref.(reference.Named)
+	if !ok {
+		return nil, fmt.Errorf("invalid image %q of env %s: the repository is missing", image, constants.GlooGatewayDeployerImage)
+	}
+	repository := named.Name()
+	if d.inputs.ImageRegistryOverride != "" {
+		repository = overrideImageRegistry(repository, d.inputs.ImageRegistryOverride)
+	}
+	// images without a tag default to the version of the chart, unless they are pinned by digest
+	imageValues := map[string]any{
+		"repository": repository,
+		"tag":        "",
+	}
+	if tagged, ok := ref.(reference.Tagged); ok {
+		imageValues["tag"] = tagged.Tag()
+	}
+	if digested, ok := ref.(reference.Digested); ok {
+		imageValues["digest"] = digested.Digest().String()
+	}
+	return imageValues, nil
+}
+
+// defaultImageRepository returns the proxy image repository set in the values of the chart
+func (d *Deployer) defaultImageRepository() string {
+	gateway, _ := d.chart.Values["gateway"].(map[string]any)
+	image, _ := gateway["image"].(map[string]any)
+	repository, _ := image["repository"].(string)
+	return repository
+}
 
-	imageParts := strings.Split(image, ":")
-	if len(imageParts) != 2 {
-		// If the user provided an invalid override, fallback to the default
-		return defaultImageValues
+// overrideImageRegistry replaces the registry host of the given image repository with the given registry.
+// Repositories without an explicit registry host (e.g. "solo-io/gloo-envoy-wrapper") are prefixed with it.
+func overrideImageRegistry(repository, registry string) string {
+	if repository == "" {
+		return repository
 	}
-	return map[string]any{
-		"repository": imageParts[0],
-		"tag":        imageParts[1],
+	registry = strings.TrimSuffix(registry, "/")
+	host, path, found := strings.Cut(repository, "/")
+	// same heuristic as docker: the first component is a registry host if it looks like a domain or a host:port
+	if found && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		return registry + "/" + path
 	}
+	return registry + "/" + repository
 }
@@ -3,23 +3,37 @@ package deployer_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/go-logr/logr/funcr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	api "sigs.k8s.io/gateway-api/apis/v1"
 
 	"github.com/solo-io/gloo/pkg/version"
 	"github.com/solo-io/gloo/projects/gateway2/controller/scheme"
 	"github.com/solo-io/gloo/projects/gateway2/deployer"
 	"github.com/solo-io/gloo/projects/gateway2/wellknown"
+	"github.com/solo-io/gloo/projects/gloo/constants"
 	"github.com/solo-io/gloo/projects/gloo/pkg/bootstrap"
 )
 
@@ -342,6 +356,1866 @@ var _ = Describe("Deployer", func() {
 		}
 	})
 
+	It("should add common labels to all objects without clobbering chart labels", func() {
+		d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+			ControllerName: wellknown.GatewayControllerName,
+			Port:           8080,
+			CommonLabels: map[string]string{
+				"team": "edge",
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+
+		objs, err := d.GetObjsToDeploy(context.Background(), gw)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objs).NotTo(BeEmpty())
+
+		for _, obj := range objs {
+			labels := obj.GetLabels()
+			Expect(labels).To(HaveKeyWithValue(deployer.GatewayNameLabel, "foo"))
+			Expect(labels).To(HaveKeyWithValue(deployer.ControllerNameLabel, "solo.io.gloo-gateway"))
+			Expect(labels).To(HaveKeyWithValue("team", "edge"))
+			// the chart sets managed-by, which must be preserved
+			Expect(labels).To(HaveKeyWithValue(deployer.ManagedByLabel, "Helm"))
+		}
+	})
+
+	It("should only return objects of the requested kinds", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+		allObjs, err := d.GetObjsToDeploy(context.Background(), gw)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allObjs).To(ContainElement(BeAssignableToTypeOf(&appsv1.Deployment{})))
+
+		objs, err := d.GetObjsToDeploy(context.Background(), gw, corev1.SchemeGroupVersion.WithKind("Service"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objs).To(HaveLen(1))
+		Expect(objs[0]).To(BeAssignableToTypeOf(&corev1.Service{}))
+		// filtered objects are still owned by the gateway
+		Expect(objs[0].GetOwnerReferences()).To(HaveLen(1))
+	})
+
+	Context("termination grace period", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+		getPodSpec := func(d *deployer.Deployer) corev1.PodSpec {
+			objs, err := d.GetObjsToDeploy(context.Background(), gw, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(1))
+			return objs[0].(*appsv1.Deployment).Spec.Template.Spec
+		}
+
+		It("should leave the default when unset", func() {
+			Expect(getPodSpec(d).TerminationGracePeriodSeconds).To(BeNil())
+		})
+
+		It("should set the termination grace period on the pod spec", func() {
+			for _, period := range []int64{0, 120} {
+				d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+					ControllerName:                wellknown.GatewayControllerName,
+					Port:                          8080,
+					TerminationGracePeriodSeconds: &period,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(getPodSpec(d).TerminationGracePeriodSeconds).To(Equal(&period))
+			}
+		})
+
+		It("should reject a negative termination grace period", func() {
+			period := int64(-1)
+			_, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName:                wellknown.GatewayControllerName,
+				Port:                          8080,
+				TerminationGracePeriodSeconds: &period,
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("security context", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+		getPodSpec := func(d *deployer.Deployer) corev1.PodSpec {
+			objs, err := d.GetObjsToDeploy(context.Background(), gw, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(1))
+			return objs[0].(*appsv1.Deployment).Spec.Template.Spec
+		}
+		runtimeDefault := &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+
+		It("should render the restricted security context of the chart by default", func() {
+			securityContext := getPodSpec(d).Containers[0].SecurityContext
+			Expect(securityContext.RunAsNonRoot).To(Equal(ptr.To(true)))
+			Expect(securityContext.ReadOnlyRootFilesystem).To(Equal(ptr.To(true)))
+			Expect(securityContext.AllowPrivilegeEscalation).To(Equal(ptr.To(false)))
+		})
+
+		It("should set the security contexts on the pod and proxy container", func() {
+			d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+				PodSecurityContext: &corev1.PodSecurityContext{
+					RunAsNonRoot:   ptr.To(true),
+					RunAsUser:      ptr.To(int64(10101)),
+					FSGroup:        ptr.To(int64(10101)),
+					SeccompProfile: runtimeDefault,
+				},
+				ContainerSecurityContext: &corev1.SecurityContext{
+					RunAsNonRoot:             ptr.To(true),
+					RunAsUser:                ptr.To(int64(10101)),
+					ReadOnlyRootFilesystem:   ptr.To(true),
+					AllowPrivilegeEscalation: ptr.To(false),
+					Capabilities: &corev1.Capabilities{
+						Drop: []corev1.Capability{"ALL"},
+					},
+					SeccompProfile: runtimeDefault,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			podSpec := getPodSpec(d)
+			Expect(podSpec.SecurityContext).To(Equal(&corev1.PodSecurityContext{
+				RunAsNonRoot:   ptr.To(true),
+				RunAsUser:      ptr.To(int64(10101)),
+				FSGroup:        ptr.To(int64(10101)),
+				SeccompProfile: runtimeDefault,
+			}))
+			securityContext := podSpec.Containers[0].SecurityContext
+			Expect(securityContext.RunAsNonRoot).To(Equal(ptr.To(true)))
+			Expect(securityContext.RunAsUser).To(Equal(ptr.To(int64(10101))))
+			Expect(securityContext.ReadOnlyRootFilesystem).To(Equal(ptr.To(true)))
+			Expect(securityContext.AllowPrivilegeEscalation).To(Equal(ptr.To(false)))
+			Expect(securityContext.Capabilities.Drop).To(ConsistOf(corev1.Capability("ALL")))
+			Expect(securityContext.SeccompProfile).To(Equal(runtimeDefault))
+		})
+
+		DescribeTable("should reject invalid security contexts",
+			func(pod *corev1.PodSecurityContext, container *corev1.SecurityContext) {
+				_, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+					ControllerName:           wellknown.GatewayControllerName,
+					Port:                     8080,
+					PodSecurityContext:       pod,
+					ContainerSecurityContext: container,
+				})
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("pod running as root with runAsNonRoot",
+				&corev1.PodSecurityContext{RunAsNonRoot: ptr.To(true), RunAsUser: ptr.To(int64(0))}, nil),
+			Entry("container running as root with runAsNonRoot",
+				nil, &corev1.SecurityContext{RunAsNonRoot: ptr.To(true), RunAsUser: ptr.To(int64(0))}),
+			Entry("privileged container without privilege escalation",
+				nil, &corev1.SecurityContext{Privileged: ptr.To(true), AllowPrivilegeEscalation: ptr.To(false)}),
+		)
+	})
+
+	Context("automount service account token", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+		getPodSpec := func(d *deployer.Deployer) corev1.PodSpec {
+			objs, err := d.GetObjsToDeploy(context.Background(), gw, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(1))
+			return objs[0].(*appsv1.Deployment).Spec.Template.Spec
+		}
+
+		It("should leave the default of the service account when unset", func() {
+			Expect(getPodSpec(d).AutomountServiceAccountToken).To(BeNil())
+		})
+
+		It("should set automountServiceAccountToken on the pod spec", func() {
+			for _, automount := range []bool{false, true} {
+				d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+					ControllerName:               wellknown.GatewayControllerName,
+					Port:                         8080,
+					AutomountServiceAccountToken: &automount,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(getPodSpec(d).AutomountServiceAccountToken).To(Equal(&automount))
+			}
+		})
+	})
+
+	Context("runtime class", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+		getPodSpec := func(d *deployer.Deployer) corev1.PodSpec {
+			objs, err := d.GetObjsToDeploy(context.Background(), gw, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(1))
+			return objs[0].(*appsv1.Deployment).Spec.Template.Spec
+		}
+		newDeployer := func(runtimeClassName *string) (*deployer.Deployer, error) {
+			return deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName:   wellknown.GatewayControllerName,
+				Port:             8080,
+				RuntimeClassName: runtimeClassName,
+			})
+		}
+
+		It("should omit the runtime class when unset", func() {
+			Expect(getPodSpec(d).RuntimeClassName).To(BeNil())
+		})
+
+		It("should set the runtime class on the pod spec", func() {
+			d, err := newDeployer(ptr.To("gvisor"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(getPodSpec(d).RuntimeClassName).To(Equal(ptr.To("gvisor")))
+		})
+
+		It("should reject an empty runtime class", func() {
+			_, err := newDeployer(ptr.To(""))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("dns", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+		getPodSpec := func(d *deployer.Deployer) corev1.PodSpec {
+			objs, err := d.GetObjsToDeploy(context.Background(), gw, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(1))
+			return objs[0].(*appsv1.Deployment).Spec.Template.Spec
+		}
+		newDeployer := func(policy corev1.DNSPolicy, config *corev1.PodDNSConfig) (*deployer.Deployer, error) {
+			return deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+				DNSPolicy:      policy,
+				DNSConfig:      config,
+			})
+		}
+
+		It("should leave the defaults when unset", func() {
+			podSpec := getPodSpec(d)
+			Expect(podSpec.DNSPolicy).To(BeEmpty())
+			Expect(podSpec.DNSConfig).To(BeNil())
+		})
+
+		It("should set the dns policy and an ndots override on the pod spec", func() {
+			dnsConfig := &corev1.PodDNSConfig{
+				Options: []corev1.PodDNSConfigOption{{Name: "ndots", Value: ptr.To("2")}},
+			}
+			d, err := newDeployer(corev1.DNSClusterFirst, dnsConfig)
+			Expect(err).NotTo(HaveOccurred())
+			podSpec := getPodSpec(d)
+			Expect(podSpec.DNSPolicy).To(Equal(corev1.DNSClusterFirst))
+			Expect(podSpec.DNSConfig).To(Equal(dnsConfig))
+		})
+
+		It("should set a custom dns config with the None policy", func() {
+			dnsConfig := &corev1.PodDNSConfig{
+				Nameservers: []string{"10.0.0.10"},
+				Searches:    []string{"default.svc.cluster.local"},
+			}
+			d, err := newDeployer(corev1.DNSNone, dnsConfig)
+			Expect(err).NotTo(HaveOccurred())
+			podSpec := getPodSpec(d)
+			Expect(podSpec.DNSPolicy).To(Equal(corev1.DNSNone))
+			Expect(podSpec.DNSConfig).To(Equal(dnsConfig))
+		})
+
+		It("should reject an unknown dns policy", func() {
+			_, err := newDeployer("ClusterLast", nil)
+			Expect(err).To(MatchError(ContainSubstring(`unknown dns policy "ClusterLast"`)))
+		})
+
+		It("should reject the None policy without nameservers", func() {
+			_, err := newDeployer(corev1.DNSNone, &corev1.PodDNSConfig{
+				Options: []corev1.PodDNSConfigOption{{Name: "ndots", Value: ptr.To("2")}},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("preStop hook", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+		getProxyContainer := func(d *deployer.Deployer) corev1.Container {
+			objs, err := d.GetObjsToDeploy(context.Background(), gw, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(1))
+			return objs[0].(*appsv1.Deployment).Spec.Template.Spec.Containers[0]
+		}
+
+		It("should not set a lifecycle by default", func() {
+			Expect(getProxyContainer(d).Lifecycle).To(BeNil())
+		})
+
+		It("should render the preStop hook on the proxy container", func() {
+			hook := &corev1.LifecycleHandler{
+				Exec: &corev1.ExecAction{Command: []string{"/bin/sh", "-c", "sleep 15"}},
+			}
+			d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+				PreStopHook:    hook,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			lifecycle := getProxyContainer(d).Lifecycle
+			Expect(lifecycle).NotTo(BeNil())
+			Expect(lifecycle.PreStop).To(Equal(hook))
+		})
+
+		It("should reject a hook without a handler", func() {
+			_, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+				PreStopHook:    &corev1.LifecycleHandler{},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("sidecar containers", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+		newDeployer := func(sidecars ...corev1.Container) (*deployer.Deployer, error) {
+			return deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName:    wellknown.GatewayControllerName,
+				Port:              8080,
+				SidecarContainers: sidecars,
+			})
+		}
+
+		It("should add sidecars alongside the proxy container", func() {
+			sidecar := corev1.Container{
+				Name:  "log-shipper",
+				Image: "fluent/fluent-bit:2.2",
+				Args:  []string{"-c", "/etc/fluent-bit/fluent-bit.conf"},
+			}
+			d, err := newDeployer(sidecar)
+			Expect(err).NotTo(HaveOccurred())
+
+			objs, err := d.GetObjsToDeploy(context.Background(), gw, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(1))
+			containers := objs[0].(*appsv1.Deployment).Spec.Template.Spec.Containers
+			Expect(containers).To(HaveLen(2))
+			Expect(containers[0].Name).To(Equal("gloo-gateway"))
+			Expect(containers[1]).To(Equal(sidecar))
+		})
+
+		It("should reject sidecars with duplicate names", func() {
+			_, err := newDeployer(
+				corev1.Container{Name: "metrics", Image: "metrics:1"},
+				corev1.Container{Name: "metrics", Image: "metrics:2"},
+			)
+			Expect(err).To(MatchError(ContainSubstring("duplicate container name")))
+
+			_, err = newDeployer(corev1.Container{Name: "gloo-gateway", Image: "metrics:1"})
+			Expect(err).To(MatchError(ContainSubstring("duplicate container name")))
+		})
+
+		It("should reject sidecars without an image", func() {
+			_, err := newDeployer(corev1.Container{Name: "metrics"})
+			Expect(err).To(MatchError(ContainSubstring("must have an image")))
+		})
+	})
+
+	Context("replicas", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+		render := func(inputs *deployer.Inputs) (*appsv1.Deployment, *autoscalingv2.HorizontalPodAutoscaler) {
+			inputs.ControllerName = wellknown.GatewayControllerName
+			inputs.Port = 8080
+			d, err := deployer.NewDeployer(scheme.NewScheme(), inputs)
+			Expect(err).NotTo(HaveOccurred())
+			objs, err := d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).NotTo(HaveOccurred())
+
+			var (
+				dep *appsv1.Deployment
+				hpa *autoscalingv2.HorizontalPodAutoscaler
+			)
+			for _, obj := range objs {
+				switch obj := obj.(type) {
+				case *appsv1.Deployment:
+					dep = obj
+				case *autoscalingv2.HorizontalPodAutoscaler:
+					hpa = obj
+				}
+			}
+			Expect(dep).NotTo(BeNil())
+			return dep, hpa
+		}
+
+		It("should default to a single replica", func() {
+			dep, hpa := render(&deployer.Inputs{})
+			Expect(dep.Spec.Replicas).To(Equal(ptr.To[int32](1)))
+			Expect(hpa).To(BeNil())
+		})
+
+		It("should render an explicit replica count", func() {
+			dep, hpa := render(&deployer.Inputs{Replicas: ptr.To[int32](3)})
+			Expect(dep.Spec.Replicas).To(Equal(ptr.To[int32](3)))
+			Expect(hpa).To(BeNil())
+		})
+
+		It("should omit replicas when autoscaling is enabled", func() {
+			dep, hpa := render(&deployer.Inputs{
+				Replicas: ptr.To[int32](3),
+				Autoscaling: &deployer.AutoscalingConfig{
+					MinReplicas: 2,
+					MaxReplicas: 5,
+				},
+			})
+			Expect(dep.Spec.Replicas).To(BeNil())
+			Expect(hpa).NotTo(BeNil())
+			Expect(hpa.Spec.MinReplicas).To(Equal(ptr.To[int32](2)))
+			Expect(hpa.Spec.MaxReplicas).To(Equal(int32(5)))
+			Expect(hpa.Spec.ScaleTargetRef.Name).To(Equal(dep.Name))
+		})
+
+		It("should reject invalid replica counts", func() {
+			_, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{Replicas: ptr.To[int32](-1)})
+			Expect(err).To(HaveOccurred())
+			_, err = deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				Autoscaling: &deployer.AutoscalingConfig{MinReplicas: 3, MaxReplicas: 2},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("service monitor", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+		serviceMonitorGvk := schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+
+		It("should not render a service monitor by default", func() {
+			objs, err := d.GetObjsToDeploy(context.Background(), gw, serviceMonitorGvk)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(BeEmpty())
+
+			gvks, err := d.GetGvksToWatch(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gvks).NotTo(ContainElement(serviceMonitorGvk))
+		})
+
+		It("should render a service monitor scraping the proxy service", func() {
+			d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+				ServiceMonitor: &deployer.ServiceMonitorConfig{
+					Interval: time.Minute,
+					Path:     "/stats",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			gvks, err := d.GetGvksToWatch(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gvks).To(ContainElement(serviceMonitorGvk))
+
+			objs, err := d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).NotTo(HaveOccurred())
+
+			var (
+				svc *corev1.Service
+				sm  *unstructured.Unstructured
+			)
+			for _, obj := range objs {
+				switch obj := obj.(type) {
+				case *corev1.Service:
+					svc = obj
+				case *unstructured.Unstructured:
+					if obj.GroupVersionKind() == serviceMonitorGvk {
+						sm = obj
+					}
+				}
+			}
+			Expect(svc).NotTo(BeNil())
+			Expect(sm).NotTo(BeNil())
+			Expect(sm.GetOwnerReferences()).To(HaveLen(1))
+
+			Expect(svc.Spec.Ports).To(ContainElement(corev1.ServicePort{
+				Name:       "http-monitoring",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       9091,
+				TargetPort: intstr.FromInt(9091),
+			}))
+
+			selector, _, err := unstructured.NestedStringMap(sm.Object, "spec", "selector", "matchLabels")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selector).NotTo(BeEmpty())
+			for k, v := range selector {
+				Expect(svc.Labels).To(HaveKeyWithValue(k, v))
+			}
+
+			endpoints, _, err := unstructured.NestedSlice(sm.Object, "spec", "endpoints")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(endpoints).To(ConsistOf(map[string]any{
+				"port":     "http-monitoring",
+				"path":     "/stats",
+				"interval": "60s",
+			}))
+
+			// the metrics are served by envoy on the stats port
+			var envoyConfig map[string]any
+			Expect(yaml.Unmarshal([]byte(getEnvoyConfig(objs)), &envoyConfig)).To(Succeed())
+			listeners := envoyConfig["static_resources"].(map[string]any)["listeners"].([]any)
+			Expect(listeners).To(ContainElement(HaveKeyWithValue("name", "prometheus_listener")))
+		})
+
+		It("should reject an invalid path", func() {
+			_, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ServiceMonitor: &deployer.ServiceMonitorConfig{Path: "metrics"},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("proxy ports", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+			Spec: api.GatewaySpec{
+				Listeners: []api.Listener{
+					{
+						Name: "listener-1",
+						Port: 80,
+					},
+				},
+			},
+		}
+
+		It("should render custom stats and admin ports", func() {
+			d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+				ServiceMonitor: &deployer.ServiceMonitorConfig{},
+				StatsPort:      9191,
+				AdminPort:      19001,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			objs, err := d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).NotTo(HaveOccurred())
+
+			var svc *corev1.Service
+			for _, obj := range objs {
+				if s, ok := obj.(*corev1.Service); ok {
+					svc = s
+				}
+			}
+			Expect(svc).NotTo(BeNil())
+			Expect(svc.Spec.Ports).To(ContainElement(corev1.ServicePort{
+				Name:       "http-monitoring",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       9191,
+				TargetPort: intstr.FromInt(9191),
+			}))
+
+			var envoyConfig map[string]any
+			Expect(yaml.Unmarshal([]byte(getEnvoyConfig(objs)), &envoyConfig)).To(Succeed())
+			adminAddress := envoyConfig["admin"].(map[string]any)["address"].(map[string]any)["socket_address"]
+			Expect(adminAddress).To(HaveKeyWithValue("port_value", BeNumerically("==", 19001)))
+		})
+
+		It("should reject a stats port colliding with a listener target port", func() {
+			d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+				StatsPort:      8080,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).To(MatchError(ContainSubstring("stats port 8080 collides with the target port of listener listener-1")))
+		})
+
+		It("should reject an admin port colliding with a listener target port", func() {
+			d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+				AdminPort:      8080,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).To(MatchError(ContainSubstring("admin port 8080 collides with the target port of listener listener-1")))
+		})
+
+		It("should reject equal stats and admin ports", func() {
+			_, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				StatsPort: 9191,
+				AdminPort: 9191,
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("logging", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+		var lines []string
+		newDeployer := func(verbosity int) (*deployer.Deployer, error) {
+			lines = nil
+			return deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+				XdsTLS: deployer.XdsTLSConfig{
+					Enabled:      true,
+					CASecretName: "xds-ca",
+				},
+				SidecarContainers: []corev1.Container{{
+					Name:  "log-shipper",
+					Image: "fluent/fluent-bit",
+					Env:   []corev1.EnvVar{{Name: "API_KEY", Value: "hunter2"}},
+				}},
+				Logger: funcr.New(func(prefix, args string) {
+					lines = append(lines, args)
+				}, funcr.Options{Verbosity: verbosity}),
+				SensitiveValueKeys: []string{"secretName"},
+			})
+		}
+
+		It("should not log the values at info level", func() {
+			d, err := newDeployer(0)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lines).To(BeEmpty())
+		})
+
+		It("should redact sensitive values when logging at debug level", func() {
+			d, err := newDeployer(1)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lines).To(ContainElement(And(
+				ContainSubstring(`"level"=1`),
+				ContainSubstring("rendering helm chart"),
+				ContainSubstring(`"secretName":"<redacted>"`),
+				ContainSubstring(`"env":"<redacted>"`),
+			)))
+			for _, line := range lines {
+				Expect(line).NotTo(ContainSubstring("xds-ca"))
+				Expect(line).NotTo(ContainSubstring("hunter2"))
+			}
+		})
+	})
+
+	Context("max rendered objects", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+		newDeployer := func(maxRenderedObjects int) (*deployer.Deployer, error) {
+			return deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName:     wellknown.GatewayControllerName,
+				Port:               8080,
+				MaxRenderedObjects: maxRenderedObjects,
+			})
+		}
+
+		It("should enforce the limit on the rendered objects", func() {
+			d, err := newDeployer(0)
+			Expect(err).NotTo(HaveOccurred())
+			objs, err := d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).NotTo(HaveOccurred())
+			count := len(objs)
+
+			d, err = newDeployer(count)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).NotTo(HaveOccurred())
+
+			d, err = newDeployer(count - 1)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("rendered %d objects, exceeding the limit of %d", count, count-1))))
+		})
+
+		It("should count the rendered objects before filtering by kind", func() {
+			d, err := newDeployer(1)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = d.GetObjsToDeploy(context.Background(), gw, schema.GroupVersionKind{Version: "v1", Kind: "Service"})
+			Expect(err).To(MatchError(ContainSubstring("exceeding the limit of 1")))
+		})
+
+		It("should reject a negative limit", func() {
+			_, err := newDeployer(-1)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("service annotations", func() {
+		newGateway := func(annotations map[string]string) *api.Gateway {
+			return &api.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "foo",
+					Namespace:   "default",
+					UID:         "1235",
+					Annotations: annotations,
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Gateway",
+					APIVersion: "gateway.solo.io/v1beta1",
+				},
+			}
+		}
+		getService := func(gw *api.Gateway) (*corev1.Service, error) {
+			d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+				ServiceAnnotations: map[string]string{
+					"service.beta.kubernetes.io/aws-load-balancer-type":   "nlb",
+					"service.beta.kubernetes.io/aws-load-balancer-scheme": "internet-facing",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			objs, err := d.GetObjsToDeploy(context.Background(), gw)
+			if err != nil {
+				return nil, err
+			}
+			for _, obj := range objs {
+				if svc, ok := obj.(*corev1.Service); ok {
+					return svc, nil
+				}
+			}
+			return nil, nil
+		}
+
+		It("should not annotate the service by default", func() {
+			objs, err := d.GetObjsToDeploy(context.Background(), newGateway(nil))
+			Expect(err).NotTo(HaveOccurred())
+			for _, obj := range objs {
+				if svc, ok := obj.(*corev1.Service); ok {
+					Expect(svc.Annotations).To(BeEmpty())
+				}
+			}
+		})
+
+		It("should add the input annotations to the service", func() {
+			svc, err := getService(newGateway(nil))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(svc).NotTo(BeNil())
+			Expect(svc.Annotations).To(Equal(map[string]string{
+				"service.beta.kubernetes.io/aws-load-balancer-type":   "nlb",
+				"service.beta.kubernetes.io/aws-load-balancer-scheme": "internet-facing",
+			}))
+		})
+
+		It("should let the gateway annotation override the input annotations", func() {
+			svc, err := getService(newGateway(map[string]string{
+				deployer.ServiceAnnotationsAnnotation: `{"service.beta.kubernetes.io/aws-load-balancer-scheme": "internal", "example.com/team": "edge"}`,
+			}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(svc).NotTo(BeNil())
+			Expect(svc.Annotations).To(Equal(map[string]string{
+				"service.beta.kubernetes.io/aws-load-balancer-type":   "nlb",
+				"service.beta.kubernetes.io/aws-load-balancer-scheme": "internal",
+				"example.com/team": "edge",
+			}))
+		})
+
+		It("should reject an invalid gateway annotation", func() {
+			_, err := getService(newGateway(map[string]string{
+				deployer.ServiceAnnotationsAnnotation: "internal",
+			}))
+			Expect(err).To(MatchError(ContainSubstring(deployer.ServiceAnnotationsAnnotation)))
+		})
+	})
+
+	Context("gateway class parameters", func() {
+		newGateway := func(annotations map[string]string) *api.Gateway {
+			return &api.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "foo",
+					Namespace:   "default",
+					UID:         "1235",
+					Annotations: annotations,
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Gateway",
+					APIVersion: "gateway.solo.io/v1beta1",
+				},
+				Spec: api.GatewaySpec{
+					GatewayClassName: wellknown.GatewayClassName,
+				},
+			}
+		}
+		gwClass := func(ref *api.ParametersReference) *api.GatewayClass {
+			return &api.GatewayClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: wellknown.GatewayClassName,
+				},
+				Spec: api.GatewayClassSpec{
+					ControllerName: wellknown.GatewayControllerName,
+					ParametersRef:  ref,
+				},
+			}
+		}
+		paramsRef := &api.ParametersReference{
+			Kind:      "ConfigMap",
+			Name:      "proxy-params",
+			Namespace: ptr.To(api.Namespace("gloo-system")),
+		}
+		params := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "proxy-params",
+				Namespace: "gloo-system",
+			},
+			Data: map[string]string{
+				deployer.ReplicasParameter:    "3",
+				deployer.ServiceTypeParameter: "NodePort",
+				deployer.ResourcesParameter:   `{"limits": {"cpu": "500m"}}`,
+			},
+		}
+		render := func(gw *api.Gateway, clusterObjs ...client.Object) (*appsv1.Deployment, *corev1.Service, error) {
+			cli := fake.NewClientBuilder().WithScheme(scheme.NewScheme()).WithObjects(clusterObjs...).Build()
+			d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+				Replicas:       ptr.To[int32](1),
+				ClusterReader:  cli,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			objs, err := d.GetObjsToDeploy(context.Background(), gw)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			var (
+				dep *appsv1.Deployment
+				svc *corev1.Service
+			)
+			for _, obj := range objs {
+				switch obj := obj.(type) {
+				case *appsv1.Deployment:
+					dep = obj
+				case *corev1.Service:
+					svc = obj
+				}
+			}
+			Expect(dep).NotTo(BeNil())
+			Expect(svc).NotTo(BeNil())
+			return dep, svc, nil
+		}
+		proxyResources := func(dep *appsv1.Deployment) corev1.ResourceRequirements {
+			for _, c := range dep.Spec.Template.Spec.Containers {
+				if c.Name == "gloo-gateway" {
+					return c.Resources
+				}
+			}
+			Fail("no proxy container rendered")
+			return corev1.ResourceRequirements{}
+		}
+
+		It("should use the inputs without parameters", func() {
+			dep, svc, err := render(newGateway(nil), gwClass(nil))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dep.Spec.Replicas).To(Equal(ptr.To[int32](1)))
+			Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeLoadBalancer))
+			Expect(proxyResources(dep).Limits).To(BeEmpty())
+		})
+
+		It("should apply the parameters of the gateway class", func() {
+			dep, svc, err := render(newGateway(nil), gwClass(paramsRef), params)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dep.Spec.Replicas).To(Equal(ptr.To[int32](3)))
+			Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeNodePort))
+			Expect(proxyResources(dep).Limits).To(HaveKeyWithValue(corev1.ResourceCPU, resource.MustParse("500m")))
+		})
+
+		It("should let the gateway annotations override the parameters of the gateway class", func() {
+			dep, svc, err := render(newGateway(map[string]string{
+				deployer.ReplicasAnnotation:    "5",
+				deployer.ServiceTypeAnnotation: "ClusterIP",
+			}), gwClass(paramsRef), params)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dep.Spec.Replicas).To(Equal(ptr.To[int32](5)))
+			Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeClusterIP))
+			// settings which are not overridden are kept from the gateway class
+			Expect(proxyResources(dep).Limits).To(HaveKeyWithValue(corev1.ResourceCPU, resource.MustParse("500m")))
+		})
+
+		It("should fail when the parameters do not exist", func() {
+			_, _, err := render(newGateway(nil), gwClass(paramsRef))
+			Expect(err).To(MatchError(ContainSubstring("failed to get parameters gloo-system.proxy-params")))
+		})
+
+		It("should reject unsupported parameters kinds", func() {
+			_, _, err := render(newGateway(nil), gwClass(&api.ParametersReference{
+				Group: "example.com",
+				Kind:  "ProxyConfig",
+				Name:  "proxy-params",
+			}))
+			Expect(err).To(MatchError(ContainSubstring("only ConfigMaps are supported")))
+		})
+
+		It("should reject invalid overrides", func() {
+			_, _, err := render(newGateway(map[string]string{
+				deployer.ServiceTypeAnnotation: "ExternalName",
+			}), gwClass(paramsRef), params)
+			Expect(err).To(MatchError(ContainSubstring(deployer.ServiceTypeAnnotation)))
+		})
+	})
+
+	Context("release name", func() {
+		newGateway := func(ns, name string) *api.Gateway {
+			return &api.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: ns,
+					UID:       "1235",
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Gateway",
+					APIVersion: "gateway.solo.io/v1beta1",
+				},
+			}
+		}
+
+		It("should qualify the release name with the namespace", func() {
+			Expect(deployer.ReleaseName(newGateway("default", "foo"))).To(Equal("default-foo"))
+			Expect(deployer.ReleaseName(newGateway("other", "foo"))).To(Equal("other-foo"))
+		})
+
+		It("should truncate long release names to unique valid names", func() {
+			name1 := deployer.ReleaseName(newGateway("default", strings.Repeat("a", 60)+"-1"))
+			name2 := deployer.ReleaseName(newGateway("default", strings.Repeat("a", 60)+"-2"))
+			Expect(name1).NotTo(Equal(name2))
+			for _, name := range []string{name1, name2} {
+				Expect(len(name)).To(BeNumerically("<=", 53))
+				Expect(name).To(HavePrefix("default-aaaa"))
+			}
+		})
+
+		It("should name the objects and selectors after the gateway only", func() {
+			objs, err := d.GetObjsToDeploy(context.Background(), newGateway("other", "foo"))
+			Expect(err).NotTo(HaveOccurred())
+			for _, obj := range objs {
+				Expect(obj.GetName()).To(Equal("gloo-proxy-foo"))
+				if dep, ok := obj.(*appsv1.Deployment); ok {
+					Expect(dep.Spec.Selector.MatchLabels).To(HaveKeyWithValue("app.kubernetes.io/instance", "foo"))
+				}
+			}
+		})
+	})
+
+	Context("object names", func() {
+		newGateway := func(ns, name string) *api.Gateway {
+			return &api.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: ns,
+					UID:       "1235",
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Gateway",
+					APIVersion: "gateway.solo.io/v1beta1",
+				},
+			}
+		}
+		objectNames := func(gw *api.Gateway) map[string]string {
+			objs, err := d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).NotTo(HaveOccurred())
+			names := map[string]string{}
+			for _, obj := range objs {
+				names[obj.GetObjectKind().GroupVersionKind().Kind] = obj.GetName()
+			}
+			return names
+		}
+
+		It("should keep the names stable across renders", func() {
+			gw := newGateway("default", "foo")
+			names := objectNames(gw)
+			Expect(names).NotTo(BeEmpty())
+			for _, name := range names {
+				Expect(name).To(Equal(deployer.ProxyName(gw)))
+			}
+			Expect(objectNames(gw)).To(Equal(names))
+		})
+
+		It("should keep the names independent of the namespace and release name", func() {
+			Expect(objectNames(newGateway("other", "foo"))).To(Equal(objectNames(newGateway("default", "foo"))))
+		})
+
+		It("should truncate long names to valid label values", func() {
+			gw := newGateway("default", strings.Repeat("a", 51)+"-b")
+			Expect(deployer.ProxyName(gw)).To(Equal("gloo-proxy-" + strings.Repeat("a", 51)))
+			for _, name := range objectNames(gw) {
+				Expect(name).To(Equal(deployer.ProxyName(gw)))
+			}
+		})
+
+		It("should reference the objects by their pinned names", func() {
+			gw := newGateway("default", "foo")
+			objs, err := d.GetObjsToDeploy(context.Background(), gw, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(1))
+			podSpec := objs[0].(*appsv1.Deployment).Spec.Template.Spec
+			Expect(podSpec.ServiceAccountName).To(Equal(deployer.ProxyName(gw)))
+			Expect(podSpec.Volumes).To(ContainElement(HaveField("ConfigMap.Name", deployer.ProxyName(gw))))
+		})
+	})
+
+	Context("release metadata", func() {
+		vals := map[string]any{
+			"gateway": map[string]any{
+				"enabled": true,
+				"name":    "foo",
+				"ports":   []any{},
+			},
+		}
+
+		It("should not stamp release metadata by default", func() {
+			objs, err := d.Render(context.Background(), "default-foo", "default", vals)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).NotTo(BeEmpty())
+			for _, obj := range objs {
+				Expect(obj.GetAnnotations()).NotTo(HaveKey(deployer.ReleaseRevisionAnnotation))
+				Expect(obj.GetLabels()).NotTo(HaveKey("example.com/release"))
+			}
+		})
+
+		It("should stamp the configured release metadata on the rendered objects", func() {
+			objs, err := d.Render(context.Background(), "default-foo", "default", vals,
+				deployer.WithReleaseRevision(3),
+				deployer.WithReleaseLabels(map[string]string{
+					"example.com/release":    "edge",
+					"app.kubernetes.io/name": "overridden",
+				}),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).NotTo(BeEmpty())
+			for _, obj := range objs {
+				Expect(obj.GetAnnotations()).To(HaveKeyWithValue(deployer.ReleaseRevisionAnnotation, "3"))
+				Expect(obj.GetLabels()).To(HaveKeyWithValue("example.com/release", "edge"))
+				// labels rendered by the chart are not overwritten
+				Expect(obj.GetLabels()["app.kubernetes.io/name"]).NotTo(Equal("overridden"))
+			}
+		})
+
+		It("should reject labels reserved by helm", func() {
+			_, err := d.Render(context.Background(), "default-foo", "default", vals,
+				deployer.WithReleaseLabels(map[string]string{"owner": "me"}))
+			Expect(err).To(MatchError(deployer.ErrRender))
+		})
+
+		It("should reject a negative revision", func() {
+			_, err := d.Render(context.Background(), "default-foo", "default", vals, deployer.WithReleaseRevision(-1))
+			Expect(err).To(MatchError(deployer.ErrRender))
+		})
+	})
+
+	Context("auto service type", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+
+		renderServiceType := func(autoServiceType bool, clusterObjs ...client.Object) corev1.ServiceType {
+			cli := fake.NewClientBuilder().WithScheme(scheme.NewScheme()).WithObjects(clusterObjs...).Build()
+			d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName:  wellknown.GatewayControllerName,
+				Port:            8080,
+				AutoServiceType: autoServiceType,
+				ClusterReader:   cli,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			objs, err := d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).NotTo(HaveOccurred())
+			for _, obj := range objs {
+				if svc, ok := obj.(*corev1.Service); ok {
+					return svc.Spec.Type
+				}
+			}
+			Fail("no service rendered")
+			return ""
+		}
+
+		node := func(instanceType string) *corev1.Node {
+			n := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node"},
+			}
+			if instanceType != "" {
+				n.Labels = map[string]string{corev1.LabelInstanceTypeStable: instanceType}
+			}
+			return n
+		}
+
+		lbService := func(namespace string) *corev1.Service {
+			return &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "lb", Namespace: namespace},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+				Status: corev1.ServiceStatus{
+					LoadBalancer: corev1.LoadBalancerStatus{
+						Ingress: []corev1.LoadBalancerIngress{{IP: "172.18.0.100"}},
+					},
+				},
+			}
+		}
+
+		It("should keep LoadBalancer when disabled", func() {
+			Expect(renderServiceType(false, node(""))).To(Equal(corev1.ServiceTypeLoadBalancer))
+		})
+
+		It("should fall back to NodePort on a local cluster", func() {
+			Expect(renderServiceType(true, node(""))).To(Equal(corev1.ServiceTypeNodePort))
+			Expect(renderServiceType(true, node("k3s"))).To(Equal(corev1.ServiceTypeNodePort))
+		})
+
+		It("should keep LoadBalancer on a cloud provider", func() {
+			Expect(renderServiceType(true, node("m5.large"))).To(Equal(corev1.ServiceTypeLoadBalancer))
+		})
+
+		It("should keep LoadBalancer when a load balancer controller is assigning addresses", func() {
+			Expect(renderServiceType(true, node(""), lbService("default"))).To(Equal(corev1.ServiceTypeLoadBalancer))
+		})
+
+		It("should only probe the Services of the Gateway's namespace", func() {
+			Expect(renderServiceType(true, node(""), lbService("other"))).To(Equal(corev1.ServiceTypeNodePort))
+		})
+	})
+
+	Context("wait for ready", func() {
+		var (
+			gw   *api.Gateway
+			cli  client.Client
+			objs []client.Object
+		)
+		BeforeEach(func() {
+			gw = &api.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "default",
+					UID:       "1235",
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Gateway",
+					APIVersion: "gateway.solo.io/v1beta1",
+				},
+			}
+			var err error
+			objs, err = d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).NotTo(HaveOccurred())
+
+			// the fake client does not support server-side apply, so create the objects directly
+			cli = fake.NewClientBuilder().WithScheme(scheme.NewScheme()).WithObjects(objs...).Build()
+		})
+
+		markReady := func() {
+			defer GinkgoRecover()
+			for _, obj := range objs {
+				switch obj := obj.(type) {
+				case *appsv1.Deployment:
+					var dep appsv1.Deployment
+					Expect(cli.Get(context.Background(), client.ObjectKeyFromObject(obj), &dep)).To(Succeed())
+					dep.Status.AvailableReplicas = 1
+					Expect(cli.Status().Update(context.Background(), &dep)).To(Succeed())
+				case *corev1.Service:
+					var svc corev1.Service
+					Expect(cli.Get(context.Background(), client.ObjectKeyFromObject(obj), &svc)).To(Succeed())
+					svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "127.0.0.1"}}
+					Expect(cli.Status().Update(context.Background(), &svc)).To(Succeed())
+				}
+			}
+		}
+
+		It("should return once the deployment and service are ready", func() {
+			go func() {
+				time.Sleep(500 * time.Millisecond)
+				markReady()
+			}()
+			Expect(d.WaitForReady(context.Background(), gw, cli, 5*time.Second)).To(Succeed())
+		})
+
+		It("should time out if the proxy never becomes ready", func() {
+			err := d.WaitForReady(context.Background(), gw, cli, 500*time.Millisecond)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("is not ready"))
+		})
+	})
+
+	Context("image registry override", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+		getObjs := func(registryOverride string) ([]client.Object, error) {
+			d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName:        wellknown.GatewayControllerName,
+				Port:                  8080,
+				ImageRegistryOverride: registryOverride,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return d.GetObjsToDeploy(context.Background(), gw)
+		}
+		getProxyImage := func(registryOverride string) string {
+			objs, err := getObjs(registryOverride)
+			Expect(err).NotTo(HaveOccurred())
+			for _, obj := range objs {
+				if dep, ok := obj.(*appsv1.Deployment); ok {
+					return dep.Spec.Template.Spec.Containers[0].Image
+				}
+			}
+			return ""
+		}
+
+		It("should use the default image when not set", func() {
+			Expect(getProxyImage("")).To(HavePrefix("quay.io/solo-io/gloo-envoy-wrapper:"))
+		})
+
+		It("should rewrite the registry of the default image", func() {
+			Expect(getProxyImage("registry.internal:5000/")).To(HavePrefix("registry.internal:5000/solo-io/gloo-envoy-wrapper:"))
+		})
+
+		It("should rewrite the registry of a user overridden image", func() {
+			os.Setenv(constants.GlooGatewayDeployerImage, "docker.io/myorg/envoy:1.2.3")
+			DeferCleanup(os.Unsetenv, constants.GlooGatewayDeployerImage)
+			Expect(getProxyImage("")).To(Equal("docker.io/myorg/envoy:1.2.3"))
+			Expect(getProxyImage("mirror.internal")).To(Equal("mirror.internal/myorg/envoy:1.2.3"))
+		})
+
+		It("should prefix a user overridden image without a registry", func() {
+			os.Setenv(constants.GlooGatewayDeployerImage, "myorg/envoy:1.2.3")
+			DeferCleanup(os.Unsetenv, constants.GlooGatewayDeployerImage)
+			Expect(getProxyImage("mirror.internal")).To(Equal("mirror.internal/myorg/envoy:1.2.3"))
+		})
+
+		DescribeTable("should parse the user overridden image",
+			func(image, expected, expectedWithOverride string) {
+				os.Setenv(constants.GlooGatewayDeployerImage, image)
+				DeferCleanup(os.Unsetenv, constants.GlooGatewayDeployerImage)
+				Expect(getProxyImage("")).To(Equal(expected))
+				Expect(getProxyImage("mirror.internal")).To(Equal(expectedWithOverride))
+			},
+			Entry("registry with a port",
+				"registry.example.com:5000/myorg/envoy:1.2.3",
+				"registry.example.com:5000/myorg/envoy:1.2.3",
+				"mirror.internal/myorg/envoy:1.2.3"),
+			Entry("digest",
+				"myorg/envoy@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+				"myorg/envoy@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+				"mirror.internal/myorg/envoy@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"),
+			Entry("tag and digest",
+				"localhost:5000/envoy:1.2.3@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+				"localhost:5000/envoy:1.2.3@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+				"mirror.internal/envoy:1.2.3@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"),
+		)
+
+		It("should default the tag of a user overridden image to the chart version", func() {
+			os.Setenv(constants.GlooGatewayDeployerImage, "registry.example.com:5000/myorg/envoy")
+			DeferCleanup(os.Unsetenv, constants.GlooGatewayDeployerImage)
+			image := getProxyImage("")
+			Expect(image).To(HavePrefix("registry.example.com:5000/myorg/envoy:"))
+			Expect(image).NotTo(HaveSuffix(":"))
+		})
+
+		DescribeTable("should reject an invalid user overridden image",
+			func(image string) {
+				os.Setenv(constants.GlooGatewayDeployerImage, image)
+				DeferCleanup(os.Unsetenv, constants.GlooGatewayDeployerImage)
+				_, err := getObjs("")
+				Expect(err).To(MatchError(ContainSubstring(constants.GlooGatewayDeployerImage)))
+			},
+			Entry("empty tag", "myorg/envoy:"),
+			Entry("uppercase repository", "myorg/Envoy:1.2.3"),
+			Entry("invalid digest", "myorg/envoy@sha256:abc"),
+			Entry("digest only", "@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"),
+		)
+	})
+
+	Context("xds tls", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+		getDeployment := func(objs []client.Object) *appsv1.Deployment {
+			for _, obj := range objs {
+				if dep, ok := obj.(*appsv1.Deployment); ok {
+					return dep
+				}
+			}
+			return nil
+		}
+		getXdsCluster := func(objs []client.Object) map[string]any {
+			var envoyConfig map[string]any
+			Expect(yaml.Unmarshal([]byte(getEnvoyConfig(objs)), &envoyConfig)).To(Succeed())
+			clusters := envoyConfig["static_resources"].(map[string]any)["clusters"].([]any)
+			for _, c := range clusters {
+				if c.(map[string]any)["name"] == "xds_cluster" {
+					return c.(map[string]any)
+				}
+			}
+			return nil
+		}
+
+		It("should connect over plaintext by default", func() {
+			objs, err := d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).NotTo(HaveOccurred())
+
+			xdsCluster := getXdsCluster(objs)
+			Expect(xdsCluster).NotTo(BeNil())
+			Expect(xdsCluster).NotTo(HaveKey("transport_socket"))
+			for _, v := range getDeployment(objs).Spec.Template.Spec.Volumes {
+				Expect(v.Name).NotTo(Equal("xds-tls"))
+			}
+		})
+
+		It("should render xds tls values when enabled", func() {
+			d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+				XdsTLS: deployer.XdsTLSConfig{
+					Enabled:      true,
+					CASecretName: "xds-certs",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			objs, err := d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).NotTo(HaveOccurred())
+
+			xdsCluster := getXdsCluster(objs)
+			Expect(xdsCluster).To(HaveKey("transport_socket"))
+			transportSocket := xdsCluster["transport_socket"].(map[string]any)
+			Expect(transportSocket).To(HaveKeyWithValue("name", "envoy.transport_sockets.tls"))
+
+			dep := getDeployment(objs)
+			Expect(dep.Spec.Template.Spec.Volumes).To(ContainElement(corev1.Volume{
+				Name: "xds-tls",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: "xds-certs"},
+				},
+			}))
+			Expect(dep.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      "xds-tls",
+				MountPath: "/etc/xds-tls",
+				ReadOnly:  true,
+			}))
+		})
+
+		It("should require a secret name when enabled", func() {
+			_, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+				XdsTLS:         deployer.XdsTLSConfig{Enabled: true},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("listener shards", func() {
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+			Spec: api.GatewaySpec{
+				Listeners: []api.Listener{
+					{Name: "https-alt", Port: 8443},
+					{Name: "http", Port: 80},
+					{Name: "http-other-host", Port: 80},
+					{Name: "https", Port: 443},
+					{Name: "http-alt", Port: 8080},
+				},
+			},
+		}
+		getObjs := func(shards string) ([]client.Object, error) {
+			d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			sharded := gw.DeepCopy()
+			sharded.Annotations = map[string]string{deployer.ListenerShardsAnnotation: shards}
+			return d.GetObjsToDeploy(context.Background(), sharded)
+		}
+		servicePorts := func(svc *corev1.Service) []int32 {
+			var ret []int32
+			for _, p := range svc.Spec.Ports {
+				ret = append(ret, p.Port)
+			}
+			return ret
+		}
+
+		It("should render a proxy per shard", func() {
+			objs, err := getObjs("2")
+			Expect(err).NotTo(HaveOccurred())
+
+			names := map[string][]string{}
+			deployments := map[string]*appsv1.Deployment{}
+			services := map[string]*corev1.Service{}
+			for _, obj := range objs {
+				kind := obj.GetObjectKind().GroupVersionKind().Kind
+				Expect(names[kind]).NotTo(ContainElement(obj.GetName()))
+				names[kind] = append(names[kind], obj.GetName())
+				Expect(obj.GetOwnerReferences()).To(ConsistOf(HaveField("Name", "foo")))
+				switch o := obj.(type) {
+				case *appsv1.Deployment:
+					deployments[o.Name] = o
+				case *corev1.Service:
+					services[o.Name] = o
+				}
+			}
+			Expect(names).To(HaveKeyWithValue("ConfigMap", ConsistOf("gloo-proxy-foo-shard-0", "gloo-proxy-foo-shard-1")))
+			Expect(names).To(HaveKeyWithValue("ServiceAccount", ConsistOf("gloo-proxy-foo-shard-0", "gloo-proxy-foo-shard-1")))
+			Expect(deployments).To(HaveLen(2))
+			Expect(services).To(HaveLen(2))
+
+			Expect(servicePorts(services["gloo-proxy-foo-shard-0"])).To(ConsistOf(int32(80), int32(8080)))
+			Expect(servicePorts(services["gloo-proxy-foo-shard-1"])).To(ConsistOf(int32(443), int32(8443)))
+			for name, svc := range services {
+				dep := deployments[name]
+				Expect(dep).NotTo(BeNil())
+				Expect(dep.Spec.Selector.MatchLabels).To(Equal(svc.Spec.Selector))
+				var containerPorts []int32
+				for _, p := range dep.Spec.Template.Spec.Containers[0].Ports {
+					containerPorts = append(containerPorts, p.ContainerPort)
+				}
+				for _, p := range svc.Spec.Ports {
+					Expect(containerPorts).To(ContainElement(p.TargetPort.IntVal))
+				}
+			}
+			Expect(deployments["gloo-proxy-foo-shard-0"].Spec.Selector.MatchLabels).NotTo(
+				Equal(deployments["gloo-proxy-foo-shard-1"].Spec.Selector.MatchLabels))
+
+			// each shard connects to xds as its shard, to only receive the configuration of its listeners
+			for _, obj := range objs {
+				if cm, ok := obj.(*corev1.ConfigMap); ok {
+					var envoyConfig map[string]any
+					Expect(yaml.Unmarshal([]byte(cm.Data["envoy.yaml"]), &envoyConfig)).To(Succeed())
+					gateway := envoyConfig["node"].(map[string]any)["metadata"].(map[string]any)["gateway"]
+					Expect(gateway).To(HaveKeyWithValue("name", strings.TrimPrefix(cm.Name, "gloo-proxy-")))
+					Expect(gateway).To(HaveKeyWithValue("namespace", "default"))
+				}
+			}
+		})
+
+		It("should render a single proxy when not sharded", func() {
+			objs, err := getObjs("1")
+			Expect(err).NotTo(HaveOccurred())
+			for _, obj := range objs {
+				Expect(obj.GetName()).To(Equal("gloo-proxy-foo"))
+			}
+		})
+
+		It("should assign the listeners of a proxy port to the same shard", func() {
+			// ports 80 and 8080 are both translated to 8080, and 443 and 8443 to 8443
+			Expect(deployer.ListenerPortShards(gw, 2)).To(Equal(map[uint16]int{8080: 0, 8443: 1}))
+			Expect(deployer.ListenerPortShards(gw, 3)).To(Equal(map[uint16]int{8080: 0, 8443: 1}))
+		})
+
+		It("should keep the names of the shards of long gateway names unique", func() {
+			long := gw.DeepCopy()
+			long.Name = strings.Repeat("a", 63)
+			name0 := deployer.ShardName(long, 0)
+			name1 := deployer.ShardName(long, 1)
+			Expect(name0).NotTo(Equal(name1))
+			Expect(len("gloo-proxy-" + name0)).To(BeNumerically("<=", 63))
+			Expect(name1).To(HaveSuffix("-shard-1"))
+		})
+
+		It("should not deploy the Gateway created last when the shards collide with another Gateway", func() {
+			sharded := gw.DeepCopy()
+			sharded.Annotations = map[string]string{deployer.ListenerShardsAnnotation: "2"}
+			sharded.CreationTimestamp = metav1.Unix(100, 0)
+			// an unsharded Gateway named like shard 1 of foo
+			other := &api.Gateway{ObjectMeta: metav1.ObjectMeta{
+				Name:              "foo-shard-1",
+				Namespace:         "default",
+				CreationTimestamp: metav1.Unix(200, 0),
+			}}
+			d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName: wellknown.GatewayControllerName,
+				Port:           8080,
+				ClusterReader:  fake.NewClientBuilder().WithScheme(scheme.NewScheme()).WithObjects(sharded, other).Build(),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = d.GetObjsToDeploy(context.Background(), sharded)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = d.GetObjsToDeploy(context.Background(), other)
+			Expect(err).To(MatchError(ContainSubstring("named like the proxies of gateway default.foo")))
+		})
+
+		It("should detect the proxies named like the proxies of other Gateways", func() {
+			sharded := gw.DeepCopy()
+			sharded.Annotations = map[string]string{deployer.ListenerShardsAnnotation: "2"}
+			Expect(deployer.ProxyNames(sharded)).To(Equal([]string{"foo-shard-0", "foo-shard-1"}))
+			Expect(deployer.ProxyNames(gw)).To(Equal([]string{"foo"}))
+			sharded.CreationTimestamp = metav1.Unix(50, 0)
+
+			older := api.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "foo-shard-0", Namespace: "default"}}
+			newer := older.DeepCopy()
+			newer.CreationTimestamp = metav1.Unix(100, 0)
+			otherNs := older.DeepCopy()
+			otherNs.Namespace = "other"
+			unrelated := older.DeepCopy()
+			unrelated.Name = "bar"
+			Expect(deployer.CollidingGateway(sharded, []api.Gateway{*otherNs, *unrelated})).To(BeNil())
+			Expect(deployer.CollidingGateway(sharded, []api.Gateway{older})).To(Equal(&older))
+			Expect(deployer.CollidingGateway(newer, []api.Gateway{*sharded})).To(Equal(sharded))
+		})
+
+		DescribeTable("should reject invalid numbers of shards",
+			func(shards string) {
+				_, err := getObjs(shards)
+				Expect(err).To(MatchError(ContainSubstring(deployer.ListenerShardsAnnotation)))
+			},
+			Entry("zero", "0"),
+			Entry("negative", "-1"),
+			Entry("not a number", "two"),
+		)
+	})
+
+	Context("object transformers", func() {
+		var (
+			gw          *api.Gateway
+			transformed []string
+		)
+		BeforeEach(func() {
+			gw = &api.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "default",
+					UID:       "1235",
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Gateway",
+					APIVersion: "gateway.solo.io/v1beta1",
+				},
+			}
+			transformed = nil
+		})
+		annotate := func(key string) func(client.Object) error {
+			return func(obj client.Object) error {
+				transformed = append(transformed, obj.GetObjectKind().GroupVersionKind().Kind)
+				annotations := obj.GetAnnotations()
+				if annotations == nil {
+					annotations = map[string]string{}
+				}
+				annotations[key] = "true"
+				obj.SetAnnotations(annotations)
+				return nil
+			}
+		}
+		getObjs := func(transformers map[schema.GroupVersionKind]func(client.Object) error) ([]client.Object, error) {
+			d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+				ControllerName:     wellknown.GatewayControllerName,
+				Port:               8080,
+				ObjectTransformers: transformers,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return d.GetObjsToDeploy(context.Background(), gw)
+		}
+
+		It("should only run each transformer on the objects of its kind", func() {
+			objs, err := getObjs(map[schema.GroupVersionKind]func(client.Object) error{
+				corev1.SchemeGroupVersion.WithKind("Service"):    annotate("service-transformer"),
+				appsv1.SchemeGroupVersion.WithKind("Deployment"): annotate("deployment-transformer"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(transformed).To(ConsistOf("Service", "Deployment"))
+
+			for _, obj := range objs {
+				switch obj.(type) {
+				case *corev1.Service:
+					Expect(obj.GetAnnotations()).To(HaveKey("service-transformer"))
+					Expect(obj.GetAnnotations()).NotTo(HaveKey("deployment-transformer"))
+				case *appsv1.Deployment:
+					Expect(obj.GetAnnotations()).To(HaveKey("deployment-transformer"))
+					Expect(obj.GetAnnotations()).NotTo(HaveKey("service-transformer"))
+				default:
+					Expect(obj.GetAnnotations()).NotTo(HaveKey("service-transformer"))
+					Expect(obj.GetAnnotations()).NotTo(HaveKey("deployment-transformer"))
+				}
+			}
+		})
+
+		It("should run the transformers once labels and owner references are set", func() {
+			_, err := getObjs(map[schema.GroupVersionKind]func(client.Object) error{
+				corev1.SchemeGroupVersion.WithKind("Service"): func(obj client.Object) error {
+					Expect(obj.GetLabels()).To(HaveKeyWithValue(deployer.GatewayNameLabel, "foo"))
+					Expect(obj.GetOwnerReferences()).To(HaveLen(1))
+					transformed = append(transformed, "Service")
+					return nil
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(transformed).To(ConsistOf("Service"))
+		})
+
+		It("should fail when a transformer fails", func() {
+			_, err := getObjs(map[schema.GroupVersionKind]func(client.Object) error{
+				corev1.SchemeGroupVersion.WithKind("Service"): func(client.Object) error {
+					return fmt.Errorf("boom")
+				},
+			})
+			Expect(err).To(MatchError(ContainSubstring("failed to transform Service gloo-proxy-foo: boom")))
+		})
+	})
+
+	Context("deployed generation", func() {
+		var (
+			gw      *api.Gateway
+			cli     client.Client
+			applied []client.Object
+		)
+		BeforeEach(func() {
+			gw = &api.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "foo",
+					Namespace:  "default",
+					UID:        "1235",
+					Generation: 2,
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Gateway",
+					APIVersion: "gateway.networking.k8s.io/v1",
+				},
+			}
+			applied = nil
+			// the fake client does not support server-side apply, so applied objects are created or updated instead
+			cli = fake.NewClientBuilder().WithScheme(scheme.NewScheme()).WithObjects(gw.DeepCopy()).WithInterceptorFuncs(interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					if patch.Type() != types.ApplyPatchType {
+						return c.Patch(ctx, obj, patch, opts...)
+					}
+					applied = append(applied, obj)
+					toApply := obj.DeepCopyObject().(client.Object)
+					err := c.Create(ctx, toApply)
+					if apierrors.IsAlreadyExists(err) {
+						toApply.SetResourceVersion("")
+						return c.Update(ctx, toApply)
+					}
+					return err
+				},
+			}).Build()
+		})
+
+		It("should record the deployed generation on success", func() {
+			Expect(deployer.IsDeployed(gw)).To(BeFalse())
+			Expect(d.Deploy(context.Background(), gw, cli)).To(Succeed())
+			Expect(applied).NotTo(BeEmpty())
+
+			var updated api.Gateway
+			Expect(cli.Get(context.Background(), client.ObjectKeyFromObject(gw), &updated)).To(Succeed())
+			Expect(updated.Annotations).To(HaveKeyWithValue(deployer.LastDeployedGenerationAnnotation, "2"))
+			generation, ok := deployer.LastDeployedGeneration(&updated)
+			Expect(ok).To(BeTrue())
+			Expect(generation).To(Equal(int64(2)))
+			Expect(deployer.IsDeployed(&updated)).To(BeTrue())
+		})
+
+		It("should apply the objects of a generation that was already deployed", func() {
+			gw.Annotations = map[string]string{deployer.LastDeployedGenerationAnnotation: "2"}
+			Expect(d.Deploy(context.Background(), gw, cli)).To(Succeed())
+			Expect(applied).NotTo(BeEmpty())
+			Expect(deployer.IsDeployed(gw)).To(BeTrue())
+		})
+
+		It("should re-create a deleted object of a generation that was already deployed", func() {
+			Expect(d.Deploy(context.Background(), gw, cli)).To(Succeed())
+			Expect(deployer.IsDeployed(gw)).To(BeTrue())
+			svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gloo-proxy-foo"}}
+			Expect(cli.Delete(context.Background(), svc)).To(Succeed())
+
+			applied = nil
+			Expect(d.Deploy(context.Background(), gw, cli)).To(Succeed())
+			Expect(applied).To(ConsistOf(HaveField("ObjectMeta.Name", "gloo-proxy-foo")))
+			Expect(applied[0]).To(BeAssignableToTypeOf(&corev1.Service{}))
+			Expect(cli.Get(context.Background(), client.ObjectKeyFromObject(svc), svc)).To(Succeed())
+		})
+
+		It("should not write anything when the deployed generation is still applied", func() {
+			Expect(d.Deploy(context.Background(), gw, cli)).To(Succeed())
+			var deployed api.Gateway
+			Expect(cli.Get(context.Background(), client.ObjectKeyFromObject(gw), &deployed)).To(Succeed())
+			objs, err := d.GetObjsToDeploy(context.Background(), &deployed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(d.IsApplied(context.Background(), objs, cli)).To(BeTrue())
+
+			applied = nil
+			Expect(d.Deploy(context.Background(), &deployed, cli)).To(Succeed())
+			Expect(applied).To(BeEmpty())
+			var updated api.Gateway
+			Expect(cli.Get(context.Background(), client.ObjectKeyFromObject(gw), &updated)).To(Succeed())
+			Expect(updated.ResourceVersion).To(Equal(deployed.ResourceVersion))
+		})
+
+		It("should re-apply the objects changed since the generation was deployed", func() {
+			Expect(d.Deploy(context.Background(), gw, cli)).To(Succeed())
+			key := client.ObjectKey{Namespace: "default", Name: "gloo-proxy-foo"}
+			var dep appsv1.Deployment
+			Expect(cli.Get(context.Background(), key, &dep)).To(Succeed())
+			dep.Spec.Replicas = ptr.To(*dep.Spec.Replicas + 1)
+			Expect(cli.Update(context.Background(), &dep)).To(Succeed())
+
+			objs, err := d.GetObjsToDeploy(context.Background(), gw)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(d.IsApplied(context.Background(), objs, cli)).To(BeFalse())
+
+			applied = nil
+			Expect(d.Deploy(context.Background(), gw, cli)).To(Succeed())
+			Expect(applied).To(ConsistOf(BeAssignableToTypeOf(&appsv1.Deployment{})))
+		})
+
+		It("should deploy a new generation", func() {
+			gw.Annotations = map[string]string{deployer.LastDeployedGenerationAnnotation: "1"}
+			Expect(d.Deploy(context.Background(), gw, cli)).To(Succeed())
+			Expect(applied).NotTo(BeEmpty())
+			Expect(deployer.IsDeployed(gw)).To(BeTrue())
+		})
+	})
+
 	It("should config map with valid envoy yaml", func() {
 		gw := &api.Gateway{
 			ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,129 @@
+package deployer
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/slices"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ObjectsDiff is the result of comparing two sets of rendered objects
+type ObjectsDiff struct {
+	// Added are the objects that are present in the new set only
+	Added []client.Object
+	// Removed are the objects that are present in the old set only
+	Removed []client.Object
+	// Modified are the objects (from the new set) that are present in both sets, but whose content changed
+	Modified []client.Object
+}
+
+// IsEmpty returns true if the two sets of objects were equivalent
+func (d ObjectsDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// ChangedGvks returns the GVKs of all objects that were added, removed or modified
+func (d ObjectsDiff) ChangedGvks() []schema.GroupVersionKind {
+	var ret []schema.GroupVersionKind
+	for _, objs := range [][]client.Object{d.Added, d.Removed, d.Modified} {
+		for _, obj := range objs {
+			gvk := obj.GetObjectKind().GroupVersionKind()
+			if !slices.Contains(ret, gvk) {
+				ret = append(ret, gvk)
+			}
+		}
+	}
+	return ret
+}
+
+type objectKey struct {
+	gvk       schema.GroupVersionKind
+	namespace string
+	name      string
+}
+
+func keyForObject(obj client.Object) objectKey {
+	return objectKey{
+		gvk:       obj.GetObjectKind().GroupVersionKind(),
+		namespace: obj.GetNamespace(),
+		name:      obj.GetName(),
+	}
+}
+
+// DiffObjects compares two sets of rendered objects (e.g. from two calls to GetObjsToDeploy), matching objects
+// by GVK, namespace and name. Objects are compared on their desired state only: status and server-populated
+// metadata (managedFields, resourceVersion, uid, generation, creationTimestamp) are ignored.
+func DiffObjects(oldObjs, newObjs []client.Object) (ObjectsDiff, error) {
+	var diff ObjectsDiff
+
+	oldByKey := make(map[objectKey]client.Object, len(oldObjs))
+	for _, obj := range oldObjs {
+		oldByKey[keyForObject(obj)] = obj
+	}
+
+	newKeys := make(map[objectKey]struct{}, len(newObjs))
+	for _, newObj := range newObjs {
+		key := keyForObject(newObj)
+		newKeys[key] = struct{}{}
+
+		oldObj, ok := oldByKey[key]
+		if !ok {
+			diff.Added = append(diff.Added, newObj)
+			continue
+		}
+		equal, err := desiredStateEqual(oldObj, newObj)
+		if err != nil {
+			return ObjectsDiff{}, err
+		}
+		if !equal {
+			diff.Modified = append(diff.Modified, newObj)
+		}
+	}
+
+	for _, oldObj := range oldObjs {
+		if _, ok := newKeys[keyForObject(oldObj)]; !ok {
+			diff.Removed = append(diff.Removed, oldObj)
+		}
+	}
+
+	return diff, nil
+}
+
+func desiredStateEqual(a, b client.Object) (bool, error) {
+	aState, err := desiredState(a)
+	if err != nil {
+		return false, err
+	}
+	bState, err := desiredState(b)
+	if err != nil {
+		return false, err
+	}
+	return equality.Semantic.DeepEqual(aState, bState), nil
+}
+
+// desiredState returns the unstructured content of the object, stripped of the fields which are not
+// part of the desired state of the object
+func desiredState(obj client.Object) (map[string]any, error) {
+	var content map[string]any
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		content = runtime.DeepCopyJSON(u.Object)
+	} else {
+		var err error
+		content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s %s to unstructured: %w", obj.GetObjectKind().GroupVersionKind(), obj.GetName(), err)
+		}
+	}
+
+	delete(content, "status")
+	if metadata, ok := content["metadata"].(map[string]any); ok {
+		for _, field := range []string{"managedFields", "resourceVersion", "uid", "generation", "creationTimestamp"} {
+			delete(metadata, field)
+		}
+	}
+	return content, nil
+}
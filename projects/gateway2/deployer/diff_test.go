@@ -0,0 +1,118 @@
+package deployer_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	api "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/solo-io/gloo/projects/gateway2/controller/scheme"
+	"github.com/solo-io/gloo/projects/gateway2/deployer"
+	"github.com/solo-io/gloo/projects/gateway2/wellknown"
+)
+
+var _ = Describe("DiffObjects", func() {
+	svc := func(port int32) *corev1.Service {
+		return &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "gloo-proxy-foo", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Name: "http", Port: port}},
+			},
+		}
+	}
+	cm := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "gloo-proxy-foo", Namespace: "default"},
+			Data:       map[string]string{"envoy.yaml": "{}"},
+		}
+	}
+	dep := func() *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "gloo-proxy-foo", Namespace: "default"},
+		}
+	}
+
+	It("should report no changes for identical renders", func() {
+		diff, err := deployer.DiffObjects([]client.Object{svc(80), cm()}, []client.Object{svc(80), cm()})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diff.IsEmpty()).To(BeTrue())
+		Expect(diff.ChangedGvks()).To(BeEmpty())
+	})
+
+	It("should report added objects", func() {
+		diff, err := deployer.DiffObjects([]client.Object{svc(80)}, []client.Object{svc(80), dep()})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diff.Added).To(HaveLen(1))
+		Expect(diff.Added[0].GetObjectKind().GroupVersionKind().Kind).To(Equal("Deployment"))
+		Expect(diff.Removed).To(BeEmpty())
+		Expect(diff.Modified).To(BeEmpty())
+		Expect(diff.ChangedGvks()).To(ConsistOf(appsv1.SchemeGroupVersion.WithKind("Deployment")))
+	})
+
+	It("should report removed objects", func() {
+		diff, err := deployer.DiffObjects([]client.Object{svc(80), cm()}, []client.Object{svc(80)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diff.Removed).To(HaveLen(1))
+		Expect(diff.Removed[0].GetObjectKind().GroupVersionKind().Kind).To(Equal("ConfigMap"))
+		Expect(diff.Added).To(BeEmpty())
+		Expect(diff.Modified).To(BeEmpty())
+	})
+
+	It("should report modified objects", func() {
+		diff, err := deployer.DiffObjects([]client.Object{svc(80), cm()}, []client.Object{svc(8080), cm()})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diff.Modified).To(HaveLen(1))
+		Expect(diff.Modified[0].(*corev1.Service).Spec.Ports[0].Port).To(Equal(int32(8080)))
+		Expect(diff.ChangedGvks()).To(ConsistOf(corev1.SchemeGroupVersion.WithKind("Service")))
+	})
+
+	It("should ignore status and server populated metadata", func() {
+		live := svc(80)
+		live.ResourceVersion = "12"
+		live.UID = "1234"
+		live.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "solo.io/gloo-gateway"}}
+		live.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "127.0.0.1"}}
+
+		diff, err := deployer.DiffObjects([]client.Object{live}, []client.Object{svc(80)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diff.IsEmpty()).To(BeTrue())
+	})
+
+	It("should detect changes between two renders of a gateway", func() {
+		d, err := deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+			ControllerName: wellknown.GatewayControllerName,
+			Port:           8080,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		gw := &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default", UID: "1235"},
+			TypeMeta:   metav1.TypeMeta{Kind: "Gateway", APIVersion: "gateway.solo.io/v1beta1"},
+			Spec: api.GatewaySpec{
+				Listeners: []api.Listener{{Name: "listener-1", Port: 80}},
+			},
+		}
+		oldObjs, err := d.GetObjsToDeploy(context.Background(), gw)
+		Expect(err).NotTo(HaveOccurred())
+
+		gw.Spec.Listeners = append(gw.Spec.Listeners, api.Listener{Name: "listener-2", Port: 443})
+		newObjs, err := d.GetObjsToDeploy(context.Background(), gw)
+		Expect(err).NotTo(HaveOccurred())
+
+		diff, err := deployer.DiffObjects(oldObjs, newObjs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diff.Added).To(BeEmpty())
+		Expect(diff.Removed).To(BeEmpty())
+		Expect(diff.ChangedGvks()).To(ConsistOf(
+			corev1.SchemeGroupVersion.WithKind("Service"),
+			appsv1.SchemeGroupVersion.WithKind("Deployment"),
+		))
+	})
+})
@@ -0,0 +1,15 @@
+package deployer
+
+import "errors"
+
+// Errors returned by the deployer wrap one of these sentinel errors, so that callers can tell which step failed
+// (e.g. to set the appropriate Gateway condition) with errors.Is. The underlying error is wrapped as well and can
+// still be inspected with errors.Is / errors.As.
+var (
+	// ErrRender is returned when the helm chart fails to render
+	ErrRender = errors.New("failed to render helm chart")
+	// ErrConvert is returned when the rendered manifest can not be converted to objects
+	ErrConvert = errors.New("failed to convert yaml to objects")
+	// ErrApply is returned when a rendered object fails to be applied to the cluster
+	ErrApply = errors.New("failed to apply object")
+)
@@ -0,0 +1,69 @@
+package deployer_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/solo-io/gloo/projects/gateway2/controller/scheme"
+	"github.com/solo-io/gloo/projects/gateway2/deployer"
+	"github.com/solo-io/gloo/projects/gateway2/wellknown"
+)
+
+var _ = Describe("Deployer errors", func() {
+	var d *deployer.Deployer
+	BeforeEach(func() {
+		var err error
+		d, err = deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+			ControllerName: wellknown.GatewayControllerName,
+			Port:           8080,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should return ErrRender when the chart fails to render", func() {
+		_, err := d.Render(context.Background(), "foo", "default", map[string]any{
+			"gateway": "not-a-map",
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(errors.Is(err, deployer.ErrRender)).To(BeTrue())
+		Expect(errors.Is(err, deployer.ErrConvert)).To(BeFalse())
+		Expect(errors.Is(err, deployer.ErrApply)).To(BeFalse())
+	})
+
+	It("should return ErrConvert when the manifest is not valid yaml", func() {
+		_, err := deployer.ConvertYAMLToObjects(scheme.NewScheme(), []byte("kind: [Service"))
+		Expect(err).To(HaveOccurred())
+		Expect(errors.Is(err, deployer.ErrConvert)).To(BeTrue())
+		Expect(errors.Is(err, deployer.ErrRender)).To(BeFalse())
+	})
+
+	It("should return ErrApply and preserve the underlying error when an object fails to apply", func() {
+		forbidden := apierrors.NewForbidden(corev1.Resource("services"), "foo", errors.New("nope"))
+		cli := fake.NewClientBuilder().WithScheme(scheme.NewScheme()).WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				return forbidden
+			},
+		}).Build()
+		svc := &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		}
+
+		err := d.DeployObjs(context.Background(), []client.Object{svc}, cli)
+		Expect(err).To(HaveOccurred())
+		Expect(errors.Is(err, deployer.ErrApply)).To(BeTrue())
+		Expect(errors.Is(err, deployer.ErrRender)).To(BeFalse())
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		var statusErr *apierrors.StatusError
+		Expect(errors.As(err, &statusErr)).To(BeTrue())
+	})
+})
@@ -0,0 +1,90 @@
+package fake
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	api "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/solo-io/gloo/projects/gateway2/deployer"
+)
+
+var _ deployer.ProxyDeployer = &Deployer{}
+
+// Deployer is a fake implementation of deployer.ProxyDeployer, intended to be used in tests.
+// It returns the configured values and records the Gateways and objects it was called with.
+type Deployer struct {
+	// Gvks is returned from GetGvksToWatch
+	Gvks []schema.GroupVersionKind
+	// Objs is returned from GetObjsToDeploy (filtered by the requested gvks, if any)
+	Objs []client.Object
+	// Applied is returned from IsApplied
+	Applied bool
+	// Err, if set, is returned from every method
+	Err error
+
+	mu           sync.Mutex
+	renderedGws  []*api.Gateway
+	deployedObjs []client.Object
+}
+
+func (d *Deployer) GetGvksToWatch(_ context.Context) ([]schema.GroupVersionKind, error) {
+	if d.Err != nil {
+		return nil, d.Err
+	}
+	return d.Gvks, nil
+}
+
+func (d *Deployer) GetObjsToDeploy(_ context.Context, gw *api.Gateway, gvks ...schema.GroupVersionKind) ([]client.Object, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.renderedGws = append(d.renderedGws, gw)
+	if d.Err != nil {
+		return nil, d.Err
+	}
+	return deployer.FilterObjectsByGvk(d.Objs, gvks...), nil
+}
+
+func (d *Deployer) DeployObjs(_ context.Context, objs []client.Object, _ client.Client) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.Err != nil {
+		return d.Err
+	}
+	d.deployedObjs = append(d.deployedObjs, objs...)
+	return nil
+}
+
+func (d *Deployer) IsApplied(_ context.Context, _ []client.Object, _ client.Client) (bool, error) {
+	if d.Err != nil {
+		return false, d.Err
+	}
+	return d.Applied, nil
+}
+
+func (d *Deployer) Deploy(ctx context.Context, gw *api.Gateway, cli client.Client) error {
+	objs, err := d.GetObjsToDeploy(ctx, gw)
+	if err != nil {
+		return err
+	}
+	if deployer.IsDeployed(gw) && d.Applied {
+		return nil
+	}
+	return d.DeployObjs(ctx, objs, cli)
+}
+
+// RenderedGateways returns the Gateways passed to GetObjsToDeploy (including via Deploy)
+func (d *Deployer) RenderedGateways() []*api.Gateway {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*api.Gateway(nil), d.renderedGws...)
+}
+
+// DeployedObjs returns the objects passed to DeployObjs (including via Deploy)
+func (d *Deployer) DeployedObjs() []client.Object {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]client.Object(nil), d.deployedObjs...)
+}
@@ -0,0 +1,45 @@
+package fake_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	api "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/solo-io/gloo/projects/gateway2/deployer/fake"
+)
+
+// see the controller package for the fake driving the gateway reconciler
+
+func TestFakeDeployer(t *testing.T) {
+	g := NewWithT(t)
+
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "gloo-proxy-foo", Namespace: "default"}}
+	d := &fake.Deployer{
+		Objs: []client.Object{svc},
+	}
+	gw := &api.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+
+	err := d.Deploy(context.Background(), gw, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(d.RenderedGateways()).To(ConsistOf(gw))
+	g.Expect(d.DeployedObjs()).To(ConsistOf(svc))
+}
+
+func TestFakeDeployerError(t *testing.T) {
+	g := NewWithT(t)
+
+	d := &fake.Deployer{
+		Err: errors.New("render failed"),
+	}
+	gw := &api.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+
+	err := d.Deploy(context.Background(), gw, nil)
+	g.Expect(err).To(MatchError("render failed"))
+	g.Expect(d.DeployedObjs()).To(BeEmpty())
+}
@@ -0,0 +1,56 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	api "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// LastDeployedGenerationAnnotation is set on a Gateway to the generation of the Gateway
+// that was last successfully deployed. Reconciling a Gateway whose generation was already deployed applies nothing,
+// unless some of its proxy objects are missing or no longer hold the state they were applied with.
+// Changes to this annotation alone do not trigger a reconcile of the Gateway.
+const LastDeployedGenerationAnnotation = "gateway2.solo.io/last-deployed-generation"
+
+// LastDeployedGeneration returns the generation of the Gateway that was last successfully deployed,
+// and whether it was recorded at all
+func LastDeployedGeneration(gw *api.Gateway) (int64, bool) {
+	val, ok := gw.GetAnnotations()[LastDeployedGenerationAnnotation]
+	if !ok {
+		return 0, false
+	}
+	generation, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return generation, true
+}
+
+// IsDeployed returns true if the current generation of the Gateway was already successfully deployed,
+// i.e. its proxy objects were applied at least once since the Gateway last changed
+func IsDeployed(gw *api.Gateway) bool {
+	generation, ok := LastDeployedGeneration(gw)
+	return ok && generation == gw.GetGeneration()
+}
+
+// RecordDeployedGeneration annotates the Gateway with its current generation, marking it as successfully deployed.
+// Updating annotations does not bump the generation of the Gateway.
+func RecordDeployedGeneration(ctx context.Context, gw *api.Gateway, cli client.Client) error {
+	if IsDeployed(gw) {
+		return nil
+	}
+	patch := client.MergeFrom(gw.DeepCopy())
+	annotations := gw.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[LastDeployedGenerationAnnotation] = strconv.FormatInt(gw.GetGeneration(), 10)
+	gw.SetAnnotations(annotations)
+	if err := cli.Patch(ctx, gw, patch); err != nil {
+		return fmt.Errorf("failed to record deployed generation of gateway %s.%s: %w", gw.Namespace, gw.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package deployer
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	api "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// instanceTypesWithoutLoadBalancer are the node instance types of local cluster providers
+// that are known to not provision LoadBalancer Services out of the box.
+var instanceTypesWithoutLoadBalancer = []string{
+	"k3s",
+}
+
+// getServiceType returns the type of the Service to render for the proxy.
+// It defaults to LoadBalancer, and only falls back to NodePort if AutoServiceType is enabled
+// and the cluster does not appear to be able to provision load balancers.
+func (d *Deployer) getServiceType(ctx context.Context, gw *api.Gateway) (corev1.ServiceType, error) {
+	if !d.inputs.AutoServiceType || d.inputs.ClusterReader == nil {
+		return corev1.ServiceTypeLoadBalancer, nil
+	}
+	supported, err := detectLoadBalancerSupport(ctx, d.inputs.ClusterReader, gw.GetNamespace())
+	if err != nil {
+		return "", err
+	}
+	if !supported {
+		return corev1.ServiceTypeNodePort, nil
+	}
+	return corev1.ServiceTypeLoadBalancer, nil
+}
+
+// detectLoadBalancerSupport probes the cluster to decide whether LoadBalancer Services will be provisioned.
+// Load balancers are considered to be supported if:
+//   - any LoadBalancer Service of the namespace already has an ingress address assigned (e.g. MetalLB is installed), or
+//   - any Node was registered by a cloud provider, which labels it with its instance type
+//
+// The probe is served by the cache of the reader: the Services are those of the Gateway's namespace,
+// and only the metadata of the labeled Nodes is read.
+func detectLoadBalancerSupport(ctx context.Context, cli client.Reader, namespace string) (bool, error) {
+	var svcList corev1.ServiceList
+	if err := cli.List(ctx, &svcList, client.InNamespace(namespace)); err != nil {
+		return false, err
+	}
+	for _, svc := range svcList.Items {
+		if svc.Spec.Type == corev1.ServiceTypeLoadBalancer && len(svc.Status.LoadBalancer.Ingress) > 0 {
+			return true, nil
+		}
+	}
+
+	nodeList := &metav1.PartialObjectMetadataList{}
+	nodeList.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("NodeList"))
+	if err := cli.List(ctx, nodeList, client.MatchingLabelsSelector{Selector: cloudNodeSelector()}); err != nil {
+		return false, err
+	}
+	return len(nodeList.Items) > 0, nil
+}
+
+// cloudNodeSelector selects the Nodes with an instance type not belonging to a local cluster provider
+func cloudNodeSelector() labels.Selector {
+	hasInstanceType, _ := labels.NewRequirement(corev1.LabelInstanceTypeStable, selection.Exists, nil)
+	notLocal, _ := labels.NewRequirement(corev1.LabelInstanceTypeStable, selection.NotIn, instanceTypesWithoutLoadBalancer)
+	return labels.NewSelector().Add(*hasInstanceType, *notLocal)
+}
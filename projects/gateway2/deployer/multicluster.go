@@ -0,0 +1,45 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	api "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// RemoteCluster is a cluster, other than the one of the Gateways, to which DeployToCluster deploys their proxies
+type RemoteCluster struct {
+	// RestConfig of the cluster, e.g. built from a kubeconfig with clientcmd.RESTConfigFromKubeConfig
+	RestConfig *rest.Config
+	// XdsHost and XdsPort are the address of the control plane the proxies connect to, which must be reachable from
+	// the cluster (e.g. the address of a LoadBalancer Service exposing the control plane). The control plane Service
+	// the local proxies connect to only resolves in the cluster of the Gateways.
+	XdsHost string
+	XdsPort int
+}
+
+// DeployToCluster renders the objects required to run a proxy for the given Gateway and applies them to the
+// given remote cluster, with a client using the scheme of the deployer. This lets a controller watching Gateways in
+// one cluster deploy their proxies to another cluster.
+// The Gateway does not live in the remote cluster, so it does not own the objects (which the garbage collector would
+// delete); they are labeled with its UID instead (see GatewayUIDLabel). Unlike Deploy, the deployed generation is
+// not recorded on the Gateway.
+func (d *Deployer) DeployToCluster(ctx context.Context, gw *api.Gateway, cluster RemoteCluster) error {
+	if cluster.RestConfig == nil {
+		return fmt.Errorf("a rest config is required to deploy gateway %s.%s to a cluster", gw.Namespace, gw.Name)
+	}
+	if cluster.XdsHost == "" || cluster.XdsPort == 0 {
+		return fmt.Errorf("an xds address reachable from the cluster is required to deploy gateway %s.%s to it", gw.Namespace, gw.Name)
+	}
+	cli, err := client.New(cluster.RestConfig, client.Options{Scheme: d.scheme})
+	if err != nil {
+		return fmt.Errorf("failed to create a client for the target cluster of gateway %s.%s: %w", gw.Namespace, gw.Name, err)
+	}
+	objs, err := d.getObjsToDeploy(ctx, gw, xdsAddress{host: cluster.XdsHost, port: cluster.XdsPort}, true)
+	if err != nil {
+		return err
+	}
+	return d.DeployObjs(ctx, objs, cli)
+}
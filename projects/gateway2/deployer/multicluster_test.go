@@ -0,0 +1,226 @@
+package deployer_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	api "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/solo-io/gloo/projects/gateway2/controller/scheme"
+	"github.com/solo-io/gloo/projects/gateway2/deployer"
+	"github.com/solo-io/gloo/projects/gateway2/wellknown"
+)
+
+// fakeAPIServer serves the discovery of the kinds rendered by the chart, and records the objects applied to it.
+// Objects are never found, and patches are answered with the patched object.
+type fakeAPIServer struct {
+	*httptest.Server
+	// patchStatus, when set, is returned to patch requests instead of the patched object
+	patchStatus *metav1.Status
+
+	mu      sync.Mutex
+	applied []appliedRequest
+}
+
+// appliedRequest is a patch request received by the fakeAPIServer
+type appliedRequest struct {
+	Path         string
+	ContentType  string
+	FieldManager string
+	Body         []byte
+}
+
+func newFakeAPIServer() *fakeAPIServer {
+	s := &fakeAPIServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *fakeAPIServer) appliedRequests() []appliedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]appliedRequest{}, s.applied...)
+}
+
+func (s *fakeAPIServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	writeJSON := func(code int, obj any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(obj)
+	}
+	resource := func(name, kind string) metav1.APIResource {
+		return metav1.APIResource{Name: name, Kind: kind, Namespaced: true, Verbs: metav1.Verbs{"get", "patch"}}
+	}
+
+	switch {
+	case r.URL.Path == "/api":
+		writeJSON(http.StatusOK, &metav1.APIVersions{
+			TypeMeta: metav1.TypeMeta{Kind: "APIVersions"},
+			Versions: []string{"v1"},
+		})
+	case r.URL.Path == "/apis":
+		writeJSON(http.StatusOK, &metav1.APIGroupList{
+			TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"},
+			Groups: []metav1.APIGroup{{
+				Name:             "apps",
+				Versions:         []metav1.GroupVersionForDiscovery{{GroupVersion: "apps/v1", Version: "v1"}},
+				PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: "apps/v1", Version: "v1"},
+			}},
+		})
+	case r.URL.Path == "/api/v1":
+		writeJSON(http.StatusOK, &metav1.APIResourceList{
+			TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				resource("configmaps", "ConfigMap"),
+				resource("services", "Service"),
+				resource("serviceaccounts", "ServiceAccount"),
+			},
+		})
+	case r.URL.Path == "/apis/apps/v1":
+		writeJSON(http.StatusOK, &metav1.APIResourceList{
+			TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{resource("deployments", "Deployment")},
+		})
+	case r.Method == http.MethodPatch:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeJSON(http.StatusBadRequest, &apierrors.NewBadRequest(err.Error()).ErrStatus)
+			return
+		}
+		s.mu.Lock()
+		s.applied = append(s.applied, appliedRequest{
+			Path:         r.URL.Path,
+			ContentType:  r.Header.Get("Content-Type"),
+			FieldManager: r.URL.Query().Get("fieldManager"),
+			Body:         body,
+		})
+		s.mu.Unlock()
+		if s.patchStatus != nil {
+			writeJSON(int(s.patchStatus.Code), s.patchStatus)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	default:
+		parts := strings.Split(r.URL.Path, "/")
+		writeJSON(http.StatusNotFound, &apierrors.NewNotFound(schema.GroupResource{Resource: parts[len(parts)-2]}, parts[len(parts)-1]).ErrStatus)
+	}
+}
+
+var _ = Describe("DeployToCluster", func() {
+	var (
+		d       *deployer.Deployer
+		server  *fakeAPIServer
+		gw      *api.Gateway
+		cluster func() deployer.RemoteCluster
+	)
+	BeforeEach(func() {
+		var err error
+		d, err = deployer.NewDeployer(scheme.NewScheme(), &deployer.Inputs{
+			ControllerName: wellknown.GatewayControllerName,
+			Port:           8080,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		server = newFakeAPIServer()
+		DeferCleanup(server.Close)
+		cluster = func() deployer.RemoteCluster {
+			return deployer.RemoteCluster{
+				RestConfig: &rest.Config{Host: server.URL},
+				XdsHost:    "xds.hub.example.com",
+				XdsPort:    9977,
+			}
+		}
+		gw = &api.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				UID:       "1235",
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Gateway",
+				APIVersion: "gateway.solo.io/v1beta1",
+			},
+		}
+	})
+
+	It("should apply the objects to the target cluster", func() {
+		Expect(d.DeployToCluster(context.Background(), gw, cluster())).To(Succeed())
+		applied := server.appliedRequests()
+		var paths []string
+		for _, req := range applied {
+			Expect(req.ContentType).To(Equal(string(types.ApplyPatchType)))
+			Expect(req.FieldManager).To(Equal(wellknown.GatewayControllerName))
+			paths = append(paths, req.Path)
+		}
+		Expect(paths).To(ConsistOf(
+			"/api/v1/namespaces/default/serviceaccounts/gloo-proxy-foo",
+			"/api/v1/namespaces/default/configmaps/gloo-proxy-foo",
+			"/api/v1/namespaces/default/services/gloo-proxy-foo",
+			"/apis/apps/v1/namespaces/default/deployments/gloo-proxy-foo",
+		))
+		Expect(gw.Annotations).NotTo(HaveKey(deployer.LastDeployedGenerationAnnotation))
+	})
+
+	It("should label the objects with the Gateway rather than owning them by it", func() {
+		Expect(d.DeployToCluster(context.Background(), gw, cluster())).To(Succeed())
+		applied := server.appliedRequests()
+		Expect(applied).NotTo(BeEmpty())
+		for _, req := range applied {
+			var obj metav1.PartialObjectMetadata
+			Expect(json.Unmarshal(req.Body, &obj)).To(Succeed())
+			Expect(obj.GetOwnerReferences()).To(BeEmpty(), req.Path)
+			Expect(obj.GetLabels()).To(HaveKeyWithValue(deployer.GatewayUIDLabel, "1235"), req.Path)
+		}
+	})
+
+	It("should connect the proxy to the xds address of the cluster", func() {
+		Expect(d.DeployToCluster(context.Background(), gw, cluster())).To(Succeed())
+		var configMap *corev1.ConfigMap
+		for _, req := range server.appliedRequests() {
+			if strings.HasSuffix(req.Path, "/configmaps/gloo-proxy-foo") {
+				configMap = &corev1.ConfigMap{}
+				Expect(json.Unmarshal(req.Body, configMap)).To(Succeed())
+			}
+		}
+		Expect(configMap).NotTo(BeNil())
+		Expect(configMap.Data["envoy.yaml"]).To(ContainSubstring("address: xds.hub.example.com"))
+		Expect(configMap.Data["envoy.yaml"]).To(ContainSubstring("port_value: 9977"))
+		Expect(configMap.Data["envoy.yaml"]).NotTo(ContainSubstring("svc.cluster.local"))
+	})
+
+	It("should return ErrApply when the target cluster rejects an object", func() {
+		server.patchStatus = &apierrors.NewForbidden(schema.GroupResource{Resource: "serviceaccounts"}, "gloo-proxy-foo", errors.New("nope")).ErrStatus
+		err := d.DeployToCluster(context.Background(), gw, cluster())
+		Expect(errors.Is(err, deployer.ErrApply)).To(BeTrue())
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+	})
+
+	It("should require a rest config", func() {
+		remote := cluster()
+		remote.RestConfig = nil
+		Expect(d.DeployToCluster(context.Background(), gw, remote)).To(MatchError(ContainSubstring("a rest config is required")))
+	})
+
+	It("should require the xds address of the cluster", func() {
+		remote := cluster()
+		remote.XdsHost = ""
+		Expect(d.DeployToCluster(context.Background(), gw, remote)).To(MatchError(ContainSubstring("an xds address reachable from the cluster is required")))
+		Expect(server.appliedRequests()).To(BeEmpty())
+	})
+})
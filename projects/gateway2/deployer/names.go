@@ -0,0 +1,32 @@
+package deployer
+
+import (
+	"strings"
+
+	api "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+const (
+	// proxyNamePrefix is prepended to the name of the Gateway to name the objects of its proxy
+	proxyNamePrefix = "gloo-proxy-"
+	// maxProxyNameLength is the maximum length of the names of the objects of a proxy, so that they are valid
+	// label values (e.g. for the selector of the proxy pods)
+	maxProxyNameLength = 63
+)
+
+// ProxyName returns the name of the objects rendered for the proxy of the given Gateway, e.g. gloo-proxy-foo for
+// Gateway foo. The name is passed to the chart, so that it does not depend on the naming logic of the chart and
+// the objects are not renamed (and the old ones orphaned) when the chart changes.
+func ProxyName(gw *api.Gateway) string {
+	return proxyName(gw.Name)
+}
+
+// proxyName returns the name of the objects of a proxy named name, truncated the way the chart used to
+// truncate it so that existing objects keep their names
+func proxyName(name string) string {
+	fullName := proxyNamePrefix + name
+	if len(fullName) > maxProxyNameLength {
+		fullName = fullName[:maxProxyNameLength]
+	}
+	return strings.TrimSuffix(fullName, "-")
+}
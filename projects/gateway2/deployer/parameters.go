@@ -0,0 +1,121 @@
+package deployer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	api "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// Keys of the ConfigMap referenced by the parametersRef of a GatewayClass, configuring the proxies of all of its
+// Gateways. They take precedence over the Inputs of the deployer.
+const (
+	// ReplicasParameter is the number of replicas of the proxy Deployment, ignored when autoscaling is enabled
+	ReplicasParameter = "replicas"
+	// ServiceTypeParameter is the type of the proxy Service, one of ClusterIP, NodePort or LoadBalancer
+	ServiceTypeParameter = "serviceType"
+	// ResourcesParameter is a JSON object of the compute resources of the proxy container
+	ResourcesParameter = "resources"
+)
+
+// Annotations set on a Gateway to override the parameters of its GatewayClass, with the same values
+// as the matching parameters.
+const (
+	ReplicasAnnotation    = "gateway2.solo.io/replicas"
+	ServiceTypeAnnotation = "gateway2.solo.io/service-type"
+	ResourcesAnnotation   = "gateway2.solo.io/resources"
+)
+
+var supportedServiceTypes = []corev1.ServiceType{
+	corev1.ServiceTypeClusterIP,
+	corev1.ServiceTypeNodePort,
+	corev1.ServiceTypeLoadBalancer,
+}
+
+// proxyParameters are the proxy settings resolved for a Gateway from its GatewayClass and annotations.
+// Unset fields leave the settings of the Inputs.
+type proxyParameters struct {
+	replicas    *int32
+	serviceType *corev1.ServiceType
+	resources   *corev1.ResourceRequirements
+}
+
+// resolveParameters returns the proxy settings of the given Gateway: the parameters of its GatewayClass,
+// overridden by the annotations of the Gateway. The GatewayClass is only resolved when the ClusterReader is set.
+func (d *Deployer) resolveParameters(ctx context.Context, gw *api.Gateway) (*proxyParameters, error) {
+	params := &proxyParameters{}
+	if d.inputs.ClusterReader != nil && gw.Spec.GatewayClassName != "" {
+		data, err := getGatewayClassParameters(ctx, d.inputs.ClusterReader, string(gw.Spec.GatewayClassName))
+		if err != nil {
+			return nil, err
+		}
+		source := fmt.Sprintf("parameter of GatewayClass %s", gw.Spec.GatewayClassName)
+		if err := params.merge(source, data, ReplicasParameter, ServiceTypeParameter, ResourcesParameter); err != nil {
+			return nil, err
+		}
+	}
+	if err := params.merge("annotation", gw.GetAnnotations(), ReplicasAnnotation, ServiceTypeAnnotation, ResourcesAnnotation); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// getGatewayClassParameters returns the data of the ConfigMap referenced by the parametersRef of the GatewayClass,
+// or nil if the GatewayClass does not exist or has no parametersRef
+func getGatewayClassParameters(ctx context.Context, cli client.Reader, className string) (map[string]string, error) {
+	var gwClass api.GatewayClass
+	if err := cli.Get(ctx, client.ObjectKey{Name: className}, &gwClass); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get GatewayClass %s: %w", className, err)
+	}
+	ref := gwClass.Spec.ParametersRef
+	if ref == nil {
+		return nil, nil
+	}
+	if ref.Group != "" || ref.Kind != "ConfigMap" {
+		return nil, fmt.Errorf("unsupported parametersRef %s/%s of GatewayClass %s: only ConfigMaps are supported", ref.Group, ref.Kind, className)
+	}
+	if ref.Namespace == nil {
+		return nil, fmt.Errorf("parametersRef of GatewayClass %s must set the namespace of the ConfigMap", className)
+	}
+	var cm corev1.ConfigMap
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: string(*ref.Namespace), Name: ref.Name}, &cm); err != nil {
+		return nil, fmt.Errorf("failed to get parameters %s.%s of GatewayClass %s: %w", *ref.Namespace, ref.Name, className, err)
+	}
+	return cm.Data, nil
+}
+
+// merge parses the settings set in values under the given keys, overriding the current ones
+func (p *proxyParameters) merge(source string, values map[string]string, replicasKey, serviceTypeKey, resourcesKey string) error {
+	if value, ok := values[replicasKey]; ok {
+		replicas, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || replicas < 0 {
+			return fmt.Errorf("invalid value %q for %s %s: must be a non-negative integer", value, source, replicasKey)
+		}
+		r := int32(replicas)
+		p.replicas = &r
+	}
+	if value, ok := values[serviceTypeKey]; ok {
+		serviceType := corev1.ServiceType(value)
+		if !slices.Contains(supportedServiceTypes, serviceType) {
+			return fmt.Errorf("invalid value %q for %s %s: must be one of %v", value, source, serviceTypeKey, supportedServiceTypes)
+		}
+		p.serviceType = &serviceType
+	}
+	if value, ok := values[resourcesKey]; ok {
+		var resources corev1.ResourceRequirements
+		if err := json.Unmarshal([]byte(value), &resources); err != nil {
+			return fmt.Errorf("invalid value for %s %s: must be a JSON object of resource requirements: %w", source, resourcesKey, err)
+		}
+		p.resources = &resources
+	}
+	return nil
+}
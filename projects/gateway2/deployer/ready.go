@@ -0,0 +1,79 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	api "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// readyPollInterval is the interval at which WaitForReady checks the state of the deployed objects
+const readyPollInterval = 250 * time.Millisecond
+
+// WaitForReady blocks until the proxy Deployments for the given Gateway have available replicas
+// and their Services have an address, or until the context is done or the timeout expires.
+// It is intended to be called after Deploy, before marking the Gateway as Programmed.
+func (d *Deployer) WaitForReady(ctx context.Context, gw *api.Gateway, cli client.Client, timeout time.Duration) error {
+	objs, err := d.GetObjsToDeploy(ctx, gw,
+		appsv1.SchemeGroupVersion.WithKind("Deployment"),
+		corev1.SchemeGroupVersion.WithKind("Service"),
+	)
+	if err != nil {
+		return err
+	}
+
+	// there is a Deployment and a Service per shard when the listeners are sharded
+	var deploymentKeys, serviceKeys []client.ObjectKey
+	for _, obj := range objs {
+		switch obj.(type) {
+		case *appsv1.Deployment:
+			deploymentKeys = append(deploymentKeys, client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()})
+		case *corev1.Service:
+			serviceKeys = append(serviceKeys, client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()})
+		}
+	}
+
+	err = wait.PollUntilContextTimeout(ctx, readyPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		for _, deploymentKey := range deploymentKeys {
+			ready, err := isDeploymentReady(ctx, cli, deploymentKey)
+			if err != nil || !ready {
+				return false, err
+			}
+		}
+		for _, serviceKey := range serviceKeys {
+			ready, err := isServiceReady(ctx, cli, serviceKey)
+			if err != nil || !ready {
+				return false, err
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("proxy for gateway %s.%s is not ready: %w", gw.Namespace, gw.Name, err)
+	}
+	return nil
+}
+
+func isDeploymentReady(ctx context.Context, cli client.Client, key client.ObjectKey) (bool, error) {
+	var dep appsv1.Deployment
+	if err := cli.Get(ctx, key, &dep); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return dep.Status.AvailableReplicas > 0, nil
+}
+
+func isServiceReady(ctx context.Context, cli client.Client, key client.ObjectKey) (bool, error) {
+	var svc corev1.Service
+	if err := cli.Get(ctx, key, &svc); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	if svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		return len(svc.Status.LoadBalancer.Ingress) > 0, nil
+	}
+	return svc.Spec.ClusterIP != "", nil
+}
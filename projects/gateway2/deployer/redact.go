@@ -0,0 +1,39 @@
+package deployer
+
+import "golang.org/x/exp/slices"
+
+// RedactedValue replaces the values of sensitive keys when the helm values are logged
+const RedactedValue = "<redacted>"
+
+// defaultSensitiveValueKeys are the keys of the helm values that are always redacted when logged,
+// as container environment variables commonly hold credentials
+var defaultSensitiveValueKeys = []string{"env"}
+
+// redactValues returns a copy of the helm values in which the values of the given keys are replaced with
+// RedactedValue, at any depth. The given values are not modified.
+func redactValues(vals map[string]any, sensitiveKeys []string) map[string]any {
+	redacted := make(map[string]any, len(vals))
+	for k, v := range vals {
+		if slices.Contains(sensitiveKeys, k) {
+			redacted[k] = RedactedValue
+			continue
+		}
+		redacted[k] = redactValue(v, sensitiveKeys)
+	}
+	return redacted
+}
+
+func redactValue(v any, sensitiveKeys []string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return redactValues(v, sensitiveKeys)
+	case []any:
+		redacted := make([]any, len(v))
+		for i, e := range v {
+			redacted[i] = redactValue(e, sensitiveKeys)
+		}
+		return redacted
+	default:
+		return v
+	}
+}
@@ -0,0 +1,178 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/solo-io/gloo/projects/gateway2/ports"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	api "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// ListenerShardsAnnotation is set on a Gateway to the number of shards its listeners are partitioned into. A proxy
+// Deployment and Service (and their ConfigMap and ServiceAccount) are rendered per shard, named after ShardName,
+// each exposing the ports of its own listeners. Each shard connects to xds as its ShardName, and only receives the
+// configuration of its own listeners. Gateways without the annotation, or with a single shard, are not sharded.
+const ListenerShardsAnnotation = "gateway2.solo.io/listener-shards"
+
+// maxShardNameLength is the maximum length of the name of a shard, so that the names of the objects rendered
+// for it (see ProxyName) are not truncated, which would make them collide with other shards
+const maxShardNameLength = maxProxyNameLength - len(proxyNamePrefix)
+
+// ListenerShards returns the number of shards of the listeners of the Gateway, see ListenerShardsAnnotation
+func ListenerShards(gw *api.Gateway) (int, error) {
+	value, ok := gw.GetAnnotations()[ListenerShardsAnnotation]
+	if !ok {
+		return 1, nil
+	}
+	shards, err := strconv.Atoi(value)
+	if err != nil || shards < 1 {
+		return 0, fmt.Errorf("invalid value for annotation %s: must be a positive number of shards, got %q", ListenerShardsAnnotation, value)
+	}
+	return shards, nil
+}
+
+// ShardName returns the name of the given listener shard of the Gateway (see ListenerShardsAnnotation), which the
+// objects of the shard are named after, e.g. the proxy Deployment of shard 1 of Gateway foo is gloo-proxy-foo-shard-1.
+// Long Gateway names are truncated, keeping the shard suffix so that the names of the shards remain unique.
+// Any name of a shard is a valid Gateway name as well, so shards may collide with other Gateways (see CollidingGateway).
+func ShardName(gw *api.Gateway, shard int) string {
+	suffix := fmt.Sprintf("-shard-%d", shard)
+	name := gw.Name
+	if len(name)+len(suffix) > maxShardNameLength {
+		name = name[:maxShardNameLength-len(suffix)]
+	}
+	return name + suffix
+}
+
+// ProxyNames returns the names the proxies of the Gateway are deployed as and connect to xds as: the names of its
+// shards (see ShardName) if its listeners are sharded, or the name of the Gateway otherwise
+func ProxyNames(gw *api.Gateway) []string {
+	shards, err := ListenerShards(gw)
+	if err != nil || shards <= 1 {
+		return []string{gw.Name}
+	}
+	names := make([]string, shards)
+	for shard := range names {
+		names[shard] = ShardName(gw, shard)
+	}
+	return names
+}
+
+// CollidingGateway returns the Gateway of the given ones whose proxies are named like a proxy of gw (see ProxyNames),
+// e.g. Gateway foo-shard-1 and shard 1 of Gateway foo, or nil if there is none. Only the Gateway created last
+// collides, so that the proxies of the Gateway created first keep running while the other is not deployed.
+func CollidingGateway(gw *api.Gateway, gateways []api.Gateway) *api.Gateway {
+	names := map[string]bool{}
+	for _, name := range ProxyNames(gw) {
+		names[name] = true
+	}
+	for i := range gateways {
+		other := &gateways[i]
+		if other.Namespace != gw.Namespace || other.Name == gw.Name || !createdBefore(other, gw) {
+			continue
+		}
+		for _, name := range ProxyNames(other) {
+			if names[name] {
+				return other
+			}
+		}
+	}
+	return nil
+}
+
+// createdBefore orders Gateways by creation, falling back to their names for Gateways created in the same second
+func createdBefore(gw, other *api.Gateway) bool {
+	if !gw.CreationTimestamp.Equal(&other.CreationTimestamp) {
+		return gw.CreationTimestamp.Before(&other.CreationTimestamp)
+	}
+	return gw.Name < other.Name
+}
+
+// checkProxyNames returns an error if the proxies of the Gateway collide with the proxies of another Gateway of its
+// namespace (see CollidingGateway). Gateways are only checked when the ClusterReader is set.
+func (d *Deployer) checkProxyNames(ctx context.Context, gw *api.Gateway) error {
+	if d.inputs.ClusterReader == nil {
+		return nil
+	}
+	var gwList api.GatewayList
+	if err := d.inputs.ClusterReader.List(ctx, &gwList, client.InNamespace(gw.Namespace)); err != nil {
+		return err
+	}
+	if other := CollidingGateway(gw, gwList.Items); other != nil {
+		return fmt.Errorf("the proxies of gateway %s.%s are named like the proxies of gateway %s.%s, see %s",
+			gw.Namespace, gw.Name, other.Namespace, other.Name, ListenerShardsAnnotation)
+	}
+	return nil
+}
+
+// ListenerPortShards returns the shard of each port the proxy listens on for the listeners of the Gateway, i.e.
+// their translated ports (see ports.TranslatePort). Ports are sorted and assigned to the shards round-robin, so
+// that the shards are balanced and the partition does not depend on the order of the listeners. Listeners sharing
+// a proxy port are merged into a single proxy listener, so they are always assigned to the same shard.
+func ListenerPortShards(gw *api.Gateway, shards int) map[uint16]int {
+	var proxyPorts []uint16
+	seen := map[uint16]bool{}
+	for _, l := range gw.Spec.Listeners {
+		port := ports.TranslatePort(uint16(l.Port))
+		if seen[port] {
+			continue
+		}
+		seen[port] = true
+		proxyPorts = append(proxyPorts, port)
+	}
+	sort.Slice(proxyPorts, func(i, j int) bool { return proxyPorts[i] < proxyPorts[j] })
+
+	ret := map[uint16]int{}
+	for i, port := range proxyPorts {
+		ret[port] = i % shards
+	}
+	return ret
+}
+
+// shardPorts partitions the ports of a Gateway into the given number of shards, see ListenerPortShards.
+// Shards may be empty if there are fewer ports than shards; they are rendered anyway so that the objects of a
+// shard are not removed and recreated as listeners come and go.
+func shardPorts(gw *api.Gateway, gwPorts []gatewayPort, shards int) [][]gatewayPort {
+	portShards := ListenerPortShards(gw, shards)
+	ret := make([][]gatewayPort, shards)
+	for i := range ret {
+		// must not be nil for helm to not fail.
+		ret[i] = []gatewayPort{}
+	}
+	for _, port := range gwPorts {
+		shard := portShards[port.TargetPort]
+		ret[shard] = append(ret[shard], port)
+	}
+	for _, shardPorts := range ret {
+		sort.SliceStable(shardPorts, func(i, j int) bool { return shardPorts[i].Port < shardPorts[j].Port })
+	}
+	return ret
+}
+
+// renderShards renders the chart once per listener shard of the Gateway, with the given values
+// overridden with the name and ports of each shard
+func (d *Deployer) renderShards(ctx context.Context, gw *api.Gateway, vals map[string]any, gwPorts []gatewayPort, shards int) ([]client.Object, error) {
+	gatewayVals := vals["gateway"].(map[string]any)
+	var objs []client.Object
+	for shard, ports := range shardPorts(gw, gwPorts, shards) {
+		var portsAny []any
+		if err := jsonConvert(ports, &portsAny); err != nil {
+			return nil, err
+		}
+		gatewayVals["name"] = ShardName(gw, shard)
+		// the shard gets the xds snapshot of the proxy translated for its listeners
+		gatewayVals["gatewayName"] = ShardName(gw, shard)
+		gatewayVals["fullnameOverride"] = proxyName(ShardName(gw, shard))
+		gatewayVals["ports"] = portsAny
+		d.logger(ctx).V(1).Info("rendering helm chart", "shard", shard, "vals", redactValues(vals, d.sensitiveValueKeys()))
+		shardObjs, err := d.Render(ctx, ReleaseName(gw), gw.Namespace, vals)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render shard %d: %w", shard, err)
+		}
+		objs = append(objs, shardObjs...)
+	}
+	return objs, nil
+}
@@ -12,8 +12,8 @@ import (
 // K8sGatewayExtensions is responsible for providing implementations for translation utilities
 // which have Enterprise variants.
 type K8sGatewayExtensions interface {
-	// CreatePluginRegistry returns the PluginRegistry
-	CreatePluginRegistry(ctx context.Context) registry.PluginRegistry
+	// CreatePluginRegistry returns the PluginRegistry, or an error if its plugins depend on each other
+	CreatePluginRegistry(ctx context.Context) (registry.PluginRegistry, error)
 }
 
 // K8sGatewayExtensionsFactory returns an extensions.K8sGatewayExtensions
@@ -31,10 +31,10 @@ type k8sGatewayExtensions struct {
 }
 
 // CreatePluginRegistry returns the PluginRegistry
-func (e *k8sGatewayExtensions) CreatePluginRegistry(_ context.Context) registry.PluginRegistry {
+func (e *k8sGatewayExtensions) CreatePluginRegistry(ctx context.Context) (registry.PluginRegistry, error) {
 	plugins := registry.BuildPlugins(query.NewData(
 		e.mgr.GetClient(),
 		e.mgr.GetScheme(),
 	))
-	return registry.NewPluginRegistry(plugins)
+	return registry.NewPluginRegistry(registry.FilterAvailablePlugins(ctx, plugins))
 }
@@ -31,7 +31,7 @@ If release name contains chart name it will be used as a full name.
 {{- .Values.gateway.fullnameOverride | trunc 63 | trimSuffix "-" }}
 {{- else }}
 {{- $name := default .Release.Name .Values.gateway.nameOverride }}
-{{- .Release.Name | printf "gloo-proxy-%s" | trunc 63 | trimSuffix "-" }}
+{{- default .Release.Name .Values.gateway.name | printf "gloo-proxy-%s" | trunc 63 | trimSuffix "-" }}
 {{- end }}
 {{- end }}
 
@@ -61,7 +61,7 @@ Selector labels
 */}}
 {{- define "gloo-gateway.gateway.selectorLabels" -}}
 app.kubernetes.io/name: {{ include "gloo-gateway.gateway.name" . }}
-app.kubernetes.io/instance: {{ .Release.Name }}
+app.kubernetes.io/instance: {{ default .Release.Name .Values.gateway.name }}
 {{- end }}
 
 {{/*
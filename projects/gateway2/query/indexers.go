@@ -7,17 +7,22 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	apiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	apiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	apiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
 const (
 	HttpRouteTargetField    = "http-route-target"
+	TlsRouteTargetField     = "tls-route-target"
+	TcpRouteTargetField     = "tcp-route-target"
 	ReferenceGrantFromField = "ref-grant-from"
 )
 
 func IterateIndices(f func(client.Object, string, client.IndexerFunc) error) error {
 	return errors.Join(
 		f(&apiv1.HTTPRoute{}, HttpRouteTargetField, httpRouteToTargetIndexer),
+		f(&apiv1alpha2.TLSRoute{}, TlsRouteTargetField, tlsRouteToTargetIndexer),
+		f(&apiv1alpha2.TCPRoute{}, TcpRouteTargetField, tcpRouteToTargetIndexer),
 		f(&apiv1beta1.ReferenceGrant{}, ReferenceGrantFromField, refGrantFromIndexer),
 	)
 }
@@ -27,8 +32,29 @@ func httpRouteToTargetIndexer(obj client.Object) []string {
 	if !ok {
 		panic(fmt.Sprintf("wrong type %T provided to indexer. expected HTTPRoute", obj))
 	}
+	return parentRefsToTargets(hr.Namespace, hr.Spec.ParentRefs)
+}
+
+func tlsRouteToTargetIndexer(obj client.Object) []string {
+	tr, ok := obj.(*apiv1alpha2.TLSRoute)
+	if !ok {
+		panic(fmt.Sprintf("wrong type %T provided to indexer. expected TLSRoute", obj))
+	}
+	return parentRefsToTargets(tr.Namespace, tr.Spec.ParentRefs)
+}
+
+func tcpRouteToTargetIndexer(obj client.Object) []string {
+	tr, ok := obj.(*apiv1alpha2.TCPRoute)
+	if !ok {
+		panic(fmt.Sprintf("wrong type %T provided to indexer. expected TCPRoute", obj))
+	}
+	return parentRefsToTargets(tr.Namespace, tr.Spec.ParentRefs)
+}
+
+// parentRefsToTargets returns the namespaced names of the Gateways referenced by the parentRefs of a route
+func parentRefsToTargets(routeNamespace string, parentRefs []apiv1.ParentReference) []string {
 	var parents []string
-	for _, pRef := range hr.Spec.ParentRefs {
+	for _, pRef := range parentRefs {
 		if pRef.Group != nil && *pRef.Group != apiv1.GroupName {
 			continue
 		}
@@ -37,7 +63,7 @@ func httpRouteToTargetIndexer(obj client.Object) []string {
 		}
 		ns := resolveNs(pRef.Namespace)
 		if ns == "" {
-			ns = hr.Namespace
+			ns = routeNamespace
 		}
 		nns := types.NamespacedName{
 			Namespace: ns,
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -14,6 +16,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	apiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	apiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	apiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
@@ -24,6 +27,7 @@ var (
 	ErrNoMatchingParent           = fmt.Errorf("no matching parent")
 	ErrNotAllowedByListeners      = fmt.Errorf("not allowed by listeners")
 	ErrLocalObjRefMissingKind     = fmt.Errorf("localObjRef provided with empty kind")
+	ErrMissingBackendPort         = fmt.Errorf("backendRef to a Service must set a port")
 )
 
 type Error struct {
@@ -111,12 +115,16 @@ func (f FromGkNs) Namespace() string {
 type GatewayQueries interface {
 	ObjToFrom(obj client.Object) From
 
-	// Returns map of listener names -> list of http routes.
+	// Returns map of listener names -> list of http, tls and tcp routes.
 	GetRoutesForGw(ctx context.Context, gw *apiv1.Gateway) (RoutesForGwResult, error)
 	// Given a backendRef that resides in namespace obj, return the service that backs it.
 	// This will error with `ErrMissingReferenceGrant` if there is no reference grant allowing the reference
 	// return value depends on the group/kind in the backendRef.
 	GetBackendForRef(ctx context.Context, obj From, backendRef *apiv1.BackendObjectReference) (client.Object, error)
+	// Given a backendRef that resides in namespace obj, return the reference to the upstream of the service that backs it.
+	// This will error with an `*Error`, whose reason is that of the ResolvedRefs condition of the route, wrapping
+	// `ErrMissingReferenceGrant` if there is no reference grant allowing the reference, or a not found error.
+	ResolveBackendRef(ctx context.Context, obj From, backendRef *apiv1.BackendObjectReference) (*core.ResourceRef, error)
 
 	GetSecretForRef(ctx context.Context, obj From, secretRef apiv1.SecretObjectReference) (client.Object, error)
 
@@ -127,11 +135,15 @@ type RoutesForGwResult struct {
 	// key is listener name
 	ListenerResults map[string]*ListenerResult
 	RouteErrors     []*RouteError
+	TLSRouteErrors  []*TLSRouteError
+	TCPRouteErrors  []*TCPRouteError
 }
 
 type ListenerResult struct {
-	Error  error
-	Routes []*ListenerRouteResult
+	Error     error
+	Routes    []*ListenerRouteResult
+	TLSRoutes []*ListenerTLSRouteResult
+	TCPRoutes []*ListenerTCPRouteResult
 }
 
 type ListenerRouteResult struct {
@@ -140,12 +152,36 @@ type ListenerRouteResult struct {
 	Hostnames []string
 }
 
+type ListenerTLSRouteResult struct {
+	Route     apiv1alpha2.TLSRoute
+	ParentRef apiv1.ParentReference
+	// Hostnames are the SNI names the route is selected for on the listener
+	Hostnames []string
+}
+
+type ListenerTCPRouteResult struct {
+	Route     apiv1alpha2.TCPRoute
+	ParentRef apiv1.ParentReference
+}
+
 type RouteError struct {
 	Route     apiv1.HTTPRoute
 	ParentRef apiv1.ParentReference
 	Error     Error
 }
 
+type TLSRouteError struct {
+	Route     apiv1alpha2.TLSRoute
+	ParentRef apiv1.ParentReference
+	Error     Error
+}
+
+type TCPRouteError struct {
+	Route     apiv1alpha2.TCPRoute
+	ParentRef apiv1.ParentReference
+	Error     Error
+}
+
 func NewData(c client.Client, scheme *runtime.Scheme) GatewayQueries {
 	return &gatewayQueries{c, scheme}
 }
@@ -187,64 +223,77 @@ func (r *gatewayQueries) GetRoutesForGw(ctx context.Context, gw *apiv1.Gateway)
 	}
 
 	for _, hr := range hrlist.Items {
-		refs := getParentRefsForGw(gw, &hr)
+		refs := getParentRefsForGw(gw, hr.Namespace, hr.Spec.ParentRefs)
 		for _, ref := range refs {
-			anyRoutesAllowed := false
-			anyListenerMatched := false
-			anyHostsMatch := false
-			for _, l := range gw.Spec.Listeners {
-				lr := ret.ListenerResults[string(l.Name)]
-
-				if lr == nil {
-					lr = &ListenerResult{}
-					ret.ListenerResults[string(l.Name)] = lr
-				}
-
-				allowedNs, allowedKinds, err := r.allowedRoutes(gw, &l)
-				if err != nil {
-					lr.Error = err
-					continue
-				}
-
-				if isHttpRouteAllowed(allowedKinds) {
-					if !allowedNs(hr.Namespace) {
-						continue
-					}
-					anyRoutesAllowed = true
-
-					if !parentRefMatchListener(ref, &l) {
-						continue
-					}
-					anyListenerMatched = true
-					if ok, hostnames := hostnameIntersect(&l, &hr); ok {
-						lrr := &ListenerRouteResult{
-							Route:     hr,
-							Hostnames: hostnames,
-							ParentRef: ref,
-						}
-						anyHostsMatch = true
-						lr.Routes = append(lr.Routes, lrr)
-					}
-				}
-			}
-
-			if !anyRoutesAllowed {
+			routeErr := r.attachRoute(gw, ref, hr.Namespace, hr.Spec.Hostnames, isHttpRouteAllowed, ret,
+				func(lr *ListenerResult, hostnames []string) {
+					lr.Routes = append(lr.Routes, &ListenerRouteResult{
+						Route:     hr,
+						Hostnames: hostnames,
+						ParentRef: ref,
+					})
+				})
+			if routeErr != nil {
 				ret.RouteErrors = append(ret.RouteErrors, &RouteError{
 					Route:     hr,
 					ParentRef: ref,
-					Error:     Error{E: ErrNotAllowedByListeners, Reason: apiv1.RouteReasonNotAllowedByListeners},
+					Error:     *routeErr,
 				})
-			} else if !anyListenerMatched {
-				ret.RouteErrors = append(ret.RouteErrors, &RouteError{
-					Route:     hr,
+			}
+		}
+	}
+
+	// the TLSRoute CRD is part of the experimental channel and may not be installed
+	var tlsList apiv1alpha2.TLSRouteList
+	err = r.client.List(ctx, &tlsList, client.MatchingFieldsSelector{Selector: fields.OneTermEqualSelector(TlsRouteTargetField, nns.String())})
+	if err != nil && !meta.IsNoMatchError(err) {
+		return ret, err
+	}
+
+	for _, tr := range tlsList.Items {
+		refs := getParentRefsForGw(gw, tr.Namespace, tr.Spec.ParentRefs)
+		for _, ref := range refs {
+			routeErr := r.attachRoute(gw, ref, tr.Namespace, tr.Spec.Hostnames, isTlsRouteAllowed, ret,
+				func(lr *ListenerResult, hostnames []string) {
+					lr.TLSRoutes = append(lr.TLSRoutes, &ListenerTLSRouteResult{
+						Route:     tr,
+						Hostnames: hostnames,
+						ParentRef: ref,
+					})
+				})
+			if routeErr != nil {
+				ret.TLSRouteErrors = append(ret.TLSRouteErrors, &TLSRouteError{
+					Route:     tr,
 					ParentRef: ref,
-					Error:     Error{E: ErrNoMatchingParent, Reason: apiv1.RouteReasonNoMatchingParent},
+					Error:     *routeErr,
 				})
-			} else if !anyHostsMatch {
-				ret.RouteErrors = append(ret.RouteErrors, &RouteError{
-					Route:     hr,
+			}
+		}
+	}
+
+	// the TCPRoute CRD is part of the experimental channel and may not be installed
+	var tcpList apiv1alpha2.TCPRouteList
+	err = r.client.List(ctx, &tcpList, client.MatchingFieldsSelector{Selector: fields.OneTermEqualSelector(TcpRouteTargetField, nns.String())})
+	if err != nil && !meta.IsNoMatchError(err) {
+		return ret, err
+	}
+
+	for _, tr := range tcpList.Items {
+		refs := getParentRefsForGw(gw, tr.Namespace, tr.Spec.ParentRefs)
+		for _, ref := range refs {
+			// TCPRoutes have no hostnames
+			routeErr := r.attachRoute(gw, ref, tr.Namespace, nil, isTcpRouteAllowed, ret,
+				func(lr *ListenerResult, _ []string) {
+					lr.TCPRoutes = append(lr.TCPRoutes, &ListenerTCPRouteResult{
+						Route:     tr,
+						ParentRef: ref,
+					})
+				})
+			if routeErr != nil {
+				ret.TCPRouteErrors = append(ret.TCPRouteErrors, &TCPRouteError{
+					Route:     tr,
 					ParentRef: ref,
-					Error:     Error{E: ErrNoMatchingListenerHostname, Reason: apiv1.RouteReasonNoMatchingListenerHostname},
+					Error:     *routeErr,
 				})
 			}
 		}
@@ -252,6 +301,63 @@ func (r *gatewayQueries) GetRoutesForGw(ctx context.Context, gw *apiv1.Gateway)
 	return ret, nil
 }
 
+// attachRoute attaches a route to the listeners of the gateway selected by the given parentRef.
+// For each listener the route attaches to, attach is called with the intersecting hostnames.
+// If the route could not be attached to any listener, the reason is returned.
+func (r *gatewayQueries) attachRoute(
+	gw *apiv1.Gateway,
+	ref apiv1.ParentReference,
+	routeNamespace string,
+	routeHostnames []apiv1.Hostname,
+	isKindAllowed func([]metav1.GroupKind) bool,
+	ret RoutesForGwResult,
+	attach func(lr *ListenerResult, hostnames []string),
+) *Error {
+	anyRoutesAllowed := false
+	anyListenerMatched := false
+	anyHostsMatch := false
+	for _, l := range gw.Spec.Listeners {
+		lr := ret.ListenerResults[string(l.Name)]
+
+		if lr == nil {
+			lr = &ListenerResult{}
+			ret.ListenerResults[string(l.Name)] = lr
+		}
+
+		allowedNs, allowedKinds, err := r.allowedRoutes(gw, &l)
+		if err != nil {
+			lr.Error = err
+			continue
+		}
+
+		if isKindAllowed(allowedKinds) {
+			if !allowedNs(routeNamespace) {
+				continue
+			}
+			anyRoutesAllowed = true
+
+			if !parentRefMatchListener(ref, &l) {
+				continue
+			}
+			anyListenerMatched = true
+			if ok, hostnames := hostnameIntersect(&l, routeHostnames); ok {
+				anyHostsMatch = true
+				attach(lr, hostnames)
+			}
+		}
+	}
+
+	switch {
+	case !anyRoutesAllowed:
+		return &Error{E: ErrNotAllowedByListeners, Reason: apiv1.RouteReasonNotAllowedByListeners}
+	case !anyListenerMatched:
+		return &Error{E: ErrNoMatchingParent, Reason: apiv1.RouteReasonNoMatchingParent}
+	case !anyHostsMatch:
+		return &Error{E: ErrNoMatchingListenerHostname, Reason: apiv1.RouteReasonNoMatchingListenerHostname}
+	}
+	return nil
+}
+
 func (r *gatewayQueries) allowedRoutes(gw *apiv1.Gateway, l *apiv1.Listener) (func(string) bool, []metav1.GroupKind, error) {
 	var allowedKinds []metav1.GroupKind
 
@@ -261,9 +367,9 @@ func (r *gatewayQueries) allowedRoutes(gw *apiv1.Gateway, l *apiv1.Listener) (fu
 	case apiv1.HTTPProtocolType:
 		allowedKinds = []metav1.GroupKind{{Kind: "HTTPRoute", Group: "gateway.networking.k8s.io"}}
 	case apiv1.TLSProtocolType:
-		fallthrough
+		allowedKinds = []metav1.GroupKind{{Kind: "TLSRoute", Group: "gateway.networking.k8s.io"}}
 	case apiv1.TCPProtocolType:
-		allowedKinds = []metav1.GroupKind{{}}
+		allowedKinds = []metav1.GroupKind{{Kind: "TCPRoute", Group: "gateway.networking.k8s.io"}}
 	case apiv1.UDPProtocolType:
 		allowedKinds = []metav1.GroupKind{{}}
 	}
@@ -311,9 +417,9 @@ func parentRefMatchListener(ref apiv1.ParentReference, l *apiv1.Listener) bool {
 	return true
 }
 
-func getParentRefsForGw(gw *apiv1.Gateway, hr *apiv1.HTTPRoute) []apiv1.ParentReference {
+func getParentRefsForGw(gw *apiv1.Gateway, routeNamespace string, parentRefs []apiv1.ParentReference) []apiv1.ParentReference {
 	var ret []apiv1.ParentReference
-	for _, pRef := range hr.Spec.ParentRefs {
+	for _, pRef := range parentRefs {
 
 		if pRef.Group != nil && *pRef.Group != "gateway.networking.k8s.io" {
 			continue
@@ -321,7 +427,7 @@ func getParentRefsForGw(gw *apiv1.Gateway, hr *apiv1.HTTPRoute) []apiv1.ParentRe
 		if pRef.Kind != nil && *pRef.Kind != "Gateway" {
 			continue
 		}
-		ns := hr.Namespace
+		ns := routeNamespace
 		if pRef.Namespace != nil {
 			ns = string(*pRef.Namespace)
 		}
@@ -333,10 +439,10 @@ func getParentRefsForGw(gw *apiv1.Gateway, hr *apiv1.HTTPRoute) []apiv1.ParentRe
 	return ret
 }
 
-func hostnameIntersect(l *apiv1.Listener, hr *apiv1.HTTPRoute) (bool, []string) {
+func hostnameIntersect(l *apiv1.Listener, routeHostnames []apiv1.Hostname) (bool, []string) {
 	var hostnames []string
 	if l.Hostname == nil {
-		for _, h := range hr.Spec.Hostnames {
+		for _, h := range routeHostnames {
 			hostnames = append(hostnames, string(h))
 		}
 		return true, hostnames
@@ -344,11 +450,11 @@ func hostnameIntersect(l *apiv1.Listener, hr *apiv1.HTTPRoute) (bool, []string)
 	var listenerHostname string = string(*l.Hostname)
 
 	if strings.HasPrefix(listenerHostname, "*.") {
-		if hr.Spec.Hostnames == nil {
+		if routeHostnames == nil {
 			return true, []string{listenerHostname}
 		}
 
-		for _, hostname := range hr.Spec.Hostnames {
+		for _, hostname := range routeHostnames {
 			hrHost := string(hostname)
 			if strings.HasSuffix(hrHost, listenerHostname[1:]) {
 				hostnames = append(hostnames, hrHost)
@@ -356,10 +462,10 @@ func hostnameIntersect(l *apiv1.Listener, hr *apiv1.HTTPRoute) (bool, []string)
 		}
 		return len(hostnames) > 0, hostnames
 	} else {
-		if len(hr.Spec.Hostnames) == 0 {
+		if len(routeHostnames) == 0 {
 			return true, []string{listenerHostname}
 		}
-		for _, hostname := range hr.Spec.Hostnames {
+		for _, hostname := range routeHostnames {
 			hrHost := string(hostname)
 			if hrHost == listenerHostname {
 				return true, []string{listenerHostname}
@@ -422,6 +528,11 @@ func (r *gatewayQueries) GetBackendForRef(ctx context.Context, obj From, backend
 	return r.getRef(ctx, obj, string(backend.Name), backend.Namespace, backendGK)
 }
 
+func (r *gatewayQueries) ResolveBackendRef(ctx context.Context, obj From, backend *apiv1.BackendObjectReference) (*core.ResourceRef, error) {
+	backendObj, err := r.GetBackendForRef(ctx, obj, backend)
+	return upstreamRefForBackend(backendObj, err, *backend)
+}
+
 func (r *gatewayQueries) getRef(ctx context.Context, from From, backendName string, backendNS *apiv1.Namespace, backendGK metav1.GroupKind) (client.Object, error) {
 	fromNs := from.Namespace()
 	if fromNs == "" {
@@ -474,6 +585,14 @@ func isHttpRouteAllowed(allowedKinds []metav1.GroupKind) bool {
 	return isRouteAllowed("gateway.networking.k8s.io", "HTTPRoute", allowedKinds)
 }
 
+func isTlsRouteAllowed(allowedKinds []metav1.GroupKind) bool {
+	return isRouteAllowed("gateway.networking.k8s.io", "TLSRoute", allowedKinds)
+}
+
+func isTcpRouteAllowed(allowedKinds []metav1.GroupKind) bool {
+	return isRouteAllowed("gateway.networking.k8s.io", "TCPRoute", allowedKinds)
+}
+
 func isRouteAllowed(group, kind string, allowedKinds []metav1.GroupKind) bool {
 	for _, k := range allowedKinds {
 		var allowedGroup string = k.Group
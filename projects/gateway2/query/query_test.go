@@ -2,12 +2,14 @@ package query_test
 
 import (
 	"context"
+	"errors"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	gwscheme "github.com/solo-io/gloo/projects/gateway2/controller/scheme"
 	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -15,6 +17,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	apiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	apiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	apiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
@@ -149,6 +152,100 @@ var _ = Describe("Query", func() {
 		})
 	})
 
+	Describe("ResolveBackendRef", func() {
+		expectReason := func(err error, reason apiv1.RouteConditionReason) {
+			var queryErr *query.Error
+			ExpectWithOffset(1, errors.As(err, &queryErr)).To(BeTrue())
+			ExpectWithOffset(1, queryErr.Reason).To(Equal(reason))
+		}
+
+		It("should resolve a service in the same namespace", func() {
+			fakeClient := builder.WithObjects(svc("default")).Build()
+			gq := query.NewData(fakeClient, scheme)
+			ref := &apiv1.BackendObjectReference{
+				Name: "foo",
+				Port: portptr(8080),
+			}
+
+			upstreamRef, err := gq.ResolveBackendRef(context.Background(), tofrom(httpRoute()), ref)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(upstreamRef).To(Equal(&core.ResourceRef{Name: "default-foo-8080", Namespace: "default"}))
+		})
+
+		It("should resolve a service in a different ns if we have a ref grant", func() {
+			fakeClient := builder.WithObjects(svc("default2"), refGrant()).Build()
+			gq := query.NewData(fakeClient, scheme)
+			ref := &apiv1.BackendObjectReference{
+				Name:      "foo",
+				Namespace: nsptr("default2"),
+				Port:      portptr(8080),
+			}
+
+			upstreamRef, err := gq.ResolveBackendRef(context.Background(), tofrom(httpRoute()), ref)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(upstreamRef).To(Equal(&core.ResourceRef{Name: "default2-foo-8080", Namespace: "default2"}))
+		})
+
+		It("should fail as forbidden without a ref grant", func() {
+			fakeClient := builder.WithObjects(svc("default3")).Build()
+			gq := query.NewData(fakeClient, scheme)
+			ref := &apiv1.BackendObjectReference{
+				Name:      "foo",
+				Namespace: nsptr("default3"),
+				Port:      portptr(8080),
+			}
+
+			upstreamRef, err := gq.ResolveBackendRef(context.Background(), tofrom(httpRoute()), ref)
+			Expect(err).To(MatchError(query.ErrMissingReferenceGrant))
+			expectReason(err, apiv1.RouteReasonRefNotPermitted)
+			Expect(upstreamRef).To(BeNil())
+		})
+
+		It("should fail as not found if the service does not exist", func() {
+			fakeClient := builder.WithObjects(refGrant()).Build()
+			gq := query.NewData(fakeClient, scheme)
+			ref := &apiv1.BackendObjectReference{
+				Name:      "foo",
+				Namespace: nsptr("default2"),
+				Port:      portptr(8080),
+			}
+
+			upstreamRef, err := gq.ResolveBackendRef(context.Background(), tofrom(httpRoute()), ref)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			expectReason(err, apiv1.RouteReasonBackendNotFound)
+			Expect(upstreamRef).To(BeNil())
+		})
+
+		It("should fail without a port", func() {
+			fakeClient := builder.WithObjects(svc("default")).Build()
+			gq := query.NewData(fakeClient, scheme)
+			ref := &apiv1.BackendObjectReference{
+				Name: "foo",
+			}
+
+			upstreamRef, err := gq.ResolveBackendRef(context.Background(), tofrom(httpRoute()), ref)
+			Expect(err).To(MatchError(query.ErrMissingBackendPort))
+			expectReason(err, apiv1.RouteReasonUnsupportedValue)
+			Expect(upstreamRef).To(BeNil())
+		})
+
+		It("should fail with an unknown kind", func() {
+			fakeClient := builder.Build()
+			gq := query.NewData(fakeClient, scheme)
+			ref := &apiv1.BackendObjectReference{
+				Group: groupptr("example.com"),
+				Kind:  kindptr("Backend"),
+				Name:  "foo",
+				Port:  portptr(8080),
+			}
+
+			upstreamRef, err := gq.ResolveBackendRef(context.Background(), tofrom(httpRoute()), ref)
+			Expect(err).To(MatchError(query.ErrUnknownKind))
+			expectReason(err, apiv1.RouteReasonInvalidKind)
+			Expect(upstreamRef).To(BeNil())
+		})
+	})
+
 	Describe("GetSecretRef", func() {
 
 		It("should get secret from different ns if we have a ref grant", func() {
@@ -226,6 +323,95 @@ var _ = Describe("Query", func() {
 			Expect(routes.ListenerResults["foo"].Routes).To(HaveLen(1))
 		})
 
+		It("should get tls routes for tls listener", func() {
+			gwWithListener := gw()
+			gwWithListener.Spec.Listeners = []apiv1.Listener{
+				{
+					Name:     "foo",
+					Protocol: apiv1.TLSProtocolType,
+				},
+				{
+					Name:     "bar",
+					Protocol: apiv1.HTTPProtocolType,
+				},
+			}
+			tr := tlsRoute()
+			tr.Spec.ParentRefs = []apiv1.ParentReference{
+				{
+					Name: apiv1.ObjectName(gwWithListener.Name),
+				},
+			}
+			tr.Spec.Hostnames = []apiv1alpha2.Hostname{"foo.example.com"}
+
+			fakeClient := builder.WithObjects(tr).Build()
+			gq := query.NewData(fakeClient, scheme)
+			routes, err := gq.GetRoutesForGw(context.Background(), gwWithListener)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes.ListenerResults["foo"].TLSRoutes).To(HaveLen(1))
+			Expect(routes.ListenerResults["foo"].TLSRoutes[0].Hostnames).To(ConsistOf("foo.example.com"))
+			Expect(routes.ListenerResults["foo"].Routes).To(BeEmpty())
+			Expect(routes.ListenerResults["bar"].TLSRoutes).To(BeEmpty())
+			Expect(routes.TLSRouteErrors).To(BeEmpty())
+		})
+
+		It("should error when no listener allows tls routes", func() {
+			gwWithListener := gw()
+			gwWithListener.Spec.Listeners = []apiv1.Listener{
+				{
+					Name:     "bar",
+					Protocol: apiv1.HTTPProtocolType,
+				},
+			}
+			tr := tlsRoute()
+			tr.Spec.ParentRefs = []apiv1.ParentReference{
+				{
+					Name: apiv1.ObjectName(gwWithListener.Name),
+				},
+			}
+
+			fakeClient := builder.WithObjects(tr).Build()
+			gq := query.NewData(fakeClient, scheme)
+			routes, err := gq.GetRoutesForGw(context.Background(), gwWithListener)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes.TLSRouteErrors).To(HaveLen(1))
+			Expect(routes.TLSRouteErrors[0].Error.E).To(MatchError(query.ErrNotAllowedByListeners))
+		})
+
+		It("should get tcp routes for the tcp listener of the parent ref section", func() {
+			gwWithListener := gw()
+			gwWithListener.Spec.Listeners = []apiv1.Listener{
+				{
+					Name:     "foo",
+					Protocol: apiv1.TCPProtocolType,
+					Port:     5432,
+				},
+				{
+					Name:     "bar",
+					Protocol: apiv1.TCPProtocolType,
+					Port:     9000,
+				},
+			}
+			section := apiv1.SectionName("foo")
+			tr := tcpRoute()
+			tr.Spec.ParentRefs = []apiv1.ParentReference{
+				{
+					Name:        apiv1.ObjectName(gwWithListener.Name),
+					SectionName: &section,
+				},
+			}
+
+			fakeClient := builder.WithObjects(tr).Build()
+			gq := query.NewData(fakeClient, scheme)
+			routes, err := gq.GetRoutesForGw(context.Background(), gwWithListener)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes.ListenerResults["foo"].TCPRoutes).To(HaveLen(1))
+			Expect(routes.ListenerResults["bar"].TCPRoutes).To(BeEmpty())
+			Expect(routes.TCPRouteErrors).To(BeEmpty())
+		})
+
 		It("should error with invalid label selector", func() {
 			gwWithListener := gw()
 			selector := apiv1.NamespacesFromSelector
@@ -587,6 +773,24 @@ func httpRoute() *apiv1.HTTPRoute {
 
 }
 
+func tlsRoute() *apiv1alpha2.TLSRoute {
+	return &apiv1alpha2.TLSRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "test",
+		},
+	}
+}
+
+func tcpRoute() *apiv1alpha2.TCPRoute {
+	return &apiv1alpha2.TCPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "test",
+		},
+	}
+}
+
 func gw() *apiv1.Gateway {
 	return &apiv1.Gateway{
 		ObjectMeta: metav1.ObjectMeta{
@@ -619,3 +823,18 @@ func nsptr(s string) *apiv1.Namespace {
 	var ns apiv1.Namespace = apiv1.Namespace(s)
 	return &ns
 }
+
+func portptr(p int32) *apiv1.PortNumber {
+	var port apiv1.PortNumber = apiv1.PortNumber(p)
+	return &port
+}
+
+func groupptr(s string) *apiv1.Group {
+	var group apiv1.Group = apiv1.Group(s)
+	return &group
+}
+
+func kindptr(s string) *apiv1.Kind {
+	var kind apiv1.Kind = apiv1.Kind(s)
+	return &kind
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/solo-io/gloo/projects/gateway2/reports"
 	"github.com/solo-io/gloo/projects/gloo/pkg/plugins/kubernetes"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -12,63 +13,70 @@ import (
 	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
+// Backend refs which do not resolve are translated to destinations to the blackhole upstream, which does not exist,
+// so that the requests they would receive are responded to with the cluster not found response code of the route.
+const (
+	BlackholeUpstreamName      = "blackhole_cluster"
+	BlackholeUpstreamNamespace = "blackhole_ns"
+)
+
 // ProcessBackendRef is meant to take the result of a call to `GetBackendForRef` as well as a reporter and the original ref.
 // The return value is a pointer to a string which is the cluster_name of the upstream that the ref resolved to.
 // This function will return nil if the ref is not valid.
 // This function will also set the appropriate condition on the parent via the reporter.
 func ProcessBackendRef(obj client.Object, err error, reporter reports.ParentRefReporter, backendRef gwv1.BackendObjectReference) *string {
+	upstreamRef, err := upstreamRefForBackend(obj, err, backendRef)
+	if err != nil {
+		ReportBackendRefError(reporter, err)
+		return nil
+	}
+	return &upstreamRef.Name
+}
+
+// ReportBackendRefError sets the ResolvedRefs condition of the parent to false, with the reason of the error returned by
+// `ResolveBackendRef`.
+func ReportBackendRefError(reporter reports.ParentRefReporter, err error) {
+	var queryErr *Error
+	if !errors.As(err, &queryErr) {
+		return
+	}
+	reporter.SetCondition(reports.HTTPRouteCondition{
+		Type:   gwv1.RouteConditionResolvedRefs,
+		Status: metav1.ConditionFalse,
+		Reason: queryErr.Reason,
+	})
+}
+
+// upstreamRefForBackend returns the reference to the upstream of the backend returned by `GetBackendForRef`,
+// or an *Error with the reason the backendRef did not resolve.
+func upstreamRefForBackend(obj client.Object, err error, backendRef gwv1.BackendObjectReference) (*core.ResourceRef, error) {
 	if err != nil {
 		switch {
 		case errors.Is(err, ErrUnknownKind):
-			reporter.SetCondition(reports.HTTPRouteCondition{
-				Type:   gwv1.RouteConditionResolvedRefs,
-				Status: metav1.ConditionFalse,
-				Reason: gwv1.RouteReasonInvalidKind,
-			})
+			return nil, &Error{Reason: gwv1.RouteReasonInvalidKind, E: err}
 		case errors.Is(err, ErrMissingReferenceGrant):
-			reporter.SetCondition(reports.HTTPRouteCondition{
-				Type:   gwv1.RouteConditionResolvedRefs,
-				Status: metav1.ConditionFalse,
-				Reason: gwv1.RouteReasonRefNotPermitted,
-			})
+			return nil, &Error{Reason: gwv1.RouteReasonRefNotPermitted, E: err}
 		case apierrors.IsNotFound(err):
-			reporter.SetCondition(reports.HTTPRouteCondition{
-				Type:   gwv1.RouteConditionResolvedRefs,
-				Status: metav1.ConditionFalse,
-				Reason: gwv1.RouteReasonBackendNotFound,
-			})
+			return nil, &Error{Reason: gwv1.RouteReasonBackendNotFound, E: err}
 		default:
 			// setting other errors to not found. not sure if there's a better option.
-			reporter.SetCondition(reports.HTTPRouteCondition{
-				Type:   gwv1.RouteConditionResolvedRefs,
-				Status: metav1.ConditionFalse,
-				Reason: gwv1.RouteReasonBackendNotFound,
-			})
-		}
-	} else {
-		var port uint32
-		if backendRef.Port != nil {
-			port = uint32(*backendRef.Port)
-		}
-		switch cli := obj.(type) {
-		case *corev1.Service:
-			if port == 0 {
-				reporter.SetCondition(reports.HTTPRouteCondition{
-					Type:   gwv1.RouteConditionResolvedRefs,
-					Status: metav1.ConditionFalse,
-					Reason: gwv1.RouteReasonUnsupportedValue,
-				})
-			} else {
-				name := kubernetes.UpstreamName(cli.Namespace, cli.Name, int32(port))
-				return &name
-			}
-		default:
-			reporter.SetCondition(reports.HTTPRouteCondition{
-				Type:   gwv1.RouteConditionResolvedRefs,
-				Status: metav1.ConditionFalse,
-				Reason: gwv1.RouteReasonInvalidKind,
-			})
+			return nil, &Error{Reason: gwv1.RouteReasonBackendNotFound, E: err}
 		}
 	}
-	return nil
+
+	svc, ok := obj.(*corev1.Service)
+	if !ok {
+		return nil, &Error{Reason: gwv1.RouteReasonInvalidKind, E: ErrUnknownKind}
+	}
+	var port int32
+	if backendRef.Port != nil {
+		port = int32(*backendRef.Port)
+	}
+	if port == 0 {
+		return nil, &Error{Reason: gwv1.RouteReasonUnsupportedValue, E: ErrMissingBackendPort}
+	}
+	return &core.ResourceRef{
+		Name:      kubernetes.UpstreamName(svc.Namespace, svc.Name, port),
+		Namespace: svc.Namespace,
+	}, nil
 }
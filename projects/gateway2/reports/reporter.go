@@ -5,11 +5,14 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
 
 type ReportMap struct {
-	gateways map[types.NamespacedName]*GatewayReport
-	routes   map[types.NamespacedName]*RouteReport
+	gateways  map[types.NamespacedName]*GatewayReport
+	routes    map[types.NamespacedName]*RouteReport
+	tlsRoutes map[types.NamespacedName]*RouteReport
+	tcpRoutes map[types.NamespacedName]*RouteReport
 }
 
 type GatewayReport struct {
@@ -40,9 +43,13 @@ type ParentRefKey struct {
 func NewReportMap() ReportMap {
 	gr := make(map[types.NamespacedName]*GatewayReport)
 	rr := make(map[types.NamespacedName]*RouteReport)
+	tlr := make(map[types.NamespacedName]*RouteReport)
+	tcr := make(map[types.NamespacedName]*RouteReport)
 	return ReportMap{
-		gateways: gr,
-		routes:   rr,
+		gateways:  gr,
+		routes:    rr,
+		tlsRoutes: tlr,
+		tcpRoutes: tcr,
 	}
 }
 
@@ -80,6 +87,34 @@ func (r *ReportMap) newRouteReport(route *gwv1.HTTPRoute) *RouteReport {
 	return rr
 }
 
+// Returns a RouteReport for the provided TLSRoute, nil if there is not a report present.
+func (r *ReportMap) tlsRoute(route *gwv1alpha2.TLSRoute) *RouteReport {
+	key := client.ObjectKeyFromObject(route)
+	return r.tlsRoutes[key]
+}
+
+func (r *ReportMap) newTLSRouteReport(route *gwv1alpha2.TLSRoute) *RouteReport {
+	rr := &RouteReport{}
+	rr.observedGeneration = route.Generation
+	key := client.ObjectKeyFromObject(route)
+	r.tlsRoutes[key] = rr
+	return rr
+}
+
+// Returns a RouteReport for the provided TCPRoute, nil if there is not a report present.
+func (r *ReportMap) tcpRoute(route *gwv1alpha2.TCPRoute) *RouteReport {
+	key := client.ObjectKeyFromObject(route)
+	return r.tcpRoutes[key]
+}
+
+func (r *ReportMap) newTCPRouteReport(route *gwv1alpha2.TCPRoute) *RouteReport {
+	rr := &RouteReport{}
+	rr.observedGeneration = route.Generation
+	key := client.ObjectKeyFromObject(route)
+	r.tcpRoutes[key] = rr
+	return rr
+}
+
 func (g *GatewayReport) Listener(listener *gwv1.Listener) ListenerReporter {
 	return g.listener(listener)
 }
@@ -157,6 +192,22 @@ func (r *reporter) Route(route *gwv1.HTTPRoute) HTTPRouteReporter {
 	return rr
 }
 
+func (r *reporter) TLSRoute(route *gwv1alpha2.TLSRoute) HTTPRouteReporter {
+	rr := r.report.tlsRoute(route)
+	if rr == nil {
+		rr = r.report.newTLSRouteReport(route)
+	}
+	return rr
+}
+
+func (r *reporter) TCPRoute(route *gwv1alpha2.TCPRoute) HTTPRouteReporter {
+	rr := r.report.tcpRoute(route)
+	if rr == nil {
+		rr = r.report.newTCPRouteReport(route)
+	}
+	return rr
+}
+
 func getParentRefKey(parentRef *gwv1.ParentReference) ParentRefKey {
 	var kind string
 	if parentRef.Kind != nil {
@@ -212,6 +263,9 @@ type Reporter interface {
 	// returns the object reporter for the given type
 	Gateway(gateway *gwv1.Gateway) GatewayReporter
 	Route(route *gwv1.HTTPRoute) HTTPRouteReporter
+	// TLSRoutes and TCPRoutes report the same parentRef conditions as HTTPRoutes
+	TLSRoute(route *gwv1alpha2.TLSRoute) HTTPRouteReporter
+	TCPRoute(route *gwv1alpha2.TCPRoute) HTTPRouteReporter
 }
 
 type GatewayReporter interface {
@@ -9,11 +9,14 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
 
 var (
-	missingGatewayReportErr = "building status for Gateway '%s' (namespace: '%s') but no GatewayReport was present"
-	missingRouteReportErr   = "building status for HTTPRoute '%s' (namespace: '%s') but no RouteReport was present"
+	missingGatewayReportErr  = "building status for Gateway '%s' (namespace: '%s') but no GatewayReport was present"
+	missingRouteReportErr    = "building status for HTTPRoute '%s' (namespace: '%s') but no RouteReport was present"
+	missingTLSRouteReportErr = "building status for TLSRoute '%s' (namespace: '%s') but no RouteReport was present"
+	missingTCPRouteReportErr = "building status for TCPRoute '%s' (namespace: '%s') but no RouteReport was present"
 )
 
 func (r *ReportMap) BuildGWStatus(ctx context.Context, gw gwv1.Gateway) *gwv1.GatewayStatus {
@@ -82,18 +85,51 @@ func (r *ReportMap) BuildRouteStatus(ctx context.Context, route gwv1.HTTPRoute,
 		return nil
 	}
 
+	return &gwv1.HTTPRouteStatus{
+		RouteStatus: buildRouteStatus(routeReport, route.Spec.ParentRefs, route.Status.RouteStatus, cName),
+	}
+}
+
+func (r *ReportMap) BuildTLSRouteStatus(ctx context.Context, route gwv1alpha2.TLSRoute, cName string) *gwv1alpha2.TLSRouteStatus {
+	routeReport := r.tlsRoute(&route)
+	if routeReport == nil {
+		// see BuildRouteStatus, a missing report is expected when routes changed during translation
+		contextutils.LoggerFrom(ctx).Infof(missingTLSRouteReportErr, route.Name, route.Namespace)
+		return nil
+	}
+
+	return &gwv1alpha2.TLSRouteStatus{
+		RouteStatus: buildRouteStatus(routeReport, route.Spec.ParentRefs, route.Status.RouteStatus, cName),
+	}
+}
+
+func (r *ReportMap) BuildTCPRouteStatus(ctx context.Context, route gwv1alpha2.TCPRoute, cName string) *gwv1alpha2.TCPRouteStatus {
+	routeReport := r.tcpRoute(&route)
+	if routeReport == nil {
+		// see BuildRouteStatus, a missing report is expected when routes changed during translation
+		contextutils.LoggerFrom(ctx).Infof(missingTCPRouteReportErr, route.Name, route.Namespace)
+		return nil
+	}
+
+	return &gwv1alpha2.TCPRouteStatus{
+		RouteStatus: buildRouteStatus(routeReport, route.Spec.ParentRefs, route.Status.RouteStatus, cName),
+	}
+}
+
+// buildRouteStatus computes the parent statuses, which are common to all route kinds, from a route report
+func buildRouteStatus(routeReport *RouteReport, parentRefs []gwv1.ParentReference, currentStatus gwv1.RouteStatus, cName string) gwv1.RouteStatus {
 	routeStatus := gwv1.RouteStatus{}
-	for _, parentRef := range route.Spec.ParentRefs {
+	for _, parentRef := range parentRefs {
 		parentStatusReport := routeReport.parentRef(&parentRef)
 		addMissingParentRefConditions(parentStatusReport)
 
 		// get status of current parentRef status if it exists
 		var currentParentRefConditions []metav1.Condition
-		currentParentRefIdx := slices.IndexFunc(route.Status.Parents, func(s gwv1.RouteParentStatus) bool {
+		currentParentRefIdx := slices.IndexFunc(currentStatus.Parents, func(s gwv1.RouteParentStatus) bool {
 			return reflect.DeepEqual(s.ParentRef, parentRef)
 		})
 		if currentParentRefIdx != -1 {
-			currentParentRefConditions = route.Status.Parents[currentParentRefIdx].Conditions
+			currentParentRefConditions = currentStatus.Parents[currentParentRefIdx].Conditions
 		}
 
 		finalConditions := make([]metav1.Condition, 0, len(parentStatusReport.Conditions))
@@ -114,9 +150,7 @@ func (r *ReportMap) BuildRouteStatus(ctx context.Context, route gwv1.HTTPRoute,
 		}
 		routeStatus.Parents = append(routeStatus.Parents, routeParentStatus)
 	}
-	return &gwv1.HTTPRouteStatus{
-		RouteStatus: routeStatus,
-	}
+	return routeStatus
 }
 
 // Reports will initially only contain negative conditions found during translation,
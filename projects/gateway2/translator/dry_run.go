@@ -0,0 +1,57 @@
+package translator
+
+import (
+	"context"
+	"errors"
+
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/reports"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/registry"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	extauthv1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/extauth/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// DryRunResult holds the resources the translation of a Gateway produces
+type DryRunResult struct {
+	// Proxy translated from the Gateway, nil if the Gateway failed to translate
+	Proxy *v1.Proxy
+	// AuthConfigs generated by the post-translation plugins for the Proxy
+	AuthConfigs extauthv1.AuthConfigList
+	// Reports of the Gateway and its routes, which the controller writes to their statuses
+	Reports reports.ReportMap
+}
+
+// DryRun translates the Gateway with the plugins of the registry as the controller does, without syncing the result
+// to envoy nor writing statuses. The queries are only read from, so they can be backed by a cluster to preview the
+// Proxy of a deployed Gateway, or by objects loaded from files.
+// The errors of the post-translation plugins, which the controller only logs, are returned alongside the result.
+func DryRun(
+	ctx context.Context,
+	queries query.GatewayQueries,
+	pluginRegistry registry.PluginRegistry,
+	gateway *gwv1.Gateway,
+) (*DryRunResult, error) {
+	result := &DryRunResult{
+		Reports: reports.NewReportMap(),
+	}
+	result.Proxy = NewTranslator(queries, pluginRegistry).TranslateProxy(ctx, gateway, reports.NewReporter(&result.Reports))
+	if result.Proxy == nil {
+		return result, nil
+	}
+
+	postTranslationContext := &plugins.PostTranslationContext{
+		TranslatedGateways: []plugins.TranslatedGateway{{
+			Gateway: *gateway,
+		}},
+	}
+	var errs []error
+	for _, postTranslationPlugin := range pluginRegistry.GetPostTranslationPlugins() {
+		if err := postTranslationPlugin.ApplyPostTranslationPlugin(ctx, postTranslationContext); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	result.AuthConfigs = postTranslationContext.AuthConfigs
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,133 @@
+package translator_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	. "github.com/solo-io/gloo/projects/gateway2/translator"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/registry"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/core/matchers"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("DryRun", func() {
+	var gateway *gwv1.Gateway
+
+	BeforeEach(func() {
+		gateway = &gwv1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-gateway",
+				Namespace: "default",
+			},
+			Spec: gwv1.GatewaySpec{
+				GatewayClassName: "example-gateway-class",
+				Listeners: []gwv1.Listener{{
+					Name:     "http",
+					Protocol: gwv1.HTTPProtocolType,
+					Port:     80,
+				}},
+			},
+		}
+	})
+
+	dryRun := func(objs ...client.Object) (*DryRunResult, error) {
+		queries := testutils.BuildGatewayQueries(append(objs, gateway))
+		pluginRegistry, err := registry.NewPluginRegistry(registry.BuildPlugins(queries))
+		Expect(err).NotTo(HaveOccurred())
+		return DryRun(context.Background(), queries, pluginRegistry, gateway)
+	}
+
+	It("translates the filters of the routes into the Proxy", func() {
+		prefix := "/v2"
+		port := gwv1.PortNumber(8080)
+		result, err := dryRun(
+			&gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example-route",
+					Namespace: "default",
+				},
+				Spec: gwv1.HTTPRouteSpec{
+					CommonRouteSpec: gwv1.CommonRouteSpec{
+						ParentRefs: []gwv1.ParentReference{{Name: "example-gateway"}},
+					},
+					Hostnames: []gwv1.Hostname{"example.com"},
+					Rules: []gwv1.HTTPRouteRule{{
+						Filters: []gwv1.HTTPRouteFilter{
+							{
+								Type: gwv1.HTTPRouteFilterRequestHeaderModifier,
+								RequestHeaderModifier: &gwv1.HTTPHeaderFilter{
+									Set: []gwv1.HTTPHeader{{Name: "x-env", Value: "preview"}},
+								},
+							},
+							{
+								Type: gwv1.HTTPRouteFilterURLRewrite,
+								URLRewrite: &gwv1.HTTPURLRewriteFilter{
+									Path: &gwv1.HTTPPathModifier{
+										Type:               gwv1.PrefixMatchHTTPPathModifier,
+										ReplacePrefixMatch: &prefix,
+									},
+								},
+							},
+						},
+						BackendRefs: []gwv1.HTTPBackendRef{{
+							BackendRef: gwv1.BackendRef{
+								BackendObjectReference: gwv1.BackendObjectReference{
+									Name: "example-svc",
+									Port: &port,
+								},
+							},
+						}},
+					}},
+				},
+			},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example-svc",
+					Namespace: "default",
+				},
+				Spec: corev1.ServiceSpec{
+					Ports: []corev1.ServicePort{{Port: 8080}},
+				},
+			},
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Proxy).NotTo(BeNil())
+		Expect(result.Proxy.GetMetadata().GetName()).To(Equal("example-gateway"))
+		Expect(result.AuthConfigs).To(BeEmpty())
+
+		Expect(result.Proxy.GetListeners()).To(HaveLen(1))
+		virtualHost := result.Proxy.GetListeners()[0].GetAggregateListener().GetHttpResources().GetVirtualHosts()["http~example.com"]
+		Expect(virtualHost.GetRoutes()).To(HaveLen(1))
+		route := virtualHost.GetRoutes()[0]
+		Expect(proto.Equal(route.GetMatchers()[0], &matchers.Matcher{
+			PathSpecifier: &matchers.Matcher_Prefix{Prefix: "/"},
+		})).To(BeTrue())
+		headersToAdd := route.GetOptions().GetHeaderManipulation().GetRequestHeadersToAdd()
+		Expect(headersToAdd).To(HaveLen(1))
+		Expect(headersToAdd[0].GetHeader().GetKey()).To(Equal("x-env"))
+		Expect(headersToAdd[0].GetHeader().GetValue()).To(Equal("preview"))
+		Expect(headersToAdd[0].GetAppend().GetValue()).To(BeFalse())
+		Expect(route.GetOptions().GetPrefixRewrite().GetValue()).To(Equal("/v2"))
+		Expect(proto.Equal(route.GetRouteAction().GetSingle().GetUpstream(), &core.ResourceRef{
+			Name:      "default-example-svc-8080",
+			Namespace: "default",
+		})).To(BeTrue())
+	})
+
+	It("translates a Gateway without routes", func() {
+		result, err := dryRun()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Proxy).NotTo(BeNil())
+		for _, listener := range result.Proxy.GetListeners() {
+			Expect(listener.GetAggregateListener().GetHttpResources().GetVirtualHosts()).To(BeEmpty())
+		}
+	})
+})
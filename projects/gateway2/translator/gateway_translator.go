@@ -59,11 +59,25 @@ func (t *translator) TranslateProxy(
 			// TODO message
 		})
 	}
+	for _, rErr := range routesForGw.TLSRouteErrors {
+		reporter.TLSRoute(&rErr.Route).ParentRef(&rErr.ParentRef).SetCondition(reports.HTTPRouteCondition{
+			Type:   gwv1.RouteConditionAccepted,
+			Status: metav1.ConditionFalse,
+			Reason: rErr.Error.Reason,
+		})
+	}
+	for _, rErr := range routesForGw.TCPRouteErrors {
+		reporter.TCPRoute(&rErr.Route).ParentRef(&rErr.ParentRef).SetCondition(reports.HTTPRouteCondition{
+			Type:   gwv1.RouteConditionAccepted,
+			Status: metav1.ConditionFalse,
+			Reason: rErr.Error.Reason,
+		})
+	}
 
 	for _, listener := range gateway.Spec.Listeners {
 		availRoutes := 0
 		if res, ok := routesForGw.ListenerResults[string(listener.Name)]; ok {
-			availRoutes = len(res.Routes)
+			availRoutes = len(res.Routes) + len(res.TLSRoutes) + len(res.TCPRoutes)
 		}
 		reporter.Gateway(gateway).Listener(&listener).SetAttachedRoutes(uint(availRoutes))
 	}
@@ -66,4 +66,113 @@ var _ = Describe("GatewayTranslator", func() {
 			Name:      "example-gateway",
 		}]).To(BeTrue())
 	})
+
+	It("should translate a gateway with an https listener serving multiple certificates", func() {
+		results, err := TestCase{
+			Name:       "https-multi-cert",
+			InputFiles: []string{dir + "/testutils/inputs/https-multi-cert"},
+			ResultsByGateway: map[types.NamespacedName]ExpectedTestResult{
+				{
+					Namespace: "default",
+					Name:      "example-gateway",
+				}: {
+					Proxy: dir + "/testutils/outputs/https-multi-cert-proxy.yaml",
+				},
+			},
+		}.Run(ctx)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(1))
+		Expect(results[types.NamespacedName{
+			Namespace: "default",
+			Name:      "example-gateway",
+		}]).To(BeTrue())
+	})
+
+	It("should translate a gateway with https listeners sharing a port routing on sni", func() {
+		results, err := TestCase{
+			Name:       "https-sni-routing",
+			InputFiles: []string{dir + "/testutils/inputs/https-sni-routing"},
+			ResultsByGateway: map[types.NamespacedName]ExpectedTestResult{
+				{
+					Namespace: "default",
+					Name:      "example-gateway",
+				}: {
+					Proxy: dir + "/testutils/outputs/https-sni-routing-proxy.yaml",
+				},
+			},
+		}.Run(ctx)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(1))
+		Expect(results[types.NamespacedName{
+			Namespace: "default",
+			Name:      "example-gateway",
+		}]).To(BeTrue())
+	})
+
+	It("should translate a gateway with a tls passthrough listener routing on sni", func() {
+		results, err := TestCase{
+			Name:       "tls-passthrough",
+			InputFiles: []string{dir + "/testutils/inputs/tls-passthrough"},
+			ResultsByGateway: map[types.NamespacedName]ExpectedTestResult{
+				{
+					Namespace: "default",
+					Name:      "example-gateway",
+				}: {
+					Proxy: dir + "/testutils/outputs/tls-passthrough-proxy.yaml",
+				},
+			},
+		}.Run(ctx)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(1))
+		Expect(results[types.NamespacedName{
+			Namespace: "default",
+			Name:      "example-gateway",
+		}]).To(BeTrue())
+	})
+
+	It("should translate a gateway with tcp listeners forwarding to weighted backends", func() {
+		results, err := TestCase{
+			Name:       "tcp-routing",
+			InputFiles: []string{dir + "/testutils/inputs/tcp-routing"},
+			ResultsByGateway: map[types.NamespacedName]ExpectedTestResult{
+				{
+					Namespace: "default",
+					Name:      "example-gateway",
+				}: {
+					Proxy: dir + "/testutils/outputs/tcp-routing-proxy.yaml",
+				},
+			},
+		}.Run(ctx)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(1))
+		Expect(results[types.NamespacedName{
+			Namespace: "default",
+			Name:      "example-gateway",
+		}]).To(BeTrue())
+	})
+	It("should translate a gateway with a canary listener taking a share of another listener's traffic", func() {
+		results, err := TestCase{
+			Name:       "canary-listener",
+			InputFiles: []string{dir + "/testutils/inputs/canary-listener"},
+			ResultsByGateway: map[types.NamespacedName]ExpectedTestResult{
+				{
+					Namespace: "default",
+					Name:      "example-gateway",
+				}: {
+					Proxy: dir + "/testutils/outputs/canary-listener-proxy.yaml",
+				},
+			},
+		}.Run(ctx)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(1))
+		Expect(results[types.NamespacedName{
+			Namespace: "default",
+			Name:      "example-gateway",
+		}]).To(BeTrue())
+	})
 })
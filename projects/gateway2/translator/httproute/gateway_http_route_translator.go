@@ -3,8 +3,10 @@ package httproute
 import (
 	"context"
 	"net/http"
+	"regexp"
 
 	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
 	"github.com/solo-io/gloo/projects/gateway2/query"
 	"github.com/solo-io/gloo/projects/gateway2/reports"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
@@ -12,10 +14,21 @@ import (
 	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
 
 	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/core/matchers"
+	"github.com/solo-io/go-utils/contextutils"
 	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
+var (
+	InvalidPathRegexErr = func(regex string, err error) error {
+		return errors.Wrapf(err, "invalid path regex '%s'", regex)
+	}
+	InvalidQueryParamRegexErr = func(name, regex string, err error) error {
+		return errors.Wrapf(err, "invalid regex '%s' for query parameter %s", regex, name)
+	}
+)
+
 func TranslateGatewayHTTPRouteRules(
 	ctx context.Context,
 	pluginRegistry registry.PluginRegistry,
@@ -66,6 +79,15 @@ func translateGatewayHTTPRouteRule(
 ) []*v1.Route {
 	routes := make([]*v1.Route, len(rule.Matches))
 	for idx, match := range rule.Matches {
+		if err := validateMatch(match); err != nil {
+			reporter.SetCondition(reports.HTTPRouteCondition{
+				Type:    gwv1.RouteConditionAccepted,
+				Status:  metav1.ConditionFalse,
+				Reason:  gwv1.RouteReasonUnsupportedValue,
+				Message: err.Error(),
+			})
+			continue
+		}
 		outputRoute := &v1.Route{
 			Matchers: []*matchers.Matcher{translateGlooMatcher(match)},
 			Action:   nil,
@@ -89,12 +111,7 @@ func translateGatewayHTTPRouteRule(
 			Match:    &match,
 			Reporter: reporter,
 		}
-		for _, plugin := range pluginRegistry.GetRoutePlugins() {
-			err := plugin.ApplyRoutePlugin(ctx, rtCtx, outputRoute)
-			if err != nil {
-				// TODO Log
-			}
-		}
+		applyRoutePlugins(ctx, pluginRegistry, rtCtx, outputRoute)
 
 		if outputRoute.GetAction() == nil {
 			outputRoute.Action = &v1.Route_DirectResponseAction{
@@ -103,12 +120,57 @@ func translateGatewayHTTPRouteRule(
 				},
 			}
 		}
-		outputRoute.Matchers = []*matchers.Matcher{translateGlooMatcher(match)}
 		routes[idx] = outputRoute
 	}
 	return routes
 }
 
+// applyRoutePlugins applies the route plugins to the route, reporting the errors of the plugins that fail.
+// The route is kept, the plugins that must not serve a route they fail to apply to (e.g. authentication)
+// replace its action with a direct response themselves.
+func applyRoutePlugins(
+	ctx context.Context,
+	pluginRegistry registry.PluginRegistry,
+	rtCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) {
+	for _, plugin := range pluginRegistry.GetRoutePlugins() {
+		if err := plugin.ApplyRoutePlugin(ctx, rtCtx, outputRoute); err != nil {
+			contextutils.LoggerFrom(ctx).Errorf("error applying route plugin to route %s.%s: %v", rtCtx.Route.Namespace, rtCtx.Route.Name, err)
+			rtCtx.Reporter.SetCondition(reports.HTTPRouteCondition{
+				Type:    gwv1.RouteConditionAccepted,
+				Status:  metav1.ConditionFalse,
+				Reason:  gwv1.RouteReasonUnsupportedValue,
+				Message: err.Error(),
+			})
+		}
+	}
+}
+
+// validateMatch returns an error if envoy would reject the match
+func validateMatch(match gwv1.HTTPRouteMatch) error {
+	pathType, pathValue := parsePath(match.Path)
+	if pathType == gwv1.PathMatchRegularExpression {
+		// envoy uses RE2, like the regexp package
+		if _, err := regexp.Compile(pathValue); err != nil {
+			return InvalidPathRegexErr(pathValue, err)
+		}
+	}
+	for _, param := range match.QueryParams {
+		if !isRegexQueryParam(param) {
+			continue
+		}
+		if _, err := regexp.Compile(param.Value); err != nil {
+			return InvalidQueryParamRegexErr(string(param.Name), param.Value, err)
+		}
+	}
+	return nil
+}
+
+func isRegexQueryParam(param gwv1.HTTPQueryParamMatch) bool {
+	return param.Type != nil && *param.Type == gwv1.QueryParamMatchRegularExpression
+}
+
 func translateGlooMatcher(match gwv1.HTTPRouteMatch) *matchers.Matcher {
 	// headers
 	headers := make([]*matchers.HeaderMatcher, 0, len(match.Headers))
@@ -125,7 +187,7 @@ func translateGlooMatcher(match gwv1.HTTPRouteMatch) *matchers.Matcher {
 		queryParamMatchers = append(queryParamMatchers, &matchers.QueryParameterMatcher{
 			Name:  string(param.Name),
 			Value: param.Value,
-			Regex: false,
+			Regex: isRegexQueryParam(param),
 		})
 	}
 
@@ -197,8 +259,8 @@ func setRouteAction(
 	var weightedDestinations []*v1.WeightedDestination
 
 	for _, backendRef := range backendRefs {
-		clusterName := "blackhole_cluster"
-		ns := "blackhole_ns"
+		clusterName := query.BlackholeUpstreamName
+		ns := query.BlackholeUpstreamNamespace
 		obj, err := queries.GetBackendForRef(context.TODO(), queries.ObjToFrom(gwroute), &backendRef.BackendObjectReference)
 		ptrClusterName := query.ProcessBackendRef(obj, err, reporter, backendRef.BackendObjectReference)
 		if ptrClusterName != nil {
@@ -1,8 +1,241 @@
 package httproute_test
 
 import (
+	"context"
+
 	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/reports"
+	. "github.com/solo-io/gloo/projects/gateway2/translator/httproute"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/registry"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/core/matchers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
+type fakeReporter struct {
+	conditions []reports.HTTPRouteCondition
+}
+
+func (r *fakeReporter) SetCondition(condition reports.HTTPRouteCondition) {
+	r.conditions = append(r.conditions, condition)
+}
+
+// failingPlugin fails to apply to the routes matching the prefix
+type failingPlugin struct {
+	prefix string
+}
+
+func (p *failingPlugin) ApplyRoutePlugin(ctx context.Context, routeCtx *plugins.RouteContext, outputRoute *v1.Route) error {
+	if outputRoute.GetMatchers()[0].GetPrefix() == p.prefix {
+		return errors.Errorf("unsupported option on %s", p.prefix)
+	}
+	return nil
+}
+
+// recordingPlugin records the prefixes of the routes it is applied to
+type recordingPlugin struct {
+	prefixes []string
+}
+
+func (p *recordingPlugin) ApplyRoutePlugin(ctx context.Context, routeCtx *plugins.RouteContext, outputRoute *v1.Route) error {
+	p.prefixes = append(p.prefixes, outputRoute.GetMatchers()[0].GetPrefix())
+	return nil
+}
+
 var _ = Describe("GatewayHttpRouteTranslator", func() {
+	var (
+		reporter     *fakeReporter
+		routePlugins []plugins.Plugin
+	)
+
+	BeforeEach(func() {
+		reporter = &fakeReporter{}
+		routePlugins = nil
+	})
+
+	translate := func(matches ...gwv1.HTTPRouteMatch) []*v1.Route {
+		route := gwv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "route",
+				Namespace: "default",
+			},
+			Spec: gwv1.HTTPRouteSpec{
+				Rules: []gwv1.HTTPRouteRule{{
+					Matches: matches,
+				}},
+			},
+		}
+		pluginRegistry, err := registry.NewPluginRegistry(routePlugins)
+		Expect(err).NotTo(HaveOccurred())
+		return TranslateGatewayHTTPRouteRules(
+			context.Background(),
+			pluginRegistry,
+			testutils.BuildGatewayQueries(nil),
+			gwv1.Listener{},
+			route,
+			reporter,
+		)
+	}
+
+	pathMatch := func(pathType gwv1.PathMatchType, value string) gwv1.HTTPRouteMatch {
+		return gwv1.HTTPRouteMatch{
+			Path: &gwv1.HTTPPathMatch{
+				Type:  &pathType,
+				Value: &value,
+			},
+		}
+	}
+
+	It("translates regex path matches", func() {
+		routes := translate(pathMatch(gwv1.PathMatchRegularExpression, "/users/[0-9]+"))
+		Expect(routes).To(HaveLen(1))
+		Expect(proto.Equal(routes[0].GetMatchers()[0], &matchers.Matcher{
+			PathSpecifier: &matchers.Matcher_Regex{Regex: "/users/[0-9]+"},
+		})).To(BeTrue())
+		Expect(reporter.conditions).To(BeEmpty())
+	})
+
+	It("drops matches with an invalid path regex", func() {
+		routes := translate(
+			pathMatch(gwv1.PathMatchRegularExpression, "/users/[0-9+"),
+			pathMatch(gwv1.PathMatchPathPrefix, "/users"),
+		)
+		Expect(routes).To(HaveLen(1))
+		Expect(routes[0].GetMatchers()[0].GetPrefix()).To(Equal("/users"))
+		Expect(reporter.conditions).To(HaveLen(1))
+		Expect(reporter.conditions[0].Type).To(Equal(gwv1.RouteConditionAccepted))
+		Expect(reporter.conditions[0].Status).To(Equal(metav1.ConditionFalse))
+		Expect(reporter.conditions[0].Reason).To(Equal(gwv1.RouteReasonUnsupportedValue))
+		Expect(reporter.conditions[0].Message).To(ContainSubstring("invalid path regex '/users/[0-9+'"))
+	})
+
+	It("keeps the matches the route plugins fail to apply to, reporting the error", func() {
+		applied := &recordingPlugin{}
+		routePlugins = []plugins.Plugin{&failingPlugin{prefix: "/broken"}, applied}
+		routes := translate(
+			pathMatch(gwv1.PathMatchPathPrefix, "/broken"),
+			pathMatch(gwv1.PathMatchPathPrefix, "/users"),
+		)
+		Expect(routes).To(HaveLen(2))
+		Expect(routes[0].GetMatchers()[0].GetPrefix()).To(Equal("/broken"))
+		Expect(routes[1].GetMatchers()[0].GetPrefix()).To(Equal("/users"))
+		// the plugins following the failing plugin are still applied
+		Expect(applied.prefixes).To(Equal([]string{"/broken", "/users"}))
+		Expect(reporter.conditions).To(HaveLen(1))
+		Expect(reporter.conditions[0].Type).To(Equal(gwv1.RouteConditionAccepted))
+		Expect(reporter.conditions[0].Status).To(Equal(metav1.ConditionFalse))
+		Expect(reporter.conditions[0].Reason).To(Equal(gwv1.RouteReasonUnsupportedValue))
+		Expect(reporter.conditions[0].Message).To(Equal("unsupported option on /broken"))
+	})
+
+	Context("query parameters", func() {
+		exact := gwv1.QueryParamMatchExact
+		regex := gwv1.QueryParamMatchRegularExpression
+		headerExact := gwv1.HeaderMatchExact
+
+		It("translates a single exact query parameter match", func() {
+			routes := translate(gwv1.HTTPRouteMatch{
+				QueryParams: []gwv1.HTTPQueryParamMatch{
+					{Name: "version", Value: "v2"},
+				},
+			})
+			Expect(routes).To(HaveLen(1))
+			Expect(proto.Equal(routes[0].GetMatchers()[0], &matchers.Matcher{
+				PathSpecifier: &matchers.Matcher_Prefix{Prefix: "/"},
+				Headers:       []*matchers.HeaderMatcher{},
+				QueryParameters: []*matchers.QueryParameterMatcher{
+					{Name: "version", Value: "v2"},
+				},
+			})).To(BeTrue())
+		})
+
+		It("requires all query parameter and header matches", func() {
+			routes := translate(gwv1.HTTPRouteMatch{
+				QueryParams: []gwv1.HTTPQueryParamMatch{
+					{Type: &exact, Name: "version", Value: "v2"},
+					{Type: &regex, Name: "id", Value: "[0-9]+"},
+				},
+				Headers: []gwv1.HTTPHeaderMatch{
+					{Type: &headerExact, Name: "x-tenant", Value: "acme"},
+				},
+			})
+			Expect(routes).To(HaveLen(1))
+			// all matchers of a gloo Matcher must match, like all matches of an HTTPRouteMatch
+			Expect(routes[0].GetMatchers()).To(HaveLen(1))
+			Expect(proto.Equal(routes[0].GetMatchers()[0], &matchers.Matcher{
+				PathSpecifier: &matchers.Matcher_Prefix{Prefix: "/"},
+				Headers: []*matchers.HeaderMatcher{
+					{Name: "x-tenant", Value: "acme"},
+				},
+				QueryParameters: []*matchers.QueryParameterMatcher{
+					{Name: "version", Value: "v2"},
+					{Name: "id", Value: "[0-9]+", Regex: true},
+				},
+			})).To(BeTrue())
+		})
+
+		It("drops matches with an invalid query parameter regex", func() {
+			routes := translate(gwv1.HTTPRouteMatch{
+				QueryParams: []gwv1.HTTPQueryParamMatch{
+					{Type: &regex, Name: "id", Value: "[0-9+"},
+				},
+			})
+			Expect(routes).To(BeEmpty())
+			Expect(reporter.conditions).To(HaveLen(1))
+			Expect(reporter.conditions[0].Reason).To(Equal(gwv1.RouteReasonUnsupportedValue))
+			Expect(reporter.conditions[0].Message).To(ContainSubstring("invalid regex '[0-9+' for query parameter id"))
+		})
+	})
+
+	Context("methods", func() {
+		methodMatch := func(method gwv1.HTTPMethod) gwv1.HTTPRouteMatch {
+			return gwv1.HTTPRouteMatch{Method: &method}
+		}
+
+		DescribeTable("matches only the method",
+			func(method gwv1.HTTPMethod) {
+				routes := translate(methodMatch(method))
+				Expect(routes).To(HaveLen(1))
+				Expect(proto.Equal(routes[0].GetMatchers()[0], &matchers.Matcher{
+					PathSpecifier: &matchers.Matcher_Prefix{Prefix: "/"},
+					Headers:       []*matchers.HeaderMatcher{},
+					Methods:       []string{string(method)},
+				})).To(BeTrue())
+			},
+			Entry("GET", gwv1.HTTPMethodGet),
+			Entry("POST", gwv1.HTTPMethodPost),
+		)
+
+		It("matches all methods without a method", func() {
+			routes := translate(gwv1.HTTPRouteMatch{})
+			Expect(routes).To(HaveLen(1))
+			Expect(routes[0].GetMatchers()[0].GetMethods()).To(BeEmpty())
+		})
+
+		It("requires the method, path and header matches", func() {
+			match := pathMatch(gwv1.PathMatchExact, "/orders")
+			method := gwv1.HTTPMethodPost
+			match.Method = &method
+			match.Headers = []gwv1.HTTPHeaderMatch{
+				{Name: "content-type", Value: "application/json"},
+			}
+			routes := translate(match)
+			Expect(routes).To(HaveLen(1))
+			Expect(routes[0].GetMatchers()).To(HaveLen(1))
+			Expect(proto.Equal(routes[0].GetMatchers()[0], &matchers.Matcher{
+				PathSpecifier: &matchers.Matcher_Exact{Exact: "/orders"},
+				Headers: []*matchers.HeaderMatcher{
+					{Name: "content-type", Value: "application/json"},
+				},
+				Methods: []string{"POST"},
+			})).To(BeTrue())
+		})
+	})
 })
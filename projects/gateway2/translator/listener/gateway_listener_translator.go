@@ -4,21 +4,28 @@ import (
 	"context"
 	"errors"
 	"sort"
+	"strings"
 
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/registry"
 	"github.com/solo-io/gloo/projects/gateway2/translator/sslutils"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/rotisserie/eris"
 	"github.com/solo-io/gloo/projects/gateway2/ports"
 	"github.com/solo-io/gloo/projects/gateway2/query"
 	"github.com/solo-io/gloo/projects/gateway2/reports"
 	"github.com/solo-io/gloo/projects/gateway2/translator/httproute"
 	"github.com/solo-io/gloo/projects/gateway2/translator/routeutils"
+	buffer "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/extensions/filters/http/buffer/v3"
 	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
 	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/ssl"
+	"github.com/solo-io/go-utils/contextutils"
 	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
@@ -35,6 +42,7 @@ func TranslateListeners(
 
 	mergedListeners := mergeGWListeners(queries, gateway.Namespace, validatedListeners, routesForGw, reporter.Gateway(gateway))
 	translatedListeners := mergedListeners.translateListeners(ctx, pluginRegistry, queries, reporter)
+	mergedListeners.applyListenerPlugins(ctx, pluginRegistry, gateway, translatedListeners, reporter.Gateway(gateway))
 	return translatedListeners
 }
 
@@ -57,11 +65,17 @@ func mergeGWListeners(
 			// continue
 		}
 		listenerReporter := reporter.Listener(&listener)
-		var routes []*query.ListenerRouteResult
+		var (
+			routes    []*query.ListenerRouteResult
+			tlsRoutes []*query.ListenerTLSRouteResult
+			tcpRoutes []*query.ListenerTCPRouteResult
+		)
 		if result != nil {
 			routes = result.Routes
+			tlsRoutes = result.TLSRoutes
+			tcpRoutes = result.TCPRoutes
 		}
-		ml.appendListener(listener, routes, listenerReporter)
+		ml.appendListener(listener, routes, tlsRoutes, tcpRoutes, listenerReporter)
 	}
 	return ml
 }
@@ -75,6 +89,8 @@ type mergedListeners struct {
 func (ml *mergedListeners) appendListener(
 	listener gwv1.Listener,
 	routes []*query.ListenerRouteResult,
+	tlsRoutes []*query.ListenerTLSRouteResult,
+	tcpRoutes []*query.ListenerTCPRouteResult,
 	reporter reports.ListenerReporter,
 ) error {
 	switch listener.Protocol {
@@ -82,6 +98,10 @@ func (ml *mergedListeners) appendListener(
 		ml.appendHttpListener(listener, routes, reporter)
 	case gwv1.HTTPSProtocolType:
 		ml.appendHttpsListener(listener, routes, reporter)
+	case gwv1.TLSProtocolType:
+		ml.appendTlsPassthroughListener(listener, tlsRoutes, reporter)
+	case gwv1.TCPProtocolType:
+		ml.appendTcpListener(listener, tcpRoutes, reporter)
 	// TODO default handling
 	default:
 		return eris.Errorf("unsupported protocol: %v", listener.Protocol)
@@ -112,6 +132,7 @@ func (ml *mergedListeners) appendHttpListener(
 			// concatenate the names on the parent output listener/filterchain
 			// TODO is this valid listener name?
 			lis.name += "~" + listenerName
+			lis.gatewayListeners = append(lis.gatewayListeners, listener)
 			if lis.httpFilterChain != nil {
 				lis.httpFilterChain.parents = append(lis.httpFilterChain.parents, parent)
 			} else {
@@ -129,6 +150,7 @@ func (ml *mergedListeners) appendHttpListener(
 		httpFilterChain:  fc,
 		listenerReporter: reporter,
 		listener:         listener,
+		gatewayListeners: []gwv1.Listener{listener},
 	})
 
 }
@@ -150,11 +172,13 @@ func (ml *mergedListeners) appendHttpsListener(
 	}
 
 	listenerName := string(listener.Name)
+	finalPort := gwv1.PortNumber(ports.TranslatePort(uint16(listener.Port)))
 	for _, lis := range ml.listeners {
-		if lis.port == listener.Port {
+		if lis.port == finalPort {
 			// concatenate the names on the parent output listener
 			// TODO is this valid listener name?
 			lis.name += "~" + listenerName
+			lis.gatewayListeners = append(lis.gatewayListeners, listener)
 			lis.httpsFilterChains = append(lis.httpsFilterChains, mfc)
 			return
 		}
@@ -162,9 +186,64 @@ func (ml *mergedListeners) appendHttpsListener(
 	ml.listeners = append(ml.listeners, &mergedListener{
 		name:              listenerName,
 		gatewayNamespace:  ml.gatewayNamespace,
-		port:              gwv1.PortNumber(ports.TranslatePort(uint16(listener.Port))),
+		port:              finalPort,
 		httpsFilterChains: []httpsFilterChain{mfc},
 		listenerReporter:  reporter,
+		gatewayListeners:  []gwv1.Listener{listener},
+	})
+}
+
+func (ml *mergedListeners) appendTlsPassthroughListener(
+	listener gwv1.Listener,
+	routesWithHosts []*query.ListenerTLSRouteResult,
+	reporter reports.ListenerReporter,
+) {
+	fc := tlsPassthroughFilterChain{
+		gatewayListenerName: string(listener.Name),
+		routesWithHosts:     routesWithHosts,
+		queries:             ml.queries,
+	}
+
+	// passthrough filter chains are selected by SNI, so they can share a port with https filter chains
+	listenerName := string(listener.Name)
+	finalPort := gwv1.PortNumber(ports.TranslatePort(uint16(listener.Port)))
+	for _, lis := range ml.listeners {
+		if lis.port == finalPort {
+			lis.name += "~" + listenerName
+			lis.gatewayListeners = append(lis.gatewayListeners, listener)
+			lis.tlsPassthroughFilterChains = append(lis.tlsPassthroughFilterChains, fc)
+			return
+		}
+	}
+	ml.listeners = append(ml.listeners, &mergedListener{
+		name:                       listenerName,
+		gatewayNamespace:           ml.gatewayNamespace,
+		port:                       finalPort,
+		tlsPassthroughFilterChains: []tlsPassthroughFilterChain{fc},
+		listenerReporter:           reporter,
+		listener:                   listener,
+		gatewayListeners:           []gwv1.Listener{listener},
+	})
+}
+
+func (ml *mergedListeners) appendTcpListener(
+	listener gwv1.Listener,
+	routes []*query.ListenerTCPRouteResult,
+	reporter reports.ListenerReporter,
+) {
+	// tcp listeners can not share a port with other listeners, this is reported as a conflict during validation
+	ml.listeners = append(ml.listeners, &mergedListener{
+		name:             string(listener.Name),
+		gatewayNamespace: ml.gatewayNamespace,
+		port:             gwv1.PortNumber(ports.TranslatePort(uint16(listener.Port))),
+		tcpFilterChain: &tcpFilterChain{
+			gatewayListenerName: string(listener.Name),
+			routes:              routes,
+			queries:             ml.queries,
+		},
+		listenerReporter: reporter,
+		listener:         listener,
+		gatewayListeners: []gwv1.Listener{listener},
 	})
 }
 
@@ -181,14 +260,53 @@ func (ml *mergedListeners) translateListeners(
 	return listeners
 }
 
+// applyListenerPlugins runs the listener plugins on the translated listeners, which are in the same order as ml.listeners
+func (ml *mergedListeners) applyListenerPlugins(
+	ctx context.Context,
+	pluginRegistry registry.PluginRegistry,
+	gateway *gwv1.Gateway,
+	translatedListeners []*v1.Listener,
+	reporter reports.GatewayReporter,
+) {
+	listenersByGwListener := map[string]*v1.Listener{}
+	for i, mergedListener := range ml.listeners {
+		for _, gwListener := range mergedListener.gatewayListeners {
+			listenersByGwListener[string(gwListener.Name)] = translatedListeners[i]
+		}
+	}
+
+	for i, mergedListener := range ml.listeners {
+		listenerCtx := &plugins.ListenerContext{
+			Gateway:              gateway,
+			GatewayListeners:     mergedListener.gatewayListeners,
+			TranslatedListeners:  listenersByGwListener,
+			VirtualHostListeners: mergedListener.vhostListeners,
+			Reporter:             reporter,
+		}
+		for _, plugin := range pluginRegistry.GetListenerPlugins() {
+			if err := plugin.ApplyListenerPlugin(ctx, listenerCtx, translatedListeners[i]); err != nil {
+				contextutils.LoggerFrom(ctx).Errorf("error applying listener plugin to listener %s: %v", translatedListeners[i].GetName(), err)
+			}
+		}
+	}
+}
+
 type mergedListener struct {
 	name              string
 	gatewayNamespace  string
 	port              gwv1.PortNumber
 	httpFilterChain   *httpFilterChain
 	httpsFilterChains []httpsFilterChain
-	listenerReporter  reports.ListenerReporter
-	listener          gwv1.Listener
+	// tlsPassthroughFilterChains forward the encrypted traffic of TLS listeners in Passthrough mode
+	tlsPassthroughFilterChains []tlsPassthroughFilterChain
+	tcpFilterChain             *tcpFilterChain
+	listenerReporter           reports.ListenerReporter
+	listener                   gwv1.Listener
+	// gatewayListeners are all the Gateway Listeners merged into this listener
+	gatewayListeners []gwv1.Listener
+	// vhostListeners are the names of the Gateway Listeners whose routes are served by each virtual host,
+	// set once the listener is translated
+	vhostListeners map[string][]string
 
 	// TODO(policy via http listener options)
 }
@@ -201,11 +319,13 @@ func (ml *mergedListener) translateListener(
 ) *v1.Listener {
 	var (
 		httpFilterChains []*v1.AggregateListener_HttpFilterChain
+		tcpListeners     []*v1.MatchedTcpListener
 		mergedVhosts     = map[string]*v1.VirtualHost{}
 	)
+	ml.vhostListeners = map[string][]string{}
 
 	if ml.httpFilterChain != nil {
-		httpFilterChain, vhostsForFilterchain := ml.httpFilterChain.translateHttpFilterChain(
+		httpFilterChain, vhostsForFilterchain, vhostListeners := ml.httpFilterChain.translateHttpFilterChain(
 			ctx,
 			ml.name,
 			ml.gatewayNamespace,
@@ -220,12 +340,13 @@ func (ml *mergedListener) translateListener(
 
 			}
 			mergedVhosts[vhostRef] = vhost
+			ml.vhostListeners[vhostRef] = vhostListeners[vhostRef]
 		}
 	}
 	for _, mfc := range ml.httpsFilterChains {
 		// each virtual host name must be unique across all filter chains
 		// to prevent collisions because the vhosts have to be re-computed for each set
-		httpsFilterChain, vhostsForFilterchain := mfc.translateHttpsFilterChain(
+		httpsFilterChains, vhostsForFilterchain := mfc.translateHttpsFilterChains(
 			ctx,
 			pluginRegistry,
 			mfc.gatewayListenerName,
@@ -235,16 +356,36 @@ func (ml *mergedListener) translateListener(
 			reporter,
 			ml.listenerReporter,
 		)
-		if httpsFilterChain == nil {
+		if len(httpsFilterChains) == 0 {
 			// TODO report
 			continue
 		}
-		httpFilterChains = append(httpFilterChains, httpsFilterChain)
+		httpFilterChains = append(httpFilterChains, httpsFilterChains...)
 		for vhostRef, vhost := range vhostsForFilterchain {
 			if _, ok := mergedVhosts[vhostRef]; ok {
 				// TODO handle internal error
 			}
 			mergedVhosts[vhostRef] = vhost
+			ml.vhostListeners[vhostRef] = []string{mfc.gatewayListenerName}
+		}
+	}
+
+	for _, tfc := range ml.tlsPassthroughFilterChains {
+		tcpListeners = append(tcpListeners, tfc.translateTcpListeners(ctx, reporter)...)
+	}
+	if ml.tcpFilterChain != nil {
+		if tcpListener := ml.tcpFilterChain.translateTcpListener(ctx, reporter); tcpListener != nil {
+			tcpListeners = append(tcpListeners, tcpListener)
+		}
+	}
+
+	var httpOptions map[string]*v1.HttpListenerOptions
+	if options := httpOptionsForBufferLimits(mergedVhosts); options != nil {
+		httpOptions = map[string]*v1.HttpListenerOptions{
+			ml.name: options,
+		}
+		for _, fc := range httpFilterChains {
+			fc.HttpOptionsRef = ml.name
 		}
 	}
 
@@ -257,11 +398,10 @@ func (ml *mergedListener) translateListener(
 				HttpResources: &v1.AggregateListener_HttpResources{
 					VirtualHosts: mergedVhosts,
 					// TODO(ilackarms): mid term - add http listener options
-					HttpOptions: nil,
+					HttpOptions: httpOptions,
 				},
 				HttpFilterChains: httpFilterChains,
-				// TODO(ilackarms): mid term - add http listener options
-				TcpListeners: nil,
+				TcpListeners:     tcpListeners,
 			},
 		},
 		// TODO(ilackarms): mid term - add listener options
@@ -290,24 +430,34 @@ func (httpFilterChain *httpFilterChain) translateHttpFilterChain(
 	listener gwv1.Listener,
 	pluginRegistry registry.PluginRegistry,
 	reporter reports.Reporter,
-) (*v1.AggregateListener_HttpFilterChain, map[string]*v1.VirtualHost) {
+) (*v1.AggregateListener_HttpFilterChain, map[string]*v1.VirtualHost, map[string][]string) {
 
-	var routesByHost = map[string]routeutils.SortableRoutes{}
+	// the virtual hosts are shared by all the merged listeners, a host can serve the routes of several of them
+	var (
+		routesByHost    = map[string]routeutils.SortableRoutes{}
+		listenersByHost = map[string][]string{}
+	)
 	for _, parent := range httpFilterChain.parents {
+		parentRoutesByHost := map[string]routeutils.SortableRoutes{}
 		buildRoutesPerHost(
 			ctx,
-			routesByHost,
+			parentRoutesByHost,
 			parent.routesWithHosts,
 			listener,
 			pluginRegistry,
 			httpFilterChain.queries,
 			reporter,
 		)
+		for host, routes := range parentRoutesByHost {
+			routesByHost[host] = append(routesByHost[host], routes...)
+			listenersByHost[host] = append(listenersByHost[host], parent.gatewayListenerName)
+		}
 	}
 
 	var (
 		virtualHostRefs []string
 		virtualHosts    = map[string]*v1.VirtualHost{}
+		vhostListeners  = map[string][]string{}
 	)
 	for host, vhostRoutes := range routesByHost {
 		sort.Stable(vhostRoutes)
@@ -318,6 +468,7 @@ func (httpFilterChain *httpFilterChain) translateHttpFilterChain(
 			Routes:  vhostRoutes.ToRoutes(),
 			Options: nil,
 		}
+		vhostListeners[vhostName] = listenersByHost[host]
 
 		virtualHostRefs = append(virtualHostRefs, vhostName)
 	}
@@ -326,7 +477,7 @@ func (httpFilterChain *httpFilterChain) translateHttpFilterChain(
 	return &v1.AggregateListener_HttpFilterChain{
 		Matcher:         &v1.Matcher{}, // http filter chain matcher is not used
 		VirtualHostRefs: virtualHostRefs,
-	}, virtualHosts
+	}, virtualHosts, vhostListeners
 }
 
 type httpsFilterChain struct {
@@ -337,7 +488,7 @@ type httpsFilterChain struct {
 	queries             query.GatewayQueries
 }
 
-func (httpsFilterChain *httpsFilterChain) translateHttpsFilterChain(
+func (httpsFilterChain *httpsFilterChain) translateHttpsFilterChains(
 	ctx context.Context,
 	pluginRegistry registry.PluginRegistry,
 	parentName string,
@@ -346,7 +497,7 @@ func (httpsFilterChain *httpsFilterChain) translateHttpsFilterChain(
 	queries query.GatewayQueries,
 	reporter reports.Reporter,
 	listenerReporter reports.ListenerReporter,
-) (*v1.AggregateListener_HttpFilterChain, map[string]*v1.VirtualHost) {
+) ([]*v1.AggregateListener_HttpFilterChain, map[string]*v1.VirtualHost) {
 	// process routes first, so any route related errors are reported on the httproute.
 	var routesByHost = map[string]routeutils.SortableRoutes{}
 	buildRoutesPerHost(
@@ -377,7 +528,7 @@ func (httpsFilterChain *httpsFilterChain) translateHttpsFilterChain(
 	}
 	sort.Strings(virtualHostRefs)
 
-	sslConfig, err := translateSslConfig(
+	sslConfigs, err := translateSslConfigs(
 		ctx,
 		gatewayNamespace,
 		httpsFilterChain.sniDomain,
@@ -390,9 +541,10 @@ func (httpsFilterChain *httpsFilterChain) translateHttpsFilterChain(
 			reason = gwv1.ListenerReasonInvalidCertificateRef
 		}
 		listenerReporter.SetCondition(reports.ListenerCondition{
-			Type:   gwv1.ListenerConditionResolvedRefs,
-			Status: metav1.ConditionFalse,
-			Reason: reason,
+			Type:    gwv1.ListenerConditionResolvedRefs,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: err.Error(),
 		})
 		// listener with no ssl is invalid. We return nil so set programmed to false
 		listenerReporter.SetCondition(reports.ListenerCondition{
@@ -402,12 +554,210 @@ func (httpsFilterChain *httpsFilterChain) translateHttpsFilterChain(
 		})
 		return nil, nil
 	}
-	matcher := &v1.Matcher{SslConfig: sslConfig, SourcePrefixRanges: nil, PassthroughCipherSuites: nil}
 
-	return &v1.AggregateListener_HttpFilterChain{
-		Matcher:         matcher,
-		VirtualHostRefs: virtualHostRefs,
-	}, virtualHosts
+	// one filter chain per certificate, selected by SNI
+	var filterChains []*v1.AggregateListener_HttpFilterChain
+	for _, sslConfig := range sslConfigs {
+		filterChains = append(filterChains, &v1.AggregateListener_HttpFilterChain{
+			Matcher:         &v1.Matcher{SslConfig: sslConfig, SourcePrefixRanges: nil, PassthroughCipherSuites: nil},
+			VirtualHostRefs: virtualHostRefs,
+		})
+	}
+	return filterChains, virtualHosts
+}
+
+// tlsPassthroughFilterChain represents a TLS listener in Passthrough mode. Connections are routed to the
+// backends of the attached TLSRoutes based on the SNI of the client hello, without terminating TLS.
+type tlsPassthroughFilterChain struct {
+	gatewayListenerName string
+	routesWithHosts     []*query.ListenerTLSRouteResult
+	queries             query.GatewayQueries
+}
+
+func (tfc *tlsPassthroughFilterChain) translateTcpListeners(
+	ctx context.Context,
+	reporter reports.Reporter,
+) []*v1.MatchedTcpListener {
+	// a hostname can only be served by a single route, the first route for a hostname wins
+	var (
+		hosts       []string
+		hostActions = map[string]*v1.TcpHost_TcpAction{}
+	)
+	for _, routeWithHosts := range tfc.routesWithHosts {
+		route := &routeWithHosts.Route
+		parentRefReporter := reporter.TLSRoute(route).ParentRef(&routeWithHosts.ParentRef)
+
+		var backendRefs []gwv1.BackendRef
+		for _, rule := range route.Spec.Rules {
+			backendRefs = append(backendRefs, rule.BackendRefs...)
+		}
+		action := translateTcpAction(ctx, tfc.queries, route, backendRefs, parentRefReporter)
+		if action == nil {
+			// TODO report
+			continue
+		}
+
+		hostnames := routeWithHosts.Hostnames
+		if len(hostnames) == 0 {
+			hostnames = []string{DefaultHostname}
+		}
+		for _, host := range hostnames {
+			if _, ok := hostActions[host]; ok {
+				continue
+			}
+			hosts = append(hosts, host)
+			hostActions[host] = action
+		}
+	}
+	sort.Strings(hosts)
+
+	var tcpListeners []*v1.MatchedTcpListener
+	for _, host := range hosts {
+		var sniDomains []string
+		if host != DefaultHostname {
+			sniDomains = []string{host}
+		}
+		tcpListeners = append(tcpListeners, &v1.MatchedTcpListener{
+			// the ssl config only selects the filter chain by SNI, as there is no certificate TLS is not terminated
+			Matcher: &v1.Matcher{SslConfig: &ssl.SslConfig{SniDomains: sniDomains}},
+			TcpListener: &v1.TcpListener{
+				TcpHosts: []*v1.TcpHost{{
+					Name:        makeVhostName(tfc.gatewayListenerName, host),
+					Destination: hostActions[host],
+				}},
+			},
+		})
+	}
+	return tcpListeners
+}
+
+// tcpFilterChain represents a TCP listener. Connections are forwarded to the backends of the attached TCPRoute.
+type tcpFilterChain struct {
+	gatewayListenerName string
+	routes              []*query.ListenerTCPRouteResult
+	queries             query.GatewayQueries
+}
+
+func (tfc *tcpFilterChain) translateTcpListener(
+	ctx context.Context,
+	reporter reports.Reporter,
+) *v1.MatchedTcpListener {
+	// all connections on the port are forwarded to the backends of a single route, the first route wins
+	for _, routeResult := range tfc.routes {
+		route := &routeResult.Route
+		parentRefReporter := reporter.TCPRoute(route).ParentRef(&routeResult.ParentRef)
+
+		var backendRefs []gwv1.BackendRef
+		for _, rule := range route.Spec.Rules {
+			backendRefs = append(backendRefs, rule.BackendRefs...)
+		}
+		action := translateTcpAction(ctx, tfc.queries, route, backendRefs, parentRefReporter)
+		if action == nil {
+			// TODO report
+			continue
+		}
+
+		return &v1.MatchedTcpListener{
+			Matcher: &v1.Matcher{},
+			TcpListener: &v1.TcpListener{
+				TcpHosts: []*v1.TcpHost{{
+					Name:        tfc.gatewayListenerName,
+					Destination: action,
+				}},
+			},
+		}
+	}
+	return nil
+}
+
+// translateTcpAction translates the backendRefs of a TCP-level route (a TLSRoute or TCPRoute) into the destination of a TcpHost.
+// Returns nil if the route has no backendRefs.
+func translateTcpAction(
+	ctx context.Context,
+	queries query.GatewayQueries,
+	route client.Object,
+	backendRefs []gwv1.BackendRef,
+	reporter reports.ParentRefReporter,
+) *v1.TcpHost_TcpAction {
+	var weightedDestinations []*v1.WeightedDestination
+	for _, backendRef := range backendRefs {
+		clusterName := query.BlackholeUpstreamName
+		ns := query.BlackholeUpstreamNamespace
+		obj, err := queries.GetBackendForRef(ctx, queries.ObjToFrom(route), &backendRef.BackendObjectReference)
+		ptrClusterName := query.ProcessBackendRef(obj, err, reporter, backendRef.BackendObjectReference)
+		if ptrClusterName != nil {
+			clusterName = *ptrClusterName
+			ns = obj.GetNamespace()
+		}
+
+		// according to spec, default weight is 1
+		weight := uint32(1)
+		if backendRef.Weight != nil {
+			weight = uint32(*backendRef.Weight)
+		}
+
+		weightedDestinations = append(weightedDestinations, &v1.WeightedDestination{
+			Destination: &v1.Destination{
+				DestinationType: &v1.Destination_Upstream{
+					Upstream: &core.ResourceRef{
+						Name:      clusterName,
+						Namespace: ns,
+					},
+				},
+			},
+			Weight: &wrappers.UInt32Value{Value: weight},
+		})
+	}
+
+	switch len(weightedDestinations) {
+	case 0:
+		return nil
+	case 1:
+		return &v1.TcpHost_TcpAction{
+			Destination: &v1.TcpHost_TcpAction_Single{Single: weightedDestinations[0].GetDestination()},
+		}
+	default:
+		return &v1.TcpHost_TcpAction{
+			Destination: &v1.TcpHost_TcpAction_Multi{Multi: &v1.MultiDestination{Destinations: weightedDestinations}},
+		}
+	}
+}
+
+// httpOptionsForBufferLimits enables the buffer filter on the listener when any of its routes limit the request body size.
+// The filter buffers every request it sees, so routes without a limit of their own opt out of it.
+func httpOptionsForBufferLimits(vhosts map[string]*v1.VirtualHost) *v1.HttpListenerOptions {
+	var maxRequestBytes uint32
+	for _, vhost := range vhosts {
+		for _, route := range vhost.GetRoutes() {
+			if limit := route.GetOptions().GetBufferPerRoute().GetBuffer().GetMaxRequestBytes().GetValue(); limit > maxRequestBytes {
+				maxRequestBytes = limit
+			}
+		}
+	}
+	if maxRequestBytes == 0 {
+		return nil
+	}
+
+	for _, vhost := range vhosts {
+		for _, route := range vhost.GetRoutes() {
+			if route.GetOptions().GetBufferPerRoute() != nil {
+				continue
+			}
+			if route.GetOptions() == nil {
+				route.Options = &v1.RouteOptions{}
+			}
+			route.GetOptions().BufferPerRoute = &buffer.BufferPerRoute{
+				Override: &buffer.BufferPerRoute_Disabled{
+					Disabled: true,
+				},
+			}
+		}
+	}
+	return &v1.HttpListenerOptions{
+		Buffer: &buffer.Buffer{
+			MaxRequestBytes: &wrappers.UInt32Value{Value: maxRequestBytes},
+		},
+	}
 }
 
 func buildRoutesPerHost(
@@ -446,13 +796,16 @@ func buildRoutesPerHost(
 	}
 }
 
-func translateSslConfig(
+// translateSslConfigs resolves the certificates referenced by an HTTPS listener into Gloo ssl configs.
+// A single certificate is served for the listener hostname. When the listener references multiple
+// certificates, each certificate gets its own ssl config, selected by SNI on the DNS names of the certificate.
+func translateSslConfigs(
 	ctx context.Context,
 	parentNamespace string,
 	sniDomain *gwv1.Hostname,
 	tls *gwv1.GatewayTLSConfig,
 	queries query.GatewayQueries,
-) (*ssl.SslConfig, error) {
+) ([]*ssl.SslConfig, error) {
 	if tls == nil {
 		return nil, nil
 	}
@@ -463,10 +816,23 @@ func translateSslConfig(
 		return nil, nil
 	}
 
-	var secretRef *core.ResourceRef
+	var listenerSniDomains []string
+	if sniDomain != nil {
+		listenerSniDomains = []string{string(*sniDomain)}
+	}
+
+	var (
+		sslConfigs []*ssl.SslConfig
+		usedSnis   = map[string]struct{}{}
+	)
 	for _, certRef := range tls.CertificateRefs {
-		// validate via query
-		secret, err := queries.GetSecretForRef(ctx, query.FromGkNs{
+		secretNamespace := parentNamespace
+		if certRef.Namespace != nil {
+			secretNamespace = string(*certRef.Namespace)
+		}
+
+		// validate via query, this also checks for a reference grant on cross namespace references
+		secretObj, err := queries.GetSecretForRef(ctx, query.FromGkNs{
 			Gk: metav1.GroupKind{
 				Group: gwv1.GroupName,
 				Kind:  "Gateway",
@@ -474,42 +840,83 @@ func translateSslConfig(
 			Ns: parentNamespace,
 		}, certRef)
 		if err != nil {
-			return nil, err
+			if apierrors.IsNotFound(err) {
+				return nil, eris.Wrapf(err, "certificate ref %s.%s not found", secretNamespace, certRef.Name)
+			}
+			return nil, eris.Wrapf(err, "failed to resolve certificate ref %s.%s", secretNamespace, certRef.Name)
 		}
-		if err := sslutils.ValidateTlsSecret(secret.(*corev1.Secret)); err != nil {
+		secret := secretObj.(*corev1.Secret)
+		if err := sslutils.ValidateTlsSecret(secret); err != nil {
 			return nil, err
 		}
 
-		// TODO verify secret ref / grant using query
-		secretNamespace := parentNamespace
-		if certRef.Namespace != nil {
-			secretNamespace = string(*certRef.Namespace)
-		}
-		secretRef = &core.ResourceRef{
-			Name:      string(certRef.Name),
-			Namespace: secretNamespace,
+		sniDomains := listenerSniDomains
+		if len(tls.CertificateRefs) > 1 {
+			dnsNames, err := sslutils.GetCertificateDnsNames(secret)
+			if err != nil {
+				return nil, err
+			}
+			sniDomains = sniDomainsForCert(dnsNames, sniDomain, usedSnis)
+			if len(sniDomains) == 0 {
+				// the certificate does not serve any (new) hostname of the listener
+				continue
+			}
 		}
-		break // TODO support multiple certs
-	}
-	if secretRef == nil {
-		return nil, nil
+
+		sslConfigs = append(sslConfigs, &ssl.SslConfig{
+			SslSecrets: &ssl.SslConfig_SecretRef{SecretRef: &core.ResourceRef{
+				Name:      string(certRef.Name),
+				Namespace: secretNamespace,
+			}},
+			SniDomains:                    sniDomains,
+			VerifySubjectAltName:          nil,
+			Parameters:                    nil,
+			AlpnProtocols:                 nil,
+			OneWayTls:                     nil,
+			DisableTlsSessionResumption:   nil,
+			TransportSocketConnectTimeout: nil,
+			OcspStaplePolicy:              0,
+		})
 	}
+	return sslConfigs, nil
+}
 
+// sniDomainsForCert returns the SNI domains for which a certificate with the given DNS names should be served
+// on a listener with the given hostname. Domains already served by another certificate are skipped.
+func sniDomainsForCert(dnsNames []string, listenerHostname *gwv1.Hostname, usedSnis map[string]struct{}) []string {
 	var sniDomains []string
-	if sniDomain != nil {
-		sniDomains = []string{string(*sniDomain)}
-	}
-	return &ssl.SslConfig{
-		SslSecrets:                    &ssl.SslConfig_SecretRef{SecretRef: secretRef},
-		SniDomains:                    sniDomains,
-		VerifySubjectAltName:          nil,
-		Parameters:                    nil,
-		AlpnProtocols:                 nil,
-		OneWayTls:                     nil,
-		DisableTlsSessionResumption:   nil,
-		TransportSocketConnectTimeout: nil,
-		OcspStaplePolicy:              0,
-	}, nil
+	for _, dnsName := range dnsNames {
+		sni := dnsName
+		if listenerHostname != nil {
+			hostname := string(*listenerHostname)
+			switch {
+			case hostnameCovers(hostname, dnsName):
+				// the listener hostname is wider than the certificate, serve it for the certificate name
+			case hostnameCovers(dnsName, hostname):
+				// the certificate is wider than the listener hostname, serve it for the listener hostname
+				sni = hostname
+			default:
+				continue
+			}
+		}
+		if _, ok := usedSnis[sni]; ok {
+			continue
+		}
+		usedSnis[sni] = struct{}{}
+		sniDomains = append(sniDomains, sni)
+	}
+	return sniDomains
+}
+
+// hostnameCovers returns true if the (possibly wildcard) hostname matches the other hostname
+func hostnameCovers(hostname, other string) bool {
+	if hostname == other {
+		return true
+	}
+	if suffix, ok := strings.CutPrefix(hostname, "*"); ok {
+		return strings.HasSuffix(other, suffix) && !strings.HasPrefix(other, "*")
+	}
+	return false
 }
 
 // makeVhostName computes the name of a virtual host based on the parent name and domain.
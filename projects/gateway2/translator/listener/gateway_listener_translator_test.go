@@ -0,0 +1,332 @@
+package listener
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/skv2/codegen/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gwv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/reports"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/registry"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	buffer "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/extensions/filters/http/buffer/v3"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+)
+
+// loadCertSecrets loads the foo-cert and bar-cert TLS secrets (for foo.example.com and bar.example.com)
+func loadCertSecrets(t *testing.T) map[string]*corev1.Secret {
+	objs, err := testutils.LoadFromFiles(context.Background(), util.MustGetThisDir()+"/../testutils/inputs/https-multi-cert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := map[string]*corev1.Secret{}
+	for _, obj := range objs {
+		if secret, ok := obj.(*corev1.Secret); ok {
+			secrets[secret.Name] = secret
+		}
+	}
+	return secrets
+}
+
+func secretRef(name string, namespace *gwv1.Namespace) gwv1.SecretObjectReference {
+	kind := gwv1.Kind("Secret")
+	return gwv1.SecretObjectReference{
+		Kind:      &kind,
+		Name:      gwv1.ObjectName(name),
+		Namespace: namespace,
+	}
+}
+
+func terminateTls(refs ...gwv1.SecretObjectReference) *gwv1.GatewayTLSConfig {
+	mode := gwv1.TLSModeTerminate
+	return &gwv1.GatewayTLSConfig{
+		Mode:            &mode,
+		CertificateRefs: refs,
+	}
+}
+
+func buildPluginRegistry(g *WithT, queries query.GatewayQueries) registry.PluginRegistry {
+	pluginRegistry, err := registry.NewPluginRegistry(registry.BuildPlugins(queries))
+	g.Expect(err).NotTo(HaveOccurred())
+	return pluginRegistry
+}
+
+func TestTranslateSslConfigsSingleCert(t *testing.T) {
+	g := NewWithT(t)
+	secrets := loadCertSecrets(t)
+	queries := testutils.BuildGatewayQueries([]client.Object{secrets["foo-cert"]})
+
+	hostname := gwv1.Hostname("foo.example.com")
+	sslConfigs, err := translateSslConfigs(context.Background(), "default", &hostname, terminateTls(secretRef("foo-cert", nil)), queries)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sslConfigs).To(HaveLen(1))
+	g.Expect(sslConfigs[0].GetSecretRef().GetName()).To(Equal("foo-cert"))
+	g.Expect(sslConfigs[0].GetSecretRef().GetNamespace()).To(Equal("default"))
+	g.Expect(sslConfigs[0].GetSniDomains()).To(ConsistOf("foo.example.com"))
+}
+
+func TestTranslateSslConfigsMultipleCertsBySni(t *testing.T) {
+	g := NewWithT(t)
+	secrets := loadCertSecrets(t)
+	queries := testutils.BuildGatewayQueries([]client.Object{secrets["foo-cert"], secrets["bar-cert"]})
+
+	hostname := gwv1.Hostname("*.example.com")
+	sslConfigs, err := translateSslConfigs(context.Background(), "default", &hostname,
+		terminateTls(secretRef("foo-cert", nil), secretRef("bar-cert", nil)), queries)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sslConfigs).To(HaveLen(2))
+	g.Expect(sslConfigs[0].GetSecretRef().GetName()).To(Equal("foo-cert"))
+	g.Expect(sslConfigs[0].GetSniDomains()).To(ConsistOf("foo.example.com"))
+	g.Expect(sslConfigs[1].GetSecretRef().GetName()).To(Equal("bar-cert"))
+	g.Expect(sslConfigs[1].GetSniDomains()).To(ConsistOf("bar.example.com"))
+
+	// certificates not matching the listener hostname are not served
+	hostname = "foo.example.com"
+	sslConfigs, err = translateSslConfigs(context.Background(), "default", &hostname,
+		terminateTls(secretRef("foo-cert", nil), secretRef("bar-cert", nil)), queries)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sslConfigs).To(HaveLen(1))
+	g.Expect(sslConfigs[0].GetSecretRef().GetName()).To(Equal("foo-cert"))
+}
+
+func TestTranslateSslConfigsCrossNamespace(t *testing.T) {
+	g := NewWithT(t)
+	secret := loadCertSecrets(t)["foo-cert"].DeepCopy()
+	secret.Namespace = "certs"
+	certsNs := gwv1.Namespace("certs")
+	tls := terminateTls(secretRef("foo-cert", &certsNs))
+
+	queries := testutils.BuildGatewayQueries([]client.Object{secret})
+	_, err := translateSslConfigs(context.Background(), "default", nil, tls, queries)
+	g.Expect(err).To(MatchError(query.ErrMissingReferenceGrant))
+
+	refGrant := &gwv1beta1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "allow-gateways", Namespace: "certs"},
+		Spec: gwv1beta1.ReferenceGrantSpec{
+			From: []gwv1beta1.ReferenceGrantFrom{{Group: gwv1.GroupName, Kind: "Gateway", Namespace: "default"}},
+			To:   []gwv1beta1.ReferenceGrantTo{{Group: "", Kind: "Secret"}},
+		},
+	}
+	queries = testutils.BuildGatewayQueries([]client.Object{secret, refGrant})
+	sslConfigs, err := translateSslConfigs(context.Background(), "default", nil, tls, queries)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sslConfigs).To(HaveLen(1))
+	g.Expect(sslConfigs[0].GetSecretRef().GetNamespace()).To(Equal("certs"))
+}
+
+func TestTranslateListenersReportsMissingSecret(t *testing.T) {
+	g := NewWithT(t)
+	gateway := &gwv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw", Namespace: "default"},
+		Spec: gwv1.GatewaySpec{
+			Listeners: []gwv1.Listener{{
+				Name:     "https",
+				Port:     443,
+				Protocol: gwv1.HTTPSProtocolType,
+				TLS:      terminateTls(secretRef("missing", nil)),
+			}},
+		},
+	}
+	queries := testutils.BuildGatewayQueries(nil)
+	reportMap := reports.NewReportMap()
+	reporter := reports.NewReporter(&reportMap)
+
+	TranslateListeners(context.Background(), queries, buildPluginRegistry(g, queries),
+		gateway, query.RoutesForGwResult{}, reporter)
+
+	status := reportMap.Gateway(gateway).Listener(&gateway.Spec.Listeners[0]).(*reports.ListenerReport).Status
+	var resolvedRefs *metav1.Condition
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == string(gwv1.ListenerConditionResolvedRefs) {
+			resolvedRefs = &status.Conditions[i]
+		}
+	}
+	g.Expect(resolvedRefs).NotTo(BeNil())
+	g.Expect(resolvedRefs.Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(resolvedRefs.Reason).To(Equal(string(gwv1.ListenerReasonInvalidCertificateRef)))
+	g.Expect(resolvedRefs.Message).To(ContainSubstring("default.missing not found"))
+}
+
+func TestTranslateListenersTlsPassthrough(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	objs, err := testutils.LoadFromFiles(ctx, util.MustGetThisDir()+"/../testutils/inputs/tls-passthrough")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var (
+		gateway      *gwv1.Gateway
+		dependencies []client.Object
+	)
+	for _, obj := range objs {
+		if gw, ok := obj.(*gwv1.Gateway); ok {
+			gateway = gw
+			continue
+		}
+		dependencies = append(dependencies, obj)
+	}
+	queries := testutils.BuildGatewayQueries(dependencies)
+	routesForGw, err := queries.GetRoutesForGw(ctx, gateway)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(routesForGw.ListenerResults["tls"].TLSRoutes).To(HaveLen(2))
+
+	reportMap := reports.NewReportMap()
+	listeners := TranslateListeners(ctx, queries, buildPluginRegistry(g, queries),
+		gateway, routesForGw, reports.NewReporter(&reportMap))
+	g.Expect(listeners).To(HaveLen(1))
+
+	aggregate := listeners[0].GetAggregateListener()
+	g.Expect(aggregate.GetHttpFilterChains()).To(BeEmpty())
+	upstreamsBySni := map[string]string{}
+	for _, tcpListener := range aggregate.GetTcpListeners() {
+		// tls is not terminated, the ssl config only selects the filter chain by SNI
+		sslConfig := tcpListener.GetMatcher().GetSslConfig()
+		g.Expect(sslConfig.GetSslSecrets()).To(BeNil())
+		g.Expect(sslConfig.GetSniDomains()).To(HaveLen(1))
+
+		tcpHosts := tcpListener.GetTcpListener().GetTcpHosts()
+		g.Expect(tcpHosts).To(HaveLen(1))
+		g.Expect(tcpHosts[0].GetSslConfig()).To(BeNil())
+		upstreamsBySni[sslConfig.GetSniDomains()[0]] = tcpHosts[0].GetDestination().GetSingle().GetUpstream().GetName()
+	}
+	g.Expect(upstreamsBySni).To(Equal(map[string]string{
+		"foo.example.com": "default-foo-svc-443",
+		"bar.example.com": "default-bar-svc-443",
+	}))
+}
+
+func TestTranslateListenersRejectsTlsTerminate(t *testing.T) {
+	g := NewWithT(t)
+	gateway := &gwv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw", Namespace: "default"},
+		Spec: gwv1.GatewaySpec{
+			Listeners: []gwv1.Listener{{
+				Name:     "tls",
+				Port:     443,
+				Protocol: gwv1.TLSProtocolType,
+				TLS:      terminateTls(secretRef("foo-cert", nil)),
+			}},
+		},
+	}
+	queries := testutils.BuildGatewayQueries(nil)
+	reportMap := reports.NewReportMap()
+
+	listeners := TranslateListeners(context.Background(), queries, buildPluginRegistry(g, queries),
+		gateway, query.RoutesForGwResult{}, reports.NewReporter(&reportMap))
+	g.Expect(listeners).To(BeEmpty())
+
+	status := reportMap.Gateway(gateway).Listener(&gateway.Spec.Listeners[0]).(*reports.ListenerReport).Status
+	g.Expect(status.Conditions).To(ContainElement(And(
+		HaveField("Type", string(gwv1.ListenerConditionAccepted)),
+		HaveField("Status", metav1.ConditionFalse),
+		HaveField("Reason", string(gwv1.ListenerReasonUnsupportedProtocol)),
+	)))
+}
+
+func TestTranslateTcpListenerDestinations(t *testing.T) {
+	g := NewWithT(t)
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-svc", Namespace: "default"},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 5432}}},
+	}
+	port := gwv1.PortNumber(5432)
+	backendRef := func(name string) gwv1.BackendRef {
+		return gwv1.BackendRef{BackendObjectReference: gwv1.BackendObjectReference{Name: gwv1.ObjectName(name), Port: &port}}
+	}
+	route := gwv1alpha2.TCPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-route", Namespace: "default"},
+		Spec: gwv1alpha2.TCPRouteSpec{
+			Rules: []gwv1alpha2.TCPRouteRule{{
+				BackendRefs: []gwv1.BackendRef{backendRef("db-svc"), backendRef("missing-svc")},
+			}},
+		},
+	}
+	parentRef := gwv1.ParentReference{Name: "gw"}
+	queries := testutils.BuildGatewayQueries([]client.Object{svc})
+	reportMap := reports.NewReportMap()
+	reporter := reports.NewReporter(&reportMap)
+
+	fc := &tcpFilterChain{
+		gatewayListenerName: "db",
+		routes:              []*query.ListenerTCPRouteResult{{Route: route, ParentRef: parentRef}},
+		queries:             queries,
+	}
+	tcpListener := fc.translateTcpListener(context.Background(), reporter)
+	g.Expect(tcpListener).NotTo(BeNil())
+	g.Expect(tcpListener.GetMatcher().GetSslConfig()).To(BeNil())
+
+	tcpHosts := tcpListener.GetTcpListener().GetTcpHosts()
+	g.Expect(tcpHosts).To(HaveLen(1))
+	destinations := tcpHosts[0].GetDestination().GetMulti().GetDestinations()
+	g.Expect(destinations).To(HaveLen(2))
+	// backends without a weight get the default weight of 1
+	g.Expect(destinations[0].GetWeight().GetValue()).To(Equal(uint32(1)))
+	g.Expect(destinations[0].GetDestination().GetUpstream().GetName()).To(Equal("default-db-svc-5432"))
+	// unresolved backends are blackholed and reported on the route
+	g.Expect(destinations[1].GetDestination().GetUpstream().GetName()).To(Equal("blackhole_cluster"))
+	conditions := reporter.TCPRoute(&route).ParentRef(&parentRef).(*reports.ParentRefReport).Conditions
+	g.Expect(conditions).To(ContainElement(And(
+		HaveField("Type", string(gwv1.RouteConditionResolvedRefs)),
+		HaveField("Reason", string(gwv1.RouteReasonBackendNotFound)),
+	)))
+
+	// a route without backends does not produce a listener
+	route.Spec.Rules = nil
+	fc.routes = []*query.ListenerTCPRouteResult{{Route: route, ParentRef: parentRef}}
+	g.Expect(fc.translateTcpListener(context.Background(), reporter)).To(BeNil())
+}
+
+func TestHttpOptionsForBufferLimits(t *testing.T) {
+	g := NewWithT(t)
+
+	limited := &v1.Route{
+		Name: "limited",
+		Options: &v1.RouteOptions{
+			BufferPerRoute: &buffer.BufferPerRoute{
+				Override: &buffer.BufferPerRoute_Buffer{
+					Buffer: &buffer.Buffer{MaxRequestBytes: &wrappers.UInt32Value{Value: 1024}},
+				},
+			},
+		},
+	}
+	larger := &v1.Route{
+		Name: "larger",
+		Options: &v1.RouteOptions{
+			BufferPerRoute: &buffer.BufferPerRoute{
+				Override: &buffer.BufferPerRoute_Buffer{
+					Buffer: &buffer.Buffer{MaxRequestBytes: &wrappers.UInt32Value{Value: 4096}},
+				},
+			},
+		},
+	}
+	unlimited := &v1.Route{Name: "unlimited"}
+	streaming := &v1.Route{
+		Name: "streaming",
+		Options: &v1.RouteOptions{
+			BufferPerRoute: &buffer.BufferPerRoute{
+				Override: &buffer.BufferPerRoute_Disabled{Disabled: true},
+			},
+		},
+	}
+
+	g.Expect(httpOptionsForBufferLimits(map[string]*v1.VirtualHost{
+		"foo": {Routes: []*v1.Route{{Name: "plain"}, streaming}},
+	})).To(BeNil())
+
+	options := httpOptionsForBufferLimits(map[string]*v1.VirtualHost{
+		"foo": {Routes: []*v1.Route{limited, unlimited, streaming}},
+		"bar": {Routes: []*v1.Route{larger}},
+	})
+	g.Expect(options.GetBuffer().GetMaxRequestBytes().GetValue()).To(BeEquivalentTo(4096))
+	g.Expect(limited.GetOptions().GetBufferPerRoute().GetBuffer().GetMaxRequestBytes().GetValue()).To(BeEquivalentTo(1024))
+	g.Expect(unlimited.GetOptions().GetBufferPerRoute().GetDisabled()).To(BeTrue())
+	g.Expect(streaming.GetOptions().GetBufferPerRoute().GetDisabled()).To(BeTrue())
+}
@@ -11,6 +11,8 @@ import (
 const NormalizedHTTPSTLSType = "HTTPS/TLS"
 const DefaultHostname = "*"
 const HTTPRouteKind = "HTTPRoute"
+const TLSRouteKind = "TLSRoute"
+const TCPRouteKind = "TCPRoute"
 
 type portProtocol struct {
 	hostnames map[gwv1.Hostname]int
@@ -24,7 +26,7 @@ type groupName = string
 type routeKind = string
 
 func getSupportedProtocolsRoutes() map[protocol]map[groupName][]routeKind {
-	// we currently only support HTTPRoute on HTTP and HTTPS protocols
+	// we currently support HTTPRoute on HTTP and HTTPS protocols, TLSRoute on TLS (passthrough only) and TCPRoute on TCP
 	supportedProtocolToKinds := map[protocol]map[groupName][]routeKind{
 		string(gwv1.HTTPProtocolType): {
 			gwv1.GroupName: []string{
@@ -36,6 +38,16 @@ func getSupportedProtocolsRoutes() map[protocol]map[groupName][]routeKind {
 				HTTPRouteKind,
 			},
 		},
+		string(gwv1.TLSProtocolType): {
+			gwv1.GroupName: []string{
+				TLSRouteKind,
+			},
+		},
+		string(gwv1.TCPProtocolType): {
+			gwv1.GroupName: []string{
+				TCPRouteKind,
+			},
+		},
 	}
 	return supportedProtocolToKinds
 }
@@ -69,6 +81,16 @@ func validateSupportedRoutes(listeners []gwv1.Listener, reporter reports.Gateway
 			continue
 		}
 
+		if listener.Protocol == gwv1.TLSProtocolType && !isTlsPassthrough(listener.TLS) {
+			reporter.Listener(&listener).SetCondition(reports.ListenerCondition{
+				Type:    gwv1.ListenerConditionAccepted,
+				Status:  metav1.ConditionFalse,
+				Reason:  gwv1.ListenerReasonUnsupportedProtocol,
+				Message: "only the Passthrough tls mode is supported for TLS listeners",
+			})
+			continue
+		}
+
 		if listener.AllowedRoutes == nil || len(listener.AllowedRoutes.Kinds) == 0 {
 			// default to whatever route kinds we support on this protocol
 			// TODO(Law): confirm this matches spec
@@ -151,44 +173,44 @@ func validateListeners(gw *gwv1.Gateway, reporter reports.GatewayReporter) []gwv
 		}
 	}
 
-	// reset valid listeners
+	// reset valid listeners, keeping the order they were declared in on the Gateway
+	supportedListeners := validListeners
 	validListeners = []gwv1.Listener{}
-	for _, pp := range portListeners {
+	for _, listener := range supportedListeners {
+		pp := portListeners[listener.Port]
 		protocolConflict := false
 		if len(pp.protocol) > 1 {
 			protocolConflict = true
 		}
 
-		for _, listener := range pp.listeners {
-			if protocolConflict {
-				reporter.Listener(&listener).SetCondition(reports.ListenerCondition{
-					Type:    gwv1.ListenerConditionConflicted,
-					Status:  metav1.ConditionTrue,
-					Reason:  gwv1.ListenerReasonProtocolConflict,
-					Message: "Found conflicting protocols on listeners, a single port can only contain listeners with compatible protocols",
-				})
+		if protocolConflict {
+			reporter.Listener(&listener).SetCondition(reports.ListenerCondition{
+				Type:    gwv1.ListenerConditionConflicted,
+				Status:  metav1.ConditionTrue,
+				Reason:  gwv1.ListenerReasonProtocolConflict,
+				Message: "Found conflicting protocols on listeners, a single port can only contain listeners with compatible protocols",
+			})
 
-				// continue as protocolConflict will take precedence over hostname conflicts
-				continue
-			}
+			// continue as protocolConflict will take precedence over hostname conflicts
+			continue
+		}
 
-			var hostname gwv1.Hostname
-			if listener.Hostname == nil {
-				hostname = DefaultHostname
-			} else {
-				hostname = *listener.Hostname
-			}
-			if count := pp.hostnames[hostname]; count > 1 {
-				reporter.Listener(&listener).SetCondition(reports.ListenerCondition{
-					Type:    gwv1.ListenerConditionConflicted,
-					Status:  metav1.ConditionTrue,
-					Reason:  gwv1.ListenerReasonHostnameConflict,
-					Message: "Found conflicting hostnames on listeners, all listeners on a single port must have unique hostnames",
-				})
-			} else {
-				// TODO should check this is exactly 1?
-				validListeners = append(validListeners, listener)
-			}
+		var hostname gwv1.Hostname
+		if listener.Hostname == nil {
+			hostname = DefaultHostname
+		} else {
+			hostname = *listener.Hostname
+		}
+		if count := pp.hostnames[hostname]; count > 1 {
+			reporter.Listener(&listener).SetCondition(reports.ListenerCondition{
+				Type:    gwv1.ListenerConditionConflicted,
+				Status:  metav1.ConditionTrue,
+				Reason:  gwv1.ListenerReasonHostnameConflict,
+				Message: "Found conflicting hostnames on listeners, all listeners on a single port must have unique hostnames",
+			})
+		} else {
+			// TODO should check this is exactly 1?
+			validListeners = append(validListeners, listener)
 		}
 	}
 
@@ -208,6 +230,10 @@ func validateListeners(gw *gwv1.Gateway, reporter reports.GatewayReporter) []gwv
 	return validListeners
 }
 
+func isTlsPassthrough(tls *gwv1.GatewayTLSConfig) bool {
+	return tls != nil && tls.Mode != nil && *tls.Mode == gwv1.TLSModePassthrough
+}
+
 func getGroupName() *gwv1.Group {
 	g := gwv1.Group(gwv1.GroupName)
 	return &g
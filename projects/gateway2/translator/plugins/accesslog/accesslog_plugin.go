@@ -0,0 +1,142 @@
+package accesslog
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	envoycore_gloo "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/config/core/v3"
+	envoytype_gloo "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/type/v3"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/als"
+)
+
+// Annotations set on a Gateway to log the requests and connections of all of its listeners.
+const (
+	// AccessLogPathAnnotation enables access logging, and is the path of the file the entries are written to,
+	// e.g. /dev/stdout. Entries use the default envoy format.
+	AccessLogPathAnnotation = "gateway2.solo.io/access-log-path"
+	// AccessLogMinStatusCodeAnnotation only logs the requests whose response status code is at least this code,
+	// e.g. 400 to only log client and server errors
+	AccessLogMinStatusCodeAnnotation = "gateway2.solo.io/access-log-min-status-code"
+	// AccessLogSamplePercentageAnnotation is the percentage of the requests which are logged, between 0 and 100,
+	// defaulting to 100. It is combined with AccessLogMinStatusCodeAnnotation if both are set.
+	AccessLogSamplePercentageAnnotation = "gateway2.solo.io/access-log-sample-percentage"
+)
+
+// sampleRuntimeKey is the runtime key which can override the sampled percentage of the access log entries
+const sampleRuntimeKey = "access_log.sample_percentage"
+
+var (
+	MissingPathErr = func(annotation string) error {
+		return errors.Errorf("annotation %s requires annotation %s", annotation, AccessLogPathAnnotation)
+	}
+	InvalidStatusCodeErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a status code between 100 and 599", value, AccessLogMinStatusCodeAnnotation)
+	}
+	InvalidSamplePercentageErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a percentage between 0 and 100", value, AccessLogSamplePercentageAnnotation)
+	}
+)
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	annotations := listenerCtx.Gateway.GetAnnotations()
+	path, ok := annotations[AccessLogPathAnnotation]
+	if !ok {
+		for _, annotation := range []string{AccessLogMinStatusCodeAnnotation, AccessLogSamplePercentageAnnotation} {
+			if _, ok := annotations[annotation]; ok {
+				return MissingPathErr(annotation)
+			}
+		}
+		return nil
+	}
+	filter, err := getFilter(annotations)
+	if err != nil {
+		return err
+	}
+
+	if outputListener.GetOptions() == nil {
+		outputListener.Options = &v1.ListenerOptions{}
+	}
+	outputListener.GetOptions().AccessLoggingService = &als.AccessLoggingService{
+		AccessLog: []*als.AccessLog{{
+			OutputDestination: &als.AccessLog_FileSink{
+				FileSink: &als.FileSink{
+					Path: path,
+				},
+			},
+			Filter: filter,
+		}},
+	}
+	return nil
+}
+
+// getFilter returns the filter of the access log entries configured by the annotations, or nil to log everything
+func getFilter(annotations map[string]string) (*als.AccessLogFilter, error) {
+	var filters []*als.AccessLogFilter
+	if value, ok := annotations[AccessLogMinStatusCodeAnnotation]; ok {
+		code, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || code < 100 || code > 599 {
+			return nil, InvalidStatusCodeErr(value)
+		}
+		filters = append(filters, &als.AccessLogFilter{
+			FilterSpecifier: &als.AccessLogFilter_StatusCodeFilter{
+				StatusCodeFilter: &als.StatusCodeFilter{
+					Comparison: &als.ComparisonFilter{
+						Op: als.ComparisonFilter_GE,
+						Value: &envoycore_gloo.RuntimeUInt32{
+							DefaultValue: uint32(code),
+						},
+					},
+				},
+			},
+		})
+	}
+	if value, ok := annotations[AccessLogSamplePercentageAnnotation]; ok {
+		percentage, err := strconv.ParseFloat(value, 64)
+		if err != nil || percentage < 0 || percentage > 100 {
+			return nil, InvalidSamplePercentageErr(value)
+		}
+		filters = append(filters, &als.AccessLogFilter{
+			FilterSpecifier: &als.AccessLogFilter_RuntimeFilter{
+				RuntimeFilter: &als.RuntimeFilter{
+					RuntimeKey: sampleRuntimeKey,
+					// a million allows sampling fractions of a percent
+					PercentSampled: &envoytype_gloo.FractionalPercent{
+						Numerator:   uint32(math.Round(percentage * 10000)),
+						Denominator: envoytype_gloo.FractionalPercent_MILLION,
+					},
+				},
+			},
+		})
+	}
+
+	switch len(filters) {
+	case 0:
+		return nil, nil
+	case 1:
+		return filters[0], nil
+	default:
+		return &als.AccessLogFilter{
+			FilterSpecifier: &als.AccessLogFilter_AndFilter{
+				AndFilter: &als.AndFilter{
+					Filters: filters,
+				},
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,155 @@
+package accesslog
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	envoycore_gloo "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/config/core/v3"
+	envoytype_gloo "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/type/v3"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/als"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("AccessLogPlugin", func() {
+	var outputListener *v1.Listener
+
+	BeforeEach(func() {
+		outputListener = &v1.Listener{
+			Name: "http",
+		}
+	})
+
+	apply := func(annotations map[string]string) error {
+		listenerCtx := &plugins.ListenerContext{
+			Gateway: &gwv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+			},
+		}
+		return NewPlugin().ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+	}
+
+	accessLog := func(filter *als.AccessLogFilter) *als.AccessLoggingService {
+		return &als.AccessLoggingService{
+			AccessLog: []*als.AccessLog{{
+				OutputDestination: &als.AccessLog_FileSink{
+					FileSink: &als.FileSink{Path: "/dev/stdout"},
+				},
+				Filter: filter,
+			}},
+		}
+	}
+
+	errorsFilter := &als.AccessLogFilter{
+		FilterSpecifier: &als.AccessLogFilter_StatusCodeFilter{
+			StatusCodeFilter: &als.StatusCodeFilter{
+				Comparison: &als.ComparisonFilter{
+					Op:    als.ComparisonFilter_GE,
+					Value: &envoycore_gloo.RuntimeUInt32{DefaultValue: 400},
+				},
+			},
+		},
+	}
+	onePercentFilter := &als.AccessLogFilter{
+		FilterSpecifier: &als.AccessLogFilter_RuntimeFilter{
+			RuntimeFilter: &als.RuntimeFilter{
+				RuntimeKey: sampleRuntimeKey,
+				PercentSampled: &envoytype_gloo.FractionalPercent{
+					Numerator:   10000,
+					Denominator: envoytype_gloo.FractionalPercent_MILLION,
+				},
+			},
+		},
+	}
+
+	It("logs every request to the file", func() {
+		Expect(apply(map[string]string{AccessLogPathAnnotation: "/dev/stdout"})).To(Succeed())
+		Expect(proto.Equal(outputListener.GetOptions().GetAccessLoggingService(), accessLog(nil))).To(BeTrue())
+	})
+
+	It("only logs 4xx and 5xx responses", func() {
+		Expect(apply(map[string]string{
+			AccessLogPathAnnotation:          "/dev/stdout",
+			AccessLogMinStatusCodeAnnotation: "400",
+		})).To(Succeed())
+		Expect(proto.Equal(outputListener.GetOptions().GetAccessLoggingService(), accessLog(errorsFilter))).To(BeTrue())
+	})
+
+	It("samples 1% of the requests", func() {
+		Expect(apply(map[string]string{
+			AccessLogPathAnnotation:             "/dev/stdout",
+			AccessLogSamplePercentageAnnotation: "1",
+		})).To(Succeed())
+		Expect(proto.Equal(outputListener.GetOptions().GetAccessLoggingService(), accessLog(onePercentFilter))).To(BeTrue())
+	})
+
+	It("samples fractions of a percent", func() {
+		Expect(apply(map[string]string{
+			AccessLogPathAnnotation:             "/dev/stdout",
+			AccessLogSamplePercentageAnnotation: "0.05",
+		})).To(Succeed())
+		runtimeFilter := outputListener.GetOptions().GetAccessLoggingService().GetAccessLog()[0].GetFilter().GetRuntimeFilter()
+		Expect(runtimeFilter.GetPercentSampled().GetNumerator()).To(Equal(uint32(500)))
+	})
+
+	It("combines the status code and sampling filters", func() {
+		Expect(apply(map[string]string{
+			AccessLogPathAnnotation:             "/dev/stdout",
+			AccessLogMinStatusCodeAnnotation:    "400",
+			AccessLogSamplePercentageAnnotation: "1",
+		})).To(Succeed())
+		Expect(proto.Equal(outputListener.GetOptions().GetAccessLoggingService(), accessLog(&als.AccessLogFilter{
+			FilterSpecifier: &als.AccessLogFilter_AndFilter{
+				AndFilter: &als.AndFilter{
+					Filters: []*als.AccessLogFilter{errorsFilter, onePercentFilter},
+				},
+			},
+		}))).To(BeTrue())
+	})
+
+	It("keeps the other listener options", func() {
+		outputListener.Options = &v1.ListenerOptions{
+			PerConnectionBufferLimitBytes: &wrappers.UInt32Value{Value: 1024},
+		}
+		Expect(apply(map[string]string{AccessLogPathAnnotation: "/dev/stdout"})).To(Succeed())
+		Expect(proto.Equal(outputListener.GetOptions(), &v1.ListenerOptions{
+			PerConnectionBufferLimitBytes: &wrappers.UInt32Value{Value: 1024},
+			AccessLoggingService:          accessLog(nil),
+		})).To(BeTrue())
+	})
+
+	It("does nothing without the annotations", func() {
+		Expect(apply(nil)).To(Succeed())
+		Expect(outputListener.GetOptions()).To(BeNil())
+	})
+
+	DescribeTable("rejects invalid access logs",
+		func(annotations map[string]string, expectedErr string) {
+			Expect(apply(annotations)).To(MatchError(expectedErr))
+			Expect(outputListener.GetOptions()).To(BeNil())
+		},
+		Entry("status code filter without path",
+			map[string]string{AccessLogMinStatusCodeAnnotation: "500"},
+			MissingPathErr(AccessLogMinStatusCodeAnnotation).Error()),
+		Entry("sampling without path",
+			map[string]string{AccessLogSamplePercentageAnnotation: "10"},
+			MissingPathErr(AccessLogSamplePercentageAnnotation).Error()),
+		Entry("invalid status code",
+			map[string]string{AccessLogPathAnnotation: "/dev/stdout", AccessLogMinStatusCodeAnnotation: "4xx"},
+			InvalidStatusCodeErr("4xx").Error()),
+		Entry("out of range status code",
+			map[string]string{AccessLogPathAnnotation: "/dev/stdout", AccessLogMinStatusCodeAnnotation: "600"},
+			InvalidStatusCodeErr("600").Error()),
+		Entry("out of range percentage",
+			map[string]string{AccessLogPathAnnotation: "/dev/stdout", AccessLogSamplePercentageAnnotation: "101"},
+			InvalidSamplePercentageErr("101").Error()),
+	)
+})
@@ -0,0 +1,286 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	kubeconverters "github.com/solo-io/gloo/projects/gloo/pkg/api/converters/kube"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	extauthv1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/extauth/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/transformation"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Annotations set on a RouteOption to only allow requests carrying a valid API key on the routes it is applied to.
+// The plugin generates an AuthConfig for each RouteOption, which the routes reference.
+const (
+	// ApiKeySecretsAnnotation is a comma-separated list of [<namespace>/]<name> of the Secrets holding the valid
+	// API keys, and enables API key authentication. Each Secret is of type extauth.solo.io/apikey and holds a key
+	// in its api-key entry. Secrets in other namespaces than the RouteOption must be allowed by a ReferenceGrant.
+	ApiKeySecretsAnnotation = "gateway2.solo.io/api-key-secrets"
+	// ApiKeyHeaderAnnotation is the request header carrying the API key, defaulting to "api-key"
+	ApiKeyHeaderAnnotation = "gateway2.solo.io/api-key-header"
+	// ApiKeyQueryParamAnnotation is the query parameter carrying the API key, instead of a header
+	ApiKeyQueryParamAnnotation = "gateway2.solo.io/api-key-query-param"
+)
+
+const defaultHeader = "api-key"
+
+var (
+	headerRegex     = regexp.MustCompile(`^[A-Za-z0-9!#$%&'*+\-.^_|~]+$`)
+	queryParamRegex = regexp.MustCompile(`^[A-Za-z0-9\-._~]+$`)
+
+	InvalidHeaderErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a valid header name", value, ApiKeyHeaderAnnotation)
+	}
+	InvalidQueryParamErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a valid query parameter name", value, ApiKeyQueryParamAnnotation)
+	}
+	ConflictingSourceErr = errors.Errorf("annotations %s and %s are mutually exclusive", ApiKeyHeaderAnnotation, ApiKeyQueryParamAnnotation)
+	MissingSecretErr     = func(value string, err error) error {
+		return errors.Wrapf(err, "cannot resolve secret '%s' of annotation %s", value, ApiKeySecretsAnnotation)
+	}
+	NoApiKeysErr                      = errors.Errorf("none of the secrets of annotation %s holds an API key", ApiKeySecretsAnnotation)
+	ConflictingExtAuthErr             = errors.Errorf("annotation %s cannot be combined with the extauth option of the RouteOption", ApiKeySecretsAnnotation)
+	ConflictingEarlyTransformationErr = errors.Errorf("annotation %s cannot be combined with early transformations of the RouteOption", ApiKeyQueryParamAnnotation)
+)
+
+var (
+	_ plugins.RoutePlugin           = &plugin{}
+	_ plugins.PostTranslationPlugin = &plugin{}
+	_ plugins.RouteOptionValidator  = &plugin{}
+	_ plugins.DependentPlugin       = &plugin{}
+)
+
+// Plugins are created for each translation, so the AuthConfigs generated while translating routes
+// are those referenced by the translated Proxies.
+type plugin struct {
+	queries query.GatewayQueries
+	// AuthConfigs generated for the routes translated so far, keyed by the RouteOption they are generated from
+	authConfigs map[types.NamespacedName]*extauthv1.AuthConfig
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries:     queries,
+		authConfigs: map[types.NamespacedName]*extauthv1.AuthConfig{},
+	}
+}
+
+// DependsOn the routeoptions plugin, which would otherwise replace the extauth config of the route
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+	annotations := routeOption.GetAnnotations()
+	if _, ok := annotations[ApiKeySecretsAnnotation]; !ok {
+		return nil
+	}
+
+	// the route requires authentication, so if the policy cannot be applied the route must not be served
+	if outputRoute.GetOptions().GetExtauth() != nil {
+		respond(outputRoute, http.StatusInternalServerError)
+		return ConflictingExtAuthErr
+	}
+	header, queryParam, err := getApiKeySource(annotations)
+	if err != nil {
+		respond(outputRoute, http.StatusInternalServerError)
+		return err
+	}
+	fromQueryParam := queryParam != ""
+	if fromQueryParam && outputRoute.GetOptions().GetStagedTransformations().GetEarly() != nil {
+		respond(outputRoute, http.StatusInternalServerError)
+		return ConflictingEarlyTransformationErr
+	}
+
+	key := types.NamespacedName{Namespace: routeOption.GetNamespace(), Name: routeOption.GetName()}
+	authConfig, ok := p.authConfigs[key]
+	if !ok {
+		secretRefs, err := p.getApiKeySecrets(ctx, annotations, p.queries.ObjToFrom(routeOption))
+		if err != nil {
+			respond(outputRoute, http.StatusInternalServerError)
+			return err
+		}
+		if len(secretRefs) == 0 {
+			// no request can carry a valid API key
+			respond(outputRoute, http.StatusUnauthorized)
+			return NoApiKeysErr
+		}
+		authConfig = &extauthv1.AuthConfig{
+			Metadata: &core.Metadata{
+				Name:      fmt.Sprintf("%s-api-key", key.Name),
+				Namespace: key.Namespace,
+			},
+			Configs: []*extauthv1.AuthConfig_Config{{
+				AuthConfig: &extauthv1.AuthConfig_Config_ApiKeyAuth{
+					ApiKeyAuth: &extauthv1.ApiKeyAuth{
+						ApiKeySecretRefs: secretRefs,
+						HeaderName:       header,
+					},
+				},
+			}},
+		}
+		p.authConfigs[key] = authConfig
+	}
+
+	if outputRoute.GetOptions() == nil {
+		outputRoute.Options = &v1.RouteOptions{}
+	}
+	outputRoute.GetOptions().Extauth = &extauthv1.ExtAuthExtension{
+		Spec: &extauthv1.ExtAuthExtension_ConfigRef{
+			ConfigRef: authConfig.GetMetadata().Ref(),
+		},
+	}
+	if fromQueryParam {
+		setQueryParamTransformation(outputRoute.GetOptions(), queryParam, header)
+	}
+	return nil
+}
+
+// ValidateRouteOption does not resolve the secrets of the annotation, which may be created after the RouteOption
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	annotations := routeOption.GetAnnotations()
+	if _, ok := annotations[ApiKeySecretsAnnotation]; !ok {
+		return nil
+	}
+	options := routeOption.Spec.GetOptions()
+	if options.GetExtauth() != nil {
+		return ConflictingExtAuthErr
+	}
+	_, queryParam, err := getApiKeySource(annotations)
+	if err != nil {
+		return err
+	}
+	if queryParam != "" && options.GetStagedTransformations().GetEarly() != nil {
+		return ConflictingEarlyTransformationErr
+	}
+	_, err = utils.GetSecretRefsAnnotation(annotations, ApiKeySecretsAnnotation)
+	return err
+}
+
+// getApiKeySource returns the header gloo reads the API key from, and the query parameter the key is copied from
+// into the header if any
+func getApiKeySource(annotations map[string]string) (string, string, error) {
+	header := defaultHeader
+	queryParam, fromQueryParam := annotations[ApiKeyQueryParamAnnotation]
+	if h, ok := annotations[ApiKeyHeaderAnnotation]; ok {
+		if fromQueryParam {
+			return "", "", ConflictingSourceErr
+		}
+		if !headerRegex.MatchString(h) {
+			return "", "", InvalidHeaderErr(h)
+		}
+		header = strings.ToLower(h)
+	}
+	if fromQueryParam && !queryParamRegex.MatchString(queryParam) {
+		return "", "", InvalidQueryParamErr(queryParam)
+	}
+	return header, queryParam, nil
+}
+
+// getApiKeySecrets resolves the Secrets of the annotation, returning references to those holding an API key
+func (p *plugin) getApiKeySecrets(ctx context.Context, annotations map[string]string, from query.From) ([]*core.ResourceRef, error) {
+	refs, err := utils.GetSecretRefsAnnotation(annotations, ApiKeySecretsAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	var secretRefs []*core.ResourceRef
+	for _, ref := range refs {
+		obj, err := p.queries.GetSecretForRef(ctx, from, *ref)
+		if err != nil {
+			return nil, MissingSecretErr(string(ref.Name), err)
+		}
+		// gloo ignores secrets which are not API key secrets
+		secret, ok := obj.(*corev1.Secret)
+		if !ok || secret.Type != kubeconverters.APIKeySecretType {
+			continue
+		}
+		if _, ok := secret.Data[kubeconverters.APIKeyDataKey]; !ok {
+			continue
+		}
+		secretRefs = append(secretRefs, &core.ResourceRef{
+			Name:      secret.GetName(),
+			Namespace: secret.GetNamespace(),
+		})
+	}
+	return secretRefs, nil
+}
+
+// setQueryParamTransformation copies the API key from the query parameter to the header before ext auth runs,
+// as gloo only extracts API keys from headers
+func setQueryParamTransformation(options *v1.RouteOptions, queryParam, header string) {
+	if options.GetStagedTransformations() == nil {
+		options.StagedTransformations = &transformation.TransformationStages{}
+	}
+	options.GetStagedTransformations().Early = &transformation.RequestResponseTransformations{
+		RequestTransforms: []*transformation.RequestMatch{{
+			RequestTransformation: &transformation.Transformation{
+				TransformationType: &transformation.Transformation_TransformationTemplate{
+					TransformationTemplate: &transformation.TransformationTemplate{
+						Extractors: map[string]*transformation.Extraction{
+							"api_key": {
+								Source:   &transformation.Extraction_Header{Header: ":path"},
+								Regex:    fmt.Sprintf(`[^?]*\?(.*&)?%s=([^&]*).*`, regexp.QuoteMeta(queryParam)),
+								Subgroup: 2,
+							},
+						},
+						Headers: map[string]*transformation.InjaTemplate{
+							header: {Text: "{{ api_key }}"},
+						},
+						BodyTransformation: &transformation.TransformationTemplate_Passthrough{
+							Passthrough: &transformation.Passthrough{},
+						},
+					},
+				},
+			},
+		}},
+	}
+}
+
+func (p *plugin) ApplyPostTranslationPlugin(
+	ctx context.Context,
+	postTranslationContext *plugins.PostTranslationContext,
+) error {
+	keys := make([]types.NamespacedName, 0, len(p.authConfigs))
+	for key := range p.authConfigs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	for _, key := range keys {
+		postTranslationContext.AuthConfigs = append(postTranslationContext.AuthConfigs, p.authConfigs[key])
+	}
+	return nil
+}
+
+func respond(route *v1.Route, status uint32) {
+	route.Action = &v1.Route_DirectResponseAction{
+		DirectResponseAction: &v1.DirectResponseAction{
+			Status: status,
+		},
+	}
+}
@@ -0,0 +1,251 @@
+package apikey
+
+import (
+	"context"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	kubeconverters "github.com/solo-io/gloo/projects/gloo/pkg/api/converters/kube"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	extauthv1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/extauth/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/transformation"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("ApiKeyPlugin", func() {
+	var (
+		annotations map[string]string
+		objs        []client.Object
+		p           *plugin
+	)
+
+	BeforeEach(func() {
+		annotations = map[string]string{
+			ApiKeySecretsAnnotation: "key-1, key-2",
+		}
+		objs = []client.Object{apiKeySecret("key-1"), apiKeySecret("key-2")}
+		p = nil
+	})
+
+	translateRoute := func(options *v1.RouteOptions) (*v1.Route, error) {
+		if p == nil {
+			p = NewPlugin(testutils.BuildGatewayQueries(append(objs, testutils.RouteOption(annotations))))
+		}
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: &gwv1.HTTPRouteRule{
+				Filters: []gwv1.HTTPRouteFilter{{
+					Type: gwv1.HTTPRouteFilterExtensionRef,
+					ExtensionRef: &gwv1.LocalObjectReference{
+						Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Name:  "policy",
+					},
+				}},
+			},
+		}
+		outputRoute := &v1.Route{
+			Action: &v1.Route_RouteAction{
+				RouteAction: &v1.RouteAction{},
+			},
+			Options: options,
+		}
+		err := p.ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+		return outputRoute, err
+	}
+
+	translateAuthConfigs := func() extauthv1.AuthConfigList {
+		postTranslationContext := &plugins.PostTranslationContext{}
+		Expect(p.ApplyPostTranslationPlugin(context.Background(), postTranslationContext)).To(Succeed())
+		return postTranslationContext.AuthConfigs
+	}
+
+	expectAuthConfig := func(header string) {
+		authConfigs := translateAuthConfigs()
+		Expect(authConfigs).To(HaveLen(1))
+		Expect(proto.Equal(authConfigs[0], &extauthv1.AuthConfig{
+			Metadata: &core.Metadata{Name: "policy-api-key", Namespace: "default"},
+			Configs: []*extauthv1.AuthConfig_Config{{
+				AuthConfig: &extauthv1.AuthConfig_Config_ApiKeyAuth{
+					ApiKeyAuth: &extauthv1.ApiKeyAuth{
+						ApiKeySecretRefs: []*core.ResourceRef{
+							{Name: "key-1", Namespace: "default"},
+							{Name: "key-2", Namespace: "default"},
+						},
+						HeaderName: header,
+					},
+				},
+			}},
+		})).To(BeTrue())
+	}
+
+	expectStatus := func(route *v1.Route, status int) {
+		Expect(route.GetDirectResponseAction().GetStatus()).To(Equal(uint32(status)))
+		Expect(translateAuthConfigs()).To(BeEmpty())
+	}
+
+	configRef := &extauthv1.ExtAuthExtension{
+		Spec: &extauthv1.ExtAuthExtension_ConfigRef{
+			ConfigRef: &core.ResourceRef{Name: "policy-api-key", Namespace: "default"},
+		},
+	}
+
+	It("extracts API keys from the default header", func() {
+		route, err := translateRoute(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetRouteAction()).NotTo(BeNil())
+		Expect(proto.Equal(route.GetOptions().GetExtauth(), configRef)).To(BeTrue())
+		Expect(route.GetOptions().GetStagedTransformations()).To(BeNil())
+		expectAuthConfig("api-key")
+	})
+
+	It("extracts API keys from a custom header", func() {
+		annotations[ApiKeyHeaderAnnotation] = "X-Api-Key"
+		route, err := translateRoute(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proto.Equal(route.GetOptions().GetExtauth(), configRef)).To(BeTrue())
+		expectAuthConfig("x-api-key")
+	})
+
+	It("extracts API keys from a query parameter", func() {
+		annotations[ApiKeyQueryParamAnnotation] = "api.key"
+		route, err := translateRoute(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proto.Equal(route.GetOptions().GetExtauth(), configRef)).To(BeTrue())
+		Expect(proto.Equal(route.GetOptions().GetStagedTransformations(), &transformation.TransformationStages{
+			Early: &transformation.RequestResponseTransformations{
+				RequestTransforms: []*transformation.RequestMatch{{
+					RequestTransformation: &transformation.Transformation{
+						TransformationType: &transformation.Transformation_TransformationTemplate{
+							TransformationTemplate: &transformation.TransformationTemplate{
+								Extractors: map[string]*transformation.Extraction{
+									"api_key": {
+										Source:   &transformation.Extraction_Header{Header: ":path"},
+										Regex:    `[^?]*\?(.*&)?api\.key=([^&]*).*`,
+										Subgroup: 2,
+									},
+								},
+								Headers: map[string]*transformation.InjaTemplate{
+									"api-key": {Text: "{{ api_key }}"},
+								},
+								BodyTransformation: &transformation.TransformationTemplate_Passthrough{
+									Passthrough: &transformation.Passthrough{},
+								},
+							},
+						},
+					},
+				}},
+			},
+		})).To(BeTrue())
+		expectAuthConfig("api-key")
+	})
+
+	It("generates a single AuthConfig for routes sharing the RouteOption", func() {
+		_, err := translateRoute(nil)
+		Expect(err).NotTo(HaveOccurred())
+		route, err := translateRoute(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proto.Equal(route.GetOptions().GetExtauth(), configRef)).To(BeTrue())
+		expectAuthConfig("api-key")
+	})
+
+	It("responds unauthorized when no secret holds an API key", func() {
+		opaque := apiKeySecret("key-1")
+		opaque.Type = corev1.SecretTypeOpaque
+		noKey := apiKeySecret("key-2")
+		noKey.Data = map[string][]byte{"user": []byte("alice")}
+		objs = []client.Object{opaque, noKey}
+		route, err := translateRoute(nil)
+		Expect(err).To(MatchError(NoApiKeysErr))
+		expectStatus(route, http.StatusUnauthorized)
+	})
+
+	It("fails closed when a secret does not exist", func() {
+		objs = []client.Object{apiKeySecret("key-1")}
+		route, err := translateRoute(nil)
+		Expect(err).To(MatchError(ContainSubstring("cannot resolve secret 'key-2'")))
+		expectStatus(route, http.StatusInternalServerError)
+	})
+
+	It("fails closed when the RouteOption already sets extauth", func() {
+		route, err := translateRoute(&v1.RouteOptions{
+			Extauth: &extauthv1.ExtAuthExtension{
+				Spec: &extauthv1.ExtAuthExtension_Disable{Disable: true},
+			},
+		})
+		Expect(err).To(MatchError(ConflictingExtAuthErr))
+		expectStatus(route, http.StatusInternalServerError)
+	})
+
+	It("fails closed when the RouteOption already sets early transformations", func() {
+		annotations[ApiKeyQueryParamAnnotation] = "key"
+		route, err := translateRoute(&v1.RouteOptions{
+			StagedTransformations: &transformation.TransformationStages{
+				Early: &transformation.RequestResponseTransformations{},
+			},
+		})
+		Expect(err).To(MatchError(ConflictingEarlyTransformationErr))
+		expectStatus(route, http.StatusInternalServerError)
+	})
+
+	It("does nothing without the secrets annotation", func() {
+		delete(annotations, ApiKeySecretsAnnotation)
+		annotations[ApiKeyHeaderAnnotation] = "x-api-key"
+		route, err := translateRoute(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetRouteAction()).NotTo(BeNil())
+		Expect(route.GetOptions().GetExtauth()).To(BeNil())
+		Expect(translateAuthConfigs()).To(BeEmpty())
+	})
+
+	DescribeTable("rejects invalid API key policies",
+		func(extraAnnotations map[string]string, expectedErr string) {
+			for k, v := range extraAnnotations {
+				annotations[k] = v
+			}
+			route, err := translateRoute(nil)
+			Expect(err).To(MatchError(expectedErr))
+			expectStatus(route, http.StatusInternalServerError)
+		},
+		Entry("invalid secret",
+			map[string]string{ApiKeySecretsAnnotation: "key-1,"},
+			utils.InvalidSecretRefErr(ApiKeySecretsAnnotation, "").Error()),
+		Entry("invalid header",
+			map[string]string{ApiKeyHeaderAnnotation: "api key"},
+			InvalidHeaderErr("api key").Error()),
+		Entry("invalid query parameter",
+			map[string]string{ApiKeyQueryParamAnnotation: "key&x"},
+			InvalidQueryParamErr("key&x").Error()),
+		Entry("header and query parameter",
+			map[string]string{ApiKeyHeaderAnnotation: "x-api-key", ApiKeyQueryParamAnnotation: "key"},
+			ConflictingSourceErr.Error()),
+	)
+})
+
+func apiKeySecret(name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Type: kubeconverters.APIKeySecretType,
+		Data: map[string][]byte{
+			kubeconverters.APIKeyDataKey: []byte(name),
+		},
+	}
+}
@@ -0,0 +1,13 @@
+package apikey
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestApiKeyPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Api Key Plugin Suite")
+}
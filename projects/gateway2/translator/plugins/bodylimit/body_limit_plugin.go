@@ -0,0 +1,163 @@
+package bodylimit
+
+import (
+	"context"
+	"math"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	buffer "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/extensions/filters/http/buffer/v3"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// The annotations of this package cannot be combined with the bufferPerRoute option of a RouteOption: the option
+// takes precedence, and the conflict is reported on the routes the RouteOption is applied to.
+const (
+	// MaxRequestBytesAnnotation is set on a RouteOption to cap the size of request bodies on the routes it is applied to.
+	// The value is a byte count or a Kubernetes quantity, e.g. "8192" or "1Mi".
+	// Response bodies cannot be capped per route: envoy's buffer filter only bounds requests.
+	MaxRequestBytesAnnotation = "gateway2.solo.io/max-request-bytes"
+	// BufferingAnnotation is set on a RouteOption to either "on" to buffer the request bodies of the routes it is
+	// applied to, up to the limit of MaxRequestBytesAnnotation, or "off" to stream them, e.g. for streaming endpoints.
+	// Routes without the annotation are buffered when they set a request limit. Response bodies are always streamed,
+	// as envoy's buffer filter only buffers requests.
+	BufferingAnnotation = "gateway2.solo.io/buffering"
+)
+
+const (
+	BufferingOn  = "on"
+	BufferingOff = "off"
+)
+
+var (
+	InvalidLimitErr = func(annotation, value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a positive byte quantity no greater than %d", value, annotation, math.MaxUint32)
+	}
+	UnknownBufferingErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be one of %s or %s", value, BufferingAnnotation, BufferingOn, BufferingOff)
+	}
+	BufferingLimitRequiredErr = errors.Errorf("annotation %s set to %s requires annotation %s", BufferingAnnotation, BufferingOn, MaxRequestBytesAnnotation)
+	ConflictingBufferingErr   = errors.Errorf("annotation %s set to %s cannot be combined with annotation %s", BufferingAnnotation, BufferingOff, MaxRequestBytesAnnotation)
+
+	ConflictingBufferPerRouteErr = errors.Errorf("annotations %s and %s cannot be combined with the bufferPerRoute option of the RouteOption",
+		MaxRequestBytesAnnotation, BufferingAnnotation)
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+)
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+// DependsOn the routeoptions plugin, which would otherwise replace the buffer limit of the route
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+
+	bufferPerRoute, err := getBufferPerRoute(routeOption.GetAnnotations())
+	if err != nil {
+		return err
+	}
+	if bufferPerRoute != nil {
+		if outputRoute.GetOptions().GetBufferPerRoute() != nil {
+			return ConflictingBufferPerRouteErr
+		}
+		if outputRoute.GetOptions() == nil {
+			outputRoute.Options = &v1.RouteOptions{}
+		}
+		outputRoute.GetOptions().BufferPerRoute = bufferPerRoute
+	}
+	return nil
+}
+
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	bufferPerRoute, err := getBufferPerRoute(routeOption.GetAnnotations())
+	if err != nil {
+		return err
+	}
+	if bufferPerRoute != nil && routeOption.Spec.GetOptions().GetBufferPerRoute() != nil {
+		return ConflictingBufferPerRouteErr
+	}
+	return nil
+}
+
+func getBufferPerRoute(annotations map[string]string) (*buffer.BufferPerRoute, error) {
+	maxRequestBytes, err := parseLimit(annotations, MaxRequestBytesAnnotation)
+	if err != nil {
+		return nil, err
+	}
+
+	var bufferPerRoute *buffer.BufferPerRoute
+	buffering, ok := annotations[BufferingAnnotation]
+	switch {
+	case ok && buffering == BufferingOff:
+		if maxRequestBytes != nil {
+			return nil, ConflictingBufferingErr
+		}
+		bufferPerRoute = &buffer.BufferPerRoute{
+			Override: &buffer.BufferPerRoute_Disabled{
+				Disabled: true,
+			},
+		}
+	case ok && buffering != BufferingOn:
+		return nil, UnknownBufferingErr(buffering)
+	case ok && maxRequestBytes == nil:
+		// envoy cannot buffer without bounding the size of the buffer
+		return nil, BufferingLimitRequiredErr
+	case maxRequestBytes != nil:
+		bufferPerRoute = &buffer.BufferPerRoute{
+			Override: &buffer.BufferPerRoute_Buffer{
+				Buffer: &buffer.Buffer{
+					MaxRequestBytes: maxRequestBytes,
+				},
+			},
+		}
+	}
+	return bufferPerRoute, nil
+}
+
+// parseLimit returns nil if the annotation is not set
+func parseLimit(annotations map[string]string, annotation string) (*wrappers.UInt32Value, error) {
+	value, ok := annotations[annotation]
+	if !ok {
+		return nil, nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return nil, InvalidLimitErr(annotation, value)
+	}
+	bytes, ok := quantity.AsInt64()
+	if !ok || bytes <= 0 || bytes > math.MaxUint32 {
+		return nil, InvalidLimitErr(annotation, value)
+	}
+	return &wrappers.UInt32Value{Value: uint32(bytes)}, nil
+}
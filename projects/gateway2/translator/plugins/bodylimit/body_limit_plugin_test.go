@@ -0,0 +1,157 @@
+package bodylimit
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	buffer "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/extensions/filters/http/buffer/v3"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("BodyLimitPlugin", func() {
+	applyTo := func(annotations map[string]string, outputRoute *v1.Route) error {
+		queries := testutils.BuildGatewayQueries([]client.Object{testutils.RouteOption(annotations)})
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: &gwv1.HTTPRouteRule{
+				Filters: []gwv1.HTTPRouteFilter{{
+					Type: gwv1.HTTPRouteFilterExtensionRef,
+					ExtensionRef: &gwv1.LocalObjectReference{
+						Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Name:  "policy",
+					},
+				}},
+			},
+		}
+		return NewPlugin(queries).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+	}
+
+	apply := func(annotations map[string]string) (*v1.Route, error) {
+		outputRoute := &v1.Route{
+			Options: &v1.RouteOptions{},
+		}
+		err := applyTo(annotations, outputRoute)
+		return outputRoute, err
+	}
+
+	bufferLimit := func(bytes uint32) *buffer.BufferPerRoute {
+		return &buffer.BufferPerRoute{
+			Override: &buffer.BufferPerRoute_Buffer{
+				Buffer: &buffer.Buffer{
+					MaxRequestBytes: &wrappers.UInt32Value{Value: bytes},
+				},
+			},
+		}
+	}
+
+	It("applies a request-only limit", func() {
+		route, err := apply(map[string]string{
+			MaxRequestBytesAnnotation: "1Mi",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proto.Equal(route.GetOptions().GetBufferPerRoute(), bufferLimit(1048576))).To(BeTrue())
+	})
+
+	It("buffers requests up to the limit when buffering is on", func() {
+		route, err := apply(map[string]string{
+			BufferingAnnotation:       BufferingOn,
+			MaxRequestBytesAnnotation: "64Ki",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proto.Equal(route.GetOptions().GetBufferPerRoute(), bufferLimit(65536))).To(BeTrue())
+	})
+
+	It("streams requests when buffering is off", func() {
+		route, err := apply(map[string]string{
+			BufferingAnnotation: BufferingOff,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proto.Equal(route.GetOptions().GetBufferPerRoute(), &buffer.BufferPerRoute{
+			Override: &buffer.BufferPerRoute_Disabled{
+				Disabled: true,
+			},
+		})).To(BeTrue())
+	})
+
+	It("does nothing when no limits are set", func() {
+		route, err := apply(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetBufferPerRoute()).To(BeNil())
+	})
+
+	It("keeps the bufferPerRoute option of the RouteOption, rejecting the annotations", func() {
+		route := &v1.Route{
+			Options: &v1.RouteOptions{
+				BufferPerRoute: bufferLimit(1024),
+			},
+		}
+		err := applyTo(map[string]string{MaxRequestBytesAnnotation: "1Mi"}, route)
+		Expect(err).To(MatchError(ConflictingBufferPerRouteErr))
+		Expect(proto.Equal(route.GetOptions().GetBufferPerRoute(), bufferLimit(1024))).To(BeTrue())
+	})
+
+	It("keeps the bufferPerRoute option of the RouteOption when turning buffering off", func() {
+		route := &v1.Route{
+			Options: &v1.RouteOptions{
+				BufferPerRoute: bufferLimit(1024),
+			},
+		}
+		err := applyTo(map[string]string{BufferingAnnotation: BufferingOff}, route)
+		Expect(err).To(MatchError(ConflictingBufferPerRouteErr))
+		Expect(proto.Equal(route.GetOptions().GetBufferPerRoute(), bufferLimit(1024))).To(BeTrue())
+	})
+
+	It("validates the RouteOption against its bufferPerRoute option", func() {
+		routeOption := testutils.RouteOption(map[string]string{BufferingAnnotation: BufferingOff})
+		Expect(NewPlugin(nil).ValidateRouteOption(context.Background(), routeOption)).To(Succeed())
+
+		routeOption.Spec.Options = &v1.RouteOptions{BufferPerRoute: bufferLimit(1024)}
+		Expect(NewPlugin(nil).ValidateRouteOption(context.Background(), routeOption)).To(MatchError(ConflictingBufferPerRouteErr))
+	})
+
+	DescribeTable("rejects invalid limits",
+		func(annotation, value string) {
+			route, err := apply(map[string]string{
+				annotation: value,
+			})
+			Expect(err).To(MatchError(InvalidLimitErr(annotation, value).Error()))
+			Expect(route.GetOptions().GetBufferPerRoute()).To(BeNil())
+		},
+		Entry("unparseable request limit", MaxRequestBytesAnnotation, "lots"),
+		Entry("zero request limit", MaxRequestBytesAnnotation, "0"),
+		Entry("negative request limit", MaxRequestBytesAnnotation, "-1"),
+		Entry("request limit overflowing uint32", MaxRequestBytesAnnotation, "8Gi"),
+	)
+
+	DescribeTable("rejects invalid buffering",
+		func(annotations map[string]string, expectedErr error) {
+			route, err := apply(annotations)
+			Expect(err).To(MatchError(expectedErr.Error()))
+			Expect(route.GetOptions().GetBufferPerRoute()).To(BeNil())
+		},
+		Entry("unknown buffering",
+			map[string]string{BufferingAnnotation: "true"},
+			UnknownBufferingErr("true")),
+		Entry("buffering on without a limit",
+			map[string]string{BufferingAnnotation: BufferingOn},
+			BufferingLimitRequiredErr),
+		Entry("buffering off with a limit",
+			map[string]string{BufferingAnnotation: BufferingOff, MaxRequestBytesAnnotation: "1Mi"},
+			ConflictingBufferingErr),
+	)
+})
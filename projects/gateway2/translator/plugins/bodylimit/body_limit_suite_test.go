@@ -0,0 +1,13 @@
+package bodylimit
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestBodyLimitPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "BodyLimit Plugin Suite")
+}
@@ -0,0 +1,239 @@
+package canary
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// CanaryAnnotationPrefix is suffixed with the name of a Gateway Listener to send part of its traffic to the
+// configuration of another Listener of the same Gateway, e.g. `gateway2.solo.io/canary-blue: green=10`.
+//
+// The weight is the percentage of requests sent to the canary Listener's configuration. For every route of
+// the primary Listener, the canary Listener is searched for a route with the same domain and matchers;
+// when one exists, the primary route's destinations are split with the canary route's destinations by weight.
+// Routes that exist on only one of the Listeners are left untouched, and the canary Listener keeps serving
+// its own traffic, so it can be tested directly before taking a share of the primary's traffic.
+// The Listeners must be translated into distinct Gloo Listeners, i.e. be served on different ports.
+const CanaryAnnotationPrefix = "gateway2.solo.io/canary-"
+
+const maxWeight = 100
+
+var (
+	InvalidCanaryErr = func(annotation, value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be of the form <listener>=<weight> with a weight between 0 and %d", value, annotation, maxWeight)
+	}
+	CanaryListenerNotFoundErr = func(annotation, listener string) error {
+		return errors.Errorf("canary listener '%s' of annotation %s was not translated", listener, annotation)
+	}
+	CanarySharesListenerErr = func(annotation, listener string) error {
+		return errors.Errorf("canary listener '%s' of annotation %s must be served on a different port than its primary", listener, annotation)
+	}
+	WeightOverflowErr = errors.Errorf("canary weights overflow")
+)
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	for _, gwListener := range listenerCtx.GatewayListeners {
+		annotation := CanaryAnnotationPrefix + string(gwListener.Name)
+		value, ok := listenerCtx.Gateway.GetAnnotations()[annotation]
+		if !ok {
+			continue
+		}
+		canaryName, weight, err := parseCanary(annotation, value)
+		if err != nil {
+			return err
+		}
+		canaryListener, ok := listenerCtx.TranslatedListeners[canaryName]
+		if !ok {
+			return CanaryListenerNotFoundErr(annotation, canaryName)
+		}
+		if canaryListener == outputListener {
+			return CanarySharesListenerErr(annotation, canaryName)
+		}
+		if weight == 0 {
+			continue
+		}
+		if err := splitRoutes(outputListener, canaryListener, weight); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseCanary(annotation, value string) (string, uint64, error) {
+	listener, weightStr, ok := strings.Cut(value, "=")
+	if !ok || listener == "" {
+		return "", 0, InvalidCanaryErr(annotation, value)
+	}
+	weight, err := strconv.ParseUint(weightStr, 10, 32)
+	if err != nil || weight > maxWeight {
+		return "", 0, InvalidCanaryErr(annotation, value)
+	}
+	return listener, weight, nil
+}
+
+func splitRoutes(primary, canary *v1.Listener, weight uint64) error {
+	canaryVhosts := canary.GetAggregateListener().GetHttpResources().GetVirtualHosts()
+	for _, vhost := range primary.GetAggregateListener().GetHttpResources().GetVirtualHosts() {
+		for _, route := range vhost.GetRoutes() {
+			canaryRoute := findCanaryRoute(canaryVhosts, vhost.GetDomains(), route)
+			if canaryRoute == nil {
+				continue
+			}
+			if err := splitDestinations(route.GetRouteAction(), canaryRoute.GetRouteAction(), weight); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func findCanaryRoute(canaryVhosts map[string]*v1.VirtualHost, domains []string, route *v1.Route) *v1.Route {
+	if route.GetRouteAction() == nil {
+		return nil
+	}
+	for _, vhost := range canaryVhosts {
+		if !sharesDomain(vhost.GetDomains(), domains) {
+			continue
+		}
+		for _, canaryRoute := range vhost.GetRoutes() {
+			if canaryRoute.GetRouteAction() != nil && matchersEqual(canaryRoute, route) {
+				return canaryRoute
+			}
+		}
+	}
+	return nil
+}
+
+func sharesDomain(a, b []string) bool {
+	for _, da := range a {
+		for _, db := range b {
+			if da == db {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchersEqual(a, b *v1.Route) bool {
+	if len(a.GetMatchers()) != len(b.GetMatchers()) {
+		return false
+	}
+	for i := range a.GetMatchers() {
+		if !proto.Equal(a.GetMatchers()[i], b.GetMatchers()[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitDestinations replaces the primary route action's destinations with the destinations of both actions,
+// keeping the relative weights within each action while giving the canary action `weight` percent of the total.
+func splitDestinations(primary, canary *v1.RouteAction, weight uint64) error {
+	primaryDests := weightedDestinations(primary)
+	canaryDests := weightedDestinations(canary)
+	if len(primaryDests) == 0 || len(canaryDests) == 0 {
+		return nil
+	}
+	primaryTotal, canaryTotal := totalWeight(primaryDests), totalWeight(canaryDests)
+	if primaryTotal == 0 || canaryTotal == 0 {
+		return nil
+	}
+
+	var (
+		dests   []*v1.WeightedDestination
+		weights []uint64
+	)
+	// cross-multiplying by the other action's total weight puts both actions on a common scale
+	for _, dest := range primaryDests {
+		if w := uint64(dest.GetWeight().GetValue()) * (maxWeight - weight) * canaryTotal; w > 0 {
+			dests = append(dests, dest)
+			weights = append(weights, w)
+		}
+	}
+	for _, dest := range canaryDests {
+		if w := uint64(dest.GetWeight().GetValue()) * weight * primaryTotal; w > 0 {
+			dests = append(dests, dest)
+			weights = append(weights, w)
+		}
+	}
+
+	// weights are kept on the percentage scale when they fit, which keeps them readable in the common cases
+	divisor := uint64(1)
+	for _, w := range weights {
+		if w > math.MaxUint32 {
+			divisor = weights[0]
+			for _, w := range weights[1:] {
+				divisor = gcd(divisor, w)
+			}
+			break
+		}
+	}
+	for i, dest := range dests {
+		w := weights[i] / divisor
+		if w > math.MaxUint32 {
+			return WeightOverflowErr
+		}
+		dest.Weight = &wrappers.UInt32Value{Value: uint32(w)}
+	}
+
+	primary.Destination = &v1.RouteAction_Multi{
+		Multi: &v1.MultiDestination{
+			Destinations: dests,
+		},
+	}
+	return nil
+}
+
+// weightedDestinations returns copies of the destinations of the action, or nil for actions not routing to destinations
+func weightedDestinations(action *v1.RouteAction) []*v1.WeightedDestination {
+	switch dest := action.GetDestination().(type) {
+	case *v1.RouteAction_Single:
+		return []*v1.WeightedDestination{{
+			Destination: proto.Clone(dest.Single).(*v1.Destination),
+			Weight:      &wrappers.UInt32Value{Value: 1},
+		}}
+	case *v1.RouteAction_Multi:
+		var dests []*v1.WeightedDestination
+		for _, d := range dest.Multi.GetDestinations() {
+			dests = append(dests, proto.Clone(d).(*v1.WeightedDestination))
+		}
+		return dests
+	}
+	return nil
+}
+
+func totalWeight(dests []*v1.WeightedDestination) uint64 {
+	var total uint64
+	for _, dest := range dests {
+		total += uint64(dest.GetWeight().GetValue())
+	}
+	return total
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,184 @@
+package canary
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/core/matchers"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("CanaryPlugin", func() {
+	var (
+		annotations     map[string]string
+		primaryListener *v1.Listener
+		canaryListener  *v1.Listener
+	)
+
+	BeforeEach(func() {
+		annotations = map[string]string{
+			CanaryAnnotationPrefix + "blue": "green=10",
+		}
+		primaryListener = httpListener("blue", "/", &v1.RouteAction{
+			Destination: &v1.RouteAction_Single{Single: upstream("blue-v1")},
+		})
+		canaryListener = httpListener("green", "/", &v1.RouteAction{
+			Destination: &v1.RouteAction_Single{Single: upstream("green-v1")},
+		})
+	})
+
+	apply := func() error {
+		listenerCtx := &plugins.ListenerContext{
+			Gateway: &gwv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+			},
+			GatewayListeners: []gwv1.Listener{{Name: "blue"}},
+			TranslatedListeners: map[string]*v1.Listener{
+				"blue":  primaryListener,
+				"green": canaryListener,
+			},
+		}
+		return NewPlugin().ApplyListenerPlugin(context.Background(), listenerCtx, primaryListener)
+	}
+
+	destinations := func(listener *v1.Listener) map[string]uint32 {
+		weights := map[string]uint32{}
+		route := listener.GetAggregateListener().GetHttpResources().GetVirtualHosts()["vhost"].GetRoutes()[0]
+		for _, dest := range route.GetRouteAction().GetMulti().GetDestinations() {
+			weights[dest.GetDestination().GetUpstream().GetName()] = dest.GetWeight().GetValue()
+		}
+		return weights
+	}
+
+	It("splits single destinations by the canary weight", func() {
+		Expect(apply()).To(Succeed())
+		Expect(destinations(primaryListener)).To(Equal(map[string]uint32{
+			"blue-v1":  90,
+			"green-v1": 10,
+		}))
+		// the canary listener keeps serving its own configuration
+		canaryRoute := canaryListener.GetAggregateListener().GetHttpResources().GetVirtualHosts()["vhost"].GetRoutes()[0]
+		Expect(canaryRoute.GetRouteAction().GetSingle().GetUpstream().GetName()).To(Equal("green-v1"))
+	})
+
+	It("keeps the relative weights of multiple destinations", func() {
+		primaryListener = httpListener("blue", "/", &v1.RouteAction{
+			Destination: &v1.RouteAction_Multi{Multi: &v1.MultiDestination{
+				Destinations: []*v1.WeightedDestination{
+					weighted("blue-v1", 3),
+					weighted("blue-v2", 1),
+				},
+			}},
+		})
+		canaryListener = httpListener("green", "/", &v1.RouteAction{
+			Destination: &v1.RouteAction_Multi{Multi: &v1.MultiDestination{
+				Destinations: []*v1.WeightedDestination{
+					weighted("green-v1", 1),
+					weighted("green-v2", 1),
+				},
+			}},
+		})
+		annotations[CanaryAnnotationPrefix+"blue"] = "green=50"
+
+		Expect(apply()).To(Succeed())
+		Expect(destinations(primaryListener)).To(Equal(map[string]uint32{
+			"blue-v1":  300,
+			"blue-v2":  100,
+			"green-v1": 200,
+			"green-v2": 200,
+		}))
+	})
+
+	It("sends all traffic to the canary at full weight", func() {
+		annotations[CanaryAnnotationPrefix+"blue"] = "green=100"
+		Expect(apply()).To(Succeed())
+		Expect(destinations(primaryListener)).To(Equal(map[string]uint32{
+			"green-v1": 100,
+		}))
+	})
+
+	It("leaves routes without a matching canary route untouched", func() {
+		canaryListener = httpListener("green", "/other", &v1.RouteAction{
+			Destination: &v1.RouteAction_Single{Single: upstream("green-v1")},
+		})
+		Expect(apply()).To(Succeed())
+		route := primaryListener.GetAggregateListener().GetHttpResources().GetVirtualHosts()["vhost"].GetRoutes()[0]
+		Expect(route.GetRouteAction().GetSingle().GetUpstream().GetName()).To(Equal("blue-v1"))
+	})
+
+	It("leaves the listener untouched at zero weight", func() {
+		annotations[CanaryAnnotationPrefix+"blue"] = "green=0"
+		Expect(apply()).To(Succeed())
+		route := primaryListener.GetAggregateListener().GetHttpResources().GetVirtualHosts()["vhost"].GetRoutes()[0]
+		Expect(route.GetRouteAction().GetSingle().GetUpstream().GetName()).To(Equal("blue-v1"))
+	})
+
+	It("errors when the canary listener was not translated", func() {
+		annotations[CanaryAnnotationPrefix+"blue"] = "red=10"
+		Expect(apply()).To(MatchError(CanaryListenerNotFoundErr(CanaryAnnotationPrefix+"blue", "red").Error()))
+	})
+
+	It("errors when the canary listener shares the primary's listener", func() {
+		canaryListener = primaryListener
+		Expect(apply()).To(MatchError(CanarySharesListenerErr(CanaryAnnotationPrefix+"blue", "green").Error()))
+	})
+
+	DescribeTable("rejects invalid annotations",
+		func(value string) {
+			annotations[CanaryAnnotationPrefix+"blue"] = value
+			Expect(apply()).To(MatchError(InvalidCanaryErr(CanaryAnnotationPrefix+"blue", value).Error()))
+		},
+		Entry("missing weight", "green"),
+		Entry("missing listener", "=10"),
+		Entry("non-numeric weight", "green=ten"),
+		Entry("weight above 100", "green=101"),
+	)
+})
+
+func httpListener(name, prefix string, action *v1.RouteAction) *v1.Listener {
+	return &v1.Listener{
+		Name: name,
+		ListenerType: &v1.Listener_AggregateListener{
+			AggregateListener: &v1.AggregateListener{
+				HttpResources: &v1.AggregateListener_HttpResources{
+					VirtualHosts: map[string]*v1.VirtualHost{
+						"vhost": {
+							Name:    "vhost",
+							Domains: []string{"example.com"},
+							Routes: []*v1.Route{{
+								Matchers: []*matchers.Matcher{{
+									PathSpecifier: &matchers.Matcher_Prefix{Prefix: prefix},
+								}},
+								Action: &v1.Route_RouteAction{RouteAction: action},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func upstream(name string) *v1.Destination {
+	return &v1.Destination{
+		DestinationType: &v1.Destination_Upstream{
+			Upstream: &core.ResourceRef{Name: name, Namespace: "default"},
+		},
+	}
+}
+
+func weighted(name string, weight uint32) *v1.WeightedDestination {
+	return &v1.WeightedDestination{
+		Destination: upstream(name),
+		Weight:      &wrappers.UInt32Value{Value: weight},
+	}
+}
@@ -0,0 +1,13 @@
+package canary
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCanaryPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Canary Plugin Suite")
+}
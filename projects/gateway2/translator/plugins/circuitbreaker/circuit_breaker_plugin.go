@@ -0,0 +1,67 @@
+package circuitbreaker
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+)
+
+// Annotations set on a Service to limit the connections and requests sent to the Upstreams discovered from it.
+// Limits that are not set keep envoy's defaults.
+const (
+	// MaxConnectionsAnnotation is the maximum number of connections to the backend
+	MaxConnectionsAnnotation = "gateway2.solo.io/circuit-breaker-max-connections"
+	// MaxPendingRequestsAnnotation is the maximum number of requests queued while waiting for a connection
+	MaxPendingRequestsAnnotation = "gateway2.solo.io/circuit-breaker-max-pending-requests"
+	// MaxRequestsAnnotation is the maximum number of in-flight requests to the backend
+	MaxRequestsAnnotation = "gateway2.solo.io/circuit-breaker-max-requests"
+	// MaxRetriesAnnotation is the maximum number of in-flight retries to the backend
+	MaxRetriesAnnotation = "gateway2.solo.io/circuit-breaker-max-retries"
+)
+
+var _ plugins.BackendPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyBackendPlugin(
+	ctx context.Context,
+	backendCtx *plugins.BackendContext,
+	outputUpstream *v1.Upstream,
+) error {
+	annotations := backendCtx.Service.GetAnnotations()
+
+	circuitBreakers := &v1.CircuitBreakerConfig{}
+	found := false
+	for _, limit := range []struct {
+		annotation string
+		value      **wrappers.UInt32Value
+	}{
+		{MaxConnectionsAnnotation, &circuitBreakers.MaxConnections},
+		{MaxPendingRequestsAnnotation, &circuitBreakers.MaxPendingRequests},
+		{MaxRequestsAnnotation, &circuitBreakers.MaxRequests},
+		{MaxRetriesAnnotation, &circuitBreakers.MaxRetries},
+	} {
+		value, err := utils.GetUint32Annotation(annotations, limit.annotation)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			continue
+		}
+		*limit.value = value
+		found = true
+	}
+	if !found {
+		return nil
+	}
+
+	outputUpstream.CircuitBreakers = circuitBreakers
+	return nil
+}
@@ -0,0 +1,85 @@
+package circuitbreaker
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("CircuitBreakerPlugin", func() {
+	apply := func(annotations map[string]string) (*v1.Upstream, error) {
+		backendCtx := &plugins.BackendContext{
+			Service: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "svc",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+			},
+		}
+		us := &v1.Upstream{}
+		err := NewPlugin().ApplyBackendPlugin(context.Background(), backendCtx, us)
+		return us, err
+	}
+
+	It("translates all limits", func() {
+		us, err := apply(map[string]string{
+			MaxConnectionsAnnotation:     "100",
+			MaxPendingRequestsAnnotation: "50",
+			MaxRequestsAnnotation:        "200",
+			MaxRetriesAnnotation:         "0",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proto.Equal(us.GetCircuitBreakers(), &v1.CircuitBreakerConfig{
+			MaxConnections:     &wrappers.UInt32Value{Value: 100},
+			MaxPendingRequests: &wrappers.UInt32Value{Value: 50},
+			MaxRequests:        &wrappers.UInt32Value{Value: 200},
+			MaxRetries:         &wrappers.UInt32Value{Value: 0},
+		})).To(BeTrue())
+	})
+
+	DescribeTable("translates a single limit",
+		func(annotation string, expected *v1.CircuitBreakerConfig) {
+			us, err := apply(map[string]string{annotation: "7"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(us.GetCircuitBreakers(), expected)).To(BeTrue())
+		},
+		Entry("max connections", MaxConnectionsAnnotation,
+			&v1.CircuitBreakerConfig{MaxConnections: &wrappers.UInt32Value{Value: 7}}),
+		Entry("max pending requests", MaxPendingRequestsAnnotation,
+			&v1.CircuitBreakerConfig{MaxPendingRequests: &wrappers.UInt32Value{Value: 7}}),
+		Entry("max requests", MaxRequestsAnnotation,
+			&v1.CircuitBreakerConfig{MaxRequests: &wrappers.UInt32Value{Value: 7}}),
+		Entry("max retries", MaxRetriesAnnotation,
+			&v1.CircuitBreakerConfig{MaxRetries: &wrappers.UInt32Value{Value: 7}}),
+	)
+
+	It("does nothing without any annotation", func() {
+		us, err := apply(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(us.GetCircuitBreakers()).To(BeNil())
+	})
+
+	DescribeTable("rejects invalid limits",
+		func(annotation, value string) {
+			us, err := apply(map[string]string{
+				MaxConnectionsAnnotation: "100",
+				annotation:               value,
+			})
+			Expect(err).To(MatchError(ContainSubstring(annotation)))
+			Expect(us.GetCircuitBreakers()).To(BeNil())
+		},
+		Entry("negative max connections", MaxConnectionsAnnotation, "-1"),
+		Entry("non-numeric max pending requests", MaxPendingRequestsAnnotation, "many"),
+		Entry("fractional max requests", MaxRequestsAnnotation, "1.5"),
+		Entry("overflowing max retries", MaxRetriesAnnotation, "4294967296"),
+	)
+})
@@ -0,0 +1,13 @@
+package circuitbreaker
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCircuitBreakerPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CircuitBreaker Plugin Suite")
+}
@@ -0,0 +1,143 @@
+package clientvalidation
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/ssl"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// TLS options set in the tls.options of an HTTPS Listener of a Gateway to validate the certificates of its clients.
+//
+// Gloo validates client certificates against the CA bundled with the serving certificate, so the CA Secret must be
+// the certificate Secret of the Listener, with the CA under the ca.crt key.
+const (
+	// ClientValidationOption enables the validation of client certificates: either "require" to reject clients without
+	// a valid certificate, or "optional" to also accept clients without a certificate
+	ClientValidationOption gwv1.AnnotationKey = "gateway2.solo.io/client-validation"
+	// ClientCaSecretOption is the [<namespace>/]<name> of the Secret holding the CA validating the client certificates,
+	// in the namespace of the Gateway by default. Secrets in other namespaces must be allowed by a ReferenceGrant.
+	ClientCaSecretOption gwv1.AnnotationKey = "gateway2.solo.io/client-ca-secret"
+)
+
+const (
+	RequireMode  = "require"
+	OptionalMode = "optional"
+)
+
+var (
+	UnknownModeErr = func(listener, value string) error {
+		return errors.Errorf("invalid value '%s' for TLS option %s of listener %s: must be one of %s or %s", value, ClientValidationOption, listener, RequireMode, OptionalMode)
+	}
+	MissingCaSecretErr = func(listener string) error {
+		return errors.Errorf("TLS option %s of listener %s requires TLS option %s", ClientValidationOption, listener, ClientCaSecretOption)
+	}
+	UnresolvedCaSecretErr = func(listener string, err error) error {
+		return errors.Wrapf(err, "cannot resolve the CA secret of TLS option %s of listener %s", ClientCaSecretOption, listener)
+	}
+	MissingCaErr = func(listener string) error {
+		return errors.Errorf("CA secret of TLS option %s of listener %s has no %s key", ClientCaSecretOption, listener, corev1.ServiceAccountRootCAKey)
+	}
+	SeparateCaSecretUnsupportedErr = func(listener string) error {
+		return errors.Errorf("CA secret of TLS option %s of listener %s cannot be applied: gloo only validates client certificates "+
+			"against the CA of the certificate secret of the listener", ClientCaSecretOption, listener)
+	}
+)
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	for _, gwListener := range listenerCtx.GatewayListeners {
+		if gwListener.TLS == nil {
+			continue
+		}
+		options := map[string]string{}
+		for key, value := range gwListener.TLS.Options {
+			options[string(key)] = string(value)
+		}
+		mode, ok := options[string(ClientValidationOption)]
+		if !ok {
+			continue
+		}
+		listener := string(gwListener.Name)
+		if mode != RequireMode && mode != OptionalMode {
+			return UnknownModeErr(listener, mode)
+		}
+
+		caRef, err := p.resolveCaSecret(ctx, listenerCtx.Gateway, listener, options)
+		if err != nil {
+			return err
+		}
+		var sslConfigs []*ssl.SslConfig
+		for _, fc := range outputListener.GetAggregateListener().GetHttpFilterChains() {
+			sslConfig := fc.GetMatcher().GetSslConfig()
+			if sslConfig == nil || !utils.ServesGatewayListener(listenerCtx, fc, listener) {
+				continue
+			}
+			if sslConfig.GetSecretRef().GetName() != caRef.Name || sslConfig.GetSecretRef().GetNamespace() != caRef.Namespace {
+				return SeparateCaSecretUnsupportedErr(listener)
+			}
+			sslConfigs = append(sslConfigs, sslConfig)
+		}
+		for _, sslConfig := range sslConfigs {
+			sslConfig.OneWayTls = &wrappers.BoolValue{Value: mode == OptionalMode}
+		}
+	}
+	return nil
+}
+
+// resolveCaSecret returns the namespaced name of the CA Secret of the listener, which must hold a CA
+func (p *plugin) resolveCaSecret(
+	ctx context.Context,
+	gateway *gwv1.Gateway,
+	listener string,
+	options map[string]string,
+) (types.NamespacedName, error) {
+	secretRef, err := utils.GetSecretRefAnnotation(options, string(ClientCaSecretOption))
+	if err != nil {
+		return types.NamespacedName{}, err
+	}
+	if secretRef == nil {
+		return types.NamespacedName{}, MissingCaSecretErr(listener)
+	}
+	from := query.FromGkNs{
+		Gk: metav1.GroupKind{
+			Group: gwv1.GroupName,
+			Kind:  "Gateway",
+		},
+		Ns: gateway.GetNamespace(),
+	}
+	obj, err := p.queries.GetSecretForRef(ctx, from, *secretRef)
+	if err != nil {
+		return types.NamespacedName{}, UnresolvedCaSecretErr(listener, err)
+	}
+	secret, ok := obj.(*corev1.Secret)
+	if !ok || len(secret.Data[corev1.ServiceAccountRootCAKey]) == 0 {
+		return types.NamespacedName{}, MissingCaErr(listener)
+	}
+	return client.ObjectKeyFromObject(secret), nil
+}
@@ -0,0 +1,185 @@
+package clientvalidation
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/ssl"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+var _ = Describe("ClientValidationPlugin", func() {
+	var (
+		objs           []client.Object
+		options        map[gwv1.AnnotationKey]gwv1.AnnotationValue
+		outputListener *v1.Listener
+	)
+
+	BeforeEach(func() {
+		objs = []client.Object{secret("default", "cert", true)}
+		options = map[gwv1.AnnotationKey]gwv1.AnnotationValue{
+			ClientValidationOption: RequireMode,
+			ClientCaSecretOption:   "cert",
+		}
+		outputListener = httpsListener("default", "cert")
+	})
+
+	apply := func() error {
+		mode := gwv1.TLSModeTerminate
+		gwListeners := []gwv1.Listener{{
+			Name:     "https",
+			Port:     8443,
+			Protocol: gwv1.HTTPSProtocolType,
+			TLS: &gwv1.GatewayTLSConfig{
+				Mode:    &mode,
+				Options: options,
+			},
+		}}
+		listenerCtx := &plugins.ListenerContext{
+			Gateway: &gwv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "gw",
+					Namespace: "default",
+				},
+				Spec: gwv1.GatewaySpec{
+					Listeners: gwListeners,
+				},
+			},
+			GatewayListeners: gwListeners,
+			VirtualHostListeners: map[string][]string{
+				"https~example.com": {"https"},
+			},
+		}
+		return NewPlugin(testutils.BuildGatewayQueries(objs)).ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+	}
+
+	oneWayTls := func() *wrappers.BoolValue {
+		return outputListener.GetAggregateListener().GetHttpFilterChains()[0].GetMatcher().GetSslConfig().GetOneWayTls()
+	}
+
+	It("requires client certificates", func() {
+		Expect(apply()).To(Succeed())
+		Expect(oneWayTls()).To(Equal(&wrappers.BoolValue{Value: false}))
+	})
+
+	It("accepts clients without certificates in optional mode", func() {
+		options[ClientValidationOption] = OptionalMode
+		Expect(apply()).To(Succeed())
+		Expect(oneWayTls()).To(Equal(&wrappers.BoolValue{Value: true}))
+	})
+
+	It("resolves a CA secret in another namespace allowed by a ReferenceGrant", func() {
+		options[ClientCaSecretOption] = "certs/cert"
+		outputListener = httpsListener("certs", "cert")
+		objs = []client.Object{
+			secret("certs", "cert", true),
+			&gwv1b1.ReferenceGrant{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "grant",
+					Namespace: "certs",
+				},
+				Spec: gwv1b1.ReferenceGrantSpec{
+					From: []gwv1b1.ReferenceGrantFrom{{
+						Group:     gwv1.GroupName,
+						Kind:      "Gateway",
+						Namespace: "default",
+					}},
+					To: []gwv1b1.ReferenceGrantTo{{
+						Group: "",
+						Kind:  "Secret",
+					}},
+				},
+			},
+		}
+		Expect(apply()).To(Succeed())
+		Expect(oneWayTls()).To(Equal(&wrappers.BoolValue{Value: false}))
+	})
+
+	It("does nothing without the validation option", func() {
+		delete(options, ClientValidationOption)
+		Expect(apply()).To(Succeed())
+		Expect(oneWayTls()).To(BeNil())
+	})
+
+	It("rejects a CA secret in another namespace without a ReferenceGrant", func() {
+		options[ClientCaSecretOption] = "certs/cert"
+		outputListener = httpsListener("certs", "cert")
+		objs = []client.Object{secret("certs", "cert", true)}
+		Expect(apply()).To(MatchError(query.ErrMissingReferenceGrant))
+		Expect(oneWayTls()).To(BeNil())
+	})
+
+	DescribeTable("rejects invalid client validation",
+		func(update func(), expectedErr string) {
+			update()
+			Expect(apply()).To(MatchError(ContainSubstring(expectedErr)))
+			Expect(oneWayTls()).To(BeNil())
+		},
+		Entry("unknown mode", func() {
+			options[ClientValidationOption] = "strict"
+		}, UnknownModeErr("https", "strict").Error()),
+		Entry("missing CA secret option", func() {
+			delete(options, ClientCaSecretOption)
+		}, MissingCaSecretErr("https").Error()),
+		Entry("missing CA secret", func() {
+			objs = nil
+		}, "cannot resolve the CA secret"),
+		Entry("secret without CA", func() {
+			objs = []client.Object{secret("default", "cert", false)}
+		}, MissingCaErr("https").Error()),
+		Entry("CA secret other than the certificate secret", func() {
+			options[ClientCaSecretOption] = "ca"
+			objs = append(objs, secret("default", "ca", true))
+		}, SeparateCaSecretUnsupportedErr("https").Error()),
+	)
+})
+
+func httpsListener(namespace, certName string) *v1.Listener {
+	return &v1.Listener{
+		Name:     "https",
+		BindPort: 8443,
+		ListenerType: &v1.Listener_AggregateListener{
+			AggregateListener: &v1.AggregateListener{
+				HttpResources: &v1.AggregateListener_HttpResources{},
+				HttpFilterChains: []*v1.AggregateListener_HttpFilterChain{{
+					Matcher: &v1.Matcher{SslConfig: &ssl.SslConfig{
+						SslSecrets: &ssl.SslConfig_SecretRef{
+							SecretRef: &core.ResourceRef{Name: certName, Namespace: namespace},
+						},
+					}},
+					VirtualHostRefs: []string{"https~example.com"},
+				}},
+			},
+		},
+	}
+}
+
+func secret(namespace, name string, withCa bool) *corev1.Secret {
+	s := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       []byte("cert"),
+			corev1.TLSPrivateKeyKey: []byte("key"),
+		},
+	}
+	if withCa {
+		s.Data[corev1.ServiceAccountRootCAKey] = []byte("ca")
+	}
+	return s
+}
@@ -0,0 +1,13 @@
+package clientvalidation
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestClientValidation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ClientValidation Plugin Suite")
+}
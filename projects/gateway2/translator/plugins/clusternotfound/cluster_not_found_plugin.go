@@ -0,0 +1,118 @@
+package clusternotfound
+
+import (
+	"context"
+	"strconv"
+
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+)
+
+// ClusterNotFoundResponseCodeAnnotation is set on a RouteOption to the status responded by the routes it is applied to
+// when none of their backends resolve, e.g. "404" or "503". Routes without the annotation respond 500.
+// Routes with some of their backends resolved keep responding 500 to the share of requests of the unresolved ones.
+const ClusterNotFoundResponseCodeAnnotation = "gateway2.solo.io/cluster-not-found-response-code"
+
+var InvalidResponseCodeErr = func(value string) error {
+	return errors.Errorf("invalid value '%s' for annotation %s: must be an HTTP status between 200 and 599", value, ClusterNotFoundResponseCodeAnnotation)
+}
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+)
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+// DependsOn the redirect plugin, which rejects routes that already have an action
+func (p *plugin) DependsOn() []string {
+	return []string{"redirect"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+	code, err := getResponseCode(routeOption.GetAnnotations())
+	if err != nil || code == 0 {
+		return err
+	}
+
+	if !hasNoBackends(outputRoute) {
+		return nil
+	}
+	outputRoute.Action = &v1.Route_DirectResponseAction{
+		DirectResponseAction: &v1.DirectResponseAction{
+			Status: code,
+		},
+	}
+	return nil
+}
+
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	_, err := getResponseCode(routeOption.GetAnnotations())
+	return err
+}
+
+// getResponseCode returns 0 if the annotation is not set
+func getResponseCode(annotations map[string]string) (uint32, error) {
+	value, ok := annotations[ClusterNotFoundResponseCodeAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	code, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || code < 200 || code > 599 {
+		return 0, InvalidResponseCodeErr(value)
+	}
+	return uint32(code), nil
+}
+
+// hasNoBackends returns whether the route has no action, or forwards to unresolved backends only
+func hasNoBackends(route *v1.Route) bool {
+	switch action := route.GetAction().(type) {
+	case nil:
+		return true
+	case *v1.Route_RouteAction:
+		// the backends of the route are either a single destination or weighted destinations
+		if single := action.RouteAction.GetSingle(); single != nil {
+			return isBlackhole(single)
+		}
+		destinations := action.RouteAction.GetMulti().GetDestinations()
+		if len(destinations) == 0 {
+			return false
+		}
+		for _, destination := range destinations {
+			if !isBlackhole(destination.GetDestination()) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func isBlackhole(destination *v1.Destination) bool {
+	upstream := destination.GetUpstream()
+	return upstream.GetName() == query.BlackholeUpstreamName && upstream.GetNamespace() == query.BlackholeUpstreamNamespace
+}
@@ -0,0 +1,146 @@
+package clusternotfound
+
+import (
+	"context"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("ClusterNotFoundPlugin", func() {
+	apply := func(annotations map[string]string, outputRoute *v1.Route) error {
+		queries := testutils.BuildGatewayQueries([]client.Object{
+			&solokubev1.RouteOption{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "policy",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+				Spec: sologatewayv1.RouteOption{},
+			},
+		})
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: &gwv1.HTTPRouteRule{
+				Filters: []gwv1.HTTPRouteFilter{{
+					Type: gwv1.HTTPRouteFilterExtensionRef,
+					ExtensionRef: &gwv1.LocalObjectReference{
+						Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Name:  "policy",
+					},
+				}},
+			},
+		}
+		return NewPlugin(queries).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+	}
+
+	destination := func(name, namespace string) *v1.Destination {
+		return &v1.Destination{
+			DestinationType: &v1.Destination_Upstream{
+				Upstream: &core.ResourceRef{
+					Name:      name,
+					Namespace: namespace,
+				},
+			},
+		}
+	}
+	blackhole := func() *v1.Destination {
+		return destination(query.BlackholeUpstreamName, query.BlackholeUpstreamNamespace)
+	}
+	singleRoute := func(destination *v1.Destination) *v1.Route {
+		return &v1.Route{
+			Action: &v1.Route_RouteAction{
+				RouteAction: &v1.RouteAction{
+					Destination: &v1.RouteAction_Single{Single: destination},
+				},
+			},
+		}
+	}
+	multiRoute := func(destinations ...*v1.Destination) *v1.Route {
+		var weighted []*v1.WeightedDestination
+		for _, destination := range destinations {
+			weighted = append(weighted, &v1.WeightedDestination{Destination: destination})
+		}
+		return &v1.Route{
+			Action: &v1.Route_RouteAction{
+				RouteAction: &v1.RouteAction{
+					Destination: &v1.RouteAction_Multi{Multi: &v1.MultiDestination{Destinations: weighted}},
+				},
+			},
+		}
+	}
+	directResponse := func(status uint32) *v1.Route_DirectResponseAction {
+		return &v1.Route_DirectResponseAction{
+			DirectResponseAction: &v1.DirectResponseAction{Status: status},
+		}
+	}
+
+	DescribeTable("responds the configured code when no backend resolves",
+		func(route *v1.Route) {
+			err := apply(map[string]string{
+				ClusterNotFoundResponseCodeAnnotation: "404",
+			}, route)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(route, &v1.Route{Action: directResponse(http.StatusNotFound)})).To(BeTrue())
+		},
+		Entry("unresolved backend", singleRoute(blackhole())),
+		Entry("several unresolved backends", multiRoute(blackhole(), blackhole())),
+		Entry("route without backends", &v1.Route{}),
+	)
+
+	DescribeTable("leaves the route unchanged",
+		func(annotations map[string]string, route *v1.Route) {
+			expected := proto.Clone(route)
+			err := apply(annotations, route)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(route, expected)).To(BeTrue())
+		},
+		Entry("without the annotation",
+			nil,
+			singleRoute(blackhole())),
+		Entry("with a resolved backend",
+			map[string]string{ClusterNotFoundResponseCodeAnnotation: "503"},
+			singleRoute(destination("svc", "default"))),
+		Entry("with some backends resolved",
+			map[string]string{ClusterNotFoundResponseCodeAnnotation: "503"},
+			multiRoute(blackhole(), destination("svc", "default"))),
+		Entry("with a direct response",
+			map[string]string{ClusterNotFoundResponseCodeAnnotation: "503"},
+			&v1.Route{Action: directResponse(http.StatusTeapot)}),
+		Entry("with a redirect",
+			map[string]string{ClusterNotFoundResponseCodeAnnotation: "503"},
+			&v1.Route{Action: &v1.Route_RedirectAction{RedirectAction: &v1.RedirectAction{HostRedirect: "example.com"}}}),
+	)
+
+	DescribeTable("rejects invalid response codes",
+		func(value string) {
+			route := singleRoute(blackhole())
+			err := apply(map[string]string{
+				ClusterNotFoundResponseCodeAnnotation: value,
+			}, route)
+			Expect(err).To(MatchError(InvalidResponseCodeErr(value).Error()))
+			Expect(proto.Equal(route, singleRoute(blackhole()))).To(BeTrue())
+		},
+		Entry("not a number", "not-found"),
+		Entry("below 200", "199"),
+		Entry("above 599", "600"),
+	)
+})
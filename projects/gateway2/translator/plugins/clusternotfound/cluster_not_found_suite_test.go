@@ -0,0 +1,13 @@
+package clusternotfound
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestClusterNotFound(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cluster Not Found Plugin Suite")
+}
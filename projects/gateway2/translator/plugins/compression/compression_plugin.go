@@ -0,0 +1,95 @@
+package compression
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	gzipv2 "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/config/filter/http/gzip/v2"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+)
+
+// Annotations set on a Gateway to compress responses on all of its HTTP listeners.
+// Gloo doesn't support compressing the responses of individual routes.
+const (
+	// CompressionAnnotation enables compression with the algorithm, among "gzip" and "brotli".
+	// Gloo only supports gzip.
+	CompressionAnnotation = "gateway2.solo.io/compression"
+	// CompressionMinContentLengthAnnotation is the minimum size in bytes of compressed responses, defaulting to 30
+	CompressionMinContentLengthAnnotation = "gateway2.solo.io/compression-min-content-length"
+	// CompressionContentTypesAnnotation is a comma-separated list of the content types of compressed responses,
+	// defaulting to common text types
+	CompressionContentTypesAnnotation = "gateway2.solo.io/compression-content-types"
+)
+
+const (
+	Gzip   = "gzip"
+	Brotli = "brotli"
+)
+
+var (
+	contentTypeRegex = regexp.MustCompile(`^[A-Za-z0-9!#$&^_.+\-]+/[A-Za-z0-9!#$&^_.+\-]+$`)
+
+	UnknownAlgorithmErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be one of %s or %s", value, CompressionAnnotation, Gzip, Brotli)
+	}
+	UnsupportedAlgorithmErr = func(value string) error {
+		return errors.Errorf("compression algorithm '%s' of annotation %s is not supported, only %s is", value, CompressionAnnotation, Gzip)
+	}
+	InvalidContentTypeErr = func(value string) error {
+		return errors.Errorf("invalid content type '%s' in annotation %s: must be of the form <type>/<subtype>", value, CompressionContentTypesAnnotation)
+	}
+)
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	annotations := listenerCtx.Gateway.GetAnnotations()
+	algorithm, ok := annotations[CompressionAnnotation]
+	if !ok {
+		return nil
+	}
+	switch algorithm {
+	case Gzip:
+	case Brotli:
+		return UnsupportedAlgorithmErr(algorithm)
+	default:
+		return UnknownAlgorithmErr(algorithm)
+	}
+
+	minContentLength, err := utils.GetUint32Annotation(annotations, CompressionMinContentLengthAnnotation)
+	if err != nil {
+		return err
+	}
+	var contentTypes []string
+	if value, ok := annotations[CompressionContentTypesAnnotation]; ok {
+		for _, contentType := range strings.Split(value, ",") {
+			contentType = strings.TrimSpace(contentType)
+			if !contentTypeRegex.MatchString(contentType) {
+				return InvalidContentTypeErr(contentType)
+			}
+			contentTypes = append(contentTypes, contentType)
+		}
+	}
+
+	for _, options := range utils.GetHttpListenerOptions(outputListener) {
+		options.Gzip = &gzipv2.Gzip{
+			ContentLength: minContentLength,
+			ContentType:   contentTypes,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package compression
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	gzipv2 "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/config/filter/http/gzip/v2"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("CompressionPlugin", func() {
+	var outputListener *v1.Listener
+
+	BeforeEach(func() {
+		outputListener = &v1.Listener{
+			Name: "http",
+			ListenerType: &v1.Listener_AggregateListener{
+				AggregateListener: &v1.AggregateListener{
+					HttpResources: &v1.AggregateListener_HttpResources{},
+					HttpFilterChains: []*v1.AggregateListener_HttpFilterChain{{
+						VirtualHostRefs: []string{"vhost"},
+					}},
+				},
+			},
+		}
+	})
+
+	apply := func(annotations map[string]string) error {
+		listenerCtx := &plugins.ListenerContext{
+			Gateway: &gwv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+			},
+		}
+		return NewPlugin().ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+	}
+
+	gzip := func() *gzipv2.Gzip {
+		aggregateListener := outputListener.GetAggregateListener()
+		ref := aggregateListener.GetHttpFilterChains()[0].GetHttpOptionsRef()
+		return aggregateListener.GetHttpResources().GetHttpOptions()[ref].GetGzip()
+	}
+
+	It("compresses responses with gzip", func() {
+		Expect(apply(map[string]string{
+			CompressionAnnotation:                 Gzip,
+			CompressionMinContentLengthAnnotation: "1024",
+			CompressionContentTypesAnnotation:     "application/json, text/html",
+		})).To(Succeed())
+		Expect(proto.Equal(gzip(), &gzipv2.Gzip{
+			ContentLength: &wrappers.UInt32Value{Value: 1024},
+			ContentType:   []string{"application/json", "text/html"},
+		})).To(BeTrue())
+	})
+
+	It("defaults the content length and types", func() {
+		Expect(apply(map[string]string{
+			CompressionAnnotation: Gzip,
+		})).To(Succeed())
+		Expect(proto.Equal(gzip(), &gzipv2.Gzip{})).To(BeTrue())
+	})
+
+	It("does nothing without the annotation", func() {
+		Expect(apply(map[string]string{
+			CompressionContentTypesAnnotation: "application/json",
+		})).To(Succeed())
+		Expect(outputListener.GetAggregateListener().GetHttpResources().GetHttpOptions()).To(BeEmpty())
+	})
+
+	DescribeTable("rejects invalid compression",
+		func(annotations map[string]string, expectedErr string) {
+			Expect(apply(annotations)).To(MatchError(ContainSubstring(expectedErr)))
+			Expect(outputListener.GetAggregateListener().GetHttpResources().GetHttpOptions()).To(BeEmpty())
+		},
+		Entry("brotli",
+			map[string]string{CompressionAnnotation: Brotli, CompressionContentTypesAnnotation: "application/json"},
+			UnsupportedAlgorithmErr(Brotli).Error()),
+		Entry("unknown algorithm",
+			map[string]string{CompressionAnnotation: "zstd"},
+			UnknownAlgorithmErr("zstd").Error()),
+		Entry("negative min content length",
+			map[string]string{CompressionAnnotation: Gzip, CompressionMinContentLengthAnnotation: "-1"},
+			CompressionMinContentLengthAnnotation),
+		Entry("invalid content type",
+			map[string]string{CompressionAnnotation: Gzip, CompressionContentTypesAnnotation: "application/json,json"},
+			InvalidContentTypeErr("json").Error()),
+	)
+})
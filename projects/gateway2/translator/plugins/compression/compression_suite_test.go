@@ -0,0 +1,13 @@
+package compression
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCompressionPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Compression Plugin Suite")
+}
@@ -0,0 +1,70 @@
+package connectiontimeout
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/hcm"
+)
+
+// Annotations set on a Gateway to close the downstream connections of all of its HTTP listeners.
+// A zero duration, e.g. "0s", leaves envoy's default.
+const (
+	// IdleTimeoutAnnotation is the time after which connections without active requests are closed
+	IdleTimeoutAnnotation = "gateway2.solo.io/idle-timeout"
+	// MaxConnectionDurationAnnotation is the time after which connections are drained and closed,
+	// whether or not they are idle
+	MaxConnectionDurationAnnotation = "gateway2.solo.io/max-connection-duration"
+)
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	annotations := listenerCtx.Gateway.GetAnnotations()
+	idleTimeout, err := getTimeout(annotations, IdleTimeoutAnnotation)
+	if err != nil {
+		return err
+	}
+	maxConnectionDuration, err := getTimeout(annotations, MaxConnectionDurationAnnotation)
+	if err != nil {
+		return err
+	}
+	if idleTimeout == nil && maxConnectionDuration == nil {
+		return nil
+	}
+
+	for _, options := range utils.GetHttpListenerOptions(outputListener) {
+		if options.GetHttpConnectionManagerSettings() == nil {
+			options.HttpConnectionManagerSettings = &hcm.HttpConnectionManagerSettings{}
+		}
+		if idleTimeout != nil {
+			options.GetHttpConnectionManagerSettings().IdleTimeout = idleTimeout
+		}
+		if maxConnectionDuration != nil {
+			options.GetHttpConnectionManagerSettings().MaxConnectionDuration = maxConnectionDuration
+		}
+	}
+	return nil
+}
+
+// getTimeout returns the duration of the annotation, or nil if it is not set or zero
+func getTimeout(annotations map[string]string, annotation string) (*duration.Duration, error) {
+	if d, err := time.ParseDuration(annotations[annotation]); err == nil && d == 0 {
+		return nil, nil
+	}
+	return utils.GetDurationAnnotation(annotations, annotation)
+}
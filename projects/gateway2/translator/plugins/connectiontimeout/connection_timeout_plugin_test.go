@@ -0,0 +1,114 @@
+package connectiontimeout
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/hcm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("ConnectionTimeoutPlugin", func() {
+	var outputListener *v1.Listener
+
+	BeforeEach(func() {
+		outputListener = &v1.Listener{
+			Name: "http",
+			ListenerType: &v1.Listener_AggregateListener{
+				AggregateListener: &v1.AggregateListener{
+					HttpResources: &v1.AggregateListener_HttpResources{},
+					HttpFilterChains: []*v1.AggregateListener_HttpFilterChain{{
+						VirtualHostRefs: []string{"vhost"},
+					}},
+				},
+			},
+		}
+	})
+
+	apply := func(annotations map[string]string) error {
+		listenerCtx := &plugins.ListenerContext{
+			Gateway: &gwv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+			},
+		}
+		return NewPlugin().ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+	}
+
+	hcmSettings := func() *hcm.HttpConnectionManagerSettings {
+		aggregateListener := outputListener.GetAggregateListener()
+		ref := aggregateListener.GetHttpFilterChains()[0].GetHttpOptionsRef()
+		return aggregateListener.GetHttpResources().GetHttpOptions()[ref].GetHttpConnectionManagerSettings()
+	}
+
+	It("sets the idle timeout and max connection duration", func() {
+		Expect(apply(map[string]string{
+			IdleTimeoutAnnotation:           "5m",
+			MaxConnectionDurationAnnotation: "1h",
+		})).To(Succeed())
+		Expect(proto.Equal(hcmSettings(), &hcm.HttpConnectionManagerSettings{
+			IdleTimeout:           durationpb.New(5 * time.Minute),
+			MaxConnectionDuration: durationpb.New(time.Hour),
+		})).To(BeTrue())
+	})
+
+	It("leaves zero durations unset", func() {
+		Expect(apply(map[string]string{
+			IdleTimeoutAnnotation:           "30s",
+			MaxConnectionDurationAnnotation: "0s",
+		})).To(Succeed())
+		Expect(proto.Equal(hcmSettings(), &hcm.HttpConnectionManagerSettings{
+			IdleTimeout: durationpb.New(30 * time.Second),
+		})).To(BeTrue())
+	})
+
+	It("keeps the other connection manager settings", func() {
+		aggregateListener := outputListener.GetAggregateListener()
+		aggregateListener.GetHttpFilterChains()[0].HttpOptionsRef = "http"
+		aggregateListener.GetHttpResources().HttpOptions = map[string]*v1.HttpListenerOptions{
+			"http": {
+				HttpConnectionManagerSettings: &hcm.HttpConnectionManagerSettings{
+					StreamIdleTimeout: durationpb.New(time.Minute),
+				},
+			},
+		}
+		Expect(apply(map[string]string{
+			IdleTimeoutAnnotation: "5m",
+		})).To(Succeed())
+		Expect(proto.Equal(hcmSettings(), &hcm.HttpConnectionManagerSettings{
+			StreamIdleTimeout: durationpb.New(time.Minute),
+			IdleTimeout:       durationpb.New(5 * time.Minute),
+		})).To(BeTrue())
+	})
+
+	DescribeTable("does nothing without durations",
+		func(annotations map[string]string) {
+			Expect(apply(annotations)).To(Succeed())
+			Expect(outputListener.GetAggregateListener().GetHttpResources().GetHttpOptions()).To(BeEmpty())
+		},
+		Entry("no annotations", nil),
+		Entry("zero durations", map[string]string{
+			IdleTimeoutAnnotation:           "0",
+			MaxConnectionDurationAnnotation: "0s",
+		}),
+	)
+
+	DescribeTable("rejects invalid durations",
+		func(annotation, value string) {
+			Expect(apply(map[string]string{annotation: value})).To(MatchError(ContainSubstring(annotation)))
+			Expect(outputListener.GetAggregateListener().GetHttpResources().GetHttpOptions()).To(BeEmpty())
+		},
+		Entry("negative idle timeout", IdleTimeoutAnnotation, "-5s"),
+		Entry("unparseable idle timeout", IdleTimeoutAnnotation, "5"),
+		Entry("negative max connection duration", MaxConnectionDurationAnnotation, "-1h"),
+	)
+})
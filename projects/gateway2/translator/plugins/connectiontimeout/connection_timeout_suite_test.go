@@ -0,0 +1,13 @@
+package connectiontimeout
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestConnectionTimeoutPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Connection Timeout Plugin Suite")
+}
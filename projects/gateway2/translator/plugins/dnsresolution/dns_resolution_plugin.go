@@ -0,0 +1,102 @@
+package dnsresolution
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+)
+
+// Annotations set on a Service to configure how envoy resolves the hostnames of the Upstreams discovered from it.
+// They only apply to Upstreams addressed by hostname, such as those of ExternalName Services: Upstreams resolved from
+// the endpoints of the Service are rejected, as they are not resolved through DNS.
+const (
+	// DnsRefreshRateAnnotation is the time between DNS resolutions of the hostnames, at least 1ms
+	DnsRefreshRateAnnotation = "gateway2.solo.io/dns-refresh-rate"
+	// RespectDnsTtlAnnotation set to "true" resolves the hostnames again once the TTL of their records expires,
+	// falling back to the refresh rate for records with a zero TTL
+	RespectDnsTtlAnnotation = "gateway2.solo.io/respect-dns-ttl"
+)
+
+// minDnsRefreshRate is the minimum refresh rate accepted by envoy
+const minDnsRefreshRate = time.Millisecond
+
+var (
+	InvalidDnsRefreshRateErr = errors.Errorf("annotation %s must be at least %s", DnsRefreshRateAnnotation, minDnsRefreshRate)
+	InvalidBoolErr           = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be true or false", value, RespectDnsTtlAnnotation)
+	}
+	NotDnsResolvedErr = errors.Errorf("annotations %s and %s require an upstream addressed by hostname, "+
+		"upstreams of Service endpoints are not resolved through DNS", DnsRefreshRateAnnotation, RespectDnsTtlAnnotation)
+)
+
+var (
+	_ plugins.BackendPlugin   = &plugin{}
+	_ plugins.DependentPlugin = &plugin{}
+)
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+// DependsOn the externalservice plugin, which makes the upstreams of ExternalName Services resolved through DNS
+func (p *plugin) DependsOn() []string {
+	return []string{"externalservice"}
+}
+
+func (p *plugin) ApplyBackendPlugin(
+	ctx context.Context,
+	backendCtx *plugins.BackendContext,
+	outputUpstream *v1.Upstream,
+) error {
+	annotations := backendCtx.Service.GetAnnotations()
+	refreshRate, err := utils.GetDurationAnnotation(annotations, DnsRefreshRateAnnotation)
+	if err != nil {
+		return err
+	}
+	if refreshRate != nil && refreshRate.AsDuration() < minDnsRefreshRate {
+		return InvalidDnsRefreshRateErr
+	}
+	var respectDnsTtl *wrappers.BoolValue
+	if value, ok := annotations[RespectDnsTtlAnnotation]; ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return InvalidBoolErr(value)
+		}
+		respectDnsTtl = &wrappers.BoolValue{Value: b}
+	}
+	if refreshRate == nil && respectDnsTtl == nil {
+		return nil
+	}
+
+	// gloo rejects clusters with a refresh rate which are not resolved through DNS
+	if !isDnsResolved(outputUpstream) {
+		return NotDnsResolvedErr
+	}
+	if refreshRate != nil {
+		outputUpstream.DnsRefreshRate = refreshRate
+	}
+	if respectDnsTtl != nil {
+		outputUpstream.RespectDnsTtl = respectDnsTtl
+	}
+	return nil
+}
+
+// isDnsResolved returns whether the upstream is translated to a STRICT_DNS cluster, which gloo does for
+// static upstreams with at least one host addressed by hostname
+func isDnsResolved(upstream *v1.Upstream) bool {
+	for _, host := range upstream.GetStatic().GetHosts() {
+		if net.ParseIP(host.GetAddr()) == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package dnsresolution
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/kubernetes"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/static"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("DnsResolutionPlugin", func() {
+	apply := func(annotations map[string]string, us *v1.Upstream) error {
+		backendCtx := &plugins.BackendContext{
+			Service: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "svc",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+			},
+		}
+		return NewPlugin().ApplyBackendPlugin(context.Background(), backendCtx, us)
+	}
+
+	staticUpstream := func(addrs ...string) *v1.Upstream {
+		var hosts []*static.Host
+		for _, addr := range addrs {
+			hosts = append(hosts, &static.Host{Addr: addr, Port: 443})
+		}
+		return &v1.Upstream{
+			UpstreamType: &v1.Upstream_Static{
+				Static: &static.UpstreamSpec{Hosts: hosts},
+			},
+		}
+	}
+
+	It("sets a custom refresh rate", func() {
+		us := staticUpstream("example.com")
+		Expect(apply(map[string]string{
+			DnsRefreshRateAnnotation: "30s",
+		}, us)).To(Succeed())
+		Expect(proto.Equal(us.GetDnsRefreshRate(), durationpb.New(30*time.Second))).To(BeTrue())
+		Expect(us.GetRespectDnsTtl()).To(BeNil())
+	})
+
+	It("respects the TTL of the records", func() {
+		us := staticUpstream("10.0.0.1", "example.com")
+		Expect(apply(map[string]string{
+			DnsRefreshRateAnnotation: "1m",
+			RespectDnsTtlAnnotation:  "true",
+		}, us)).To(Succeed())
+		Expect(proto.Equal(us.GetDnsRefreshRate(), durationpb.New(time.Minute))).To(BeTrue())
+		Expect(proto.Equal(us.GetRespectDnsTtl(), &wrappers.BoolValue{Value: true})).To(BeTrue())
+	})
+
+	It("does nothing without the annotations", func() {
+		us := staticUpstream("example.com")
+		Expect(apply(nil, us)).To(Succeed())
+		Expect(proto.Equal(us, staticUpstream("example.com"))).To(BeTrue())
+	})
+
+	DescribeTable("rejects invalid dns resolution",
+		func(annotations map[string]string, us *v1.Upstream, expectedErr string) {
+			err := apply(annotations, us)
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			Expect(us.GetDnsRefreshRate()).To(BeNil())
+			Expect(us.GetRespectDnsTtl()).To(BeNil())
+		},
+		Entry("unparseable refresh rate",
+			map[string]string{DnsRefreshRateAnnotation: "30"},
+			staticUpstream("example.com"),
+			DnsRefreshRateAnnotation),
+		Entry("refresh rate below the minimum",
+			map[string]string{DnsRefreshRateAnnotation: "500us"},
+			staticUpstream("example.com"),
+			InvalidDnsRefreshRateErr.Error()),
+		Entry("invalid respect TTL",
+			map[string]string{RespectDnsTtlAnnotation: "yes"},
+			staticUpstream("example.com"),
+			InvalidBoolErr("yes").Error()),
+		Entry("upstream of Service endpoints",
+			map[string]string{RespectDnsTtlAnnotation: "true"},
+			&v1.Upstream{
+				UpstreamType: &v1.Upstream_Kube{
+					Kube: &kubernetes.UpstreamSpec{ServiceName: "svc", ServiceNamespace: "default", ServicePort: 80},
+				},
+			},
+			NotDnsResolvedErr.Error()),
+		Entry("static upstream addressed by IP",
+			map[string]string{DnsRefreshRateAnnotation: "30s"},
+			staticUpstream("10.0.0.1"),
+			NotDnsResolvedErr.Error()),
+	)
+})
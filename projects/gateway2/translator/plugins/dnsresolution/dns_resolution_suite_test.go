@@ -0,0 +1,13 @@
+package dnsresolution
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDnsResolution(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DNS Resolution Plugin Suite")
+}
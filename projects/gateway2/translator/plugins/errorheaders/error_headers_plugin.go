@@ -0,0 +1,184 @@
+package errorheaders
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/core/matchers"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/transformation"
+)
+
+// Annotations set on a RouteOption to add headers to the error responses of the routes it is applied to.
+// The headers are added to error responses generated by the gateway, e.g. 503 when there is no healthy
+// endpoint, as well as to error responses of the backends.
+const (
+	// ErrorResponseHeadersAnnotation is a comma-separated list of <name>:<value> headers set on error responses
+	ErrorResponseHeadersAnnotation = "gateway2.solo.io/error-response-headers"
+	// ErrorResponseStatusCodesAnnotation is a comma-separated list of the status codes of the error responses,
+	// each a code between 400 and 599 or a class "4xx" or "5xx". Defaults to "4xx,5xx".
+	ErrorResponseStatusCodesAnnotation = "gateway2.solo.io/error-response-status-codes"
+	// ErrorResponseCodeDetailsAnnotation restricts the error responses to those with the envoy response code
+	// details, e.g. "no_healthy_upstream" or "via_upstream" for the responses of the backends
+	ErrorResponseCodeDetailsAnnotation = "gateway2.solo.io/error-response-code-details"
+)
+
+var (
+	defaultStatusCodes = []string{"4xx", "5xx"}
+
+	headerNameRegex = regexp.MustCompile(`^[A-Za-z0-9!#$%&'*+\-.^_|~]+$`)
+
+	InvalidHeaderErr = func(value string) error {
+		return errors.Errorf("invalid header '%s' in annotation %s: must be of the form <name>:<value>", value, ErrorResponseHeadersAnnotation)
+	}
+	InvalidStatusCodeErr = func(value string) error {
+		return errors.Errorf("invalid status code '%s' in annotation %s: must be between 400 and 599, 4xx or 5xx", value, ErrorResponseStatusCodesAnnotation)
+	}
+	OptionWithoutHeadersErr      = errors.Errorf("error response annotations require annotation %s", ErrorResponseHeadersAnnotation)
+	ConflictingTransformationErr = errors.Errorf("annotation %s cannot be combined with response transformations of the RouteOption", ErrorResponseHeadersAnnotation)
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+)
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+// DependsOn the routeoptions plugin, which would otherwise replace the response transformations of the route
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+	annotations := routeOption.GetAnnotations()
+	headers, statusRegex, err := parseAnnotations(annotations)
+	if err != nil || headers == nil {
+		return err
+	}
+	if len(outputRoute.GetOptions().GetStagedTransformations().GetRegular().GetResponseTransforms()) > 0 {
+		return ConflictingTransformationErr
+	}
+
+	if outputRoute.GetOptions() == nil {
+		outputRoute.Options = &v1.RouteOptions{}
+	}
+	options := outputRoute.GetOptions()
+	if options.GetStagedTransformations() == nil {
+		options.StagedTransformations = &transformation.TransformationStages{}
+	}
+	if options.GetStagedTransformations().GetRegular() == nil {
+		options.GetStagedTransformations().Regular = &transformation.RequestResponseTransformations{}
+	}
+	options.GetStagedTransformations().GetRegular().ResponseTransforms = []*transformation.ResponseMatch{{
+		Matchers: []*matchers.HeaderMatcher{{
+			Name:  ":status",
+			Value: statusRegex,
+			Regex: true,
+		}},
+		ResponseCodeDetails: annotations[ErrorResponseCodeDetailsAnnotation],
+		ResponseTransformation: &transformation.Transformation{
+			TransformationType: &transformation.Transformation_TransformationTemplate{
+				TransformationTemplate: &transformation.TransformationTemplate{
+					Headers: headers,
+					BodyTransformation: &transformation.TransformationTemplate_Passthrough{
+						Passthrough: &transformation.Passthrough{},
+					},
+				},
+			},
+		},
+	}}
+	return nil
+}
+
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	_, _, err := parseAnnotations(routeOption.GetAnnotations())
+	return err
+}
+
+// parseAnnotations returns the error response headers and the regex matching the status codes of the error responses,
+// or nil headers without the error response headers annotation
+func parseAnnotations(annotations map[string]string) (map[string]*transformation.InjaTemplate, string, error) {
+	value, ok := annotations[ErrorResponseHeadersAnnotation]
+	if !ok {
+		_, hasStatusCodes := annotations[ErrorResponseStatusCodesAnnotation]
+		_, hasCodeDetails := annotations[ErrorResponseCodeDetailsAnnotation]
+		if hasStatusCodes || hasCodeDetails {
+			return nil, "", OptionWithoutHeadersErr
+		}
+		return nil, "", nil
+	}
+
+	headers, err := parseHeaders(value)
+	if err != nil {
+		return nil, "", err
+	}
+	statusCodes := defaultStatusCodes
+	if value, ok := annotations[ErrorResponseStatusCodesAnnotation]; ok {
+		statusCodes = strings.Split(value, ",")
+	}
+	statusRegex, err := getStatusRegex(statusCodes)
+	if err != nil {
+		return nil, "", err
+	}
+	return headers, statusRegex, nil
+}
+
+func parseHeaders(value string) (map[string]*transformation.InjaTemplate, error) {
+	headers := map[string]*transformation.InjaTemplate{}
+	for _, header := range strings.Split(value, ",") {
+		name, v, found := strings.Cut(strings.TrimSpace(header), ":")
+		name, v = strings.TrimSpace(name), strings.TrimSpace(v)
+		if !found || !headerNameRegex.MatchString(name) || v == "" {
+			return nil, InvalidHeaderErr(header)
+		}
+		headers[strings.ToLower(name)] = &transformation.InjaTemplate{Text: v}
+	}
+	return headers, nil
+}
+
+// getStatusRegex returns a regex matching the status codes
+func getStatusRegex(statusCodes []string) (string, error) {
+	var alternatives []string
+	for _, code := range statusCodes {
+		code = strings.TrimSpace(code)
+		switch code {
+		case "4xx", "5xx":
+			alternatives = append(alternatives, code[:1]+"[0-9]{2}")
+		default:
+			c, err := strconv.Atoi(code)
+			if err != nil || c < 400 || c > 599 {
+				return "", InvalidStatusCodeErr(code)
+			}
+			alternatives = append(alternatives, code)
+		}
+	}
+	return strings.Join(alternatives, "|"), nil
+}
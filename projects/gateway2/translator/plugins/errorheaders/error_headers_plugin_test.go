@@ -0,0 +1,195 @@
+package errorheaders
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/core/matchers"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/transformation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("ErrorHeadersPlugin", func() {
+	apply := func(annotations map[string]string, outputRoute *v1.Route) error {
+		queries := testutils.BuildGatewayQueries([]client.Object{
+			&solokubev1.RouteOption{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "policy",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+				Spec: sologatewayv1.RouteOption{},
+			},
+		})
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: &gwv1.HTTPRouteRule{
+				Filters: []gwv1.HTTPRouteFilter{{
+					Type: gwv1.HTTPRouteFilterExtensionRef,
+					ExtensionRef: &gwv1.LocalObjectReference{
+						Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Name:  "policy",
+					},
+				}},
+			},
+		}
+		return NewPlugin(queries).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+	}
+
+	expectedResponseMatch := func(statusRegex, codeDetails string, headers map[string]*transformation.InjaTemplate) *transformation.ResponseMatch {
+		return &transformation.ResponseMatch{
+			Matchers: []*matchers.HeaderMatcher{{
+				Name:  ":status",
+				Value: statusRegex,
+				Regex: true,
+			}},
+			ResponseCodeDetails: codeDetails,
+			ResponseTransformation: &transformation.Transformation{
+				TransformationType: &transformation.Transformation_TransformationTemplate{
+					TransformationTemplate: &transformation.TransformationTemplate{
+						Headers: headers,
+						BodyTransformation: &transformation.TransformationTemplate_Passthrough{
+							Passthrough: &transformation.Passthrough{},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	It("sets headers on all error responses by default", func() {
+		route := &v1.Route{}
+		err := apply(map[string]string{
+			ErrorResponseHeadersAnnotation: "X-Error-Source: gateway, cache-control:no-store",
+		}, route)
+		Expect(err).NotTo(HaveOccurred())
+		transforms := route.GetOptions().GetStagedTransformations().GetRegular().GetResponseTransforms()
+		Expect(transforms).To(HaveLen(1))
+		Expect(proto.Equal(transforms[0], expectedResponseMatch("4[0-9]{2}|5[0-9]{2}", "", map[string]*transformation.InjaTemplate{
+			"x-error-source": {Text: "gateway"},
+			"cache-control":  {Text: "no-store"},
+		}))).To(BeTrue())
+	})
+
+	It("sets headers on the synthetic error responses of the gateway", func() {
+		route := &v1.Route{}
+		err := apply(map[string]string{
+			ErrorResponseHeadersAnnotation:     "retry-after:30",
+			ErrorResponseStatusCodesAnnotation: "503, 429",
+			ErrorResponseCodeDetailsAnnotation: "no_healthy_upstream",
+		}, route)
+		Expect(err).NotTo(HaveOccurred())
+		transforms := route.GetOptions().GetStagedTransformations().GetRegular().GetResponseTransforms()
+		Expect(transforms).To(HaveLen(1))
+		Expect(proto.Equal(transforms[0], expectedResponseMatch("503|429", "no_healthy_upstream", map[string]*transformation.InjaTemplate{
+			"retry-after": {Text: "30"},
+		}))).To(BeTrue())
+	})
+
+	It("keeps the other transformations of the route", func() {
+		early := &transformation.RequestResponseTransformations{
+			RequestTransforms: []*transformation.RequestMatch{{ClearRouteCache: true}},
+		}
+		route := &v1.Route{
+			Options: &v1.RouteOptions{
+				StagedTransformations: &transformation.TransformationStages{
+					Early: early,
+				},
+			},
+		}
+		err := apply(map[string]string{ErrorResponseHeadersAnnotation: "x-error:true"}, route)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetStagedTransformations().GetEarly()).To(Equal(early))
+		Expect(route.GetOptions().GetStagedTransformations().GetRegular().GetResponseTransforms()).To(HaveLen(1))
+	})
+
+	It("does nothing without the annotation", func() {
+		route := &v1.Route{}
+		err := apply(nil, route)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions()).To(BeNil())
+	})
+
+	It("rejects response transformations of the RouteOption", func() {
+		route := &v1.Route{
+			Options: &v1.RouteOptions{
+				StagedTransformations: &transformation.TransformationStages{
+					Regular: &transformation.RequestResponseTransformations{
+						ResponseTransforms: []*transformation.ResponseMatch{{ResponseCodeDetails: "via_upstream"}},
+					},
+				},
+			},
+		}
+		err := apply(map[string]string{ErrorResponseHeadersAnnotation: "x-error:true"}, route)
+		Expect(err).To(MatchError(ConflictingTransformationErr))
+		Expect(route.GetOptions().GetStagedTransformations().GetRegular().GetResponseTransforms()).To(HaveLen(1))
+	})
+
+	DescribeTable("rejects invalid error response headers",
+		func(annotations map[string]string, expectedErr string) {
+			route := &v1.Route{}
+			err := apply(annotations, route)
+			Expect(err).To(MatchError(expectedErr))
+			Expect(route.GetOptions()).To(BeNil())
+		},
+		Entry("header without a value",
+			map[string]string{ErrorResponseHeadersAnnotation: "x-error"},
+			InvalidHeaderErr("x-error").Error()),
+		Entry("invalid header name",
+			map[string]string{ErrorResponseHeadersAnnotation: "x error:true"},
+			InvalidHeaderErr("x error:true").Error()),
+		Entry("status code below 400",
+			map[string]string{ErrorResponseHeadersAnnotation: "x-error:true", ErrorResponseStatusCodesAnnotation: "302"},
+			InvalidStatusCodeErr("302").Error()),
+		Entry("unknown status class",
+			map[string]string{ErrorResponseHeadersAnnotation: "x-error:true", ErrorResponseStatusCodesAnnotation: "6xx"},
+			InvalidStatusCodeErr("6xx").Error()),
+		Entry("options without headers",
+			map[string]string{ErrorResponseCodeDetailsAnnotation: "no_healthy_upstream"},
+			OptionWithoutHeadersErr.Error()),
+	)
+
+	Describe("validation", func() {
+		validate := func(annotations map[string]string) error {
+			return NewPlugin(nil).ValidateRouteOption(context.Background(), &solokubev1.RouteOption{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "policy",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+			})
+		}
+
+		It("accepts valid error response headers", func() {
+			Expect(validate(map[string]string{
+				ErrorResponseHeadersAnnotation:     "x-error:true",
+				ErrorResponseStatusCodesAnnotation: "503,4xx",
+			})).To(Succeed())
+		})
+
+		It("rejects the error response headers rejected at translation", func() {
+			Expect(validate(map[string]string{
+				ErrorResponseHeadersAnnotation:     "x-error:true",
+				ErrorResponseStatusCodesAnnotation: "5[0-9]{2}",
+			})).To(MatchError(InvalidStatusCodeErr("5[0-9]{2}").Error()))
+			Expect(validate(map[string]string{
+				ErrorResponseStatusCodesAnnotation: "5xx",
+			})).To(MatchError(OptionWithoutHeadersErr))
+		})
+	})
+})
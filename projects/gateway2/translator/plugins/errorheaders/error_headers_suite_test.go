@@ -0,0 +1,13 @@
+package errorheaders
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestErrorHeadersPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Error Headers Plugin Suite")
+}
@@ -0,0 +1,85 @@
+package externalservice
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/static"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ExternalTlsAnnotation is set on an ExternalName Service to "true" to connect to its external host over TLS,
+// or "false" to connect in plaintext. Defaults to TLS when the port of the Upstream is 443.
+const ExternalTlsAnnotation = "gateway2.solo.io/external-tls"
+
+var (
+	InvalidExternalNameErr = func(value string) error {
+		return errors.Errorf("invalid external name '%s': must be a DNS hostname", value)
+	}
+	InvalidBoolErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be true or false", value, ExternalTlsAnnotation)
+	}
+)
+
+var (
+	_ plugins.BackendPlugin   = &plugin{}
+	_ plugins.DependentPlugin = &plugin{}
+)
+
+// plugin routes the backendRefs to ExternalName Services to their external host, by replacing the Upstreams
+// discovered from them, which have no endpoints, with static Upstreams resolving the host through DNS.
+// The Upstreams keep their name, so the routes referencing the Services are unchanged.
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+// DependsOn the upstreamprotocol plugin, which reads the Service port of the kube upstream replaced by this plugin
+func (p *plugin) DependsOn() []string {
+	return []string{"upstreamprotocol"}
+}
+
+func (p *plugin) ApplyBackendPlugin(
+	ctx context.Context,
+	backendCtx *plugins.BackendContext,
+	outputUpstream *v1.Upstream,
+) error {
+	svc := backendCtx.Service
+	if svc.Spec.Type != corev1.ServiceTypeExternalName {
+		return nil
+	}
+	// a fully qualified hostname is resolved the same, but its trailing dot is not a valid SNI
+	host := strings.TrimSuffix(svc.Spec.ExternalName, ".")
+	if len(validation.IsDNS1123Subdomain(host)) > 0 {
+		return InvalidExternalNameErr(svc.Spec.ExternalName)
+	}
+	kube := outputUpstream.GetKube()
+	if kube == nil {
+		return nil
+	}
+
+	spec := &static.UpstreamSpec{
+		Hosts: []*static.Host{{
+			Addr: host,
+			Port: kube.GetServicePort(),
+		}},
+	}
+	if value, ok := svc.GetAnnotations()[ExternalTlsAnnotation]; ok {
+		useTls, err := strconv.ParseBool(value)
+		if err != nil {
+			return InvalidBoolErr(value)
+		}
+		spec.UseTls = &wrappers.BoolValue{Value: useTls}
+	}
+	outputUpstream.UpstreamType = &v1.Upstream_Static{
+		Static: spec,
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package externalservice
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/dnsresolution"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/kubernetes"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/static"
+	kubeplugin "github.com/solo-io/gloo/projects/gloo/pkg/plugins/kubernetes"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("ExternalServicePlugin", func() {
+	service := func(serviceType corev1.ServiceType, externalName string, annotations map[string]string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "external",
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+			Spec: corev1.ServiceSpec{
+				Type:         serviceType,
+				ExternalName: externalName,
+			},
+		}
+	}
+	// the upstream discovered for port 443 of the Service, as referenced by the destinations of routes
+	discoveredUpstream := func() *v1.Upstream {
+		return &v1.Upstream{
+			Metadata: &core.Metadata{
+				Name:      kubeplugin.UpstreamName("default", "external", 443),
+				Namespace: "default",
+			},
+			UpstreamType: &v1.Upstream_Kube{
+				Kube: &kubernetes.UpstreamSpec{
+					ServiceName:      "external",
+					ServiceNamespace: "default",
+					ServicePort:      443,
+				},
+			},
+		}
+	}
+	apply := func(svc *corev1.Service, us *v1.Upstream) error {
+		return NewPlugin().ApplyBackendPlugin(context.Background(), &plugins.BackendContext{Service: svc}, us)
+	}
+
+	It("resolves the external host of the Service through DNS", func() {
+		us := discoveredUpstream()
+		Expect(apply(service(corev1.ServiceTypeExternalName, "api.example.com.", nil), us)).To(Succeed())
+		Expect(proto.Equal(us, &v1.Upstream{
+			Metadata: &core.Metadata{
+				Name:      kubeplugin.UpstreamName("default", "external", 443),
+				Namespace: "default",
+			},
+			UpstreamType: &v1.Upstream_Static{
+				Static: &static.UpstreamSpec{
+					Hosts: []*static.Host{{
+						Addr: "api.example.com",
+						Port: 443,
+					}},
+				},
+			},
+		})).To(BeTrue())
+	})
+
+	It("sets the DNS resolution of the external host", func() {
+		svc := service(corev1.ServiceTypeExternalName, "api.example.com", map[string]string{
+			dnsresolution.DnsRefreshRateAnnotation: "30s",
+		})
+		us := discoveredUpstream()
+		Expect(apply(svc, us)).To(Succeed())
+		Expect(dnsresolution.NewPlugin().ApplyBackendPlugin(context.Background(), &plugins.BackendContext{Service: svc}, us)).To(Succeed())
+		Expect(us.GetDnsRefreshRate().AsDuration().String()).To(Equal("30s"))
+	})
+
+	It("connects to the external host in plaintext", func() {
+		us := discoveredUpstream()
+		Expect(apply(service(corev1.ServiceTypeExternalName, "api.example.com", map[string]string{
+			ExternalTlsAnnotation: "false",
+		}), us)).To(Succeed())
+		Expect(proto.Equal(us.GetStatic().GetUseTls(), &wrappers.BoolValue{Value: false})).To(BeTrue())
+	})
+
+	It("leaves the Upstreams of other Services", func() {
+		us := discoveredUpstream()
+		Expect(apply(service(corev1.ServiceTypeClusterIP, "", nil), us)).To(Succeed())
+		Expect(proto.Equal(us, discoveredUpstream())).To(BeTrue())
+	})
+
+	DescribeTable("rejects invalid external services",
+		func(svc *corev1.Service, expectedErr error) {
+			us := discoveredUpstream()
+			Expect(apply(svc, us)).To(MatchError(expectedErr.Error()))
+			Expect(proto.Equal(us, discoveredUpstream())).To(BeTrue())
+		},
+		Entry("empty external name",
+			service(corev1.ServiceTypeExternalName, "", nil),
+			InvalidExternalNameErr("")),
+		Entry("external name with a port",
+			service(corev1.ServiceTypeExternalName, "api.example.com:443", nil),
+			InvalidExternalNameErr("api.example.com:443")),
+		Entry("invalid tls",
+			service(corev1.ServiceTypeExternalName, "api.example.com", map[string]string{ExternalTlsAnnotation: "yes"}),
+			InvalidBoolErr("yes")),
+	)
+})
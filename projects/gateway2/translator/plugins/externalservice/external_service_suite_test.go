@@ -0,0 +1,13 @@
+package externalservice
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestExternalService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "External Service Plugin Suite")
+}
@@ -0,0 +1,290 @@
+package extproc
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	extprocv3 "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/extensions/filters/http/ext_proc/v3"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/extproc"
+)
+
+// Annotations set on a Gateway to configure the external processing filter on its listeners.
+// Routes of the Gateway are sent to the external processing server only if they opt in with ExtProcAnnotation.
+const (
+	// ExtProcServiceAnnotation is the external processing server, as a <service>:<port> in the namespace of the Gateway.
+	// It can also be set on a RouteOption to override the server for its routes, in the namespace of the RouteOption.
+	ExtProcServiceAnnotation = "gateway2.solo.io/ext-proc-service"
+	// ExtProcFailureModeAnnotation is either "fail-closed", rejecting requests when the external processing server
+	// can't be reached, or "fail-open", letting them through unprocessed. Defaults to "fail-closed".
+	ExtProcFailureModeAnnotation = "gateway2.solo.io/ext-proc-failure-mode"
+)
+
+// Annotations set on a RouteOption to send the routes it is applied to through external processing.
+// The extProc option of the RouteOption takes precedence over them: routes setting both keep the option, and report
+// the conflict.
+const (
+	// ExtProcAnnotation is either "enabled" or "disabled"
+	ExtProcAnnotation = "gateway2.solo.io/ext-proc"
+	// ExtProcRequestHeaderModeAnnotation is either "send" or "skip"
+	ExtProcRequestHeaderModeAnnotation = "gateway2.solo.io/ext-proc-request-header-mode"
+	// ExtProcResponseHeaderModeAnnotation is either "send" or "skip"
+	ExtProcResponseHeaderModeAnnotation = "gateway2.solo.io/ext-proc-response-header-mode"
+	// ExtProcRequestBodyModeAnnotation is one of "none", "streamed", "buffered" or "buffered-partial"
+	ExtProcRequestBodyModeAnnotation = "gateway2.solo.io/ext-proc-request-body-mode"
+	// ExtProcResponseBodyModeAnnotation is one of "none", "streamed", "buffered" or "buffered-partial"
+	ExtProcResponseBodyModeAnnotation = "gateway2.solo.io/ext-proc-response-body-mode"
+)
+
+const (
+	Enabled  = "enabled"
+	Disabled = "disabled"
+
+	FailOpen   = "fail-open"
+	FailClosed = "fail-closed"
+)
+
+var (
+	headerModes = map[string]extprocv3.ProcessingMode_HeaderSendMode{
+		"send": extprocv3.ProcessingMode_SEND,
+		"skip": extprocv3.ProcessingMode_SKIP,
+	}
+	bodyModes = map[string]extprocv3.ProcessingMode_BodySendMode{
+		"none":             extprocv3.ProcessingMode_NONE,
+		"streamed":         extprocv3.ProcessingMode_STREAMED,
+		"buffered":         extprocv3.ProcessingMode_BUFFERED,
+		"buffered-partial": extprocv3.ProcessingMode_BUFFERED_PARTIAL,
+	}
+
+	InvalidValueErr = func(annotation, value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s", value, annotation)
+	}
+	OptionWithoutEnabledErr = errors.Errorf("external processing annotations require annotation %s to be %s", ExtProcAnnotation, Enabled)
+	ConflictingExtProcErr   = errors.Errorf("annotation %s cannot be combined with the extProc option of the RouteOption", ExtProcAnnotation)
+	NotConfiguredErr        = errors.Errorf("routes enable external processing but the Gateway has no annotation %s", ExtProcServiceAnnotation)
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.ListenerPlugin       = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+)
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+// DependsOn the routeoptions plugin, which would otherwise replace the external processing settings of the route
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+
+	routeSettings, err := getRouteSettings(routeOption.GetAnnotations(), routeOption.GetNamespace())
+	if err != nil {
+		return err
+	}
+	if routeSettings == nil {
+		return nil
+	}
+	if outputRoute.GetOptions().GetExtProc() != nil {
+		return ConflictingExtProcErr
+	}
+
+	if outputRoute.GetOptions() == nil {
+		outputRoute.Options = &v1.RouteOptions{}
+	}
+	outputRoute.GetOptions().ExtProc = routeSettings
+	return nil
+}
+
+// ValidateRouteOption does not require the ext proc service of the Gateways, which are only known at translation
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	routeSettings, err := getRouteSettings(routeOption.GetAnnotations(), routeOption.GetNamespace())
+	if err != nil {
+		return err
+	}
+	if routeSettings != nil && routeOption.Spec.GetOptions().GetExtProc() != nil {
+		return ConflictingExtProcErr
+	}
+	return nil
+}
+
+func getRouteSettings(annotations map[string]string, namespace string) (*extproc.RouteSettings, error) {
+	overrides := &extproc.Overrides{}
+	grpcService, err := getGrpcService(annotations, ExtProcServiceAnnotation, namespace)
+	if err != nil {
+		return nil, err
+	}
+	overrides.GrpcService = grpcService
+	processingMode, err := getProcessingMode(annotations)
+	if err != nil {
+		return nil, err
+	}
+	overrides.ProcessingMode = processingMode
+	hasOverrides := grpcService != nil || processingMode != nil
+
+	switch value, ok := annotations[ExtProcAnnotation]; {
+	case !ok:
+		if hasOverrides {
+			return nil, OptionWithoutEnabledErr
+		}
+		return nil, nil
+	case value == Enabled:
+		return &extproc.RouteSettings{
+			Override: &extproc.RouteSettings_Overrides{
+				Overrides: overrides,
+			},
+		}, nil
+	case value == Disabled:
+		if hasOverrides {
+			return nil, OptionWithoutEnabledErr
+		}
+		return &extproc.RouteSettings{
+			Override: &extproc.RouteSettings_Disabled{
+				Disabled: &wrappers.BoolValue{Value: true},
+			},
+		}, nil
+	default:
+		return nil, InvalidValueErr(ExtProcAnnotation, value)
+	}
+}
+
+// getProcessingMode returns the processing mode set by the annotations, or nil if none are set
+func getProcessingMode(annotations map[string]string) (*extprocv3.ProcessingMode, error) {
+	processingMode := &extprocv3.ProcessingMode{}
+	found := false
+	for _, mode := range []struct {
+		annotation string
+		value      *extprocv3.ProcessingMode_HeaderSendMode
+	}{
+		{ExtProcRequestHeaderModeAnnotation, &processingMode.RequestHeaderMode},
+		{ExtProcResponseHeaderModeAnnotation, &processingMode.ResponseHeaderMode},
+	} {
+		value, ok := annotations[mode.annotation]
+		if !ok {
+			continue
+		}
+		headerMode, ok := headerModes[value]
+		if !ok {
+			return nil, InvalidValueErr(mode.annotation, value)
+		}
+		*mode.value = headerMode
+		found = true
+	}
+	for _, mode := range []struct {
+		annotation string
+		value      *extprocv3.ProcessingMode_BodySendMode
+	}{
+		{ExtProcRequestBodyModeAnnotation, &processingMode.RequestBodyMode},
+		{ExtProcResponseBodyModeAnnotation, &processingMode.ResponseBodyMode},
+	} {
+		value, ok := annotations[mode.annotation]
+		if !ok {
+			continue
+		}
+		bodyMode, ok := bodyModes[value]
+		if !ok {
+			return nil, InvalidValueErr(mode.annotation, value)
+		}
+		*mode.value = bodyMode
+		found = true
+	}
+	if !found {
+		return nil, nil
+	}
+	return processingMode, nil
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	annotations := listenerCtx.Gateway.GetAnnotations()
+	grpcService, err := getGrpcService(annotations, ExtProcServiceAnnotation, listenerCtx.Gateway.GetNamespace())
+	if err != nil {
+		return err
+	}
+	vhosts := outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts()
+	if grpcService == nil {
+		for _, vhost := range vhosts {
+			for _, route := range vhost.GetRoutes() {
+				if route.GetOptions().GetExtProc().GetOverrides() != nil {
+					return NotConfiguredErr
+				}
+			}
+		}
+		return nil
+	}
+
+	failureModeAllow := false
+	switch value, ok := annotations[ExtProcFailureModeAnnotation]; {
+	case !ok || value == FailClosed:
+	case value == FailOpen:
+		failureModeAllow = true
+	default:
+		return InvalidValueErr(ExtProcFailureModeAnnotation, value)
+	}
+
+	for _, options := range utils.GetHttpListenerOptions(outputListener) {
+		options.ExtProcConfig = &v1.HttpListenerOptions_ExtProc{
+			ExtProc: &extproc.Settings{
+				GrpcService:      grpcService,
+				FailureModeAllow: &wrappers.BoolValue{Value: failureModeAllow},
+			},
+		}
+	}
+	// routes are only processed when they opt in
+	for _, vhost := range vhosts {
+		for _, route := range vhost.GetRoutes() {
+			if route.GetOptions().GetExtProc() != nil {
+				continue
+			}
+			if route.GetOptions() == nil {
+				route.Options = &v1.RouteOptions{}
+			}
+			route.GetOptions().ExtProc = &extproc.RouteSettings{
+				Override: &extproc.RouteSettings_Disabled{
+					Disabled: &wrappers.BoolValue{Value: true},
+				},
+			}
+		}
+	}
+	return nil
+}
+
+// getGrpcService returns the external processing server referenced by the annotation, or nil if it is not set
+func getGrpcService(annotations map[string]string, annotation, namespace string) (*extproc.GrpcService, error) {
+	ref, err := utils.GetServiceRefAnnotation(annotations, annotation, namespace)
+	if err != nil || ref == nil {
+		return nil, err
+	}
+	return &extproc.GrpcService{
+		ExtProcServerRef: ref,
+	}, nil
+}
@@ -0,0 +1,242 @@
+package extproc
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	extprocv3 "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/extensions/filters/http/ext_proc/v3"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/extproc"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("ExtProcPlugin", func() {
+	disabled := &extproc.RouteSettings{
+		Override: &extproc.RouteSettings_Disabled{
+			Disabled: &wrappers.BoolValue{Value: true},
+		},
+	}
+
+	Context("routes", func() {
+		apply := func(annotations map[string]string, options *v1.RouteOptions) (*v1.Route, error) {
+			queries := testutils.BuildGatewayQueries([]client.Object{testutils.RouteOption(annotations)})
+			routeCtx := &plugins.RouteContext{
+				Route: &gwv1.HTTPRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "default",
+					},
+				},
+				Rule: &gwv1.HTTPRouteRule{
+					Filters: []gwv1.HTTPRouteFilter{{
+						Type: gwv1.HTTPRouteFilterExtensionRef,
+						ExtensionRef: &gwv1.LocalObjectReference{
+							Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+							Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+							Name:  "policy",
+						},
+					}},
+				},
+			}
+			outputRoute := &v1.Route{
+				Options: options,
+			}
+			err := NewPlugin(queries).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+			return outputRoute, err
+		}
+
+		It("enables external processing", func() {
+			route, err := apply(map[string]string{ExtProcAnnotation: Enabled}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(route.GetOptions().GetExtProc(), &extproc.RouteSettings{
+				Override: &extproc.RouteSettings_Overrides{
+					Overrides: &extproc.Overrides{},
+				},
+			})).To(BeTrue())
+		})
+
+		It("overrides the server and processing mode", func() {
+			route, err := apply(map[string]string{
+				ExtProcAnnotation:                   Enabled,
+				ExtProcServiceAnnotation:            "processor:9000",
+				ExtProcRequestHeaderModeAnnotation:  "send",
+				ExtProcResponseHeaderModeAnnotation: "skip",
+				ExtProcRequestBodyModeAnnotation:    "buffered",
+				ExtProcResponseBodyModeAnnotation:   "streamed",
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(route.GetOptions().GetExtProc(), &extproc.RouteSettings{
+				Override: &extproc.RouteSettings_Overrides{
+					Overrides: &extproc.Overrides{
+						GrpcService: &extproc.GrpcService{
+							ExtProcServerRef: &core.ResourceRef{Name: "default-processor-9000", Namespace: "default"},
+						},
+						ProcessingMode: &extprocv3.ProcessingMode{
+							RequestHeaderMode:  extprocv3.ProcessingMode_SEND,
+							ResponseHeaderMode: extprocv3.ProcessingMode_SKIP,
+							RequestBodyMode:    extprocv3.ProcessingMode_BUFFERED,
+							ResponseBodyMode:   extprocv3.ProcessingMode_STREAMED,
+						},
+					},
+				},
+			})).To(BeTrue())
+		})
+
+		It("disables external processing", func() {
+			route, err := apply(map[string]string{ExtProcAnnotation: Disabled}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(route.GetOptions().GetExtProc(), disabled)).To(BeTrue())
+		})
+
+		It("does nothing without the annotation", func() {
+			route, err := apply(nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(route.GetOptions().GetExtProc()).To(BeNil())
+		})
+
+		It("rejects the annotation alongside the extProc option", func() {
+			route, err := apply(map[string]string{ExtProcAnnotation: Disabled}, &v1.RouteOptions{
+				ExtProc: &extproc.RouteSettings{
+					Override: &extproc.RouteSettings_Overrides{Overrides: &extproc.Overrides{}},
+				},
+			})
+			Expect(err).To(MatchError(ConflictingExtProcErr))
+			Expect(route.GetOptions().GetExtProc().GetOverrides()).NotTo(BeNil())
+		})
+
+		DescribeTable("rejects invalid annotations",
+			func(annotations map[string]string, expectedErr string) {
+				route, err := apply(annotations, nil)
+				Expect(err).To(MatchError(expectedErr))
+				Expect(route.GetOptions().GetExtProc()).To(BeNil())
+			},
+			Entry("unknown value",
+				map[string]string{ExtProcAnnotation: "on"},
+				InvalidValueErr(ExtProcAnnotation, "on").Error()),
+			Entry("unknown body mode",
+				map[string]string{ExtProcAnnotation: Enabled, ExtProcRequestBodyModeAnnotation: "chunked"},
+				InvalidValueErr(ExtProcRequestBodyModeAnnotation, "chunked").Error()),
+			Entry("service without port",
+				map[string]string{ExtProcAnnotation: Enabled, ExtProcServiceAnnotation: "processor"},
+				utils.InvalidServiceRefErr(ExtProcServiceAnnotation, "processor").Error()),
+			Entry("overrides without enabling",
+				map[string]string{ExtProcRequestHeaderModeAnnotation: "skip"},
+				OptionWithoutEnabledErr.Error()),
+			Entry("overrides while disabled",
+				map[string]string{ExtProcAnnotation: Disabled, ExtProcServiceAnnotation: "processor:9000"},
+				OptionWithoutEnabledErr.Error()),
+		)
+	})
+
+	Context("listeners", func() {
+		var (
+			annotations    map[string]string
+			outputListener *v1.Listener
+		)
+
+		BeforeEach(func() {
+			annotations = map[string]string{
+				ExtProcServiceAnnotation: "processor:9000",
+			}
+			outputListener = &v1.Listener{
+				Name: "http",
+				ListenerType: &v1.Listener_AggregateListener{
+					AggregateListener: &v1.AggregateListener{
+						HttpResources: &v1.AggregateListener_HttpResources{
+							VirtualHosts: map[string]*v1.VirtualHost{
+								"vhost": {
+									Routes: []*v1.Route{
+										{Name: "opted-in", Options: &v1.RouteOptions{
+											ExtProc: &extproc.RouteSettings{
+												Override: &extproc.RouteSettings_Overrides{Overrides: &extproc.Overrides{}},
+											},
+										}},
+										{Name: "default"},
+									},
+								},
+							},
+						},
+						HttpFilterChains: []*v1.AggregateListener_HttpFilterChain{{
+							VirtualHostRefs: []string{"vhost"},
+						}},
+					},
+				},
+			}
+		})
+
+		apply := func() error {
+			listenerCtx := &plugins.ListenerContext{
+				Gateway: &gwv1.Gateway{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace:   "gateways",
+						Annotations: annotations,
+					},
+				},
+			}
+			return NewPlugin(nil).ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+		}
+
+		settings := func() *extproc.Settings {
+			aggregateListener := outputListener.GetAggregateListener()
+			ref := aggregateListener.GetHttpFilterChains()[0].GetHttpOptionsRef()
+			return aggregateListener.GetHttpResources().GetHttpOptions()[ref].GetExtProc()
+		}
+
+		routes := func() []*v1.Route {
+			return outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts()["vhost"].GetRoutes()
+		}
+
+		DescribeTable("configures the filter with the failure mode",
+			func(failureMode string, failureModeAllow bool) {
+				if failureMode != "" {
+					annotations[ExtProcFailureModeAnnotation] = failureMode
+				}
+				Expect(apply()).To(Succeed())
+				Expect(proto.Equal(settings(), &extproc.Settings{
+					GrpcService: &extproc.GrpcService{
+						ExtProcServerRef: &core.ResourceRef{Name: "gateways-processor-9000", Namespace: "gateways"},
+					},
+					FailureModeAllow: &wrappers.BoolValue{Value: failureModeAllow},
+				})).To(BeTrue())
+			},
+			Entry("fail closed by default", "", false),
+			Entry("fail closed", FailClosed, false),
+			Entry("fail open", FailOpen, true),
+		)
+
+		It("disables routes that don't opt in", func() {
+			Expect(apply()).To(Succeed())
+			Expect(routes()[0].GetOptions().GetExtProc().GetOverrides()).NotTo(BeNil())
+			Expect(proto.Equal(routes()[1].GetOptions().GetExtProc(), disabled)).To(BeTrue())
+		})
+
+		It("does nothing without the annotation", func() {
+			annotations = nil
+			routes()[0].Options = nil
+			Expect(apply()).To(Succeed())
+			Expect(outputListener.GetAggregateListener().GetHttpResources().GetHttpOptions()).To(BeEmpty())
+			Expect(routes()[1].GetOptions()).To(BeNil())
+		})
+
+		It("rejects routes opting in without a server", func() {
+			annotations = nil
+			Expect(apply()).To(MatchError(NotConfiguredErr))
+		})
+
+		It("rejects an unknown failure mode", func() {
+			annotations[ExtProcFailureModeAnnotation] = "fail-sometimes"
+			Expect(apply()).To(MatchError(InvalidValueErr(ExtProcFailureModeAnnotation, "fail-sometimes").Error()))
+			Expect(settings()).To(BeNil())
+		})
+	})
+})
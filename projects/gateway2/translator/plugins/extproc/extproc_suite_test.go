@@ -0,0 +1,13 @@
+package extproc
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestExtProcPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ExtProc Plugin Suite")
+}
@@ -0,0 +1,158 @@
+package grpcjson
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/grpc_json"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	corev1 "k8s.io/api/core/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// Annotations set on a Gateway to transcode JSON requests on all of its HTTP listeners to gRPC, following the
+// google.api.http annotations of the gRPC methods.
+const (
+	// GrpcJsonDescriptorConfigMapAnnotation is the name of the ConfigMap holding the base64-encoded
+	// FileDescriptorSet of the services, in the namespace of the Gateway. The FileDescriptorSet must include
+	// the imports of the services, e.g. built with `protoc --include_imports --descriptor_set_out`.
+	GrpcJsonDescriptorConfigMapAnnotation = "gateway2.solo.io/grpc-json-descriptor-config-map"
+	// GrpcJsonDescriptorKeyAnnotation is the key of the FileDescriptorSet in the ConfigMap, which can be omitted
+	// if the ConfigMap holds a single key
+	GrpcJsonDescriptorKeyAnnotation = "gateway2.solo.io/grpc-json-descriptor-key"
+	// GrpcJsonServicesAnnotation is a comma-separated list of the fully qualified names of the transcoded services,
+	// e.g. "bookstore.Bookstore"
+	GrpcJsonServicesAnnotation = "gateway2.solo.io/grpc-json-services"
+)
+
+var (
+	MissingServicesErr  = errors.Errorf("annotation %s requires annotation %s", GrpcJsonDescriptorConfigMapAnnotation, GrpcJsonServicesAnnotation)
+	MissingConfigMapErr = func(name string, err error) error {
+		return errors.Wrapf(err, "cannot resolve ConfigMap '%s' of annotation %s", name, GrpcJsonDescriptorConfigMapAnnotation)
+	}
+	MissingKeyErr = func(name string) error {
+		return errors.Errorf("ConfigMap '%s' must hold a single key unless annotation %s selects one", name, GrpcJsonDescriptorKeyAnnotation)
+	}
+	MissingDescriptorErr = func(name, key string) error {
+		return errors.Errorf("ConfigMap '%s' has no key '%s'", name, key)
+	}
+	InvalidDescriptorErr = func(name, key string, err error) error {
+		return errors.Wrapf(err, "key '%s' of ConfigMap '%s' is not a base64-encoded FileDescriptorSet", key, name)
+	}
+	UnknownServiceErr = func(service string) error {
+		return errors.Errorf("service '%s' of annotation %s is not in the descriptor", service, GrpcJsonServicesAnnotation)
+	}
+)
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	annotations := listenerCtx.Gateway.GetAnnotations()
+	name, ok := annotations[GrpcJsonDescriptorConfigMapAnnotation]
+	if !ok {
+		return nil
+	}
+	var services []string
+	for _, service := range strings.Split(annotations[GrpcJsonServicesAnnotation], ",") {
+		if service = strings.TrimSpace(service); service != "" {
+			services = append(services, service)
+		}
+	}
+	if len(services) == 0 {
+		return MissingServicesErr
+	}
+
+	descriptor, key, err := p.getDescriptor(ctx, listenerCtx.Gateway, name, annotations[GrpcJsonDescriptorKeyAnnotation])
+	if err != nil {
+		return err
+	}
+	// envoy rejects the whole listener if the descriptor is invalid, so validate it here
+	files, err := loadDescriptor(descriptor)
+	if err != nil {
+		return InvalidDescriptorErr(name, key, err)
+	}
+	for _, service := range services {
+		d, err := files.FindDescriptorByName(protoreflect.FullName(service))
+		if err != nil {
+			return UnknownServiceErr(service)
+		}
+		if _, ok := d.(protoreflect.ServiceDescriptor); !ok {
+			return UnknownServiceErr(service)
+		}
+	}
+
+	for _, options := range utils.GetHttpListenerOptions(outputListener) {
+		options.GrpcJsonTranscoder = &grpc_json.GrpcJsonTranscoder{
+			DescriptorSet: &grpc_json.GrpcJsonTranscoder_ProtoDescriptorBin{
+				ProtoDescriptorBin: descriptor,
+			},
+			Services: services,
+		}
+	}
+	return nil
+}
+
+// getDescriptor returns the decoded FileDescriptorSet of the ConfigMap, and the key holding it
+func (p *plugin) getDescriptor(ctx context.Context, gw *gwv1.Gateway, name, key string) ([]byte, string, error) {
+	obj, err := p.queries.GetLocalObjRef(ctx, p.queries.ObjToFrom(gw), gwv1.LocalObjectReference{
+		Kind: "ConfigMap",
+		Name: gwv1.ObjectName(name),
+	})
+	if err != nil {
+		return nil, "", MissingConfigMapErr(name, err)
+	}
+	configMap, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return nil, "", MissingConfigMapErr(name, errors.Errorf("unexpected type %T", obj))
+	}
+	if key == "" {
+		if len(configMap.Data) != 1 {
+			return nil, "", MissingKeyErr(name)
+		}
+		for k := range configMap.Data {
+			key = k
+		}
+	}
+	value, ok := configMap.Data[key]
+	if !ok {
+		return nil, "", MissingDescriptorErr(name, key)
+	}
+	descriptor, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, "", InvalidDescriptorErr(name, key, err)
+	}
+	return descriptor, key, nil
+}
+
+// loadDescriptor checks the FileDescriptorSet is complete, returning the files it defines
+func loadDescriptor(descriptor []byte) (*protoregistry.Files, error) {
+	fileDescriptorSet := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(descriptor, fileDescriptorSet); err != nil {
+		return nil, err
+	}
+	return protodesc.NewFiles(fileDescriptorSet)
+}
@@ -0,0 +1,155 @@
+package grpcjson
+
+import (
+	"context"
+	"encoding/base64"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/grpc_json"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+const healthService = "grpc.health.v1.Health"
+
+var _ = Describe("GrpcJsonPlugin", func() {
+	var (
+		descriptor     []byte
+		annotations    map[string]string
+		data           map[string]string
+		outputListener *v1.Listener
+	)
+
+	BeforeEach(func() {
+		// the health service defines the Check and Watch methods
+		var err error
+		descriptor, err = proto.Marshal(&descriptorpb.FileDescriptorSet{
+			File: []*descriptorpb.FileDescriptorProto{
+				protodesc.ToFileDescriptorProto(grpc_health_v1.File_grpc_health_v1_health_proto),
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		annotations = map[string]string{
+			GrpcJsonDescriptorConfigMapAnnotation: "descriptors",
+			GrpcJsonServicesAnnotation:            healthService,
+		}
+		data = map[string]string{
+			"health.pb": base64.StdEncoding.EncodeToString(descriptor),
+		}
+		outputListener = &v1.Listener{
+			Name: "http",
+			ListenerType: &v1.Listener_AggregateListener{
+				AggregateListener: &v1.AggregateListener{
+					HttpResources: &v1.AggregateListener_HttpResources{},
+					HttpFilterChains: []*v1.AggregateListener_HttpFilterChain{{
+						VirtualHostRefs: []string{"vhost"},
+					}},
+				},
+			},
+		}
+	})
+
+	apply := func() error {
+		queries := testutils.BuildGatewayQueries([]client.Object{
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "descriptors",
+					Namespace: "gateways",
+				},
+				Data: data,
+			},
+		})
+		listenerCtx := &plugins.ListenerContext{
+			Gateway: &gwv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "gw",
+					Namespace:   "gateways",
+					Annotations: annotations,
+				},
+			},
+		}
+		return NewPlugin(queries).ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+	}
+
+	transcoder := func() *grpc_json.GrpcJsonTranscoder {
+		aggregateListener := outputListener.GetAggregateListener()
+		ref := aggregateListener.GetHttpFilterChains()[0].GetHttpOptionsRef()
+		return aggregateListener.GetHttpResources().GetHttpOptions()[ref].GetGrpcJsonTranscoder()
+	}
+
+	It("transcodes the services of the descriptor", func() {
+		Expect(apply()).To(Succeed())
+		Expect(proto.Equal(transcoder(), &grpc_json.GrpcJsonTranscoder{
+			DescriptorSet: &grpc_json.GrpcJsonTranscoder_ProtoDescriptorBin{
+				ProtoDescriptorBin: descriptor,
+			},
+			Services: []string{healthService},
+		})).To(BeTrue())
+	})
+
+	It("selects the descriptor among several keys", func() {
+		data["other.pb"] = "invalid"
+		annotations[GrpcJsonDescriptorKeyAnnotation] = "health.pb"
+		Expect(apply()).To(Succeed())
+		Expect(transcoder().GetProtoDescriptorBin()).To(Equal(descriptor))
+	})
+
+	It("does nothing without the annotation", func() {
+		delete(annotations, GrpcJsonDescriptorConfigMapAnnotation)
+		Expect(apply()).To(Succeed())
+		Expect(outputListener.GetAggregateListener().GetHttpResources().GetHttpOptions()).To(BeEmpty())
+	})
+
+	It("rejects a descriptor missing its imports", func() {
+		incomplete, err := proto.Marshal(&descriptorpb.FileDescriptorSet{
+			File: []*descriptorpb.FileDescriptorProto{{
+				Name:       proto.String("bookstore.proto"),
+				Dependency: []string{"google/api/annotations.proto"},
+			}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		data["health.pb"] = base64.StdEncoding.EncodeToString(incomplete)
+		Expect(apply()).To(MatchError(ContainSubstring("key 'health.pb' of ConfigMap 'descriptors' is not a base64-encoded FileDescriptorSet")))
+		Expect(transcoder()).To(BeNil())
+	})
+
+	DescribeTable("rejects invalid transcoding",
+		func(setup func(), expectedErr string) {
+			setup()
+			Expect(apply()).To(MatchError(ContainSubstring(expectedErr)))
+			Expect(transcoder()).To(BeNil())
+		},
+		Entry("unknown service", func() {
+			annotations[GrpcJsonServicesAnnotation] = healthService + ", bookstore.Bookstore"
+		}, UnknownServiceErr("bookstore.Bookstore").Error()),
+		Entry("message instead of service", func() {
+			annotations[GrpcJsonServicesAnnotation] = "grpc.health.v1.HealthCheckRequest"
+		}, UnknownServiceErr("grpc.health.v1.HealthCheckRequest").Error()),
+		Entry("missing services", func() {
+			delete(annotations, GrpcJsonServicesAnnotation)
+		}, MissingServicesErr.Error()),
+		Entry("missing ConfigMap", func() {
+			annotations[GrpcJsonDescriptorConfigMapAnnotation] = "missing"
+		}, "cannot resolve ConfigMap 'missing'"),
+		Entry("several keys", func() {
+			data["other.pb"] = "invalid"
+		}, MissingKeyErr("descriptors").Error()),
+		Entry("missing key", func() {
+			annotations[GrpcJsonDescriptorKeyAnnotation] = "bookstore.pb"
+		}, MissingDescriptorErr("descriptors", "bookstore.pb").Error()),
+		Entry("not base64-encoded", func() {
+			data["health.pb"] = "not base64!"
+		}, "key 'health.pb' of ConfigMap 'descriptors' is not a base64-encoded FileDescriptorSet"),
+	)
+})
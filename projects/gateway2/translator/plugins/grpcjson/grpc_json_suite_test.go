@@ -0,0 +1,13 @@
+package grpcjson
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestGrpcJsonPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "gRPC-JSON Plugin Suite")
+}
@@ -0,0 +1,153 @@
+package hashpolicy
+
+import (
+	"context"
+	"strconv"
+
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/lbhash"
+)
+
+// Annotations set on a RouteOption to hash requests on the routes it is applied to, so that requests with the
+// same hash key are sent to the same endpoint. Hashing only takes effect on backends using a consistent hashing
+// load balancer policy, i.e. the "ring-hash" or "maglev" gateway2.solo.io/load-balancer-policy of the Service.
+// When several hash sources are set, they are combined in the order header, cookie, source IP.
+const (
+	// HashHeaderAnnotation is the name of the request header to hash
+	HashHeaderAnnotation = "gateway2.solo.io/hash-header"
+	// HashCookieAnnotation is the name of the cookie to hash
+	HashCookieAnnotation = "gateway2.solo.io/hash-cookie"
+	// HashCookieTtlAnnotation makes envoy generate the hashed cookie with this TTL when the request has none
+	HashCookieTtlAnnotation = "gateway2.solo.io/hash-cookie-ttl"
+	// HashCookiePathAnnotation is the path of the cookie generated by envoy
+	HashCookiePathAnnotation = "gateway2.solo.io/hash-cookie-path"
+	// HashSourceIpAnnotation hashes the source IP address of the request when set to "true"
+	HashSourceIpAnnotation = "gateway2.solo.io/hash-source-ip"
+)
+
+var (
+	InvalidSourceIpErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a boolean", value, HashSourceIpAnnotation)
+	}
+	CookieOptionWithoutCookieErr = errors.Errorf("annotations %s and %s require annotation %s",
+		HashCookieTtlAnnotation, HashCookiePathAnnotation, HashCookieAnnotation)
+	ConflictingHashErr = errors.Errorf("hash annotations cannot be combined with the lbHash option of the RouteOption")
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+)
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+// DependsOn the routeoptions plugin, which would otherwise replace the hash policies of the route
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+
+	hashPolicies, err := getHashPolicies(routeOption.GetAnnotations())
+	if err != nil {
+		return err
+	}
+	if len(hashPolicies) == 0 {
+		return nil
+	}
+	// the lbHash of the route is the one of the RouteOption, set by the routeoptions plugin
+	if outputRoute.GetOptions().GetLbHash() != nil {
+		return ConflictingHashErr
+	}
+
+	if outputRoute.GetOptions() == nil {
+		outputRoute.Options = &v1.RouteOptions{}
+	}
+	outputRoute.GetOptions().LbHash = &lbhash.RouteActionHashConfig{
+		HashPolicies: hashPolicies,
+	}
+	return nil
+}
+
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	hashPolicies, err := getHashPolicies(routeOption.GetAnnotations())
+	if err != nil {
+		return err
+	}
+	if len(hashPolicies) > 0 && routeOption.Spec.GetOptions().GetLbHash() != nil {
+		return ConflictingHashErr
+	}
+	return nil
+}
+
+func getHashPolicies(annotations map[string]string) ([]*lbhash.HashPolicy, error) {
+	var hashPolicies []*lbhash.HashPolicy
+
+	if header, ok := annotations[HashHeaderAnnotation]; ok && header != "" {
+		hashPolicies = append(hashPolicies, &lbhash.HashPolicy{
+			KeyType: &lbhash.HashPolicy_Header{
+				Header: header,
+			},
+		})
+	}
+
+	ttl, err := utils.GetDurationAnnotation(annotations, HashCookieTtlAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	path := annotations[HashCookiePathAnnotation]
+	if name, ok := annotations[HashCookieAnnotation]; ok && name != "" {
+		hashPolicies = append(hashPolicies, &lbhash.HashPolicy{
+			KeyType: &lbhash.HashPolicy_Cookie{
+				Cookie: &lbhash.Cookie{
+					Name: name,
+					Ttl:  ttl,
+					Path: path,
+				},
+			},
+		})
+	} else if ttl != nil || path != "" {
+		return nil, CookieOptionWithoutCookieErr
+	}
+
+	if value, ok := annotations[HashSourceIpAnnotation]; ok {
+		sourceIp, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, InvalidSourceIpErr(value)
+		}
+		if sourceIp {
+			hashPolicies = append(hashPolicies, &lbhash.HashPolicy{
+				KeyType: &lbhash.HashPolicy_SourceIp{
+					SourceIp: true,
+				},
+			})
+		}
+	}
+
+	return hashPolicies, nil
+}
@@ -0,0 +1,149 @@
+package hashpolicy
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/lbhash"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("HashPolicyPlugin", func() {
+	apply := func(annotations map[string]string, options *v1.RouteOptions) (*v1.Route, error) {
+		queries := testutils.BuildGatewayQueries([]client.Object{testutils.RouteOption(annotations)})
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: &gwv1.HTTPRouteRule{
+				Filters: []gwv1.HTTPRouteFilter{{
+					Type: gwv1.HTTPRouteFilterExtensionRef,
+					ExtensionRef: &gwv1.LocalObjectReference{
+						Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Name:  "policy",
+					},
+				}},
+			},
+		}
+		outputRoute := &v1.Route{
+			Options: options,
+		}
+		err := NewPlugin(queries).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+		return outputRoute, err
+	}
+
+	DescribeTable("translates the hash source",
+		func(annotations map[string]string, expected ...*lbhash.HashPolicy) {
+			route, err := apply(annotations, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(route.GetOptions().GetLbHash(), &lbhash.RouteActionHashConfig{
+				HashPolicies: expected,
+			})).To(BeTrue())
+		},
+		Entry("header",
+			map[string]string{HashHeaderAnnotation: "x-user-id"},
+			&lbhash.HashPolicy{KeyType: &lbhash.HashPolicy_Header{Header: "x-user-id"}}),
+		Entry("cookie",
+			map[string]string{HashCookieAnnotation: "session"},
+			&lbhash.HashPolicy{KeyType: &lbhash.HashPolicy_Cookie{Cookie: &lbhash.Cookie{Name: "session"}}}),
+		Entry("generated cookie",
+			map[string]string{
+				HashCookieAnnotation:     "session",
+				HashCookieTtlAnnotation:  "1h",
+				HashCookiePathAnnotation: "/app",
+			},
+			&lbhash.HashPolicy{KeyType: &lbhash.HashPolicy_Cookie{Cookie: &lbhash.Cookie{
+				Name: "session",
+				Ttl:  durationpb.New(time.Hour),
+				Path: "/app",
+			}}}),
+		Entry("source ip",
+			map[string]string{HashSourceIpAnnotation: "true"},
+			&lbhash.HashPolicy{KeyType: &lbhash.HashPolicy_SourceIp{SourceIp: true}}),
+		Entry("all sources in order",
+			map[string]string{
+				HashSourceIpAnnotation: "true",
+				HashCookieAnnotation:   "session",
+				HashHeaderAnnotation:   "x-user-id",
+			},
+			&lbhash.HashPolicy{KeyType: &lbhash.HashPolicy_Header{Header: "x-user-id"}},
+			&lbhash.HashPolicy{KeyType: &lbhash.HashPolicy_Cookie{Cookie: &lbhash.Cookie{Name: "session"}}},
+			&lbhash.HashPolicy{KeyType: &lbhash.HashPolicy_SourceIp{SourceIp: true}}),
+	)
+
+	It("does nothing without hash annotations", func() {
+		route, err := apply(map[string]string{HashSourceIpAnnotation: "false"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetLbHash()).To(BeNil())
+	})
+
+	It("rejects hash annotations alongside the lbHash option", func() {
+		existing := &lbhash.RouteActionHashConfig{
+			HashPolicies: []*lbhash.HashPolicy{{KeyType: &lbhash.HashPolicy_Header{Header: "x-tenant"}}},
+		}
+		route, err := apply(map[string]string{HashHeaderAnnotation: "x-user-id"}, &v1.RouteOptions{LbHash: existing})
+		Expect(err).To(MatchError(ConflictingHashErr))
+		Expect(proto.Equal(route.GetOptions().GetLbHash(), existing)).To(BeTrue())
+	})
+
+	DescribeTable("rejects invalid hash annotations",
+		func(annotations map[string]string, expectedErr string) {
+			route, err := apply(annotations, nil)
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			Expect(route.GetOptions().GetLbHash()).To(BeNil())
+		},
+		Entry("non-boolean source ip",
+			map[string]string{HashSourceIpAnnotation: "yes please"},
+			InvalidSourceIpErr("yes please").Error()),
+		Entry("unparseable cookie ttl",
+			map[string]string{HashCookieAnnotation: "session", HashCookieTtlAnnotation: "forever"},
+			HashCookieTtlAnnotation),
+		Entry("cookie path without cookie",
+			map[string]string{HashCookiePathAnnotation: "/app"},
+			CookieOptionWithoutCookieErr.Error()),
+	)
+
+	Describe("validation", func() {
+		validate := func(annotations map[string]string, options *v1.RouteOptions) error {
+			ro := testutils.RouteOption(annotations)
+			ro.Spec.Options = options
+			return NewPlugin(nil).ValidateRouteOption(context.Background(), ro)
+		}
+
+		It("accepts valid hash annotations", func() {
+			Expect(validate(map[string]string{
+				HashCookieAnnotation:    "session",
+				HashCookieTtlAnnotation: "1h",
+			}, nil)).To(Succeed())
+		})
+
+		It("rejects hash annotations alongside the lbHash option", func() {
+			Expect(validate(map[string]string{HashHeaderAnnotation: "x-user-id"}, &v1.RouteOptions{
+				LbHash: &lbhash.RouteActionHashConfig{
+					HashPolicies: []*lbhash.HashPolicy{{KeyType: &lbhash.HashPolicy_Header{Header: "x-tenant"}}},
+				},
+			})).To(MatchError(ConflictingHashErr))
+		})
+
+		It("rejects a negative cookie ttl", func() {
+			Expect(validate(map[string]string{
+				HashCookieAnnotation:    "session",
+				HashCookieTtlAnnotation: "-1h",
+			}, nil)).To(MatchError(ContainSubstring("must be a positive duration")))
+		})
+	})
+})
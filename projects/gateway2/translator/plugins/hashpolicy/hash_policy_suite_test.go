@@ -0,0 +1,13 @@
+package hashpolicy
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestHashPolicyPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "HashPolicy Plugin Suite")
+}
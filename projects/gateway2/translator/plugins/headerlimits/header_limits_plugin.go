@@ -0,0 +1,74 @@
+package headerlimits
+
+import (
+	"context"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/hcm"
+)
+
+// Annotations set on a Gateway to limit the request headers accepted by all of its HTTP listeners.
+// Requests exceeding a limit are rejected with a 431 response.
+const (
+	// MaxRequestHeadersKbAnnotation is the maximum size of the request headers in KiB, defaulting to envoy's 60
+	MaxRequestHeadersKbAnnotation = "gateway2.solo.io/max-request-headers-kb"
+	// MaxHeadersCountAnnotation is the maximum number of request headers, defaulting to envoy's 100
+	MaxHeadersCountAnnotation = "gateway2.solo.io/max-headers-count"
+)
+
+// the maximum size of the request headers allowed by envoy
+const maxRequestHeadersKb = 8192
+
+var (
+	InvalidMaxRequestHeadersKbErr = errors.Errorf("annotation %s must be between 1 and %d", MaxRequestHeadersKbAnnotation, maxRequestHeadersKb)
+	InvalidMaxHeadersCountErr     = errors.Errorf("annotation %s must be at least 1", MaxHeadersCountAnnotation)
+)
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	annotations := listenerCtx.Gateway.GetAnnotations()
+	maxHeadersKb, err := utils.GetUint32Annotation(annotations, MaxRequestHeadersKbAnnotation)
+	if err != nil {
+		return err
+	}
+	if maxHeadersKb != nil && (maxHeadersKb.GetValue() == 0 || maxHeadersKb.GetValue() > maxRequestHeadersKb) {
+		return InvalidMaxRequestHeadersKbErr
+	}
+	maxHeadersCount, err := utils.GetUint32Annotation(annotations, MaxHeadersCountAnnotation)
+	if err != nil {
+		return err
+	}
+	if maxHeadersCount != nil && maxHeadersCount.GetValue() == 0 {
+		return InvalidMaxHeadersCountErr
+	}
+	if maxHeadersKb == nil && maxHeadersCount == nil {
+		return nil
+	}
+
+	for _, options := range utils.GetHttpListenerOptions(outputListener) {
+		if options.GetHttpConnectionManagerSettings() == nil {
+			options.HttpConnectionManagerSettings = &hcm.HttpConnectionManagerSettings{}
+		}
+		if maxHeadersKb != nil {
+			options.GetHttpConnectionManagerSettings().MaxRequestHeadersKb = maxHeadersKb
+		}
+		if maxHeadersCount != nil {
+			options.GetHttpConnectionManagerSettings().MaxHeadersCount = maxHeadersCount
+		}
+	}
+	return nil
+}
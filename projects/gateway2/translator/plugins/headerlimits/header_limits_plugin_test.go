@@ -0,0 +1,100 @@
+package headerlimits
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/hcm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("HeaderLimitsPlugin", func() {
+	var outputListener *v1.Listener
+
+	BeforeEach(func() {
+		outputListener = &v1.Listener{
+			Name: "http",
+			ListenerType: &v1.Listener_AggregateListener{
+				AggregateListener: &v1.AggregateListener{
+					HttpResources: &v1.AggregateListener_HttpResources{},
+					HttpFilterChains: []*v1.AggregateListener_HttpFilterChain{{
+						VirtualHostRefs: []string{"vhost"},
+					}},
+				},
+			},
+		}
+	})
+
+	apply := func(annotations map[string]string) error {
+		listenerCtx := &plugins.ListenerContext{
+			Gateway: &gwv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+			},
+		}
+		return NewPlugin().ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+	}
+
+	hcmSettings := func() *hcm.HttpConnectionManagerSettings {
+		aggregateListener := outputListener.GetAggregateListener()
+		ref := aggregateListener.GetHttpFilterChains()[0].GetHttpOptionsRef()
+		return aggregateListener.GetHttpResources().GetHttpOptions()[ref].GetHttpConnectionManagerSettings()
+	}
+
+	It("limits the size and number of the request headers", func() {
+		Expect(apply(map[string]string{
+			MaxRequestHeadersKbAnnotation: "32",
+			MaxHeadersCountAnnotation:     "50",
+		})).To(Succeed())
+		Expect(proto.Equal(hcmSettings(), &hcm.HttpConnectionManagerSettings{
+			MaxRequestHeadersKb: &wrappers.UInt32Value{Value: 32},
+			MaxHeadersCount:     &wrappers.UInt32Value{Value: 50},
+		})).To(BeTrue())
+	})
+
+	It("keeps the other connection manager settings", func() {
+		aggregateListener := outputListener.GetAggregateListener()
+		aggregateListener.GetHttpFilterChains()[0].HttpOptionsRef = "http"
+		aggregateListener.GetHttpResources().HttpOptions = map[string]*v1.HttpListenerOptions{
+			"http": {
+				HttpConnectionManagerSettings: &hcm.HttpConnectionManagerSettings{
+					IdleTimeout: durationpb.New(time.Minute),
+				},
+			},
+		}
+		Expect(apply(map[string]string{
+			MaxHeadersCountAnnotation: "200",
+		})).To(Succeed())
+		Expect(proto.Equal(hcmSettings(), &hcm.HttpConnectionManagerSettings{
+			IdleTimeout:     durationpb.New(time.Minute),
+			MaxHeadersCount: &wrappers.UInt32Value{Value: 200},
+		})).To(BeTrue())
+	})
+
+	It("does nothing without the annotations", func() {
+		Expect(apply(nil)).To(Succeed())
+		Expect(outputListener.GetAggregateListener().GetHttpResources().GetHttpOptions()).To(BeEmpty())
+	})
+
+	DescribeTable("rejects invalid limits",
+		func(annotation, value, expectedErr string) {
+			Expect(apply(map[string]string{annotation: value})).To(MatchError(ContainSubstring(expectedErr)))
+			Expect(outputListener.GetAggregateListener().GetHttpResources().GetHttpOptions()).To(BeEmpty())
+		},
+		Entry("zero headers size", MaxRequestHeadersKbAnnotation, "0", InvalidMaxRequestHeadersKbErr.Error()),
+		Entry("headers size above envoy's maximum", MaxRequestHeadersKbAnnotation, "8193", InvalidMaxRequestHeadersKbErr.Error()),
+		Entry("negative headers size", MaxRequestHeadersKbAnnotation, "-1", MaxRequestHeadersKbAnnotation),
+		Entry("zero headers count", MaxHeadersCountAnnotation, "0", InvalidMaxHeadersCountErr.Error()),
+		Entry("unparseable headers count", MaxHeadersCountAnnotation, "many", MaxHeadersCountAnnotation),
+	)
+})
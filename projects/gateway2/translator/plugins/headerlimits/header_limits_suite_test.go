@@ -0,0 +1,13 @@
+package headerlimits
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestHeaderLimits(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "HeaderLimits Plugin Suite")
+}
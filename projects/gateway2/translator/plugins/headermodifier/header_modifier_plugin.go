@@ -2,23 +2,61 @@ package headermodifier
 
 import (
 	"context"
+	"strings"
 
 	"github.com/golang/protobuf/ptypes/wrappers"
 	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
 	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
 	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/headers"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/transformation"
 	"github.com/solo-io/solo-kit/pkg/api/external/envoy/api/v2/core"
 	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
-var _ plugins.RoutePlugin = &plugin{}
+// RequestHeaderModifierStageAnnotation is set on a RouteOption to select when the RequestHeaderModifier filters of
+// the routes it is applied to modify the request headers: either "late", the default, when the request is forwarded
+// after all the http filters ran, or "early", before ext auth and rate limiting, e.g. to set a header they consume.
+// Early modifications use the early transformation stage, and cannot be combined with early transformations.
+const RequestHeaderModifierStageAnnotation = "gateway2.solo.io/request-header-modifier-stage"
 
-type plugin struct{}
+const (
+	EarlyStage = "early"
+	LateStage  = "late"
+)
+
+var (
+	UnknownStageErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be one of %s or %s", value, RequestHeaderModifierStageAnnotation, EarlyStage, LateStage)
+	}
+	InvalidEarlyHeaderValueErr = func(name string) error {
+		return errors.Errorf("value of header '%s' cannot contain template delimiters in the %s stage", name, EarlyStage)
+	}
+	ConflictingEarlyTransformationErr = errors.Errorf("the %s stage of annotation %s cannot be combined with early transformations of the RouteOption", EarlyStage, RequestHeaderModifierStageAnnotation)
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+)
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
 
-func NewPlugin() *plugin {
-	return &plugin{}
+// DependsOn the routeoptions plugin, which replaces the route's options wholesale
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
 }
 
 func (p *plugin) ApplyRoutePlugin(
@@ -34,7 +72,7 @@ func (p *plugin) ApplyRoutePlugin(
 	for _, filter := range filtersToApply {
 		var err error
 		if filter.Type == gwv1.HTTPRouteFilterRequestHeaderModifier {
-			err = p.applyRequestFilter(filter.RequestHeaderModifier, outputRoute)
+			err = p.applyRequestFilter(ctx, routeCtx, filter.RequestHeaderModifier, outputRoute)
 		}
 		if filter.Type == gwv1.HTTPRouteFilterResponseHeaderModifier {
 			err = p.applyResponseFilter(filter.ResponseHeaderModifier, outputRoute)
@@ -47,12 +85,25 @@ func (p *plugin) ApplyRoutePlugin(
 }
 
 func (p *plugin) applyRequestFilter(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
 	config *gwv1.HTTPHeaderFilter,
 	outputRoute *v1.Route,
 ) error {
 	if config == nil {
 		return errors.Errorf("RequestHeaderModifier filter supplied does not define requestHeaderModifier")
 	}
+	var annotations map[string]string
+	if routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries); routeOption != nil {
+		annotations = routeOption.GetAnnotations()
+	}
+	stage, err := getStage(annotations)
+	if err != nil {
+		return err
+	}
+	if stage == EarlyStage {
+		return p.applyEarlyRequestFilter(config, outputRoute)
+	}
 	headerManipulation := outputRoute.GetOptions().GetHeaderManipulation()
 	if headerManipulation == nil {
 		headerManipulation = &headers.HeaderManipulation{}
@@ -63,6 +114,79 @@ func (p *plugin) applyRequestFilter(
 	return nil
 }
 
+// ValidateRouteOption validates the annotation even though it only applies to the routes with a RequestHeaderModifier filter
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	_, err := getStage(routeOption.GetAnnotations())
+	return err
+}
+
+func getStage(annotations map[string]string) (string, error) {
+	stage, ok := annotations[RequestHeaderModifierStageAnnotation]
+	if !ok {
+		return LateStage, nil
+	}
+	if stage != EarlyStage && stage != LateStage {
+		return "", UnknownStageErr(stage)
+	}
+	return stage, nil
+}
+
+// applyEarlyRequestFilter modifies the request headers with an early transformation, which runs before ext auth
+// and rate limiting
+func (p *plugin) applyEarlyRequestFilter(
+	config *gwv1.HTTPHeaderFilter,
+	outputRoute *v1.Route,
+) error {
+	if outputRoute.GetOptions().GetStagedTransformations().GetEarly() != nil {
+		return ConflictingEarlyTransformationErr
+	}
+	template := &transformation.TransformationTemplate{
+		HeadersToRemove: config.Remove,
+		BodyTransformation: &transformation.TransformationTemplate_Passthrough{
+			Passthrough: &transformation.Passthrough{},
+		},
+	}
+	// header values are inja templates in transformations
+	for _, header := range append(append([]gwv1.HTTPHeader{}, config.Add...), config.Set...) {
+		if strings.Contains(header.Value, "{{") || strings.Contains(header.Value, "{%") || strings.Contains(header.Value, "{#") {
+			return InvalidEarlyHeaderValueErr(string(header.Name))
+		}
+	}
+	for _, header := range config.Add {
+		template.HeadersToAppend = append(template.HeadersToAppend, &transformation.TransformationTemplate_HeaderToAppend{
+			Key:   string(header.Name),
+			Value: &transformation.InjaTemplate{Text: header.Value},
+		})
+	}
+	if len(config.Set) > 0 {
+		template.Headers = map[string]*transformation.InjaTemplate{}
+		for _, header := range config.Set {
+			template.GetHeaders()[string(header.Name)] = &transformation.InjaTemplate{Text: header.Value}
+		}
+	}
+
+	if outputRoute.GetOptions() == nil {
+		outputRoute.Options = &v1.RouteOptions{}
+	}
+	options := outputRoute.GetOptions()
+	if options.GetStagedTransformations() == nil {
+		options.StagedTransformations = &transformation.TransformationStages{}
+	}
+	options.GetStagedTransformations().Early = &transformation.RequestResponseTransformations{
+		RequestTransforms: []*transformation.RequestMatch{{
+			RequestTransformation: &transformation.Transformation{
+				TransformationType: &transformation.Transformation_TransformationTemplate{
+					TransformationTemplate: template,
+				},
+			},
+		}},
+	}
+	return nil
+}
+
 func (p *plugin) applyResponseFilter(
 	config *gwv1.HTTPHeaderFilter,
 	outputRoute *v1.Route,
@@ -1,14 +1,25 @@
 package headermodifier_test
 
 import (
+	"context"
+
 	"github.com/golang/protobuf/ptypes/wrappers"
 	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/filtertests"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/headermodifier"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
 	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
 	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/headers"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/transformation"
 	"github.com/solo-io/solo-kit/pkg/api/external/envoy/api/v2/core"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
@@ -28,7 +39,7 @@ var _ = DescribeTable(
 	},
 	Entry(
 		"applies request header modifier filter",
-		headermodifier.NewPlugin(),
+		headermodifier.NewPlugin(nil),
 		gwv1.HTTPRouteFilter{
 			Type: gwv1.HTTPRouteFilterRequestHeaderModifier,
 			RequestHeaderModifier: &gwv1.HTTPHeaderFilter{
@@ -77,7 +88,7 @@ var _ = DescribeTable(
 	),
 	Entry(
 		"applies response header modifier filter",
-		headermodifier.NewPlugin(),
+		headermodifier.NewPlugin(nil),
 		gwv1.HTTPRouteFilter{
 			Type: gwv1.HTTPRouteFilterResponseHeaderModifier,
 			ResponseHeaderModifier: &gwv1.HTTPHeaderFilter{
@@ -121,3 +132,146 @@ var _ = DescribeTable(
 		},
 	),
 )
+
+var _ = Describe("HeaderModifierPlugin stages", func() {
+	requestHeaderModifier := gwv1.HTTPRouteFilter{
+		Type: gwv1.HTTPRouteFilterRequestHeaderModifier,
+		RequestHeaderModifier: &gwv1.HTTPHeaderFilter{
+			Add:    []gwv1.HTTPHeader{{Name: "x-tenant", Value: "a"}},
+			Set:    []gwv1.HTTPHeader{{Name: "x-auth-scope", Value: "internal"}},
+			Remove: []string{"x-debug"},
+		},
+	}
+
+	apply := func(annotations map[string]string, outputRoute *v1.Route, filters ...gwv1.HTTPRouteFilter) error {
+		queries := testutils.BuildGatewayQueries([]client.Object{
+			&solokubev1.RouteOption{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "policy",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+				Spec: sologatewayv1.RouteOption{},
+			},
+		})
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: &gwv1.HTTPRouteRule{
+				Filters: append(filters, gwv1.HTTPRouteFilter{
+					Type: gwv1.HTTPRouteFilterExtensionRef,
+					ExtensionRef: &gwv1.LocalObjectReference{
+						Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Name:  "policy",
+					},
+				}),
+			},
+		}
+		return headermodifier.NewPlugin(queries).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+	}
+
+	It("modifies request headers in the early transformation stage, before auth", func() {
+		route := &v1.Route{Options: &v1.RouteOptions{}}
+		err := apply(map[string]string{
+			headermodifier.RequestHeaderModifierStageAnnotation: headermodifier.EarlyStage,
+		}, route, requestHeaderModifier)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetHeaderManipulation()).To(BeNil())
+		Expect(route.GetOptions().GetStagedTransformations().GetRegular()).To(BeNil())
+		Expect(proto.Equal(route.GetOptions().GetStagedTransformations().GetEarly(), &transformation.RequestResponseTransformations{
+			RequestTransforms: []*transformation.RequestMatch{{
+				RequestTransformation: &transformation.Transformation{
+					TransformationType: &transformation.Transformation_TransformationTemplate{
+						TransformationTemplate: &transformation.TransformationTemplate{
+							Headers: map[string]*transformation.InjaTemplate{
+								"x-auth-scope": {Text: "internal"},
+							},
+							HeadersToAppend: []*transformation.TransformationTemplate_HeaderToAppend{{
+								Key:   "x-tenant",
+								Value: &transformation.InjaTemplate{Text: "a"},
+							}},
+							HeadersToRemove: []string{"x-debug"},
+							BodyTransformation: &transformation.TransformationTemplate_Passthrough{
+								Passthrough: &transformation.Passthrough{},
+							},
+						},
+					},
+				},
+			}},
+		})).To(BeTrue())
+	})
+
+	DescribeTable("modifies request headers at the router by default",
+		func(annotations map[string]string) {
+			route := &v1.Route{Options: &v1.RouteOptions{}}
+			err := apply(annotations, route, requestHeaderModifier)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(route.GetOptions().GetStagedTransformations()).To(BeNil())
+			Expect(route.GetOptions().GetHeaderManipulation().GetRequestHeadersToAdd()).To(HaveLen(2))
+			Expect(route.GetOptions().GetHeaderManipulation().GetRequestHeadersToRemove()).To(Equal([]string{"x-debug"}))
+		},
+		Entry("without the annotation", nil),
+		Entry("in the late stage", map[string]string{
+			headermodifier.RequestHeaderModifierStageAnnotation: headermodifier.LateStage,
+		}),
+	)
+
+	It("keeps modifying response headers at the router in the early stage", func() {
+		route := &v1.Route{Options: &v1.RouteOptions{}}
+		err := apply(map[string]string{
+			headermodifier.RequestHeaderModifierStageAnnotation: headermodifier.EarlyStage,
+		}, route, requestHeaderModifier, gwv1.HTTPRouteFilter{
+			Type: gwv1.HTTPRouteFilterResponseHeaderModifier,
+			ResponseHeaderModifier: &gwv1.HTTPHeaderFilter{
+				Remove: []string{"server"},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetStagedTransformations().GetEarly().GetRequestTransforms()).To(HaveLen(1))
+		Expect(route.GetOptions().GetHeaderManipulation().GetRequestHeadersToAdd()).To(BeEmpty())
+		Expect(route.GetOptions().GetHeaderManipulation().GetResponseHeadersToRemove()).To(Equal([]string{"server"}))
+	})
+
+	It("rejects early transformations of the RouteOption", func() {
+		early := &transformation.RequestResponseTransformations{
+			RequestTransforms: []*transformation.RequestMatch{{ClearRouteCache: true}},
+		}
+		route := &v1.Route{
+			Options: &v1.RouteOptions{
+				StagedTransformations: &transformation.TransformationStages{Early: early},
+			},
+		}
+		err := apply(map[string]string{
+			headermodifier.RequestHeaderModifierStageAnnotation: headermodifier.EarlyStage,
+		}, route, requestHeaderModifier)
+		Expect(err).To(MatchError(headermodifier.ConflictingEarlyTransformationErr))
+		Expect(route.GetOptions().GetStagedTransformations().GetEarly()).To(Equal(early))
+	})
+
+	It("rejects template delimiters in early header values", func() {
+		route := &v1.Route{Options: &v1.RouteOptions{}}
+		err := apply(map[string]string{
+			headermodifier.RequestHeaderModifierStageAnnotation: headermodifier.EarlyStage,
+		}, route, gwv1.HTTPRouteFilter{
+			Type: gwv1.HTTPRouteFilterRequestHeaderModifier,
+			RequestHeaderModifier: &gwv1.HTTPHeaderFilter{
+				Set: []gwv1.HTTPHeader{{Name: "x-template", Value: "{{ header(\"host\") }}"}},
+			},
+		})
+		Expect(err).To(MatchError(headermodifier.InvalidEarlyHeaderValueErr("x-template").Error()))
+		Expect(route.GetOptions().GetStagedTransformations()).To(BeNil())
+	})
+
+	It("rejects an unknown stage", func() {
+		route := &v1.Route{Options: &v1.RouteOptions{}}
+		err := apply(map[string]string{
+			headermodifier.RequestHeaderModifierStageAnnotation: "pre-routing",
+		}, route, requestHeaderModifier)
+		Expect(err).To(MatchError(headermodifier.UnknownStageErr("pre-routing").Error()))
+		Expect(route.GetOptions().GetHeaderManipulation()).To(BeNil())
+	})
+})
@@ -0,0 +1,134 @@
+package healthcheck
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/api/v2/core"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Annotations set on a Service to actively health check the endpoints of the Upstreams discovered from it.
+const (
+	// HealthCheckAnnotation enables health checking, and is either "http" or "tcp".
+	// TCP health checks only verify that a connection can be established.
+	HealthCheckAnnotation = "gateway2.solo.io/health-check"
+	// HealthCheckPathAnnotation is the path requested by HTTP health checks, defaulting to "/"
+	HealthCheckPathAnnotation = "gateway2.solo.io/health-check-path"
+	// HealthCheckIntervalAnnotation is the time between health checks, defaulting to 10s
+	HealthCheckIntervalAnnotation = "gateway2.solo.io/health-check-interval"
+	// HealthCheckTimeoutAnnotation is the time to wait for a health check response, defaulting to 5s
+	HealthCheckTimeoutAnnotation = "gateway2.solo.io/health-check-timeout"
+	// HealthCheckHealthyThresholdAnnotation is the number of consecutive successful health checks
+	// before an endpoint is marked healthy, defaulting to 2
+	HealthCheckHealthyThresholdAnnotation = "gateway2.solo.io/health-check-healthy-threshold"
+	// HealthCheckUnhealthyThresholdAnnotation is the number of consecutive failed health checks
+	// before an endpoint is marked unhealthy, defaulting to 3
+	HealthCheckUnhealthyThresholdAnnotation = "gateway2.solo.io/health-check-unhealthy-threshold"
+)
+
+const (
+	HttpHealthCheck = "http"
+	TcpHealthCheck  = "tcp"
+)
+
+var (
+	defaultInterval           = 10 * time.Second
+	defaultTimeout            = 5 * time.Second
+	defaultHealthyThreshold   = uint32(2)
+	defaultUnhealthyThreshold = uint32(3)
+
+	UnknownHealthCheckErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be one of %s or %s", value, HealthCheckAnnotation, HttpHealthCheck, TcpHealthCheck)
+	}
+	InvalidThresholdErr = func(annotation string) error {
+		return errors.Errorf("annotation %s must be at least 1", annotation)
+	}
+)
+
+var _ plugins.BackendPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyBackendPlugin(
+	ctx context.Context,
+	backendCtx *plugins.BackendContext,
+	outputUpstream *v1.Upstream,
+) error {
+	annotations := backendCtx.Service.GetAnnotations()
+	checkType, ok := annotations[HealthCheckAnnotation]
+	if !ok {
+		return nil
+	}
+
+	healthCheck := &core.HealthCheck{
+		Interval:           durationpb.New(defaultInterval),
+		Timeout:            durationpb.New(defaultTimeout),
+		HealthyThreshold:   &wrappers.UInt32Value{Value: defaultHealthyThreshold},
+		UnhealthyThreshold: &wrappers.UInt32Value{Value: defaultUnhealthyThreshold},
+	}
+	switch checkType {
+	case HttpHealthCheck:
+		path := "/"
+		if p, ok := annotations[HealthCheckPathAnnotation]; ok && p != "" {
+			path = p
+		}
+		healthCheck.HealthChecker = &core.HealthCheck_HttpHealthCheck_{
+			HttpHealthCheck: &core.HealthCheck_HttpHealthCheck{
+				Path: path,
+			},
+		}
+	case TcpHealthCheck:
+		healthCheck.HealthChecker = &core.HealthCheck_TcpHealthCheck_{
+			TcpHealthCheck: &core.HealthCheck_TcpHealthCheck{},
+		}
+	default:
+		return UnknownHealthCheckErr(checkType)
+	}
+
+	interval, err := utils.GetDurationAnnotation(annotations, HealthCheckIntervalAnnotation)
+	if err != nil {
+		return err
+	}
+	if interval != nil {
+		healthCheck.Interval = interval
+	}
+	timeout, err := utils.GetDurationAnnotation(annotations, HealthCheckTimeoutAnnotation)
+	if err != nil {
+		return err
+	}
+	if timeout != nil {
+		healthCheck.Timeout = timeout
+	}
+	for _, threshold := range []struct {
+		annotation string
+		value      **wrappers.UInt32Value
+	}{
+		{HealthCheckHealthyThresholdAnnotation, &healthCheck.HealthyThreshold},
+		{HealthCheckUnhealthyThresholdAnnotation, &healthCheck.UnhealthyThreshold},
+	} {
+		value, err := utils.GetUint32Annotation(annotations, threshold.annotation)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			continue
+		}
+		if value.GetValue() == 0 {
+			return InvalidThresholdErr(threshold.annotation)
+		}
+		*threshold.value = value
+	}
+
+	outputUpstream.HealthChecks = []*core.HealthCheck{healthCheck}
+	return nil
+}
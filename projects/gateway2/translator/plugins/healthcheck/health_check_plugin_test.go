@@ -0,0 +1,115 @@
+package healthcheck
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/api/v2/core"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("HealthCheckPlugin", func() {
+	apply := func(annotations map[string]string) (*v1.Upstream, error) {
+		backendCtx := &plugins.BackendContext{
+			Service: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "svc",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+			},
+		}
+		us := &v1.Upstream{}
+		err := NewPlugin().ApplyBackendPlugin(context.Background(), backendCtx, us)
+		return us, err
+	}
+
+	It("translates an http health check", func() {
+		us, err := apply(map[string]string{
+			HealthCheckAnnotation:                   HttpHealthCheck,
+			HealthCheckPathAnnotation:               "/healthz",
+			HealthCheckIntervalAnnotation:           "30s",
+			HealthCheckTimeoutAnnotation:            "2s",
+			HealthCheckHealthyThresholdAnnotation:   "1",
+			HealthCheckUnhealthyThresholdAnnotation: "5",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(us.GetHealthChecks()).To(HaveLen(1))
+		Expect(proto.Equal(us.GetHealthChecks()[0], &core.HealthCheck{
+			Interval:           durationpb.New(30 * time.Second),
+			Timeout:            durationpb.New(2 * time.Second),
+			HealthyThreshold:   &wrappers.UInt32Value{Value: 1},
+			UnhealthyThreshold: &wrappers.UInt32Value{Value: 5},
+			HealthChecker: &core.HealthCheck_HttpHealthCheck_{
+				HttpHealthCheck: &core.HealthCheck_HttpHealthCheck{
+					Path: "/healthz",
+				},
+			},
+		})).To(BeTrue())
+	})
+
+	It("translates a tcp health check with defaults", func() {
+		us, err := apply(map[string]string{
+			HealthCheckAnnotation: TcpHealthCheck,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(us.GetHealthChecks()).To(HaveLen(1))
+		Expect(proto.Equal(us.GetHealthChecks()[0], &core.HealthCheck{
+			Interval:           durationpb.New(10 * time.Second),
+			Timeout:            durationpb.New(5 * time.Second),
+			HealthyThreshold:   &wrappers.UInt32Value{Value: 2},
+			UnhealthyThreshold: &wrappers.UInt32Value{Value: 3},
+			HealthChecker: &core.HealthCheck_TcpHealthCheck_{
+				TcpHealthCheck: &core.HealthCheck_TcpHealthCheck{},
+			},
+		})).To(BeTrue())
+	})
+
+	It("defaults the http health check path", func() {
+		us, err := apply(map[string]string{
+			HealthCheckAnnotation: HttpHealthCheck,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(us.GetHealthChecks()[0].GetHttpHealthCheck().GetPath()).To(Equal("/"))
+	})
+
+	It("does nothing without the annotation", func() {
+		us, err := apply(map[string]string{
+			HealthCheckPathAnnotation: "/healthz",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(us.GetHealthChecks()).To(BeEmpty())
+	})
+
+	DescribeTable("rejects invalid health checks",
+		func(annotations map[string]string, expectedErr string) {
+			us, err := apply(annotations)
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			Expect(us.GetHealthChecks()).To(BeEmpty())
+		},
+		Entry("unknown type",
+			map[string]string{HealthCheckAnnotation: "grpc"},
+			UnknownHealthCheckErr("grpc").Error()),
+		Entry("unparseable interval",
+			map[string]string{HealthCheckAnnotation: TcpHealthCheck, HealthCheckIntervalAnnotation: "often"},
+			HealthCheckIntervalAnnotation),
+		Entry("negative timeout",
+			map[string]string{HealthCheckAnnotation: TcpHealthCheck, HealthCheckTimeoutAnnotation: "-1s"},
+			HealthCheckTimeoutAnnotation),
+		Entry("non-numeric threshold",
+			map[string]string{HealthCheckAnnotation: HttpHealthCheck, HealthCheckHealthyThresholdAnnotation: "two"},
+			HealthCheckHealthyThresholdAnnotation),
+		Entry("zero threshold",
+			map[string]string{HealthCheckAnnotation: HttpHealthCheck, HealthCheckUnhealthyThresholdAnnotation: "0"},
+			InvalidThresholdErr(HealthCheckUnhealthyThresholdAnnotation).Error()),
+	)
+})
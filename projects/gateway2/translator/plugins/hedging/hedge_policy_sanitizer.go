@@ -0,0 +1,95 @@
+package hedging
+
+import (
+	"context"
+
+	envoy_config_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	v1snap "github.com/solo-io/gloo/projects/gloo/pkg/api/v1/gloosnapshot"
+	"github.com/solo-io/gloo/projects/gloo/pkg/syncer/sanitizer"
+	"github.com/solo-io/gloo/projects/gloo/pkg/translator"
+	"github.com/solo-io/gloo/projects/gloo/pkg/xds"
+	"github.com/solo-io/go-utils/contextutils"
+	envoycache "github.com/solo-io/solo-kit/pkg/api/v1/control-plane/cache"
+	"github.com/solo-io/solo-kit/pkg/api/v1/control-plane/types"
+	"github.com/solo-io/solo-kit/pkg/api/v2/reporter"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ sanitizer.XdsSanitizer = &hedgePolicySanitizer{}
+
+// hedgePolicySanitizer sets the hedge policy of the envoy routes marked by the plugin, and removes the marker
+type hedgePolicySanitizer struct{}
+
+func NewSanitizer() *hedgePolicySanitizer {
+	return &hedgePolicySanitizer{}
+}
+
+func (s *hedgePolicySanitizer) SanitizeSnapshot(
+	ctx context.Context,
+	glooSnapshot *v1snap.ApiSnapshot,
+	xdsSnapshot envoycache.Snapshot,
+	reports reporter.ResourceReports,
+) envoycache.Snapshot {
+	var (
+		routeConfigs []*envoy_config_route_v3.RouteConfiguration
+		hedged       bool
+	)
+	for _, item := range xdsSnapshot.GetResources(types.RouteTypeV3).Items {
+		routeConfig, ok := item.ResourceProto().(*envoy_config_route_v3.RouteConfiguration)
+		if !ok {
+			contextutils.LoggerFrom(ctx).DPanicf("xds snapshot resources of type RouteTypeV3 were not "+
+				"converted to *envoy_config_route_v3.RouteConfiguration, instead found %T", item.ResourceProto())
+			return xdsSnapshot
+		}
+		if hasHedgedRoutes(routeConfig) {
+			// the snapshot resources are not modified, their version was computed from them
+			routeConfig = proto.Clone(routeConfig).(*envoy_config_route_v3.RouteConfiguration)
+			hedgeRoutes(routeConfig)
+			hedged = true
+		}
+		routeConfigs = append(routeConfigs, routeConfig)
+	}
+	if !hedged {
+		return xdsSnapshot
+	}
+
+	return xds.NewSnapshotFromResources(
+		xdsSnapshot.GetResources(types.EndpointTypeV3),
+		xdsSnapshot.GetResources(types.ClusterTypeV3),
+		translator.MakeRdsResources(routeConfigs),
+		xdsSnapshot.GetResources(types.ListenerTypeV3),
+	)
+}
+
+func hasHedgedRoutes(routeConfig *envoy_config_route_v3.RouteConfiguration) bool {
+	for _, vh := range routeConfig.GetVirtualHosts() {
+		for _, route := range vh.GetRoutes() {
+			if _, ok := route.GetMetadata().GetFilterMetadata()[HedgeMetadataNamespace]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hedgeRoutes(routeConfig *envoy_config_route_v3.RouteConfiguration) {
+	for _, vh := range routeConfig.GetVirtualHosts() {
+		for _, route := range vh.GetRoutes() {
+			marker, ok := route.GetMetadata().GetFilterMetadata()[HedgeMetadataNamespace]
+			if !ok {
+				continue
+			}
+			delete(route.GetMetadata().GetFilterMetadata(), HedgeMetadataNamespace)
+			if len(route.GetMetadata().GetFilterMetadata()) == 0 {
+				route.Metadata = nil
+			}
+			// routes replaced by a direct response, e.g. by a sanitizer, have no route action left to hedge
+			if route.GetRoute() == nil {
+				continue
+			}
+			route.GetRoute().HedgePolicy = &envoy_config_route_v3.HedgePolicy{
+				HedgeOnPerTryTimeout: marker.GetFields()[hedgeOnPerTryTimeoutField].GetBoolValue(),
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package hedging
+
+import (
+	"context"
+
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_config_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/solo-io/gloo/projects/gloo/pkg/xds"
+	envoycache "github.com/solo-io/solo-kit/pkg/api/v1/control-plane/cache"
+	"github.com/solo-io/solo-kit/pkg/api/v1/control-plane/resource"
+	"github.com/solo-io/solo-kit/pkg/api/v1/control-plane/types"
+)
+
+var _ = Describe("HedgePolicySanitizer", func() {
+	var (
+		hedgeMarker = &structpb.Struct{
+			Fields: map[string]*structpb.Value{
+				"hedge_on_per_try_timeout": structpb.NewBoolValue(true),
+			},
+		}
+		otherMetadata = &structpb.Struct{
+			Fields: map[string]*structpb.Value{
+				"team": structpb.NewStringValue("blue"),
+			},
+		}
+	)
+
+	route := func(name string, filterMetadata map[string]*structpb.Struct) *envoy_config_route_v3.Route {
+		r := &envoy_config_route_v3.Route{
+			Name: name,
+			Action: &envoy_config_route_v3.Route_Route{
+				Route: &envoy_config_route_v3.RouteAction{
+					ClusterSpecifier: &envoy_config_route_v3.RouteAction_Cluster{Cluster: "cluster"},
+					RetryPolicy: &envoy_config_route_v3.RetryPolicy{
+						RetryOn: "5xx",
+					},
+				},
+			},
+		}
+		if filterMetadata != nil {
+			r.Metadata = &envoy_config_core_v3.Metadata{FilterMetadata: filterMetadata}
+		}
+		return r
+	}
+
+	snapshot := func(routes ...*envoy_config_route_v3.Route) envoycache.Snapshot {
+		return xds.NewSnapshotFromResources(
+			envoycache.NewResources("", nil),
+			envoycache.NewResources("", nil),
+			envoycache.NewResources("routes", []envoycache.Resource{
+				resource.NewEnvoyResource(&envoy_config_route_v3.RouteConfiguration{
+					Name: "listener-8080-routes",
+					VirtualHosts: []*envoy_config_route_v3.VirtualHost{{
+						Name:    "http~example_com",
+						Domains: []string{"example.com"},
+						Routes:  routes,
+					}},
+				}),
+			}),
+			envoycache.NewResources("", nil),
+		)
+	}
+
+	sanitizedRoutes := func(snap envoycache.Snapshot) []*envoy_config_route_v3.Route {
+		routeConfig := snap.GetResources(types.RouteTypeV3).Items["listener-8080-routes"].ResourceProto().(*envoy_config_route_v3.RouteConfiguration)
+		return routeConfig.GetVirtualHosts()[0].GetRoutes()
+	}
+
+	It("hedges the marked routes on the per try timeout", func() {
+		in := snapshot(
+			route("hedged", map[string]*structpb.Struct{HedgeMetadataNamespace: hedgeMarker}),
+			route("other", nil),
+		)
+		out := NewSanitizer().SanitizeSnapshot(context.Background(), nil, in, nil)
+
+		hedged := route("hedged", nil)
+		hedged.GetRoute().HedgePolicy = &envoy_config_route_v3.HedgePolicy{HedgeOnPerTryTimeout: true}
+		routes := sanitizedRoutes(out)
+		Expect(routes).To(HaveLen(2))
+		Expect(proto.Equal(routes[0], hedged)).To(BeTrue())
+		Expect(proto.Equal(routes[1], route("other", nil))).To(BeTrue())
+	})
+
+	It("keeps the other metadata of the route", func() {
+		in := snapshot(route("hedged", map[string]*structpb.Struct{
+			HedgeMetadataNamespace: hedgeMarker,
+			"example.com":          otherMetadata,
+		}))
+		out := NewSanitizer().SanitizeSnapshot(context.Background(), nil, in, nil)
+
+		routes := sanitizedRoutes(out)
+		Expect(routes[0].GetRoute().GetHedgePolicy().GetHedgeOnPerTryTimeout()).To(BeTrue())
+		Expect(routes[0].GetMetadata().GetFilterMetadata()).To(HaveLen(1))
+		Expect(proto.Equal(routes[0].GetMetadata().GetFilterMetadata()["example.com"], otherMetadata)).To(BeTrue())
+	})
+
+	It("does not modify the input snapshot", func() {
+		in := snapshot(route("hedged", map[string]*structpb.Struct{HedgeMetadataNamespace: hedgeMarker}))
+		version := in.GetResources(types.RouteTypeV3).Version
+		out := NewSanitizer().SanitizeSnapshot(context.Background(), nil, in, nil)
+
+		Expect(sanitizedRoutes(in)[0].GetRoute().GetHedgePolicy()).To(BeNil())
+		Expect(sanitizedRoutes(in)[0].GetMetadata().GetFilterMetadata()).To(HaveKey(HedgeMetadataNamespace))
+		Expect(out.GetResources(types.RouteTypeV3).Version).NotTo(Equal(version))
+	})
+
+	It("returns the snapshot as is without marked routes", func() {
+		in := snapshot(route("other", map[string]*structpb.Struct{"example.com": otherMetadata}))
+		Expect(NewSanitizer().SanitizeSnapshot(context.Background(), nil, in, nil)).To(BeIdenticalTo(in))
+	})
+
+	It("removes the marker of routes without route action", func() {
+		direct := &envoy_config_route_v3.Route{
+			Name: "direct",
+			Action: &envoy_config_route_v3.Route_DirectResponse{
+				DirectResponse: &envoy_config_route_v3.DirectResponseAction{Status: 503},
+			},
+			Metadata: &envoy_config_core_v3.Metadata{
+				FilterMetadata: map[string]*structpb.Struct{HedgeMetadataNamespace: hedgeMarker},
+			},
+		}
+		out := NewSanitizer().SanitizeSnapshot(context.Background(), nil, snapshot(direct), nil)
+
+		routes := sanitizedRoutes(out)
+		Expect(routes[0].GetMetadata()).To(BeNil())
+		Expect(routes[0].GetDirectResponse().GetStatus()).To(Equal(uint32(503)))
+	})
+})
@@ -0,0 +1,126 @@
+package hedging
+
+import (
+	"context"
+	"strconv"
+
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Annotations set on a RouteOption to hedge the requests of the routes it is applied to, i.e. send additional
+// requests to other endpoints when the first one is slow.
+const (
+	// HedgeAdditionalRequestsAnnotation would be the number of requests sent in addition to the first one. Envoy does
+	// not implement hedging on the initial requests, so the annotation is rejected.
+	HedgeAdditionalRequestsAnnotation = "gateway2.solo.io/hedge-additional-requests"
+	// HedgeOnPerTryTimeoutAnnotation is "true" to send a new request when the per try timeout of the retry policy of
+	// the route elapses, keeping the first request in flight and responding with the first response received.
+	HedgeOnPerTryTimeoutAnnotation = "gateway2.solo.io/hedge-on-per-try-timeout"
+)
+
+// HedgeMetadataNamespace is the envoy metadata namespace marking the routes to hedge. Gloo routes cannot configure
+// an envoy hedge policy, so the sanitizer of this package sets it on the envoy routes carrying the metadata.
+const HedgeMetadataNamespace = "gateway2.solo.io/hedge"
+
+const hedgeOnPerTryTimeoutField = "hedge_on_per_try_timeout"
+
+var (
+	AdditionalRequestsUnsupportedErr = errors.Errorf("annotation %s cannot be applied: envoy does not hedge the initial requests, use annotation %s instead", HedgeAdditionalRequestsAnnotation, HedgeOnPerTryTimeoutAnnotation)
+	InvalidHedgeOnPerTryTimeoutErr   = func(value string) error {
+		return errors.Errorf("invalid value '%s' of annotation %s: must be true or false", value, HedgeOnPerTryTimeoutAnnotation)
+	}
+	MissingPerTryTimeoutErr = errors.Errorf("annotation %s requires the retry policy of the RouteOption to set a per try timeout", HedgeOnPerTryTimeoutAnnotation)
+	ConflictingMetadataErr  = errors.Errorf("annotation %s cannot be combined with envoy metadata of the RouteOption in namespace %s", HedgeOnPerTryTimeoutAnnotation, HedgeMetadataNamespace)
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+)
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+// DependsOn the routeoptions plugin, which would otherwise replace the hedging marker of the route
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+	hedge, err := getHedgeOnPerTryTimeout(routeOption.GetAnnotations())
+	if err != nil || !hedge {
+		return err
+	}
+	// the retry policy is set by the RouteOption plugin, which runs first
+	options := outputRoute.GetOptions()
+	if options.GetRetries().GetPerTryTimeout() == nil {
+		return MissingPerTryTimeoutErr
+	}
+	if _, ok := options.GetEnvoyMetadata()[HedgeMetadataNamespace]; ok {
+		return ConflictingMetadataErr
+	}
+	if options.GetEnvoyMetadata() == nil {
+		options.EnvoyMetadata = map[string]*structpb.Struct{}
+	}
+	options.GetEnvoyMetadata()[HedgeMetadataNamespace] = &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			hedgeOnPerTryTimeoutField: structpb.NewBoolValue(true),
+		},
+	}
+	return nil
+}
+
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	hedge, err := getHedgeOnPerTryTimeout(routeOption.GetAnnotations())
+	if err != nil || !hedge {
+		return err
+	}
+	options := routeOption.Spec.GetOptions()
+	if options.GetRetries().GetPerTryTimeout() == nil {
+		return MissingPerTryTimeoutErr
+	}
+	if _, ok := options.GetEnvoyMetadata()[HedgeMetadataNamespace]; ok {
+		return ConflictingMetadataErr
+	}
+	return nil
+}
+
+func getHedgeOnPerTryTimeout(annotations map[string]string) (bool, error) {
+	if _, ok := annotations[HedgeAdditionalRequestsAnnotation]; ok {
+		return false, AdditionalRequestsUnsupportedErr
+	}
+	value, ok := annotations[HedgeOnPerTryTimeoutAnnotation]
+	if !ok {
+		return false, nil
+	}
+	hedge, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, InvalidHedgeOnPerTryTimeoutErr(value)
+	}
+	return hedge, nil
+}
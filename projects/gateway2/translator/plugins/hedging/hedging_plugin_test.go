@@ -0,0 +1,142 @@
+package hedging
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/retries"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("HedgingPlugin", func() {
+	apply := func(annotations map[string]string, outputRoute *v1.Route) error {
+		queries := testutils.BuildGatewayQueries([]client.Object{
+			&solokubev1.RouteOption{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "policy",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+				Spec: sologatewayv1.RouteOption{},
+			},
+		})
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: &gwv1.HTTPRouteRule{
+				Filters: []gwv1.HTTPRouteFilter{{
+					Type: gwv1.HTTPRouteFilterExtensionRef,
+					ExtensionRef: &gwv1.LocalObjectReference{
+						Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Name:  "policy",
+					},
+				}},
+			},
+		}
+		return NewPlugin(queries).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+	}
+
+	retryOptions := func() *v1.RouteOptions {
+		return &v1.RouteOptions{
+			Retries: &retries.RetryPolicy{
+				NumRetries:    2,
+				PerTryTimeout: durationpb.New(100 * time.Millisecond),
+			},
+		}
+	}
+
+	It("marks the route to hedge on the per try timeout", func() {
+		route := &v1.Route{Options: retryOptions()}
+		Expect(apply(map[string]string{HedgeOnPerTryTimeoutAnnotation: "true"}, route)).To(Succeed())
+		Expect(route.GetOptions().GetEnvoyMetadata()).To(HaveLen(1))
+		Expect(proto.Equal(route.GetOptions().GetEnvoyMetadata()[HedgeMetadataNamespace], &structpb.Struct{
+			Fields: map[string]*structpb.Value{
+				"hedge_on_per_try_timeout": structpb.NewBoolValue(true),
+			},
+		})).To(BeTrue())
+		Expect(proto.Equal(route.GetOptions().GetRetries(), retryOptions().GetRetries())).To(BeTrue())
+	})
+
+	It("does not hedge when disabled", func() {
+		route := &v1.Route{Options: retryOptions()}
+		Expect(apply(map[string]string{HedgeOnPerTryTimeoutAnnotation: "false"}, route)).To(Succeed())
+		Expect(proto.Equal(route.GetOptions(), retryOptions())).To(BeTrue())
+	})
+
+	It("does nothing without the annotations", func() {
+		route := &v1.Route{}
+		Expect(apply(map[string]string{"other": "value"}, route)).To(Succeed())
+		Expect(route.GetOptions()).To(BeNil())
+	})
+
+	It("rejects additional requests", func() {
+		route := &v1.Route{Options: retryOptions()}
+		err := apply(map[string]string{HedgeAdditionalRequestsAnnotation: "1", HedgeOnPerTryTimeoutAnnotation: "true"}, route)
+		Expect(err).To(MatchError(AdditionalRequestsUnsupportedErr))
+		Expect(route.GetOptions().GetEnvoyMetadata()).To(BeEmpty())
+	})
+
+	It("rejects invalid values", func() {
+		route := &v1.Route{Options: retryOptions()}
+		err := apply(map[string]string{HedgeOnPerTryTimeoutAnnotation: "always"}, route)
+		Expect(err).To(MatchError(InvalidHedgeOnPerTryTimeoutErr("always").Error()))
+		Expect(route.GetOptions().GetEnvoyMetadata()).To(BeEmpty())
+	})
+
+	DescribeTable("rejects routes without a per try timeout",
+		func(options *v1.RouteOptions) {
+			route := &v1.Route{Options: options}
+			err := apply(map[string]string{HedgeOnPerTryTimeoutAnnotation: "true"}, route)
+			Expect(err).To(MatchError(MissingPerTryTimeoutErr))
+			Expect(route.GetOptions().GetEnvoyMetadata()).To(BeEmpty())
+		},
+		Entry("no options", nil),
+		Entry("no retry policy", &v1.RouteOptions{}),
+		Entry("no per try timeout", &v1.RouteOptions{Retries: &retries.RetryPolicy{NumRetries: 2}}),
+	)
+
+	It("rejects conflicting metadata", func() {
+		route := &v1.Route{Options: retryOptions()}
+		route.GetOptions().EnvoyMetadata = map[string]*structpb.Struct{HedgeMetadataNamespace: {}}
+		err := apply(map[string]string{HedgeOnPerTryTimeoutAnnotation: "true"}, route)
+		Expect(err).To(MatchError(ConflictingMetadataErr))
+	})
+	DescribeTable("validates the RouteOption",
+		func(options *v1.RouteOptions, expectedErr error) {
+			err := NewPlugin(nil).ValidateRouteOption(context.Background(), &solokubev1.RouteOption{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{HedgeOnPerTryTimeoutAnnotation: "true"},
+				},
+				Spec: sologatewayv1.RouteOption{Options: options},
+			})
+			if expectedErr == nil {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedErr))
+			}
+		},
+		Entry("per try timeout", retryOptions(), nil),
+		Entry("no per try timeout", &v1.RouteOptions{Retries: &retries.RetryPolicy{NumRetries: 2}}, MissingPerTryTimeoutErr),
+		Entry("conflicting metadata", &v1.RouteOptions{
+			Retries:       retryOptions().GetRetries(),
+			EnvoyMetadata: map[string]*structpb.Struct{HedgeMetadataNamespace: {}},
+		}, ConflictingMetadataErr),
+	)
+})
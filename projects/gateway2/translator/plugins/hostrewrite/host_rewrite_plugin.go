@@ -0,0 +1,137 @@
+package hostrewrite
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/network"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// HostRewriteFromBackendAnnotation is set to "true" on a RouteOption to rewrite the Host header of requests
+// to the cluster DNS name of the Service the route forwards to, in the cluster domain of the controller's resolv.conf.
+// Literal host rewrites are configured with the URLRewrite filter or the RouteOption's hostRewrite option instead.
+// A hostname set by the URLRewrite filter takes precedence over the annotation. The annotation cannot be combined
+// with the host rewrite options of the RouteOption (e.g. hostRewrite or autoHostRewrite), which are kept on the
+// routes that report the conflict.
+const HostRewriteFromBackendAnnotation = "gateway2.solo.io/host-rewrite-from-backend"
+
+var (
+	InvalidHostRewriteFromBackendErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a boolean", value, HostRewriteFromBackendAnnotation)
+	}
+	MultipleBackendsErr  = errors.Errorf("annotation %s requires the rule to have exactly one backend", HostRewriteFromBackendAnnotation)
+	NonServiceBackendErr = errors.Errorf("annotation %s requires the rule's backend to be a Service", HostRewriteFromBackendAnnotation)
+
+	ConflictingHostRewriteErr = errors.Errorf("annotation %s cannot be combined with the host rewrite options of the RouteOption",
+		HostRewriteFromBackendAnnotation)
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+)
+
+type plugin struct {
+	queries       query.GatewayQueries
+	clusterDomain string
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return newPlugin(queries, network.GetClusterDomainName())
+}
+
+func newPlugin(queries query.GatewayQueries, clusterDomain string) *plugin {
+	return &plugin{
+		queries:       queries,
+		clusterDomain: clusterDomain,
+	}
+}
+
+// DependsOn the routeoptions plugin, which would otherwise replace the host rewrite of the route
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+	fromBackend, err := getHostRewriteFromBackend(routeOption.GetAnnotations())
+	if err != nil || !fromBackend {
+		return err
+	}
+
+	// a hostname set on the URLRewrite filter is an explicit choice and takes precedence
+	if filter := utils.FindAppliedRouteFilter(routeCtx, gwv1.HTTPRouteFilterURLRewrite); filter != nil &&
+		filter.URLRewrite != nil && filter.URLRewrite.Hostname != nil {
+		return nil
+	}
+	if outputRoute.GetOptions().GetHostRewriteType() != nil {
+		return ConflictingHostRewriteErr
+	}
+
+	if len(routeCtx.Rule.BackendRefs) != 1 {
+		return MultipleBackendsErr
+	}
+	backendRef := routeCtx.Rule.BackendRefs[0].BackendObjectReference
+	obj, err := p.queries.GetBackendForRef(ctx, p.queries.ObjToFrom(routeCtx.Route), &backendRef)
+	if err != nil {
+		// unresolved backends are reported when translating the route action
+		return nil
+	}
+	svc, ok := obj.(*corev1.Service)
+	if !ok {
+		return NonServiceBackendErr
+	}
+
+	if outputRoute.GetOptions() == nil {
+		outputRoute.Options = &v1.RouteOptions{}
+	}
+	outputRoute.GetOptions().HostRewriteType = &v1.RouteOptions_HostRewrite{
+		HostRewrite: fmt.Sprintf("%s.%s.svc.%s", svc.GetName(), svc.GetNamespace(), p.clusterDomain),
+	}
+	return nil
+}
+
+// ValidateRouteOption only validates the annotation, the backends are those of the routes the RouteOption is applied to
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	fromBackend, err := getHostRewriteFromBackend(routeOption.GetAnnotations())
+	if err != nil {
+		return err
+	}
+	if fromBackend && routeOption.Spec.GetOptions().GetHostRewriteType() != nil {
+		return ConflictingHostRewriteErr
+	}
+	return nil
+}
+
+func getHostRewriteFromBackend(annotations map[string]string) (bool, error) {
+	value, ok := annotations[HostRewriteFromBackendAnnotation]
+	if !ok {
+		return false, nil
+	}
+	fromBackend, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, InvalidHostRewriteFromBackendErr(value)
+	}
+	return fromBackend, nil
+}
@@ -0,0 +1,159 @@
+package hostrewrite
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("HostRewritePlugin", func() {
+	var (
+		routeOptionAnnotations map[string]string
+		filters                []gwv1.HTTPRouteFilter
+		backendRefs            []gwv1.HTTPBackendRef
+		clusterDomain          string
+		outputOptions          *v1.RouteOptions
+	)
+
+	BeforeEach(func() {
+		routeOptionAnnotations = map[string]string{
+			HostRewriteFromBackendAnnotation: "true",
+		}
+		filters = []gwv1.HTTPRouteFilter{{
+			Type: gwv1.HTTPRouteFilterExtensionRef,
+			ExtensionRef: &gwv1.LocalObjectReference{
+				Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+				Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+				Name:  "policy",
+			},
+		}}
+		backendRefs = []gwv1.HTTPBackendRef{backendRef("foo")}
+		clusterDomain = "cluster.local"
+		outputOptions = &v1.RouteOptions{}
+	})
+
+	apply := func() (*v1.Route, error) {
+		queries := testutils.BuildGatewayQueries([]client.Object{
+			testutils.RouteOption(routeOptionAnnotations),
+			service("foo"),
+			service("bar"),
+		})
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: &gwv1.HTTPRouteRule{
+				Filters:     filters,
+				BackendRefs: backendRefs,
+			},
+		}
+		outputRoute := &v1.Route{
+			Options: outputOptions,
+		}
+		err := newPlugin(queries, clusterDomain).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+		return outputRoute, err
+	}
+
+	It("rewrites the host to the backend service's cluster hostname", func() {
+		route, err := apply()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetHostRewrite()).To(Equal("foo.default.svc.cluster.local"))
+	})
+
+	It("rewrites the host in the cluster domain", func() {
+		clusterDomain = "corp.internal"
+		route, err := apply()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetHostRewrite()).To(Equal("foo.default.svc.corp.internal"))
+	})
+
+	It("does nothing without the annotation", func() {
+		routeOptionAnnotations = nil
+		route, err := apply()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetHostRewriteType()).To(BeNil())
+	})
+
+	It("prefers a literal hostname from the URLRewrite filter", func() {
+		hostname := gwv1.PreciseHostname("literal.example.com")
+		filters = append(filters, gwv1.HTTPRouteFilter{
+			Type: gwv1.HTTPRouteFilterURLRewrite,
+			URLRewrite: &gwv1.HTTPURLRewriteFilter{
+				Hostname: &hostname,
+			},
+		})
+		route, err := apply()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetHostRewriteType()).To(BeNil())
+	})
+
+	It("keeps the host rewrite option of the RouteOption, rejecting the annotation", func() {
+		outputOptions = &v1.RouteOptions{
+			HostRewriteType: &v1.RouteOptions_AutoHostRewrite{AutoHostRewrite: &wrappers.BoolValue{Value: true}},
+		}
+		route, err := apply()
+		Expect(err).To(MatchError(ConflictingHostRewriteErr))
+		Expect(route.GetOptions().GetAutoHostRewrite().GetValue()).To(BeTrue())
+	})
+
+	It("validates the RouteOption against its host rewrite options", func() {
+		routeOption := testutils.RouteOption(routeOptionAnnotations)
+		Expect(NewPlugin(nil).ValidateRouteOption(context.Background(), routeOption)).To(Succeed())
+
+		routeOption.Spec.Options = &v1.RouteOptions{
+			HostRewriteType: &v1.RouteOptions_HostRewrite{HostRewrite: "literal.example.com"},
+		}
+		Expect(NewPlugin(nil).ValidateRouteOption(context.Background(), routeOption)).To(MatchError(ConflictingHostRewriteErr))
+
+		routeOption.Annotations[HostRewriteFromBackendAnnotation] = "false"
+		Expect(NewPlugin(nil).ValidateRouteOption(context.Background(), routeOption)).To(Succeed())
+	})
+
+	It("errors when the rule has multiple backends", func() {
+		backendRefs = append(backendRefs, backendRef("bar"))
+		route, err := apply()
+		Expect(err).To(MatchError(MultipleBackendsErr))
+		Expect(route.GetOptions().GetHostRewriteType()).To(BeNil())
+	})
+
+	It("leaves unresolved backends to the route action", func() {
+		backendRefs = []gwv1.HTTPBackendRef{backendRef("missing")}
+		route, err := apply()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetHostRewriteType()).To(BeNil())
+	})
+})
+
+func backendRef(name string) gwv1.HTTPBackendRef {
+	port := gwv1.PortNumber(8080)
+	return gwv1.HTTPBackendRef{
+		BackendRef: gwv1.BackendRef{
+			BackendObjectReference: gwv1.BackendObjectReference{
+				Name: gwv1.ObjectName(name),
+				Port: &port,
+			},
+		},
+	}
+}
+
+func service(name string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+	}
+}
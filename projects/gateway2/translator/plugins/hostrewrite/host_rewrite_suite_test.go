@@ -0,0 +1,13 @@
+package hostrewrite
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestHostRewritePlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "HostRewrite Plugin Suite")
+}
@@ -0,0 +1,242 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	wafrules "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/extensions/waf"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/core/matchers"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/waf"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/transformation"
+)
+
+// Annotations set on a RouteOption to require an idempotency key header on the unsafe requests of the routes
+// it is applied to.
+const (
+	// IdempotencyKeyAnnotation enables the idempotency key check, and selects what happens to requests missing the key:
+	// either "reject" to respond 400, or "passthrough" to forward them with the header of IdempotencyKeyMissingHeaderAnnotation.
+	// Rejection uses a WAF rule, and requires Gloo Enterprise.
+	IdempotencyKeyAnnotation = "gateway2.solo.io/idempotency-key"
+	// IdempotencyKeyHeaderAnnotation is the request header carrying the idempotency key, defaulting to "idempotency-key"
+	IdempotencyKeyHeaderAnnotation = "gateway2.solo.io/idempotency-key-header"
+	// IdempotencyKeyMethodsAnnotation is a comma-separated list of the methods requiring the key,
+	// defaulting to "POST,PUT,PATCH,DELETE"
+	IdempotencyKeyMethodsAnnotation = "gateway2.solo.io/idempotency-key-methods"
+	// IdempotencyKeyMissingHeaderAnnotation is the header set to "true" on the requests forwarded without a key
+	// in passthrough mode, defaulting to "x-idempotency-key-missing"
+	IdempotencyKeyMissingHeaderAnnotation = "gateway2.solo.io/idempotency-key-missing-header"
+)
+
+const (
+	RejectMode      = "reject"
+	PassthroughMode = "passthrough"
+)
+
+const (
+	defaultHeader        = "idempotency-key"
+	defaultMissingHeader = "x-idempotency-key-missing"
+
+	// wafRuleId identifies the rule rejecting requests missing the key, it is the only rule of the route
+	wafRuleId = 1
+)
+
+var (
+	defaultMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+	// stricter than the header name characters allowed by RFC 9110, as the header is part of the WAF rule
+	headerRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	methodRegex = regexp.MustCompile(`^[A-Z]+$`)
+
+	UnknownModeErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be one of %s or %s", value, IdempotencyKeyAnnotation, RejectMode, PassthroughMode)
+	}
+	InvalidHeaderErr = func(annotation, value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a header name of letters, digits, - and _", value, annotation)
+	}
+	InvalidMethodErr = func(value string) error {
+		return errors.Errorf("invalid method '%s' in annotation %s: must be an uppercase HTTP method", value, IdempotencyKeyMethodsAnnotation)
+	}
+	ConflictingWafErr            = errors.Errorf("annotation %s cannot be combined with the waf option of the RouteOption", IdempotencyKeyAnnotation)
+	ConflictingTransformationErr = errors.Errorf("annotation %s cannot be combined with request transformations of the RouteOption", IdempotencyKeyAnnotation)
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+)
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+// DependsOn the routeoptions plugin, which would otherwise replace the waf rules and request transformations of the route
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+	key, err := getIdempotencyKey(routeOption.GetAnnotations())
+	if err != nil || key == nil {
+		return err
+	}
+	header, methods := key.header, key.methods
+
+	if outputRoute.GetOptions() == nil {
+		outputRoute.Options = &v1.RouteOptions{}
+	}
+	options := outputRoute.GetOptions()
+	switch key.mode {
+	case RejectMode:
+		if options.GetWaf() != nil {
+			return ConflictingWafErr
+		}
+		options.Waf = &waf.Settings{
+			CustomInterventionMessage: fmt.Sprintf("missing %s header", header),
+			RuleSets: []*wafrules.RuleSet{{
+				RuleStr: rejectRule(header, methods),
+			}},
+			RequestHeadersOnly: true,
+		}
+	case PassthroughMode:
+		if len(options.GetStagedTransformations().GetRegular().GetRequestTransforms()) > 0 {
+			return ConflictingTransformationErr
+		}
+		if options.GetStagedTransformations() == nil {
+			options.StagedTransformations = &transformation.TransformationStages{}
+		}
+		if options.GetStagedTransformations().GetRegular() == nil {
+			options.GetStagedTransformations().Regular = &transformation.RequestResponseTransformations{}
+		}
+		options.GetStagedTransformations().GetRegular().RequestTransforms = []*transformation.RequestMatch{{
+			Matcher: &matchers.Matcher{
+				PathSpecifier: &matchers.Matcher_Prefix{Prefix: "/"},
+				Methods:       methods,
+				Headers: []*matchers.HeaderMatcher{{
+					Name:        header,
+					InvertMatch: true,
+				}},
+			},
+			RequestTransformation: &transformation.Transformation{
+				TransformationType: &transformation.Transformation_TransformationTemplate{
+					TransformationTemplate: &transformation.TransformationTemplate{
+						Headers: map[string]*transformation.InjaTemplate{
+							key.missingHeader: {Text: "true"},
+						},
+						BodyTransformation: &transformation.TransformationTemplate_Passthrough{
+							Passthrough: &transformation.Passthrough{},
+						},
+					},
+				},
+			},
+		}}
+	}
+	return nil
+}
+
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	key, err := getIdempotencyKey(routeOption.GetAnnotations())
+	if err != nil || key == nil {
+		return err
+	}
+	options := routeOption.Spec.GetOptions()
+	switch {
+	case key.mode == RejectMode && options.GetWaf() != nil:
+		return ConflictingWafErr
+	case key.mode == PassthroughMode && len(options.GetStagedTransformations().GetRegular().GetRequestTransforms()) > 0:
+		return ConflictingTransformationErr
+	}
+	return nil
+}
+
+type idempotencyKey struct {
+	mode    string
+	header  string
+	methods []string
+	// missingHeader is only set in passthrough mode
+	missingHeader string
+}
+
+// getIdempotencyKey returns nil if the annotations do not enable the idempotency key check
+func getIdempotencyKey(annotations map[string]string) (*idempotencyKey, error) {
+	mode, ok := annotations[IdempotencyKeyAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	if mode != RejectMode && mode != PassthroughMode {
+		return nil, UnknownModeErr(mode)
+	}
+
+	header, err := getHeader(annotations, IdempotencyKeyHeaderAnnotation, defaultHeader)
+	if err != nil {
+		return nil, err
+	}
+	methods := defaultMethods
+	if value, ok := annotations[IdempotencyKeyMethodsAnnotation]; ok {
+		methods = nil
+		for _, method := range strings.Split(value, ",") {
+			method = strings.TrimSpace(method)
+			if !methodRegex.MatchString(method) {
+				return nil, InvalidMethodErr(method)
+			}
+			methods = append(methods, method)
+		}
+	}
+	key := &idempotencyKey{
+		mode:    mode,
+		header:  header,
+		methods: methods,
+	}
+	if mode == PassthroughMode {
+		key.missingHeader, err = getHeader(annotations, IdempotencyKeyMissingHeaderAnnotation, defaultMissingHeader)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+func getHeader(annotations map[string]string, annotation, defaultValue string) (string, error) {
+	value, ok := annotations[annotation]
+	if !ok {
+		return defaultValue, nil
+	}
+	if !headerRegex.MatchString(value) {
+		return "", InvalidHeaderErr(annotation, value)
+	}
+	return strings.ToLower(value), nil
+}
+
+// rejectRule returns a ModSecurity rule responding 400 to the requests with one of the methods missing the header
+func rejectRule(header string, methods []string) string {
+	return fmt.Sprintf(`SecRuleEngine On
+SecRule REQUEST_METHOD "@rx ^(?:%s)$" "id:%d,phase:1,deny,status:%d,nolog,chain"
+SecRule &REQUEST_HEADERS:%s "@eq 0"`,
+		strings.Join(methods, "|"), wafRuleId, http.StatusBadRequest, header)
+}
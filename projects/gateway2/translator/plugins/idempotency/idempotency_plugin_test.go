@@ -0,0 +1,173 @@
+package idempotency
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	wafrules "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/extensions/waf"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/core/matchers"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/waf"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/transformation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("IdempotencyPlugin", func() {
+	apply := func(annotations map[string]string, outputRoute *v1.Route) error {
+		queries := testutils.BuildGatewayQueries([]client.Object{
+			&solokubev1.RouteOption{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "policy",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+				Spec: sologatewayv1.RouteOption{},
+			},
+		})
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: &gwv1.HTTPRouteRule{
+				Filters: []gwv1.HTTPRouteFilter{{
+					Type: gwv1.HTTPRouteFilterExtensionRef,
+					ExtensionRef: &gwv1.LocalObjectReference{
+						Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Name:  "policy",
+					},
+				}},
+			},
+		}
+		return NewPlugin(queries).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+	}
+
+	It("rejects unsafe requests missing the key", func() {
+		route := &v1.Route{}
+		err := apply(map[string]string{
+			IdempotencyKeyAnnotation: RejectMode,
+		}, route)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proto.Equal(route.GetOptions().GetWaf(), &waf.Settings{
+			CustomInterventionMessage: "missing idempotency-key header",
+			RuleSets: []*wafrules.RuleSet{{
+				RuleStr: `SecRuleEngine On
+SecRule REQUEST_METHOD "@rx ^(?:POST|PUT|PATCH|DELETE)$" "id:1,phase:1,deny,status:400,nolog,chain"
+SecRule &REQUEST_HEADERS:idempotency-key "@eq 0"`,
+			}},
+			RequestHeadersOnly: true,
+		})).To(BeTrue())
+		Expect(route.GetOptions().GetStagedTransformations()).To(BeNil())
+	})
+
+	It("rejects requests of the configured methods missing the configured header", func() {
+		route := &v1.Route{}
+		err := apply(map[string]string{
+			IdempotencyKeyAnnotation:        RejectMode,
+			IdempotencyKeyHeaderAnnotation:  "X-Request-Key",
+			IdempotencyKeyMethodsAnnotation: "POST, PATCH",
+		}, route)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetWaf().GetCustomInterventionMessage()).To(Equal("missing x-request-key header"))
+		Expect(route.GetOptions().GetWaf().GetRuleSets()[0].GetRuleStr()).To(Equal(`SecRuleEngine On
+SecRule REQUEST_METHOD "@rx ^(?:POST|PATCH)$" "id:1,phase:1,deny,status:400,nolog,chain"
+SecRule &REQUEST_HEADERS:x-request-key "@eq 0"`))
+	})
+
+	It("passes through requests missing the key with a header", func() {
+		route := &v1.Route{}
+		err := apply(map[string]string{
+			IdempotencyKeyAnnotation:              PassthroughMode,
+			IdempotencyKeyMissingHeaderAnnotation: "x-no-idempotency-key",
+		}, route)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetWaf()).To(BeNil())
+		transforms := route.GetOptions().GetStagedTransformations().GetRegular().GetRequestTransforms()
+		Expect(transforms).To(HaveLen(1))
+		// the transformation only matches requests without the key, those with the key pass through untouched
+		Expect(proto.Equal(transforms[0], &transformation.RequestMatch{
+			Matcher: &matchers.Matcher{
+				PathSpecifier: &matchers.Matcher_Prefix{Prefix: "/"},
+				Methods:       []string{"POST", "PUT", "PATCH", "DELETE"},
+				Headers: []*matchers.HeaderMatcher{{
+					Name:        "idempotency-key",
+					InvertMatch: true,
+				}},
+			},
+			RequestTransformation: &transformation.Transformation{
+				TransformationType: &transformation.Transformation_TransformationTemplate{
+					TransformationTemplate: &transformation.TransformationTemplate{
+						Headers: map[string]*transformation.InjaTemplate{
+							"x-no-idempotency-key": {Text: "true"},
+						},
+						BodyTransformation: &transformation.TransformationTemplate_Passthrough{
+							Passthrough: &transformation.Passthrough{},
+						},
+					},
+				},
+			},
+		})).To(BeTrue())
+	})
+
+	It("does nothing without the annotation", func() {
+		route := &v1.Route{}
+		err := apply(map[string]string{IdempotencyKeyHeaderAnnotation: "x-request-key"}, route)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions()).To(BeNil())
+	})
+
+	It("rejects the waf option of the RouteOption", func() {
+		route := &v1.Route{
+			Options: &v1.RouteOptions{
+				Waf: &waf.Settings{Disabled: true},
+			},
+		}
+		err := apply(map[string]string{IdempotencyKeyAnnotation: RejectMode}, route)
+		Expect(err).To(MatchError(ConflictingWafErr))
+		Expect(route.GetOptions().GetWaf().GetRuleSets()).To(BeEmpty())
+	})
+
+	It("rejects request transformations of the RouteOption", func() {
+		route := &v1.Route{
+			Options: &v1.RouteOptions{
+				StagedTransformations: &transformation.TransformationStages{
+					Regular: &transformation.RequestResponseTransformations{
+						RequestTransforms: []*transformation.RequestMatch{{ClearRouteCache: true}},
+					},
+				},
+			},
+		}
+		err := apply(map[string]string{IdempotencyKeyAnnotation: PassthroughMode}, route)
+		Expect(err).To(MatchError(ConflictingTransformationErr))
+		Expect(route.GetOptions().GetStagedTransformations().GetRegular().GetRequestTransforms()).To(HaveLen(1))
+	})
+
+	DescribeTable("rejects invalid idempotency key checks",
+		func(annotations map[string]string, expectedErr string) {
+			route := &v1.Route{}
+			err := apply(annotations, route)
+			Expect(err).To(MatchError(expectedErr))
+			Expect(route.GetOptions()).To(BeNil())
+		},
+		Entry("unknown mode",
+			map[string]string{IdempotencyKeyAnnotation: "deduplicate"},
+			UnknownModeErr("deduplicate").Error()),
+		Entry("invalid header",
+			map[string]string{IdempotencyKeyAnnotation: RejectMode, IdempotencyKeyHeaderAnnotation: "key\" \"@eq 1"},
+			InvalidHeaderErr(IdempotencyKeyHeaderAnnotation, "key\" \"@eq 1").Error()),
+		Entry("lowercase method",
+			map[string]string{IdempotencyKeyAnnotation: RejectMode, IdempotencyKeyMethodsAnnotation: "post"},
+			InvalidMethodErr("post").Error()),
+	)
+})
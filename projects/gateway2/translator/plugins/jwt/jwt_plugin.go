@@ -0,0 +1,278 @@
+package jwt
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/jwt"
+	"google.golang.org/protobuf/proto"
+)
+
+// Annotations set on a RouteOption to require a valid JWT on the routes it is applied to.
+// Exactly one of JwtJwksUriAnnotation or JwtJwksAnnotation must be set alongside JwtIssuerAnnotation.
+// Neither the annotations nor the jwt options of the RouteOption take precedence when both are set: the routes
+// require authentication either way, so they fail closed and report the conflict.
+const (
+	// JwtIssuerAnnotation is the issuer JWTs must have, and enables JWT validation
+	JwtIssuerAnnotation = "gateway2.solo.io/jwt-issuer"
+	// JwtJwksUriAnnotation is the http(s) URL the JWKS verifying JWTs is fetched from
+	JwtJwksUriAnnotation = "gateway2.solo.io/jwt-jwks-uri"
+	// JwtJwksServiceAnnotation is the <service>:<port> serving JwtJwksUriAnnotation, in the namespace of the RouteOption
+	JwtJwksServiceAnnotation = "gateway2.solo.io/jwt-jwks-service"
+	// JwtJwksCacheDurationAnnotation is how long the fetched JWKS is cached for, defaulting to 5m
+	JwtJwksCacheDurationAnnotation = "gateway2.solo.io/jwt-jwks-cache-duration"
+	// JwtJwksAnnotation is an inline JWKS or PEM public key verifying JWTs
+	JwtJwksAnnotation = "gateway2.solo.io/jwt-jwks"
+	// JwtAudiencesAnnotation is a comma-separated list of audiences, one of which JWTs must have
+	JwtAudiencesAnnotation = "gateway2.solo.io/jwt-audiences"
+	// JwtClaimsToHeadersAnnotation is a comma-separated list of <claim>=<header> copying claims of the JWT
+	// to request headers, e.g. "sub=x-user-id,email=x-user-email"
+	JwtClaimsToHeadersAnnotation = "gateway2.solo.io/jwt-claims-to-headers"
+)
+
+var (
+	JwksSourceErr     = errors.Errorf("exactly one of annotations %s or %s must be set", JwtJwksUriAnnotation, JwtJwksAnnotation)
+	InvalidJwksUriErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be an http or https URL", value, JwtJwksUriAnnotation)
+	}
+	MissingJwksServiceErr   = errors.Errorf("annotation %s requires annotation %s", JwtJwksUriAnnotation, JwtJwksServiceAnnotation)
+	InvalidClaimToHeaderErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a comma-separated list of <claim>=<header>", value, JwtClaimsToHeadersAnnotation)
+	}
+	ConflictingJwtErr       = errors.Errorf("annotation %s cannot be combined with the jwt options of the RouteOption", JwtIssuerAnnotation)
+	ConflictingProvidersErr = func(vhost string) error {
+		return errors.Errorf("routes of virtual host %s require different JWT policies, which gloo cannot enforce on a single virtual host", vhost)
+	}
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.ListenerPlugin       = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+)
+
+// Gloo validates JWTs with providers configured on virtual hosts, and routes can only opt out of validation.
+// The plugin resolves the JWT policies of routes when translating them, and moves them onto the virtual hosts
+// of the routes once their listener is translated; routes requiring no JWT are opted out of validation.
+// Plugins are created for each translation, so that routes are only tracked for the duration of a translation.
+type plugin struct {
+	queries query.GatewayQueries
+	// JWT providers, keyed by name, of the routes translated with a JWT policy
+	routeProviders map[*v1.Route]namedProvider
+}
+
+type namedProvider struct {
+	name     string
+	provider *jwt.Provider
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries:        queries,
+		routeProviders: map[*v1.Route]namedProvider{},
+	}
+}
+
+// DependsOn the routeoptions plugin, so that the jwt options of the RouteOption are set when checking for conflicts
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+	annotations := routeOption.GetAnnotations()
+	if _, ok := annotations[JwtIssuerAnnotation]; !ok {
+		return nil
+	}
+
+	// the route requires authentication, so if the policy cannot be applied the route must not be served
+	if outputRoute.GetOptions().GetJwtConfig() != nil {
+		failClosed(outputRoute)
+		return ConflictingJwtErr
+	}
+	provider, err := getProvider(annotations, routeOption.GetNamespace())
+	if err != nil {
+		failClosed(outputRoute)
+		return err
+	}
+	p.routeProviders[outputRoute] = namedProvider{
+		name:     fmt.Sprintf("%s_%s", routeOption.GetNamespace(), routeOption.GetName()),
+		provider: provider,
+	}
+	return nil
+}
+
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	annotations := routeOption.GetAnnotations()
+	if _, ok := annotations[JwtIssuerAnnotation]; !ok {
+		return nil
+	}
+	if routeOption.Spec.GetOptions().GetJwtConfig() != nil {
+		return ConflictingJwtErr
+	}
+	_, err := getProvider(annotations, routeOption.GetNamespace())
+	return err
+}
+
+func getProvider(annotations map[string]string, namespace string) (*jwt.Provider, error) {
+	provider := &jwt.Provider{
+		Issuer: annotations[JwtIssuerAnnotation],
+	}
+
+	uri, remote := annotations[JwtJwksUriAnnotation]
+	key, local := annotations[JwtJwksAnnotation]
+	switch {
+	case remote && !local:
+		u, err := url.Parse(uri)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, InvalidJwksUriErr(uri)
+		}
+		upstreamRef, err := utils.GetServiceRefAnnotation(annotations, JwtJwksServiceAnnotation, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if upstreamRef == nil {
+			return nil, MissingJwksServiceErr
+		}
+		cacheDuration, err := utils.GetDurationAnnotation(annotations, JwtJwksCacheDurationAnnotation)
+		if err != nil {
+			return nil, err
+		}
+		provider.Jwks = &jwt.Jwks{
+			Jwks: &jwt.Jwks_Remote{
+				Remote: &jwt.RemoteJwks{
+					Url:           uri,
+					UpstreamRef:   upstreamRef,
+					CacheDuration: cacheDuration,
+				},
+			},
+		}
+	case local && !remote && strings.TrimSpace(key) != "":
+		provider.Jwks = &jwt.Jwks{
+			Jwks: &jwt.Jwks_Local{
+				Local: &jwt.LocalJwks{
+					Key: key,
+				},
+			},
+		}
+	default:
+		return nil, JwksSourceErr
+	}
+
+	if value, ok := annotations[JwtAudiencesAnnotation]; ok {
+		for _, audience := range strings.Split(value, ",") {
+			if audience = strings.TrimSpace(audience); audience != "" {
+				provider.Audiences = append(provider.GetAudiences(), audience)
+			}
+		}
+	}
+
+	if value, ok := annotations[JwtClaimsToHeadersAnnotation]; ok {
+		for _, mapping := range strings.Split(value, ",") {
+			claim, header, found := strings.Cut(strings.TrimSpace(mapping), "=")
+			if !found || claim == "" || header == "" {
+				return nil, InvalidClaimToHeaderErr(value)
+			}
+			provider.ClaimsToHeaders = append(provider.GetClaimsToHeaders(), &jwt.ClaimToHeader{
+				Claim:  claim,
+				Header: header,
+			})
+		}
+	}
+	return provider, nil
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	var errs []error
+	for vhostName, vhost := range outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts() {
+		var (
+			provider  *namedProvider
+			jwtRoutes []*v1.Route
+			conflict  bool
+		)
+		for _, route := range vhost.GetRoutes() {
+			routeProvider, ok := p.routeProviders[route]
+			if !ok {
+				continue
+			}
+			jwtRoutes = append(jwtRoutes, route)
+			if provider == nil {
+				provider = &routeProvider
+			} else if !proto.Equal(provider.provider, routeProvider.provider) {
+				conflict = true
+			}
+		}
+		if provider == nil {
+			continue
+		}
+		if conflict {
+			for _, route := range jwtRoutes {
+				failClosed(route)
+			}
+			errs = append(errs, ConflictingProvidersErr(vhostName))
+			continue
+		}
+
+		if vhost.GetOptions() == nil {
+			vhost.Options = &v1.VirtualHostOptions{}
+		}
+		vhost.GetOptions().JwtConfig = &v1.VirtualHostOptions_JwtStaged{
+			JwtStaged: &jwt.JwtStagedVhostExtension{
+				BeforeExtAuth: &jwt.VhostExtension{
+					Providers: map[string]*jwt.Provider{
+						provider.name: provider.provider,
+					},
+				},
+			},
+		}
+		for _, route := range vhost.GetRoutes() {
+			if _, ok := p.routeProviders[route]; ok {
+				continue
+			}
+			if route.GetOptions() == nil {
+				route.Options = &v1.RouteOptions{}
+			}
+			route.GetOptions().JwtConfig = &v1.RouteOptions_JwtStaged{
+				JwtStaged: &jwt.JwtStagedRouteExtension{
+					BeforeExtAuth: &jwt.RouteExtension{
+						Disable: true,
+					},
+				},
+			}
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+func failClosed(route *v1.Route) {
+	route.Action = &v1.Route_DirectResponseAction{
+		DirectResponseAction: &v1.DirectResponseAction{
+			Status: http.StatusInternalServerError,
+		},
+	}
+}
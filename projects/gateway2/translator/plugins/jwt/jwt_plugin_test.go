@@ -0,0 +1,274 @@
+package jwt
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/jwt"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+const localJwks = `{"keys":[{"kty":"RSA","kid":"key","n":"xyz","e":"AQAB"}]}`
+
+var _ = Describe("JwtPlugin", func() {
+	var (
+		routeOptions map[string]map[string]string
+		p            *plugin
+	)
+
+	BeforeEach(func() {
+		routeOptions = map[string]map[string]string{
+			"remote": {
+				JwtIssuerAnnotation:            "https://issuer.example.com",
+				JwtJwksUriAnnotation:           "https://issuer.example.com/.well-known/jwks.json",
+				JwtJwksServiceAnnotation:       "issuer:443",
+				JwtJwksCacheDurationAnnotation: "10m",
+				JwtAudiencesAnnotation:         "api, web",
+				JwtClaimsToHeadersAnnotation:   "sub=x-user-id,email=x-user-email",
+			},
+			"local": {
+				JwtIssuerAnnotation: "local-issuer",
+				JwtJwksAnnotation:   localJwks,
+			},
+		}
+	})
+
+	// translateRoute applies the route plugin to a route using the named RouteOption, if any
+	translateRoute := func(routeOptionName string, options *v1.RouteOptions) (*v1.Route, error) {
+		var objs []client.Object
+		for name, annotations := range routeOptions {
+			ro := testutils.RouteOption(annotations)
+			ro.Name = name
+			objs = append(objs, ro)
+		}
+		if p == nil {
+			p = NewPlugin(testutils.BuildGatewayQueries(objs))
+		}
+		rule := &gwv1.HTTPRouteRule{}
+		if routeOptionName != "" {
+			rule.Filters = []gwv1.HTTPRouteFilter{{
+				Type: gwv1.HTTPRouteFilterExtensionRef,
+				ExtensionRef: &gwv1.LocalObjectReference{
+					Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+					Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+					Name:  gwv1.ObjectName(routeOptionName),
+				},
+			}}
+		}
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: rule,
+		}
+		outputRoute := &v1.Route{
+			Name: routeOptionName,
+			Action: &v1.Route_RouteAction{
+				RouteAction: &v1.RouteAction{},
+			},
+			Options: options,
+		}
+		err := p.ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+		return outputRoute, err
+	}
+
+	translateListener := func(routes ...*v1.Route) (*v1.VirtualHost, error) {
+		vhost := &v1.VirtualHost{
+			Name:   "vhost",
+			Routes: routes,
+		}
+		outputListener := &v1.Listener{
+			ListenerType: &v1.Listener_AggregateListener{
+				AggregateListener: &v1.AggregateListener{
+					HttpResources: &v1.AggregateListener_HttpResources{
+						VirtualHosts: map[string]*v1.VirtualHost{
+							"vhost": vhost,
+						},
+					},
+				},
+			},
+		}
+		err := p.ApplyListenerPlugin(context.Background(), &plugins.ListenerContext{}, outputListener)
+		return vhost, err
+	}
+
+	providers := func(vhost *v1.VirtualHost) map[string]*jwt.Provider {
+		return vhost.GetOptions().GetJwtStaged().GetBeforeExtAuth().GetProviders()
+	}
+
+	disabled := &v1.RouteOptions{
+		JwtConfig: &v1.RouteOptions_JwtStaged{
+			JwtStaged: &jwt.JwtStagedRouteExtension{
+				BeforeExtAuth: &jwt.RouteExtension{Disable: true},
+			},
+		},
+	}
+
+	isFailedClosed := func(route *v1.Route) bool {
+		return route.GetDirectResponseAction().GetStatus() == http.StatusInternalServerError
+	}
+
+	AfterEach(func() {
+		p = nil
+	})
+
+	It("translates a remote JWKS issuer", func() {
+		jwtRoute, err := translateRoute("remote", nil)
+		Expect(err).NotTo(HaveOccurred())
+		otherRoute, err := translateRoute("", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		vhost, err := translateListener(jwtRoute, otherRoute)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(providers(vhost)).To(HaveLen(1))
+		Expect(proto.Equal(providers(vhost)["default_remote"], &jwt.Provider{
+			Issuer: "https://issuer.example.com",
+			Jwks: &jwt.Jwks{
+				Jwks: &jwt.Jwks_Remote{
+					Remote: &jwt.RemoteJwks{
+						Url:           "https://issuer.example.com/.well-known/jwks.json",
+						UpstreamRef:   &core.ResourceRef{Name: "default-issuer-443", Namespace: "default"},
+						CacheDuration: durationpb.New(10 * time.Minute),
+					},
+				},
+			},
+			Audiences: []string{"api", "web"},
+			ClaimsToHeaders: []*jwt.ClaimToHeader{
+				{Claim: "sub", Header: "x-user-id"},
+				{Claim: "email", Header: "x-user-email"},
+			},
+		})).To(BeTrue())
+
+		Expect(jwtRoute.GetOptions().GetJwtConfig()).To(BeNil())
+		Expect(proto.Equal(otherRoute.GetOptions(), disabled)).To(BeTrue())
+	})
+
+	It("translates a local JWKS issuer", func() {
+		jwtRoute, err := translateRoute("local", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		vhost, err := translateListener(jwtRoute)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(providers(vhost)).To(HaveLen(1))
+		Expect(proto.Equal(providers(vhost)["default_local"], &jwt.Provider{
+			Issuer: "local-issuer",
+			Jwks: &jwt.Jwks{
+				Jwks: &jwt.Jwks_Local{
+					Local: &jwt.LocalJwks{Key: localJwks},
+				},
+			},
+		})).To(BeTrue())
+	})
+
+	It("shares a policy between routes of a virtual host", func() {
+		routeOptions["copy"] = routeOptions["local"]
+		first, err := translateRoute("local", nil)
+		Expect(err).NotTo(HaveOccurred())
+		second, err := translateRoute("copy", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		vhost, err := translateListener(first, second)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(providers(vhost)).To(HaveLen(1))
+		Expect(first.GetOptions().GetJwtConfig()).To(BeNil())
+		Expect(second.GetOptions().GetJwtConfig()).To(BeNil())
+	})
+
+	It("fails closed on routes of a virtual host with mismatched audiences", func() {
+		routeOptions["other-audience"] = map[string]string{
+			JwtIssuerAnnotation:    "local-issuer",
+			JwtJwksAnnotation:      localJwks,
+			JwtAudiencesAnnotation: "admin",
+		}
+		routeOptions["local"][JwtAudiencesAnnotation] = "api"
+		first, err := translateRoute("local", nil)
+		Expect(err).NotTo(HaveOccurred())
+		second, err := translateRoute("other-audience", nil)
+		Expect(err).NotTo(HaveOccurred())
+		otherRoute, err := translateRoute("", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		vhost, err := translateListener(first, second, otherRoute)
+		Expect(err).To(MatchError(ConflictingProvidersErr("vhost").Error()))
+		Expect(vhost.GetOptions()).To(BeNil())
+		Expect(isFailedClosed(first)).To(BeTrue())
+		Expect(isFailedClosed(second)).To(BeTrue())
+		Expect(isFailedClosed(otherRoute)).To(BeFalse())
+	})
+
+	It("does nothing without a JWT policy", func() {
+		routeOptions["none"] = map[string]string{JwtAudiencesAnnotation: "api"}
+		route, err := translateRoute("none", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		vhost, err := translateListener(route)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vhost.GetOptions()).To(BeNil())
+		Expect(route.GetOptions()).To(BeNil())
+	})
+
+	It("fails closed when combined with the jwt options of the RouteOption", func() {
+		route, err := translateRoute("local", disabled)
+		Expect(err).To(MatchError(ConflictingJwtErr))
+		Expect(isFailedClosed(route)).To(BeTrue())
+	})
+
+	DescribeTable("fails closed on invalid policies",
+		func(annotations map[string]string, expectedErr string) {
+			routeOptions["invalid"] = annotations
+			route, err := translateRoute("invalid", nil)
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			Expect(isFailedClosed(route)).To(BeTrue())
+
+			vhost, err := translateListener(route)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vhost.GetOptions()).To(BeNil())
+		},
+		Entry("no JWKS",
+			map[string]string{JwtIssuerAnnotation: "issuer"},
+			JwksSourceErr.Error()),
+		Entry("both JWKS sources",
+			map[string]string{
+				JwtIssuerAnnotation:      "issuer",
+				JwtJwksAnnotation:        localJwks,
+				JwtJwksUriAnnotation:     "https://issuer.example.com/jwks",
+				JwtJwksServiceAnnotation: "issuer:443",
+			},
+			JwksSourceErr.Error()),
+		Entry("non-http JWKS uri",
+			map[string]string{
+				JwtIssuerAnnotation:      "issuer",
+				JwtJwksUriAnnotation:     "file:///etc/jwks.json",
+				JwtJwksServiceAnnotation: "issuer:443",
+			},
+			InvalidJwksUriErr("file:///etc/jwks.json").Error()),
+		Entry("JWKS uri without service",
+			map[string]string{
+				JwtIssuerAnnotation:  "issuer",
+				JwtJwksUriAnnotation: "https://issuer.example.com/jwks",
+			},
+			MissingJwksServiceErr.Error()),
+		Entry("invalid claim to header",
+			map[string]string{
+				JwtIssuerAnnotation:          "issuer",
+				JwtJwksAnnotation:            localJwks,
+				JwtClaimsToHeadersAnnotation: "sub",
+			},
+			InvalidClaimToHeaderErr("sub").Error()),
+	)
+})
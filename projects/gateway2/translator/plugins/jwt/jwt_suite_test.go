@@ -0,0 +1,13 @@
+package jwt
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestJwtPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Jwt Plugin Suite")
+}
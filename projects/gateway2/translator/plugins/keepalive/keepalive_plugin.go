@@ -0,0 +1,86 @@
+package keepalive
+
+import (
+	"context"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+)
+
+// Annotations set on a Service to send TCP keepalive probes on the connections to the Upstreams discovered from it.
+// Settings that are not set keep the defaults of the OS.
+const (
+	// TcpKeepaliveProbesAnnotation is the number of unanswered probes after which the connection is closed
+	TcpKeepaliveProbesAnnotation = "gateway2.solo.io/tcp-keepalive-probes"
+	// TcpKeepaliveTimeAnnotation is how long a connection is idle before probes are sent, rounded up to the second
+	TcpKeepaliveTimeAnnotation = "gateway2.solo.io/tcp-keepalive-time"
+	// TcpKeepaliveIntervalAnnotation is the interval between probes, rounded up to the second
+	TcpKeepaliveIntervalAnnotation = "gateway2.solo.io/tcp-keepalive-interval"
+)
+
+// Annotations that would be set on a Service to send HTTP/2 PING frames on the connections to its Upstreams.
+// Gloo Upstreams cannot configure HTTP/2 keepalives: the keepalive annotations of a Service setting either one are
+// not applied to its Upstreams, and the error is logged by the controller since Services have no status to report it.
+const (
+	Http2KeepaliveIntervalAnnotation = "gateway2.solo.io/http2-keepalive-interval"
+	Http2KeepaliveTimeoutAnnotation  = "gateway2.solo.io/http2-keepalive-timeout"
+)
+
+var (
+	InvalidTcpKeepaliveProbesErr = errors.Errorf("annotation %s must be at least 1", TcpKeepaliveProbesAnnotation)
+	Http2KeepaliveUnsupportedErr = func(annotation string) error {
+		return errors.Errorf("annotation %s cannot be applied: gloo upstreams do not support HTTP/2 keepalives, use TCP keepalives instead", annotation)
+	}
+)
+
+var _ plugins.BackendPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyBackendPlugin(
+	ctx context.Context,
+	backendCtx *plugins.BackendContext,
+	outputUpstream *v1.Upstream,
+) error {
+	annotations := backendCtx.Service.GetAnnotations()
+	for _, annotation := range []string{Http2KeepaliveIntervalAnnotation, Http2KeepaliveTimeoutAnnotation} {
+		if _, ok := annotations[annotation]; ok {
+			return Http2KeepaliveUnsupportedErr(annotation)
+		}
+	}
+
+	probes, err := utils.GetUint32Annotation(annotations, TcpKeepaliveProbesAnnotation)
+	if err != nil {
+		return err
+	}
+	if probes != nil && probes.GetValue() == 0 {
+		return InvalidTcpKeepaliveProbesErr
+	}
+	keepaliveTime, err := utils.GetDurationAnnotation(annotations, TcpKeepaliveTimeAnnotation)
+	if err != nil {
+		return err
+	}
+	keepaliveInterval, err := utils.GetDurationAnnotation(annotations, TcpKeepaliveIntervalAnnotation)
+	if err != nil {
+		return err
+	}
+	if probes == nil && keepaliveTime == nil && keepaliveInterval == nil {
+		return nil
+	}
+
+	if outputUpstream.GetConnectionConfig() == nil {
+		outputUpstream.ConnectionConfig = &v1.ConnectionConfig{}
+	}
+	outputUpstream.GetConnectionConfig().TcpKeepalive = &v1.ConnectionConfig_TcpKeepAlive{
+		KeepaliveProbes:   probes.GetValue(),
+		KeepaliveTime:     keepaliveTime,
+		KeepaliveInterval: keepaliveInterval,
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package keepalive
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("KeepalivePlugin", func() {
+	apply := func(annotations map[string]string, us *v1.Upstream) error {
+		backendCtx := &plugins.BackendContext{
+			Service: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "svc",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+			},
+		}
+		return NewPlugin().ApplyBackendPlugin(context.Background(), backendCtx, us)
+	}
+
+	It("translates all TCP keepalive settings", func() {
+		us := &v1.Upstream{}
+		Expect(apply(map[string]string{
+			TcpKeepaliveProbesAnnotation:   "3",
+			TcpKeepaliveTimeAnnotation:     "60s",
+			TcpKeepaliveIntervalAnnotation: "10s",
+		}, us)).To(Succeed())
+		Expect(proto.Equal(us.GetConnectionConfig(), &v1.ConnectionConfig{
+			TcpKeepalive: &v1.ConnectionConfig_TcpKeepAlive{
+				KeepaliveProbes:   3,
+				KeepaliveTime:     durationpb.New(time.Minute),
+				KeepaliveInterval: durationpb.New(10 * time.Second),
+			},
+		})).To(BeTrue())
+	})
+
+	It("keeps the other connection settings", func() {
+		us := &v1.Upstream{
+			ConnectionConfig: &v1.ConnectionConfig{MaxRequestsPerConnection: 100},
+		}
+		Expect(apply(map[string]string{TcpKeepaliveTimeAnnotation: "30s"}, us)).To(Succeed())
+		Expect(proto.Equal(us.GetConnectionConfig(), &v1.ConnectionConfig{
+			MaxRequestsPerConnection: 100,
+			TcpKeepalive: &v1.ConnectionConfig_TcpKeepAlive{
+				KeepaliveTime: durationpb.New(30 * time.Second),
+			},
+		})).To(BeTrue())
+	})
+
+	It("does nothing without the annotations", func() {
+		us := &v1.Upstream{}
+		Expect(apply(map[string]string{"other": "value"}, us)).To(Succeed())
+		Expect(us.GetConnectionConfig()).To(BeNil())
+	})
+
+	DescribeTable("rejects invalid keepalive settings",
+		func(annotations map[string]string, expectedErr string) {
+			us := &v1.Upstream{}
+			Expect(apply(annotations, us)).To(MatchError(ContainSubstring(expectedErr)))
+			Expect(us.GetConnectionConfig()).To(BeNil())
+		},
+		Entry("zero probes", map[string]string{TcpKeepaliveProbesAnnotation: "0"}, InvalidTcpKeepaliveProbesErr.Error()),
+		Entry("invalid probes", map[string]string{TcpKeepaliveProbesAnnotation: "three"}, "must be a non-negative integer"),
+		Entry("invalid time", map[string]string{TcpKeepaliveTimeAnnotation: "60"}, "must be a positive duration"),
+		Entry("negative interval", map[string]string{TcpKeepaliveIntervalAnnotation: "-10s"}, "must be a positive duration"),
+		Entry("HTTP/2 keepalive interval", map[string]string{
+			TcpKeepaliveTimeAnnotation:       "60s",
+			Http2KeepaliveIntervalAnnotation: "30s",
+		}, Http2KeepaliveUnsupportedErr(Http2KeepaliveIntervalAnnotation).Error()),
+		Entry("HTTP/2 keepalive timeout", map[string]string{Http2KeepaliveTimeoutAnnotation: "5s"},
+			Http2KeepaliveUnsupportedErr(Http2KeepaliveTimeoutAnnotation).Error()),
+	)
+})
@@ -0,0 +1,13 @@
+package keepalive
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestKeepalive(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Keepalive Plugin Suite")
+}
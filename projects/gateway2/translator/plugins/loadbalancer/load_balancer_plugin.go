@@ -0,0 +1,130 @@
+package loadbalancer
+
+import (
+	"context"
+	"strconv"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+)
+
+// Annotations set on a Service to select the load balancing algorithm of the Upstreams discovered from it.
+// Consistent hashing algorithms hash the keys configured on the route, e.g. by the lbHash of a RouteOption.
+const (
+	// LoadBalancerPolicyAnnotation is one of "round-robin", "least-request", "random", "ring-hash" or "maglev"
+	LoadBalancerPolicyAnnotation = "gateway2.solo.io/load-balancer-policy"
+	// RingHashMinimumRingSizeAnnotation is the minimum number of entries of the hash ring of the "ring-hash" policy
+	RingHashMinimumRingSizeAnnotation = "gateway2.solo.io/load-balancer-ring-hash-minimum-ring-size"
+	// RingHashMaximumRingSizeAnnotation is the maximum number of entries of the hash ring of the "ring-hash" policy
+	RingHashMaximumRingSizeAnnotation = "gateway2.solo.io/load-balancer-ring-hash-maximum-ring-size"
+)
+
+const (
+	RoundRobinPolicy   = "round-robin"
+	LeastRequestPolicy = "least-request"
+	RandomPolicy       = "random"
+	RingHashPolicy     = "ring-hash"
+	MaglevPolicy       = "maglev"
+)
+
+var (
+	UnknownPolicyErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be one of %s, %s, %s, %s or %s",
+			value, LoadBalancerPolicyAnnotation, RoundRobinPolicy, LeastRequestPolicy, RandomPolicy, RingHashPolicy, MaglevPolicy)
+	}
+	InvalidRingSizeErr = func(annotation, value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a positive integer", value, annotation)
+	}
+	RingSizeRangeErr = errors.Errorf("annotation %s must not be greater than %s", RingHashMinimumRingSizeAnnotation, RingHashMaximumRingSizeAnnotation)
+)
+
+var _ plugins.BackendPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyBackendPlugin(
+	ctx context.Context,
+	backendCtx *plugins.BackendContext,
+	outputUpstream *v1.Upstream,
+) error {
+	annotations := backendCtx.Service.GetAnnotations()
+	policy, ok := annotations[LoadBalancerPolicyAnnotation]
+	if !ok {
+		return nil
+	}
+
+	loadBalancerConfig := &v1.LoadBalancerConfig{}
+	switch policy {
+	case RoundRobinPolicy:
+		loadBalancerConfig.Type = &v1.LoadBalancerConfig_RoundRobin_{
+			RoundRobin: &v1.LoadBalancerConfig_RoundRobin{},
+		}
+	case LeastRequestPolicy:
+		loadBalancerConfig.Type = &v1.LoadBalancerConfig_LeastRequest_{
+			LeastRequest: &v1.LoadBalancerConfig_LeastRequest{},
+		}
+	case RandomPolicy:
+		loadBalancerConfig.Type = &v1.LoadBalancerConfig_Random_{
+			Random: &v1.LoadBalancerConfig_Random{},
+		}
+	case RingHashPolicy:
+		ringHashConfig, err := getRingHashConfig(annotations)
+		if err != nil {
+			return err
+		}
+		loadBalancerConfig.Type = &v1.LoadBalancerConfig_RingHash_{
+			RingHash: &v1.LoadBalancerConfig_RingHash{
+				RingHashConfig: ringHashConfig,
+			},
+		}
+	case MaglevPolicy:
+		loadBalancerConfig.Type = &v1.LoadBalancerConfig_Maglev_{
+			Maglev: &v1.LoadBalancerConfig_Maglev{},
+		}
+	default:
+		return UnknownPolicyErr(policy)
+	}
+
+	outputUpstream.LoadBalancerConfig = loadBalancerConfig
+	return nil
+}
+
+// getRingHashConfig returns the ring size limits of the ring-hash policy, or nil if neither is set
+func getRingHashConfig(annotations map[string]string) (*v1.LoadBalancerConfig_RingHashConfig, error) {
+	minimum, err := getRingSize(annotations, RingHashMinimumRingSizeAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	maximum, err := getRingSize(annotations, RingHashMaximumRingSizeAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	if minimum == 0 && maximum == 0 {
+		return nil, nil
+	}
+	if minimum != 0 && maximum != 0 && minimum > maximum {
+		return nil, RingSizeRangeErr
+	}
+	return &v1.LoadBalancerConfig_RingHashConfig{
+		MinimumRingSize: minimum,
+		MaximumRingSize: maximum,
+	}, nil
+}
+
+// getRingSize returns the ring size set by the annotation, or 0 for envoy's default if it is not set
+func getRingSize(annotations map[string]string, annotation string) (uint64, error) {
+	value, ok := annotations[annotation]
+	if !ok {
+		return 0, nil
+	}
+	size, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || size == 0 {
+		return 0, InvalidRingSizeErr(annotation, value)
+	}
+	return size, nil
+}
@@ -0,0 +1,105 @@
+package loadbalancer
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("LoadBalancerPlugin", func() {
+	apply := func(annotations map[string]string) (*v1.Upstream, error) {
+		backendCtx := &plugins.BackendContext{
+			Service: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "svc",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+			},
+		}
+		us := &v1.Upstream{}
+		err := NewPlugin().ApplyBackendPlugin(context.Background(), backendCtx, us)
+		return us, err
+	}
+
+	DescribeTable("translates the policy",
+		func(annotations map[string]string, expected *v1.LoadBalancerConfig) {
+			us, err := apply(annotations)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(us.GetLoadBalancerConfig(), expected)).To(BeTrue())
+		},
+		Entry("round robin",
+			map[string]string{LoadBalancerPolicyAnnotation: RoundRobinPolicy},
+			&v1.LoadBalancerConfig{
+				Type: &v1.LoadBalancerConfig_RoundRobin_{RoundRobin: &v1.LoadBalancerConfig_RoundRobin{}},
+			}),
+		Entry("least request",
+			map[string]string{LoadBalancerPolicyAnnotation: LeastRequestPolicy},
+			&v1.LoadBalancerConfig{
+				Type: &v1.LoadBalancerConfig_LeastRequest_{LeastRequest: &v1.LoadBalancerConfig_LeastRequest{}},
+			}),
+		Entry("random",
+			map[string]string{LoadBalancerPolicyAnnotation: RandomPolicy},
+			&v1.LoadBalancerConfig{
+				Type: &v1.LoadBalancerConfig_Random_{Random: &v1.LoadBalancerConfig_Random{}},
+			}),
+		Entry("ring hash",
+			map[string]string{LoadBalancerPolicyAnnotation: RingHashPolicy},
+			&v1.LoadBalancerConfig{
+				Type: &v1.LoadBalancerConfig_RingHash_{RingHash: &v1.LoadBalancerConfig_RingHash{}},
+			}),
+		Entry("ring hash with ring sizes",
+			map[string]string{
+				LoadBalancerPolicyAnnotation:      RingHashPolicy,
+				RingHashMinimumRingSizeAnnotation: "512",
+				RingHashMaximumRingSizeAnnotation: "4096",
+			},
+			&v1.LoadBalancerConfig{
+				Type: &v1.LoadBalancerConfig_RingHash_{RingHash: &v1.LoadBalancerConfig_RingHash{
+					RingHashConfig: &v1.LoadBalancerConfig_RingHashConfig{
+						MinimumRingSize: 512,
+						MaximumRingSize: 4096,
+					},
+				}},
+			}),
+		Entry("maglev",
+			map[string]string{LoadBalancerPolicyAnnotation: MaglevPolicy},
+			&v1.LoadBalancerConfig{
+				Type: &v1.LoadBalancerConfig_Maglev_{Maglev: &v1.LoadBalancerConfig_Maglev{}},
+			}),
+	)
+
+	It("does nothing without the annotation", func() {
+		us, err := apply(map[string]string{RingHashMinimumRingSizeAnnotation: "512"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(us.GetLoadBalancerConfig()).To(BeNil())
+	})
+
+	DescribeTable("rejects invalid policies",
+		func(annotations map[string]string, expectedErr string) {
+			us, err := apply(annotations)
+			Expect(err).To(MatchError(expectedErr))
+			Expect(us.GetLoadBalancerConfig()).To(BeNil())
+		},
+		Entry("unknown policy",
+			map[string]string{LoadBalancerPolicyAnnotation: "weighted-least-busy"},
+			UnknownPolicyErr("weighted-least-busy").Error()),
+		Entry("zero ring size",
+			map[string]string{LoadBalancerPolicyAnnotation: RingHashPolicy, RingHashMaximumRingSizeAnnotation: "0"},
+			InvalidRingSizeErr(RingHashMaximumRingSizeAnnotation, "0").Error()),
+		Entry("minimum ring size above maximum",
+			map[string]string{
+				LoadBalancerPolicyAnnotation:      RingHashPolicy,
+				RingHashMinimumRingSizeAnnotation: "4096",
+				RingHashMaximumRingSizeAnnotation: "512",
+			},
+			RingSizeRangeErr.Error()),
+	)
+})
@@ -0,0 +1,104 @@
+package maintenance
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/core/matchers"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// Annotations set on a Gateway to put some of its Listeners into maintenance.
+const (
+	// MaintenanceListenersAnnotation is a comma-separated list of the names of the HTTP and HTTPS Listeners of the
+	// Gateway in maintenance. All the routes of their virtual hosts are replaced with a single route responding 503
+	// to every request, and are restored once the Listener is removed from the list. The HTTP Listeners sharing a port
+	// share the virtual hosts of the hostnames they both serve, which are put into maintenance with either Listener.
+	MaintenanceListenersAnnotation = "gateway2.solo.io/maintenance-listeners"
+	// MaintenanceBodyAnnotation is the body of the responses of the Listeners in maintenance,
+	// defaulting to "service under maintenance, please retry later"
+	MaintenanceBodyAnnotation = "gateway2.solo.io/maintenance-body"
+)
+
+const defaultBody = "service under maintenance, please retry later"
+
+var UnknownListenerErr = func(name string) error {
+	return errors.Errorf("listener '%s' of annotation %s is not an HTTP or HTTPS listener of the Gateway", name, MaintenanceListenersAnnotation)
+}
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	annotations := listenerCtx.Gateway.GetAnnotations()
+	value, ok := annotations[MaintenanceListenersAnnotation]
+	if !ok {
+		return nil
+	}
+	names, err := getMaintenanceListeners(listenerCtx.Gateway, value)
+	if err != nil {
+		return err
+	}
+	body := defaultBody
+	if b, ok := annotations[MaintenanceBodyAnnotation]; ok {
+		body = b
+	}
+
+	// requests are routed by host, so a virtual host shared by several Listeners is put into maintenance with any of them
+	vhosts := outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts()
+	for ref, vhost := range vhosts {
+		if slices.ContainsFunc(listenerCtx.VirtualHostListeners[ref], func(listener string) bool {
+			return names[listener]
+		}) {
+			vhost.Routes = []*v1.Route{maintenanceRoute(body)}
+		}
+	}
+	return nil
+}
+
+func getMaintenanceListeners(gateway *gwv1.Gateway, value string) (map[string]bool, error) {
+	httpListeners := map[string]bool{}
+	for _, listener := range gateway.Spec.Listeners {
+		if listener.Protocol == gwv1.HTTPProtocolType || listener.Protocol == gwv1.HTTPSProtocolType {
+			httpListeners[string(listener.Name)] = true
+		}
+	}
+	names := map[string]bool{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if !httpListeners[name] {
+			return nil, UnknownListenerErr(name)
+		}
+		names[name] = true
+	}
+	return names, nil
+}
+
+// maintenanceRoute returns a route responding 503 with the body to every request
+func maintenanceRoute(body string) *v1.Route {
+	return &v1.Route{
+		Matchers: []*matchers.Matcher{{
+			PathSpecifier: &matchers.Matcher_Prefix{Prefix: "/"},
+		}},
+		Action: &v1.Route_DirectResponseAction{
+			DirectResponseAction: &v1.DirectResponseAction{
+				Status: http.StatusServiceUnavailable,
+				Body:   body,
+			},
+		},
+	}
+}
@@ -0,0 +1,200 @@
+package maintenance_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/solo-io/gloo/projects/gateway2/reports"
+	"github.com/solo-io/gloo/projects/gateway2/translator/listener"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	. "github.com/solo-io/gloo/projects/gateway2/translator/plugins/maintenance"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/registry"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("MaintenancePlugin", func() {
+	const defaultBody = "service under maintenance, please retry later"
+
+	var (
+		gateway *gwv1.Gateway
+		objs    []client.Object
+	)
+
+	service := func(name string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}}},
+		}
+	}
+
+	rule := func(prefix, backend string) gwv1.HTTPRouteRule {
+		return gwv1.HTTPRouteRule{
+			Matches: []gwv1.HTTPRouteMatch{{
+				Path: &gwv1.HTTPPathMatch{
+					Type:  ptr.To(gwv1.PathMatchPathPrefix),
+					Value: ptr.To(prefix),
+				},
+			}},
+			BackendRefs: []gwv1.HTTPBackendRef{{
+				BackendRef: gwv1.BackendRef{
+					BackendObjectReference: gwv1.BackendObjectReference{
+						Name: gwv1.ObjectName(backend),
+						Port: ptr.To(gwv1.PortNumber(80)),
+					},
+				},
+			}},
+		}
+	}
+
+	httpRoute := func(name, section string, hostnames []gwv1.Hostname, rules ...gwv1.HTTPRouteRule) *gwv1.HTTPRoute {
+		return &gwv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: gwv1.HTTPRouteSpec{
+				CommonRouteSpec: gwv1.CommonRouteSpec{
+					ParentRefs: []gwv1.ParentReference{{
+						Name:        "gw",
+						SectionName: ptr.To(gwv1.SectionName(section)),
+					}},
+				},
+				Hostnames: hostnames,
+				Rules:     rules,
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		// the http and admin listeners share a port, so they are translated into a single listener
+		gateway = &gwv1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "gw",
+				Namespace: "default",
+			},
+			Spec: gwv1.GatewaySpec{
+				Listeners: []gwv1.Listener{
+					{Name: "http", Port: 8080, Protocol: gwv1.HTTPProtocolType},
+					{Name: "admin", Port: 8080, Protocol: gwv1.HTTPProtocolType, Hostname: ptr.To(gwv1.Hostname("admin.example.com"))},
+					{Name: "tcp", Port: 9000, Protocol: gwv1.TCPProtocolType},
+				},
+			},
+		}
+		objs = []client.Object{
+			service("api"),
+			service("web"),
+			service("bar"),
+			service("admin"),
+			httpRoute("web", "http", []gwv1.Hostname{"foo.example.com"}, rule("/api", "api"), rule("/", "web")),
+			httpRoute("bar", "http", []gwv1.Hostname{"bar.example.com"}, rule("/", "bar")),
+			httpRoute("admin", "admin", nil, rule("/", "admin")),
+		}
+	})
+
+	translate := func() []*v1.Listener {
+		ctx := context.Background()
+		queries := testutils.BuildGatewayQueries(objs)
+		routesForGw, err := queries.GetRoutesForGw(ctx, gateway)
+		Expect(err).NotTo(HaveOccurred())
+		pluginRegistry, err := registry.NewPluginRegistry([]plugins.Plugin{NewPlugin()})
+		Expect(err).NotTo(HaveOccurred())
+
+		reportMap := reports.NewReportMap()
+		return listener.TranslateListeners(ctx, queries, pluginRegistry, gateway, routesForGw, reports.NewReporter(&reportMap))
+	}
+
+	// backends returns the upstream of each route of the virtual host of the shared port, or the body of direct responses
+	backends := func(listeners []*v1.Listener, host string) []string {
+		Expect(listeners).NotTo(BeEmpty())
+		Expect(listeners[0].GetName()).To(Equal("http~admin"))
+		vhosts := listeners[0].GetAggregateListener().GetHttpResources().GetVirtualHosts()
+		Expect(vhosts).To(HaveKey("http~admin~" + host))
+
+		var backends []string
+		for _, route := range vhosts["http~admin~"+host].GetRoutes() {
+			if direct := route.GetDirectResponseAction(); direct != nil {
+				Expect(direct.GetStatus()).To(Equal(uint32(503)))
+				Expect(route.GetMatchers()[0].GetPrefix()).To(Equal("/"))
+				backends = append(backends, direct.GetBody())
+				continue
+			}
+			backends = append(backends, route.GetRouteAction().GetSingle().GetUpstream().GetName())
+		}
+		return backends
+	}
+
+	It("responds 503 to all the requests of the listeners in maintenance", func() {
+		gateway.Annotations = map[string]string{MaintenanceListenersAnnotation: "http"}
+		listeners := translate()
+		Expect(backends(listeners, "foo.example.com")).To(Equal([]string{defaultBody}))
+		Expect(backends(listeners, "bar.example.com")).To(Equal([]string{defaultBody}))
+		Expect(backends(listeners, "admin.example.com")).To(Equal([]string{"default-admin-80"}))
+	})
+
+	It("keeps the routes of the listeners sharing the port out of maintenance", func() {
+		gateway.Annotations = map[string]string{MaintenanceListenersAnnotation: "admin"}
+		listeners := translate()
+		Expect(backends(listeners, "foo.example.com")).To(Equal([]string{"default-api-80", "default-web-80"}))
+		Expect(backends(listeners, "bar.example.com")).To(Equal([]string{"default-bar-80"}))
+		Expect(backends(listeners, "admin.example.com")).To(Equal([]string{defaultBody}))
+	})
+
+	It("responds with the maintenance page", func() {
+		gateway.Annotations = map[string]string{
+			MaintenanceListenersAnnotation: "http, admin",
+			MaintenanceBodyAnnotation:      "<h1>Back soon</h1>",
+		}
+		listeners := translate()
+		Expect(backends(listeners, "foo.example.com")).To(Equal([]string{"<h1>Back soon</h1>"}))
+		Expect(backends(listeners, "bar.example.com")).To(Equal([]string{"<h1>Back soon</h1>"}))
+		Expect(backends(listeners, "admin.example.com")).To(Equal([]string{"<h1>Back soon</h1>"}))
+	})
+
+	It("puts the hostnames shared by the listeners into maintenance with either of them", func() {
+		objs = append(objs, httpRoute("status", "http", []gwv1.Hostname{"admin.example.com"}, rule("/status", "web")))
+
+		gateway.Annotations = map[string]string{MaintenanceListenersAnnotation: "http"}
+		listeners := translate()
+		Expect(backends(listeners, "admin.example.com")).To(Equal([]string{defaultBody}))
+
+		gateway.Annotations = map[string]string{MaintenanceListenersAnnotation: "admin"}
+		listeners = translate()
+		Expect(backends(listeners, "foo.example.com")).To(Equal([]string{"default-api-80", "default-web-80"}))
+		Expect(backends(listeners, "admin.example.com")).To(Equal([]string{defaultBody}))
+	})
+
+	It("serves the normal routes once maintenance is disabled", func() {
+		listeners := translate()
+		Expect(backends(listeners, "foo.example.com")).To(Equal([]string{"default-api-80", "default-web-80"}))
+		Expect(backends(listeners, "bar.example.com")).To(Equal([]string{"default-bar-80"}))
+		Expect(backends(listeners, "admin.example.com")).To(Equal([]string{"default-admin-80"}))
+	})
+
+	DescribeTable("rejects unknown listeners",
+		func(value string) {
+			gateway.Annotations = map[string]string{MaintenanceListenersAnnotation: value}
+			listeners := translate()
+			Expect(backends(listeners, "foo.example.com")).To(Equal([]string{"default-api-80", "default-web-80"}))
+			Expect(backends(listeners, "admin.example.com")).To(Equal([]string{"default-admin-80"}))
+		},
+		Entry("missing listener", "http,https"),
+		Entry("tcp listener", "tcp"),
+		Entry("empty name", "http,"),
+	)
+
+	DescribeTable("reports unknown listeners",
+		func(value, name string) {
+			gateway.Annotations = map[string]string{MaintenanceListenersAnnotation: value}
+			err := NewPlugin().ApplyListenerPlugin(context.Background(), &plugins.ListenerContext{Gateway: gateway}, &v1.Listener{})
+			Expect(err).To(MatchError(UnknownListenerErr(name).Error()))
+		},
+		Entry("missing listener", "http,https", "https"),
+		Entry("tcp listener", "tcp", "tcp"),
+		Entry("empty name", "http,", ""),
+	)
+})
@@ -2,18 +2,72 @@ package mirror
 
 import (
 	"context"
+	"regexp"
+	"strings"
 
 	"github.com/pkg/errors"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
 	"github.com/solo-io/gloo/projects/gateway2/query"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
 	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
 	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/shadowing"
-	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	"google.golang.org/protobuf/types/known/structpb"
 	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
-var _ plugins.RoutePlugin = &plugin{}
+// Annotations set on a RouteOption applied to a rule with a RequestMirror filter, to modify the shadow requests
+// independently of the primary requests. The shadow request is a copy of the primary request, made after the host
+// rewrite and header modifications of the route are applied, and envoy appends "-shadow" to its host.
+const (
+	// MirrorHostRewriteAnnotation is the host the shadow requests are sent with, replacing the host of the primary request
+	MirrorHostRewriteAnnotation = "gateway2.solo.io/mirror-host-rewrite"
+	// MirrorRequestHeadersAnnotation is a comma-separated list of <name>:<value> headers set on the shadow requests
+	MirrorRequestHeadersAnnotation = "gateway2.solo.io/mirror-request-headers"
+	// MirrorRemoveRequestHeadersAnnotation is a comma-separated list of the headers removed from the shadow requests
+	MirrorRemoveRequestHeadersAnnotation = "gateway2.solo.io/mirror-remove-request-headers"
+)
+
+// ShadowMetadataNamespace is the envoy metadata namespace marking the routes whose shadow requests are modified.
+// Gloo's RouteShadowing, like the envoy request mirror policy it is built on, only selects the shadow upstream, so
+// the sanitizer of this package mirrors the routes carrying the metadata to a copy of the cluster of the shadow
+// upstream, modifying the requests it sends.
+const ShadowMetadataNamespace = "gateway2.solo.io/shadow"
+
+const (
+	hostRewriteField   = "host_rewrite"
+	setHeadersField    = "request_headers_to_set"
+	removeHeadersField = "request_headers_to_remove"
+)
+
+var (
+	// the token characters of RFC 9110 field names
+	headerNameRegex = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+	hostRegex       = regexp.MustCompile(`^[A-Za-z0-9.-]+(:[0-9]+)?$`)
+
+	InvalidHostRewriteErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a host", value, MirrorHostRewriteAnnotation)
+	}
+	InvalidHeaderErr = func(annotation, value string) error {
+		return errors.Errorf("invalid header '%s' in annotation %s", value, annotation)
+	}
+	// the host is set with the mirror-host-rewrite annotation, and envoy requires the pseudo-headers
+	UnmodifiableHeaderErr = func(annotation, value string) error {
+		return errors.Errorf("header '%s' in annotation %s cannot be modified: the host and pseudo-headers are managed by envoy", value, annotation)
+	}
+	ShadowWithoutMirrorErr = errors.Errorf("annotations %s, %s and %s require a RequestMirror filter",
+		MirrorHostRewriteAnnotation, MirrorRequestHeadersAnnotation, MirrorRemoveRequestHeadersAnnotation)
+	ConflictingShadowMetadataErr = errors.Errorf("the shadow requests of the RequestMirror filter cannot be modified along with "+
+		"envoy metadata of the RouteOption in namespace %s", ShadowMetadataNamespace)
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+)
+
+// The mirror plugin translates the RequestMirror filter into the shadowing options of the route.
 
 type plugin struct {
 	queries query.GatewayQueries
@@ -25,13 +79,28 @@ func NewPlugin(queries query.GatewayQueries) *plugin {
 	}
 }
 
+// DependsOn the routeoptions plugin, which replaces the route's options wholesale
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
 func (p *plugin) ApplyRoutePlugin(
 	ctx context.Context,
 	routeCtx *plugins.RouteContext,
 	outputRoute *v1.Route,
 ) error {
+	var shadow *structpb.Struct
+	if routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries); routeOption != nil {
+		var err error
+		if shadow, err = parseShadowAnnotations(routeOption.GetAnnotations()); err != nil {
+			return err
+		}
+	}
 	filter := utils.FindAppliedRouteFilter(routeCtx, gwv1.HTTPRouteFilterRequestMirror)
 	if filter == nil {
+		if shadow != nil {
+			return ShadowWithoutMirrorErr
+		}
 		return nil
 	}
 
@@ -45,24 +114,86 @@ func (p *plugin) ApplyRoutePlugin(
 		return errors.Errorf("RequestMirror must have destinations")
 	}
 
-	obj, err := p.queries.GetBackendForRef(ctx, p.queries.ObjToFrom(routeCtx.Route), &config.BackendRef)
-	clusterName := query.ProcessBackendRef(
-		obj,
-		err,
-		routeCtx.Reporter,
-		config.BackendRef,
-	)
-	if clusterName == nil {
+	upstreamRef, err := p.queries.ResolveBackendRef(ctx, p.queries.ObjToFrom(routeCtx.Route), &config.BackendRef)
+	if err != nil {
+		query.ReportBackendRefError(routeCtx.Reporter, err)
 		return nil //TODO https://github.com/solo-io/gloo/pull/8890/files#r1391523183
 	}
 
-	outputRoute.GetOptions().Shadowing = &shadowing.RouteShadowing{
-		Upstream: &core.ResourceRef{
-			Name:      *clusterName,
-			Namespace: obj.GetNamespace(),
-		},
+	options := outputRoute.GetOptions()
+	if shadow != nil {
+		if _, ok := options.GetEnvoyMetadata()[ShadowMetadataNamespace]; ok {
+			return ConflictingShadowMetadataErr
+		}
+		if options.GetEnvoyMetadata() == nil {
+			options.EnvoyMetadata = map[string]*structpb.Struct{}
+		}
+		options.GetEnvoyMetadata()[ShadowMetadataNamespace] = shadow
+	}
+	options.Shadowing = &shadowing.RouteShadowing{
+		Upstream:   upstreamRef,
 		Percentage: 100.0,
 	}
 
 	return nil
 }
+
+// parseShadowAnnotations returns the metadata marking the route with the modifications of its shadow requests,
+// or nil if they are not modified
+// ValidateRouteOption does not require a RequestMirror filter, which is set on the routes
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	shadow, err := parseShadowAnnotations(routeOption.GetAnnotations())
+	if err != nil {
+		return err
+	}
+	if _, ok := routeOption.Spec.GetOptions().GetEnvoyMetadata()[ShadowMetadataNamespace]; ok && shadow != nil {
+		return ConflictingShadowMetadataErr
+	}
+	return nil
+}
+
+func parseShadowAnnotations(annotations map[string]string) (*structpb.Struct, error) {
+	shadow := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	if host, ok := annotations[MirrorHostRewriteAnnotation]; ok {
+		if !hostRegex.MatchString(host) {
+			return nil, InvalidHostRewriteErr(host)
+		}
+		shadow.GetFields()[hostRewriteField] = structpb.NewStringValue(host)
+	}
+	if value, ok := annotations[MirrorRequestHeadersAnnotation]; ok {
+		headers := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+		for _, header := range strings.Split(value, ",") {
+			name, v, found := strings.Cut(strings.TrimSpace(header), ":")
+			name, v = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(v)
+			if !found || !headerNameRegex.MatchString(name) || v == "" {
+				return nil, InvalidHeaderErr(MirrorRequestHeadersAnnotation, header)
+			}
+			if name == "host" {
+				return nil, UnmodifiableHeaderErr(MirrorRequestHeadersAnnotation, name)
+			}
+			headers.GetFields()[name] = structpb.NewStringValue(v)
+		}
+		shadow.GetFields()[setHeadersField] = structpb.NewStructValue(headers)
+	}
+	if value, ok := annotations[MirrorRemoveRequestHeadersAnnotation]; ok {
+		var headers []*structpb.Value
+		for _, header := range strings.Split(value, ",") {
+			header = strings.ToLower(strings.TrimSpace(header))
+			if strings.HasPrefix(header, ":") || header == "host" {
+				return nil, UnmodifiableHeaderErr(MirrorRemoveRequestHeadersAnnotation, header)
+			}
+			if !headerNameRegex.MatchString(header) {
+				return nil, InvalidHeaderErr(MirrorRemoveRequestHeadersAnnotation, header)
+			}
+			headers = append(headers, structpb.NewStringValue(header))
+		}
+		shadow.GetFields()[removeHeadersField] = structpb.NewListValue(&structpb.ListValue{Values: headers})
+	}
+	if len(shadow.GetFields()) == 0 {
+		return nil, nil
+	}
+	return shadow, nil
+}
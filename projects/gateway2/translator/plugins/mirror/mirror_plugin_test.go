@@ -6,12 +6,22 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/onsi/gomega"
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/reports"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/mirror"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/mirror/mocks"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
 	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
@@ -40,15 +50,9 @@ func TestSingleMirror(t *testing.T) {
 			},
 		},
 	}
-	svc := &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "foo",
-			Namespace: "bar",
-		},
-	}
-
 	queries.EXPECT().ObjToFrom(rt).Return(nil)
-	queries.EXPECT().GetBackendForRef(context.Background(), gomock.Any(), &filter.RequestMirror.BackendRef).Return(svc, nil)
+	queries.EXPECT().ResolveBackendRef(context.Background(), gomock.Any(), &filter.RequestMirror.BackendRef).
+		Return(&core.ResourceRef{Name: "bar-foo-8080", Namespace: "bar"}, nil)
 	plugin := mirror.NewPlugin(queries)
 	outputRoute := &v1.Route{
 		Action:  &v1.Route_RouteAction{},
@@ -64,6 +68,275 @@ func TestSingleMirror(t *testing.T) {
 	g.Expect(shadowing.Percentage).To(gomega.Equal(float32(100.0)))
 }
 
+func TestMirrorLeavesPrimaryUnaffected(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	queries := mocks.NewMockGatewayQueries(ctrl)
+
+	filter := gwv1.HTTPRouteFilter{
+		Type: gwv1.HTTPRouteFilterRequestMirror,
+		RequestMirror: &gwv1.HTTPRequestMirrorFilter{
+			BackendRef: gwv1.BackendObjectReference{
+				Name: "shadow",
+				Port: ptr(gwv1.PortNumber(8080)),
+			},
+		},
+	}
+	rt := &gwv1.HTTPRoute{}
+	routeCtx := &plugins.RouteContext{
+		Route: rt,
+		Rule: &gwv1.HTTPRouteRule{
+			Filters: []gwv1.HTTPRouteFilter{
+				filter,
+			},
+		},
+	}
+	queries.EXPECT().ObjToFrom(rt).Return(nil)
+	queries.EXPECT().ResolveBackendRef(context.Background(), gomock.Any(), &filter.RequestMirror.BackendRef).
+		Return(&core.ResourceRef{Name: "bar-shadow-8080", Namespace: "bar"}, nil)
+	plugin := mirror.NewPlugin(queries)
+	primaryAction := &v1.RouteAction{
+		Destination: &v1.RouteAction_Single{
+			Single: &v1.Destination{
+				DestinationType: &v1.Destination_Upstream{
+					Upstream: &core.ResourceRef{Name: "bar-foo-8080", Namespace: "bar"},
+				},
+			},
+		},
+	}
+	outputRoute := &v1.Route{
+		Action: &v1.Route_RouteAction{
+			RouteAction: proto.Clone(primaryAction).(*v1.RouteAction),
+		},
+		Options: &v1.RouteOptions{
+			HostRewriteType: &v1.RouteOptions_HostRewrite{HostRewrite: "primary.example.com"},
+		},
+	}
+	err := plugin.ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	g.Expect(outputRoute.GetOptions().GetShadowing().GetUpstream().GetName()).To(gomega.Equal("bar-shadow-8080"))
+	g.Expect(outputRoute.GetOptions().GetHostRewrite()).To(gomega.Equal("primary.example.com"))
+	g.Expect(proto.Equal(outputRoute.GetRouteAction(), primaryAction)).To(gomega.BeTrue())
+}
+
+func TestMirrorUnresolvedBackend(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	queries := mocks.NewMockGatewayQueries(ctrl)
+
+	filter := gwv1.HTTPRouteFilter{
+		Type: gwv1.HTTPRouteFilterRequestMirror,
+		RequestMirror: &gwv1.HTTPRequestMirrorFilter{
+			BackendRef: gwv1.BackendObjectReference{
+				Name:      "shadow",
+				Namespace: ptr(gwv1.Namespace("other")),
+				Port:      ptr(gwv1.PortNumber(8080)),
+			},
+		},
+	}
+	parentRef := gwv1.ParentReference{Name: "gw"}
+	rt := &gwv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "route",
+			Namespace: "default",
+		},
+		Spec: gwv1.HTTPRouteSpec{
+			CommonRouteSpec: gwv1.CommonRouteSpec{
+				ParentRefs: []gwv1.ParentReference{parentRef},
+			},
+		},
+	}
+	rm := reports.NewReportMap()
+	routeCtx := &plugins.RouteContext{
+		Route: rt,
+		Rule: &gwv1.HTTPRouteRule{
+			Filters: []gwv1.HTTPRouteFilter{
+				filter,
+			},
+		},
+		Reporter: reports.NewReporter(&rm).Route(rt).ParentRef(&parentRef),
+	}
+
+	queries.EXPECT().ObjToFrom(rt).Return(nil)
+	queries.EXPECT().ResolveBackendRef(context.Background(), gomock.Any(), &filter.RequestMirror.BackendRef).
+		Return(nil, &query.Error{Reason: gwv1.RouteReasonRefNotPermitted, E: query.ErrMissingReferenceGrant})
+	plugin := mirror.NewPlugin(queries)
+	outputRoute := &v1.Route{
+		Action:  &v1.Route_RouteAction{},
+		Options: &v1.RouteOptions{},
+	}
+	err := plugin.ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	g.Expect(outputRoute.GetOptions().GetShadowing()).To(gomega.BeNil())
+	condition := meta.FindStatusCondition(rm.BuildRouteStatus(context.Background(), *rt, "").Parents[0].Conditions, string(gwv1.RouteConditionResolvedRefs))
+	g.Expect(condition).ToNot(gomega.BeNil())
+	g.Expect(condition.Status).To(gomega.Equal(metav1.ConditionFalse))
+	g.Expect(condition.Reason).To(gomega.Equal(string(gwv1.RouteReasonRefNotPermitted)))
+}
+
+// shadowRouteCtx returns the context of a rule mirroring to Service shadow, with the RouteOption policy applied
+func shadowRouteCtx() *plugins.RouteContext {
+	return &plugins.RouteContext{
+		Route: &gwv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "default"},
+		},
+		Rule: &gwv1.HTTPRouteRule{
+			Filters: []gwv1.HTTPRouteFilter{
+				{
+					Type: gwv1.HTTPRouteFilterRequestMirror,
+					RequestMirror: &gwv1.HTTPRequestMirrorFilter{
+						BackendRef: gwv1.BackendObjectReference{
+							Name: "shadow",
+							Port: ptr(gwv1.PortNumber(8080)),
+						},
+					},
+				},
+				{
+					Type: gwv1.HTTPRouteFilterExtensionRef,
+					ExtensionRef: &gwv1.LocalObjectReference{
+						Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Name:  "policy",
+					},
+				},
+			},
+		},
+	}
+}
+
+func shadowQueries(annotations map[string]string) query.GatewayQueries {
+	return testutils.BuildGatewayQueries([]client.Object{
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "shadow", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 8080}}},
+		},
+		&solokubev1.RouteOption{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "policy",
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+		},
+	})
+}
+
+func TestMirrorModifiesShadowRequests(t *testing.T) {
+	g := gomega.NewWithT(t)
+	plugin := mirror.NewPlugin(shadowQueries(map[string]string{
+		mirror.MirrorHostRewriteAnnotation:          "shadow.example.com",
+		mirror.MirrorRequestHeadersAnnotation:       "x-shadow: true, X-Env:staging",
+		mirror.MirrorRemoveRequestHeadersAnnotation: "Authorization",
+	}))
+	primaryAction := &v1.RouteAction{
+		Destination: &v1.RouteAction_Single{
+			Single: &v1.Destination{
+				DestinationType: &v1.Destination_Upstream{
+					Upstream: &core.ResourceRef{Name: "default-foo-8080", Namespace: "default"},
+				},
+			},
+		},
+	}
+	outputRoute := &v1.Route{
+		Action: &v1.Route_RouteAction{
+			RouteAction: proto.Clone(primaryAction).(*v1.RouteAction),
+		},
+		Options: &v1.RouteOptions{
+			HostRewriteType: &v1.RouteOptions_HostRewrite{HostRewrite: "primary.example.com"},
+		},
+	}
+	err := plugin.ApplyRoutePlugin(context.Background(), shadowRouteCtx(), outputRoute)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	g.Expect(outputRoute.GetOptions().GetShadowing().GetUpstream().GetName()).To(gomega.Equal("default-shadow-8080"))
+	g.Expect(proto.Equal(outputRoute.GetOptions().GetEnvoyMetadata()[mirror.ShadowMetadataNamespace], &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"host_rewrite": structpb.NewStringValue("shadow.example.com"),
+			"request_headers_to_set": structpb.NewStructValue(&structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"x-shadow": structpb.NewStringValue("true"),
+					"x-env":    structpb.NewStringValue("staging"),
+				},
+			}),
+			"request_headers_to_remove": structpb.NewListValue(&structpb.ListValue{
+				Values: []*structpb.Value{structpb.NewStringValue("authorization")},
+			}),
+		},
+	})).To(gomega.BeTrue())
+	// the primary requests keep the host rewrite and destination of the route
+	g.Expect(outputRoute.GetOptions().GetHostRewrite()).To(gomega.Equal("primary.example.com"))
+	g.Expect(proto.Equal(outputRoute.GetRouteAction(), primaryAction)).To(gomega.BeTrue())
+}
+
+func TestMirrorWithoutShadowModifications(t *testing.T) {
+	g := gomega.NewWithT(t)
+	plugin := mirror.NewPlugin(shadowQueries(map[string]string{"other": "value"}))
+	outputRoute := &v1.Route{
+		Action:  &v1.Route_RouteAction{},
+		Options: &v1.RouteOptions{},
+	}
+	err := plugin.ApplyRoutePlugin(context.Background(), shadowRouteCtx(), outputRoute)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	g.Expect(outputRoute.GetOptions().GetShadowing().GetUpstream().GetName()).To(gomega.Equal("default-shadow-8080"))
+	g.Expect(outputRoute.GetOptions().GetEnvoyMetadata()).To(gomega.BeEmpty())
+}
+
+func TestShadowModificationsWithoutMirror(t *testing.T) {
+	g := gomega.NewWithT(t)
+	plugin := mirror.NewPlugin(shadowQueries(map[string]string{
+		mirror.MirrorHostRewriteAnnotation: "shadow.example.com",
+	}))
+	routeCtx := shadowRouteCtx()
+	routeCtx.Rule.Filters = routeCtx.Rule.Filters[1:]
+	outputRoute := &v1.Route{
+		Action:  &v1.Route_RouteAction{},
+		Options: &v1.RouteOptions{},
+	}
+	err := plugin.ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+	g.Expect(err).To(gomega.MatchError(mirror.ShadowWithoutMirrorErr))
+	g.Expect(outputRoute.GetOptions().GetEnvoyMetadata()).To(gomega.BeEmpty())
+}
+
+func TestInvalidShadowModifications(t *testing.T) {
+	for _, tc := range []struct {
+		annotations map[string]string
+		expectedErr error
+	}{
+		{
+			annotations: map[string]string{mirror.MirrorHostRewriteAnnotation: "shadow example.com"},
+			expectedErr: mirror.InvalidHostRewriteErr("shadow example.com"),
+		},
+		{
+			annotations: map[string]string{mirror.MirrorRequestHeadersAnnotation: "x-shadow"},
+			expectedErr: mirror.InvalidHeaderErr(mirror.MirrorRequestHeadersAnnotation, "x-shadow"),
+		},
+		{
+			annotations: map[string]string{mirror.MirrorRequestHeadersAnnotation: "Host: shadow.example.com"},
+			expectedErr: mirror.UnmodifiableHeaderErr(mirror.MirrorRequestHeadersAnnotation, "host"),
+		},
+		{
+			annotations: map[string]string{mirror.MirrorRemoveRequestHeadersAnnotation: "x-a,:path"},
+			expectedErr: mirror.UnmodifiableHeaderErr(mirror.MirrorRemoveRequestHeadersAnnotation, ":path"),
+		},
+		{
+			annotations: map[string]string{mirror.MirrorRemoveRequestHeadersAnnotation: "x-a,"},
+			expectedErr: mirror.InvalidHeaderErr(mirror.MirrorRemoveRequestHeadersAnnotation, ""),
+		},
+	} {
+		g := gomega.NewWithT(t)
+		plugin := mirror.NewPlugin(shadowQueries(tc.annotations))
+		outputRoute := &v1.Route{
+			Action:  &v1.Route_RouteAction{},
+			Options: &v1.RouteOptions{},
+		}
+		err := plugin.ApplyRoutePlugin(context.Background(), shadowRouteCtx(), outputRoute)
+		g.Expect(err).To(gomega.MatchError(tc.expectedErr.Error()))
+		g.Expect(outputRoute.GetOptions().GetShadowing()).To(gomega.BeNil())
+	}
+}
+
 // NOTE: Gloo Edge Proxy IR doesn't support multiple mirror/shadow policies on the same route
 // func TestMultipleMirrors(t *testing.T) {
 // 	g := gomega.NewWithT(t)
@@ -10,6 +10,7 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	query "github.com/solo-io/gloo/projects/gateway2/query"
+	core "github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
 	client "sigs.k8s.io/controller-runtime/pkg/client"
 	v1 "sigs.k8s.io/gateway-api/apis/v1"
 )
@@ -110,3 +111,18 @@ func (mr *MockGatewayQueriesMockRecorder) ObjToFrom(arg0 interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObjToFrom", reflect.TypeOf((*MockGatewayQueries)(nil).ObjToFrom), arg0)
 }
+
+// ResolveBackendRef mocks base method.
+func (m *MockGatewayQueries) ResolveBackendRef(arg0 context.Context, arg1 query.From, arg2 *v1.BackendObjectReference) (*core.ResourceRef, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveBackendRef", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*core.ResourceRef)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveBackendRef indicates an expected call of ResolveBackendRef.
+func (mr *MockGatewayQueriesMockRecorder) ResolveBackendRef(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveBackendRef", reflect.TypeOf((*MockGatewayQueries)(nil).ResolveBackendRef), arg0, arg1, arg2)
+}
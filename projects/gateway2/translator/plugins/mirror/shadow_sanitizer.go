@@ -0,0 +1,302 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	envoy_config_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_config_common_mutation_rules_v3 "github.com/envoyproxy/go-control-plane/envoy/config/common/mutation_rules/v3"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_config_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_extensions_filters_http_header_mutation_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/header_mutation/v3"
+	envoy_extensions_filters_http_upstream_codec_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/upstream_codec/v3"
+	envoy_extensions_filters_network_http_connection_manager_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_extensions_upstreams_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	v1snap "github.com/solo-io/gloo/projects/gloo/pkg/api/v1/gloosnapshot"
+	"github.com/solo-io/gloo/projects/gloo/pkg/plugins/pluginutils"
+	"github.com/solo-io/gloo/projects/gloo/pkg/syncer/sanitizer"
+	"github.com/solo-io/gloo/projects/gloo/pkg/translator"
+	"github.com/solo-io/gloo/projects/gloo/pkg/utils"
+	"github.com/solo-io/gloo/projects/gloo/pkg/xds"
+	"github.com/solo-io/go-utils/contextutils"
+	envoycache "github.com/solo-io/solo-kit/pkg/api/v1/control-plane/cache"
+	"github.com/solo-io/solo-kit/pkg/api/v1/control-plane/resource"
+	"github.com/solo-io/solo-kit/pkg/api/v1/control-plane/types"
+	"github.com/solo-io/solo-kit/pkg/api/v2/reporter"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	httpProtocolOptionsExtension = "envoy.extensions.upstreams.http.v3.HttpProtocolOptions"
+	headerMutationFilterName     = "envoy.filters.http.header_mutation"
+	upstreamCodecFilterName      = "envoy.filters.http.upstream_codec"
+)
+
+var _ sanitizer.XdsSanitizer = &shadowSanitizer{}
+
+// shadowSanitizer mirrors the envoy routes marked by the plugin to a shadow cluster, a copy of the cluster of their
+// shadow upstream with an upstream filter modifying the requests it sends, and removes the marker. The primary
+// requests are sent to the clusters of the routes, and are therefore not modified.
+type shadowSanitizer struct{}
+
+func NewSanitizer() *shadowSanitizer {
+	return &shadowSanitizer{}
+}
+
+func (s *shadowSanitizer) SanitizeSnapshot(
+	ctx context.Context,
+	glooSnapshot *v1snap.ApiSnapshot,
+	xdsSnapshot envoycache.Snapshot,
+	reports reporter.ResourceReports,
+) envoycache.Snapshot {
+	var (
+		routeConfigs []*envoy_config_route_v3.RouteConfiguration
+		marked       bool
+	)
+	clusters := xdsSnapshot.GetResources(types.ClusterTypeV3).Items
+	shadowClusters := map[string]*envoy_config_cluster_v3.Cluster{}
+	for _, item := range xdsSnapshot.GetResources(types.RouteTypeV3).Items {
+		routeConfig, ok := item.ResourceProto().(*envoy_config_route_v3.RouteConfiguration)
+		if !ok {
+			contextutils.LoggerFrom(ctx).DPanicf("xds snapshot resources of type RouteTypeV3 were not "+
+				"converted to *envoy_config_route_v3.RouteConfiguration, instead found %T", item.ResourceProto())
+			return xdsSnapshot
+		}
+		if hasShadowedRoutes(routeConfig) {
+			// the snapshot resources are not modified, their version was computed from them
+			routeConfig = proto.Clone(routeConfig).(*envoy_config_route_v3.RouteConfiguration)
+			shadowRoutes(ctx, routeConfig, clusters, shadowClusters)
+			marked = true
+		}
+		routeConfigs = append(routeConfigs, routeConfig)
+	}
+	if !marked {
+		return xdsSnapshot
+	}
+
+	var clusterResources []envoycache.Resource
+	for _, item := range clusters {
+		clusterResources = append(clusterResources, item)
+	}
+	for _, cluster := range shadowClusters {
+		clusterResources = append(clusterResources, resource.NewEnvoyResource(cluster))
+	}
+	clustersVersion, err := translator.EnvoyCacheResourcesListToFnvHash(clusterResources)
+	if err != nil {
+		contextutils.LoggerFrom(ctx).DPanic(fmt.Sprintf("error trying to hash clusters: %v", err))
+		return xdsSnapshot
+	}
+
+	return xds.NewSnapshotFromResources(
+		xdsSnapshot.GetResources(types.EndpointTypeV3),
+		envoycache.NewResources(fmt.Sprintf("%v", clustersVersion), clusterResources),
+		translator.MakeRdsResources(routeConfigs),
+		xdsSnapshot.GetResources(types.ListenerTypeV3),
+	)
+}
+
+func hasShadowedRoutes(routeConfig *envoy_config_route_v3.RouteConfiguration) bool {
+	for _, vh := range routeConfig.GetVirtualHosts() {
+		for _, route := range vh.GetRoutes() {
+			if _, ok := route.GetMetadata().GetFilterMetadata()[ShadowMetadataNamespace]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// shadowRoutes removes the marker of the routes, and mirrors them to the shadow clusters of their shadow upstreams,
+// adding the shadow clusters missing from shadowClusters
+func shadowRoutes(
+	ctx context.Context,
+	routeConfig *envoy_config_route_v3.RouteConfiguration,
+	clusters map[string]envoycache.Resource,
+	shadowClusters map[string]*envoy_config_cluster_v3.Cluster,
+) {
+	for _, vh := range routeConfig.GetVirtualHosts() {
+		for _, route := range vh.GetRoutes() {
+			marker, ok := route.GetMetadata().GetFilterMetadata()[ShadowMetadataNamespace]
+			if !ok {
+				continue
+			}
+			delete(route.GetMetadata().GetFilterMetadata(), ShadowMetadataNamespace)
+			if len(route.GetMetadata().GetFilterMetadata()) == 0 {
+				route.Metadata = nil
+			}
+			for _, policy := range route.GetRoute().GetRequestMirrorPolicies() {
+				item, ok := clusters[policy.GetCluster()]
+				if !ok {
+					// e.g. removed from the snapshot with its errored upstream
+					continue
+				}
+				cluster, ok := item.ResourceProto().(*envoy_config_cluster_v3.Cluster)
+				if !ok {
+					contextutils.LoggerFrom(ctx).DPanicf("xds snapshot resources of type ClusterTypeV3 were not "+
+						"converted to *envoy_config_cluster_v3.Cluster, instead found %T", item.ResourceProto())
+					continue
+				}
+				name := shadowClusterName(cluster.GetName(), marker)
+				if _, ok := shadowClusters[name]; !ok {
+					shadowCluster, err := makeShadowCluster(cluster, name, marker)
+					if err != nil {
+						contextutils.LoggerFrom(ctx).Errorf("failed to modify the shadow requests of route %s: %v", route.GetName(), err)
+						continue
+					}
+					shadowClusters[name] = shadowCluster
+				}
+				policy.Cluster = name
+			}
+		}
+	}
+}
+
+// shadowClusterName returns a name unique to the cluster and the modifications of its shadow requests, so that
+// routes modifying the shadow requests to the same upstream in the same way share their shadow cluster
+func shadowClusterName(cluster string, marker *structpb.Struct) string {
+	hash := fnv.New32a()
+	b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(marker)
+	hash.Write(b)
+	return fmt.Sprintf("%s-shadow-%x", cluster, hash.Sum32())
+}
+
+// makeShadowCluster copies the cluster, adding an upstream header mutation filter modifying its requests
+func makeShadowCluster(cluster *envoy_config_cluster_v3.Cluster, name string, marker *structpb.Struct) (*envoy_config_cluster_v3.Cluster, error) {
+	shadowCluster := proto.Clone(cluster).(*envoy_config_cluster_v3.Cluster)
+	shadowCluster.Name = name
+	if shadowCluster.GetType() == envoy_config_cluster_v3.Cluster_EDS && shadowCluster.GetEdsClusterConfig().GetServiceName() == "" {
+		// the endpoints are looked up with the name of the cluster by default
+		if shadowCluster.GetEdsClusterConfig() == nil {
+			shadowCluster.EdsClusterConfig = &envoy_config_cluster_v3.Cluster_EdsClusterConfig{}
+		}
+		shadowCluster.GetEdsClusterConfig().ServiceName = cluster.GetName()
+	}
+
+	options, err := httpProtocolOptions(shadowCluster)
+	if err != nil {
+		return nil, err
+	}
+	headerMutation, err := utils.MessageToAny(&envoy_extensions_filters_http_header_mutation_v3.HeaderMutation{
+		Mutations: &envoy_extensions_filters_http_header_mutation_v3.Mutations{
+			RequestMutations: requestMutations(marker),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	filters := []*envoy_extensions_filters_network_http_connection_manager_v3.HttpFilter{{
+		Name:       headerMutationFilterName,
+		ConfigType: &envoy_extensions_filters_network_http_connection_manager_v3.HttpFilter_TypedConfig{TypedConfig: headerMutation},
+	}}
+	if len(options.GetHttpFilters()) == 0 {
+		// the upstream filters must end with the codec filter, which envoy adds only without upstream filters
+		upstreamCodec, err := utils.MessageToAny(&envoy_extensions_filters_http_upstream_codec_v3.UpstreamCodec{})
+		if err != nil {
+			return nil, err
+		}
+		options.HttpFilters = []*envoy_extensions_filters_network_http_connection_manager_v3.HttpFilter{{
+			Name:       upstreamCodecFilterName,
+			ConfigType: &envoy_extensions_filters_network_http_connection_manager_v3.HttpFilter_TypedConfig{TypedConfig: upstreamCodec},
+		}}
+	}
+	options.HttpFilters = append(filters, options.GetHttpFilters()...)
+
+	if err := pluginutils.SetExtensionProtocolOptions(shadowCluster, httpProtocolOptionsExtension, options); err != nil {
+		return nil, err
+	}
+	return shadowCluster, nil
+}
+
+// httpProtocolOptions returns the http protocol options of the cluster, moving the deprecated protocol options of the
+// cluster into them, as envoy rejects clusters setting both
+func httpProtocolOptions(cluster *envoy_config_cluster_v3.Cluster) (*envoy_extensions_upstreams_http_v3.HttpProtocolOptions, error) {
+	options := &envoy_extensions_upstreams_http_v3.HttpProtocolOptions{}
+	if typedOptions, ok := cluster.GetTypedExtensionProtocolOptions()[httpProtocolOptionsExtension]; ok {
+		if err := typedOptions.UnmarshalTo(options); err != nil {
+			return nil, err
+		}
+		return options, nil
+	}
+
+	options.CommonHttpProtocolOptions = cluster.GetCommonHttpProtocolOptions()
+	if maxRequests := cluster.GetMaxRequestsPerConnection(); maxRequests != nil {
+		if options.GetCommonHttpProtocolOptions() == nil {
+			options.CommonHttpProtocolOptions = &envoy_config_core_v3.HttpProtocolOptions{}
+		}
+		options.GetCommonHttpProtocolOptions().MaxRequestsPerConnection = maxRequests
+	}
+	options.UpstreamHttpProtocolOptions = cluster.GetUpstreamHttpProtocolOptions()
+	switch {
+	case cluster.GetProtocolSelection() == envoy_config_cluster_v3.Cluster_USE_DOWNSTREAM_PROTOCOL:
+		options.UpstreamProtocolOptions = &envoy_extensions_upstreams_http_v3.HttpProtocolOptions_UseDownstreamProtocolConfig{
+			UseDownstreamProtocolConfig: &envoy_extensions_upstreams_http_v3.HttpProtocolOptions_UseDownstreamHttpConfig{
+				HttpProtocolOptions:  cluster.GetHttpProtocolOptions(),
+				Http2ProtocolOptions: cluster.GetHttp2ProtocolOptions(),
+			},
+		}
+	case cluster.GetHttp2ProtocolOptions() != nil:
+		options.UpstreamProtocolOptions = &envoy_extensions_upstreams_http_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+			ExplicitHttpConfig: &envoy_extensions_upstreams_http_v3.HttpProtocolOptions_ExplicitHttpConfig{
+				ProtocolConfig: &envoy_extensions_upstreams_http_v3.HttpProtocolOptions_ExplicitHttpConfig_Http2ProtocolOptions{
+					Http2ProtocolOptions: cluster.GetHttp2ProtocolOptions(),
+				},
+			},
+		}
+	default:
+		http1 := cluster.GetHttpProtocolOptions()
+		if http1 == nil {
+			http1 = &envoy_config_core_v3.Http1ProtocolOptions{}
+		}
+		options.UpstreamProtocolOptions = &envoy_extensions_upstreams_http_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+			ExplicitHttpConfig: &envoy_extensions_upstreams_http_v3.HttpProtocolOptions_ExplicitHttpConfig{
+				ProtocolConfig: &envoy_extensions_upstreams_http_v3.HttpProtocolOptions_ExplicitHttpConfig_HttpProtocolOptions{
+					HttpProtocolOptions: http1,
+				},
+			},
+		}
+	}
+	cluster.CommonHttpProtocolOptions = nil
+	cluster.MaxRequestsPerConnection = nil
+	cluster.UpstreamHttpProtocolOptions = nil
+	cluster.ProtocolSelection = envoy_config_cluster_v3.Cluster_USE_CONFIGURED_PROTOCOL
+	cluster.HttpProtocolOptions = nil
+	cluster.Http2ProtocolOptions = nil
+	return options, nil
+}
+
+// requestMutations returns the header mutations of the marker, removing the headers before setting the others
+func requestMutations(marker *structpb.Struct) []*envoy_config_common_mutation_rules_v3.HeaderMutation {
+	var mutations []*envoy_config_common_mutation_rules_v3.HeaderMutation
+	for _, header := range marker.GetFields()[removeHeadersField].GetListValue().GetValues() {
+		mutations = append(mutations, &envoy_config_common_mutation_rules_v3.HeaderMutation{
+			Action: &envoy_config_common_mutation_rules_v3.HeaderMutation_Remove{Remove: header.GetStringValue()},
+		})
+	}
+	set := marker.GetFields()[setHeadersField].GetStructValue().GetFields()
+	headers := make(map[string]string, len(set)+1)
+	var names []string
+	for name, value := range set {
+		headers[name] = value.GetStringValue()
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if host, ok := marker.GetFields()[hostRewriteField]; ok {
+		// envoy holds the host of requests in the :authority pseudo-header, including the -shadow suffix
+		headers[":authority"] = host.GetStringValue()
+		names = append([]string{":authority"}, names...)
+	}
+	for _, name := range names {
+		mutations = append(mutations, &envoy_config_common_mutation_rules_v3.HeaderMutation{
+			Action: &envoy_config_common_mutation_rules_v3.HeaderMutation_Append{
+				Append: &envoy_config_core_v3.HeaderValueOption{
+					Header:       &envoy_config_core_v3.HeaderValue{Key: name, Value: headers[name]},
+					AppendAction: envoy_config_core_v3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+				},
+			},
+		})
+	}
+	return mutations
+}
@@ -0,0 +1,251 @@
+package mirror_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	envoy_config_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_config_common_mutation_rules_v3 "github.com/envoyproxy/go-control-plane/envoy/config/common/mutation_rules/v3"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_config_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_extensions_filters_http_header_mutation_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/header_mutation/v3"
+	envoy_extensions_upstreams_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	"github.com/onsi/gomega"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/mirror"
+	"github.com/solo-io/gloo/projects/gloo/pkg/plugins/pluginutils"
+	"github.com/solo-io/gloo/projects/gloo/pkg/xds"
+	envoycache "github.com/solo-io/solo-kit/pkg/api/v1/control-plane/cache"
+	"github.com/solo-io/solo-kit/pkg/api/v1/control-plane/resource"
+	"github.com/solo-io/solo-kit/pkg/api/v1/control-plane/types"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const httpProtocolOptionsExtension = "envoy.extensions.upstreams.http.v3.HttpProtocolOptions"
+
+var shadowMarker = &structpb.Struct{
+	Fields: map[string]*structpb.Value{
+		"host_rewrite": structpb.NewStringValue("shadow.example.com"),
+		"request_headers_to_set": structpb.NewStructValue(&structpb.Struct{
+			Fields: map[string]*structpb.Value{
+				"x-shadow": structpb.NewStringValue("true"),
+			},
+		}),
+		"request_headers_to_remove": structpb.NewListValue(&structpb.ListValue{
+			Values: []*structpb.Value{structpb.NewStringValue("authorization")},
+		}),
+	},
+}
+
+func shadowedRoute(name string, marker *structpb.Struct) *envoy_config_route_v3.Route {
+	r := &envoy_config_route_v3.Route{
+		Name: name,
+		Action: &envoy_config_route_v3.Route_Route{
+			Route: &envoy_config_route_v3.RouteAction{
+				ClusterSpecifier: &envoy_config_route_v3.RouteAction_Cluster{Cluster: "primary"},
+				RequestMirrorPolicies: []*envoy_config_route_v3.RouteAction_RequestMirrorPolicy{{
+					Cluster: "shadow",
+				}},
+			},
+		},
+	}
+	if marker != nil {
+		r.Metadata = &envoy_config_core_v3.Metadata{
+			FilterMetadata: map[string]*structpb.Struct{mirror.ShadowMetadataNamespace: marker},
+		}
+	}
+	return r
+}
+
+func edsCluster(name string) *envoy_config_cluster_v3.Cluster {
+	return &envoy_config_cluster_v3.Cluster{
+		Name:                 name,
+		ClusterDiscoveryType: &envoy_config_cluster_v3.Cluster_Type{Type: envoy_config_cluster_v3.Cluster_EDS},
+		EdsClusterConfig:     &envoy_config_cluster_v3.Cluster_EdsClusterConfig{},
+	}
+}
+
+func shadowSnapshot(clusters []*envoy_config_cluster_v3.Cluster, routes ...*envoy_config_route_v3.Route) envoycache.Snapshot {
+	var clusterResources []envoycache.Resource
+	for _, cluster := range clusters {
+		clusterResources = append(clusterResources, resource.NewEnvoyResource(cluster))
+	}
+	return xds.NewSnapshotFromResources(
+		envoycache.NewResources("", nil),
+		envoycache.NewResources("clusters", clusterResources),
+		envoycache.NewResources("routes", []envoycache.Resource{
+			resource.NewEnvoyResource(&envoy_config_route_v3.RouteConfiguration{
+				Name: "listener-8080-routes",
+				VirtualHosts: []*envoy_config_route_v3.VirtualHost{{
+					Name:    "http~example_com",
+					Domains: []string{"example.com"},
+					Routes:  routes,
+				}},
+			}),
+		}),
+		envoycache.NewResources("", nil),
+	)
+}
+
+func sanitizedRoutes(snap envoycache.Snapshot) []*envoy_config_route_v3.Route {
+	routeConfig := snap.GetResources(types.RouteTypeV3).Items["listener-8080-routes"].ResourceProto().(*envoy_config_route_v3.RouteConfiguration)
+	return routeConfig.GetVirtualHosts()[0].GetRoutes()
+}
+
+func sanitizedCluster(snap envoycache.Snapshot, name string) *envoy_config_cluster_v3.Cluster {
+	item, ok := snap.GetResources(types.ClusterTypeV3).Items[name]
+	if !ok {
+		return nil
+	}
+	return item.ResourceProto().(*envoy_config_cluster_v3.Cluster)
+}
+
+// clusterHttpOptions returns the http protocol options of the cluster, and the header mutations of its upstream filter
+func clusterHttpOptions(g *gomega.WithT, cluster *envoy_config_cluster_v3.Cluster) (
+	*envoy_extensions_upstreams_http_v3.HttpProtocolOptions,
+	[]*envoy_config_common_mutation_rules_v3.HeaderMutation,
+) {
+	options := &envoy_extensions_upstreams_http_v3.HttpProtocolOptions{}
+	g.Expect(cluster.GetTypedExtensionProtocolOptions()).To(gomega.HaveKey(httpProtocolOptionsExtension))
+	g.Expect(cluster.GetTypedExtensionProtocolOptions()[httpProtocolOptionsExtension].UnmarshalTo(options)).To(gomega.Succeed())
+	g.Expect(options.GetHttpFilters()).NotTo(gomega.BeEmpty())
+	g.Expect(options.GetHttpFilters()[0].GetName()).To(gomega.Equal("envoy.filters.http.header_mutation"))
+	headerMutation := &envoy_extensions_filters_http_header_mutation_v3.HeaderMutation{}
+	g.Expect(options.GetHttpFilters()[0].GetTypedConfig().UnmarshalTo(headerMutation)).To(gomega.Succeed())
+	return options, headerMutation.GetMutations().GetRequestMutations()
+}
+
+func setHeader(name, value string) *envoy_config_common_mutation_rules_v3.HeaderMutation {
+	return &envoy_config_common_mutation_rules_v3.HeaderMutation{
+		Action: &envoy_config_common_mutation_rules_v3.HeaderMutation_Append{
+			Append: &envoy_config_core_v3.HeaderValueOption{
+				Header:       &envoy_config_core_v3.HeaderValue{Key: name, Value: value},
+				AppendAction: envoy_config_core_v3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+			},
+		},
+	}
+}
+
+func TestShadowSanitizerModifiesShadowRequests(t *testing.T) {
+	g := gomega.NewWithT(t)
+	in := shadowSnapshot(
+		[]*envoy_config_cluster_v3.Cluster{edsCluster("primary"), edsCluster("shadow")},
+		shadowedRoute("route", shadowMarker),
+	)
+	out := mirror.NewSanitizer().SanitizeSnapshot(context.Background(), nil, in, nil)
+
+	routes := sanitizedRoutes(out)
+	g.Expect(routes[0].GetMetadata()).To(gomega.BeNil())
+	// the primary requests are still sent to the cluster of the route, unmodified
+	g.Expect(routes[0].GetRoute().GetCluster()).To(gomega.Equal("primary"))
+	g.Expect(proto.Equal(sanitizedCluster(out, "primary"), edsCluster("primary"))).To(gomega.BeTrue())
+	g.Expect(proto.Equal(sanitizedCluster(out, "shadow"), edsCluster("shadow"))).To(gomega.BeTrue())
+
+	policies := routes[0].GetRoute().GetRequestMirrorPolicies()
+	g.Expect(policies).To(gomega.HaveLen(1))
+	g.Expect(strings.HasPrefix(policies[0].GetCluster(), "shadow-shadow-")).To(gomega.BeTrue())
+	shadowCluster := sanitizedCluster(out, policies[0].GetCluster())
+	g.Expect(shadowCluster).NotTo(gomega.BeNil())
+	// the shadow cluster gets the endpoints of the shadow upstream
+	g.Expect(shadowCluster.GetEdsClusterConfig().GetServiceName()).To(gomega.Equal("shadow"))
+
+	options, mutations := clusterHttpOptions(g, shadowCluster)
+	g.Expect(options.GetExplicitHttpConfig().GetHttpProtocolOptions()).NotTo(gomega.BeNil())
+	g.Expect(options.GetHttpFilters()).To(gomega.HaveLen(2))
+	g.Expect(options.GetHttpFilters()[1].GetName()).To(gomega.Equal("envoy.filters.http.upstream_codec"))
+	expectedMutations := []*envoy_config_common_mutation_rules_v3.HeaderMutation{
+		{Action: &envoy_config_common_mutation_rules_v3.HeaderMutation_Remove{Remove: "authorization"}},
+		setHeader(":authority", "shadow.example.com"),
+		setHeader("x-shadow", "true"),
+	}
+	g.Expect(mutations).To(gomega.HaveLen(len(expectedMutations)))
+	for i := range expectedMutations {
+		g.Expect(proto.Equal(mutations[i], expectedMutations[i])).To(gomega.BeTrue())
+	}
+}
+
+func TestShadowSanitizerSharesShadowClusters(t *testing.T) {
+	g := gomega.NewWithT(t)
+	otherMarker := &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"host_rewrite": structpb.NewStringValue("other.example.com"),
+		},
+	}
+	in := shadowSnapshot(
+		[]*envoy_config_cluster_v3.Cluster{edsCluster("primary"), edsCluster("shadow")},
+		shadowedRoute("a", shadowMarker),
+		shadowedRoute("b", proto.Clone(shadowMarker).(*structpb.Struct)),
+		shadowedRoute("c", otherMarker),
+		shadowedRoute("d", nil),
+	)
+	out := mirror.NewSanitizer().SanitizeSnapshot(context.Background(), nil, in, nil)
+
+	routes := sanitizedRoutes(out)
+	shadowClusterOf := func(route *envoy_config_route_v3.Route) string {
+		return route.GetRoute().GetRequestMirrorPolicies()[0].GetCluster()
+	}
+	g.Expect(shadowClusterOf(routes[0])).To(gomega.Equal(shadowClusterOf(routes[1])))
+	g.Expect(shadowClusterOf(routes[2])).NotTo(gomega.Equal(shadowClusterOf(routes[0])))
+	g.Expect(shadowClusterOf(routes[3])).To(gomega.Equal("shadow"))
+	g.Expect(out.GetResources(types.ClusterTypeV3).Items).To(gomega.HaveLen(4))
+
+	_, mutations := clusterHttpOptions(g, sanitizedCluster(out, shadowClusterOf(routes[2])))
+	g.Expect(mutations).To(gomega.HaveLen(1))
+	g.Expect(proto.Equal(mutations[0], setHeader(":authority", "other.example.com"))).To(gomega.BeTrue())
+}
+
+func TestShadowSanitizerKeepsTheProtocolOptionsOfTheUpstream(t *testing.T) {
+	g := gomega.NewWithT(t)
+	http2 := edsCluster("shadow")
+	g.Expect(pluginutils.SetExtensionProtocolOptions(http2, httpProtocolOptionsExtension, &envoy_extensions_upstreams_http_v3.HttpProtocolOptions{
+		UpstreamProtocolOptions: &envoy_extensions_upstreams_http_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+			ExplicitHttpConfig: &envoy_extensions_upstreams_http_v3.HttpProtocolOptions_ExplicitHttpConfig{
+				ProtocolConfig: &envoy_extensions_upstreams_http_v3.HttpProtocolOptions_ExplicitHttpConfig_Http2ProtocolOptions{
+					Http2ProtocolOptions: &envoy_config_core_v3.Http2ProtocolOptions{},
+				},
+			},
+		},
+	})).To(gomega.Succeed())
+	in := shadowSnapshot([]*envoy_config_cluster_v3.Cluster{edsCluster("primary"), http2}, shadowedRoute("route", shadowMarker))
+	out := mirror.NewSanitizer().SanitizeSnapshot(context.Background(), nil, in, nil)
+
+	options, _ := clusterHttpOptions(g, sanitizedCluster(out, sanitizedRoutes(out)[0].GetRoute().GetRequestMirrorPolicies()[0].GetCluster()))
+	g.Expect(options.GetExplicitHttpConfig().GetHttp2ProtocolOptions()).NotTo(gomega.BeNil())
+}
+
+func TestShadowSanitizerMovesTheDeprecatedProtocolOptionsOfTheUpstream(t *testing.T) {
+	g := gomega.NewWithT(t)
+	http2 := edsCluster("shadow")
+	http2.Http2ProtocolOptions = &envoy_config_core_v3.Http2ProtocolOptions{}
+	http2.CommonHttpProtocolOptions = &envoy_config_core_v3.HttpProtocolOptions{}
+	in := shadowSnapshot([]*envoy_config_cluster_v3.Cluster{edsCluster("primary"), http2}, shadowedRoute("route", shadowMarker))
+	out := mirror.NewSanitizer().SanitizeSnapshot(context.Background(), nil, in, nil)
+
+	shadowCluster := sanitizedCluster(out, sanitizedRoutes(out)[0].GetRoute().GetRequestMirrorPolicies()[0].GetCluster())
+	// envoy rejects clusters setting both the deprecated and the typed protocol options
+	g.Expect(shadowCluster.GetHttp2ProtocolOptions()).To(gomega.BeNil())
+	g.Expect(shadowCluster.GetCommonHttpProtocolOptions()).To(gomega.BeNil())
+	options, _ := clusterHttpOptions(g, shadowCluster)
+	g.Expect(options.GetExplicitHttpConfig().GetHttp2ProtocolOptions()).NotTo(gomega.BeNil())
+	g.Expect(options.GetCommonHttpProtocolOptions()).NotTo(gomega.BeNil())
+	g.Expect(sanitizedCluster(out, "shadow").GetHttp2ProtocolOptions()).NotTo(gomega.BeNil())
+}
+
+func TestShadowSanitizerWithoutMarkedRoutes(t *testing.T) {
+	g := gomega.NewWithT(t)
+	in := shadowSnapshot([]*envoy_config_cluster_v3.Cluster{edsCluster("primary"), edsCluster("shadow")}, shadowedRoute("route", nil))
+	g.Expect(mirror.NewSanitizer().SanitizeSnapshot(context.Background(), nil, in, nil)).To(gomega.BeIdenticalTo(in))
+}
+
+func TestShadowSanitizerWithoutShadowCluster(t *testing.T) {
+	g := gomega.NewWithT(t)
+	// e.g. the shadow upstream errored, and its cluster was removed from the snapshot
+	in := shadowSnapshot([]*envoy_config_cluster_v3.Cluster{edsCluster("primary")}, shadowedRoute("route", shadowMarker))
+	out := mirror.NewSanitizer().SanitizeSnapshot(context.Background(), nil, in, nil)
+
+	routes := sanitizedRoutes(out)
+	g.Expect(routes[0].GetMetadata()).To(gomega.BeNil())
+	g.Expect(routes[0].GetRoute().GetRequestMirrorPolicies()[0].GetCluster()).To(gomega.Equal("shadow"))
+	g.Expect(out.GetResources(types.ClusterTypeV3).Items).To(gomega.HaveLen(1))
+}
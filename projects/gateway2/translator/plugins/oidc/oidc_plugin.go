@@ -0,0 +1,253 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	extauthv1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/extauth/v1"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	"k8s.io/apimachinery/pkg/types"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// Annotations set on a RouteOption to authenticate users of the routes it is applied to with the OIDC
+// authorization code flow. The plugin generates an AuthConfig for each RouteOption, which the routes reference.
+const (
+	// OidcIssuerAnnotation is the https URL of the OIDC issuer, and enables OIDC authentication
+	OidcIssuerAnnotation = "gateway2.solo.io/oidc-issuer"
+	// OidcClientIdAnnotation is the client id registered with the issuer
+	OidcClientIdAnnotation = "gateway2.solo.io/oidc-client-id"
+	// OidcClientSecretAnnotation is the [<namespace>/]<name> of the Secret holding the client secret. Secrets in
+	// other namespaces than the RouteOption must be allowed by a ReferenceGrant.
+	OidcClientSecretAnnotation = "gateway2.solo.io/oidc-client-secret"
+	// OidcAppUrlAnnotation is the public http(s) URL of the application, users are redirected back to it after login
+	OidcAppUrlAnnotation = "gateway2.solo.io/oidc-app-url"
+	// OidcCallbackPathAnnotation is the path of the application the issuer redirects users to after login
+	OidcCallbackPathAnnotation = "gateway2.solo.io/oidc-callback-path"
+	// OidcLogoutPathAnnotation is the path of the application logging users out
+	OidcLogoutPathAnnotation = "gateway2.solo.io/oidc-logout-path"
+	// OidcScopesAnnotation is a comma-separated list of scopes requested in addition to "openid"
+	OidcScopesAnnotation = "gateway2.solo.io/oidc-scopes"
+)
+
+var (
+	MissingAnnotationErr = func(annotation string) error {
+		return errors.Errorf("annotation %s requires annotation %s", OidcIssuerAnnotation, annotation)
+	}
+	InvalidUrlErr = func(annotation, value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be an absolute http or https URL", value, annotation)
+	}
+	InvalidPathErr = func(annotation, value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a path starting with /", value, annotation)
+	}
+	MissingSecretErr = func(value string, err error) error {
+		return errors.Wrapf(err, "cannot resolve secret '%s' of annotation %s", value, OidcClientSecretAnnotation)
+	}
+	ConflictingExtAuthErr = errors.Errorf("annotation %s cannot be combined with the extauth option of the RouteOption", OidcIssuerAnnotation)
+)
+
+var (
+	_ plugins.RoutePlugin           = &plugin{}
+	_ plugins.PostTranslationPlugin = &plugin{}
+	_ plugins.RouteOptionValidator  = &plugin{}
+	_ plugins.DependentPlugin       = &plugin{}
+)
+
+// Plugins are created for each translation, so the AuthConfigs generated while translating routes
+// are those referenced by the translated Proxies.
+type plugin struct {
+	queries query.GatewayQueries
+	// AuthConfigs generated for the routes translated so far, keyed by the RouteOption they are generated from
+	authConfigs map[types.NamespacedName]*extauthv1.AuthConfig
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries:     queries,
+		authConfigs: map[types.NamespacedName]*extauthv1.AuthConfig{},
+	}
+}
+
+// DependsOn the routeoptions plugin, which would otherwise replace the extauth config of the route
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+	if _, ok := routeOption.GetAnnotations()[OidcIssuerAnnotation]; !ok {
+		return nil
+	}
+
+	// the route requires authentication, so if the policy cannot be applied the route must not be served
+	if outputRoute.GetOptions().GetExtauth() != nil {
+		failClosed(outputRoute)
+		return ConflictingExtAuthErr
+	}
+	key := types.NamespacedName{Namespace: routeOption.GetNamespace(), Name: routeOption.GetName()}
+	authConfig, ok := p.authConfigs[key]
+	if !ok {
+		config, err := p.getOidcConfig(ctx, routeOption.GetAnnotations(), p.queries.ObjToFrom(routeOption))
+		if err != nil {
+			failClosed(outputRoute)
+			return err
+		}
+		authConfig = &extauthv1.AuthConfig{
+			Metadata: &core.Metadata{
+				Name:      fmt.Sprintf("%s-oidc", key.Name),
+				Namespace: key.Namespace,
+			},
+			Configs: []*extauthv1.AuthConfig_Config{{
+				AuthConfig: &extauthv1.AuthConfig_Config_Oauth2{
+					Oauth2: &extauthv1.OAuth2{
+						OauthType: &extauthv1.OAuth2_OidcAuthorizationCode{
+							OidcAuthorizationCode: config,
+						},
+					},
+				},
+			}},
+		}
+		p.authConfigs[key] = authConfig
+	}
+
+	if outputRoute.GetOptions() == nil {
+		outputRoute.Options = &v1.RouteOptions{}
+	}
+	outputRoute.GetOptions().Extauth = &extauthv1.ExtAuthExtension{
+		Spec: &extauthv1.ExtAuthExtension_ConfigRef{
+			ConfigRef: authConfig.GetMetadata().Ref(),
+		},
+	}
+	return nil
+}
+
+// ValidateRouteOption does not resolve the client secret, which may be created after the RouteOption
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	annotations := routeOption.GetAnnotations()
+	if _, ok := annotations[OidcIssuerAnnotation]; !ok {
+		return nil
+	}
+	if routeOption.Spec.GetOptions().GetExtauth() != nil {
+		return ConflictingExtAuthErr
+	}
+	_, _, err := parseOidcAnnotations(annotations)
+	return err
+}
+
+func (p *plugin) getOidcConfig(
+	ctx context.Context,
+	annotations map[string]string,
+	from query.From,
+) (*extauthv1.OidcAuthorizationCode, error) {
+	config, secretRef, err := parseOidcAnnotations(annotations)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := p.queries.GetSecretForRef(ctx, from, *secretRef)
+	if err != nil {
+		return nil, MissingSecretErr(annotations[OidcClientSecretAnnotation], err)
+	}
+	config.ClientSecretRef = &core.ResourceRef{
+		Name:      secret.GetName(),
+		Namespace: secret.GetNamespace(),
+	}
+	return config, nil
+}
+
+// parseOidcAnnotations returns the config without its client secret, and the reference to the client secret
+func parseOidcAnnotations(annotations map[string]string) (*extauthv1.OidcAuthorizationCode, *gwv1.SecretObjectReference, error) {
+	issuerUrl := annotations[OidcIssuerAnnotation]
+	if !isHttpUrl(issuerUrl) {
+		return nil, nil, InvalidUrlErr(OidcIssuerAnnotation, issuerUrl)
+	}
+	clientId, ok := annotations[OidcClientIdAnnotation]
+	if !ok || clientId == "" {
+		return nil, nil, MissingAnnotationErr(OidcClientIdAnnotation)
+	}
+	appUrl, ok := annotations[OidcAppUrlAnnotation]
+	if !ok {
+		return nil, nil, MissingAnnotationErr(OidcAppUrlAnnotation)
+	}
+	if !isHttpUrl(appUrl) {
+		return nil, nil, InvalidUrlErr(OidcAppUrlAnnotation, appUrl)
+	}
+	for _, annotation := range []string{OidcCallbackPathAnnotation, OidcLogoutPathAnnotation} {
+		if path, ok := annotations[annotation]; ok && !strings.HasPrefix(path, "/") {
+			return nil, nil, InvalidPathErr(annotation, path)
+		}
+	}
+
+	secretRef, err := utils.GetSecretRefAnnotation(annotations, OidcClientSecretAnnotation)
+	if err != nil {
+		return nil, nil, err
+	}
+	if secretRef == nil {
+		return nil, nil, MissingAnnotationErr(OidcClientSecretAnnotation)
+	}
+
+	var scopes []string
+	for _, scope := range strings.Split(annotations[OidcScopesAnnotation], ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &extauthv1.OidcAuthorizationCode{
+		ClientId:     clientId,
+		IssuerUrl:    issuerUrl,
+		AppUrl:       appUrl,
+		CallbackPath: annotations[OidcCallbackPathAnnotation],
+		LogoutPath:   annotations[OidcLogoutPathAnnotation],
+		Scopes:       scopes,
+	}, secretRef, nil
+}
+
+func (p *plugin) ApplyPostTranslationPlugin(
+	ctx context.Context,
+	postTranslationContext *plugins.PostTranslationContext,
+) error {
+	keys := make([]types.NamespacedName, 0, len(p.authConfigs))
+	for key := range p.authConfigs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	for _, key := range keys {
+		postTranslationContext.AuthConfigs = append(postTranslationContext.AuthConfigs, p.authConfigs[key])
+	}
+	return nil
+}
+
+func isHttpUrl(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func failClosed(route *v1.Route) {
+	route.Action = &v1.Route_DirectResponseAction{
+		DirectResponseAction: &v1.DirectResponseAction{
+			Status: http.StatusInternalServerError,
+		},
+	}
+}
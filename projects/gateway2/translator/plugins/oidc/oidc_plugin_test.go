@@ -0,0 +1,234 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	extauthv1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/extauth/v1"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+var _ = Describe("OidcPlugin", func() {
+	var (
+		annotations map[string]string
+		objs        []client.Object
+		p           *plugin
+	)
+
+	BeforeEach(func() {
+		annotations = map[string]string{
+			OidcIssuerAnnotation:       "https://issuer.example.com",
+			OidcClientIdAnnotation:     "client",
+			OidcClientSecretAnnotation: "client-secret",
+			OidcAppUrlAnnotation:       "https://app.example.com",
+			OidcCallbackPathAnnotation: "/callback",
+			OidcLogoutPathAnnotation:   "/logout",
+			OidcScopesAnnotation:       "email, profile",
+		}
+		objs = []client.Object{secret("default", "client-secret")}
+		p = nil
+	})
+
+	translateRoute := func(options *v1.RouteOptions) (*v1.Route, error) {
+		if p == nil {
+			p = NewPlugin(testutils.BuildGatewayQueries(append(objs, testutils.RouteOption(annotations))))
+		}
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: &gwv1.HTTPRouteRule{
+				Filters: []gwv1.HTTPRouteFilter{{
+					Type: gwv1.HTTPRouteFilterExtensionRef,
+					ExtensionRef: &gwv1.LocalObjectReference{
+						Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Name:  "policy",
+					},
+				}},
+			},
+		}
+		outputRoute := &v1.Route{
+			Action: &v1.Route_RouteAction{
+				RouteAction: &v1.RouteAction{},
+			},
+			Options: options,
+		}
+		err := p.ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+		return outputRoute, err
+	}
+
+	translateAuthConfigs := func() extauthv1.AuthConfigList {
+		postTranslationContext := &plugins.PostTranslationContext{}
+		Expect(p.ApplyPostTranslationPlugin(context.Background(), postTranslationContext)).To(Succeed())
+		return postTranslationContext.AuthConfigs
+	}
+
+	expectFailClosed := func(route *v1.Route) {
+		Expect(route.GetDirectResponseAction().GetStatus()).To(Equal(uint32(http.StatusInternalServerError)))
+		Expect(translateAuthConfigs()).To(BeEmpty())
+	}
+
+	It("references a generated OIDC AuthConfig", func() {
+		route, err := translateRoute(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetRouteAction()).NotTo(BeNil())
+		Expect(proto.Equal(route.GetOptions().GetExtauth(), &extauthv1.ExtAuthExtension{
+			Spec: &extauthv1.ExtAuthExtension_ConfigRef{
+				ConfigRef: &core.ResourceRef{Name: "policy-oidc", Namespace: "default"},
+			},
+		})).To(BeTrue())
+
+		authConfigs := translateAuthConfigs()
+		Expect(authConfigs).To(HaveLen(1))
+		Expect(proto.Equal(authConfigs[0], &extauthv1.AuthConfig{
+			Metadata: &core.Metadata{Name: "policy-oidc", Namespace: "default"},
+			Configs: []*extauthv1.AuthConfig_Config{{
+				AuthConfig: &extauthv1.AuthConfig_Config_Oauth2{
+					Oauth2: &extauthv1.OAuth2{
+						OauthType: &extauthv1.OAuth2_OidcAuthorizationCode{
+							OidcAuthorizationCode: &extauthv1.OidcAuthorizationCode{
+								ClientId:        "client",
+								ClientSecretRef: &core.ResourceRef{Name: "client-secret", Namespace: "default"},
+								IssuerUrl:       "https://issuer.example.com",
+								AppUrl:          "https://app.example.com",
+								CallbackPath:    "/callback",
+								LogoutPath:      "/logout",
+								Scopes:          []string{"email", "profile"},
+							},
+						},
+					},
+				},
+			}},
+		})).To(BeTrue())
+	})
+
+	It("generates a single AuthConfig for routes sharing the RouteOption", func() {
+		_, err := translateRoute(nil)
+		Expect(err).NotTo(HaveOccurred())
+		route, err := translateRoute(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetExtauth().GetConfigRef().GetName()).To(Equal("policy-oidc"))
+		Expect(translateAuthConfigs()).To(HaveLen(1))
+	})
+
+	It("resolves a secret in another namespace allowed by a ReferenceGrant", func() {
+		annotations[OidcClientSecretAnnotation] = "auth/client-secret"
+		objs = []client.Object{
+			secret("auth", "client-secret"),
+			&gwv1b1.ReferenceGrant{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "grant",
+					Namespace: "auth",
+				},
+				Spec: gwv1b1.ReferenceGrantSpec{
+					From: []gwv1b1.ReferenceGrantFrom{{
+						Group:     gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:      gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Namespace: "default",
+					}},
+					To: []gwv1b1.ReferenceGrantTo{{
+						Group: "",
+						Kind:  "Secret",
+					}},
+				},
+			},
+		}
+		_, err := translateRoute(nil)
+		Expect(err).NotTo(HaveOccurred())
+		authConfigs := translateAuthConfigs()
+		Expect(authConfigs).To(HaveLen(1))
+		Expect(authConfigs[0].GetConfigs()[0].GetOauth2().GetOidcAuthorizationCode().GetClientSecretRef()).To(Equal(
+			&core.ResourceRef{Name: "client-secret", Namespace: "auth"}))
+	})
+
+	It("fails closed when the secret does not exist", func() {
+		objs = nil
+		route, err := translateRoute(nil)
+		Expect(err).To(MatchError(ContainSubstring("cannot resolve secret 'client-secret'")))
+		expectFailClosed(route)
+	})
+
+	It("fails closed when a secret in another namespace is not allowed by a ReferenceGrant", func() {
+		annotations[OidcClientSecretAnnotation] = "auth/client-secret"
+		objs = []client.Object{secret("auth", "client-secret")}
+		route, err := translateRoute(nil)
+		Expect(err).To(MatchError(query.ErrMissingReferenceGrant))
+		expectFailClosed(route)
+	})
+
+	It("fails closed when the RouteOption already sets extauth", func() {
+		route, err := translateRoute(&v1.RouteOptions{
+			Extauth: &extauthv1.ExtAuthExtension{
+				Spec: &extauthv1.ExtAuthExtension_Disable{Disable: true},
+			},
+		})
+		Expect(err).To(MatchError(ConflictingExtAuthErr))
+		expectFailClosed(route)
+	})
+
+	It("does nothing without the issuer annotation", func() {
+		delete(annotations, OidcIssuerAnnotation)
+		route, err := translateRoute(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetRouteAction()).NotTo(BeNil())
+		Expect(route.GetOptions().GetExtauth()).To(BeNil())
+		Expect(translateAuthConfigs()).To(BeEmpty())
+	})
+
+	DescribeTable("rejects invalid OIDC policies",
+		func(annotation, value, expectedErr string) {
+			if value == "" {
+				delete(annotations, annotation)
+			} else {
+				annotations[annotation] = value
+			}
+			route, err := translateRoute(nil)
+			Expect(err).To(MatchError(expectedErr))
+			expectFailClosed(route)
+		},
+		Entry("non-URL issuer", OidcIssuerAnnotation, "issuer.example.com",
+			InvalidUrlErr(OidcIssuerAnnotation, "issuer.example.com").Error()),
+		Entry("missing client id", OidcClientIdAnnotation, "",
+			MissingAnnotationErr(OidcClientIdAnnotation).Error()),
+		Entry("missing client secret", OidcClientSecretAnnotation, "",
+			MissingAnnotationErr(OidcClientSecretAnnotation).Error()),
+		Entry("invalid client secret", OidcClientSecretAnnotation, "auth/",
+			utils.InvalidSecretRefErr(OidcClientSecretAnnotation, "auth/").Error()),
+		Entry("missing app URL", OidcAppUrlAnnotation, "",
+			MissingAnnotationErr(OidcAppUrlAnnotation).Error()),
+		Entry("non-http app URL", OidcAppUrlAnnotation, "ftp://app.example.com",
+			InvalidUrlErr(OidcAppUrlAnnotation, "ftp://app.example.com").Error()),
+		Entry("relative callback path", OidcCallbackPathAnnotation, "callback",
+			InvalidPathErr(OidcCallbackPathAnnotation, "callback").Error()),
+	)
+})
+
+func secret(namespace, name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			"client-secret": []byte("secret"),
+		},
+	}
+}
@@ -0,0 +1,13 @@
+package oidc
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestOidcPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Oidc Plugin Suite")
+}
@@ -0,0 +1,126 @@
+package outlierdetection
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/api/v2/cluster"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Annotations set on a Service to eject misbehaving endpoints of the Upstreams discovered from it.
+const (
+	// OutlierDetectionAnnotation enables outlier detection, and selects the errors endpoints are ejected for:
+	// either "consecutive-5xx" for any 5xx response, or "consecutive-gateway-errors" for 502, 503 and 504 responses only.
+	OutlierDetectionAnnotation = "gateway2.solo.io/outlier-detection"
+	// OutlierDetectionConsecutiveErrorsAnnotation is the number of consecutive errors ejecting an endpoint, defaulting to 5
+	OutlierDetectionConsecutiveErrorsAnnotation = "gateway2.solo.io/outlier-detection-consecutive-errors"
+	// OutlierDetectionIntervalAnnotation is the time between ejection sweeps, defaulting to 10s
+	OutlierDetectionIntervalAnnotation = "gateway2.solo.io/outlier-detection-interval"
+	// OutlierDetectionBaseEjectionTimeAnnotation is how long an endpoint is ejected for, multiplied by the number
+	// of times it has been ejected, defaulting to 30s
+	OutlierDetectionBaseEjectionTimeAnnotation = "gateway2.solo.io/outlier-detection-base-ejection-time"
+	// OutlierDetectionMaxEjectionPercentAnnotation caps the percentage of endpoints ejected at once, defaulting to 10
+	OutlierDetectionMaxEjectionPercentAnnotation = "gateway2.solo.io/outlier-detection-max-ejection-percent"
+)
+
+const (
+	Consecutive5xxMode           = "consecutive-5xx"
+	ConsecutiveGatewayErrorsMode = "consecutive-gateway-errors"
+)
+
+var (
+	defaultConsecutiveErrors  = uint32(5)
+	defaultInterval           = 10 * time.Second
+	defaultBaseEjectionTime   = 30 * time.Second
+	defaultMaxEjectionPercent = uint32(10)
+
+	UnknownModeErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be one of %s or %s", value, OutlierDetectionAnnotation, Consecutive5xxMode, ConsecutiveGatewayErrorsMode)
+	}
+	InvalidConsecutiveErrorsErr  = errors.Errorf("annotation %s must be at least 1", OutlierDetectionConsecutiveErrorsAnnotation)
+	InvalidMaxEjectionPercentErr = errors.Errorf("annotation %s must be between 0 and 100", OutlierDetectionMaxEjectionPercentAnnotation)
+)
+
+var _ plugins.BackendPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyBackendPlugin(
+	ctx context.Context,
+	backendCtx *plugins.BackendContext,
+	outputUpstream *v1.Upstream,
+) error {
+	annotations := backendCtx.Service.GetAnnotations()
+	mode, ok := annotations[OutlierDetectionAnnotation]
+	if !ok {
+		return nil
+	}
+	if mode != Consecutive5xxMode && mode != ConsecutiveGatewayErrorsMode {
+		return UnknownModeErr(mode)
+	}
+
+	consecutiveErrors, err := utils.GetUint32Annotation(annotations, OutlierDetectionConsecutiveErrorsAnnotation)
+	if err != nil {
+		return err
+	}
+	if consecutiveErrors == nil {
+		consecutiveErrors = &wrappers.UInt32Value{Value: defaultConsecutiveErrors}
+	} else if consecutiveErrors.GetValue() == 0 {
+		return InvalidConsecutiveErrorsErr
+	}
+
+	interval, err := utils.GetDurationAnnotation(annotations, OutlierDetectionIntervalAnnotation)
+	if err != nil {
+		return err
+	}
+	if interval == nil {
+		interval = durationpb.New(defaultInterval)
+	}
+
+	baseEjectionTime, err := utils.GetDurationAnnotation(annotations, OutlierDetectionBaseEjectionTimeAnnotation)
+	if err != nil {
+		return err
+	}
+	if baseEjectionTime == nil {
+		baseEjectionTime = durationpb.New(defaultBaseEjectionTime)
+	}
+
+	maxEjectionPercent, err := utils.GetUint32Annotation(annotations, OutlierDetectionMaxEjectionPercentAnnotation)
+	if err != nil {
+		return err
+	}
+	if maxEjectionPercent == nil {
+		maxEjectionPercent = &wrappers.UInt32Value{Value: defaultMaxEjectionPercent}
+	} else if maxEjectionPercent.GetValue() > 100 {
+		return InvalidMaxEjectionPercentErr
+	}
+
+	outlierDetection := &cluster.OutlierDetection{
+		Interval:           interval,
+		BaseEjectionTime:   baseEjectionTime,
+		MaxEjectionPercent: maxEjectionPercent,
+	}
+	// envoy tracks both kinds of errors, only enforce ejections for the selected one
+	switch mode {
+	case Consecutive5xxMode:
+		outlierDetection.Consecutive_5Xx = consecutiveErrors
+		outlierDetection.EnforcingConsecutive_5Xx = &wrappers.UInt32Value{Value: 100}
+		outlierDetection.EnforcingConsecutiveGatewayFailure = &wrappers.UInt32Value{Value: 0}
+	case ConsecutiveGatewayErrorsMode:
+		outlierDetection.ConsecutiveGatewayFailure = consecutiveErrors
+		outlierDetection.EnforcingConsecutiveGatewayFailure = &wrappers.UInt32Value{Value: 100}
+		outlierDetection.EnforcingConsecutive_5Xx = &wrappers.UInt32Value{Value: 0}
+	}
+	outputUpstream.OutlierDetection = outlierDetection
+	return nil
+}
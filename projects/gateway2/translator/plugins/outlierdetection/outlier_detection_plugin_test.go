@@ -0,0 +1,103 @@
+package outlierdetection
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/api/v2/cluster"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("OutlierDetectionPlugin", func() {
+	apply := func(annotations map[string]string) (*v1.Upstream, error) {
+		backendCtx := &plugins.BackendContext{
+			Service: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "svc",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+			},
+		}
+		us := &v1.Upstream{}
+		err := NewPlugin().ApplyBackendPlugin(context.Background(), backendCtx, us)
+		return us, err
+	}
+
+	It("ejects endpoints on consecutive 5xx responses", func() {
+		us, err := apply(map[string]string{
+			OutlierDetectionAnnotation:                   Consecutive5xxMode,
+			OutlierDetectionConsecutiveErrorsAnnotation:  "3",
+			OutlierDetectionIntervalAnnotation:           "5s",
+			OutlierDetectionBaseEjectionTimeAnnotation:   "1m",
+			OutlierDetectionMaxEjectionPercentAnnotation: "50",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proto.Equal(us.GetOutlierDetection(), &cluster.OutlierDetection{
+			Consecutive_5Xx:                    &wrappers.UInt32Value{Value: 3},
+			EnforcingConsecutive_5Xx:           &wrappers.UInt32Value{Value: 100},
+			EnforcingConsecutiveGatewayFailure: &wrappers.UInt32Value{Value: 0},
+			Interval:                           durationpb.New(5 * time.Second),
+			BaseEjectionTime:                   durationpb.New(time.Minute),
+			MaxEjectionPercent:                 &wrappers.UInt32Value{Value: 50},
+		})).To(BeTrue())
+	})
+
+	It("ejects endpoints on consecutive gateway errors with defaults", func() {
+		us, err := apply(map[string]string{
+			OutlierDetectionAnnotation: ConsecutiveGatewayErrorsMode,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proto.Equal(us.GetOutlierDetection(), &cluster.OutlierDetection{
+			ConsecutiveGatewayFailure:          &wrappers.UInt32Value{Value: 5},
+			EnforcingConsecutiveGatewayFailure: &wrappers.UInt32Value{Value: 100},
+			EnforcingConsecutive_5Xx:           &wrappers.UInt32Value{Value: 0},
+			Interval:                           durationpb.New(10 * time.Second),
+			BaseEjectionTime:                   durationpb.New(30 * time.Second),
+			MaxEjectionPercent:                 &wrappers.UInt32Value{Value: 10},
+		})).To(BeTrue())
+	})
+
+	It("does nothing without the annotation", func() {
+		us, err := apply(map[string]string{
+			OutlierDetectionConsecutiveErrorsAnnotation: "3",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(us.GetOutlierDetection()).To(BeNil())
+	})
+
+	DescribeTable("rejects invalid outlier detection",
+		func(annotations map[string]string, expectedErr string) {
+			us, err := apply(annotations)
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			Expect(us.GetOutlierDetection()).To(BeNil())
+		},
+		Entry("unknown mode",
+			map[string]string{OutlierDetectionAnnotation: "success-rate"},
+			UnknownModeErr("success-rate").Error()),
+		Entry("zero consecutive errors",
+			map[string]string{OutlierDetectionAnnotation: Consecutive5xxMode, OutlierDetectionConsecutiveErrorsAnnotation: "0"},
+			InvalidConsecutiveErrorsErr.Error()),
+		Entry("negative consecutive errors",
+			map[string]string{OutlierDetectionAnnotation: Consecutive5xxMode, OutlierDetectionConsecutiveErrorsAnnotation: "-1"},
+			OutlierDetectionConsecutiveErrorsAnnotation),
+		Entry("unparseable interval",
+			map[string]string{OutlierDetectionAnnotation: Consecutive5xxMode, OutlierDetectionIntervalAnnotation: "10"},
+			OutlierDetectionIntervalAnnotation),
+		Entry("zero base ejection time",
+			map[string]string{OutlierDetectionAnnotation: Consecutive5xxMode, OutlierDetectionBaseEjectionTimeAnnotation: "0s"},
+			OutlierDetectionBaseEjectionTimeAnnotation),
+		Entry("max ejection percent above 100",
+			map[string]string{OutlierDetectionAnnotation: ConsecutiveGatewayErrorsMode, OutlierDetectionMaxEjectionPercentAnnotation: "101"},
+			InvalidMaxEjectionPercentErr.Error()),
+	)
+})
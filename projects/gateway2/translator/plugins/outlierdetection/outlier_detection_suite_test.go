@@ -0,0 +1,13 @@
+package outlierdetection
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestOutlierDetectionPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "OutlierDetection Plugin Suite")
+}
@@ -0,0 +1,87 @@
+package pathmatch
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/core/matchers"
+)
+
+const (
+	// PathIgnoreCaseAnnotation is set to "true" on a RouteOption to match the paths of the routes it is applied to
+	// regardless of case, whether they are exact, prefix or regex matches. Headers and query parameters are still
+	// matched case-sensitively.
+	PathIgnoreCaseAnnotation = "gateway2.solo.io/path-ignore-case"
+)
+
+var InvalidIgnoreCaseErr = func(value string) error {
+	return errors.Errorf("invalid value '%s' for annotation %s: must be a boolean", value, PathIgnoreCaseAnnotation)
+}
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+)
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+	ignoreCase, err := getIgnoreCase(routeOption.GetAnnotations())
+	if err != nil || !ignoreCase {
+		return err
+	}
+
+	for _, matcher := range outputRoute.GetMatchers() {
+		switch path := matcher.GetPathSpecifier().(type) {
+		case *matchers.Matcher_Regex:
+			// envoy ignores case sensitivity for regex matches, so use a case-insensitive regex instead
+			path.Regex = "(?i)" + path.Regex
+		default:
+			matcher.CaseSensitive = &wrappers.BoolValue{Value: false}
+		}
+	}
+	return nil
+}
+
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	_, err := getIgnoreCase(routeOption.GetAnnotations())
+	return err
+}
+
+func getIgnoreCase(annotations map[string]string) (bool, error) {
+	value, ok := annotations[PathIgnoreCaseAnnotation]
+	if !ok {
+		return false, nil
+	}
+	ignoreCase, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, InvalidIgnoreCaseErr(value)
+	}
+	return ignoreCase, nil
+}
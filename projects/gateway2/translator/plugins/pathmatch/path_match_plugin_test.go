@@ -0,0 +1,96 @@
+package pathmatch
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/core/matchers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("PathMatchPlugin", func() {
+	apply := func(annotations map[string]string, matcher *matchers.Matcher) (*v1.Route, error) {
+		queries := testutils.BuildGatewayQueries([]client.Object{
+			&solokubev1.RouteOption{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "policy",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+				Spec: sologatewayv1.RouteOption{},
+			},
+		})
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: &gwv1.HTTPRouteRule{
+				Filters: []gwv1.HTTPRouteFilter{{
+					Type: gwv1.HTTPRouteFilterExtensionRef,
+					ExtensionRef: &gwv1.LocalObjectReference{
+						Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Name:  "policy",
+					},
+				}},
+			},
+		}
+		outputRoute := &v1.Route{
+			Matchers: []*matchers.Matcher{matcher},
+		}
+		err := NewPlugin(queries).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+		return outputRoute, err
+	}
+
+	DescribeTable("matches paths regardless of case",
+		func(matcher, expected *matchers.Matcher) {
+			route, err := apply(map[string]string{PathIgnoreCaseAnnotation: "true"}, matcher)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(route.GetMatchers()[0], expected)).To(BeTrue())
+		},
+		Entry("prefix",
+			&matchers.Matcher{PathSpecifier: &matchers.Matcher_Prefix{Prefix: "/API"}},
+			&matchers.Matcher{
+				PathSpecifier: &matchers.Matcher_Prefix{Prefix: "/API"},
+				CaseSensitive: &wrappers.BoolValue{Value: false},
+			}),
+		Entry("exact",
+			&matchers.Matcher{PathSpecifier: &matchers.Matcher_Exact{Exact: "/login"}},
+			&matchers.Matcher{
+				PathSpecifier: &matchers.Matcher_Exact{Exact: "/login"},
+				CaseSensitive: &wrappers.BoolValue{Value: false},
+			}),
+		Entry("regex",
+			&matchers.Matcher{PathSpecifier: &matchers.Matcher_Regex{Regex: "/users/[0-9]+"}},
+			&matchers.Matcher{PathSpecifier: &matchers.Matcher_Regex{Regex: "(?i)/users/[0-9]+"}}),
+	)
+
+	DescribeTable("keeps case-sensitive matching",
+		func(annotations map[string]string) {
+			route, err := apply(annotations, &matchers.Matcher{PathSpecifier: &matchers.Matcher_Prefix{Prefix: "/API"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(route.GetMatchers()[0].GetCaseSensitive()).To(BeNil())
+		},
+		Entry("without the annotation", nil),
+		Entry("when disabled", map[string]string{PathIgnoreCaseAnnotation: "false"}),
+	)
+
+	It("rejects an invalid value", func() {
+		route, err := apply(map[string]string{PathIgnoreCaseAnnotation: "yes"}, &matchers.Matcher{})
+		Expect(err).To(MatchError(InvalidIgnoreCaseErr("yes").Error()))
+		Expect(route.GetMatchers()[0].GetCaseSensitive()).To(BeNil())
+	})
+})
@@ -0,0 +1,13 @@
+package pathmatch
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPathMatchPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Path Match Plugin Suite")
+}
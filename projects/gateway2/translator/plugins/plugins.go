@@ -3,15 +3,37 @@ package plugins
 import (
 	"context"
 
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
 	"github.com/solo-io/gloo/projects/gateway2/reports"
 	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	extauthv1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/extauth/v1"
 
+	corev1 "k8s.io/api/core/v1"
 	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
 // Plugin is an empty type for base plugins, currently no base methods.
 type Plugin interface{}
 
+// NamedPlugin is implemented by plugins naming themselves in the metrics of a PluginRegistry.
+// Plugins not implementing it are named after their package.
+type NamedPlugin interface {
+	Name() string
+}
+
+// ConditionalPlugin is implemented by plugins requiring capabilities of the cluster, e.g. a CRD or a server.
+type ConditionalPlugin interface {
+	// Available returns whether the capabilities required by the plugin are present. Unavailable plugins are not
+	// registered.
+	Available(ctx context.Context) bool
+}
+
+// DependentPlugin is implemented by plugins that must be applied after other plugins of the same phase.
+type DependentPlugin interface {
+	// DependsOn returns the names of the plugins to apply first, ignoring the plugins not applied in the phase
+	DependsOn() []string
+}
+
 type RouteContext struct {
 	// top-level gw Listener
 	Listener *gwv1.Listener
@@ -34,9 +56,58 @@ type RoutePlugin interface {
 	) error
 }
 
+type RouteOptionValidator interface {
+	// ValidateRouteOption returns the errors ApplyRoutePlugin would return for any route the RouteOption is applied to,
+	// so that admission and translation reject the same invalid RouteOptions. Errors depending on the routes are
+	// only returned at translation.
+	ValidateRouteOption(
+		ctx context.Context,
+		routeOption *solokubev1.RouteOption,
+	) error
+}
+
+type ListenerContext struct {
+	// top-level Gateway
+	Gateway *gwv1.Gateway
+	// Gateway Listeners that were merged into the Gloo Listener being processed
+	GatewayListeners []gwv1.Listener
+	// Gloo Listener each Gateway Listener was translated into, keyed by Gateway Listener name
+	TranslatedListeners map[string]*v1.Listener
+	// names of the Gateway Listeners whose routes are served by each virtual host of the Gloo Listener being processed,
+	// keyed by virtual host name. HTTP Listeners sharing a port share their virtual hosts.
+	VirtualHostListeners map[string][]string
+	// Reporter for the top-level Gateway
+	Reporter reports.GatewayReporter
+}
+
+type ListenerPlugin interface {
+	// ApplyListenerPlugin is called for each Gloo Listener once all Listeners of the Gateway have been translated
+	ApplyListenerPlugin(
+		ctx context.Context,
+		listenerCtx *ListenerContext,
+		outputListener *v1.Listener,
+	) error
+}
+
+type BackendContext struct {
+	// Service the Upstream being processed was discovered from
+	Service *corev1.Service
+}
+
+type BackendPlugin interface {
+	// ApplyBackendPlugin is called for each Upstream discovered from a Service, on a copy of the discovered Upstream
+	ApplyBackendPlugin(
+		ctx context.Context,
+		backendCtx *BackendContext,
+		outputUpstream *v1.Upstream,
+	) error
+}
+
 type PostTranslationContext struct {
 	// TranslatedGateways is the list of Gateways that were generated in a single translation run
 	TranslatedGateways []TranslatedGateway
+	// AuthConfigs referenced by the translated Proxies, appended to by plugins generating them
+	AuthConfigs extauthv1.AuthConfigList
 }
 
 type TranslatedGateway struct {
@@ -0,0 +1,76 @@
+package proxyprotocol
+
+import (
+	"context"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/proxy_protocol"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// ProxyProtocolListenersAnnotation is set on a Gateway to a comma-separated list of the names of its Listeners
+// receiving connections prefixed with a PROXY protocol header, e.g. from an L4 load balancer, to recover the address
+// of the clients. The proxy protocol listener filter detects both v1 and v2 headers, and rejects connections without
+// a header.
+//
+// The listener filter applies to the whole port, so it also applies to the other Listeners sharing the port.
+const ProxyProtocolListenersAnnotation = "gateway2.solo.io/proxy-protocol-listeners"
+
+var UnknownListenerErr = func(name string) error {
+	return errors.Errorf("listener '%s' of annotation %s is not a listener of the Gateway", name, ProxyProtocolListenersAnnotation)
+}
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	value, ok := listenerCtx.Gateway.GetAnnotations()[ProxyProtocolListenersAnnotation]
+	if !ok {
+		return nil
+	}
+	names, err := getProxyProtocolListeners(listenerCtx.Gateway, value)
+	if err != nil {
+		return err
+	}
+
+	for _, gwListener := range listenerCtx.GatewayListeners {
+		if !names[string(gwListener.Name)] {
+			continue
+		}
+		if outputListener.GetOptions() == nil {
+			outputListener.Options = &v1.ListenerOptions{}
+		}
+		outputListener.GetOptions().ProxyProtocol = &proxy_protocol.ProxyProtocol{}
+		return nil
+	}
+	return nil
+}
+
+// getProxyProtocolListeners returns the names of the annotation, which must be Listeners of the Gateway
+func getProxyProtocolListeners(gateway *gwv1.Gateway, value string) (map[string]bool, error) {
+	listeners := map[string]bool{}
+	for _, listener := range gateway.Spec.Listeners {
+		listeners[string(listener.Name)] = true
+	}
+	names := map[string]bool{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if !listeners[name] {
+			return nil, UnknownListenerErr(name)
+		}
+		names[name] = true
+	}
+	return names, nil
+}
@@ -0,0 +1,93 @@
+package proxyprotocol
+
+import (
+	"context"
+
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	glooproxyprotocol "github.com/solo-io/gloo/projects/gloo/pkg/plugins/proxyprotocol"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("ProxyProtocolPlugin", func() {
+	var outputListener *v1.Listener
+
+	gwListeners := []gwv1.Listener{
+		{Name: "http", Port: 8080, Protocol: gwv1.HTTPProtocolType},
+		{Name: "tcp", Port: 9000, Protocol: gwv1.TCPProtocolType},
+	}
+
+	BeforeEach(func() {
+		outputListener = &v1.Listener{
+			Name:     "http",
+			BindPort: 8080,
+			ListenerType: &v1.Listener_AggregateListener{
+				AggregateListener: &v1.AggregateListener{},
+			},
+		}
+	})
+
+	apply := func(annotations map[string]string) error {
+		listenerCtx := &plugins.ListenerContext{
+			Gateway: &gwv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+				Spec: gwv1.GatewaySpec{
+					Listeners: gwListeners,
+				},
+			},
+			GatewayListeners: gwListeners[:1],
+		}
+		return NewPlugin().ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+	}
+
+	It("enables the proxy protocol on the listener", func() {
+		Expect(apply(map[string]string{
+			ProxyProtocolListenersAnnotation: "tcp, http",
+		})).To(Succeed())
+		Expect(outputListener.GetOptions().GetProxyProtocol()).NotTo(BeNil())
+		Expect(outputListener.GetOptions().GetProxyProtocol().GetAllowRequestsWithoutProxyProtocol()).To(BeFalse())
+
+		// the same listener filter parses both v1 and v2 headers
+		listenerFilter, err := glooproxyprotocol.GenerateProxyProtocolListenerFilter(outputListener)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listenerFilter.GetName()).To(Equal(wellknown.ProxyProtocol))
+	})
+
+	It("keeps the other listener options", func() {
+		outputListener.Options = &v1.ListenerOptions{
+			PerConnectionBufferLimitBytes: &wrappers.UInt32Value{Value: 1024},
+		}
+		Expect(apply(map[string]string{
+			ProxyProtocolListenersAnnotation: "http",
+		})).To(Succeed())
+		Expect(outputListener.GetOptions().GetProxyProtocol()).NotTo(BeNil())
+		Expect(outputListener.GetOptions().GetPerConnectionBufferLimitBytes().GetValue()).To(Equal(uint32(1024)))
+	})
+
+	It("does nothing for the other listeners", func() {
+		Expect(apply(map[string]string{
+			ProxyProtocolListenersAnnotation: "tcp",
+		})).To(Succeed())
+		Expect(outputListener.GetOptions()).To(BeNil())
+	})
+
+	It("does nothing without the annotation", func() {
+		Expect(apply(nil)).To(Succeed())
+		Expect(outputListener.GetOptions()).To(BeNil())
+	})
+
+	It("rejects unknown listeners", func() {
+		Expect(apply(map[string]string{
+			ProxyProtocolListenersAnnotation: "http,https",
+		})).To(MatchError(UnknownListenerErr("https")))
+		Expect(outputListener.GetOptions()).To(BeNil())
+	})
+})
@@ -0,0 +1,13 @@
+package proxyprotocol
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestProxyProtocol(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ProxyProtocol Plugin Suite")
+}
@@ -0,0 +1,254 @@
+package ratelimit
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/ratelimit"
+	rlv1alpha1 "github.com/solo-io/solo-apis/pkg/api/ratelimit.solo.io/v1alpha1"
+)
+
+// Annotations set on a Gateway to send requests on its listeners to a global rate limit server.
+const (
+	// RateLimitServiceAnnotation is the rate limit server, as a <service>:<port> in the namespace of the Gateway
+	RateLimitServiceAnnotation = "gateway2.solo.io/rate-limit-service"
+	// RateLimitRequestTimeoutAnnotation is the time to wait for a response of the rate limit server
+	RateLimitRequestTimeoutAnnotation = "gateway2.solo.io/rate-limit-request-timeout"
+	// RateLimitFailureModeAnnotation is either "fail-open", letting requests through when the rate limit server
+	// can't be reached, or "fail-closed", rejecting them. Defaults to "fail-open".
+	RateLimitFailureModeAnnotation = "gateway2.solo.io/rate-limit-failure-mode"
+)
+
+// Annotations set on a RouteOption to rate limit the routes it is applied to. Routes whose RouteOption also sets
+// rate limit options (e.g. rateLimitConfigs) keep those options, and report the conflict.
+const (
+	// RateLimitDescriptorsAnnotation is a semicolon-separated list of descriptors sent to the rate limit server for
+	// each request, each a comma-separated list of the actions generating its entries:
+	//   - "generic-key=<value>" for a generic_key entry of the value
+	//   - "request-header=<header>:<key>" for an entry of the key with the value of the request header. Requests
+	//     without the header don't generate the descriptor.
+	//   - "remote-address" for a remote_address entry of the client IP
+	// e.g. "generic-key=api,remote-address;request-header=x-user-id:user" limits the requests of each client IP
+	// and of each user separately. The limits of the descriptors are configured on the rate limit server.
+	RateLimitDescriptorsAnnotation = "gateway2.solo.io/rate-limit-descriptors"
+)
+
+const (
+	FailOpen   = "fail-open"
+	FailClosed = "fail-closed"
+
+	genericKeyAction    = "generic-key"
+	requestHeaderAction = "request-header"
+	remoteAddressAction = "remote-address"
+
+	// descriptor keys of the entries generated by envoy for these actions
+	genericKeyDescriptorKey    = "generic_key"
+	remoteAddressDescriptorKey = "remote_address"
+)
+
+var (
+	headerRegex = regexp.MustCompile(`^[A-Za-z0-9!#$%&'*+\-.^_|~]+$`)
+
+	InvalidValueErr = func(annotation, value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s", value, annotation)
+	}
+	InvalidActionErr = func(action string) error {
+		return errors.Errorf("invalid action '%s' in annotation %s: must be one of %s=<value>, %s=<header>:<key> or %s",
+			action, RateLimitDescriptorsAnnotation, genericKeyAction, requestHeaderAction, remoteAddressAction)
+	}
+	EmptyDescriptorErr = errors.Errorf("annotation %s contains an empty descriptor", RateLimitDescriptorsAnnotation)
+	DuplicateKeyErr    = func(key string) error {
+		return errors.Errorf("a descriptor of annotation %s has several entries for key '%s'", RateLimitDescriptorsAnnotation, key)
+	}
+	ConflictingRateLimitErr = errors.Errorf("annotation %s cannot be combined with the rate limit options of the RouteOption", RateLimitDescriptorsAnnotation)
+	NotConfiguredErr        = errors.Errorf("routes are rate limited but the Gateway has no annotation %s", RateLimitServiceAnnotation)
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.ListenerPlugin       = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+)
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+// DependsOn the routeoptions plugin, which would otherwise replace the rate limits of the route
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+	rateLimits, err := getRateLimits(routeOption.GetAnnotations())
+	if err != nil || rateLimits == nil {
+		return err
+	}
+	if outputRoute.GetOptions().GetRateLimitConfigType() != nil {
+		return ConflictingRateLimitErr
+	}
+
+	if outputRoute.GetOptions() == nil {
+		outputRoute.Options = &v1.RouteOptions{}
+	}
+	outputRoute.GetOptions().RateLimitConfigType = &v1.RouteOptions_Ratelimit{
+		Ratelimit: &ratelimit.RateLimitRouteExtension{
+			RateLimits: rateLimits,
+		},
+	}
+	return nil
+}
+
+// ValidateRouteOption does not require the rate limit service of the Gateways, which are only known at translation
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	rateLimits, err := getRateLimits(routeOption.GetAnnotations())
+	if err != nil {
+		return err
+	}
+	if rateLimits != nil && routeOption.Spec.GetOptions().GetRateLimitConfigType() != nil {
+		return ConflictingRateLimitErr
+	}
+	return nil
+}
+
+// getRateLimits returns nil if the annotation is not set
+func getRateLimits(annotations map[string]string) ([]*rlv1alpha1.RateLimitActions, error) {
+	value, ok := annotations[RateLimitDescriptorsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	return parseDescriptors(value)
+}
+
+func parseDescriptors(value string) ([]*rlv1alpha1.RateLimitActions, error) {
+	var rateLimits []*rlv1alpha1.RateLimitActions
+	for _, descriptor := range strings.Split(value, ";") {
+		if strings.TrimSpace(descriptor) == "" {
+			return nil, EmptyDescriptorErr
+		}
+		keys := map[string]bool{}
+		rateLimit := &rlv1alpha1.RateLimitActions{}
+		for _, a := range strings.Split(descriptor, ",") {
+			action, key, err := parseAction(strings.TrimSpace(a))
+			if err != nil {
+				return nil, err
+			}
+			if keys[key] {
+				return nil, DuplicateKeyErr(key)
+			}
+			keys[key] = true
+			rateLimit.Actions = append(rateLimit.GetActions(), action)
+		}
+		rateLimits = append(rateLimits, rateLimit)
+	}
+	return rateLimits, nil
+}
+
+// parseAction returns the action and the key of the descriptor entry it generates
+func parseAction(value string) (*rlv1alpha1.Action, string, error) {
+	name, arg, _ := strings.Cut(value, "=")
+	switch name {
+	case genericKeyAction:
+		if arg == "" {
+			return nil, "", InvalidActionErr(value)
+		}
+		return &rlv1alpha1.Action{
+			ActionSpecifier: &rlv1alpha1.Action_GenericKey_{
+				GenericKey: &rlv1alpha1.Action_GenericKey{DescriptorValue: arg},
+			},
+		}, genericKeyDescriptorKey, nil
+	case requestHeaderAction:
+		header, key, found := strings.Cut(arg, ":")
+		if !found || !headerRegex.MatchString(header) || key == "" {
+			return nil, "", InvalidActionErr(value)
+		}
+		return &rlv1alpha1.Action{
+			ActionSpecifier: &rlv1alpha1.Action_RequestHeaders_{
+				RequestHeaders: &rlv1alpha1.Action_RequestHeaders{
+					HeaderName:    header,
+					DescriptorKey: key,
+				},
+			},
+		}, key, nil
+	case remoteAddressAction:
+		if value != remoteAddressAction {
+			return nil, "", InvalidActionErr(value)
+		}
+		return &rlv1alpha1.Action{
+			ActionSpecifier: &rlv1alpha1.Action_RemoteAddress_{
+				RemoteAddress: &rlv1alpha1.Action_RemoteAddress{},
+			},
+		}, remoteAddressDescriptorKey, nil
+	default:
+		return nil, "", InvalidActionErr(value)
+	}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	annotations := listenerCtx.Gateway.GetAnnotations()
+	ref, err := utils.GetServiceRefAnnotation(annotations, RateLimitServiceAnnotation, listenerCtx.Gateway.GetNamespace())
+	if err != nil {
+		return err
+	}
+	if ref == nil {
+		for _, vhost := range outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts() {
+			for _, route := range vhost.GetRoutes() {
+				if len(route.GetOptions().GetRatelimit().GetRateLimits()) > 0 {
+					return NotConfiguredErr
+				}
+			}
+		}
+		return nil
+	}
+
+	timeout, err := utils.GetDurationAnnotation(annotations, RateLimitRequestTimeoutAnnotation)
+	if err != nil {
+		return err
+	}
+	denyOnFail := false
+	switch value, ok := annotations[RateLimitFailureModeAnnotation]; {
+	case !ok || value == FailOpen:
+	case value == FailClosed:
+		denyOnFail = true
+	default:
+		return InvalidValueErr(RateLimitFailureModeAnnotation, value)
+	}
+
+	for _, options := range utils.GetHttpListenerOptions(outputListener) {
+		options.RatelimitServer = &ratelimit.Settings{
+			RatelimitServerRef: ref,
+			RequestTimeout:     timeout,
+			DenyOnFail:         denyOnFail,
+		}
+	}
+	return nil
+}
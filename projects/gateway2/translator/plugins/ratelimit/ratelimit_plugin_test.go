@@ -0,0 +1,231 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/ratelimit"
+	rlv1alpha1 "github.com/solo-io/solo-apis/pkg/api/ratelimit.solo.io/v1alpha1"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("RateLimitPlugin", func() {
+	genericKey := func(value string) *rlv1alpha1.Action {
+		return &rlv1alpha1.Action{
+			ActionSpecifier: &rlv1alpha1.Action_GenericKey_{
+				GenericKey: &rlv1alpha1.Action_GenericKey{DescriptorValue: value},
+			},
+		}
+	}
+	requestHeader := func(header, key string) *rlv1alpha1.Action {
+		return &rlv1alpha1.Action{
+			ActionSpecifier: &rlv1alpha1.Action_RequestHeaders_{
+				RequestHeaders: &rlv1alpha1.Action_RequestHeaders{HeaderName: header, DescriptorKey: key},
+			},
+		}
+	}
+	remoteAddress := &rlv1alpha1.Action{
+		ActionSpecifier: &rlv1alpha1.Action_RemoteAddress_{
+			RemoteAddress: &rlv1alpha1.Action_RemoteAddress{},
+		},
+	}
+
+	Context("routes", func() {
+		apply := func(annotations map[string]string, options *v1.RouteOptions) (*v1.Route, error) {
+			queries := testutils.BuildGatewayQueries([]client.Object{testutils.RouteOption(annotations)})
+			routeCtx := &plugins.RouteContext{
+				Route: &gwv1.HTTPRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "default",
+					},
+				},
+				Rule: &gwv1.HTTPRouteRule{
+					Filters: []gwv1.HTTPRouteFilter{{
+						Type: gwv1.HTTPRouteFilterExtensionRef,
+						ExtensionRef: &gwv1.LocalObjectReference{
+							Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+							Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+							Name:  "policy",
+						},
+					}},
+				},
+			}
+			outputRoute := &v1.Route{
+				Options: options,
+			}
+			err := NewPlugin(queries).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+			return outputRoute, err
+		}
+
+		It("translates descriptors with multiple actions", func() {
+			route, err := apply(map[string]string{
+				RateLimitDescriptorsAnnotation: "generic-key=api, remote-address; request-header=x-user-id:user,generic-key=per-user",
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(route.GetOptions().GetRatelimit(), &ratelimit.RateLimitRouteExtension{
+				RateLimits: []*rlv1alpha1.RateLimitActions{
+					{Actions: []*rlv1alpha1.Action{genericKey("api"), remoteAddress}},
+					{Actions: []*rlv1alpha1.Action{requestHeader("x-user-id", "user"), genericKey("per-user")}},
+				},
+			})).To(BeTrue())
+		})
+
+		It("does nothing without the annotation", func() {
+			route, err := apply(nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(route.GetOptions().GetRateLimitConfigType()).To(BeNil())
+		})
+
+		It("rejects the annotation alongside the rate limit options", func() {
+			route, err := apply(map[string]string{RateLimitDescriptorsAnnotation: "remote-address"}, &v1.RouteOptions{
+				RateLimitConfigType: &v1.RouteOptions_RateLimitConfigs{
+					RateLimitConfigs: &ratelimit.RateLimitConfigRefs{},
+				},
+			})
+			Expect(err).To(MatchError(ConflictingRateLimitErr))
+			Expect(route.GetOptions().GetRateLimitConfigs()).NotTo(BeNil())
+		})
+
+		DescribeTable("rejects invalid descriptors",
+			func(descriptors string, expectedErr string) {
+				route, err := apply(map[string]string{RateLimitDescriptorsAnnotation: descriptors}, nil)
+				Expect(err).To(MatchError(expectedErr))
+				Expect(route.GetOptions().GetRateLimitConfigType()).To(BeNil())
+			},
+			Entry("unknown action", "remote-address;destination-cluster",
+				InvalidActionErr("destination-cluster").Error()),
+			Entry("generic key without value", "generic-key=",
+				InvalidActionErr("generic-key=").Error()),
+			Entry("request header without key", "request-header=x-user-id",
+				InvalidActionErr("request-header=x-user-id").Error()),
+			Entry("invalid request header", "request-header=x user:user",
+				InvalidActionErr("request-header=x user:user").Error()),
+			Entry("remote address with value", "remote-address=10.0.0.1",
+				InvalidActionErr("remote-address=10.0.0.1").Error()),
+			Entry("empty descriptor", "remote-address;",
+				EmptyDescriptorErr.Error()),
+			Entry("duplicate keys", "generic-key=a,generic-key=b",
+				DuplicateKeyErr("generic_key").Error()),
+		)
+	})
+
+	Context("listeners", func() {
+		var (
+			annotations    map[string]string
+			outputListener *v1.Listener
+		)
+
+		BeforeEach(func() {
+			annotations = map[string]string{
+				RateLimitServiceAnnotation: "ratelimit:18081",
+			}
+			outputListener = &v1.Listener{
+				Name: "http",
+				ListenerType: &v1.Listener_AggregateListener{
+					AggregateListener: &v1.AggregateListener{
+						HttpResources: &v1.AggregateListener_HttpResources{
+							VirtualHosts: map[string]*v1.VirtualHost{
+								"vhost": {
+									Routes: []*v1.Route{
+										{Name: "limited", Options: &v1.RouteOptions{
+											RateLimitConfigType: &v1.RouteOptions_Ratelimit{
+												Ratelimit: &ratelimit.RateLimitRouteExtension{
+													RateLimits: []*rlv1alpha1.RateLimitActions{
+														{Actions: []*rlv1alpha1.Action{remoteAddress}},
+													},
+												},
+											},
+										}},
+										{Name: "default"},
+									},
+								},
+							},
+						},
+						HttpFilterChains: []*v1.AggregateListener_HttpFilterChain{{
+							VirtualHostRefs: []string{"vhost"},
+						}},
+					},
+				},
+			}
+		})
+
+		apply := func() error {
+			listenerCtx := &plugins.ListenerContext{
+				Gateway: &gwv1.Gateway{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace:   "gateways",
+						Annotations: annotations,
+					},
+				},
+			}
+			return NewPlugin(nil).ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+		}
+
+		settings := func() *ratelimit.Settings {
+			aggregateListener := outputListener.GetAggregateListener()
+			ref := aggregateListener.GetHttpFilterChains()[0].GetHttpOptionsRef()
+			return aggregateListener.GetHttpResources().GetHttpOptions()[ref].GetRatelimitServer()
+		}
+
+		DescribeTable("points to the rate limit server with the failure mode",
+			func(failureMode string, denyOnFail bool) {
+				if failureMode != "" {
+					annotations[RateLimitFailureModeAnnotation] = failureMode
+				}
+				Expect(apply()).To(Succeed())
+				Expect(proto.Equal(settings(), &ratelimit.Settings{
+					RatelimitServerRef: &core.ResourceRef{Name: "gateways-ratelimit-18081", Namespace: "gateways"},
+					DenyOnFail:         denyOnFail,
+				})).To(BeTrue())
+			},
+			Entry("fail open by default", "", false),
+			Entry("fail open", FailOpen, false),
+			Entry("fail closed", FailClosed, true),
+		)
+
+		It("sets the request timeout", func() {
+			annotations[RateLimitRequestTimeoutAnnotation] = "100ms"
+			Expect(apply()).To(Succeed())
+			Expect(proto.Equal(settings().GetRequestTimeout(), durationpb.New(100*time.Millisecond))).To(BeTrue())
+		})
+
+		It("does nothing without the annotation", func() {
+			annotations = nil
+			outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts()["vhost"].GetRoutes()[0].Options = nil
+			Expect(apply()).To(Succeed())
+			Expect(outputListener.GetAggregateListener().GetHttpResources().GetHttpOptions()).To(BeEmpty())
+		})
+
+		It("rejects rate limited routes without a server", func() {
+			annotations = nil
+			Expect(apply()).To(MatchError(NotConfiguredErr))
+		})
+
+		DescribeTable("rejects invalid annotations",
+			func(annotation, value string, expectedErr string) {
+				annotations[annotation] = value
+				Expect(apply()).To(MatchError(ContainSubstring(expectedErr)))
+				Expect(settings()).To(BeNil())
+			},
+			Entry("service without port", RateLimitServiceAnnotation, "ratelimit",
+				utils.InvalidServiceRefErr(RateLimitServiceAnnotation, "ratelimit").Error()),
+			Entry("unknown failure mode", RateLimitFailureModeAnnotation, "fail-sometimes",
+				InvalidValueErr(RateLimitFailureModeAnnotation, "fail-sometimes").Error()),
+			Entry("unparseable timeout", RateLimitRequestTimeoutAnnotation, "soon",
+				RateLimitRequestTimeoutAnnotation),
+		)
+	})
+})
@@ -0,0 +1,22 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/go-utils/contextutils"
+)
+
+// FilterAvailablePlugins returns the plugins, in order, without the ConditionalPlugins that are not available,
+// logging the plugins skipped
+func FilterAvailablePlugins(ctx context.Context, allPlugins []plugins.Plugin) []plugins.Plugin {
+	available := make([]plugins.Plugin, 0, len(allPlugins))
+	for _, plugin := range allPlugins {
+		if conditional, ok := plugin.(plugins.ConditionalPlugin); ok && !conditional.Available(ctx) {
+			contextutils.LoggerFrom(ctx).Infof("skipping plugin %s: the capabilities it requires are not available in the cluster", PluginName(plugin))
+			continue
+		}
+		available = append(available, plugin)
+	}
+	return available
+}
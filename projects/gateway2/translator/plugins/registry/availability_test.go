@@ -0,0 +1,63 @@
+package registry_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	. "github.com/solo-io/gloo/projects/gateway2/translator/plugins/registry"
+)
+
+var _ = Describe("FilterAvailablePlugins", func() {
+	type capabilityKey struct{}
+
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.WithValue(context.Background(), capabilityKey{}, "ratelimit")
+	})
+
+	requiring := func(name, capability string) *conditionalPlugin {
+		return &conditionalPlugin{
+			dependentPlugin: dependentPlugin{name: name},
+			available: func(ctx context.Context) bool {
+				return ctx.Value(capabilityKey{}) == capability
+			},
+		}
+	}
+
+	It("registers the available plugins", func() {
+		available := requiring("available", "ratelimit")
+		unconditional := &dependentPlugin{name: "unconditional"}
+
+		allPlugins := FilterAvailablePlugins(ctx, []plugins.Plugin{available, unconditional})
+		Expect(allPlugins).To(Equal([]plugins.Plugin{available, unconditional}))
+
+		pluginRegistry, err := NewPluginRegistry(allPlugins)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pluginNames(pluginRegistry.GetRoutePlugins())).To(Equal([]string{"available", "unconditional"}))
+	})
+
+	It("skips the unavailable plugins", func() {
+		unavailable := requiring("unavailable", "extauth")
+		unconditional := &dependentPlugin{name: "unconditional"}
+
+		allPlugins := FilterAvailablePlugins(ctx, []plugins.Plugin{unavailable, unconditional})
+		Expect(allPlugins).To(Equal([]plugins.Plugin{unconditional}))
+
+		pluginRegistry, err := NewPluginRegistry(allPlugins)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pluginNames(pluginRegistry.GetRoutePlugins())).To(Equal([]string{"unconditional"}))
+	})
+})
+
+type conditionalPlugin struct {
+	dependentPlugin
+	available func(ctx context.Context) bool
+}
+
+func (p *conditionalPlugin) Available(ctx context.Context) bool {
+	return p.available(ctx)
+}
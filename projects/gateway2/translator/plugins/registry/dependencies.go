@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"golang.org/x/exp/slices"
+)
+
+var CyclicDependencyErr = func(phase Phase, cycle []string) error {
+	return errors.Errorf("plugins of the %s phase depend on each other: %s", phase, strings.Join(cycle, " -> "))
+}
+
+// sortByDependencies returns the indices of the plugins of the phase sorted so that each plugin comes after the
+// plugins it depends on, keeping the order of the plugins otherwise
+func sortByDependencies[T any](phase Phase, phasePlugins []T) ([]int, error) {
+	byName := map[string][]int{}
+	for i, plugin := range phasePlugins {
+		name := PluginName(plugin)
+		byName[name] = append(byName[name], i)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	var (
+		states = make([]int, len(phasePlugins))
+		// plugins being visited, to report cycles
+		path   []int
+		sorted = make([]int, 0, len(phasePlugins))
+		visit  func(i int) error
+	)
+	visit = func(i int) error {
+		switch states[i] {
+		case visited:
+			return nil
+		case visiting:
+			start := slices.Index(path, i)
+			var cycle []string
+			for _, j := range append(path[start:], i) {
+				cycle = append(cycle, PluginName(phasePlugins[j]))
+			}
+			return CyclicDependencyErr(phase, cycle)
+		}
+		states[i] = visiting
+		path = append(path, i)
+		if dependent, ok := any(phasePlugins[i]).(plugins.DependentPlugin); ok {
+			for _, dependency := range dependent.DependsOn() {
+				for _, j := range byName[dependency] {
+					if err := visit(j); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		states[i] = visited
+		sorted = append(sorted, i)
+		return nil
+	}
+
+	for i := range phasePlugins {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
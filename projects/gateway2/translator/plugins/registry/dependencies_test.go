@@ -0,0 +1,130 @@
+package registry_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	. "github.com/solo-io/gloo/projects/gateway2/translator/plugins/registry"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+)
+
+var _ = Describe("Dependencies", func() {
+	It("applies the plugins after their dependencies", func() {
+		pluginRegistry, err := NewPluginRegistry([]plugins.Plugin{
+			&dependentPlugin{name: "ratelimit", dependsOn: []string{"jwt"}},
+			&dependentPlugin{name: "redirect"},
+			&dependentPlugin{name: "jwt", dependsOn: []string{"extauth"}},
+			&dependentPlugin{name: "extauth"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pluginNames(pluginRegistry.GetRoutePlugins())).To(Equal([]string{"extauth", "jwt", "ratelimit", "redirect"}))
+	})
+
+	It("keeps the order of plugins without dependencies", func() {
+		pluginRegistry, err := NewPluginRegistry([]plugins.Plugin{
+			&dependentPlugin{name: "b"},
+			&dependentPlugin{name: "a"},
+			&dependentPlugin{name: "c", dependsOn: []string{"b"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pluginNames(pluginRegistry.GetRoutePlugins())).To(Equal([]string{"b", "a", "c"}))
+	})
+
+	It("ignores dependencies not applied in the phase", func() {
+		pluginRegistry, err := NewPluginRegistry([]plugins.Plugin{
+			&dependentPlugin{name: "a", dependsOn: []string{"missing"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pluginNames(pluginRegistry.GetRoutePlugins())).To(Equal([]string{"a"}))
+	})
+
+	It("rejects plugins depending on each other", func() {
+		_, err := NewPluginRegistry([]plugins.Plugin{
+			&dependentPlugin{name: "a", dependsOn: []string{"b"}},
+			&dependentPlugin{name: "b", dependsOn: []string{"c"}},
+			&dependentPlugin{name: "c", dependsOn: []string{"a"}},
+		})
+		Expect(err).To(MatchError(CyclicDependencyErr(RoutePhase, []string{"a", "b", "c", "a"}).Error()))
+	})
+
+	It("rejects a plugin depending on itself", func() {
+		_, err := NewPluginRegistry([]plugins.Plugin{
+			&dependentPlugin{name: "a", dependsOn: []string{"a"}},
+		})
+		Expect(err).To(MatchError(CyclicDependencyErr(RoutePhase, []string{"a", "a"}).Error()))
+	})
+
+	It("applies the plugins of the gateway after their dependencies", func() {
+		allPlugins := BuildPlugins(testutils.BuildGatewayQueries(nil))
+		pluginRegistry, err := NewPluginRegistry(allPlugins)
+		Expect(err).NotTo(HaveOccurred())
+		for _, phasePlugins := range [][]string{
+			pluginNames(pluginRegistry.GetRoutePlugins()),
+			pluginNames(pluginRegistry.GetListenerPlugins()),
+			pluginNames(pluginRegistry.GetBackendPlugins()),
+		} {
+			for _, plugin := range allPlugins {
+				dependent, ok := plugin.(plugins.DependentPlugin)
+				if !ok || indexOf(phasePlugins, PluginName(plugin)) < 0 {
+					continue
+				}
+				for _, dependency := range dependent.DependsOn() {
+					if indexOf(phasePlugins, dependency) >= 0 {
+						Expect(indexOf(phasePlugins, dependency)).To(BeNumerically("<", indexOf(phasePlugins, PluginName(plugin))),
+							"%s depends on %s", PluginName(plugin), dependency)
+					}
+				}
+			}
+		}
+	})
+
+	// plugins whose order matters must declare their dependencies, this only guards against unintended reorderings
+	It("applies the plugins of the gateway in the order of BuildPlugins otherwise", func() {
+		pluginRegistry, err := NewPluginRegistry(BuildPlugins(testutils.BuildGatewayQueries(nil)))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pluginNames(pluginRegistry.GetRoutePlugins())).To(Equal([]string{
+			"routeoptions", "mirror", "redirect", "urlrewrite", "clusternotfound", "headermodifier", "bodylimit",
+			"hostrewrite", "hashpolicy", "extproc", "jwt", "oidc", "apikey", "ratelimit", "pathmatch", "errorheaders",
+			"statusremap", "idempotency", "tracing", "upgrades", "routemetadata", "hedging", "streamduration",
+		}))
+		Expect(pluginNames(pluginRegistry.GetListenerPlugins())).To(Equal([]string{
+			"extproc", "jwt", "ratelimit", "tracing", "canary", "wasm", "compression", "grpcjson", "connectiontimeout",
+			"headerlimits", "transportprotocol", "xff", "requestid", "accesslog", "maintenance", "retrypolicy",
+			"statprefix", "removeheaders", "proxyprotocol", "tlsparameters", "clientvalidation",
+		}))
+		Expect(pluginNames(pluginRegistry.GetBackendPlugins())).To(Equal([]string{
+			"upstreamprotocol", "externalservice", "healthcheck", "outlierdetection", "circuitbreaker", "loadbalancer",
+			"keepalive", "dnsresolution",
+		}))
+	})
+})
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+type dependentPlugin struct {
+	name      string
+	dependsOn []string
+}
+
+func (p *dependentPlugin) Name() string {
+	return p.name
+}
+
+func (p *dependentPlugin) DependsOn() []string {
+	return p.dependsOn
+}
+
+func (p *dependentPlugin) ApplyRoutePlugin(context.Context, *plugins.RouteContext, *v1.Route) error {
+	return nil
+}
@@ -0,0 +1,81 @@
+package registry_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	. "github.com/solo-io/gloo/projects/gateway2/translator/plugins/registry"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+)
+
+var _ = Describe("Describe", func() {
+	var (
+		allPlugins     []plugins.Plugin
+		pluginRegistry PluginRegistry
+	)
+
+	BeforeEach(func() {
+		allPlugins = BuildPlugins(testutils.BuildGatewayQueries(nil))
+		var err error
+		pluginRegistry, err = NewPluginRegistry(allPlugins)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	infoOf := func(name string) PluginInfo {
+		for _, info := range pluginRegistry.Describe() {
+			if info.Name == name {
+				return info
+			}
+		}
+		Fail("no plugin named " + name)
+		return PluginInfo{}
+	}
+
+	It("describes the plugins from BuildPlugins", func() {
+		infos := pluginRegistry.Describe()
+		Expect(infos).To(HaveLen(len(allPlugins)))
+		for i, plugin := range allPlugins {
+			Expect(infos[i].Name).To(Equal(PluginName(plugin)))
+			Expect(infos[i].Phases).NotTo(BeEmpty(), infos[i].Name)
+		}
+	})
+
+	It("describes the phases of the plugins", func() {
+		Expect(infoOf("headermodifier").Phases).To(Equal([]Phase{RoutePhase}))
+		Expect(infoOf("tracing").Phases).To(Equal([]Phase{RoutePhase, ListenerPhase}))
+		Expect(infoOf("circuitbreaker").Phases).To(Equal([]Phase{BackendPhase}))
+		Expect(infoOf("apikey").Phases).To(ContainElement(PostTranslationPhase))
+	})
+
+	It("describes the order the plugins are applied in", func() {
+		for phase, names := range map[Phase][]string{
+			RoutePhase:           pluginNames(pluginRegistry.GetRoutePlugins()),
+			ListenerPhase:        pluginNames(pluginRegistry.GetListenerPlugins()),
+			BackendPhase:         pluginNames(pluginRegistry.GetBackendPlugins()),
+			PostTranslationPhase: pluginNames(pluginRegistry.GetPostTranslationPlugins()),
+		} {
+			ordered := make([]string, len(names))
+			for _, info := range pluginRegistry.Describe() {
+				if position, ok := info.Order[phase]; ok {
+					ordered[position] = info.Name
+				}
+			}
+			Expect(ordered).To(Equal(names), string(phase))
+		}
+	})
+
+	It("describes the dependencies of the plugins", func() {
+		Expect(infoOf("dnsresolution").DependsOn).To(Equal([]string{"externalservice"}))
+		Expect(infoOf("dnsresolution").Order[BackendPhase]).To(BeNumerically(">", infoOf("externalservice").Order[BackendPhase]))
+		Expect(infoOf("redirect").DependsOn).To(BeEmpty())
+	})
+})
+
+func pluginNames[T any](phasePlugins []T) []string {
+	var names []string
+	for _, plugin := range phasePlugins {
+		names = append(names, PluginName(plugin))
+	}
+	return names
+}
@@ -1,13 +1,60 @@
 package registry
 
 import (
+	"context"
+	"errors"
+
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
 	"github.com/solo-io/gloo/projects/gateway2/query"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/accesslog"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/apikey"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/bodylimit"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/canary"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/circuitbreaker"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/clientvalidation"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/clusternotfound"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/compression"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/connectiontimeout"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/dnsresolution"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/errorheaders"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/externalservice"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/extproc"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/grpcjson"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/hashpolicy"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/headerlimits"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/headermodifier"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/healthcheck"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/hedging"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/hostrewrite"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/idempotency"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/jwt"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/keepalive"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/loadbalancer"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/maintenance"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/mirror"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/oidc"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/outlierdetection"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/pathmatch"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/proxyprotocol"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/ratelimit"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/redirect"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/removeheaders"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/requestid"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/retrypolicy"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/routemetadata"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/routeoptions"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/statprefix"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/statusremap"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/streamduration"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/tlsparameters"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/tracing"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/transportprotocol"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/upgrades"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/upstreamprotocol"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/urlrewrite"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/wasm"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/xff"
 )
 
 // PluginRegistry is used to provide Plugins to the K8s Gateway translator.
@@ -15,35 +62,155 @@ import (
 // into a Gloo Proxy resource, or during the post-processing of that conversion.
 type PluginRegistry struct {
 	routePlugins           []plugins.RoutePlugin
+	listenerPlugins        []plugins.ListenerPlugin
+	backendPlugins         []plugins.BackendPlugin
 	postTranslationPlugins []plugins.PostTranslationPlugin
+	routeOptionValidators  []plugins.RouteOptionValidator
+	infos                  []PluginInfo
+}
+
+// PluginInfo describes a plugin of a PluginRegistry
+type PluginInfo struct {
+	// Name of the plugin, see PluginName
+	Name string
+	// Phases the plugin is applied in
+	Phases []Phase
+	// Order is the position the plugin is applied at in each of its phases, starting at 0
+	Order map[Phase]int
+	// DependsOn are the names of the plugins the plugin is applied after
+	DependsOn []string
 }
 
 func (p *PluginRegistry) GetRoutePlugins() []plugins.RoutePlugin {
 	return p.routePlugins
 }
 
+func (p *PluginRegistry) GetListenerPlugins() []plugins.ListenerPlugin {
+	return p.listenerPlugins
+}
+
+func (p *PluginRegistry) GetBackendPlugins() []plugins.BackendPlugin {
+	return p.backendPlugins
+}
+
 func (p *PluginRegistry) GetPostTranslationPlugins() []plugins.PostTranslationPlugin {
 	return p.postTranslationPlugins
 }
 
-func NewPluginRegistry(allPlugins []plugins.Plugin) PluginRegistry {
-	var (
-		routePlugins           []plugins.RoutePlugin
-		postTranslationPlugins []plugins.PostTranslationPlugin
-	)
+func (p *PluginRegistry) GetRouteOptionValidators() []plugins.RouteOptionValidator {
+	return p.routeOptionValidators
+}
 
+// Describe returns the infos of the plugins of the registry, in the order they were registered
+func (p *PluginRegistry) Describe() []PluginInfo {
+	return p.infos
+}
+
+// ValidateRouteOption returns the errors of all plugins validating the RouteOption, e.g. for an admission webhook
+func (p *PluginRegistry) ValidateRouteOption(ctx context.Context, routeOption *solokubev1.RouteOption) error {
+	var errs []error
+	for _, validator := range p.routeOptionValidators {
+		if err := validator.ValidateRouteOption(ctx, routeOption); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewPluginRegistry returns a PluginRegistry applying the plugins of each phase after the plugins they depend on,
+// in the order of allPlugins otherwise. Returns an error if the plugins of a phase depend on each other.
+func NewPluginRegistry(allPlugins []plugins.Plugin) (PluginRegistry, error) {
+	return NewRecordedPluginRegistry(allPlugins, nil)
+}
+
+// NewRecordedPluginRegistry returns a PluginRegistry whose plugins report each of their applications to the Recorder.
+// The plugins are not instrumented when the Recorder is nil.
+func NewRecordedPluginRegistry(allPlugins []plugins.Plugin, recorder Recorder) (PluginRegistry, error) {
+	infos := make([]PluginInfo, len(allPlugins))
+	for i, plugin := range allPlugins {
+		infos[i] = PluginInfo{
+			Name: PluginName(plugin),
+		}
+		if dependent, ok := plugin.(plugins.DependentPlugin); ok {
+			infos[i].DependsOn = dependent.DependsOn()
+		}
+	}
+
+	routePlugins, err := phasePlugins[plugins.RoutePlugin](RoutePhase, allPlugins, infos)
+	if err != nil {
+		return PluginRegistry{}, err
+	}
+	listenerPlugins, err := phasePlugins[plugins.ListenerPlugin](ListenerPhase, allPlugins, infos)
+	if err != nil {
+		return PluginRegistry{}, err
+	}
+	backendPlugins, err := phasePlugins[plugins.BackendPlugin](BackendPhase, allPlugins, infos)
+	if err != nil {
+		return PluginRegistry{}, err
+	}
+	postTranslationPlugins, err := phasePlugins[plugins.PostTranslationPlugin](PostTranslationPhase, allPlugins, infos)
+	if err != nil {
+		return PluginRegistry{}, err
+	}
+	var routeOptionValidators []plugins.RouteOptionValidator
 	for _, plugin := range allPlugins {
-		if routePlugin, ok := plugin.(plugins.RoutePlugin); ok {
-			routePlugins = append(routePlugins, routePlugin)
+		if routeOptionValidator, ok := plugin.(plugins.RouteOptionValidator); ok {
+			routeOptionValidators = append(routeOptionValidators, routeOptionValidator)
+		}
+	}
+
+	if recorder != nil {
+		for i, plugin := range routePlugins {
+			routePlugins[i] = &recordedRoutePlugin{newRecordedPlugin(plugin, recorder), plugin}
+		}
+		for i, plugin := range listenerPlugins {
+			listenerPlugins[i] = &recordedListenerPlugin{newRecordedPlugin(plugin, recorder), plugin}
 		}
-		if postTranslationPlugin, ok := plugin.(plugins.PostTranslationPlugin); ok {
-			postTranslationPlugins = append(postTranslationPlugins, postTranslationPlugin)
+		for i, plugin := range backendPlugins {
+			backendPlugins[i] = &recordedBackendPlugin{newRecordedPlugin(plugin, recorder), plugin}
+		}
+		for i, plugin := range postTranslationPlugins {
+			postTranslationPlugins[i] = &recordedPostTranslationPlugin{newRecordedPlugin(plugin, recorder), plugin}
 		}
 	}
 	return PluginRegistry{
 		routePlugins:           routePlugins,
+		listenerPlugins:        listenerPlugins,
+		backendPlugins:         backendPlugins,
 		postTranslationPlugins: postTranslationPlugins,
+		routeOptionValidators:  routeOptionValidators,
+		infos:                  infos,
+	}, nil
+}
+
+// phasePlugins returns the plugins implementing T sorted by their dependencies, recording the position each is applied
+// at in the phase in their infos
+func phasePlugins[T any](phase Phase, allPlugins []plugins.Plugin, infos []PluginInfo) ([]T, error) {
+	var (
+		typed   []T
+		indices []int
+	)
+	for i, plugin := range allPlugins {
+		if t, ok := plugin.(T); ok {
+			typed = append(typed, t)
+			indices = append(indices, i)
+		}
+	}
+	order, err := sortByDependencies(phase, typed)
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]T, 0, len(order))
+	for position, i := range order {
+		sorted = append(sorted, typed[i])
+		info := &infos[indices[i]]
+		if info.Order == nil {
+			info.Order = map[Phase]int{}
+		}
+		info.Phases = append(info.Phases, phase)
+		info.Order[phase] = position
 	}
+	return sorted, nil
 }
 
 // BuildPlugins returns the full set of plugins to be registered.
@@ -52,10 +219,53 @@ func NewPluginRegistry(allPlugins []plugins.Plugin) PluginRegistry {
 // we can add a new registry constructor that accepts this function
 func BuildPlugins(queries query.GatewayQueries) []plugins.Plugin {
 	return []plugins.Plugin{
-		headermodifier.NewPlugin(),
 		mirror.NewPlugin(queries),
 		redirect.NewPlugin(),
 		routeoptions.NewPlugin(queries),
 		urlrewrite.NewPlugin(),
+		clusternotfound.NewPlugin(queries),
+		headermodifier.NewPlugin(queries),
+		bodylimit.NewPlugin(queries),
+		hostrewrite.NewPlugin(queries),
+		hashpolicy.NewPlugin(queries),
+		extproc.NewPlugin(queries),
+		jwt.NewPlugin(queries),
+		oidc.NewPlugin(queries),
+		apikey.NewPlugin(queries),
+		ratelimit.NewPlugin(queries),
+		pathmatch.NewPlugin(queries),
+		errorheaders.NewPlugin(queries),
+		statusremap.NewPlugin(queries),
+		idempotency.NewPlugin(queries),
+		tracing.NewPlugin(queries),
+		upgrades.NewPlugin(queries),
+		routemetadata.NewPlugin(queries),
+		hedging.NewPlugin(queries),
+		streamduration.NewPlugin(queries),
+		externalservice.NewPlugin(),
+		canary.NewPlugin(),
+		healthcheck.NewPlugin(),
+		outlierdetection.NewPlugin(),
+		circuitbreaker.NewPlugin(),
+		loadbalancer.NewPlugin(),
+		upstreamprotocol.NewPlugin(),
+		keepalive.NewPlugin(),
+		dnsresolution.NewPlugin(),
+		wasm.NewPlugin(),
+		compression.NewPlugin(),
+		grpcjson.NewPlugin(queries),
+		connectiontimeout.NewPlugin(),
+		headerlimits.NewPlugin(),
+		transportprotocol.NewPlugin(),
+		xff.NewPlugin(),
+		requestid.NewPlugin(),
+		accesslog.NewPlugin(),
+		maintenance.NewPlugin(),
+		retrypolicy.NewPlugin(),
+		statprefix.NewPlugin(),
+		removeheaders.NewPlugin(),
+		proxyprotocol.NewPlugin(),
+		tlsparameters.NewPlugin(),
+		clientvalidation.NewPlugin(queries),
 	}
 }
@@ -0,0 +1,182 @@
+package registry_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/apikey"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/bodylimit"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/clusternotfound"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/extproc"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/headermodifier"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/hedging"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/hostrewrite"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/idempotency"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/jwt"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/mirror"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/oidc"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/pathmatch"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/ratelimit"
+	. "github.com/solo-io/gloo/projects/gateway2/translator/plugins/registry"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/routemetadata"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/streamduration"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/tracing"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/upgrades"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	buffer "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/extensions/filters/http/buffer/v3"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	extprocv1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/extproc"
+	jwtv1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/jwt"
+	ratelimitv1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1/enterprise/options/ratelimit"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/protocol_upgrade"
+	tracingv1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/tracing"
+)
+
+var _ = Describe("ValidateRouteOption", func() {
+	validateWithOptions := func(annotations map[string]string, options *v1.RouteOptions) error {
+		pluginRegistry, err := NewPluginRegistry(BuildPlugins(testutils.BuildGatewayQueries(nil)))
+		Expect(err).NotTo(HaveOccurred())
+		routeOption := testutils.RouteOption(annotations)
+		routeOption.Spec.Options = options
+		return pluginRegistry.ValidateRouteOption(context.Background(), routeOption)
+	}
+	validate := func(annotations map[string]string) error {
+		return validateWithOptions(annotations, nil)
+	}
+
+	It("accepts a RouteOption without annotations", func() {
+		Expect(validate(nil)).To(Succeed())
+	})
+
+	// translation reports those errors on the status of the routes, so admission rejects them
+	DescribeTable("rejects the annotations the plugins reject at translation",
+		func(annotations map[string]string, expectedErr error) {
+			Expect(validate(annotations)).To(MatchError(expectedErr.Error()))
+		},
+		Entry("pathmatch",
+			map[string]string{pathmatch.PathIgnoreCaseAnnotation: "maybe"},
+			pathmatch.InvalidIgnoreCaseErr("maybe")),
+		Entry("streamduration",
+			map[string]string{streamduration.MaxStreamDurationAnnotation: "forever"},
+			streamduration.InvalidDurationErr("forever")),
+		Entry("hostrewrite",
+			map[string]string{hostrewrite.HostRewriteFromBackendAnnotation: "yes please"},
+			hostrewrite.InvalidHostRewriteFromBackendErr("yes please")),
+		Entry("routemetadata",
+			map[string]string{routemetadata.RouteStatPrefixAnnotation: "api"},
+			routemetadata.StatPrefixUnsupportedErr),
+		Entry("bodylimit",
+			map[string]string{bodylimit.MaxRequestBytesAnnotation: "lots"},
+			bodylimit.InvalidLimitErr(bodylimit.MaxRequestBytesAnnotation, "lots")),
+		Entry("hedging",
+			map[string]string{hedging.HedgeOnPerTryTimeoutAnnotation: "true"},
+			hedging.MissingPerTryTimeoutErr),
+		Entry("idempotency",
+			map[string]string{idempotency.IdempotencyKeyAnnotation: "ignore"},
+			idempotency.UnknownModeErr("ignore")),
+		Entry("ratelimit",
+			map[string]string{ratelimit.RateLimitDescriptorsAnnotation: "remote-address;"},
+			ratelimit.EmptyDescriptorErr),
+		Entry("clusternotfound",
+			map[string]string{clusternotfound.ClusterNotFoundResponseCodeAnnotation: "600"},
+			clusternotfound.InvalidResponseCodeErr("600")),
+		Entry("apikey",
+			map[string]string{
+				apikey.ApiKeySecretsAnnotation:    "keys",
+				apikey.ApiKeyHeaderAnnotation:     "x-api-key",
+				apikey.ApiKeyQueryParamAnnotation: "api_key",
+			},
+			apikey.ConflictingSourceErr),
+		Entry("headermodifier",
+			map[string]string{headermodifier.RequestHeaderModifierStageAnnotation: "first"},
+			headermodifier.UnknownStageErr("first")),
+		Entry("upgrades",
+			map[string]string{upgrades.UpgradesAnnotation: "websocket,websocket"},
+			upgrades.DuplicateUpgradeErr("websocket")),
+		Entry("tracing",
+			map[string]string{tracing.TracingPropagateAnnotation: "sometimes"},
+			tracing.InvalidPropagateErr("sometimes")),
+		Entry("extproc",
+			map[string]string{extproc.ExtProcServiceAnnotation: "ext-proc:9000"},
+			extproc.OptionWithoutEnabledErr),
+		Entry("jwt",
+			map[string]string{jwt.JwtIssuerAnnotation: "https://issuer.example.com"},
+			jwt.JwksSourceErr),
+		Entry("oidc",
+			map[string]string{oidc.OidcIssuerAnnotation: "https://issuer.example.com"},
+			oidc.MissingAnnotationErr(oidc.OidcClientIdAnnotation)),
+		Entry("mirror",
+			map[string]string{mirror.MirrorHostRewriteAnnotation: "not a host"},
+			mirror.InvalidHostRewriteErr("not a host")),
+	)
+
+	// the options are kept on the routes, which report the conflict
+	DescribeTable("rejects the annotations alongside the options of the RouteOption they configure",
+		func(annotations map[string]string, options *v1.RouteOptions, expectedErr error) {
+			Expect(validateWithOptions(annotations, nil)).To(Succeed())
+			Expect(validateWithOptions(annotations, options)).To(MatchError(expectedErr.Error()))
+		},
+		Entry("bodylimit",
+			map[string]string{bodylimit.MaxRequestBytesAnnotation: "1Mi"},
+			&v1.RouteOptions{BufferPerRoute: &buffer.BufferPerRoute{
+				Override: &buffer.BufferPerRoute_Disabled{Disabled: true},
+			}},
+			bodylimit.ConflictingBufferPerRouteErr),
+		Entry("bodylimit buffering",
+			map[string]string{bodylimit.BufferingAnnotation: bodylimit.BufferingOff},
+			&v1.RouteOptions{BufferPerRoute: &buffer.BufferPerRoute{
+				Override: &buffer.BufferPerRoute_Buffer{Buffer: &buffer.Buffer{}},
+			}},
+			bodylimit.ConflictingBufferPerRouteErr),
+		Entry("hostrewrite",
+			map[string]string{hostrewrite.HostRewriteFromBackendAnnotation: "true"},
+			&v1.RouteOptions{HostRewriteType: &v1.RouteOptions_HostRewrite{HostRewrite: "literal.example.com"}},
+			hostrewrite.ConflictingHostRewriteErr),
+		Entry("extproc",
+			map[string]string{extproc.ExtProcAnnotation: extproc.Disabled},
+			&v1.RouteOptions{ExtProc: &extprocv1.RouteSettings{
+				Override: &extprocv1.RouteSettings_Disabled{Disabled: &wrappers.BoolValue{Value: true}},
+			}},
+			extproc.ConflictingExtProcErr),
+		Entry("jwt",
+			map[string]string{
+				jwt.JwtIssuerAnnotation: "https://issuer.example.com",
+				jwt.JwtJwksAnnotation:   `{"keys":[{"kty":"RSA","kid":"key","n":"xyz","e":"AQAB"}]}`,
+			},
+			&v1.RouteOptions{JwtConfig: &v1.RouteOptions_JwtStaged{
+				JwtStaged: &jwtv1.JwtStagedRouteExtension{
+					BeforeExtAuth: &jwtv1.RouteExtension{Disable: true},
+				},
+			}},
+			jwt.ConflictingJwtErr),
+		Entry("ratelimit",
+			map[string]string{ratelimit.RateLimitDescriptorsAnnotation: "remote-address"},
+			&v1.RouteOptions{RateLimitConfigType: &v1.RouteOptions_RateLimitConfigs{
+				RateLimitConfigs: &ratelimitv1.RateLimitConfigRefs{},
+			}},
+			ratelimit.ConflictingRateLimitErr),
+		Entry("tracing",
+			map[string]string{tracing.TracingSamplePercentageAnnotation: "50"},
+			&v1.RouteOptions{Tracing: &tracingv1.RouteTracingSettings{RouteDescriptor: "checkout"}},
+			tracing.ConflictingTracingErr),
+		Entry("upgrades",
+			map[string]string{upgrades.UpgradesAnnotation: "websocket"},
+			&v1.RouteOptions{Upgrades: []*protocol_upgrade.ProtocolUpgradeConfig{{
+				UpgradeType: &protocol_upgrade.ProtocolUpgradeConfig_Connect{
+					Connect: &protocol_upgrade.ProtocolUpgradeConfig_ProtocolUpgradeSpec{},
+				},
+			}}},
+			upgrades.ConflictingUpgradesErr),
+		Entry("streamduration",
+			map[string]string{streamduration.MaxStreamDurationAnnotation: "5m"},
+			&v1.RouteOptions{MaxStreamDuration: &v1.RouteOptions_MaxStreamDuration{
+				MaxStreamDuration: durationpb.New(time.Minute),
+			}},
+			streamduration.ConflictingMaxStreamDurationErr),
+	)
+})
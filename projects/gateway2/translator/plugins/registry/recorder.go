@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"context"
+	"path"
+	"reflect"
+	"time"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+)
+
+// Phase is the translation phase a plugin is applied in
+type Phase string
+
+const (
+	RoutePhase           Phase = "route"
+	ListenerPhase        Phase = "listener"
+	BackendPhase         Phase = "backend"
+	PostTranslationPhase Phase = "postTranslation"
+)
+
+// Recorder observes each application of the plugins of a PluginRegistry, e.g. to export their duration and error
+// counts as metrics
+type Recorder interface {
+	// RecordPlugin is called after the named plugin was applied in the phase, with the error it returned
+	RecordPlugin(name string, phase Phase, duration time.Duration, err error)
+}
+
+// PluginName returns the Name of the plugin if it implements NamedPlugin, the name of its package otherwise
+func PluginName(plugin plugins.Plugin) string {
+	if named, ok := plugin.(plugins.NamedPlugin); ok {
+		return named.Name()
+	}
+	t := reflect.TypeOf(plugin)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.PkgPath() == "" {
+		return t.String()
+	}
+	return path.Base(t.PkgPath())
+}
+
+type recordedPlugin struct {
+	name     string
+	recorder Recorder
+}
+
+func newRecordedPlugin(plugin plugins.Plugin, recorder Recorder) recordedPlugin {
+	return recordedPlugin{
+		name:     PluginName(plugin),
+		recorder: recorder,
+	}
+}
+
+func (r *recordedPlugin) record(phase Phase, apply func() error) error {
+	start := time.Now()
+	err := apply()
+	r.recorder.RecordPlugin(r.name, phase, time.Since(start), err)
+	return err
+}
+
+type recordedRoutePlugin struct {
+	recordedPlugin
+	plugin plugins.RoutePlugin
+}
+
+func (r *recordedRoutePlugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	return r.record(RoutePhase, func() error {
+		return r.plugin.ApplyRoutePlugin(ctx, routeCtx, outputRoute)
+	})
+}
+
+type recordedListenerPlugin struct {
+	recordedPlugin
+	plugin plugins.ListenerPlugin
+}
+
+func (r *recordedListenerPlugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	return r.record(ListenerPhase, func() error {
+		return r.plugin.ApplyListenerPlugin(ctx, listenerCtx, outputListener)
+	})
+}
+
+type recordedBackendPlugin struct {
+	recordedPlugin
+	plugin plugins.BackendPlugin
+}
+
+func (r *recordedBackendPlugin) ApplyBackendPlugin(
+	ctx context.Context,
+	backendCtx *plugins.BackendContext,
+	outputUpstream *v1.Upstream,
+) error {
+	return r.record(BackendPhase, func() error {
+		return r.plugin.ApplyBackendPlugin(ctx, backendCtx, outputUpstream)
+	})
+}
+
+type recordedPostTranslationPlugin struct {
+	recordedPlugin
+	plugin plugins.PostTranslationPlugin
+}
+
+func (r *recordedPostTranslationPlugin) ApplyPostTranslationPlugin(
+	ctx context.Context,
+	postTranslationContext *plugins.PostTranslationContext,
+) error {
+	return r.record(PostTranslationPhase, func() error {
+		return r.plugin.ApplyPostTranslationPlugin(ctx, postTranslationContext)
+	})
+}
@@ -0,0 +1,122 @@
+package registry_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	errors "github.com/rotisserie/eris"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/headermodifier"
+	. "github.com/solo-io/gloo/projects/gateway2/translator/plugins/registry"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+)
+
+var _ = Describe("Recorder", func() {
+	var (
+		recorder    *fakeRecorder
+		failingErr  = errors.New("failed")
+		allPlugins  []plugins.Plugin
+		routePlugin = &fakeRoutePlugin{}
+	)
+
+	BeforeEach(func() {
+		recorder = &fakeRecorder{}
+		allPlugins = []plugins.Plugin{
+			routePlugin,
+			&fakeNamedPlugin{err: failingErr},
+			&fakePostTranslationPlugin{},
+		}
+	})
+
+	It("records each application of the plugins", func() {
+		pluginRegistry, err := NewRecordedPluginRegistry(allPlugins, recorder)
+		Expect(err).NotTo(HaveOccurred())
+		ctx := context.Background()
+
+		Expect(pluginRegistry.GetRoutePlugins()).To(HaveLen(2))
+		for _, plugin := range pluginRegistry.GetRoutePlugins() {
+			_ = plugin.ApplyRoutePlugin(ctx, &plugins.RouteContext{}, &v1.Route{})
+		}
+		for _, plugin := range pluginRegistry.GetListenerPlugins() {
+			_ = plugin.ApplyListenerPlugin(ctx, &plugins.ListenerContext{}, &v1.Listener{})
+		}
+		for _, plugin := range pluginRegistry.GetPostTranslationPlugins() {
+			_ = plugin.ApplyPostTranslationPlugin(ctx, &plugins.PostTranslationContext{})
+		}
+
+		Expect(recorder.samples).To(Equal([]sample{
+			{name: "registry_test", phase: RoutePhase},
+			{name: "named", phase: RoutePhase, err: failingErr},
+			{name: "named", phase: ListenerPhase, err: failingErr},
+			{name: "registry_test", phase: PostTranslationPhase},
+		}))
+		Expect(routePlugin.applied).To(BeTrue())
+	})
+
+	It("returns the errors of the plugins", func() {
+		pluginRegistry, err := NewRecordedPluginRegistry(allPlugins, recorder)
+		Expect(err).NotTo(HaveOccurred())
+		err = pluginRegistry.GetListenerPlugins()[0].ApplyListenerPlugin(context.Background(), &plugins.ListenerContext{}, &v1.Listener{})
+		Expect(err).To(MatchError(failingErr))
+	})
+
+	It("does not instrument the plugins without a recorder", func() {
+		pluginRegistry, err := NewPluginRegistry(allPlugins)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pluginRegistry.GetRoutePlugins()[0]).To(BeIdenticalTo(routePlugin))
+	})
+
+	It("names plugins after their package", func() {
+		Expect(PluginName(headermodifier.NewPlugin(nil))).To(Equal("headermodifier"))
+		Expect(PluginName(&fakeNamedPlugin{})).To(Equal("named"))
+	})
+})
+
+type sample struct {
+	name  string
+	phase Phase
+	err   error
+}
+
+type fakeRecorder struct {
+	samples []sample
+}
+
+func (r *fakeRecorder) RecordPlugin(name string, phase Phase, duration time.Duration, err error) {
+	Expect(duration).To(BeNumerically(">=", 0))
+	r.samples = append(r.samples, sample{name, phase, err})
+}
+
+type fakeRoutePlugin struct {
+	applied bool
+}
+
+func (p *fakeRoutePlugin) ApplyRoutePlugin(context.Context, *plugins.RouteContext, *v1.Route) error {
+	p.applied = true
+	return nil
+}
+
+type fakeNamedPlugin struct {
+	err error
+}
+
+func (p *fakeNamedPlugin) Name() string {
+	return "named"
+}
+
+func (p *fakeNamedPlugin) ApplyRoutePlugin(context.Context, *plugins.RouteContext, *v1.Route) error {
+	return p.err
+}
+
+func (p *fakeNamedPlugin) ApplyListenerPlugin(context.Context, *plugins.ListenerContext, *v1.Listener) error {
+	return p.err
+}
+
+type fakePostTranslationPlugin struct{}
+
+func (p *fakePostTranslationPlugin) ApplyPostTranslationPlugin(context.Context, *plugins.PostTranslationContext) error {
+	return nil
+}
@@ -0,0 +1,13 @@
+package registry_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPluginRegistry(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Plugin Registry Suite")
+}
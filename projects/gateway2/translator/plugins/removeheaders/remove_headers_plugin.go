@@ -0,0 +1,73 @@
+package removeheaders
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/headers"
+)
+
+// RemoveRequestHeadersAnnotation is set on a Gateway to a comma-separated list of request headers removed from all
+// the requests of its HTTP listeners, e.g. internal headers clients must not set. The headers are removed by all the
+// virtual hosts, in addition to the headers removed by the routes, when the request is forwarded to the backend:
+// route matching and the http filters still see them.
+const RemoveRequestHeadersAnnotation = "gateway2.solo.io/remove-request-headers"
+
+var (
+	// the token characters of RFC 9110 field names
+	headerRegex = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+	InvalidHeaderErr = func(value string) error {
+		return errors.Errorf("invalid header '%s' in annotation %s: must be a header name", value, RemoveRequestHeadersAnnotation)
+	}
+	// envoy rejects the configuration removing them
+	UnremovableHeaderErr = func(value string) error {
+		return errors.Errorf("header '%s' in annotation %s cannot be removed: the host and pseudo-headers are required", value, RemoveRequestHeadersAnnotation)
+	}
+)
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	value, ok := listenerCtx.Gateway.GetAnnotations()[RemoveRequestHeadersAnnotation]
+	if !ok {
+		return nil
+	}
+	var toRemove []string
+	for _, header := range strings.Split(value, ",") {
+		header = strings.ToLower(strings.TrimSpace(header))
+		if strings.HasPrefix(header, ":") || header == "host" {
+			return UnremovableHeaderErr(header)
+		}
+		if !headerRegex.MatchString(header) {
+			return InvalidHeaderErr(header)
+		}
+		toRemove = append(toRemove, header)
+	}
+
+	for _, vhost := range outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts() {
+		if vhost.GetOptions() == nil {
+			vhost.Options = &v1.VirtualHostOptions{}
+		}
+		if vhost.GetOptions().GetHeaderManipulation() == nil {
+			vhost.GetOptions().HeaderManipulation = &headers.HeaderManipulation{}
+		}
+		headerManipulation := vhost.GetOptions().GetHeaderManipulation()
+		headerManipulation.RequestHeadersToRemove = append(headerManipulation.GetRequestHeadersToRemove(), toRemove...)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package removeheaders
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/headers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("RemoveHeadersPlugin", func() {
+	var outputListener *v1.Listener
+
+	BeforeEach(func() {
+		outputListener = &v1.Listener{
+			Name: "http",
+			ListenerType: &v1.Listener_AggregateListener{
+				AggregateListener: &v1.AggregateListener{
+					HttpResources: &v1.AggregateListener_HttpResources{
+						VirtualHosts: map[string]*v1.VirtualHost{
+							"http~example_com": {Name: "http~example_com"},
+							"http~foo_com": {
+								Name: "http~foo_com",
+								Options: &v1.VirtualHostOptions{
+									HeaderManipulation: &headers.HeaderManipulation{
+										RequestHeadersToRemove: []string{"x-foo"},
+									},
+								},
+							},
+						},
+					},
+					HttpFilterChains: []*v1.AggregateListener_HttpFilterChain{{
+						VirtualHostRefs: []string{"http~example_com", "http~foo_com"},
+					}},
+				},
+			},
+		}
+	})
+
+	apply := func(annotations map[string]string) error {
+		listenerCtx := &plugins.ListenerContext{
+			Gateway: &gwv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+			},
+		}
+		return NewPlugin().ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+	}
+
+	removedHeaders := func(vhost string) []string {
+		return outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts()[vhost].
+			GetOptions().GetHeaderManipulation().GetRequestHeadersToRemove()
+	}
+
+	It("removes the headers in all virtual hosts", func() {
+		Expect(apply(map[string]string{
+			RemoveRequestHeadersAnnotation: "X-Internal-User, x-internal-tenant",
+		})).To(Succeed())
+		Expect(removedHeaders("http~example_com")).To(Equal([]string{"x-internal-user", "x-internal-tenant"}))
+		Expect(removedHeaders("http~foo_com")).To(Equal([]string{"x-foo", "x-internal-user", "x-internal-tenant"}))
+	})
+
+	It("does nothing without the annotation", func() {
+		Expect(apply(nil)).To(Succeed())
+		Expect(removedHeaders("http~example_com")).To(BeEmpty())
+		Expect(removedHeaders("http~foo_com")).To(Equal([]string{"x-foo"}))
+	})
+
+	DescribeTable("rejects invalid headers",
+		func(value string, expectedErr error) {
+			Expect(apply(map[string]string{
+				RemoveRequestHeadersAnnotation: value,
+			})).To(MatchError(expectedErr.Error()))
+			Expect(removedHeaders("http~example_com")).To(BeEmpty())
+		},
+		Entry("empty header", "x-internal,", InvalidHeaderErr("")),
+		Entry("header with a space", "x internal", InvalidHeaderErr("x internal")),
+		Entry("pseudo-header", ":authority", UnremovableHeaderErr(":authority")),
+		Entry("host header", "Host", UnremovableHeaderErr("host")),
+	)
+})
@@ -0,0 +1,13 @@
+package removeheaders
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRemoveHeaders(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RemoveHeaders Plugin Suite")
+}
@@ -0,0 +1,72 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/hcm"
+)
+
+// RequestIdAnnotation is set on a Gateway to configure how the x-request-id header of the requests of all of its
+// HTTP listeners is generated, to correlate the logs and traces of a request across services. It is one of:
+//   - generate-if-absent: keep the request ID sent by the client, and generate one for requests without it
+//   - always-generate: generate a new request ID for every request, overwriting the one sent by the client
+//   - disabled: do not generate request IDs
+const RequestIdAnnotation = "gateway2.solo.io/request-id"
+
+const (
+	GenerateIfAbsentMode = "generate-if-absent"
+	AlwaysGenerateMode   = "always-generate"
+	DisabledMode         = "disabled"
+)
+
+var UnknownModeErr = func(mode string) error {
+	return errors.Errorf("unknown mode '%s' for annotation %s: must be one of %s, %s or %s",
+		mode, RequestIdAnnotation, GenerateIfAbsentMode, AlwaysGenerateMode, DisabledMode)
+}
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	mode, ok := listenerCtx.Gateway.GetAnnotations()[RequestIdAnnotation]
+	if !ok {
+		return nil
+	}
+
+	var generate, preserve bool
+	switch mode {
+	case GenerateIfAbsentMode:
+		generate, preserve = true, true
+	case AlwaysGenerateMode:
+		generate, preserve = true, false
+	case DisabledMode:
+		generate, preserve = false, true
+	default:
+		return UnknownModeErr(mode)
+	}
+
+	for _, options := range utils.GetHttpListenerOptions(outputListener) {
+		if options.GetHttpConnectionManagerSettings() == nil {
+			options.HttpConnectionManagerSettings = &hcm.HttpConnectionManagerSettings{}
+		}
+		settings := options.GetHttpConnectionManagerSettings()
+		settings.GenerateRequestId = &wrappers.BoolValue{Value: generate}
+		// envoy only overwrites the request ID of the requests it considers external (see the xff plugin)
+		settings.PreserveExternalRequestId = &wrappers.BoolValue{Value: preserve}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package requestid
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/hcm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("RequestIdPlugin", func() {
+	var outputListener *v1.Listener
+
+	BeforeEach(func() {
+		outputListener = &v1.Listener{
+			Name: "http",
+			ListenerType: &v1.Listener_AggregateListener{
+				AggregateListener: &v1.AggregateListener{
+					HttpResources: &v1.AggregateListener_HttpResources{},
+					HttpFilterChains: []*v1.AggregateListener_HttpFilterChain{{
+						VirtualHostRefs: []string{"vhost"},
+					}},
+				},
+			},
+		}
+	})
+
+	apply := func(annotations map[string]string) error {
+		listenerCtx := &plugins.ListenerContext{
+			Gateway: &gwv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+			},
+		}
+		return NewPlugin().ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+	}
+
+	hcmSettings := func() *hcm.HttpConnectionManagerSettings {
+		aggregateListener := outputListener.GetAggregateListener()
+		ref := aggregateListener.GetHttpFilterChains()[0].GetHttpOptionsRef()
+		return aggregateListener.GetHttpResources().GetHttpOptions()[ref].GetHttpConnectionManagerSettings()
+	}
+
+	DescribeTable("configures the generation of request IDs",
+		func(mode string, expected *hcm.HttpConnectionManagerSettings) {
+			Expect(apply(map[string]string{RequestIdAnnotation: mode})).To(Succeed())
+			Expect(proto.Equal(hcmSettings(), expected)).To(BeTrue())
+		},
+		Entry("generate if absent", GenerateIfAbsentMode, &hcm.HttpConnectionManagerSettings{
+			GenerateRequestId:         &wrappers.BoolValue{Value: true},
+			PreserveExternalRequestId: &wrappers.BoolValue{Value: true},
+		}),
+		Entry("always generate", AlwaysGenerateMode, &hcm.HttpConnectionManagerSettings{
+			GenerateRequestId:         &wrappers.BoolValue{Value: true},
+			PreserveExternalRequestId: &wrappers.BoolValue{Value: false},
+		}),
+		Entry("disabled", DisabledMode, &hcm.HttpConnectionManagerSettings{
+			GenerateRequestId:         &wrappers.BoolValue{Value: false},
+			PreserveExternalRequestId: &wrappers.BoolValue{Value: true},
+		}),
+	)
+
+	It("keeps the other connection manager settings", func() {
+		aggregateListener := outputListener.GetAggregateListener()
+		aggregateListener.GetHttpFilterChains()[0].HttpOptionsRef = "http"
+		aggregateListener.GetHttpResources().HttpOptions = map[string]*v1.HttpListenerOptions{
+			"http": {
+				HttpConnectionManagerSettings: &hcm.HttpConnectionManagerSettings{
+					IdleTimeout: durationpb.New(time.Minute),
+				},
+			},
+		}
+		Expect(apply(map[string]string{RequestIdAnnotation: GenerateIfAbsentMode})).To(Succeed())
+		Expect(proto.Equal(hcmSettings(), &hcm.HttpConnectionManagerSettings{
+			IdleTimeout:               durationpb.New(time.Minute),
+			GenerateRequestId:         &wrappers.BoolValue{Value: true},
+			PreserveExternalRequestId: &wrappers.BoolValue{Value: true},
+		})).To(BeTrue())
+	})
+
+	It("does nothing without the annotation", func() {
+		Expect(apply(nil)).To(Succeed())
+		Expect(outputListener.GetAggregateListener().GetHttpResources().GetHttpOptions()).To(BeEmpty())
+	})
+
+	It("rejects unknown modes", func() {
+		Expect(apply(map[string]string{RequestIdAnnotation: "sometimes"})).To(MatchError(UnknownModeErr("sometimes").Error()))
+		Expect(outputListener.GetAggregateListener().GetHttpResources().GetHttpOptions()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,13 @@
+package requestid
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRequestId(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RequestId Plugin Suite")
+}
@@ -0,0 +1,169 @@
+package retrypolicy
+
+import (
+	"context"
+	"fmt"
+
+	envoy_config_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_config_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	v1snap "github.com/solo-io/gloo/projects/gloo/pkg/api/v1/gloosnapshot"
+	"github.com/solo-io/gloo/projects/gloo/pkg/syncer/sanitizer"
+	"github.com/solo-io/gloo/projects/gloo/pkg/translator"
+	"github.com/solo-io/gloo/projects/gloo/pkg/xds"
+	"github.com/solo-io/go-utils/contextutils"
+	envoycache "github.com/solo-io/solo-kit/pkg/api/v1/control-plane/cache"
+	"github.com/solo-io/solo-kit/pkg/api/v1/control-plane/resource"
+	"github.com/solo-io/solo-kit/pkg/api/v1/control-plane/types"
+	"github.com/solo-io/solo-kit/pkg/api/v2/reporter"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+var _ sanitizer.XdsSanitizer = &retryBudgetSanitizer{}
+
+// retryBudgetSanitizer sets the retry budget of the envoy routes marked by the plugin on the circuit breakers of the
+// envoy clusters they route to, and removes the marker. The snapshot of a proxy is translated for a single Gateway,
+// so all the marked routes carry the same budget.
+type retryBudgetSanitizer struct{}
+
+func NewSanitizer() *retryBudgetSanitizer {
+	return &retryBudgetSanitizer{}
+}
+
+func (s *retryBudgetSanitizer) SanitizeSnapshot(
+	ctx context.Context,
+	glooSnapshot *v1snap.ApiSnapshot,
+	xdsSnapshot envoycache.Snapshot,
+	reports reporter.ResourceReports,
+) envoycache.Snapshot {
+	var (
+		routeConfigs []*envoy_config_route_v3.RouteConfiguration
+		marked       bool
+	)
+	budgets := map[string]*envoy_config_cluster_v3.CircuitBreakers_Thresholds_RetryBudget{}
+	for _, item := range xdsSnapshot.GetResources(types.RouteTypeV3).Items {
+		routeConfig, ok := item.ResourceProto().(*envoy_config_route_v3.RouteConfiguration)
+		if !ok {
+			contextutils.LoggerFrom(ctx).DPanicf("xds snapshot resources of type RouteTypeV3 were not "+
+				"converted to *envoy_config_route_v3.RouteConfiguration, instead found %T", item.ResourceProto())
+			return xdsSnapshot
+		}
+		if hasBudgetedRoutes(routeConfig) {
+			// the snapshot resources are not modified, their version was computed from them
+			routeConfig = proto.Clone(routeConfig).(*envoy_config_route_v3.RouteConfiguration)
+			collectRetryBudgets(routeConfig, budgets)
+			marked = true
+		}
+		routeConfigs = append(routeConfigs, routeConfig)
+	}
+	if !marked {
+		return xdsSnapshot
+	}
+
+	var clusters []envoycache.Resource
+	for _, item := range xdsSnapshot.GetResources(types.ClusterTypeV3).Items {
+		cluster, ok := item.ResourceProto().(*envoy_config_cluster_v3.Cluster)
+		if !ok {
+			contextutils.LoggerFrom(ctx).DPanicf("xds snapshot resources of type ClusterTypeV3 were not "+
+				"converted to *envoy_config_cluster_v3.Cluster, instead found %T", item.ResourceProto())
+			return xdsSnapshot
+		}
+		if budget, ok := budgets[cluster.GetName()]; ok {
+			cluster = proto.Clone(cluster).(*envoy_config_cluster_v3.Cluster)
+			setRetryBudget(cluster, budget)
+			item = resource.NewEnvoyResource(cluster)
+		}
+		clusters = append(clusters, item)
+	}
+
+	return xds.NewSnapshotFromResources(
+		xdsSnapshot.GetResources(types.EndpointTypeV3),
+		makeCdsResources(ctx, clusters),
+		translator.MakeRdsResources(routeConfigs),
+		xdsSnapshot.GetResources(types.ListenerTypeV3),
+	)
+}
+
+func hasBudgetedRoutes(routeConfig *envoy_config_route_v3.RouteConfiguration) bool {
+	for _, vh := range routeConfig.GetVirtualHosts() {
+		for _, route := range vh.GetRoutes() {
+			if _, ok := route.GetMetadata().GetFilterMetadata()[RetryBudgetMetadataNamespace]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// collectRetryBudgets removes the marker of the routes, and records their budget for the clusters they route to
+func collectRetryBudgets(
+	routeConfig *envoy_config_route_v3.RouteConfiguration,
+	budgets map[string]*envoy_config_cluster_v3.CircuitBreakers_Thresholds_RetryBudget,
+) {
+	for _, vh := range routeConfig.GetVirtualHosts() {
+		for _, route := range vh.GetRoutes() {
+			marker, ok := route.GetMetadata().GetFilterMetadata()[RetryBudgetMetadataNamespace]
+			if !ok {
+				continue
+			}
+			delete(route.GetMetadata().GetFilterMetadata(), RetryBudgetMetadataNamespace)
+			if len(route.GetMetadata().GetFilterMetadata()) == 0 {
+				route.Metadata = nil
+			}
+			// routes replaced by a direct response, e.g. by a sanitizer, do not route to any cluster
+			action := route.GetRoute()
+			if action == nil {
+				continue
+			}
+			budget := toRetryBudget(marker)
+			if cluster := action.GetCluster(); cluster != "" {
+				budgets[cluster] = budget
+			}
+			for _, weighted := range action.GetWeightedClusters().GetClusters() {
+				budgets[weighted.GetName()] = budget
+			}
+		}
+	}
+}
+
+func toRetryBudget(marker *structpb.Struct) *envoy_config_cluster_v3.CircuitBreakers_Thresholds_RetryBudget {
+	budget := &envoy_config_cluster_v3.CircuitBreakers_Thresholds_RetryBudget{}
+	if percent, ok := marker.GetFields()[budgetPercentField]; ok {
+		budget.BudgetPercent = &envoy_type_v3.Percent{Value: percent.GetNumberValue()}
+	}
+	if minConcurrency, ok := marker.GetFields()[minRetryConcurrencyField]; ok {
+		budget.MinRetryConcurrency = wrapperspb.UInt32(uint32(minConcurrency.GetNumberValue()))
+	}
+	return budget
+}
+
+// setRetryBudget sets the budget on the default priority thresholds of the circuit breakers of the cluster, keeping
+// the other thresholds set by the circuit breaker of the upstream. Envoy ignores their max retries once the budget is set.
+func setRetryBudget(cluster *envoy_config_cluster_v3.Cluster, budget *envoy_config_cluster_v3.CircuitBreakers_Thresholds_RetryBudget) {
+	if cluster.GetCircuitBreakers() == nil {
+		cluster.CircuitBreakers = &envoy_config_cluster_v3.CircuitBreakers{}
+	}
+	for _, thresholds := range cluster.GetCircuitBreakers().GetThresholds() {
+		if thresholds.GetPriority() == envoy_config_core_v3.RoutingPriority_DEFAULT {
+			thresholds.RetryBudget = budget
+			return
+		}
+	}
+	cluster.GetCircuitBreakers().Thresholds = append(cluster.GetCircuitBreakers().GetThresholds(),
+		&envoy_config_cluster_v3.CircuitBreakers_Thresholds{
+			Priority:    envoy_config_core_v3.RoutingPriority_DEFAULT,
+			RetryBudget: budget,
+		})
+}
+
+func makeCdsResources(ctx context.Context, clusters []envoycache.Resource) envoycache.Resources {
+	clustersVersion, err := translator.EnvoyCacheResourcesListToFnvHash(clusters)
+	if err != nil {
+		contextutils.LoggerFrom(ctx).DPanic(fmt.Sprintf("error trying to hash clusters: %v", err))
+		return envoycache.NewResources("clusters-hashErr", clusters)
+	}
+	return envoycache.NewResources(fmt.Sprintf("%v", clustersVersion), clusters)
+}
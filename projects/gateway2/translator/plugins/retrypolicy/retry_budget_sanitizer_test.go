@@ -0,0 +1,175 @@
+package retrypolicy
+
+import (
+	"context"
+
+	envoy_config_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_config_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/solo-io/gloo/projects/gloo/pkg/xds"
+	envoycache "github.com/solo-io/solo-kit/pkg/api/v1/control-plane/cache"
+	"github.com/solo-io/solo-kit/pkg/api/v1/control-plane/resource"
+	"github.com/solo-io/solo-kit/pkg/api/v1/control-plane/types"
+)
+
+var _ = Describe("RetryBudgetSanitizer", func() {
+	budgetMarker := &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"budget_percent":        structpb.NewNumberValue(25),
+			"min_retry_concurrency": structpb.NewNumberValue(5),
+		},
+	}
+	expectedBudget := &envoy_config_cluster_v3.CircuitBreakers_Thresholds_RetryBudget{
+		BudgetPercent:       &envoy_type_v3.Percent{Value: 25},
+		MinRetryConcurrency: wrapperspb.UInt32(5),
+	}
+
+	route := func(name, cluster string, marked bool) *envoy_config_route_v3.Route {
+		r := &envoy_config_route_v3.Route{
+			Name: name,
+			Action: &envoy_config_route_v3.Route_Route{
+				Route: &envoy_config_route_v3.RouteAction{
+					ClusterSpecifier: &envoy_config_route_v3.RouteAction_Cluster{Cluster: cluster},
+				},
+			},
+		}
+		if marked {
+			r.Metadata = &envoy_config_core_v3.Metadata{
+				FilterMetadata: map[string]*structpb.Struct{RetryBudgetMetadataNamespace: budgetMarker},
+			}
+		}
+		return r
+	}
+
+	snapshot := func(clusters []*envoy_config_cluster_v3.Cluster, routes ...*envoy_config_route_v3.Route) envoycache.Snapshot {
+		var clusterResources []envoycache.Resource
+		for _, cluster := range clusters {
+			clusterResources = append(clusterResources, resource.NewEnvoyResource(cluster))
+		}
+		return xds.NewSnapshotFromResources(
+			envoycache.NewResources("", nil),
+			envoycache.NewResources("clusters", clusterResources),
+			envoycache.NewResources("routes", []envoycache.Resource{
+				resource.NewEnvoyResource(&envoy_config_route_v3.RouteConfiguration{
+					Name: "listener-8080-routes",
+					VirtualHosts: []*envoy_config_route_v3.VirtualHost{{
+						Name:    "http~example_com",
+						Domains: []string{"example.com"},
+						Routes:  routes,
+					}},
+				}),
+			}),
+			envoycache.NewResources("", nil),
+		)
+	}
+
+	sanitizedCluster := func(snap envoycache.Snapshot, name string) *envoy_config_cluster_v3.Cluster {
+		return snap.GetResources(types.ClusterTypeV3).Items[name].ResourceProto().(*envoy_config_cluster_v3.Cluster)
+	}
+
+	sanitizedRoutes := func(snap envoycache.Snapshot) []*envoy_config_route_v3.Route {
+		routeConfig := snap.GetResources(types.RouteTypeV3).Items["listener-8080-routes"].ResourceProto().(*envoy_config_route_v3.RouteConfiguration)
+		return routeConfig.GetVirtualHosts()[0].GetRoutes()
+	}
+
+	It("sets the retry budget on the clusters of the marked routes", func() {
+		in := snapshot(
+			[]*envoy_config_cluster_v3.Cluster{{Name: "api"}, {Name: "web"}, {Name: "other"}},
+			route("api", "api", true),
+			&envoy_config_route_v3.Route{
+				Name: "web",
+				Action: &envoy_config_route_v3.Route_Route{
+					Route: &envoy_config_route_v3.RouteAction{
+						ClusterSpecifier: &envoy_config_route_v3.RouteAction_WeightedClusters{
+							WeightedClusters: &envoy_config_route_v3.WeightedCluster{
+								Clusters: []*envoy_config_route_v3.WeightedCluster_ClusterWeight{{Name: "web"}},
+							},
+						},
+					},
+				},
+				Metadata: &envoy_config_core_v3.Metadata{
+					FilterMetadata: map[string]*structpb.Struct{RetryBudgetMetadataNamespace: budgetMarker},
+				},
+			},
+			route("other", "other", false),
+		)
+		out := NewSanitizer().SanitizeSnapshot(context.Background(), nil, in, nil)
+
+		for _, name := range []string{"api", "web"} {
+			Expect(proto.Equal(sanitizedCluster(out, name), &envoy_config_cluster_v3.Cluster{
+				Name: name,
+				CircuitBreakers: &envoy_config_cluster_v3.CircuitBreakers{
+					Thresholds: []*envoy_config_cluster_v3.CircuitBreakers_Thresholds{{
+						Priority:    envoy_config_core_v3.RoutingPriority_DEFAULT,
+						RetryBudget: expectedBudget,
+					}},
+				},
+			})).To(BeTrue())
+		}
+		Expect(sanitizedCluster(out, "other").GetCircuitBreakers()).To(BeNil())
+		for _, r := range sanitizedRoutes(out) {
+			Expect(r.GetMetadata()).To(BeNil())
+		}
+	})
+
+	It("keeps the circuit breaker of the upstream", func() {
+		in := snapshot(
+			[]*envoy_config_cluster_v3.Cluster{{
+				Name: "api",
+				CircuitBreakers: &envoy_config_cluster_v3.CircuitBreakers{
+					Thresholds: []*envoy_config_cluster_v3.CircuitBreakers_Thresholds{{
+						MaxConnections: wrapperspb.UInt32(100),
+						MaxRetries:     wrapperspb.UInt32(10),
+					}},
+				},
+			}},
+			route("api", "api", true),
+		)
+		out := NewSanitizer().SanitizeSnapshot(context.Background(), nil, in, nil)
+
+		thresholds := sanitizedCluster(out, "api").GetCircuitBreakers().GetThresholds()
+		Expect(thresholds).To(HaveLen(1))
+		Expect(thresholds[0].GetMaxConnections().GetValue()).To(Equal(uint32(100)))
+		Expect(proto.Equal(thresholds[0].GetRetryBudget(), expectedBudget)).To(BeTrue())
+	})
+
+	It("does not modify the input snapshot", func() {
+		in := snapshot([]*envoy_config_cluster_v3.Cluster{{Name: "api"}}, route("api", "api", true))
+		clustersVersion := in.GetResources(types.ClusterTypeV3).Version
+		routesVersion := in.GetResources(types.RouteTypeV3).Version
+		out := NewSanitizer().SanitizeSnapshot(context.Background(), nil, in, nil)
+
+		Expect(sanitizedCluster(in, "api").GetCircuitBreakers()).To(BeNil())
+		Expect(sanitizedRoutes(in)[0].GetMetadata().GetFilterMetadata()).To(HaveKey(RetryBudgetMetadataNamespace))
+		Expect(out.GetResources(types.ClusterTypeV3).Version).NotTo(Equal(clustersVersion))
+		Expect(out.GetResources(types.RouteTypeV3).Version).NotTo(Equal(routesVersion))
+	})
+
+	It("returns the snapshot as is without marked routes", func() {
+		in := snapshot([]*envoy_config_cluster_v3.Cluster{{Name: "api"}}, route("api", "api", false))
+		Expect(NewSanitizer().SanitizeSnapshot(context.Background(), nil, in, nil)).To(BeIdenticalTo(in))
+	})
+
+	It("removes the marker of routes without route action", func() {
+		direct := &envoy_config_route_v3.Route{
+			Name: "direct",
+			Action: &envoy_config_route_v3.Route_DirectResponse{
+				DirectResponse: &envoy_config_route_v3.DirectResponseAction{Status: 503},
+			},
+			Metadata: &envoy_config_core_v3.Metadata{
+				FilterMetadata: map[string]*structpb.Struct{RetryBudgetMetadataNamespace: budgetMarker},
+			},
+		}
+		out := NewSanitizer().SanitizeSnapshot(context.Background(), nil, snapshot([]*envoy_config_cluster_v3.Cluster{{Name: "api"}}, direct), nil)
+
+		Expect(sanitizedRoutes(out)[0].GetMetadata()).To(BeNil())
+		Expect(sanitizedCluster(out, "api").GetCircuitBreakers()).To(BeNil())
+	})
+})
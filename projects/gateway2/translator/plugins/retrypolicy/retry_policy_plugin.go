@@ -0,0 +1,237 @@
+package retrypolicy
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/retries"
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Annotations set on a Gateway to retry the failed requests of all the virtual hosts of its HTTP listeners.
+// Routes retrying with the retries option of a RouteOption use their own policy instead: envoy applies the retry
+// policy of a route over the one of its virtual host, so the annotations never conflict with the option.
+const (
+	// RetryOnAnnotation enables retries, and is a comma-separated list of the envoy retry conditions,
+	// e.g. "5xx,reset,connect-failure"
+	RetryOnAnnotation = "gateway2.solo.io/retry-on"
+	// NumRetriesAnnotation is the number of retries of a request, defaulting to 1
+	NumRetriesAnnotation = "gateway2.solo.io/num-retries"
+	// PerTryTimeoutAnnotation is the timeout of each try of a request, defaulting to the timeout of the route
+	PerTryTimeoutAnnotation = "gateway2.solo.io/per-try-timeout"
+	// RetriableStatusCodesAnnotation is a comma-separated list of the response status codes retried, also enabling
+	// retries. Gloo retry policies cannot list status codes, so only "502,503,504" is supported, retried with
+	// the gateway-error condition.
+	RetriableStatusCodesAnnotation = "gateway2.solo.io/retriable-status-codes"
+)
+
+// RetriableMethodsAnnotation would restrict retries to a comma-separated list of request methods, e.g. the idempotent
+// ones. Gloo retry policies cannot match requests, so it is rejected.
+const RetriableMethodsAnnotation = "gateway2.solo.io/retriable-methods"
+
+// gatewayErrorCondition retries the gatewayErrorStatusCodes responses, as well as connection failures
+const gatewayErrorCondition = "gateway-error"
+
+var gatewayErrorStatusCodes = []int{502, 503, 504}
+
+// Annotations set on a Gateway to cap the retries in flight to each backend of its HTTP listeners, so that retries
+// cannot overload a failing backend. The budget applies to the retries of all the routes of the Gateway, including
+// the ones retrying with the retries option of a RouteOption.
+const (
+	// RetryBudgetPercentAnnotation is the share of the active requests to a backend which may be retries, as a
+	// percentage, defaulting to 20
+	RetryBudgetPercentAnnotation = "gateway2.solo.io/retry-budget-percent"
+	// RetryBudgetMinConcurrencyAnnotation is the number of retries in flight to a backend allowed regardless of the
+	// active requests, defaulting to 3
+	RetryBudgetMinConcurrencyAnnotation = "gateway2.solo.io/retry-budget-min-concurrency"
+)
+
+// RetryBudgetMetadataNamespace is the envoy metadata namespace marking the routes of a Gateway with its retry budget.
+// Retry budgets are set on the circuit breakers of envoy clusters, which gloo upstreams cannot configure, so the
+// sanitizer of this package sets the budget on the envoy clusters the routes carrying the metadata route to.
+const RetryBudgetMetadataNamespace = "gateway2.solo.io/retry-budget"
+
+const (
+	budgetPercentField       = "budget_percent"
+	minRetryConcurrencyField = "min_retry_concurrency"
+)
+
+var (
+	InvalidRetryOnErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a comma-separated list of retry conditions", value, RetryOnAnnotation)
+	}
+	InvalidNumRetriesErr = errors.Errorf("annotation %s must be at least 1", NumRetriesAnnotation)
+	InvalidStatusCodeErr = func(value string) error {
+		return errors.Errorf("invalid status code '%s' in annotation %s: must be between 100 and 599", value, RetriableStatusCodesAnnotation)
+	}
+	RetriableStatusCodesUnsupportedErr = func(value string) error {
+		return errors.Errorf("value '%s' of annotation %s cannot be applied: gloo retry policies only retry the 502, 503 and 504 "+
+			"status codes together", value, RetriableStatusCodesAnnotation)
+	}
+	RetriableMethodsUnsupportedErr = errors.Errorf("annotation %s cannot be applied: gloo retry policies cannot retry only some methods",
+		RetriableMethodsAnnotation)
+	InvalidRetryBudgetPercentErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a percentage between 0 and 100", value, RetryBudgetPercentAnnotation)
+	}
+	ConflictingRetryBudgetMetadataErr = func(route string) error {
+		return errors.Errorf("the retry budget of the Gateway cannot be applied to route %s, which sets envoy metadata in namespace %s",
+			route, RetryBudgetMetadataNamespace)
+	}
+)
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	annotations := listenerCtx.Gateway.GetAnnotations()
+	policy, err := retryPolicy(annotations)
+	if err != nil {
+		return err
+	}
+	budget, err := retryBudget(annotations)
+	if err != nil {
+		return err
+	}
+	vhosts := outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts()
+	if budget != nil {
+		// checked before changing any route, so that the Gateway gets either its whole budget or none of it
+		for _, vhost := range vhosts {
+			for _, route := range vhost.GetRoutes() {
+				if _, ok := route.GetOptions().GetEnvoyMetadata()[RetryBudgetMetadataNamespace]; ok {
+					return ConflictingRetryBudgetMetadataErr(route.GetName())
+				}
+			}
+		}
+	}
+
+	for _, vhost := range vhosts {
+		if policy != nil {
+			if vhost.GetOptions() == nil {
+				vhost.Options = &v1.VirtualHostOptions{}
+			}
+			vhost.GetOptions().Retries = proto.Clone(policy).(*retries.RetryPolicy)
+		}
+		if budget == nil {
+			continue
+		}
+		for _, route := range vhost.GetRoutes() {
+			if route.GetOptions() == nil {
+				route.Options = &v1.RouteOptions{}
+			}
+			if route.GetOptions().GetEnvoyMetadata() == nil {
+				route.GetOptions().EnvoyMetadata = map[string]*structpb.Struct{}
+			}
+			route.GetOptions().GetEnvoyMetadata()[RetryBudgetMetadataNamespace] = proto.Clone(budget).(*structpb.Struct)
+		}
+	}
+	return nil
+}
+
+// retryPolicy returns the retry policy of the virtual hosts of the Gateway, or nil if it does not retry
+func retryPolicy(annotations map[string]string) (*retries.RetryPolicy, error) {
+	if _, ok := annotations[RetriableMethodsAnnotation]; ok {
+		return nil, RetriableMethodsUnsupportedErr
+	}
+	retryOn, retryOnSet := annotations[RetryOnAnnotation]
+	statusCodes, statusCodesSet := annotations[RetriableStatusCodesAnnotation]
+	if !retryOnSet && !statusCodesSet {
+		return nil, nil
+	}
+	var conditions []string
+	if retryOnSet {
+		for _, condition := range strings.Split(retryOn, ",") {
+			condition = strings.TrimSpace(condition)
+			if condition == "" {
+				return nil, InvalidRetryOnErr(retryOn)
+			}
+			conditions = append(conditions, condition)
+		}
+	}
+	if statusCodesSet {
+		if err := validateStatusCodes(statusCodes); err != nil {
+			return nil, err
+		}
+		if !slices.Contains(conditions, gatewayErrorCondition) {
+			conditions = append(conditions, gatewayErrorCondition)
+		}
+	}
+
+	numRetries, err := utils.GetUint32Annotation(annotations, NumRetriesAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	if numRetries != nil && numRetries.GetValue() == 0 {
+		return nil, InvalidNumRetriesErr
+	}
+	perTryTimeout, err := utils.GetDurationAnnotation(annotations, PerTryTimeoutAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	return &retries.RetryPolicy{
+		RetryOn:       strings.Join(conditions, ","),
+		NumRetries:    numRetries.GetValue(),
+		PerTryTimeout: perTryTimeout,
+	}, nil
+}
+
+// retryBudget returns the metadata marking the routes of the Gateway with its retry budget, or nil if it has none.
+// Unset fields are left to the defaults of envoy.
+func retryBudget(annotations map[string]string) (*structpb.Struct, error) {
+	percent, percentSet := annotations[RetryBudgetPercentAnnotation]
+	minConcurrency, err := utils.GetUint32Annotation(annotations, RetryBudgetMinConcurrencyAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	if !percentSet && minConcurrency == nil {
+		return nil, nil
+	}
+	budget := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	if percentSet {
+		value, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+		if err != nil || !(value >= 0 && value <= 100) {
+			return nil, InvalidRetryBudgetPercentErr(percent)
+		}
+		budget.GetFields()[budgetPercentField] = structpb.NewNumberValue(value)
+	}
+	if minConcurrency != nil {
+		budget.GetFields()[minRetryConcurrencyField] = structpb.NewNumberValue(float64(minConcurrency.GetValue()))
+	}
+	return budget, nil
+}
+
+// validateStatusCodes checks that the comma-separated status codes are the ones of the gateway-error condition
+func validateStatusCodes(value string) error {
+	var codes []int
+	for _, code := range strings.Split(value, ",") {
+		code = strings.TrimSpace(code)
+		i, err := strconv.Atoi(code)
+		if err != nil || i < 100 || i > 599 {
+			return InvalidStatusCodeErr(code)
+		}
+		if !slices.Contains(codes, i) {
+			codes = append(codes, i)
+		}
+	}
+	sort.Ints(codes)
+	if !slices.Equal(codes, gatewayErrorStatusCodes) {
+		return RetriableStatusCodesUnsupportedErr(value)
+	}
+	return nil
+}
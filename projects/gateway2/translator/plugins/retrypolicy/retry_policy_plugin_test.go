@@ -0,0 +1,260 @@
+package retrypolicy
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/retries"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("RetryPolicyPlugin", func() {
+	var outputListener *v1.Listener
+
+	BeforeEach(func() {
+		outputListener = &v1.Listener{
+			Name: "http",
+			ListenerType: &v1.Listener_AggregateListener{
+				AggregateListener: &v1.AggregateListener{
+					HttpResources: &v1.AggregateListener_HttpResources{
+						VirtualHosts: map[string]*v1.VirtualHost{
+							"http~example_com": {
+								Name:   "http~example_com",
+								Routes: []*v1.Route{{Name: "example-api"}, {Name: "example-web"}},
+							},
+							"http~foo_com": {
+								Name:   "http~foo_com",
+								Routes: []*v1.Route{{Name: "foo"}},
+							},
+						},
+					},
+					HttpFilterChains: []*v1.AggregateListener_HttpFilterChain{{
+						VirtualHostRefs: []string{"http~example_com", "http~foo_com"},
+					}},
+				},
+			},
+		}
+	})
+
+	apply := func(annotations map[string]string) error {
+		listenerCtx := &plugins.ListenerContext{
+			Gateway: &gwv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+			},
+		}
+		return NewPlugin().ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+	}
+
+	vhostRetries := func() []*retries.RetryPolicy {
+		var policies []*retries.RetryPolicy
+		for _, vhost := range outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts() {
+			policies = append(policies, vhost.GetOptions().GetRetries())
+		}
+		return policies
+	}
+
+	routeBudgets := func() []*structpb.Struct {
+		var budgets []*structpb.Struct
+		for _, vhost := range outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts() {
+			for _, route := range vhost.GetRoutes() {
+				budgets = append(budgets, route.GetOptions().GetEnvoyMetadata()[RetryBudgetMetadataNamespace])
+			}
+		}
+		return budgets
+	}
+
+	It("retries the requests of all virtual hosts", func() {
+		Expect(apply(map[string]string{
+			RetryOnAnnotation:       "5xx, reset",
+			NumRetriesAnnotation:    "3",
+			PerTryTimeoutAnnotation: "2s",
+		})).To(Succeed())
+		policies := vhostRetries()
+		Expect(policies).To(HaveLen(2))
+		for _, policy := range policies {
+			Expect(proto.Equal(policy, &retries.RetryPolicy{
+				RetryOn:       "5xx,reset",
+				NumRetries:    3,
+				PerTryTimeout: durationpb.New(2 * time.Second),
+			})).To(BeTrue())
+		}
+	})
+
+	It("leaves the defaults of envoy when only the conditions are set", func() {
+		Expect(apply(map[string]string{
+			RetryOnAnnotation: "connect-failure",
+		})).To(Succeed())
+		for _, policy := range vhostRetries() {
+			Expect(proto.Equal(policy, &retries.RetryPolicy{
+				RetryOn: "connect-failure",
+			})).To(BeTrue())
+		}
+	})
+
+	It("retries the gateway error status codes", func() {
+		Expect(apply(map[string]string{
+			RetriableStatusCodesAnnotation: "504, 502,503",
+			NumRetriesAnnotation:           "2",
+		})).To(Succeed())
+		for _, policy := range vhostRetries() {
+			Expect(proto.Equal(policy, &retries.RetryPolicy{
+				RetryOn:    "gateway-error",
+				NumRetries: 2,
+			})).To(BeTrue())
+		}
+	})
+
+	It("adds the gateway error status codes to the retry conditions", func() {
+		Expect(apply(map[string]string{
+			RetryOnAnnotation:              "reset,gateway-error",
+			RetriableStatusCodesAnnotation: "502,503,504",
+		})).To(Succeed())
+		for _, policy := range vhostRetries() {
+			Expect(policy.GetRetryOn()).To(Equal("reset,gateway-error"))
+		}
+		Expect(apply(map[string]string{
+			RetryOnAnnotation:              "reset",
+			RetriableStatusCodesAnnotation: "502,503,504",
+		})).To(Succeed())
+		for _, policy := range vhostRetries() {
+			Expect(policy.GetRetryOn()).To(Equal("reset,gateway-error"))
+		}
+	})
+
+	It("leaves the retries option of the routes, which takes precedence over the policy of the virtual host", func() {
+		routePolicy := &retries.RetryPolicy{RetryOn: "reset", NumRetries: 5}
+		route := outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts()["http~foo_com"].GetRoutes()[0]
+		route.Options = &v1.RouteOptions{Retries: routePolicy}
+		Expect(apply(map[string]string{
+			RetriableStatusCodesAnnotation: "502,503,504",
+		})).To(Succeed())
+		for _, policy := range vhostRetries() {
+			Expect(policy.GetRetryOn()).To(Equal(gatewayErrorCondition))
+		}
+		Expect(proto.Equal(route.GetOptions().GetRetries(), &retries.RetryPolicy{RetryOn: "reset", NumRetries: 5})).To(BeTrue())
+	})
+
+	It("does nothing without the annotation", func() {
+		Expect(apply(map[string]string{
+			NumRetriesAnnotation: "3",
+		})).To(Succeed())
+		Expect(vhostRetries()).To(HaveEach(BeNil()))
+	})
+
+	It("marks all the routes with the retry budget", func() {
+		Expect(apply(map[string]string{
+			RetryOnAnnotation:                   "5xx",
+			RetryBudgetPercentAnnotation:        "25.5",
+			RetryBudgetMinConcurrencyAnnotation: "5",
+		})).To(Succeed())
+		budgets := routeBudgets()
+		Expect(budgets).To(HaveLen(3))
+		for _, budget := range budgets {
+			Expect(proto.Equal(budget, &structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"budget_percent":        structpb.NewNumberValue(25.5),
+					"min_retry_concurrency": structpb.NewNumberValue(5),
+				},
+			})).To(BeTrue())
+		}
+		Expect(vhostRetries()).To(HaveEach(Not(BeNil())))
+	})
+
+	It("caps the retries of the routes without retrying the virtual hosts", func() {
+		Expect(apply(map[string]string{
+			RetryBudgetMinConcurrencyAnnotation: "10",
+		})).To(Succeed())
+		for _, budget := range routeBudgets() {
+			// the budget percent is left to the default of envoy
+			Expect(proto.Equal(budget, &structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"min_retry_concurrency": structpb.NewNumberValue(10),
+				},
+			})).To(BeTrue())
+		}
+		Expect(vhostRetries()).To(HaveEach(BeNil()))
+	})
+
+	It("keeps the other envoy metadata of the routes", func() {
+		team := &structpb.Struct{Fields: map[string]*structpb.Value{"team": structpb.NewStringValue("blue")}}
+		route := outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts()["http~foo_com"].GetRoutes()[0]
+		route.Options = &v1.RouteOptions{EnvoyMetadata: map[string]*structpb.Struct{"example.com": team}}
+
+		Expect(apply(map[string]string{
+			RetryBudgetPercentAnnotation: "50",
+		})).To(Succeed())
+		Expect(route.GetOptions().GetEnvoyMetadata()).To(HaveLen(2))
+		Expect(route.GetOptions().GetEnvoyMetadata()).To(HaveKey(RetryBudgetMetadataNamespace))
+		Expect(proto.Equal(route.GetOptions().GetEnvoyMetadata()["example.com"], team)).To(BeTrue())
+	})
+
+	It("rejects the retry budget of the routes setting its metadata", func() {
+		route := outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts()["http~foo_com"].GetRoutes()[0]
+		route.Options = &v1.RouteOptions{EnvoyMetadata: map[string]*structpb.Struct{RetryBudgetMetadataNamespace: {}}}
+
+		err := apply(map[string]string{
+			RetryBudgetPercentAnnotation: "50",
+		})
+		Expect(err).To(MatchError(ConflictingRetryBudgetMetadataErr("foo").Error()))
+		for _, vhost := range outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts() {
+			for _, r := range vhost.GetRoutes() {
+				if r != route {
+					Expect(r.GetOptions()).To(BeNil())
+				}
+			}
+		}
+	})
+
+	DescribeTable("rejects invalid retry policies",
+		func(annotations map[string]string, expectedErr string) {
+			err := apply(annotations)
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			Expect(vhostRetries()).To(HaveEach(BeNil()))
+			Expect(routeBudgets()).To(HaveEach(BeNil()))
+		},
+		Entry("empty condition",
+			map[string]string{RetryOnAnnotation: "5xx,,reset"},
+			InvalidRetryOnErr("5xx,,reset").Error()),
+		Entry("zero retries",
+			map[string]string{RetryOnAnnotation: "5xx", NumRetriesAnnotation: "0"},
+			InvalidNumRetriesErr.Error()),
+		Entry("unparseable per try timeout",
+			map[string]string{RetryOnAnnotation: "5xx", PerTryTimeoutAnnotation: "2"},
+			PerTryTimeoutAnnotation),
+		Entry("unparseable status code",
+			map[string]string{RetriableStatusCodesAnnotation: "502,5xx"},
+			InvalidStatusCodeErr("5xx").Error()),
+		Entry("out of range status code",
+			map[string]string{RetriableStatusCodesAnnotation: "502,503,504,600"},
+			InvalidStatusCodeErr("600").Error()),
+		Entry("status codes other than the gateway errors",
+			map[string]string{RetriableStatusCodesAnnotation: "503"},
+			RetriableStatusCodesUnsupportedErr("503").Error()),
+		Entry("retriable methods",
+			map[string]string{RetryOnAnnotation: "5xx", RetriableMethodsAnnotation: "GET,HEAD"},
+			RetriableMethodsUnsupportedErr.Error()),
+		Entry("unparseable retry budget percent",
+			map[string]string{RetryOnAnnotation: "5xx", RetryBudgetPercentAnnotation: "20%"},
+			InvalidRetryBudgetPercentErr("20%").Error()),
+		Entry("out of range retry budget percent",
+			map[string]string{RetryOnAnnotation: "5xx", RetryBudgetPercentAnnotation: "150"},
+			InvalidRetryBudgetPercentErr("150").Error()),
+		Entry("not a number retry budget percent",
+			map[string]string{RetryOnAnnotation: "5xx", RetryBudgetPercentAnnotation: "NaN"},
+			InvalidRetryBudgetPercentErr("NaN").Error()),
+		Entry("unparseable retry budget min concurrency",
+			map[string]string{RetryOnAnnotation: "5xx", RetryBudgetMinConcurrencyAnnotation: "-3"},
+			RetryBudgetMinConcurrencyAnnotation),
+	)
+})
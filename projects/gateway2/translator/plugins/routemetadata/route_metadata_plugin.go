@@ -0,0 +1,136 @@
+package routemetadata
+
+import (
+	"context"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Annotations set on a RouteOption to tag the routes it is applied to, e.g. with their owning team.
+const (
+	// RouteMetadataAnnotation is a comma-separated list of key=value pairs, set as the envoy metadata of the routes
+	// in the RouteMetadataNamespace, e.g. "team=payments,owner=alice". Access logs can read them with
+	// %METADATA(ROUTE:gateway2.solo.io:team)%.
+	RouteMetadataAnnotation = "gateway2.solo.io/route-metadata"
+	// RouteStatPrefixAnnotation would prefix the stats of the routes. Gloo routes cannot configure the stat prefix
+	// of envoy routes, so it is rejected.
+	RouteStatPrefixAnnotation = "gateway2.solo.io/route-stat-prefix"
+)
+
+// RouteMetadataNamespace is the envoy metadata namespace of the route metadata
+const RouteMetadataNamespace = "gateway2.solo.io"
+
+var (
+	InvalidMetadataErr = func(value string) error {
+		return errors.Errorf("invalid entry '%s' in annotation %s: must be of the form <key>=<value>", value, RouteMetadataAnnotation)
+	}
+	DuplicateKeyErr = func(key string) error {
+		return errors.Errorf("key '%s' is set several times in annotation %s", key, RouteMetadataAnnotation)
+	}
+	ConflictingMetadataErr = errors.Errorf("annotation %s cannot be combined with envoy metadata of the RouteOption in namespace %s",
+		RouteMetadataAnnotation, RouteMetadataNamespace)
+	StatPrefixUnsupportedErr = errors.Errorf("annotation %s cannot be applied: gloo routes do not support stat prefixes", RouteStatPrefixAnnotation)
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+)
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+// DependsOn the routeoptions plugin, which would otherwise replace the envoy metadata of the route
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+	annotations := routeOption.GetAnnotations()
+
+	metadata, err := getMetadata(annotations)
+	if err != nil {
+		return err
+	}
+	if metadata != nil {
+		if outputRoute.GetOptions() == nil {
+			outputRoute.Options = &v1.RouteOptions{}
+		}
+		options := outputRoute.GetOptions()
+		if _, ok := options.GetEnvoyMetadata()[RouteMetadataNamespace]; ok {
+			return ConflictingMetadataErr
+		}
+		if options.GetEnvoyMetadata() == nil {
+			options.EnvoyMetadata = map[string]*structpb.Struct{}
+		}
+		options.GetEnvoyMetadata()[RouteMetadataNamespace] = metadata
+	}
+
+	// rather than silently dropping the stat prefix, surface that it could not be honored
+	if _, ok := annotations[RouteStatPrefixAnnotation]; ok {
+		return StatPrefixUnsupportedErr
+	}
+	return nil
+}
+
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	annotations := routeOption.GetAnnotations()
+	metadata, err := getMetadata(annotations)
+	if err != nil {
+		return err
+	}
+	if _, ok := routeOption.Spec.GetOptions().GetEnvoyMetadata()[RouteMetadataNamespace]; ok && metadata != nil {
+		return ConflictingMetadataErr
+	}
+	if _, ok := annotations[RouteStatPrefixAnnotation]; ok {
+		return StatPrefixUnsupportedErr
+	}
+	return nil
+}
+
+// getMetadata returns the route metadata of the annotation, or nil if it is not set
+func getMetadata(annotations map[string]string) (*structpb.Struct, error) {
+	value, ok := annotations[RouteMetadataAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	metadata := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	for _, entry := range strings.Split(value, ",") {
+		key, val, found := strings.Cut(strings.TrimSpace(entry), "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, InvalidMetadataErr(entry)
+		}
+		if _, ok := metadata.GetFields()[key]; ok {
+			return nil, DuplicateKeyErr(key)
+		}
+		metadata.GetFields()[key] = structpb.NewStringValue(strings.TrimSpace(val))
+	}
+	return metadata, nil
+}
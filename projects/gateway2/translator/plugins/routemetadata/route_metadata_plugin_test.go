@@ -0,0 +1,127 @@
+package routemetadata
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("RouteMetadataPlugin", func() {
+	apply := func(annotations map[string]string, outputRoute *v1.Route) error {
+		queries := testutils.BuildGatewayQueries([]client.Object{
+			&solokubev1.RouteOption{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "policy",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+				Spec: sologatewayv1.RouteOption{},
+			},
+		})
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: &gwv1.HTTPRouteRule{
+				Filters: []gwv1.HTTPRouteFilter{{
+					Type: gwv1.HTTPRouteFilterExtensionRef,
+					ExtensionRef: &gwv1.LocalObjectReference{
+						Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Name:  "policy",
+					},
+				}},
+			},
+		}
+		return NewPlugin(queries).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+	}
+
+	It("sets the metadata of the route", func() {
+		route := &v1.Route{}
+		err := apply(map[string]string{
+			RouteMetadataAnnotation: "team=payments, owner = alice,tier=",
+		}, route)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetEnvoyMetadata()).To(HaveLen(1))
+		Expect(proto.Equal(route.GetOptions().GetEnvoyMetadata()[RouteMetadataNamespace], &structpb.Struct{
+			Fields: map[string]*structpb.Value{
+				"team":  structpb.NewStringValue("payments"),
+				"owner": structpb.NewStringValue("alice"),
+				"tier":  structpb.NewStringValue(""),
+			},
+		})).To(BeTrue())
+	})
+
+	It("keeps the envoy metadata of the RouteOption in other namespaces", func() {
+		other := &structpb.Struct{Fields: map[string]*structpb.Value{"key": structpb.NewStringValue("value")}}
+		route := &v1.Route{
+			Options: &v1.RouteOptions{
+				EnvoyMetadata: map[string]*structpb.Struct{"example.com": other},
+			},
+		}
+		err := apply(map[string]string{
+			RouteMetadataAnnotation: "team=payments",
+		}, route)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetEnvoyMetadata()).To(HaveLen(2))
+		Expect(proto.Equal(route.GetOptions().GetEnvoyMetadata()["example.com"], other)).To(BeTrue())
+	})
+
+	It("does nothing without the annotation", func() {
+		route := &v1.Route{}
+		err := apply(nil, route)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetEnvoyMetadata()).To(BeEmpty())
+	})
+
+	It("reports the stat prefix as unsupported", func() {
+		route := &v1.Route{}
+		err := apply(map[string]string{
+			RouteMetadataAnnotation:   "team=payments",
+			RouteStatPrefixAnnotation: "payments",
+		}, route)
+		Expect(err).To(MatchError(StatPrefixUnsupportedErr))
+		Expect(route.GetOptions().GetEnvoyMetadata()).To(HaveKey(RouteMetadataNamespace))
+	})
+
+	DescribeTable("rejects invalid metadata",
+		func(annotations map[string]string, route *v1.Route, expectedErr error) {
+			err := apply(annotations, route)
+			Expect(err).To(MatchError(expectedErr.Error()))
+		},
+		Entry("entry without a value",
+			map[string]string{RouteMetadataAnnotation: "team"},
+			&v1.Route{},
+			InvalidMetadataErr("team")),
+		Entry("entry without a key",
+			map[string]string{RouteMetadataAnnotation: "team=payments,=alice"},
+			&v1.Route{},
+			InvalidMetadataErr("=alice")),
+		Entry("duplicate key",
+			map[string]string{RouteMetadataAnnotation: "team=payments,team=search"},
+			&v1.Route{},
+			DuplicateKeyErr("team")),
+		Entry("metadata set by the RouteOption",
+			map[string]string{RouteMetadataAnnotation: "team=payments"},
+			&v1.Route{
+				Options: &v1.RouteOptions{
+					EnvoyMetadata: map[string]*structpb.Struct{RouteMetadataNamespace: {}},
+				},
+			},
+			ConflictingMetadataErr),
+	)
+})
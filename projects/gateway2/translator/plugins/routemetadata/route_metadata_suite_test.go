@@ -0,0 +1,13 @@
+package routemetadata
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRouteMetadata(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Route Metadata Plugin Suite")
+}
@@ -0,0 +1,60 @@
+package statprefix
+
+import (
+	"context"
+	"regexp"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+)
+
+// StatPrefixAnnotation is set on a Gateway to prefix the envoy stats of the TCP proxies of its listeners,
+// e.g. "tcp.<prefix>.downstream_cx_total", instead of the default "tcp" prefix.
+// The HTTP connection managers of aggregate listeners cannot configure their stat prefix, so it is rejected for
+// HTTP listeners; route-level prefixes are rejected by the routemetadata plugin.
+const StatPrefixAnnotation = "gateway2.solo.io/stat-prefix"
+
+var (
+	// only the characters kept as is in prometheus metric names, so the series stay readable
+	statPrefixRegex = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+	InvalidStatPrefixErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must only contain letters, digits and _", value, StatPrefixAnnotation)
+	}
+	HttpStatPrefixUnsupportedErr = errors.Errorf("annotation %s cannot be applied to HTTP listeners: "+
+		"gloo aggregate listeners do not support stat prefixes for HTTP connection managers", StatPrefixAnnotation)
+)
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	prefix, ok := listenerCtx.Gateway.GetAnnotations()[StatPrefixAnnotation]
+	if !ok {
+		return nil
+	}
+	if !statPrefixRegex.MatchString(prefix) {
+		return InvalidStatPrefixErr(prefix)
+	}
+
+	aggregateListener := outputListener.GetAggregateListener()
+	for _, tcpListener := range aggregateListener.GetTcpListeners() {
+		if tcpListener.GetTcpListener() != nil {
+			tcpListener.GetTcpListener().StatPrefix = prefix
+		}
+	}
+	if len(aggregateListener.GetHttpFilterChains()) > 0 {
+		return HttpStatPrefixUnsupportedErr
+	}
+	return nil
+}
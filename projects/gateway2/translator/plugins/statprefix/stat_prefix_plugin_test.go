@@ -0,0 +1,85 @@
+package statprefix
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("StatPrefixPlugin", func() {
+	var outputListener *v1.Listener
+
+	BeforeEach(func() {
+		outputListener = &v1.Listener{
+			Name: "tcp",
+			ListenerType: &v1.Listener_AggregateListener{
+				AggregateListener: &v1.AggregateListener{
+					TcpListeners: []*v1.MatchedTcpListener{
+						{Matcher: &v1.Matcher{}, TcpListener: &v1.TcpListener{}},
+						{Matcher: &v1.Matcher{}, TcpListener: &v1.TcpListener{}},
+					},
+				},
+			},
+		}
+	})
+
+	apply := func(annotations map[string]string) error {
+		listenerCtx := &plugins.ListenerContext{
+			Gateway: &gwv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+			},
+		}
+		return NewPlugin().ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+	}
+
+	statPrefixes := func() []string {
+		var prefixes []string
+		for _, tcpListener := range outputListener.GetAggregateListener().GetTcpListeners() {
+			prefixes = append(prefixes, tcpListener.GetTcpListener().GetStatPrefix())
+		}
+		return prefixes
+	}
+
+	It("prefixes the stats of all TCP proxies", func() {
+		Expect(apply(map[string]string{
+			StatPrefixAnnotation: "payments_tcp",
+		})).To(Succeed())
+		Expect(statPrefixes()).To(Equal([]string{"payments_tcp", "payments_tcp"}))
+	})
+
+	It("does nothing without the annotation", func() {
+		Expect(apply(nil)).To(Succeed())
+		Expect(statPrefixes()).To(Equal([]string{"", ""}))
+	})
+
+	It("rejects HTTP listeners after prefixing the TCP proxies", func() {
+		outputListener.GetAggregateListener().HttpFilterChains = []*v1.AggregateListener_HttpFilterChain{{
+			VirtualHostRefs: []string{"http~example_com"},
+		}}
+		Expect(apply(map[string]string{
+			StatPrefixAnnotation: "payments",
+		})).To(MatchError(HttpStatPrefixUnsupportedErr))
+		Expect(statPrefixes()).To(Equal([]string{"payments", "payments"}))
+	})
+
+	DescribeTable("rejects unsafe prefixes",
+		func(value string) {
+			Expect(apply(map[string]string{
+				StatPrefixAnnotation: value,
+			})).To(MatchError(InvalidStatPrefixErr(value).Error()))
+			Expect(statPrefixes()).To(Equal([]string{"", ""}))
+		},
+		Entry("empty", ""),
+		Entry("dots", "payments.tcp"),
+		Entry("dashes", "payments-tcp"),
+		Entry("spaces", "payments tcp"),
+	)
+})
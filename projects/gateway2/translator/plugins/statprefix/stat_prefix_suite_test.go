@@ -0,0 +1,13 @@
+package statprefix
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestStatPrefix(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "StatPrefix Plugin Suite")
+}
@@ -0,0 +1,163 @@
+package statusremap
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/core/matchers"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/transformation"
+)
+
+// ResponseStatusRemapAnnotation is set on a RouteOption to a comma-separated list of <from>:<to> status codes,
+// e.g. "418:400,451:403", rewriting the status of the responses of the routes it is applied to, e.g. for clients
+// not handling some status codes. Responses with other status codes are passed through.
+const ResponseStatusRemapAnnotation = "gateway2.solo.io/response-status-remap"
+
+var (
+	InvalidRemapErr = func(value string) error {
+		return errors.Errorf("invalid remap '%s' in annotation %s: must be of the form <from>:<to> with status codes between 100 and 599", value, ResponseStatusRemapAnnotation)
+	}
+	DuplicateStatusCodeErr = func(code int) error {
+		return errors.Errorf("status code %d is remapped several times in annotation %s", code, ResponseStatusRemapAnnotation)
+	}
+	ConflictingTransformationErr = errors.Errorf("annotation %s cannot be combined with response transformations of the RouteOption", ResponseStatusRemapAnnotation)
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+)
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+// DependsOn the routeoptions plugin, which would otherwise replace the response transformations of the route
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+	value, ok := routeOption.GetAnnotations()[ResponseStatusRemapAnnotation]
+	if !ok {
+		return nil
+	}
+	remaps, err := parseRemaps(value)
+	if err != nil {
+		return err
+	}
+	if len(outputRoute.GetOptions().GetStagedTransformations().GetRegular().GetResponseTransforms()) > 0 {
+		return ConflictingTransformationErr
+	}
+
+	if outputRoute.GetOptions() == nil {
+		outputRoute.Options = &v1.RouteOptions{}
+	}
+	options := outputRoute.GetOptions()
+	if options.GetStagedTransformations() == nil {
+		options.StagedTransformations = &transformation.TransformationStages{}
+	}
+	if options.GetStagedTransformations().GetRegular() == nil {
+		options.GetStagedTransformations().Regular = &transformation.RequestResponseTransformations{}
+	}
+	// the status codes are matched exactly, so that at most one of the response transformations applies
+	var responseTransforms []*transformation.ResponseMatch
+	for _, remap := range remaps {
+		responseTransforms = append(responseTransforms, &transformation.ResponseMatch{
+			Matchers: []*matchers.HeaderMatcher{{
+				Name:  ":status",
+				Value: strconv.Itoa(remap.from),
+			}},
+			ResponseTransformation: &transformation.Transformation{
+				TransformationType: &transformation.Transformation_TransformationTemplate{
+					TransformationTemplate: &transformation.TransformationTemplate{
+						Headers: map[string]*transformation.InjaTemplate{
+							":status": {Text: strconv.Itoa(remap.to)},
+						},
+						BodyTransformation: &transformation.TransformationTemplate_Passthrough{
+							Passthrough: &transformation.Passthrough{},
+						},
+					},
+				},
+			},
+		})
+	}
+	options.GetStagedTransformations().GetRegular().ResponseTransforms = responseTransforms
+	return nil
+}
+
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	value, ok := routeOption.GetAnnotations()[ResponseStatusRemapAnnotation]
+	if !ok {
+		return nil
+	}
+	_, err := parseRemaps(value)
+	return err
+}
+
+type remap struct {
+	from, to int
+}
+
+// parseRemaps returns the remaps of the annotation value, sorted by the status code they rewrite
+func parseRemaps(value string) ([]remap, error) {
+	var remaps []remap
+	seen := map[int]bool{}
+	for _, r := range strings.Split(value, ",") {
+		fromStr, toStr, found := strings.Cut(strings.TrimSpace(r), ":")
+		if !found {
+			return nil, InvalidRemapErr(r)
+		}
+		from, err := parseStatusCode(fromStr)
+		if err != nil {
+			return nil, InvalidRemapErr(r)
+		}
+		to, err := parseStatusCode(toStr)
+		if err != nil {
+			return nil, InvalidRemapErr(r)
+		}
+		if seen[from] {
+			return nil, DuplicateStatusCodeErr(from)
+		}
+		seen[from] = true
+		remaps = append(remaps, remap{from: from, to: to})
+	}
+	sort.Slice(remaps, func(i, j int) bool {
+		return remaps[i].from < remaps[j].from
+	})
+	return remaps, nil
+}
+
+func parseStatusCode(value string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || code < 100 || code > 599 {
+		return 0, errors.Errorf("invalid status code '%s'", value)
+	}
+	return code, nil
+}
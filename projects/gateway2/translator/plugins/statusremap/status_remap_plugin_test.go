@@ -0,0 +1,150 @@
+package statusremap
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/core/matchers"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/transformation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("StatusRemapPlugin", func() {
+	apply := func(annotations map[string]string, outputRoute *v1.Route) error {
+		queries := testutils.BuildGatewayQueries([]client.Object{testutils.RouteOption(annotations)})
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: &gwv1.HTTPRouteRule{
+				Filters: []gwv1.HTTPRouteFilter{{
+					Type: gwv1.HTTPRouteFilterExtensionRef,
+					ExtensionRef: &gwv1.LocalObjectReference{
+						Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Name:  "policy",
+					},
+				}},
+			},
+		}
+		return NewPlugin(queries).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+	}
+
+	expectedResponseMatch := func(from, to string) *transformation.ResponseMatch {
+		return &transformation.ResponseMatch{
+			Matchers: []*matchers.HeaderMatcher{{
+				Name:  ":status",
+				Value: from,
+			}},
+			ResponseTransformation: &transformation.Transformation{
+				TransformationType: &transformation.Transformation_TransformationTemplate{
+					TransformationTemplate: &transformation.TransformationTemplate{
+						Headers: map[string]*transformation.InjaTemplate{
+							":status": {Text: to},
+						},
+						BodyTransformation: &transformation.TransformationTemplate_Passthrough{
+							Passthrough: &transformation.Passthrough{},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	responseTransforms := func(route *v1.Route) []*transformation.ResponseMatch {
+		return route.GetOptions().GetStagedTransformations().GetRegular().GetResponseTransforms()
+	}
+
+	It("remaps a status code", func() {
+		route := &v1.Route{}
+		Expect(apply(map[string]string{ResponseStatusRemapAnnotation: "418:400"}, route)).To(Succeed())
+		Expect(responseTransforms(route)).To(HaveLen(1))
+		Expect(proto.Equal(responseTransforms(route)[0], expectedResponseMatch("418", "400"))).To(BeTrue())
+	})
+
+	It("remaps several status codes in order", func() {
+		route := &v1.Route{}
+		Expect(apply(map[string]string{ResponseStatusRemapAnnotation: "451:403, 418:400, 503:500"}, route)).To(Succeed())
+		Expect(responseTransforms(route)).To(HaveLen(3))
+		Expect(proto.Equal(responseTransforms(route)[0], expectedResponseMatch("418", "400"))).To(BeTrue())
+		Expect(proto.Equal(responseTransforms(route)[1], expectedResponseMatch("451", "403"))).To(BeTrue())
+		Expect(proto.Equal(responseTransforms(route)[2], expectedResponseMatch("503", "500"))).To(BeTrue())
+	})
+
+	It("passes through the unmapped status codes", func() {
+		route := &v1.Route{}
+		Expect(apply(map[string]string{ResponseStatusRemapAnnotation: "418:400"}, route)).To(Succeed())
+		// only the exact status code is matched, other responses are not transformed
+		for _, rt := range responseTransforms(route) {
+			for _, m := range rt.GetMatchers() {
+				Expect(m.GetRegex()).To(BeFalse())
+				Expect(m.GetValue()).To(Equal("418"))
+			}
+		}
+		Expect(route.GetOptions().GetStagedTransformations().GetRegular().GetRequestTransforms()).To(BeEmpty())
+	})
+
+	It("keeps the request transformations", func() {
+		requestTransforms := []*transformation.RequestMatch{{
+			Matcher: &matchers.Matcher{PathSpecifier: &matchers.Matcher_Prefix{Prefix: "/"}},
+		}}
+		route := &v1.Route{
+			Options: &v1.RouteOptions{
+				StagedTransformations: &transformation.TransformationStages{
+					Regular: &transformation.RequestResponseTransformations{
+						RequestTransforms: requestTransforms,
+					},
+				},
+			},
+		}
+		Expect(apply(map[string]string{ResponseStatusRemapAnnotation: "418:400"}, route)).To(Succeed())
+		Expect(route.GetOptions().GetStagedTransformations().GetRegular().GetRequestTransforms()).To(Equal(requestTransforms))
+		Expect(responseTransforms(route)).To(HaveLen(1))
+	})
+
+	It("does nothing without the annotation", func() {
+		route := &v1.Route{}
+		Expect(apply(map[string]string{"other": "value"}, route)).To(Succeed())
+		Expect(route.GetOptions()).To(BeNil())
+	})
+
+	It("rejects conflicting response transformations", func() {
+		route := &v1.Route{
+			Options: &v1.RouteOptions{
+				StagedTransformations: &transformation.TransformationStages{
+					Regular: &transformation.RequestResponseTransformations{
+						ResponseTransforms: []*transformation.ResponseMatch{expectedResponseMatch("500", "502")},
+					},
+				},
+			},
+		}
+		err := apply(map[string]string{ResponseStatusRemapAnnotation: "418:400"}, route)
+		Expect(err).To(MatchError(ConflictingTransformationErr))
+	})
+
+	DescribeTable("rejects invalid remaps",
+		func(value string, expectedErr error) {
+			route := &v1.Route{}
+			Expect(apply(map[string]string{ResponseStatusRemapAnnotation: value}, route)).To(MatchError(expectedErr.Error()))
+			Expect(route.GetOptions()).To(BeNil())
+
+			err := NewPlugin(nil).ValidateRouteOption(context.Background(), testutils.RouteOption(map[string]string{ResponseStatusRemapAnnotation: value}))
+			Expect(err).To(MatchError(expectedErr.Error()))
+		},
+		Entry("missing target", "418", InvalidRemapErr("418")),
+		Entry("not a status code", "418:teapot", InvalidRemapErr("418:teapot")),
+		Entry("out of range", "418:600", InvalidRemapErr("418:600")),
+		Entry("duplicate status code", "418:400,418:404", DuplicateStatusCodeErr(418)),
+	)
+})
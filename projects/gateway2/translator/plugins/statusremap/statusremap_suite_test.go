@@ -0,0 +1,13 @@
+package statusremap
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestStatusRemap(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "StatusRemap Plugin Suite")
+}
@@ -0,0 +1,102 @@
+package streamduration
+
+import (
+	"context"
+	"time"
+
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// MaxStreamDurationAnnotation is set on a RouteOption to cap the total duration of the streams of the routes it is
+// applied to, e.g. "5m" for long-polling or bounded streaming endpoints. Streams are reset once the duration elapses,
+// even while data is flowing. "0s" disables the max stream duration of the connection manager for these routes.
+// The maxStreamDuration option of the RouteOption takes precedence over the annotation, and routes setting both report
+// the conflict.
+const MaxStreamDurationAnnotation = "gateway2.solo.io/max-stream-duration"
+
+var (
+	InvalidDurationErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a non-negative duration", value, MaxStreamDurationAnnotation)
+	}
+	ConflictingMaxStreamDurationErr = errors.Errorf("annotation %s cannot be combined with the maxStreamDuration option of the RouteOption", MaxStreamDurationAnnotation)
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+)
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+// DependsOn the routeoptions plugin, which would otherwise replace the max stream duration of the route
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+	d, err := getMaxStreamDuration(routeOption.GetAnnotations())
+	if err != nil || d == nil {
+		return err
+	}
+
+	if outputRoute.GetOptions().GetMaxStreamDuration() != nil {
+		return ConflictingMaxStreamDurationErr
+	}
+	if outputRoute.GetOptions() == nil {
+		outputRoute.Options = &v1.RouteOptions{}
+	}
+	outputRoute.GetOptions().MaxStreamDuration = &v1.RouteOptions_MaxStreamDuration{
+		MaxStreamDuration: d,
+	}
+	return nil
+}
+
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	d, err := getMaxStreamDuration(routeOption.GetAnnotations())
+	if err != nil {
+		return err
+	}
+	if d != nil && routeOption.Spec.GetOptions().GetMaxStreamDuration() != nil {
+		return ConflictingMaxStreamDurationErr
+	}
+	return nil
+}
+
+// getMaxStreamDuration returns the max stream duration of the annotation, or nil if it is not set
+func getMaxStreamDuration(annotations map[string]string) (*durationpb.Duration, error) {
+	value, ok := annotations[MaxStreamDurationAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return nil, InvalidDurationErr(value)
+	}
+	return durationpb.New(d), nil
+}
@@ -0,0 +1,126 @@
+package streamduration
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("StreamDurationPlugin", func() {
+	apply := func(annotations map[string]string, outputRoute *v1.Route) error {
+		queries := testutils.BuildGatewayQueries([]client.Object{
+			&solokubev1.RouteOption{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "policy",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+				Spec: sologatewayv1.RouteOption{},
+			},
+		})
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: &gwv1.HTTPRouteRule{
+				Filters: []gwv1.HTTPRouteFilter{{
+					Type: gwv1.HTTPRouteFilterExtensionRef,
+					ExtensionRef: &gwv1.LocalObjectReference{
+						Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Name:  "policy",
+					},
+				}},
+			},
+		}
+		return NewPlugin(queries).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+	}
+
+	DescribeTable("sets the max stream duration of the route",
+		func(value string, expected time.Duration) {
+			route := &v1.Route{}
+			Expect(apply(map[string]string{MaxStreamDurationAnnotation: value}, route)).To(Succeed())
+			Expect(proto.Equal(route.GetOptions().GetMaxStreamDuration(), &v1.RouteOptions_MaxStreamDuration{
+				MaxStreamDuration: durationpb.New(expected),
+			})).To(BeTrue())
+		},
+		Entry("bounded", "5m", 5*time.Minute),
+		Entry("zero for unlimited", "0s", time.Duration(0)),
+	)
+
+	It("keeps the other route options", func() {
+		route := &v1.Route{
+			Options: &v1.RouteOptions{
+				Timeout: durationpb.New(time.Minute),
+			},
+		}
+		Expect(apply(map[string]string{MaxStreamDurationAnnotation: "10m"}, route)).To(Succeed())
+		Expect(proto.Equal(route.GetOptions(), &v1.RouteOptions{
+			Timeout: durationpb.New(time.Minute),
+			MaxStreamDuration: &v1.RouteOptions_MaxStreamDuration{
+				MaxStreamDuration: durationpb.New(10 * time.Minute),
+			},
+		})).To(BeTrue())
+	})
+
+	It("does nothing without the annotation", func() {
+		route := &v1.Route{}
+		Expect(apply(map[string]string{"other": "value"}, route)).To(Succeed())
+		Expect(route.GetOptions()).To(BeNil())
+	})
+
+	DescribeTable("rejects invalid durations",
+		func(value string) {
+			route := &v1.Route{}
+			err := apply(map[string]string{MaxStreamDurationAnnotation: value}, route)
+			Expect(err).To(MatchError(InvalidDurationErr(value).Error()))
+			Expect(route.GetOptions()).To(BeNil())
+		},
+		Entry("not a duration", "forever"),
+		Entry("without unit", "30"),
+		Entry("negative", "-1s"),
+	)
+
+	It("keeps the max stream duration of the RouteOption, rejecting the annotation", func() {
+		route := &v1.Route{
+			Options: &v1.RouteOptions{
+				MaxStreamDuration: &v1.RouteOptions_MaxStreamDuration{
+					MaxStreamDuration: durationpb.New(time.Minute),
+				},
+			},
+		}
+		err := apply(map[string]string{MaxStreamDurationAnnotation: "5m"}, route)
+		Expect(err).To(MatchError(ConflictingMaxStreamDurationErr))
+		Expect(route.GetOptions().GetMaxStreamDuration().GetMaxStreamDuration().AsDuration()).To(Equal(time.Minute))
+	})
+	It("validates the RouteOption against its max stream duration", func() {
+		routeOption := &solokubev1.RouteOption{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{MaxStreamDurationAnnotation: "5m"},
+			},
+		}
+		Expect(NewPlugin(nil).ValidateRouteOption(context.Background(), routeOption)).To(Succeed())
+
+		routeOption.Spec.Options = &v1.RouteOptions{
+			MaxStreamDuration: &v1.RouteOptions_MaxStreamDuration{
+				MaxStreamDuration: durationpb.New(time.Minute),
+			},
+		}
+		Expect(NewPlugin(nil).ValidateRouteOption(context.Background(), routeOption)).To(MatchError(ConflictingMaxStreamDurationErr))
+	})
+})
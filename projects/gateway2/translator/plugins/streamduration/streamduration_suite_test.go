@@ -0,0 +1,13 @@
+package streamduration
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestStreamDuration(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "StreamDuration Plugin Suite")
+}
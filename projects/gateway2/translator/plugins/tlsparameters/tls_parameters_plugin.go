@@ -0,0 +1,186 @@
+package tlsparameters
+
+import (
+	"context"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/ssl"
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/proto"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// TLS options set in the tls.options of an HTTPS Listener of a Gateway to restrict the TLS parameters negotiated
+// by the filter chains terminating TLS for it.
+const (
+	// MinVersionOption is the minimum TLS version, one of TLSv1_0, TLSv1_1, TLSv1_2 or TLSv1_3
+	MinVersionOption gwv1.AnnotationKey = "gateway2.solo.io/tls-min-version"
+	// MaxVersionOption is the maximum TLS version, one of TLSv1_0, TLSv1_1, TLSv1_2 or TLSv1_3
+	MaxVersionOption gwv1.AnnotationKey = "gateway2.solo.io/tls-max-version"
+	// CipherSuitesOption is a comma-separated list of the cipher suites allowed for TLS 1.2 and lower,
+	// e.g. "ECDHE-ECDSA-AES128-GCM-SHA256,ECDHE-RSA-AES128-GCM-SHA256". The TLS 1.3 cipher suites cannot be configured.
+	CipherSuitesOption gwv1.AnnotationKey = "gateway2.solo.io/tls-cipher-suites"
+	// AlpnProtocolsOption is a comma-separated list of the application protocols advertised with ALPN, in order of
+	// preference, defaulting to "h2,http/1.1". E.g. "http/1.1" prevents clients from negotiating HTTP/2.
+	AlpnProtocolsOption gwv1.AnnotationKey = "gateway2.solo.io/tls-alpn-protocols"
+)
+
+var (
+	supportedVersions = []string{
+		ssl.SslParameters_TLSv1_0.String(),
+		ssl.SslParameters_TLSv1_1.String(),
+		ssl.SslParameters_TLSv1_2.String(),
+		ssl.SslParameters_TLSv1_3.String(),
+	}
+
+	// the cipher suites envoy supports for TLS 1.2 and lower
+	supportedCipherSuites = []string{
+		"ECDHE-ECDSA-AES128-GCM-SHA256",
+		"ECDHE-RSA-AES128-GCM-SHA256",
+		"ECDHE-ECDSA-AES256-GCM-SHA384",
+		"ECDHE-RSA-AES256-GCM-SHA384",
+		"ECDHE-ECDSA-CHACHA20-POLY1305",
+		"ECDHE-RSA-CHACHA20-POLY1305",
+		"ECDHE-ECDSA-AES128-SHA",
+		"ECDHE-RSA-AES128-SHA",
+		"ECDHE-ECDSA-AES256-SHA",
+		"ECDHE-RSA-AES256-SHA",
+		"AES128-GCM-SHA256",
+		"AES256-GCM-SHA384",
+		"AES128-SHA",
+		"AES256-SHA",
+	}
+
+	supportedAlpnProtocols = []string{"h2", "http/1.1", "http/1.0"}
+
+	InvalidVersionErr = func(listener string, option gwv1.AnnotationKey, value string) error {
+		return errors.Errorf("invalid value '%s' for TLS option %s of listener %s: must be one of %s", value, option, listener, strings.Join(supportedVersions, ", "))
+	}
+	InvalidVersionRangeErr = func(listener string) error {
+		return errors.Errorf("TLS option %s of listener %s is greater than %s", MinVersionOption, listener, MaxVersionOption)
+	}
+	UnknownCipherSuiteErr = func(listener, value string) error {
+		return errors.Errorf("unknown cipher suite '%s' in TLS option %s of listener %s", value, CipherSuitesOption, listener)
+	}
+	CipherSuitesIgnoredErr = func(listener string) error {
+		return errors.Errorf("TLS option %s of listener %s cannot be applied: the TLS 1.3 cipher suites cannot be configured", CipherSuitesOption, listener)
+	}
+	InvalidAlpnProtocolErr = func(listener, value string) error {
+		return errors.Errorf("invalid protocol '%s' in TLS option %s of listener %s: must be one of %s", value, AlpnProtocolsOption, listener, strings.Join(supportedAlpnProtocols, ", "))
+	}
+	DuplicateAlpnProtocolErr = func(listener, value string) error {
+		return errors.Errorf("protocol '%s' is listed several times in TLS option %s of listener %s", value, AlpnProtocolsOption, listener)
+	}
+)
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	for _, gwListener := range listenerCtx.GatewayListeners {
+		if gwListener.TLS == nil || len(gwListener.TLS.Options) == 0 {
+			continue
+		}
+		parameters, err := getTlsParameters(string(gwListener.Name), gwListener.TLS.Options)
+		if err != nil {
+			return err
+		}
+		alpnProtocols, err := getAlpnProtocols(string(gwListener.Name), gwListener.TLS.Options)
+		if err != nil {
+			return err
+		}
+		if parameters == nil && alpnProtocols == nil {
+			continue
+		}
+		for _, fc := range outputListener.GetAggregateListener().GetHttpFilterChains() {
+			sslConfig := fc.GetMatcher().GetSslConfig()
+			if sslConfig == nil || !utils.ServesGatewayListener(listenerCtx, fc, string(gwListener.Name)) {
+				continue
+			}
+			if parameters != nil {
+				sslConfig.Parameters = proto.Clone(parameters).(*ssl.SslParameters)
+			}
+			if alpnProtocols != nil {
+				sslConfig.AlpnProtocols = slices.Clone(alpnProtocols)
+			}
+		}
+	}
+	return nil
+}
+
+// getTlsParameters parses the TLS options of the listener, or returns nil if none of them is set
+func getTlsParameters(listener string, options map[gwv1.AnnotationKey]gwv1.AnnotationValue) (*ssl.SslParameters, error) {
+	parameters := &ssl.SslParameters{}
+	var set bool
+	for _, option := range []gwv1.AnnotationKey{MinVersionOption, MaxVersionOption} {
+		value, ok := options[option]
+		if !ok {
+			continue
+		}
+		if !slices.Contains(supportedVersions, string(value)) {
+			return nil, InvalidVersionErr(listener, option, string(value))
+		}
+		version := ssl.SslParameters_ProtocolVersion(ssl.SslParameters_ProtocolVersion_value[string(value)])
+		if option == MinVersionOption {
+			parameters.MinimumProtocolVersion = version
+		} else {
+			parameters.MaximumProtocolVersion = version
+		}
+		set = true
+	}
+	if parameters.GetMaximumProtocolVersion() != ssl.SslParameters_TLS_AUTO &&
+		parameters.GetMinimumProtocolVersion() > parameters.GetMaximumProtocolVersion() {
+		return nil, InvalidVersionRangeErr(listener)
+	}
+
+	if value, ok := options[CipherSuitesOption]; ok {
+		if parameters.GetMinimumProtocolVersion() == ssl.SslParameters_TLSv1_3 {
+			return nil, CipherSuitesIgnoredErr(listener)
+		}
+		for _, cipherSuite := range strings.Split(string(value), ",") {
+			cipherSuite = strings.TrimSpace(cipherSuite)
+			if !slices.Contains(supportedCipherSuites, cipherSuite) {
+				return nil, UnknownCipherSuiteErr(listener, cipherSuite)
+			}
+			parameters.CipherSuites = append(parameters.GetCipherSuites(), cipherSuite)
+		}
+		set = true
+	}
+	if !set {
+		return nil, nil
+	}
+	return parameters, nil
+}
+
+// getAlpnProtocols parses the ALPN protocols of the listener, or returns nil if they are not set
+func getAlpnProtocols(listener string, options map[gwv1.AnnotationKey]gwv1.AnnotationValue) ([]string, error) {
+	value, ok := options[AlpnProtocolsOption]
+	if !ok {
+		return nil, nil
+	}
+	var protocols []string
+	for _, protocol := range strings.Split(string(value), ",") {
+		protocol = strings.TrimSpace(protocol)
+		if !slices.Contains(supportedAlpnProtocols, protocol) {
+			return nil, InvalidAlpnProtocolErr(listener, protocol)
+		}
+		if slices.Contains(protocols, protocol) {
+			return nil, DuplicateAlpnProtocolErr(listener, protocol)
+		}
+		protocols = append(protocols, protocol)
+	}
+	return protocols, nil
+}
@@ -0,0 +1,186 @@
+package tlsparameters
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/ssl"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("TlsParametersPlugin", func() {
+	var outputListener *v1.Listener
+
+	BeforeEach(func() {
+		outputListener = &v1.Listener{
+			Name:     "https",
+			BindPort: 8443,
+			ListenerType: &v1.Listener_AggregateListener{
+				AggregateListener: &v1.AggregateListener{
+					HttpResources: &v1.AggregateListener_HttpResources{},
+					HttpFilterChains: []*v1.AggregateListener_HttpFilterChain{
+						{
+							Matcher:         &v1.Matcher{SslConfig: &ssl.SslConfig{SniDomains: []string{"foo.example.com"}}},
+							VirtualHostRefs: []string{"https~foo.example.com"},
+						},
+						{
+							Matcher:         &v1.Matcher{SslConfig: &ssl.SslConfig{SniDomains: []string{"bar.example.com"}}},
+							VirtualHostRefs: []string{"https~bar.example.com"},
+						},
+						{
+							Matcher:         &v1.Matcher{SslConfig: &ssl.SslConfig{SniDomains: []string{"baz.example.com"}}},
+							VirtualHostRefs: []string{"other~baz.example.com"},
+						},
+					},
+				},
+			},
+		}
+	})
+
+	apply := func(options map[gwv1.AnnotationKey]gwv1.AnnotationValue) error {
+		mode := gwv1.TLSModeTerminate
+		listenerCtx := &plugins.ListenerContext{
+			Gateway: &gwv1.Gateway{},
+			GatewayListeners: []gwv1.Listener{
+				{
+					Name:     "https",
+					Port:     8443,
+					Protocol: gwv1.HTTPSProtocolType,
+					TLS: &gwv1.GatewayTLSConfig{
+						Mode:    &mode,
+						Options: options,
+					},
+				},
+				{
+					Name:     "other",
+					Port:     8443,
+					Protocol: gwv1.HTTPSProtocolType,
+					TLS: &gwv1.GatewayTLSConfig{
+						Mode: &mode,
+					},
+				},
+			},
+			VirtualHostListeners: map[string][]string{
+				"https~foo.example.com": {"https"},
+				"https~bar.example.com": {"https"},
+				"other~baz.example.com": {"other"},
+			},
+		}
+		return NewPlugin().ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+	}
+
+	tlsParameters := func() []*ssl.SslParameters {
+		var parameters []*ssl.SslParameters
+		for _, fc := range outputListener.GetAggregateListener().GetHttpFilterChains() {
+			parameters = append(parameters, fc.GetMatcher().GetSslConfig().GetParameters())
+		}
+		return parameters
+	}
+
+	It("only accepts TLS 1.3", func() {
+		Expect(apply(map[gwv1.AnnotationKey]gwv1.AnnotationValue{
+			MinVersionOption: "TLSv1_3",
+			MaxVersionOption: "TLSv1_3",
+		})).To(Succeed())
+		parameters := tlsParameters()
+		expected := &ssl.SslParameters{
+			MinimumProtocolVersion: ssl.SslParameters_TLSv1_3,
+			MaximumProtocolVersion: ssl.SslParameters_TLSv1_3,
+		}
+		Expect(proto.Equal(parameters[0], expected)).To(BeTrue())
+		Expect(proto.Equal(parameters[1], expected)).To(BeTrue())
+		// the filter chain of the other listener is left alone
+		Expect(parameters[2]).To(BeNil())
+	})
+
+	It("restricts the cipher suites", func() {
+		Expect(apply(map[gwv1.AnnotationKey]gwv1.AnnotationValue{
+			MinVersionOption:   "TLSv1_2",
+			CipherSuitesOption: "ECDHE-ECDSA-AES128-GCM-SHA256, ECDHE-RSA-AES128-GCM-SHA256",
+		})).To(Succeed())
+		Expect(proto.Equal(tlsParameters()[0], &ssl.SslParameters{
+			MinimumProtocolVersion: ssl.SslParameters_TLSv1_2,
+			CipherSuites:           []string{"ECDHE-ECDSA-AES128-GCM-SHA256", "ECDHE-RSA-AES128-GCM-SHA256"},
+		})).To(BeTrue())
+	})
+
+	alpnProtocols := func() [][]string {
+		var protocols [][]string
+		for _, fc := range outputListener.GetAggregateListener().GetHttpFilterChains() {
+			protocols = append(protocols, fc.GetMatcher().GetSslConfig().GetAlpnProtocols())
+		}
+		return protocols
+	}
+
+	DescribeTable("advertises the ALPN protocols",
+		func(value string, expected []string) {
+			Expect(apply(map[gwv1.AnnotationKey]gwv1.AnnotationValue{
+				AlpnProtocolsOption: gwv1.AnnotationValue(value),
+			})).To(Succeed())
+			Expect(alpnProtocols()).To(Equal([][]string{expected, expected, nil}))
+			Expect(tlsParameters()).To(Equal([]*ssl.SslParameters{nil, nil, nil}))
+		},
+		Entry("HTTP/2 and HTTP/1.1", "h2, http/1.1", []string{"h2", "http/1.1"}),
+		Entry("HTTP/1.1 only", "http/1.1", []string{"http/1.1"}),
+	)
+
+	It("ignores the filter chains without TLS", func() {
+		outputListener.GetAggregateListener().HttpFilterChains = append(outputListener.GetAggregateListener().GetHttpFilterChains(),
+			&v1.AggregateListener_HttpFilterChain{
+				Matcher:         &v1.Matcher{},
+				VirtualHostRefs: []string{"https~foo.example.com"},
+			})
+		Expect(apply(map[gwv1.AnnotationKey]gwv1.AnnotationValue{
+			MinVersionOption: "TLSv1_2",
+		})).To(Succeed())
+		Expect(outputListener.GetAggregateListener().GetHttpFilterChains()[3].GetMatcher().GetSslConfig()).To(BeNil())
+	})
+
+	It("does nothing without TLS options", func() {
+		Expect(apply(map[gwv1.AnnotationKey]gwv1.AnnotationValue{
+			"example.com/other": "value",
+		})).To(Succeed())
+		Expect(tlsParameters()).To(Equal([]*ssl.SslParameters{nil, nil, nil}))
+		Expect(alpnProtocols()).To(Equal([][]string{nil, nil, nil}))
+	})
+
+	DescribeTable("rejects invalid TLS options",
+		func(options map[gwv1.AnnotationKey]gwv1.AnnotationValue, expectedErr error) {
+			Expect(apply(options)).To(MatchError(expectedErr.Error()))
+			Expect(tlsParameters()).To(Equal([]*ssl.SslParameters{nil, nil, nil}))
+			Expect(alpnProtocols()).To(Equal([][]string{nil, nil, nil}))
+		},
+		Entry("unknown version",
+			map[gwv1.AnnotationKey]gwv1.AnnotationValue{MinVersionOption: "1.3"},
+			InvalidVersionErr("https", MinVersionOption, "1.3")),
+		Entry("auto version",
+			map[gwv1.AnnotationKey]gwv1.AnnotationValue{MaxVersionOption: "TLS_AUTO"},
+			InvalidVersionErr("https", MaxVersionOption, "TLS_AUTO")),
+		Entry("minimum version above the maximum",
+			map[gwv1.AnnotationKey]gwv1.AnnotationValue{MinVersionOption: "TLSv1_3", MaxVersionOption: "TLSv1_2"},
+			InvalidVersionRangeErr("https")),
+		Entry("unknown cipher suite",
+			map[gwv1.AnnotationKey]gwv1.AnnotationValue{CipherSuitesOption: "ECDHE-RSA-AES128-GCM-SHA256,RC4-MD5"},
+			UnknownCipherSuiteErr("https", "RC4-MD5")),
+		Entry("cipher suites with TLS 1.3 only",
+			map[gwv1.AnnotationKey]gwv1.AnnotationValue{MinVersionOption: "TLSv1_3", CipherSuitesOption: "AES128-SHA"},
+			CipherSuitesIgnoredErr("https")),
+		Entry("unknown ALPN protocol",
+			map[gwv1.AnnotationKey]gwv1.AnnotationValue{AlpnProtocolsOption: "h2,h3"},
+			InvalidAlpnProtocolErr("https", "h3")),
+		Entry("empty ALPN protocol",
+			map[gwv1.AnnotationKey]gwv1.AnnotationValue{AlpnProtocolsOption: ""},
+			InvalidAlpnProtocolErr("https", "")),
+		Entry("duplicate ALPN protocol",
+			map[gwv1.AnnotationKey]gwv1.AnnotationValue{AlpnProtocolsOption: "http/1.1,h2,http/1.1"},
+			DuplicateAlpnProtocolErr("https", "http/1.1")),
+		Entry("valid ALPN protocols with an invalid version",
+			map[gwv1.AnnotationKey]gwv1.AnnotationValue{AlpnProtocolsOption: "h2", MinVersionOption: "TLSv2"},
+			InvalidVersionErr("https", MinVersionOption, "TLSv2")),
+	)
+})
@@ -0,0 +1,13 @@
+package tlsparameters
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTlsParameters(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TlsParameters Plugin Suite")
+}
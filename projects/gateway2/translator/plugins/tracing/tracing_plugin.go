@@ -0,0 +1,225 @@
+package tracing
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	envoytrace_gloo "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/config/trace/v3"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/hcm"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/tracing"
+)
+
+// Annotations set on a Gateway to trace the requests on its listeners. Envoy starts a trace for the requests
+// without trace context, and propagates the trace context of the others to the backends.
+const (
+	// TracingProviderAnnotation enables tracing, and is the provider the spans are sent to:
+	// either "zipkin" or "opentelemetry"
+	TracingProviderAnnotation = "gateway2.solo.io/tracing-provider"
+	// TracingCollectorAnnotation is the collector of the provider, as a <service>:<port> in the namespace of the Gateway
+	TracingCollectorAnnotation = "gateway2.solo.io/tracing-collector"
+	// TracingTagHeadersAnnotation is a comma-separated list of request headers, each tagging the spans of the
+	// requests with its value. Tags are named after the headers.
+	TracingTagHeadersAnnotation = "gateway2.solo.io/tracing-tag-headers"
+)
+
+// Annotations set on a RouteOption to configure the tracing of the routes it is applied to. The tracing option of
+// the RouteOption wins over them: routes setting both are traced as the option configures, reporting the conflict.
+const (
+	// TracingSamplePercentageAnnotation is the percentage of the requests without trace context which are traced,
+	// between 0 and 100, defaulting to 100
+	TracingSamplePercentageAnnotation = "gateway2.solo.io/tracing-sample-percentage"
+	// TracingPropagateAnnotation is either "true", the default, or "false" not to propagate the trace context to the backends
+	TracingPropagateAnnotation = "gateway2.solo.io/tracing-propagate"
+)
+
+const (
+	ZipkinProvider        = "zipkin"
+	OpenTelemetryProvider = "opentelemetry"
+
+	zipkinCollectorEndpoint = "/api/v2/spans"
+)
+
+var (
+	headerRegex = regexp.MustCompile(`^[A-Za-z0-9!#$%&'*+\-.^_|~]+$`)
+
+	UnknownProviderErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be one of %s or %s", value, TracingProviderAnnotation, ZipkinProvider, OpenTelemetryProvider)
+	}
+	MissingCollectorErr = errors.Errorf("annotation %s requires annotation %s", TracingProviderAnnotation, TracingCollectorAnnotation)
+	InvalidHeaderErr    = func(value string) error {
+		return errors.Errorf("invalid header '%s' in annotation %s", value, TracingTagHeadersAnnotation)
+	}
+	InvalidSamplePercentageErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a percentage between 0 and 100", value, TracingSamplePercentageAnnotation)
+	}
+	InvalidPropagateErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be true or false", value, TracingPropagateAnnotation)
+	}
+	ConflictingTracingErr = errors.Errorf("tracing annotations cannot be combined with the tracing option of the RouteOption")
+	NotConfiguredErr      = errors.Errorf("routes configure tracing but the Gateway has no annotation %s", TracingProviderAnnotation)
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.ListenerPlugin       = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+)
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+// DependsOn the routeoptions plugin, which would otherwise replace the tracing settings of the route
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+	settings, err := getRouteTracingSettings(routeOption.GetAnnotations())
+	if err != nil || settings == nil {
+		return err
+	}
+	if outputRoute.GetOptions().GetTracing() != nil {
+		return ConflictingTracingErr
+	}
+
+	if outputRoute.GetOptions() == nil {
+		outputRoute.Options = &v1.RouteOptions{}
+	}
+	outputRoute.GetOptions().Tracing = settings
+	return nil
+}
+
+// ValidateRouteOption does not require the tracing provider of the Gateways, which are only known at translation
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	settings, err := getRouteTracingSettings(routeOption.GetAnnotations())
+	if err != nil {
+		return err
+	}
+	if settings != nil && routeOption.Spec.GetOptions().GetTracing() != nil {
+		return ConflictingTracingErr
+	}
+	return nil
+}
+
+// getRouteTracingSettings returns nil if none of the annotations are set
+func getRouteTracingSettings(annotations map[string]string) (*tracing.RouteTracingSettings, error) {
+	samplePercentage, hasSamplePercentage := annotations[TracingSamplePercentageAnnotation]
+	propagate, hasPropagate := annotations[TracingPropagateAnnotation]
+	if !hasSamplePercentage && !hasPropagate {
+		return nil, nil
+	}
+
+	settings := &tracing.RouteTracingSettings{}
+	if hasSamplePercentage {
+		percentage, err := strconv.ParseFloat(samplePercentage, 32)
+		if err != nil || percentage < 0 || percentage > 100 {
+			return nil, InvalidSamplePercentageErr(samplePercentage)
+		}
+		settings.TracePercentages = &tracing.TracePercentages{
+			RandomSamplePercentage: &wrappers.FloatValue{Value: float32(percentage)},
+		}
+	}
+	if hasPropagate {
+		b, err := strconv.ParseBool(propagate)
+		if err != nil {
+			return nil, InvalidPropagateErr(propagate)
+		}
+		settings.Propagate = &wrappers.BoolValue{Value: b}
+	}
+	return settings, nil
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	annotations := listenerCtx.Gateway.GetAnnotations()
+	provider, ok := annotations[TracingProviderAnnotation]
+	if !ok {
+		for _, vhost := range outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts() {
+			for _, route := range vhost.GetRoutes() {
+				if route.GetOptions().GetTracing() != nil {
+					return NotConfiguredErr
+				}
+			}
+		}
+		return nil
+	}
+
+	collector, err := utils.GetServiceRefAnnotation(annotations, TracingCollectorAnnotation, listenerCtx.Gateway.GetNamespace())
+	if err != nil {
+		return err
+	}
+	if collector == nil {
+		return MissingCollectorErr
+	}
+	settings := &tracing.ListenerTracingSettings{}
+	switch provider {
+	case ZipkinProvider:
+		settings.ProviderConfig = &tracing.ListenerTracingSettings_ZipkinConfig{
+			ZipkinConfig: &envoytrace_gloo.ZipkinConfig{
+				CollectorCluster: &envoytrace_gloo.ZipkinConfig_CollectorUpstreamRef{
+					CollectorUpstreamRef: collector,
+				},
+				CollectorEndpoint:        zipkinCollectorEndpoint,
+				CollectorEndpointVersion: envoytrace_gloo.ZipkinConfig_HTTP_JSON,
+			},
+		}
+	case OpenTelemetryProvider:
+		settings.ProviderConfig = &tracing.ListenerTracingSettings_OpenTelemetryConfig{
+			OpenTelemetryConfig: &envoytrace_gloo.OpenTelemetryConfig{
+				CollectorCluster: &envoytrace_gloo.OpenTelemetryConfig_CollectorUpstreamRef{
+					CollectorUpstreamRef: collector,
+				},
+			},
+		}
+	default:
+		return UnknownProviderErr(provider)
+	}
+	if value, ok := annotations[TracingTagHeadersAnnotation]; ok {
+		for _, header := range strings.Split(value, ",") {
+			header = strings.TrimSpace(header)
+			if !headerRegex.MatchString(header) {
+				return InvalidHeaderErr(header)
+			}
+			settings.RequestHeadersForTags = append(settings.GetRequestHeadersForTags(), &wrappers.StringValue{Value: strings.ToLower(header)})
+		}
+	}
+
+	for _, options := range utils.GetHttpListenerOptions(outputListener) {
+		if options.GetHttpConnectionManagerSettings() == nil {
+			options.HttpConnectionManagerSettings = &hcm.HttpConnectionManagerSettings{}
+		}
+		options.GetHttpConnectionManagerSettings().Tracing = settings
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	envoytrace_gloo "github.com/solo-io/gloo/projects/gloo/pkg/api/external/envoy/config/trace/v3"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/tracing"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("TracingPlugin", func() {
+	Context("routes", func() {
+		apply := func(annotations map[string]string, options *v1.RouteOptions) (*v1.Route, error) {
+			queries := testutils.BuildGatewayQueries([]client.Object{
+				&solokubev1.RouteOption{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "policy",
+						Namespace:   "default",
+						Annotations: annotations,
+					},
+					Spec: sologatewayv1.RouteOption{},
+				},
+			})
+			routeCtx := &plugins.RouteContext{
+				Route: &gwv1.HTTPRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "default",
+					},
+				},
+				Rule: &gwv1.HTTPRouteRule{
+					Filters: []gwv1.HTTPRouteFilter{{
+						Type: gwv1.HTTPRouteFilterExtensionRef,
+						ExtensionRef: &gwv1.LocalObjectReference{
+							Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+							Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+							Name:  "policy",
+						},
+					}},
+				},
+			}
+			outputRoute := &v1.Route{
+				Options: options,
+			}
+			err := NewPlugin(queries).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+			return outputRoute, err
+		}
+
+		It("sets the sample percentage", func() {
+			route, err := apply(map[string]string{
+				TracingSamplePercentageAnnotation: "12.5",
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(route.GetOptions().GetTracing(), &tracing.RouteTracingSettings{
+				TracePercentages: &tracing.TracePercentages{
+					RandomSamplePercentage: &wrappers.FloatValue{Value: 12.5},
+				},
+			})).To(BeTrue())
+		})
+
+		It("disables propagation", func() {
+			route, err := apply(map[string]string{
+				TracingSamplePercentageAnnotation: "0",
+				TracingPropagateAnnotation:        "false",
+			}, &v1.RouteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(route.GetOptions().GetTracing(), &tracing.RouteTracingSettings{
+				TracePercentages: &tracing.TracePercentages{
+					RandomSamplePercentage: &wrappers.FloatValue{Value: 0},
+				},
+				Propagate: &wrappers.BoolValue{Value: false},
+			})).To(BeTrue())
+		})
+
+		It("does nothing without the annotations", func() {
+			route, err := apply(map[string]string{TracingProviderAnnotation: ZipkinProvider}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(route.GetOptions()).To(BeNil())
+		})
+
+		It("rejects the tracing option of the RouteOption", func() {
+			route, err := apply(map[string]string{TracingSamplePercentageAnnotation: "50"}, &v1.RouteOptions{
+				Tracing: &tracing.RouteTracingSettings{RouteDescriptor: "checkout"},
+			})
+			Expect(err).To(MatchError(ConflictingTracingErr))
+			Expect(route.GetOptions().GetTracing().GetTracePercentages()).To(BeNil())
+			Expect(route.GetOptions().GetTracing().GetRouteDescriptor()).To(Equal("checkout"))
+		})
+
+		DescribeTable("rejects invalid annotations",
+			func(annotations map[string]string, expectedErr string) {
+				route, err := apply(annotations, nil)
+				Expect(err).To(MatchError(expectedErr))
+				Expect(route.GetOptions()).To(BeNil())
+			},
+			Entry("percentage above 100",
+				map[string]string{TracingSamplePercentageAnnotation: "101"},
+				InvalidSamplePercentageErr("101").Error()),
+			Entry("negative percentage",
+				map[string]string{TracingSamplePercentageAnnotation: "-1"},
+				InvalidSamplePercentageErr("-1").Error()),
+			Entry("non-boolean propagation",
+				map[string]string{TracingPropagateAnnotation: "never"},
+				InvalidPropagateErr("never").Error()),
+		)
+	})
+
+	Context("listeners", func() {
+		var (
+			annotations    map[string]string
+			outputListener *v1.Listener
+		)
+
+		BeforeEach(func() {
+			annotations = map[string]string{
+				TracingProviderAnnotation:  OpenTelemetryProvider,
+				TracingCollectorAnnotation: "otel-collector:4317",
+			}
+			outputListener = &v1.Listener{
+				Name: "http",
+				ListenerType: &v1.Listener_AggregateListener{
+					AggregateListener: &v1.AggregateListener{
+						HttpResources: &v1.AggregateListener_HttpResources{
+							VirtualHosts: map[string]*v1.VirtualHost{
+								"vhost": {
+									Routes: []*v1.Route{
+										{Name: "sampled", Options: &v1.RouteOptions{
+											Tracing: &tracing.RouteTracingSettings{
+												TracePercentages: &tracing.TracePercentages{
+													RandomSamplePercentage: &wrappers.FloatValue{Value: 10},
+												},
+											},
+										}},
+										{Name: "default"},
+									},
+								},
+							},
+						},
+						HttpFilterChains: []*v1.AggregateListener_HttpFilterChain{{
+							VirtualHostRefs: []string{"vhost"},
+						}},
+					},
+				},
+			}
+		})
+
+		apply := func() error {
+			listenerCtx := &plugins.ListenerContext{
+				Gateway: &gwv1.Gateway{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace:   "gateways",
+						Annotations: annotations,
+					},
+				},
+			}
+			return NewPlugin(nil).ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+		}
+
+		settings := func() *tracing.ListenerTracingSettings {
+			aggregateListener := outputListener.GetAggregateListener()
+			ref := aggregateListener.GetHttpFilterChains()[0].GetHttpOptionsRef()
+			return aggregateListener.GetHttpResources().GetHttpOptions()[ref].GetHttpConnectionManagerSettings().GetTracing()
+		}
+
+		collector := &core.ResourceRef{Name: "gateways-otel-collector-4317", Namespace: "gateways"}
+
+		It("sends spans to the opentelemetry collector, tagged from request headers", func() {
+			annotations[TracingTagHeadersAnnotation] = "X-Tenant-Id, user-agent"
+			Expect(apply()).To(Succeed())
+			Expect(proto.Equal(settings(), &tracing.ListenerTracingSettings{
+				ProviderConfig: &tracing.ListenerTracingSettings_OpenTelemetryConfig{
+					OpenTelemetryConfig: &envoytrace_gloo.OpenTelemetryConfig{
+						CollectorCluster: &envoytrace_gloo.OpenTelemetryConfig_CollectorUpstreamRef{
+							CollectorUpstreamRef: collector,
+						},
+					},
+				},
+				RequestHeadersForTags: []*wrappers.StringValue{
+					{Value: "x-tenant-id"},
+					{Value: "user-agent"},
+				},
+			})).To(BeTrue())
+		})
+
+		It("sends spans to the zipkin collector", func() {
+			annotations[TracingProviderAnnotation] = ZipkinProvider
+			Expect(apply()).To(Succeed())
+			Expect(proto.Equal(settings(), &tracing.ListenerTracingSettings{
+				ProviderConfig: &tracing.ListenerTracingSettings_ZipkinConfig{
+					ZipkinConfig: &envoytrace_gloo.ZipkinConfig{
+						CollectorCluster: &envoytrace_gloo.ZipkinConfig_CollectorUpstreamRef{
+							CollectorUpstreamRef: collector,
+						},
+						CollectorEndpoint:        "/api/v2/spans",
+						CollectorEndpointVersion: envoytrace_gloo.ZipkinConfig_HTTP_JSON,
+					},
+				},
+			})).To(BeTrue())
+		})
+
+		It("does nothing without the annotation", func() {
+			annotations = nil
+			outputListener.GetAggregateListener().GetHttpResources().GetVirtualHosts()["vhost"].GetRoutes()[0].Options = nil
+			Expect(apply()).To(Succeed())
+			Expect(outputListener.GetAggregateListener().GetHttpResources().GetHttpOptions()).To(BeEmpty())
+		})
+
+		It("rejects traced routes without a provider", func() {
+			annotations = nil
+			Expect(apply()).To(MatchError(NotConfiguredErr))
+		})
+
+		DescribeTable("rejects invalid annotations",
+			func(annotation, value string, expectedErr string) {
+				if value == "" {
+					delete(annotations, annotation)
+				} else {
+					annotations[annotation] = value
+				}
+				Expect(apply()).To(MatchError(ContainSubstring(expectedErr)))
+				Expect(settings()).To(BeNil())
+			},
+			Entry("unknown provider", TracingProviderAnnotation, "jaeger",
+				UnknownProviderErr("jaeger").Error()),
+			Entry("missing collector", TracingCollectorAnnotation, "",
+				MissingCollectorErr.Error()),
+			Entry("collector without port", TracingCollectorAnnotation, "otel-collector",
+				utils.InvalidServiceRefErr(TracingCollectorAnnotation, "otel-collector").Error()),
+			Entry("invalid tag header", TracingTagHeadersAnnotation, "x-tenant,",
+				InvalidHeaderErr("").Error()),
+		)
+	})
+})
@@ -0,0 +1,13 @@
+package tracing
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTracingPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tracing Plugin Suite")
+}
@@ -0,0 +1,126 @@
+package transportprotocol
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// PlaintextListenersAnnotation is set on a Gateway to a comma-separated list of the names of its HTTPS Listeners
+// also accepting plaintext HTTP on their port. The TLS inspector tells the TLS connections apart from the plaintext
+// ones: a filter chain without TLS serving the same routes is added next to the TLS filter chains of the Listener.
+//
+// Gloo selects filter chains by SNI only, so every TLS filter chain on the port must match SNI domains, i.e. the
+// HTTPS Listeners must have a hostname or certificates for specific domains. A single Listener per port can accept
+// plaintext, as Gateway API rejects HTTP and HTTPS Listeners sharing a port.
+const PlaintextListenersAnnotation = "gateway2.solo.io/plaintext-listeners"
+
+var (
+	UnknownListenerErr = func(name string) error {
+		return errors.Errorf("listener '%s' of annotation %s is not an HTTPS listener of the Gateway", name, PlaintextListenersAnnotation)
+	}
+	MissingSniErr = func(listener string) error {
+		return errors.Errorf("listener %s accepts plaintext connections but has TLS filter chains without SNI domains, which plaintext connections would also match", listener)
+	}
+	MultiplePlaintextListenersErr = func(port uint32) error {
+		return errors.Errorf("several listeners of annotation %s are served on port %d", PlaintextListenersAnnotation, port)
+	}
+)
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	value, ok := listenerCtx.Gateway.GetAnnotations()[PlaintextListenersAnnotation]
+	if !ok {
+		return nil
+	}
+	names, err := getPlaintextListeners(listenerCtx.Gateway, value)
+	if err != nil {
+		return err
+	}
+
+	var plaintextListener string
+	for _, gwListener := range listenerCtx.GatewayListeners {
+		if !names[string(gwListener.Name)] {
+			continue
+		}
+		if plaintextListener != "" {
+			return MultiplePlaintextListenersErr(outputListener.GetBindPort())
+		}
+		plaintextListener = string(gwListener.Name)
+	}
+	if plaintextListener == "" {
+		return nil
+	}
+
+	aggregateListener := outputListener.GetAggregateListener()
+	var (
+		plaintextChain  *v1.AggregateListener_HttpFilterChain
+		virtualHostRefs = map[string]bool{}
+	)
+	for _, fc := range aggregateListener.GetHttpFilterChains() {
+		if fc.GetMatcher().GetSslConfig() == nil {
+			continue
+		}
+		if len(fc.GetMatcher().GetSslConfig().GetSniDomains()) == 0 {
+			return MissingSniErr(outputListener.GetName())
+		}
+		if !utils.ServesGatewayListener(listenerCtx, fc, plaintextListener) {
+			continue
+		}
+		if plaintextChain == nil {
+			plaintextChain = &v1.AggregateListener_HttpFilterChain{
+				Matcher:        &v1.Matcher{},
+				HttpOptionsRef: fc.GetHttpOptionsRef(),
+			}
+		}
+		for _, ref := range fc.GetVirtualHostRefs() {
+			virtualHostRefs[ref] = true
+		}
+	}
+	if plaintextChain == nil {
+		// the listener has no routes, or no valid certificate and is not programmed
+		return nil
+	}
+	for ref := range virtualHostRefs {
+		plaintextChain.VirtualHostRefs = append(plaintextChain.GetVirtualHostRefs(), ref)
+	}
+	sort.Strings(plaintextChain.GetVirtualHostRefs())
+	aggregateListener.HttpFilterChains = append(aggregateListener.GetHttpFilterChains(), plaintextChain)
+	return nil
+}
+
+// getPlaintextListeners returns the names of the annotation, which must be HTTPS Listeners of the Gateway
+func getPlaintextListeners(gateway *gwv1.Gateway, value string) (map[string]bool, error) {
+	httpsListeners := map[string]bool{}
+	for _, listener := range gateway.Spec.Listeners {
+		if listener.Protocol == gwv1.HTTPSProtocolType {
+			httpsListeners[string(listener.Name)] = true
+		}
+	}
+	names := map[string]bool{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if !httpsListeners[name] {
+			return nil, UnknownListenerErr(name)
+		}
+		names[name] = true
+	}
+	return names, nil
+}
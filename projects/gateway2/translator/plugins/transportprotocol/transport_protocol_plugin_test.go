@@ -0,0 +1,144 @@
+package transportprotocol
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/ssl"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("TransportProtocolPlugin", func() {
+	var (
+		gateway        *gwv1.Gateway
+		outputListener *v1.Listener
+	)
+
+	sslConfig := func(secret string, sniDomains ...string) *ssl.SslConfig {
+		return &ssl.SslConfig{
+			SslSecrets: &ssl.SslConfig_SecretRef{
+				SecretRef: &core.ResourceRef{Name: secret, Namespace: "default"},
+			},
+			SniDomains: sniDomains,
+		}
+	}
+
+	BeforeEach(func() {
+		gateway = &gwv1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "gw",
+				Namespace: "default",
+				Annotations: map[string]string{
+					PlaintextListenersAnnotation: "https",
+				},
+			},
+			Spec: gwv1.GatewaySpec{
+				Listeners: []gwv1.Listener{
+					{Name: "https", Port: 8443, Protocol: gwv1.HTTPSProtocolType},
+					{Name: "http", Port: 8080, Protocol: gwv1.HTTPProtocolType},
+				},
+			},
+		}
+		vhostRefs := []string{"https~bar.example.com", "https~foo.example.com"}
+		outputListener = &v1.Listener{
+			Name:     "https",
+			BindPort: 8443,
+			ListenerType: &v1.Listener_AggregateListener{
+				AggregateListener: &v1.AggregateListener{
+					HttpResources: &v1.AggregateListener_HttpResources{},
+					HttpFilterChains: []*v1.AggregateListener_HttpFilterChain{
+						{
+							Matcher:         &v1.Matcher{SslConfig: sslConfig("foo-cert", "foo.example.com")},
+							VirtualHostRefs: vhostRefs,
+							HttpOptionsRef:  "https",
+						},
+						{
+							Matcher:         &v1.Matcher{SslConfig: sslConfig("bar-cert", "bar.example.com")},
+							VirtualHostRefs: vhostRefs,
+							HttpOptionsRef:  "https",
+						},
+					},
+				},
+			},
+		}
+	})
+
+	apply := func(gwListeners ...gwv1.Listener) error {
+		listenerCtx := &plugins.ListenerContext{
+			Gateway:          gateway,
+			GatewayListeners: gwListeners,
+			VirtualHostListeners: map[string][]string{
+				"https~bar.example.com": {"https"},
+				"https~foo.example.com": {"https"},
+			},
+		}
+		return NewPlugin().ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+	}
+
+	filterChains := func() []*v1.AggregateListener_HttpFilterChain {
+		return outputListener.GetAggregateListener().GetHttpFilterChains()
+	}
+
+	It("adds a plaintext filter chain next to the TLS filter chains", func() {
+		Expect(apply(gateway.Spec.Listeners[0])).To(Succeed())
+		Expect(filterChains()).To(HaveLen(3))
+		// TLS connections are matched by SNI
+		Expect(proto.Equal(filterChains()[0].GetMatcher(), &v1.Matcher{SslConfig: sslConfig("foo-cert", "foo.example.com")})).To(BeTrue())
+		Expect(proto.Equal(filterChains()[1].GetMatcher(), &v1.Matcher{SslConfig: sslConfig("bar-cert", "bar.example.com")})).To(BeTrue())
+		// plaintext connections, without SNI, are matched by the filter chain without TLS
+		Expect(proto.Equal(filterChains()[2], &v1.AggregateListener_HttpFilterChain{
+			Matcher:         &v1.Matcher{},
+			VirtualHostRefs: []string{"https~bar.example.com", "https~foo.example.com"},
+			HttpOptionsRef:  "https",
+		})).To(BeTrue())
+	})
+
+	It("does nothing for the other listeners", func() {
+		Expect(apply(gateway.Spec.Listeners[1])).To(Succeed())
+		Expect(filterChains()).To(HaveLen(2))
+	})
+
+	It("does nothing without the annotation", func() {
+		gateway.Annotations = nil
+		Expect(apply(gateway.Spec.Listeners[0])).To(Succeed())
+		Expect(filterChains()).To(HaveLen(2))
+	})
+
+	It("does nothing for listeners without routes", func() {
+		for _, fc := range filterChains() {
+			fc.VirtualHostRefs = nil
+		}
+		Expect(apply(gateway.Spec.Listeners[0])).To(Succeed())
+		Expect(filterChains()).To(HaveLen(2))
+	})
+
+	It("rejects TLS filter chains without SNI domains", func() {
+		filterChains()[1].GetMatcher().GetSslConfig().SniDomains = nil
+		Expect(apply(gateway.Spec.Listeners[0])).To(MatchError(MissingSniErr("https").Error()))
+		Expect(filterChains()).To(HaveLen(2))
+	})
+
+	It("rejects several plaintext listeners on a port", func() {
+		gateway.Spec.Listeners = append(gateway.Spec.Listeners, gwv1.Listener{Name: "https-admin", Port: 8443, Protocol: gwv1.HTTPSProtocolType})
+		gateway.Annotations[PlaintextListenersAnnotation] = "https, https-admin"
+		Expect(apply(gateway.Spec.Listeners[0], gateway.Spec.Listeners[2])).To(MatchError(MultiplePlaintextListenersErr(8443).Error()))
+		Expect(filterChains()).To(HaveLen(2))
+	})
+
+	DescribeTable("rejects listeners which are not HTTPS listeners of the Gateway",
+		func(value, name string) {
+			gateway.Annotations[PlaintextListenersAnnotation] = value
+			Expect(apply(gateway.Spec.Listeners[0])).To(MatchError(UnknownListenerErr(name).Error()))
+			Expect(filterChains()).To(HaveLen(2))
+		},
+		Entry("http listener", "https,http", "http"),
+		Entry("unknown listener", "admin", "admin"),
+	)
+})
@@ -0,0 +1,13 @@
+package transportprotocol
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTransportProtocolPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Transport Protocol Plugin Suite")
+}
@@ -0,0 +1,135 @@
+package upgrades
+
+import (
+	"context"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/query"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/protocol_upgrade"
+)
+
+// UpgradesAnnotation is set on a RouteOption to a comma-separated list of the protocol upgrades allowed on the routes
+// it is applied to: "websocket" for requests with an "Upgrade: websocket" header, and "connect" for CONNECT requests.
+// Routes without the annotation use the upgrades of the listener, which allow websocket only.
+// The upgrades option of the RouteOption takes precedence over the annotation, and routes setting both report the conflict.
+const UpgradesAnnotation = "gateway2.solo.io/upgrades"
+
+const (
+	WebsocketUpgrade = "websocket"
+	ConnectUpgrade   = "connect"
+)
+
+var (
+	UnknownUpgradeErr = func(value string) error {
+		return errors.Errorf("invalid upgrade '%s' in annotation %s: must be one of %s or %s", value, UpgradesAnnotation, WebsocketUpgrade, ConnectUpgrade)
+	}
+	DuplicateUpgradeErr = func(value string) error {
+		return errors.Errorf("upgrade '%s' is listed several times in annotation %s", value, UpgradesAnnotation)
+	}
+	NoBackendsErr          = errors.Errorf("annotation %s requires routes forwarding to backends", UpgradesAnnotation)
+	ConflictingUpgradesErr = errors.Errorf("annotation %s cannot be combined with the upgrades option of the RouteOption", UpgradesAnnotation)
+)
+
+var (
+	_ plugins.RoutePlugin          = &plugin{}
+	_ plugins.RouteOptionValidator = &plugin{}
+	_ plugins.DependentPlugin      = &plugin{}
+)
+
+type plugin struct {
+	queries query.GatewayQueries
+}
+
+func NewPlugin(queries query.GatewayQueries) *plugin {
+	return &plugin{
+		queries,
+	}
+}
+
+// DependsOn the routeoptions plugin, which would otherwise replace the upgrades of the route, and the
+// clusternotfound plugin, so that routes responding directly are rejected
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions", "clusternotfound"}
+}
+
+func (p *plugin) ApplyRoutePlugin(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	outputRoute *v1.Route,
+) error {
+	routeOption := utils.GetAttachedRouteOption(ctx, routeCtx, p.queries)
+	if routeOption == nil {
+		return nil
+	}
+	upgrades, err := getUpgrades(routeOption.GetAnnotations())
+	if err != nil || upgrades == nil {
+		return err
+	}
+	// gloo only sets upgrades on routes forwarding to backends
+	if outputRoute.GetRouteAction() == nil {
+		return NoBackendsErr
+	}
+	if len(outputRoute.GetOptions().GetUpgrades()) > 0 {
+		return ConflictingUpgradesErr
+	}
+
+	if outputRoute.GetOptions() == nil {
+		outputRoute.Options = &v1.RouteOptions{}
+	}
+	outputRoute.GetOptions().Upgrades = upgrades
+	return nil
+}
+
+func (p *plugin) ValidateRouteOption(
+	ctx context.Context,
+	routeOption *solokubev1.RouteOption,
+) error {
+	upgrades, err := getUpgrades(routeOption.GetAnnotations())
+	if err != nil {
+		return err
+	}
+	if upgrades != nil && len(routeOption.Spec.GetOptions().GetUpgrades()) > 0 {
+		return ConflictingUpgradesErr
+	}
+	return nil
+}
+
+// getUpgrades returns nil if the annotation is not set
+func getUpgrades(annotations map[string]string) ([]*protocol_upgrade.ProtocolUpgradeConfig, error) {
+	value, ok := annotations[UpgradesAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	var upgrades []*protocol_upgrade.ProtocolUpgradeConfig
+	seen := map[string]bool{}
+	for _, upgrade := range strings.Split(value, ",") {
+		upgrade = strings.TrimSpace(upgrade)
+		if seen[upgrade] {
+			return nil, DuplicateUpgradeErr(upgrade)
+		}
+		seen[upgrade] = true
+		enabled := &protocol_upgrade.ProtocolUpgradeConfig_ProtocolUpgradeSpec{
+			Enabled: &wrappers.BoolValue{Value: true},
+		}
+		switch upgrade {
+		case WebsocketUpgrade:
+			upgrades = append(upgrades, &protocol_upgrade.ProtocolUpgradeConfig{
+				UpgradeType: &protocol_upgrade.ProtocolUpgradeConfig_Websocket{Websocket: enabled},
+			})
+		case ConnectUpgrade:
+			upgrades = append(upgrades, &protocol_upgrade.ProtocolUpgradeConfig{
+				UpgradeType: &protocol_upgrade.ProtocolUpgradeConfig_Connect{Connect: enabled},
+			})
+		default:
+			return nil, UnknownUpgradeErr(upgrade)
+		}
+	}
+	return upgrades, nil
+}
@@ -0,0 +1,145 @@
+package upgrades
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/protocol_upgrade"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("UpgradesPlugin", func() {
+	apply := func(annotations map[string]string, outputRoute *v1.Route) error {
+		queries := testutils.BuildGatewayQueries([]client.Object{
+			&solokubev1.RouteOption{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "policy",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+				Spec: sologatewayv1.RouteOption{},
+			},
+		})
+		routeCtx := &plugins.RouteContext{
+			Route: &gwv1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+			},
+			Rule: &gwv1.HTTPRouteRule{
+				Filters: []gwv1.HTTPRouteFilter{{
+					Type: gwv1.HTTPRouteFilterExtensionRef,
+					ExtensionRef: &gwv1.LocalObjectReference{
+						Group: gwv1.Group(sologatewayv1.RouteOptionGVK.Group),
+						Kind:  gwv1.Kind(sologatewayv1.RouteOptionGVK.Kind),
+						Name:  "policy",
+					},
+				}},
+			},
+		}
+		return NewPlugin(queries).ApplyRoutePlugin(context.Background(), routeCtx, outputRoute)
+	}
+
+	backendRoute := func() *v1.Route {
+		return &v1.Route{
+			Action: &v1.Route_RouteAction{
+				RouteAction: &v1.RouteAction{},
+			},
+		}
+	}
+
+	It("allows websocket upgrades on the route", func() {
+		route := backendRoute()
+		err := apply(map[string]string{
+			UpgradesAnnotation: WebsocketUpgrade,
+		}, route)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetUpgrades()).To(HaveLen(1))
+		Expect(proto.Equal(route.GetOptions().GetUpgrades()[0], &protocol_upgrade.ProtocolUpgradeConfig{
+			UpgradeType: &protocol_upgrade.ProtocolUpgradeConfig_Websocket{
+				Websocket: &protocol_upgrade.ProtocolUpgradeConfig_ProtocolUpgradeSpec{
+					Enabled: &wrappers.BoolValue{Value: true},
+				},
+			},
+		})).To(BeTrue())
+	})
+
+	It("allows several upgrades on the route", func() {
+		route := backendRoute()
+		err := apply(map[string]string{
+			UpgradesAnnotation: "websocket, connect",
+		}, route)
+		Expect(err).NotTo(HaveOccurred())
+		upgrades := route.GetOptions().GetUpgrades()
+		Expect(upgrades).To(HaveLen(2))
+		Expect(upgrades[0].GetWebsocket().GetEnabled().GetValue()).To(BeTrue())
+		Expect(upgrades[1].GetConnect().GetEnabled().GetValue()).To(BeTrue())
+	})
+
+	It("does nothing without the annotation", func() {
+		route := backendRoute()
+		err := apply(nil, route)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.GetOptions().GetUpgrades()).To(BeEmpty())
+	})
+
+	It("keeps the upgrades option of the RouteOption, rejecting the annotation", func() {
+		route := backendRoute()
+		route.Options = &v1.RouteOptions{
+			Upgrades: []*protocol_upgrade.ProtocolUpgradeConfig{{
+				UpgradeType: &protocol_upgrade.ProtocolUpgradeConfig_Connect{
+					Connect: &protocol_upgrade.ProtocolUpgradeConfig_ProtocolUpgradeSpec{},
+				},
+			}},
+		}
+		err := apply(map[string]string{UpgradesAnnotation: WebsocketUpgrade}, route)
+		Expect(err).To(MatchError(ConflictingUpgradesErr))
+		Expect(route.GetOptions().GetUpgrades()).To(HaveLen(1))
+		Expect(route.GetOptions().GetUpgrades()[0].GetConnect()).NotTo(BeNil())
+	})
+
+	DescribeTable("rejects invalid upgrades",
+		func(annotations map[string]string, route *v1.Route, expectedErr error) {
+			err := apply(annotations, route)
+			Expect(err).To(MatchError(expectedErr.Error()))
+		},
+		Entry("unknown upgrade",
+			map[string]string{UpgradesAnnotation: "h2c"},
+			backendRoute(),
+			UnknownUpgradeErr("h2c")),
+		Entry("duplicate upgrade",
+			map[string]string{UpgradesAnnotation: "websocket,websocket"},
+			backendRoute(),
+			DuplicateUpgradeErr(WebsocketUpgrade)),
+		Entry("route without backends",
+			map[string]string{UpgradesAnnotation: WebsocketUpgrade},
+			&v1.Route{
+				Action: &v1.Route_RedirectAction{RedirectAction: &v1.RedirectAction{}},
+			},
+			NoBackendsErr),
+		Entry("upgrades set by the RouteOption",
+			map[string]string{UpgradesAnnotation: WebsocketUpgrade},
+			&v1.Route{
+				Action: &v1.Route_RouteAction{RouteAction: &v1.RouteAction{}},
+				Options: &v1.RouteOptions{
+					Upgrades: []*protocol_upgrade.ProtocolUpgradeConfig{{
+						UpgradeType: &protocol_upgrade.ProtocolUpgradeConfig_Connect{
+							Connect: &protocol_upgrade.ProtocolUpgradeConfig_ProtocolUpgradeSpec{},
+						},
+					}},
+				},
+			},
+			ConflictingUpgradesErr),
+	)
+})
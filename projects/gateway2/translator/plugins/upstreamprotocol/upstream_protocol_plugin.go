@@ -0,0 +1,125 @@
+package upstreamprotocol
+
+import (
+	"context"
+	"math"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Annotations set on a Service to configure the HTTP protocol of the connections to the Upstreams discovered from it.
+const (
+	// UpstreamProtocolAnnotation is one of:
+	//   - "http1" for HTTP/1.1
+	//   - "h2c" for HTTP/2 with prior knowledge, or over TLS if the Upstream has an SSL config
+	//   - "downstream" for the protocol of the downstream request
+	// Gloo doesn't support negotiating the protocol with ALPN, so "auto" is rejected.
+	// Without the annotation, the Upstreams of Service ports with an HTTP/2 appProtocol use h2c.
+	UpstreamProtocolAnnotation = "gateway2.solo.io/upstream-protocol"
+	// MaxConcurrentStreamsAnnotation is the maximum number of concurrent streams on each HTTP/2 connection to the
+	// backend, defaulting to envoy's 2147483647. It requires the Upstreams to use HTTP/2.
+	MaxConcurrentStreamsAnnotation = "gateway2.solo.io/max-concurrent-streams"
+)
+
+// http2AppProtocols are the appProtocols of Service ports served over HTTP/2
+var http2AppProtocols = []string{
+	"kubernetes.io/h2c",
+	"grpc",
+}
+
+const (
+	Http1      = "http1"
+	H2c        = "h2c"
+	Downstream = "downstream"
+	Auto       = "auto"
+)
+
+var (
+	UnknownProtocolErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be one of %s, %s or %s", value, UpstreamProtocolAnnotation, Http1, H2c, Downstream)
+	}
+	UnsupportedAutoErr = errors.Errorf("value %s of annotation %s is not supported: upstream protocols cannot be negotiated with ALPN, use %s instead",
+		Auto, UpstreamProtocolAnnotation, Downstream)
+	InvalidMaxConcurrentStreamsErr       = errors.Errorf("annotation %s must be between 1 and %d", MaxConcurrentStreamsAnnotation, math.MaxInt32)
+	MaxConcurrentStreamsRequiresHttp2Err = errors.Errorf("annotation %s requires the upstreams to use HTTP/2", MaxConcurrentStreamsAnnotation)
+)
+
+var _ plugins.BackendPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyBackendPlugin(
+	ctx context.Context,
+	backendCtx *plugins.BackendContext,
+	outputUpstream *v1.Upstream,
+) error {
+	annotations := backendCtx.Service.GetAnnotations()
+	maxConcurrentStreams, err := utils.GetUint32Annotation(annotations, MaxConcurrentStreamsAnnotation)
+	if err != nil {
+		return err
+	}
+	if maxConcurrentStreams != nil && (maxConcurrentStreams.GetValue() == 0 || maxConcurrentStreams.GetValue() > math.MaxInt32) {
+		return InvalidMaxConcurrentStreamsErr
+	}
+
+	if err := applyProtocol(annotations, backendCtx.Service, outputUpstream); err != nil {
+		return err
+	}
+
+	if maxConcurrentStreams == nil {
+		return nil
+	}
+	if !outputUpstream.GetUseHttp2().GetValue() {
+		return MaxConcurrentStreamsRequiresHttp2Err
+	}
+	outputUpstream.MaxConcurrentStreams = maxConcurrentStreams
+	return nil
+}
+
+// applyProtocol selects the protocol of the Upstream from the annotation or the appProtocol of its Service port
+func applyProtocol(annotations map[string]string, svc *corev1.Service, outputUpstream *v1.Upstream) error {
+	protocol, ok := annotations[UpstreamProtocolAnnotation]
+	if !ok {
+		// the protocol set from the gloo annotations of the Service when it was discovered is kept
+		if outputUpstream.GetUseHttp2() == nil && usesHttp2AppProtocol(svc, outputUpstream) {
+			outputUpstream.UseHttp2 = &wrappers.BoolValue{Value: true}
+		}
+		return nil
+	}
+	switch protocol {
+	case Http1:
+		outputUpstream.UseHttp2 = &wrappers.BoolValue{Value: false}
+	case H2c:
+		outputUpstream.UseHttp2 = &wrappers.BoolValue{Value: true}
+	case Downstream:
+		// envoy needs the http2 options to forward http2 requests
+		outputUpstream.UseHttp2 = &wrappers.BoolValue{Value: true}
+		outputUpstream.ProtocolSelection = v1.Upstream_USE_DOWNSTREAM_PROTOCOL
+	case Auto:
+		return UnsupportedAutoErr
+	default:
+		return UnknownProtocolErr(protocol)
+	}
+	return nil
+}
+
+// usesHttp2AppProtocol returns whether the Service port of the Upstream declares an HTTP/2 appProtocol
+func usesHttp2AppProtocol(svc *corev1.Service, us *v1.Upstream) bool {
+	for _, port := range svc.Spec.Ports {
+		if uint32(port.Port) != us.GetKube().GetServicePort() || port.AppProtocol == nil {
+			continue
+		}
+		return slices.Contains(http2AppProtocols, *port.AppProtocol)
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package upstreamprotocol
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("UpstreamProtocolPlugin", func() {
+	apply := func(annotations map[string]string) (*v1.Upstream, error) {
+		backendCtx := &plugins.BackendContext{
+			Service: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "svc",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+			},
+		}
+		us := &v1.Upstream{}
+		err := NewPlugin().ApplyBackendPlugin(context.Background(), backendCtx, us)
+		return us, err
+	}
+
+	DescribeTable("selects the protocol",
+		func(protocol string, expected *v1.Upstream) {
+			us, err := apply(map[string]string{UpstreamProtocolAnnotation: protocol})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(us, expected)).To(BeTrue())
+		},
+		Entry("http1", Http1, &v1.Upstream{
+			UseHttp2: &wrappers.BoolValue{Value: false},
+		}),
+		Entry("h2c with prior knowledge", H2c, &v1.Upstream{
+			UseHttp2: &wrappers.BoolValue{Value: true},
+		}),
+		Entry("downstream protocol", Downstream, &v1.Upstream{
+			UseHttp2:          &wrappers.BoolValue{Value: true},
+			ProtocolSelection: v1.Upstream_USE_DOWNSTREAM_PROTOCOL,
+		}),
+	)
+
+	It("does nothing without the annotation", func() {
+		us, err := apply(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(us.GetUseHttp2()).To(BeNil())
+		Expect(us.GetProtocolSelection()).To(Equal(v1.Upstream_USE_CONFIGURED_PROTOCOL))
+	})
+
+	Describe("app protocol", func() {
+		applyToPort := func(annotations map[string]string, appProtocol *string) (*v1.Upstream, error) {
+			backendCtx := &plugins.BackendContext{
+				Service: &corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "svc",
+						Namespace:   "default",
+						Annotations: annotations,
+					},
+					Spec: corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{
+							{Name: "admin", Port: 9090},
+							{Name: "api", Port: 8080, AppProtocol: appProtocol},
+						},
+					},
+				},
+			}
+			us := &v1.Upstream{
+				UpstreamType: &v1.Upstream_Kube{
+					Kube: &kubernetes.UpstreamSpec{ServiceName: "svc", ServiceNamespace: "default", ServicePort: 8080},
+				},
+			}
+			err := NewPlugin().ApplyBackendPlugin(context.Background(), backendCtx, us)
+			return us, err
+		}
+
+		DescribeTable("uses h2c for HTTP/2 app protocols",
+			func(appProtocol string) {
+				us, err := applyToPort(nil, &appProtocol)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(proto.Equal(us.GetUseHttp2(), &wrappers.BoolValue{Value: true})).To(BeTrue())
+			},
+			Entry("h2c", "kubernetes.io/h2c"),
+			Entry("grpc", "grpc"),
+		)
+
+		It("leaves the protocol of plain Services", func() {
+			us, err := applyToPort(nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(us.GetUseHttp2()).To(BeNil())
+
+			http := "http"
+			us, err = applyToPort(nil, &http)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(us.GetUseHttp2()).To(BeNil())
+		})
+
+		It("prefers the annotation", func() {
+			appProtocol := "kubernetes.io/h2c"
+			us, err := applyToPort(map[string]string{UpstreamProtocolAnnotation: Http1}, &appProtocol)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(us.GetUseHttp2(), &wrappers.BoolValue{Value: false})).To(BeTrue())
+		})
+	})
+
+	Describe("max concurrent streams", func() {
+		It("limits the streams of HTTP/2 connections", func() {
+			us, err := apply(map[string]string{
+				UpstreamProtocolAnnotation:     H2c,
+				MaxConcurrentStreamsAnnotation: "100",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(us, &v1.Upstream{
+				UseHttp2:             &wrappers.BoolValue{Value: true},
+				MaxConcurrentStreams: &wrappers.UInt32Value{Value: 100},
+			})).To(BeTrue())
+		})
+
+		It("limits the streams of Upstreams discovered with HTTP/2", func() {
+			backendCtx := &plugins.BackendContext{
+				Service: &corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "svc",
+						Namespace:   "default",
+						Annotations: map[string]string{MaxConcurrentStreamsAnnotation: "1"},
+					},
+				},
+			}
+			us := &v1.Upstream{UseHttp2: &wrappers.BoolValue{Value: true}}
+			Expect(NewPlugin().ApplyBackendPlugin(context.Background(), backendCtx, us)).To(Succeed())
+			Expect(proto.Equal(us.GetMaxConcurrentStreams(), &wrappers.UInt32Value{Value: 1})).To(BeTrue())
+		})
+
+		DescribeTable("rejects invalid limits",
+			func(annotations map[string]string, expectedErr string) {
+				us, err := apply(annotations)
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+				Expect(us.GetMaxConcurrentStreams()).To(BeNil())
+			},
+			Entry("zero", map[string]string{
+				UpstreamProtocolAnnotation:     H2c,
+				MaxConcurrentStreamsAnnotation: "0",
+			}, InvalidMaxConcurrentStreamsErr.Error()),
+			Entry("above the HTTP/2 maximum", map[string]string{
+				UpstreamProtocolAnnotation:     H2c,
+				MaxConcurrentStreamsAnnotation: "2147483648",
+			}, InvalidMaxConcurrentStreamsErr.Error()),
+			Entry("negative", map[string]string{
+				UpstreamProtocolAnnotation:     H2c,
+				MaxConcurrentStreamsAnnotation: "-1",
+			}, "must be a non-negative integer"),
+			Entry("HTTP/1.1", map[string]string{
+				UpstreamProtocolAnnotation:     Http1,
+				MaxConcurrentStreamsAnnotation: "100",
+			}, MaxConcurrentStreamsRequiresHttp2Err.Error()),
+			Entry("default protocol", map[string]string{
+				MaxConcurrentStreamsAnnotation: "100",
+			}, MaxConcurrentStreamsRequiresHttp2Err.Error()),
+		)
+	})
+
+	It("rejects negotiating the protocol with ALPN", func() {
+		us, err := apply(map[string]string{UpstreamProtocolAnnotation: Auto})
+		Expect(err).To(MatchError(UnsupportedAutoErr))
+		Expect(us.GetUseHttp2()).To(BeNil())
+	})
+
+	It("rejects an unknown protocol", func() {
+		us, err := apply(map[string]string{UpstreamProtocolAnnotation: "http3"})
+		Expect(err).To(MatchError(UnknownProtocolErr("http3").Error()))
+		Expect(us.GetUseHttp2()).To(BeNil())
+	})
+})
@@ -0,0 +1,13 @@
+package upstreamprotocol
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestUpstreamProtocolPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Upstream Protocol Plugin Suite")
+}
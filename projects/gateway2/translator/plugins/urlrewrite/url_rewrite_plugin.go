@@ -13,7 +13,10 @@ import (
 	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
-var _ plugins.RoutePlugin = &plugin{}
+var (
+	_ plugins.RoutePlugin     = &plugin{}
+	_ plugins.DependentPlugin = &plugin{}
+)
 
 type plugin struct{}
 
@@ -21,6 +24,11 @@ func NewPlugin() *plugin {
 	return &plugin{}
 }
 
+// DependsOn the routeoptions plugin, which would otherwise replace the rewrites of the route
+func (p *plugin) DependsOn() []string {
+	return []string{"routeoptions"}
+}
+
 func (p *plugin) ApplyRoutePlugin(
 	ctx context.Context,
 	routeCtx *plugins.RouteContext,
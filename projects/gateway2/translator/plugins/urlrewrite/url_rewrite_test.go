@@ -125,4 +125,25 @@ var _ = DescribeTable(
 			},
 		},
 	),
+	Entry(
+		"applies literal host rewrite without a path rewrite",
+		urlrewrite.NewPlugin(),
+		gwv1.HTTPRouteFilter{
+			Type: gwv1.HTTPRouteFilterURLRewrite,
+			URLRewrite: &gwv1.HTTPURLRewriteFilter{
+				Hostname: ptr(gwv1.PreciseHostname("backend.example.com")),
+			},
+		},
+		&v1.Route{
+			Options: &v1.RouteOptions{},
+		},
+		&v1.Route{
+			Options: &v1.RouteOptions{
+				HostRewriteType: &v1.RouteOptions_HostRewrite{
+					HostRewrite: "backend.example.com",
+				},
+			},
+		},
+		nil,
+	),
 )
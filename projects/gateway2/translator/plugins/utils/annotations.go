@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gloo/pkg/plugins/kubernetes"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	"google.golang.org/protobuf/types/known/durationpb"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var InvalidServiceRefErr = func(annotation, value string) error {
+	return errors.Errorf("invalid value '%s' for annotation %s: must be of the form <service>:<port>", value, annotation)
+}
+
+var InvalidSecretRefErr = func(annotation, value string) error {
+	return errors.Errorf("invalid value '%s' for annotation %s: must be of the form [<namespace>/]<name>", value, annotation)
+}
+
+// GetDurationAnnotation parses a positive duration, e.g. "5s", from the annotation.
+// Returns nil if the annotation is not set.
+func GetDurationAnnotation(annotations map[string]string, annotation string) (*duration.Duration, error) {
+	value, ok := annotations[annotation]
+	if !ok {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return nil, errors.Errorf("invalid value '%s' for annotation %s: must be a positive duration", value, annotation)
+	}
+	return durationpb.New(d), nil
+}
+
+// GetUint32Annotation parses a non-negative integer from the annotation.
+// Returns nil if the annotation is not set.
+func GetUint32Annotation(annotations map[string]string, annotation string) (*wrappers.UInt32Value, error) {
+	value, ok := annotations[annotation]
+	if !ok {
+		return nil, nil
+	}
+	i, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, errors.Errorf("invalid value '%s' for annotation %s: must be a non-negative integer", value, annotation)
+	}
+	return &wrappers.UInt32Value{Value: uint32(i)}, nil
+}
+
+// GetServiceRefAnnotation parses a <service>:<port> from the annotation into a reference to the Upstream of the
+// Service port, with the Service in the given namespace. Returns nil if the annotation is not set.
+func GetServiceRefAnnotation(annotations map[string]string, annotation, namespace string) (*core.ResourceRef, error) {
+	value, ok := annotations[annotation]
+	if !ok {
+		return nil, nil
+	}
+	name, portStr, found := strings.Cut(value, ":")
+	if !found || name == "" {
+		return nil, InvalidServiceRefErr(annotation, value)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil || port == 0 {
+		return nil, InvalidServiceRefErr(annotation, value)
+	}
+	return &core.ResourceRef{
+		Name:      kubernetes.UpstreamName(namespace, name, int32(port)),
+		Namespace: namespace,
+	}, nil
+}
+
+// GetSecretRefAnnotation parses a [<namespace>/]<name> from the annotation into a reference to a Secret, to be
+// resolved with GatewayQueries.GetSecretForRef. Returns nil if the annotation is not set.
+func GetSecretRefAnnotation(annotations map[string]string, annotation string) (*gwv1.SecretObjectReference, error) {
+	value, ok := annotations[annotation]
+	if !ok {
+		return nil, nil
+	}
+	return parseSecretRef(annotation, value)
+}
+
+// GetSecretRefsAnnotation parses a comma-separated list of [<namespace>/]<name> from the annotation into
+// references to Secrets. Returns nil if the annotation is not set.
+func GetSecretRefsAnnotation(annotations map[string]string, annotation string) ([]*gwv1.SecretObjectReference, error) {
+	value, ok := annotations[annotation]
+	if !ok {
+		return nil, nil
+	}
+	var refs []*gwv1.SecretObjectReference
+	for _, v := range strings.Split(value, ",") {
+		ref, err := parseSecretRef(annotation, strings.TrimSpace(v))
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+func parseSecretRef(annotation, value string) (*gwv1.SecretObjectReference, error) {
+	ref := &gwv1.SecretObjectReference{}
+	name := value
+	if ns, n, found := strings.Cut(value, "/"); found {
+		if ns == "" {
+			return nil, InvalidSecretRefErr(annotation, value)
+		}
+		namespace := gwv1.Namespace(ns)
+		ref.Namespace = &namespace
+		name = n
+	}
+	if name == "" || strings.Contains(name, "/") {
+		return nil, InvalidSecretRefErr(annotation, value)
+	}
+	ref.Name = gwv1.ObjectName(name)
+	return ref, nil
+}
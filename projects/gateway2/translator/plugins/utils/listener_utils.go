@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"slices"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+)
+
+// GetHttpListenerOptions returns the HttpListenerOptions referenced by the HTTP filter chains of an aggregate listener,
+// so that listener plugins can modify them. Filter chains that don't reference options yet are pointed at options
+// shared under the name of the listener, which are created if needed.
+func GetHttpListenerOptions(listener *v1.Listener) []*v1.HttpListenerOptions {
+	aggregateListener := listener.GetAggregateListener()
+	if aggregateListener == nil || len(aggregateListener.GetHttpFilterChains()) == 0 {
+		return nil
+	}
+	if aggregateListener.GetHttpResources() == nil {
+		aggregateListener.HttpResources = &v1.AggregateListener_HttpResources{}
+	}
+	httpResources := aggregateListener.GetHttpResources()
+	if httpResources.GetHttpOptions() == nil {
+		httpResources.HttpOptions = map[string]*v1.HttpListenerOptions{}
+	}
+
+	var options []*v1.HttpListenerOptions
+	seen := map[string]bool{}
+	for _, fc := range aggregateListener.GetHttpFilterChains() {
+		if fc.GetHttpOptionsRef() == "" {
+			fc.HttpOptionsRef = listener.GetName()
+		}
+		ref := fc.GetHttpOptionsRef()
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		if httpResources.GetHttpOptions()[ref] == nil {
+			httpResources.GetHttpOptions()[ref] = &v1.HttpListenerOptions{}
+		}
+		options = append(options, httpResources.GetHttpOptions()[ref])
+	}
+	return options
+}
+
+// ServesGatewayListener returns whether the filter chain serves the routes of the Gateway Listener. The HTTP Listeners
+// sharing a port are merged into a single filter chain, which serves all of them.
+func ServesGatewayListener(listenerCtx *plugins.ListenerContext, fc *v1.AggregateListener_HttpFilterChain, gwListener string) bool {
+	for _, ref := range fc.GetVirtualHostRefs() {
+		if slices.Contains(listenerCtx.VirtualHostListeners[ref], gwListener) {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"reflect"
 
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
 	"github.com/solo-io/gloo/projects/gateway2/query"
 	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -98,3 +100,27 @@ func GetExtensionRefObj(
 	elem.Set(reflect.ValueOf(localObj).Elem())
 	return nil
 }
+
+var routeOptionGK = schema.GroupKind{
+	Group: sologatewayv1.RouteOptionGVK.Group,
+	Kind:  sologatewayv1.RouteOptionGVK.Kind,
+}
+
+// GetAttachedRouteOption returns the RouteOption referenced by an ExtensionRef filter of the Rule being processed.
+// Returns nil if the Rule doesn't reference a RouteOption or the referenced RouteOption can't be retrieved;
+// reporting unresolved references is left to the routeoptions plugin.
+func GetAttachedRouteOption(
+	ctx context.Context,
+	routeCtx *plugins.RouteContext,
+	queries query.GatewayQueries,
+) *solokubev1.RouteOption {
+	filter := FindExtensionRefFilter(routeCtx, routeOptionGK)
+	if filter == nil {
+		return nil
+	}
+	routeOption := &solokubev1.RouteOption{}
+	if err := GetExtensionRefObj(ctx, routeCtx, queries, filter.ExtensionRef, routeOption); err != nil {
+		return nil
+	}
+	return routeOption
+}
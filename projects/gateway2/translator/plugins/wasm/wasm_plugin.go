@@ -0,0 +1,96 @@
+package wasm
+
+import (
+	"context"
+	"path"
+	"strconv"
+
+	"github.com/docker/distribution/reference"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/wasm"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Annotations set on a Gateway to run a WASM filter on all of its HTTP listeners.
+// Gloo doesn't support WASM filters on individual routes.
+const (
+	// WasmImageAnnotation is the OCI image the WASM filter is pulled from, e.g. "webassemblyhub.io/org/filter:v1"
+	WasmImageAnnotation = "gateway2.solo.io/wasm-image"
+	// WasmNameAnnotation is the name of the filter, defaulting to the name of the image's repository
+	WasmNameAnnotation = "gateway2.solo.io/wasm-name"
+	// WasmConfigAnnotation is passed to the filter as a string when it is configured
+	WasmConfigAnnotation = "gateway2.solo.io/wasm-config"
+	// WasmRootIdAnnotation selects the root context of the filter, for modules containing several
+	WasmRootIdAnnotation = "gateway2.solo.io/wasm-root-id"
+	// WasmFailOpenAnnotation lets requests through when the filter fails to load if set to "true",
+	// instead of rejecting them
+	WasmFailOpenAnnotation = "gateway2.solo.io/wasm-fail-open"
+)
+
+var (
+	InvalidImageErr = func(value string, err error) error {
+		return errors.Wrapf(err, "invalid value '%s' for annotation %s", value, WasmImageAnnotation)
+	}
+	InvalidFailOpenErr = func(value string) error {
+		return errors.Errorf("invalid value '%s' for annotation %s: must be a boolean", value, WasmFailOpenAnnotation)
+	}
+)
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	annotations := listenerCtx.Gateway.GetAnnotations()
+	image, ok := annotations[WasmImageAnnotation]
+	if !ok {
+		return nil
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return InvalidImageErr(image, err)
+	}
+
+	name, ok := annotations[WasmNameAnnotation]
+	if !ok || name == "" {
+		name = path.Base(reference.Path(named))
+	}
+	filter := &wasm.WasmFilter{
+		Src: &wasm.WasmFilter_Image{
+			Image: image,
+		},
+		Name:   name,
+		RootId: annotations[WasmRootIdAnnotation],
+	}
+	if config, ok := annotations[WasmConfigAnnotation]; ok {
+		filter.Config, err = anypb.New(&wrappers.StringValue{Value: config})
+		if err != nil {
+			return err
+		}
+	}
+	if value, ok := annotations[WasmFailOpenAnnotation]; ok {
+		filter.FailOpen, err = strconv.ParseBool(value)
+		if err != nil {
+			return InvalidFailOpenErr(value)
+		}
+	}
+
+	for _, options := range utils.GetHttpListenerOptions(outputListener) {
+		options.Wasm = &wasm.PluginSource{
+			Filters: []*wasm.WasmFilter{filter},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package wasm
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/wasm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("WasmPlugin", func() {
+	var outputListener *v1.Listener
+
+	BeforeEach(func() {
+		outputListener = &v1.Listener{
+			Name: "http",
+			ListenerType: &v1.Listener_AggregateListener{
+				AggregateListener: &v1.AggregateListener{
+					HttpResources: &v1.AggregateListener_HttpResources{},
+					HttpFilterChains: []*v1.AggregateListener_HttpFilterChain{{
+						VirtualHostRefs: []string{"vhost"},
+					}},
+				},
+			},
+		}
+	})
+
+	apply := func(annotations map[string]string) error {
+		listenerCtx := &plugins.ListenerContext{
+			Gateway: &gwv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+			},
+		}
+		return NewPlugin().ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+	}
+
+	pluginSource := func() *wasm.PluginSource {
+		aggregateListener := outputListener.GetAggregateListener()
+		ref := aggregateListener.GetHttpFilterChains()[0].GetHttpOptionsRef()
+		return aggregateListener.GetHttpResources().GetHttpOptions()[ref].GetWasm()
+	}
+
+	It("translates the filter", func() {
+		Expect(apply(map[string]string{
+			WasmImageAnnotation:    "webassemblyhub.io/solo/add-header:v0.1",
+			WasmNameAnnotation:     "add-header",
+			WasmConfigAnnotation:   `{"header": "x-wasm"}`,
+			WasmRootIdAnnotation:   "add_header_root",
+			WasmFailOpenAnnotation: "true",
+		})).To(Succeed())
+
+		config, err := anypb.New(&wrappers.StringValue{Value: `{"header": "x-wasm"}`})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proto.Equal(pluginSource(), &wasm.PluginSource{
+			Filters: []*wasm.WasmFilter{{
+				Src:      &wasm.WasmFilter_Image{Image: "webassemblyhub.io/solo/add-header:v0.1"},
+				Name:     "add-header",
+				Config:   config,
+				RootId:   "add_header_root",
+				FailOpen: true,
+			}},
+		})).To(BeTrue())
+	})
+
+	It("defaults the name and fails closed", func() {
+		Expect(apply(map[string]string{
+			WasmImageAnnotation: "webassemblyhub.io/solo/add-header:v0.1",
+		})).To(Succeed())
+		Expect(proto.Equal(pluginSource(), &wasm.PluginSource{
+			Filters: []*wasm.WasmFilter{{
+				Src:  &wasm.WasmFilter_Image{Image: "webassemblyhub.io/solo/add-header:v0.1"},
+				Name: "add-header",
+			}},
+		})).To(BeTrue())
+	})
+
+	It("fails closed when fail open is false", func() {
+		Expect(apply(map[string]string{
+			WasmImageAnnotation:    "webassemblyhub.io/solo/add-header:v0.1",
+			WasmFailOpenAnnotation: "false",
+		})).To(Succeed())
+		Expect(pluginSource().GetFilters()[0].GetFailOpen()).To(BeFalse())
+	})
+
+	It("does nothing without the annotation", func() {
+		Expect(apply(map[string]string{WasmConfigAnnotation: "{}"})).To(Succeed())
+		Expect(outputListener.GetAggregateListener().GetHttpResources().GetHttpOptions()).To(BeEmpty())
+	})
+
+	DescribeTable("rejects invalid filters",
+		func(annotations map[string]string, expectedErr string) {
+			Expect(apply(annotations)).To(MatchError(ContainSubstring(expectedErr)))
+			Expect(pluginSource()).To(BeNil())
+		},
+		Entry("image with uppercase repository",
+			map[string]string{WasmImageAnnotation: "webassemblyhub.io/Solo/add-header:v0.1"},
+			WasmImageAnnotation),
+		Entry("image with invalid tag",
+			map[string]string{WasmImageAnnotation: "webassemblyhub.io/solo/add-header:v0.1!"},
+			WasmImageAnnotation),
+		Entry("empty image",
+			map[string]string{WasmImageAnnotation: ""},
+			WasmImageAnnotation),
+		Entry("non-boolean fail open",
+			map[string]string{WasmImageAnnotation: "webassemblyhub.io/solo/add-header:v0.1", WasmFailOpenAnnotation: "sometimes"},
+			InvalidFailOpenErr("sometimes").Error()),
+	)
+})
@@ -0,0 +1,13 @@
+package wasm
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestWasmPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Wasm Plugin Suite")
+}
@@ -0,0 +1,92 @@
+package xff
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/utils"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/hcm"
+)
+
+// Annotations set on a Gateway to configure how the client address of the requests of all of its HTTP listeners
+// is determined from the X-Forwarded-For header, e.g. when the Gateway is behind other proxies or load balancers.
+const (
+	// XffNumTrustedHopsAnnotation is the number of trusted proxies in front of the Gateway: the client address is
+	// the address this many hops from the right of the X-Forwarded-For header
+	XffNumTrustedHopsAnnotation = "gateway2.solo.io/xff-num-trusted-hops"
+	// UseRemoteAddressAnnotation is either "true" to use the address of the downstream connection as the client
+	// address, or "false" to only use the X-Forwarded-For header
+	UseRemoteAddressAnnotation = "gateway2.solo.io/use-remote-address"
+	// SkipXffAppendAnnotation is either "true" not to append the address of the downstream connection to the
+	// X-Forwarded-For header forwarded to backends, or "false"
+	SkipXffAppendAnnotation = "gateway2.solo.io/skip-xff-append"
+)
+
+var InvalidBoolErr = func(annotation, value string) error {
+	return errors.Errorf("invalid value '%s' for annotation %s: must be true or false", value, annotation)
+}
+
+var _ plugins.ListenerPlugin = &plugin{}
+
+type plugin struct{}
+
+func NewPlugin() *plugin {
+	return &plugin{}
+}
+
+func (p *plugin) ApplyListenerPlugin(
+	ctx context.Context,
+	listenerCtx *plugins.ListenerContext,
+	outputListener *v1.Listener,
+) error {
+	annotations := listenerCtx.Gateway.GetAnnotations()
+	numTrustedHops, err := utils.GetUint32Annotation(annotations, XffNumTrustedHopsAnnotation)
+	if err != nil {
+		return err
+	}
+	useRemoteAddress, err := getBoolAnnotation(annotations, UseRemoteAddressAnnotation)
+	if err != nil {
+		return err
+	}
+	skipXffAppend, err := getBoolAnnotation(annotations, SkipXffAppendAnnotation)
+	if err != nil {
+		return err
+	}
+	if numTrustedHops == nil && useRemoteAddress == nil && skipXffAppend == nil {
+		return nil
+	}
+
+	for _, options := range utils.GetHttpListenerOptions(outputListener) {
+		if options.GetHttpConnectionManagerSettings() == nil {
+			options.HttpConnectionManagerSettings = &hcm.HttpConnectionManagerSettings{}
+		}
+		settings := options.GetHttpConnectionManagerSettings()
+		if numTrustedHops != nil {
+			settings.XffNumTrustedHops = numTrustedHops
+		}
+		if useRemoteAddress != nil {
+			settings.UseRemoteAddress = useRemoteAddress
+		}
+		if skipXffAppend != nil {
+			settings.SkipXffAppend = skipXffAppend
+		}
+	}
+	return nil
+}
+
+// getBoolAnnotation returns the boolean value of the annotation, or nil if it is not set
+func getBoolAnnotation(annotations map[string]string, annotation string) (*wrappers.BoolValue, error) {
+	value, ok := annotations[annotation]
+	if !ok {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, InvalidBoolErr(annotation, value)
+	}
+	return &wrappers.BoolValue{Value: b}, nil
+}
@@ -0,0 +1,113 @@
+package xff
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins"
+	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/hcm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("XffPlugin", func() {
+	var outputListener *v1.Listener
+
+	BeforeEach(func() {
+		outputListener = &v1.Listener{
+			Name: "http",
+			ListenerType: &v1.Listener_AggregateListener{
+				AggregateListener: &v1.AggregateListener{
+					HttpResources: &v1.AggregateListener_HttpResources{},
+					HttpFilterChains: []*v1.AggregateListener_HttpFilterChain{{
+						VirtualHostRefs: []string{"vhost"},
+					}},
+				},
+			},
+		}
+	})
+
+	apply := func(annotations map[string]string) error {
+		listenerCtx := &plugins.ListenerContext{
+			Gateway: &gwv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+			},
+		}
+		return NewPlugin().ApplyListenerPlugin(context.Background(), listenerCtx, outputListener)
+	}
+
+	hcmSettings := func() *hcm.HttpConnectionManagerSettings {
+		aggregateListener := outputListener.GetAggregateListener()
+		ref := aggregateListener.GetHttpFilterChains()[0].GetHttpOptionsRef()
+		return aggregateListener.GetHttpResources().GetHttpOptions()[ref].GetHttpConnectionManagerSettings()
+	}
+
+	It("sets the trusted hops and the use of the remote address", func() {
+		Expect(apply(map[string]string{
+			XffNumTrustedHopsAnnotation: "2",
+			UseRemoteAddressAnnotation:  "false",
+		})).To(Succeed())
+		Expect(proto.Equal(hcmSettings(), &hcm.HttpConnectionManagerSettings{
+			XffNumTrustedHops: &wrappers.UInt32Value{Value: 2},
+			UseRemoteAddress:  &wrappers.BoolValue{Value: false},
+		})).To(BeTrue())
+	})
+
+	It("skips appending to the header", func() {
+		Expect(apply(map[string]string{
+			SkipXffAppendAnnotation: "true",
+		})).To(Succeed())
+		Expect(proto.Equal(hcmSettings(), &hcm.HttpConnectionManagerSettings{
+			SkipXffAppend: &wrappers.BoolValue{Value: true},
+		})).To(BeTrue())
+	})
+
+	It("keeps the other connection manager settings", func() {
+		aggregateListener := outputListener.GetAggregateListener()
+		aggregateListener.GetHttpFilterChains()[0].HttpOptionsRef = "http"
+		aggregateListener.GetHttpResources().HttpOptions = map[string]*v1.HttpListenerOptions{
+			"http": {
+				HttpConnectionManagerSettings: &hcm.HttpConnectionManagerSettings{
+					IdleTimeout: durationpb.New(time.Minute),
+				},
+			},
+		}
+		Expect(apply(map[string]string{
+			XffNumTrustedHopsAnnotation: "1",
+		})).To(Succeed())
+		Expect(proto.Equal(hcmSettings(), &hcm.HttpConnectionManagerSettings{
+			IdleTimeout:       durationpb.New(time.Minute),
+			XffNumTrustedHops: &wrappers.UInt32Value{Value: 1},
+		})).To(BeTrue())
+	})
+
+	It("does nothing without the annotations", func() {
+		Expect(apply(nil)).To(Succeed())
+		Expect(outputListener.GetAggregateListener().GetHttpResources().GetHttpOptions()).To(BeEmpty())
+	})
+
+	DescribeTable("rejects invalid values",
+		func(annotations map[string]string, expectedErr string) {
+			Expect(apply(annotations)).To(MatchError(ContainSubstring(expectedErr)))
+			Expect(outputListener.GetAggregateListener().GetHttpResources().GetHttpOptions()).To(BeEmpty())
+		},
+		Entry("negative trusted hops",
+			map[string]string{XffNumTrustedHopsAnnotation: "-1"},
+			XffNumTrustedHopsAnnotation),
+		Entry("non-boolean remote address",
+			map[string]string{XffNumTrustedHopsAnnotation: "1", UseRemoteAddressAnnotation: "yes please"},
+			InvalidBoolErr(UseRemoteAddressAnnotation, "yes please").Error()),
+		Entry("non-boolean skip append",
+			map[string]string{SkipXffAppendAnnotation: "sometimes"},
+			InvalidBoolErr(SkipXffAppendAnnotation, "sometimes").Error()),
+	)
+})
@@ -0,0 +1,13 @@
+package xff
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestXffPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "XFF Plugin Suite")
+}
@@ -15,9 +15,12 @@ type SortableRoute struct {
 
 type SortableRoutes []*SortableRoute
 
-func (a SortableRoutes) Len() int           { return len(a) }
-func (a SortableRoutes) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a SortableRoutes) Less(i, j int) bool { return !routeWrapperLessFunc(a[i], a[j]) }
+func (a SortableRoutes) Len() int      { return len(a) }
+func (a SortableRoutes) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+
+// Less sorts higher priority routes first. Routes of equal priority are not less than each other,
+// so that sort.Stable keeps their order.
+func (a SortableRoutes) Less(i, j int) bool { return routeWrapperLessFunc(a[j], a[i]) }
 
 func (a SortableRoutes) ToRoutes() []*v1.Route {
 	var routes []*v1.Route
@@ -1,6 +1,10 @@
 package routeutils
 
 import (
+	"slices"
+	"sort"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v1 "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
@@ -344,5 +348,113 @@ var _ = Describe("RouteWrappersTest", func() {
 			},
 			true,
 		),
+		Entry(
+			"equal length prefixes will check headers",
+			&SortableRoute{
+				HttpRoute: defaultRt(),
+				Route: &v1.Route{
+					Matchers: []*matchers.Matcher{
+						{
+							PathSpecifier: &matchers.Matcher_Prefix{
+								Prefix: "/api",
+							},
+						},
+					},
+				},
+			},
+			&SortableRoute{
+				HttpRoute: defaultRtB(),
+				Route: &v1.Route{
+					Matchers: []*matchers.Matcher{
+						{
+							PathSpecifier: &matchers.Matcher_Prefix{
+								Prefix: "/app",
+							},
+							Headers: []*matchers.HeaderMatcher{
+								{
+									Name:  "test",
+									Value: "hello",
+								},
+							},
+						},
+					},
+				},
+			},
+			true,
+		),
+		Entry(
+			"equal matchers will check creation timestamp before name",
+			&SortableRoute{
+				HttpRoute: &gwv1.HTTPRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              "a-test",
+						CreationTimestamp: metav1.NewTime(time.Unix(2000, 0)),
+					},
+				},
+				Route: &v1.Route{
+					Matchers: []*matchers.Matcher{defaultMatcher()},
+				},
+			},
+			&SortableRoute{
+				HttpRoute: &gwv1.HTTPRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              "b-test",
+						CreationTimestamp: metav1.NewTime(time.Unix(1000, 0)),
+					},
+				},
+				Route: &v1.Route{
+					Matchers: []*matchers.Matcher{defaultMatcher()},
+				},
+			},
+			true,
+		),
 	)
+
+	It("sorts the routes of several HTTPRoutes deterministically", func() {
+		newer := &gwv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "a-newer",
+				CreationTimestamp: metav1.NewTime(time.Unix(2000, 0)),
+			},
+		}
+		older := &gwv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "b-older",
+				CreationTimestamp: metav1.NewTime(time.Unix(1000, 0)),
+			},
+		}
+		prefix := func(name, prefix string, headers ...string) *v1.Route {
+			matcher := &matchers.Matcher{
+				PathSpecifier: &matchers.Matcher_Prefix{Prefix: prefix},
+			}
+			for _, header := range headers {
+				matcher.Headers = append(matcher.Headers, &matchers.HeaderMatcher{Name: header})
+			}
+			return &v1.Route{Name: name, Matchers: []*matchers.Matcher{matcher}}
+		}
+		routes := append(
+			ToSortable(newer, []*v1.Route{
+				prefix("newer-api", "/api"),
+				prefix("newer-app-header", "/app", "x-test"),
+				prefix("newer-root", "/"),
+			}),
+			ToSortable(older, []*v1.Route{
+				prefix("older-root", "/"),
+				prefix("older-api", "/api"),
+			})...,
+		)
+		expected := []string{"newer-app-header", "older-api", "newer-api", "older-root", "newer-root"}
+
+		for i := 0; i < 2; i++ {
+			sorted := append(SortableRoutes{}, routes...)
+			sort.Stable(sorted)
+			var names []string
+			for _, route := range sorted.ToRoutes() {
+				names = append(names, route.GetName())
+			}
+			Expect(names).To(Equal(expected))
+			// the order does not depend on the order of the input
+			slices.Reverse(routes)
+		}
+	})
 })
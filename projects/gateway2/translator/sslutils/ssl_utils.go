@@ -2,6 +2,8 @@ package sslutils
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 
 	"github.com/rotisserie/eris"
@@ -15,6 +17,10 @@ var (
 	}
 
 	NoCertificateFoundError = eris.New("no certificate information found")
+
+	NoDnsNamesFoundError = func(secret *corev1.Secret) error {
+		return eris.Errorf("certificate in %v.%v has no DNS names", secret.Namespace, secret.Name)
+	}
 )
 
 func ValidateTlsSecret(secret *corev1.Secret) error {
@@ -47,3 +53,19 @@ func isValidSslKeyPair(certChain, privateKey, rootCa []byte) error {
 	_, err := tls.X509KeyPair([]byte(certChain), []byte(privateKey))
 	return err
 }
+
+// GetCertificateDnsNames returns the DNS names (subject alternative names) of the leaf certificate of a TLS secret
+func GetCertificateDnsNames(secret *corev1.Secret) ([]string, error) {
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		return nil, InvalidTlsSecretError(secret, NoCertificateFoundError)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, InvalidTlsSecretError(secret, err)
+	}
+	if len(cert.DNSNames) == 0 {
+		return nil, NoDnsNamesFoundError(secret)
+	}
+	return cert.DNSNames, nil
+}
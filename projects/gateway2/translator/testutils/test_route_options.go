@@ -0,0 +1,19 @@
+package testutils
+
+import (
+	sologatewayv1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1"
+	solokubev1 "github.com/solo-io/gloo/projects/gateway/pkg/api/v1/kube/apis/gateway.solo.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RouteOption returns an empty RouteOption named policy in the default namespace, with the given annotations
+func RouteOption(annotations map[string]string) *solokubev1.RouteOption {
+	return &solokubev1.RouteOption{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "policy",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+		Spec: sologatewayv1.RouteOption{},
+	}
+}
@@ -65,7 +65,10 @@ func (tc TestCase) Run(ctx context.Context) (map[types.NamespacedName]bool, erro
 	}
 
 	queries := testutils.BuildGatewayQueries(dependencies)
-	pluginRegistry := registry.NewPluginRegistry(registry.BuildPlugins(queries))
+	pluginRegistry, err := registry.NewPluginRegistry(registry.BuildPlugins(queries))
+	if err != nil {
+		return nil, err
+	}
 
 	results := make(map[types.NamespacedName]bool)
 	for _, gw := range gateways {
@@ -0,0 +1,50 @@
+package xds
+
+import (
+	"context"
+
+	"github.com/solo-io/gloo/projects/gateway2/deployer"
+	gloo_solo_io "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	"github.com/solo-io/go-utils/contextutils"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	apiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// shardProxy returns the proxy translated for the Gateway, or a proxy per listener shard when the listeners of the
+// Gateway are sharded (see deployer.ListenerShardsAnnotation). The proxy of a shard is named after the shard, which
+// its proxy Deployment connects to xds as, and only holds the listeners of the shard.
+// No proxy is returned if the proxies of the Gateway are named like the proxies of another of the given Gateways,
+// which the deployer does not deploy the Gateway for either (see deployer.CollidingGateway).
+func shardProxy(ctx context.Context, gw *apiv1.Gateway, gateways []apiv1.Gateway, proxy *gloo_solo_io.Proxy) gloo_solo_io.ProxyList {
+	if other := deployer.CollidingGateway(gw, gateways); other != nil {
+		contextutils.LoggerFrom(ctx).Errorf("the proxies of gateway %s.%s are named like the proxies of gateway %s.%s",
+			gw.Namespace, gw.Name, other.Namespace, other.Name)
+		return nil
+	}
+	shards, err := deployer.ListenerShards(gw)
+	if err != nil {
+		// the deployer fails to render the proxy of the Gateway as well, so its shards are not updated
+		contextutils.LoggerFrom(ctx).Errorf("failed to shard the listeners of gateway %s.%s: %v", gw.Namespace, gw.Name, err)
+		return gloo_solo_io.ProxyList{proxy}
+	}
+	if shards <= 1 {
+		return gloo_solo_io.ProxyList{proxy}
+	}
+
+	portShards := deployer.ListenerPortShards(gw, shards)
+	proxies := make(gloo_solo_io.ProxyList, shards)
+	for shard := range proxies {
+		metadata := proxy.GetMetadata().Clone().(*core.Metadata)
+		metadata.Name = deployer.ShardName(gw, shard)
+		// shards without listeners get an empty configuration rather than none
+		proxies[shard] = &gloo_solo_io.Proxy{
+			Metadata: metadata,
+		}
+	}
+	for _, listener := range proxy.GetListeners() {
+		// the listeners are bound to the translated ports of the Gateway listeners they were merged from
+		shard := portShards[uint16(listener.GetBindPort())]
+		proxies[shard].Listeners = append(proxies[shard].GetListeners(), listener)
+	}
+	return proxies
+}
@@ -0,0 +1,197 @@
+package xds
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	envoy_config_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	apiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/solo-io/gloo/projects/gateway2/deployer"
+	"github.com/solo-io/gloo/projects/gateway2/reports"
+	gloot "github.com/solo-io/gloo/projects/gateway2/translator"
+	"github.com/solo-io/gloo/projects/gateway2/translator/plugins/registry"
+	"github.com/solo-io/gloo/projects/gateway2/translator/testutils"
+	gloo_solo_io "github.com/solo-io/gloo/projects/gloo/pkg/api/v1"
+	v1snap "github.com/solo-io/gloo/projects/gloo/pkg/api/v1/gloosnapshot"
+	"github.com/solo-io/gloo/projects/gloo/pkg/api/v1/options/static"
+	"github.com/solo-io/gloo/projects/gloo/pkg/bootstrap"
+	"github.com/solo-io/gloo/projects/gloo/pkg/plugins"
+	glooregistry "github.com/solo-io/gloo/projects/gloo/pkg/plugins/registry"
+	"github.com/solo-io/gloo/projects/gloo/pkg/translator"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients/factory"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients/memory"
+	"github.com/solo-io/solo-kit/pkg/api/v1/control-plane/types"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+)
+
+// shardedGateway returns a Gateway with an HTTP listener on each of the given ports, and the HTTPRoute attached to them
+func shardedGateway(shards string, listenerPorts ...apiv1.PortNumber) (*apiv1.Gateway, []client.Object) {
+	gw := &apiv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "gw",
+			Namespace:   "default",
+			Annotations: map[string]string{deployer.ListenerShardsAnnotation: shards},
+		},
+	}
+	for _, port := range listenerPorts {
+		gw.Spec.Listeners = append(gw.Spec.Listeners, apiv1.Listener{
+			Name:     apiv1.SectionName(fmt.Sprintf("http-%d", port)),
+			Port:     port,
+			Protocol: apiv1.HTTPProtocolType,
+		})
+	}
+	objs := []client.Object{
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}}},
+		},
+		&apiv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: apiv1.HTTPRouteSpec{
+				CommonRouteSpec: apiv1.CommonRouteSpec{
+					ParentRefs: []apiv1.ParentReference{{Name: "gw"}},
+				},
+				Rules: []apiv1.HTTPRouteRule{{
+					BackendRefs: []apiv1.HTTPBackendRef{{
+						BackendRef: apiv1.BackendRef{
+							BackendObjectReference: apiv1.BackendObjectReference{
+								Name: "web",
+								Port: ptr.To(apiv1.PortNumber(80)),
+							},
+						},
+					}},
+				}},
+			},
+		},
+	}
+	return gw, objs
+}
+
+func translateShards(g *WithT, gw *apiv1.Gateway, objs []client.Object, gateways ...apiv1.Gateway) gloo_solo_io.ProxyList {
+	ctx := context.Background()
+	queries := testutils.BuildGatewayQueries(objs)
+	pluginRegistry, err := registry.NewPluginRegistry(registry.BuildPlugins(queries))
+	g.Expect(err).NotTo(HaveOccurred())
+	reportMap := reports.NewReportMap()
+	proxy := gloot.NewTranslator(queries, pluginRegistry).TranslateProxy(ctx, gw, reports.NewReporter(&reportMap))
+	g.Expect(proxy).NotTo(BeNil())
+	return shardProxy(ctx, gw, append(gateways, *gw), proxy)
+}
+
+// xdsListeners translates the proxy into its xds snapshot, and returns the bind ports of its envoy listeners
+func xdsListeners(g *WithT, proxy *gloo_solo_io.Proxy) []uint32 {
+	ctx := context.Background()
+	settings := &gloo_solo_io.Settings{
+		Gateway: &gloo_solo_io.GatewayOptions{
+			Validation: &gloo_solo_io.GatewayOptions_ValidationOptions{
+				// transformations are validated by an envoy binary, which is not available to unit tests
+				DisableTransformationValidation: &wrappers.BoolValue{Value: true},
+			},
+		},
+	}
+	memoryClientFactory := &factory.MemoryResourceClientFactory{
+		Cache: memory.NewInMemoryResourceCache(),
+	}
+	glooPlugins := glooregistry.Plugins(bootstrap.Opts{
+		WatchOpts: clients.WatchOpts{Ctx: ctx},
+		Settings:  settings,
+		Secrets:   memoryClientFactory,
+		Upstreams: memoryClientFactory,
+	})
+	glooTranslator := translator.NewDefaultTranslator(settings, glooregistry.NewPluginRegistry(glooPlugins))
+
+	params := plugins.Params{
+		Ctx: ctx,
+		Snapshot: &v1snap.ApiSnapshot{
+			Proxies: gloo_solo_io.ProxyList{proxy},
+			Upstreams: gloo_solo_io.UpstreamList{{
+				Metadata: &core.Metadata{Name: "default-web-80", Namespace: "default"},
+				UpstreamType: &gloo_solo_io.Upstream_Static{
+					Static: &static.UpstreamSpec{Hosts: []*static.Host{{Addr: "web.default", Port: 80}}},
+				},
+			}},
+		},
+		Messages: map[*core.ResourceRef][]string{},
+	}
+	snap, _, _ := glooTranslator.Translate(params, proxy)
+
+	var ports []uint32
+	for _, item := range snap.GetResources(types.ListenerTypeV3).Items {
+		listener := item.ResourceProto().(*envoy_config_listener_v3.Listener)
+		ports = append(ports, listener.GetAddress().GetSocketAddress().GetPortValue())
+	}
+	return ports
+}
+
+func TestShardProxyPartitionsTheListeners(t *testing.T) {
+	g := NewWithT(t)
+	gw, objs := shardedGateway("2", 8082, 8080, 8081)
+
+	proxies := translateShards(g, gw, objs)
+	g.Expect(proxies).To(HaveLen(2))
+	// each shard connects to xds as its shard name, see deployer.ShardName
+	g.Expect(proxies[0].GetMetadata().GetName()).To(Equal("gw-shard-0"))
+	g.Expect(proxies[1].GetMetadata().GetName()).To(Equal("gw-shard-1"))
+	for _, proxy := range proxies {
+		g.Expect(proxy.GetMetadata().GetNamespace()).To(Equal("default"))
+	}
+
+	g.Expect(xdsListeners(g, proxies[0])).To(ConsistOf(uint32(8080), uint32(8082)))
+	g.Expect(xdsListeners(g, proxies[1])).To(ConsistOf(uint32(8081)))
+}
+
+func TestShardProxyKeepsListenersSharingAPortTogether(t *testing.T) {
+	g := NewWithT(t)
+	// port 80 is translated to 8080, so both listeners are merged into the same envoy listener
+	gw, objs := shardedGateway("2", 80, 8080, 8443)
+
+	proxies := translateShards(g, gw, objs)
+	g.Expect(proxies).To(HaveLen(2))
+	g.Expect(xdsListeners(g, proxies[0])).To(ConsistOf(uint32(8080)))
+	g.Expect(xdsListeners(g, proxies[1])).To(ConsistOf(uint32(8443)))
+}
+
+func TestShardProxyRendersEmptyShards(t *testing.T) {
+	g := NewWithT(t)
+	gw, objs := shardedGateway("3", 8080)
+
+	proxies := translateShards(g, gw, objs)
+	g.Expect(proxies).To(HaveLen(3))
+	g.Expect(xdsListeners(g, proxies[0])).To(ConsistOf(uint32(8080)))
+	g.Expect(proxies[1].GetListeners()).To(BeEmpty())
+	g.Expect(proxies[2].GetListeners()).To(BeEmpty())
+}
+
+func TestShardProxyWithoutShards(t *testing.T) {
+	for _, shards := range []string{"1", "invalid"} {
+		g := NewWithT(t)
+		gw, objs := shardedGateway(shards, 8080, 8081)
+
+		proxies := translateShards(g, gw, objs)
+		g.Expect(proxies).To(HaveLen(1))
+		g.Expect(proxies[0].GetMetadata().GetName()).To(Equal("gw"))
+		g.Expect(xdsListeners(g, proxies[0])).To(ConsistOf(uint32(8080), uint32(8081)))
+	}
+}
+
+func TestShardProxySkipsCollidingGateways(t *testing.T) {
+	g := NewWithT(t)
+	gw, objs := shardedGateway("2", 8080, 8081)
+	gw.CreationTimestamp = metav1.Unix(100, 0)
+	// an unsharded Gateway named like shard 1 of gw
+	other := apiv1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "gw-shard-1", Namespace: "default", CreationTimestamp: metav1.Unix(200, 0)}}
+
+	g.Expect(translateShards(g, gw, objs, other)).To(HaveLen(2))
+
+	other.CreationTimestamp = metav1.Unix(50, 0)
+	g.Expect(translateShards(g, gw, objs, other)).To(BeEmpty())
+}
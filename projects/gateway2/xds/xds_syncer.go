@@ -37,9 +37,13 @@ import (
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
 	"go.opencensus.io/trace"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	apiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	apiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
 
 // empty resources to give to envoy when a proxy was deleted
@@ -152,6 +156,8 @@ func (s *XdsSyncer) Start(ctx context.Context) error {
 	var (
 		discoveryWarmed bool
 		secretsWarmed   bool
+		// upstreams as discovered, before backend plugins are applied to them
+		discoveredUpstreams gloo_solo_io.UpstreamList
 	)
 	resyncXds := func() {
 		if !discoveryWarmed || !secretsWarmed {
@@ -167,9 +173,15 @@ func (s *XdsSyncer) Start(ctx context.Context) error {
 
 		gatewayQueries := query.NewData(s.mgr.GetClient(), s.mgr.GetScheme())
 
-		pluginRegistry := s.k8sGwExtensions.CreatePluginRegistry(ctx)
+		pluginRegistry, err := s.k8sGwExtensions.CreatePluginRegistry(ctx)
+		if err != nil {
+			contextutils.LoggerFrom(ctx).Errorf("failed to create the plugin registry: %v", err)
+			return
+		}
 		gatewayTranslator := gloot.NewTranslator(gatewayQueries, pluginRegistry)
 
+		proxyApiSnapshot.Upstreams = applyBackendPlugins(ctx, s.mgr.GetClient(), pluginRegistry, discoveredUpstreams)
+
 		proxies := gloo_solo_io.ProxyList{}
 		rm := reports.NewReportMap()
 		r := reports.NewReporter(&rm)
@@ -178,7 +190,7 @@ func (s *XdsSyncer) Start(ctx context.Context) error {
 		for _, gw := range gwl.Items {
 			proxy := gatewayTranslator.TranslateProxy(ctx, &gw, r)
 			if proxy != nil {
-				proxies = append(proxies, proxy)
+				proxies = append(proxies, shardProxy(ctx, &gw, gwl.Items, proxy)...)
 				translatedGateways = append(translatedGateways, gwplugins.TranslatedGateway{
 					Gateway: gw,
 				})
@@ -187,9 +199,11 @@ func (s *XdsSyncer) Start(ctx context.Context) error {
 		}
 		proxyApiSnapshot.Proxies = proxies
 
-		applyPostTranslationPlugins(ctx, pluginRegistry, &gwplugins.PostTranslationContext{
+		postTranslationContext := &gwplugins.PostTranslationContext{
 			TranslatedGateways: translatedGateways,
-		})
+		}
+		applyPostTranslationPlugins(ctx, pluginRegistry, postTranslationContext)
+		proxyApiSnapshot.AuthConfigs = postTranslationContext.AuthConfigs
 
 		s.syncEnvoy(ctx, proxyApiSnapshot)
 		s.syncStatus(ctx, rm, gwl)
@@ -205,7 +219,7 @@ func (s *XdsSyncer) Start(ctx context.Context) error {
 		case <-s.inputs.genericEvent.Next():
 			resyncXds()
 		case discoveryEvent := <-s.inputs.discoveryEvent.Next():
-			proxyApiSnapshot.Upstreams = discoveryEvent.Upstreams
+			discoveredUpstreams = discoveryEvent.Upstreams
 			proxyApiSnapshot.Endpoints = discoveryEvent.Endpoints
 			discoveryWarmed = true
 			resyncXds()
@@ -354,6 +368,55 @@ func (s *XdsSyncer) syncRouteStatus(ctx context.Context, rm reports.ReportMap) {
 			}
 		}
 	}
+
+	s.syncTLSRouteStatus(ctx, rm)
+	s.syncTCPRouteStatus(ctx, rm)
+}
+
+func (s *XdsSyncer) syncTLSRouteStatus(ctx context.Context, rm reports.ReportMap) {
+	logger := contextutils.LoggerFrom(ctx)
+	tl := apiv1alpha2.TLSRouteList{}
+	err := s.mgr.GetClient().List(ctx, &tl)
+	if err != nil {
+		// the TLSRoute CRD is optional
+		if !meta.IsNoMatchError(err) {
+			logger.Error(err)
+		}
+		return
+	}
+
+	for _, route := range tl.Items {
+		route := route // pike
+		if status := rm.BuildTLSRouteStatus(ctx, route, s.controllerName); status != nil {
+			route.Status = *status
+			if err := s.mgr.GetClient().Status().Update(ctx, &route); err != nil {
+				logger.Error(err)
+			}
+		}
+	}
+}
+
+func (s *XdsSyncer) syncTCPRouteStatus(ctx context.Context, rm reports.ReportMap) {
+	logger := contextutils.LoggerFrom(ctx)
+	tl := apiv1alpha2.TCPRouteList{}
+	err := s.mgr.GetClient().List(ctx, &tl)
+	if err != nil {
+		// the TCPRoute CRD is optional
+		if !meta.IsNoMatchError(err) {
+			logger.Error(err)
+		}
+		return
+	}
+
+	for _, route := range tl.Items {
+		route := route // pike
+		if status := rm.BuildTCPRouteStatus(ctx, route, s.controllerName); status != nil {
+			route.Status = *status
+			if err := s.mgr.GetClient().Status().Update(ctx, &route); err != nil {
+				logger.Error(err)
+			}
+		}
+	}
 }
 
 func (s *XdsSyncer) syncStatus(ctx context.Context, rm reports.ReportMap, gwl apiv1.GatewayList) {
@@ -414,6 +477,48 @@ func (s *XdsSyncer) syncProxyCache(ctx context.Context, proxyList gloo_solo_io.P
 	}
 }
 
+// applyBackendPlugins returns the upstreams with the backend plugins applied to those discovered from Services.
+// Upstreams are copied before being modified, as the discovered upstreams are kept across syncs.
+func applyBackendPlugins(
+	ctx context.Context,
+	cli client.Client,
+	pluginRegistry registry.PluginRegistry,
+	upstreams gloo_solo_io.UpstreamList,
+) gloo_solo_io.UpstreamList {
+	backendPlugins := pluginRegistry.GetBackendPlugins()
+	if len(backendPlugins) == 0 {
+		return upstreams
+	}
+	logger := contextutils.LoggerFrom(contextutils.WithLogger(ctx, "backend"))
+
+	out := make(gloo_solo_io.UpstreamList, 0, len(upstreams))
+	for _, us := range upstreams {
+		kubeSpec := us.GetKube()
+		if kubeSpec == nil {
+			out = append(out, us)
+			continue
+		}
+		svc := &corev1.Service{}
+		err := cli.Get(ctx, client.ObjectKey{Namespace: kubeSpec.GetServiceNamespace(), Name: kubeSpec.GetServiceName()}, svc)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				logger.Errorf("error getting service for upstream %s: %v", us.GetMetadata().Ref().Key(), err)
+			}
+			out = append(out, us)
+			continue
+		}
+
+		outputUpstream := us.Clone().(*gloo_solo_io.Upstream)
+		for _, backendPlugin := range backendPlugins {
+			if err := backendPlugin.ApplyBackendPlugin(ctx, &gwplugins.BackendContext{Service: svc}, outputUpstream); err != nil {
+				logger.Errorf("error applying backend plugin to upstream %s: %v", us.GetMetadata().Ref().Key(), err)
+			}
+		}
+		out = append(out, outputUpstream)
+	}
+	return out
+}
+
 func applyPostTranslationPlugins(ctx context.Context, pluginRegistry registry.PluginRegistry, translationContext *gwplugins.PostTranslationContext) {
 	ctx = contextutils.WithLogger(ctx, "postTranslation")
 	logger := contextutils.LoggerFrom(ctx)
@@ -67,6 +67,14 @@ func includeTlsInspectorForAggregateListener(in *v1.AggregateListener) bool {
 		}
 	}
 
+	// tcp listeners are selected by SNI when their matcher has an ssl config, even if they do not terminate TLS
+	for _, tcpListener := range in.GetTcpListeners() {
+		if tcpListener.GetMatcher().GetSslConfig() != nil ||
+			includeTlsInspectorForTcpListener(tcpListener.GetTcpListener()) {
+			return true
+		}
+	}
+
 	return false
 }
 
@@ -494,6 +494,38 @@ var _ = Describe("Plugin", func() {
 
 		})
 
+		It("tls inspector is added for sni matched tcp listeners", func() {
+			in := &v1.Listener{
+				ListenerType: &v1.Listener_AggregateListener{
+					AggregateListener: &v1.AggregateListener{
+						TcpListeners: []*v1.MatchedTcpListener{
+							{
+								Matcher: &v1.Matcher{
+									SslConfig: &ssl.SslConfig{SniDomains: []string{"foo.example.com"}},
+								},
+								TcpListener: &v1.TcpListener{},
+							},
+						},
+					},
+				},
+			}
+
+			filters := []*envoy_config_listener_v3.Filter{{}}
+
+			out := &envoy_config_listener_v3.Listener{
+				FilterChains: []*envoy_config_listener_v3.FilterChain{{
+					Filters: filters,
+				}},
+			}
+
+			p := NewPlugin()
+			err := p.ProcessListener(params, in, out)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.ListenerFilters).To(HaveLen(1))
+			Expect(out.ListenerFilters[0].GetName()).To(Equal(wellknown.TlsInspector))
+		})
+
 		It("tls inspector is ignored", func() {
 			in := &v1.Listener{
 				ListenerType: &v1.Listener_AggregateListener{